@@ -18,6 +18,7 @@ import (
 	"hr-management-system/internal/infrastructure/logger"
 	"hr-management-system/internal/infrastructure/queue"
 	"hr-management-system/internal/infrastructure/search"
+	"hr-management-system/internal/infrastructure/storage"
 	"hr-management-system/internal/security"
 )
 
@@ -44,6 +45,7 @@ func main() {
 	}
 
 	security.Init(&cfg.Security)
+	security.InitRateLimit(&cfg.RateLimit)
 
 	db, err := database.NewConnection(&cfg.Database)
 	if err != nil {
@@ -84,7 +86,13 @@ func main() {
 		log.Info("Email service initialized")
 	}
 
-	r := router.NewRouter(cfg, db, redisCache, jobQueue, es, emailSvc, log)
+	store, err := storage.NewBackend(&cfg.Storage, cfg.App.BaseURL)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to initialize file storage")
+	}
+	log.WithField("driver", cfg.Storage.Driver).Info("File storage initialized")
+
+	r := router.NewRouter(cfg, db, redisCache, jobQueue, es, store, emailSvc, log)
 	engine := r.Setup()
 
 	server := &http.Server{
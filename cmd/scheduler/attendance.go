@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"hr-management-system/internal/infrastructure/queue"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Forgotten check-outs are only looked for this far back, so switching the job
+// on does not rewrite years of history
+const forgotCheckoutLookbackDays = 7
+
+// MarkAbsentees also covers the days before today this far back, so shifts
+// ending after midnight are marked on the next run
+const absenteeLookbackDays = 1
+
+// CloseForgottenCheckouts handles attendance records that still have no
+// check-out after their shift ended. Depending on ATTENDANCE_FORGOT_CHECKOUT
+// they are closed at the shift end or flagged for the employee to regularize.
+func (s *Scheduler) CloseForgottenCheckouts() {
+	mode := s.cfg.Attendance.ForgotCheckout
+	if mode != "close" && mode != "regularize" {
+		return
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	today := startOfDay(now)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT a.id, a.date, a.check_in, ws.start_time, ws.end_time, e.user_id
+		FROM attendances a
+		INNER JOIN employees e ON e.id = a.employee_id
+		LEFT JOIN employee_shifts es ON es.employee_id = a.employee_id AND es.date = a.date
+		LEFT JOIN work_shifts ws ON ws.id = es.shift_id
+		WHERE a.check_in IS NOT NULL AND a.check_out IS NULL AND a.deleted_at IS NULL
+		  AND a.needs_regularization = FALSE
+		  AND a.date >= $1 AND a.date < $2
+	`, today.AddDate(0, 0, -forgotCheckoutLookbackDays).Format("2006-01-02"), today.Format("2006-01-02"))
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get attendances without check-out")
+		return
+	}
+
+	type openRecord struct {
+		id       uuid.UUID
+		date     time.Time
+		checkIn  time.Time
+		shiftEnd time.Time
+		userID   string
+	}
+	var records []openRecord
+	for rows.Next() {
+		var rec openRecord
+		var checkIn time.Time
+		var startTime, endTime sql.NullString
+		if err := rows.Scan(&rec.id, &rec.date, &checkIn, &startTime, &endTime, &rec.userID); err != nil {
+			s.log.WithError(err).Error("Failed to read attendance")
+			continue
+		}
+
+		// check_in is stored as local wall-clock time
+		rec.checkIn = time.Date(checkIn.Year(), checkIn.Month(), checkIn.Day(),
+			checkIn.Hour(), checkIn.Minute(), checkIn.Second(), checkIn.Nanosecond(), time.Local)
+		rec.shiftEnd, err = shiftEndAt(rec.date, startTime, endTime, s.cfg.Attendance.DefaultShiftEnd)
+		if err != nil {
+			s.log.WithError(err).WithField("attendance_id", rec.id).Error("Invalid shift time")
+			continue
+		}
+		// Night shifts ending this morning are picked up on the next run
+		if rec.shiftEnd.After(now) {
+			continue
+		}
+		records = append(records, rec)
+	}
+	rows.Close()
+
+	closed, flagged := 0, 0
+	for _, rec := range records {
+		date := rec.date.Format("2006-01-02")
+
+		// A check-in after the shift end leaves nothing sensible to close at
+		if mode == "close" && rec.checkIn.Before(rec.shiftEnd) {
+			workingHours := rec.shiftEnd.Sub(rec.checkIn).Hours()
+			result, err := s.db.ExecContext(ctx, `
+				UPDATE attendances SET check_out = $1, working_hours = $2, auto_closed = TRUE, updated_at = NOW()
+				WHERE id = $3 AND check_out IS NULL
+			`, rec.shiftEnd, workingHours, rec.id)
+			if err != nil {
+				s.log.WithError(err).WithField("attendance_id", rec.id).Error("Failed to auto-close attendance")
+				continue
+			}
+			if n, _ := result.RowsAffected(); n == 0 {
+				continue
+			}
+			s.logAttendanceAction(ctx, rec.id, "auto_close", rec.shiftEnd)
+
+			s.queue.SendNotification(ctx, queue.NotificationPayload{
+				UserID:  rec.userID,
+				Title:   "Hệ thống đã tự động chấm công ra",
+				Message: fmt.Sprintf("Bạn chưa chấm công ra ngày %s. Hệ thống đã ghi nhận giờ ra lúc %s theo ca làm việc. Vui lòng kiểm tra và gửi yêu cầu điều chỉnh nếu chưa đúng.", date, rec.shiftEnd.Format("15:04")),
+				Type:    "attendance_auto_closed",
+				Data:    map[string]interface{}{"attendance_id": rec.id.String(), "date": date},
+			})
+			closed++
+			continue
+		}
+
+		result, err := s.db.ExecContext(ctx, `
+			UPDATE attendances SET needs_regularization = TRUE, updated_at = NOW()
+			WHERE id = $1 AND check_out IS NULL
+		`, rec.id)
+		if err != nil {
+			s.log.WithError(err).WithField("attendance_id", rec.id).Error("Failed to flag attendance")
+			continue
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			continue
+		}
+		s.logAttendanceAction(ctx, rec.id, "flag_regularize", now)
+
+		s.queue.SendNotification(ctx, queue.NotificationPayload{
+			UserID:  rec.userID,
+			Title:   "Bạn quên chấm công ra",
+			Message: fmt.Sprintf("Bạn chưa chấm công ra ngày %s. Vui lòng gửi yêu cầu điều chỉnh chấm công.", date),
+			Type:    "attendance_regularization",
+			Data:    map[string]interface{}{"attendance_id": rec.id.String(), "date": date},
+		})
+		flagged++
+	}
+
+	s.log.WithFields(map[string]interface{}{"closed": closed, "flagged": flagged}).Info("Forgotten check-outs processed")
+}
+
+// BackfillLeaveAttendance reconciles attendance with approved leave over the
+// last ATTENDANCE_LEAVE_BACKFILL_DAYS days. It picks up leave whose
+// attendance the API failed to write, and clears days of leave that is no
+// longer approved.
+func (s *Scheduler) BackfillLeaveAttendance() {
+	if !s.cfg.Attendance.LeaveStatus {
+		return
+	}
+
+	days := s.cfg.Attendance.LeaveBackfillDays
+	if days < 0 {
+		days = 0
+	}
+	today := startOfDay(time.Now())
+	result, err := s.payroll.ReconcileLeaveAttendance(context.Background(), nil, today.AddDate(0, 0, -days), today)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to backfill leave attendance")
+		return
+	}
+
+	s.log.WithFields(map[string]interface{}{
+		"on_leave": result.OnLeave, "days_off": result.DaysOff, "cleared": result.Cleared,
+	}).Info("Leave attendance backfilled")
+}
+
+// MarkAbsentees records active employees who have no attendance on a working
+// day as absent, once their shift for the day has ended. Employees on
+// approved leave are skipped, as are rest days and full-day holidays. A day
+// with any attendance row is left alone, so running it again changes nothing,
+// and a check-in or approved leave later replaces the absence.
+func (s *Scheduler) MarkAbsentees() {
+	if !s.cfg.Attendance.MarkAbsentees {
+		return
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	today := startOfDay(now)
+
+	marked := 0
+	for day := today.AddDate(0, 0, -absenteeLookbackDays); !day.After(today); day = day.AddDate(0, 0, 1) {
+		working, err := s.isWorkingDay(ctx, day)
+		if err != nil {
+			s.log.WithError(err).WithField("date", day.Format("2006-01-02")).Error("Failed to check holidays")
+			continue
+		}
+		if !working {
+			continue
+		}
+		n, err := s.markAbsent(ctx, day, now)
+		if err != nil {
+			s.log.WithError(err).WithField("date", day.Format("2006-01-02")).Error("Failed to mark absentees")
+		}
+		marked += n
+	}
+
+	s.log.WithField("marked", marked).Info("Absentees marked")
+}
+
+// markAbsent inserts the absent rows of one day for employees whose shift
+// ended before now
+func (s *Scheduler) markAbsent(ctx context.Context, day, now time.Time) (int, error) {
+	date := day.Format("2006-01-02")
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.id, ws.start_time, ws.end_time
+		FROM employees e
+		LEFT JOIN employee_shifts es ON es.employee_id = e.id AND es.date = $1
+		LEFT JOIN work_shifts ws ON ws.id = es.shift_id
+		WHERE e.deleted_at IS NULL AND e.employment_status = 'active' AND e.join_date <= $1
+		  AND (e.resignation_date IS NULL OR e.resignation_date >= $1)
+		  AND NOT EXISTS (SELECT 1 FROM attendances a WHERE a.employee_id = e.id AND a.date = $1)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM leave_requests lr
+		      WHERE lr.employee_id = e.id AND lr.status = 'approved' AND lr.deleted_at IS NULL
+		        AND $1::date BETWEEN lr.start_date AND lr.end_date
+		  )
+	`, date)
+	if err != nil {
+		return 0, err
+	}
+
+	var employeeIDs []uuid.UUID
+	for rows.Next() {
+		var employeeID uuid.UUID
+		var startTime, endTime sql.NullString
+		if err := rows.Scan(&employeeID, &startTime, &endTime); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		shiftEnd, err := shiftEndAt(day, startTime, endTime, s.cfg.Attendance.DefaultShiftEnd)
+		if err != nil {
+			s.log.WithError(err).WithField("employee_id", employeeID).Error("Invalid shift time")
+			continue
+		}
+		// Still at work, or a night shift ending tomorrow morning
+		if shiftEnd.After(now) {
+			continue
+		}
+		employeeIDs = append(employeeIDs, employeeID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(employeeIDs) == 0 {
+		return 0, nil
+	}
+
+	// A check-in between the query and the insert wins
+	inserted, err := s.db.QueryContext(ctx, `
+		INSERT INTO attendances (id, employee_id, date, status, created_at, updated_at)
+		SELECT uuid_generate_v4(), employee_id, $1, 'absent', NOW(), NOW()
+		FROM UNNEST($2::uuid[]) AS employee_id
+		ON CONFLICT (employee_id, date) DO NOTHING
+		RETURNING id
+	`, date, pq.Array(employeeIDs))
+	if err != nil {
+		return 0, err
+	}
+	var attendanceIDs []uuid.UUID
+	for inserted.Next() {
+		var id uuid.UUID
+		if err := inserted.Scan(&id); err != nil {
+			inserted.Close()
+			return len(attendanceIDs), err
+		}
+		attendanceIDs = append(attendanceIDs, id)
+	}
+	inserted.Close()
+
+	for _, id := range attendanceIDs {
+		s.logAttendanceAction(ctx, id, "mark_absent", now)
+	}
+	return len(attendanceIDs), inserted.Err()
+}
+
+func (s *Scheduler) logAttendanceAction(ctx context.Context, attendanceID uuid.UUID, action string, at time.Time) {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO attendance_logs (id, attendance_id, action, timestamp, device_info)
+		VALUES ($1, $2, $3, $4, 'scheduler')
+	`, uuid.New(), attendanceID, action, at)
+	if err != nil {
+		s.log.WithError(err).WithField("attendance_id", attendanceID).Error("Failed to write attendance log")
+	}
+}
+
+// shiftEndAt returns when the shift worked on date ended. Without an assigned
+// shift the configured default end time is used. A shift ending at or before
+// its start runs past midnight.
+func shiftEndAt(date time.Time, startTime, endTime sql.NullString, defaultEnd string) (time.Time, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+
+	if !endTime.Valid {
+		end, err := time.Parse("15:04", defaultEnd)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return day.Add(time.Duration(end.Hour())*time.Hour + time.Duration(end.Minute())*time.Minute), nil
+	}
+
+	start, err := time.Parse("15:04:05", startTime.String)
+	if err != nil {
+		return time.Time{}, err
+	}
+	end, err := time.Parse("15:04:05", endTime.String)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	endAt := day.Add(end.Sub(time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)))
+	if !end.After(start) {
+		endAt = endAt.AddDate(0, 0, 1)
+	}
+	return endAt, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// isWorkingDay reports whether the date has working time on the payroll
+// working calendar: a work week day that is not a full-day holiday
+func (s *Scheduler) isWorkingDay(ctx context.Context, day time.Time) (bool, error) {
+	days, err := s.payroll.WorkingDaysInPeriod(ctx, day, day)
+	if err != nil {
+		return false, err
+	}
+	return days > 0, nil
+}
+
+// quietToday reports whether a job configured in SCHEDULER_QUIET_JOBS should
+// skip today. A failed holiday lookup lets the job run.
+func (s *Scheduler) quietToday(ctx context.Context, job string) bool {
+	if !s.cfg.Scheduler.IsQuiet(job) {
+		return false
+	}
+	working, err := s.isWorkingDay(ctx, time.Now())
+	if err != nil {
+		s.log.WithError(err).WithField("job", job).Error("Failed to check holidays, running job anyway")
+		return false
+	}
+	if !working {
+		s.log.WithField("job", job).Info("Skipping job on a non-working day")
+	}
+	return !working
+}
+
+// payrollReminderDue reports whether the payroll reminder goes out on the
+// date. A quiet reminder moves past weekends and holidays, but stays within
+// the month.
+func (s *Scheduler) payrollReminderDue(ctx context.Context, day time.Time) bool {
+	const reminderDay = 25
+	if day.Day() < reminderDay {
+		return false
+	}
+	if !s.cfg.Scheduler.IsQuiet("payroll_reminder") {
+		return day.Day() == reminderDay
+	}
+
+	for d := time.Date(day.Year(), day.Month(), reminderDay, 0, 0, 0, 0, day.Location()); !d.After(day); d = d.AddDate(0, 0, 1) {
+		working, err := s.isWorkingDay(ctx, d)
+		if err != nil {
+			s.log.WithError(err).WithField("job", "payroll_reminder").Error("Failed to check holidays")
+			return day.Day() == reminderDay
+		}
+		if working {
+			return d.Day() == day.Day()
+		}
+	}
+	return false
+}
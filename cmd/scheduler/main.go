@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"os"
 	"os/signal"
@@ -13,7 +14,9 @@ import (
 	"hr-management-system/internal/infrastructure/database"
 	"hr-management-system/internal/infrastructure/logger"
 	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/payroll"
 
+	"github.com/google/uuid"
 	"github.com/robfig/cron/v3"
 )
 
@@ -68,22 +71,31 @@ func main() {
 		scheduler.GenerateDailyAttendanceReport()
 	})
 
+	// Absentees at 11:30 PM daily, once day shifts have ended; the next run
+	// picks up night shifts
+	c.AddFunc("0 30 23 * * *", func() {
+		log.Info("Running: Absentee marking")
+		scheduler.MarkAbsentees()
+	})
+
 	// Weekly attendance summary on Monday at 9:00 AM
 	c.AddFunc("0 0 9 * * 1", func() {
 		log.Info("Running: Weekly attendance summary")
 		scheduler.GenerateWeeklyAttendanceSummary()
 	})
 
-	// Monthly payroll reminder on 25th at 9:00 AM
-	c.AddFunc("0 0 9 25 * *", func() {
+	// Monthly payroll reminder on 25th at 9:00 AM, or the next working day
+	c.AddFunc("0 0 9 25-31 * *", func() {
 		log.Info("Running: Monthly payroll reminder")
 		scheduler.SendPayrollReminder()
 	})
 
-	// Check leave balance expiry on 1st of each month
-	c.AddFunc("0 0 0 1 * *", func() {
-		log.Info("Running: Leave balance check")
-		scheduler.CheckLeaveBalanceExpiry()
+	// Leave balances of the current year nightly at 00:15, covering the new
+	// year on January 1st and people hired since. Unused days past the
+	// carry-over cap lapse when the year's balances are created.
+	c.AddFunc("0 15 0 * * *", func() {
+		log.Info("Running: Leave balance initialization")
+		scheduler.InitializeLeaveBalances()
 	})
 
 	// Birthday notifications at 8:00 AM daily
@@ -116,6 +128,44 @@ func main() {
 		scheduler.SyncElasticsearch()
 	})
 
+	// Elasticsearch reconcile weekly on Sunday at 4:00 AM
+	c.AddFunc("0 0 4 * * 0", func() {
+		log.Info("Running: Elasticsearch reconcile")
+		scheduler.ReconcileElasticsearch()
+	})
+
+	// Year-end leave encashment on December 31st at 10:00 PM
+	if cfg.Leave.YearEndEncashment {
+		c.AddFunc("0 0 22 31 12 *", func() {
+			log.Info("Running: Year-end leave encashment")
+			scheduler.EncashYearEndLeave()
+		})
+	}
+
+	// Department headcount snapshot on 1st of each month at 00:30
+	c.AddFunc("0 30 0 1 * *", func() {
+		log.Info("Running: Headcount snapshot")
+		scheduler.CaptureHeadcountSnapshots()
+	})
+
+	// Probation end reminders and conversions daily at 8:30 AM
+	c.AddFunc("0 30 8 * * *", func() {
+		log.Info("Running: Probation end check")
+		scheduler.CheckProbationEnd()
+	})
+
+	// Forgotten check-outs nightly at 00:30
+	c.AddFunc("0 30 0 * * *", func() {
+		log.Info("Running: Forgotten check-out close")
+		scheduler.CloseForgottenCheckouts()
+	})
+
+	// Leave attendance backfill nightly at 00:45
+	c.AddFunc("0 45 0 * * *", func() {
+		log.Info("Running: Leave attendance backfill")
+		scheduler.BackfillLeaveAttendance()
+	})
+
 	c.Start()
 	log.Info("Scheduler started successfully")
 
@@ -129,19 +179,25 @@ func main() {
 }
 
 type Scheduler struct {
-	db    *database.Database
-	cache *cache.RedisCache
-	queue *queue.Queue
-	log   *logger.Logger
-	cfg   *config.Config
+	db      *database.Database
+	cache   *cache.RedisCache
+	queue   *queue.Queue
+	payroll *payroll.Service
+	log     *logger.Logger
+	cfg     *config.Config
 }
 
 func NewScheduler(db *database.Database, cache *cache.RedisCache, q *queue.Queue, log *logger.Logger, cfg *config.Config) *Scheduler {
-	return &Scheduler{db: db, cache: cache, queue: q, log: log, cfg: cfg}
+	return &Scheduler{db: db, cache: cache, queue: q, payroll: payroll.NewService(db), log: log, cfg: cfg}
 }
 
+// SendAttendanceReminder reminds employees who have not checked in. Employees
+// on approved leave are never reminded.
 func (s *Scheduler) SendAttendanceReminder() {
 	ctx := context.Background()
+	if s.quietToday(ctx, "attendance_reminder") {
+		return
+	}
 	today := time.Now().Format("2006-01-02")
 
 	rows, err := s.db.QueryContext(ctx, `
@@ -149,6 +205,11 @@ func (s *Scheduler) SendAttendanceReminder() {
 		INNER JOIN employees e ON e.user_id = u.id
 		WHERE e.employment_status = 'active'
 		AND e.id NOT IN (SELECT employee_id FROM attendances WHERE date = $1)
+		AND NOT EXISTS (
+			SELECT 1 FROM leave_requests lr
+			WHERE lr.employee_id = e.id AND lr.status = 'approved' AND lr.deleted_at IS NULL
+			  AND $1 BETWEEN lr.start_date AND lr.end_date
+		)
 	`, today)
 	if err != nil {
 		s.log.WithError(err).Error("Failed to get employees without attendance")
@@ -170,6 +231,9 @@ func (s *Scheduler) SendAttendanceReminder() {
 
 func (s *Scheduler) GenerateDailyAttendanceReport() {
 	ctx := context.Background()
+	if s.quietToday(ctx, "daily_attendance_report") {
+		return
+	}
 	s.queue.GenerateReport(ctx, queue.ReportPayload{
 		ReportType:  "daily_attendance",
 		Format:      "excel",
@@ -180,6 +244,9 @@ func (s *Scheduler) GenerateDailyAttendanceReport() {
 
 func (s *Scheduler) GenerateWeeklyAttendanceSummary() {
 	ctx := context.Background()
+	if s.quietToday(ctx, "weekly_attendance_summary") {
+		return
+	}
 	endDate := time.Now()
 	startDate := endDate.AddDate(0, 0, -7)
 	s.queue.GenerateReport(ctx, queue.ReportPayload{
@@ -190,9 +257,15 @@ func (s *Scheduler) GenerateWeeklyAttendanceSummary() {
 	})
 }
 
+// SendPayrollReminder runs daily from the 25th. It reminds on the 25th, or
+// when the job is quiet, on the first working day from the 25th.
 func (s *Scheduler) SendPayrollReminder() {
 	ctx := context.Background()
-	month := time.Now().Format("2006-01")
+	now := time.Now()
+	if !s.payrollReminderDue(ctx, now) {
+		return
+	}
+	month := now.Format("2006-01")
 
 	rows, _ := s.db.QueryContext(ctx, `
 		SELECT u.id FROM users u
@@ -214,15 +287,17 @@ func (s *Scheduler) SendPayrollReminder() {
 	}
 }
 
-func (s *Scheduler) CheckLeaveBalanceExpiry() {
-	ctx := context.Background()
-	lastYear := time.Now().Year() - 1
-	s.db.ExecContext(ctx, `
-		UPDATE leave_balances SET carried_over = LEAST(total_days - used_days, 
-			(SELECT max_carry_over FROM leave_types WHERE id = leave_type_id))
-		WHERE year = $1
-	`, lastYear)
-	s.log.Info("Leave balance expiry check completed")
+// InitializeLeaveBalances creates the current year's missing leave balances
+func (s *Scheduler) InitializeLeaveBalances() {
+	year := time.Now().Year()
+	created, skipped, err := s.payroll.InitializeLeaveBalances(context.Background(), year)
+	if err != nil {
+		s.log.WithError(err).WithField("year", year).Error("Failed to initialize leave balances")
+		return
+	}
+	s.log.WithFields(map[string]interface{}{
+		"year": year, "created": created, "skipped": skipped,
+	}).Info("Leave balances initialized")
 }
 
 func (s *Scheduler) SendBirthdayNotifications() {
@@ -290,33 +365,212 @@ func (s *Scheduler) SyncElasticsearch() {
 	ctx := context.Background()
 
 	rows, _ := s.db.QueryContext(ctx, `
-		SELECT e.id, e.employee_code, e.full_name, u.email, e.department_id, d.name,
-		       e.position_id, p.name, e.employment_status, e.employment_type, e.join_date
+		SELECT e.id, e.employee_code, e.full_name, u.email, e.department_id, COALESCE(d.name, ''),
+		       e.position_id, COALESCE(p.name, ''), e.employment_status, e.employment_type, e.join_date
 		FROM employees e
 		INNER JOIN users u ON u.id = e.user_id
-		INNER JOIN departments d ON d.id = e.department_id
-		INNER JOIN positions p ON p.id = e.position_id
+		LEFT JOIN departments d ON d.id = e.department_id
+		LEFT JOIN positions p ON p.id = e.position_id
 		WHERE e.deleted_at IS NULL AND e.updated_at > NOW() - INTERVAL '1 day'
 	`)
 	defer rows.Close()
 
-	count := 0
+	documents := make(map[string]interface{})
 	for rows.Next() {
 		var id, code, name, email, deptID, deptName, posID, posName, status, empType string
 		var joinDate time.Time
 		rows.Scan(&id, &code, &name, &email, &deptID, &deptName, &posID, &posName, &status, &empType, &joinDate)
 
-		s.queue.IndexDocument(ctx, queue.ElasticPayload{
-			Index: "employees", DocumentID: id,
-			Document: map[string]interface{}{
-				"id": id, "employee_code": code, "full_name": name, "email": email,
-				"department_id": deptID, "department_name": deptName, "position_id": posID,
-				"position_name": posName, "employment_status": status, "employment_type": empType,
-				"join_date": joinDate, "updated_at": time.Now(),
-			},
-			Action: "index",
+		documents[id] = map[string]interface{}{
+			"id": id, "employee_code": code, "full_name": name, "email": email,
+			"department_id": deptID, "department_name": deptName, "position_id": posID,
+			"position_name": posName, "employment_status": status, "employment_type": empType,
+			"join_date": joinDate, "updated_at": time.Now(),
+		}
+	}
+	if len(documents) > 0 {
+		if _, err := s.queue.BulkIndexDocuments(ctx, queue.ElasticBulkPayload{Index: "employees", Documents: documents}); err != nil {
+			s.log.WithError(err).Error("Failed to queue Elasticsearch sync")
+			return
+		}
+	}
+	s.log.WithField("count", len(documents)).Info("Elasticsearch sync completed")
+}
+
+// ReconcileElasticsearch queues a full comparison of the employees index with
+// the database, repairing what the incremental sync missed
+func (s *Scheduler) ReconcileElasticsearch() {
+	info, err := s.queue.ReconcileIndex(context.Background(), queue.ElasticReconcilePayload{Index: "employees"})
+	if err != nil {
+		s.log.WithError(err).Warn("Failed to queue Elasticsearch reconcile")
+		return
+	}
+	s.log.WithField("task_id", info.ID).Info("Elasticsearch reconcile queued")
+}
+
+// CaptureHeadcountSnapshots records the headcount of every department as of
+// the last day of the previous month, along with that month's joiners and leavers
+func (s *Scheduler) CaptureHeadcountSnapshots() {
+	ctx := context.Background()
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -1, 0)
+	monthEnd := monthStart.AddDate(0, 1, -1)
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO headcount_snapshots (id, department_id, snapshot_date, headcount, joiners, leavers, created_at)
+		SELECT gen_random_uuid(), d.id, $2::date,
+			COUNT(e.id) FILTER (WHERE e.join_date <= $2 AND (e.resignation_date IS NULL OR e.resignation_date > $2)),
+			COUNT(e.id) FILTER (WHERE e.join_date BETWEEN $1 AND $2),
+			COUNT(e.id) FILTER (WHERE e.resignation_date BETWEEN $1 AND $2)
+		FROM departments d
+		LEFT JOIN employees e ON e.department_id = d.id AND e.deleted_at IS NULL
+		WHERE d.deleted_at IS NULL
+		GROUP BY d.id
+		ON CONFLICT (department_id, snapshot_date) DO UPDATE SET
+			headcount = EXCLUDED.headcount, joiners = EXCLUDED.joiners, leavers = EXCLUDED.leavers
+	`, monthStart.Format("2006-01-02"), monthEnd.Format("2006-01-02"))
+	if err != nil {
+		s.log.WithError(err).Error("Failed to capture headcount snapshots")
+		return
+	}
+	affected, _ := result.RowsAffected()
+	s.log.WithField("count", affected).Info("Headcount snapshots captured")
+}
+
+// EncashYearEndLeave records the payout of unused leave above the carry-over
+// cap for every active employee; the January payroll picks it up
+func (s *Scheduler) EncashYearEndLeave() {
+	ctx := context.Background()
+	year := time.Now().Year()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id FROM employees WHERE deleted_at IS NULL AND employment_status IN ('active', 'on_leave')
+	`)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get employees for leave encashment")
+		return
+	}
+	var employeeIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		rows.Scan(&id)
+		employeeIDs = append(employeeIDs, id)
+	}
+	rows.Close()
+
+	count := 0
+	for _, employeeID := range employeeIDs {
+		encashment, err := s.payroll.CalculateLeaveEncashment(ctx, employeeID, year, nil)
+		if err == nil {
+			err = s.payroll.SaveLeaveEncashment(ctx, encashment)
+		}
+		if err != nil {
+			s.log.WithError(err).WithField("employee_id", employeeID.String()).Error("Failed to encash leave")
+			continue
+		}
+		if encashment.TotalDays > 0 {
+			count++
+		}
+	}
+	s.log.WithField("count", count).Info("Year-end leave encashment completed")
+}
+
+// CheckProbationEnd reminds managers and HR ahead of probation end dates and,
+// when enabled, converts employees whose probation ended without a flagged or
+// denied review to full time
+func (s *Scheduler) CheckProbationEnd() {
+	ctx := context.Background()
+	now := time.Now()
+
+	if days := s.cfg.Employee.ProbationReminderDays; days > 0 {
+		s.sendProbationReminders(ctx, now.AddDate(0, 0, days).Format("2006-01-02"))
+	}
+
+	if !s.cfg.Employee.ProbationAutoConvert {
+		return
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE employees SET employment_type = 'full_time', probation_status = 'confirmed',
+		       probation_reviewed_at = NOW(), updated_at = NOW()
+		WHERE employment_type = 'probation' AND employment_status = 'active' AND deleted_at IS NULL
+		  AND probation_end_date <= $1
+		  AND (probation_status IS NULL OR probation_status NOT IN ('flagged', 'denied'))
+		RETURNING id, user_id, full_name
+	`, now.Format("2006-01-02"))
+	if err != nil {
+		s.log.WithError(err).Error("Failed to convert probation employees")
+		return
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var employeeID, userID, name string
+		rows.Scan(&employeeID, &userID, &name)
+
+		s.cache.Delete(ctx, "employee:"+employeeID)
+		s.queue.SendNotification(ctx, queue.NotificationPayload{
+			UserID:  userID,
+			Title:   "Chúc mừng bạn đã hoàn thành thử việc",
+			Message: "Bạn đã chính thức trở thành nhân viên toàn thời gian.",
+			Type:    "probation_converted",
+		})
+		s.queue.LogAudit(ctx, queue.AuditLogPayload{
+			Action: "probation_convert", TableName: "employees", RecordID: employeeID,
+			OldValues: map[string]interface{}{"employment_type": "probation"},
+			NewValues: map[string]interface{}{"employment_type": "full_time", "probation_status": "confirmed"},
 		})
 		count++
 	}
-	s.log.WithField("count", count).Info("Elasticsearch sync completed")
+	s.log.WithField("count", count).Info("Probation conversions completed")
+}
+
+func (s *Scheduler) sendProbationReminders(ctx context.Context, endDate string) {
+	var hrUserIDs []string
+	hrRows, err := s.db.QueryContext(ctx, `
+		SELECT ur.user_id FROM user_roles ur
+		INNER JOIN roles r ON r.id = ur.role_id
+		WHERE r.slug = 'hr_manager' AND r.deleted_at IS NULL
+	`)
+	if err == nil {
+		for hrRows.Next() {
+			var id string
+			hrRows.Scan(&id)
+			hrUserIDs = append(hrUserIDs, id)
+		}
+		hrRows.Close()
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.id, e.full_name, m.user_id FROM employees e
+		LEFT JOIN employees m ON m.id = e.manager_id
+		WHERE e.employment_type = 'probation' AND e.employment_status = 'active'
+		  AND e.deleted_at IS NULL AND e.probation_end_date = $1
+	`, endDate)
+	if err != nil {
+		s.log.WithError(err).Error("Failed to get employees ending probation")
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var employeeID, name string
+		var managerUserID sql.NullString
+		rows.Scan(&employeeID, &name, &managerUserID)
+
+		recipients := hrUserIDs
+		if managerUserID.Valid {
+			recipients = append([]string{managerUserID.String}, hrUserIDs...)
+		}
+		for _, userID := range recipients {
+			s.queue.SendNotification(ctx, queue.NotificationPayload{
+				UserID:  userID,
+				Title:   "Nhân viên sắp kết thúc thử việc",
+				Message: fmt.Sprintf("%s sẽ kết thúc thử việc vào ngày %s. Vui lòng đánh giá trước ngày này.", name, endDate),
+				Type:    "probation_reminder",
+				Data:    map[string]interface{}{"employee_id": employeeID},
+			})
+		}
+	}
 }
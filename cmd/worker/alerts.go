@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/infrastructure/cache"
+	"hr-management-system/internal/infrastructure/email"
+	"hr-management-system/internal/infrastructure/logger"
+	"hr-management-system/internal/infrastructure/queue"
+
+	"github.com/hibiken/asynq"
+)
+
+// alerter notifies operators about tasks that will not be retried any more and
+// about queues whose archive keeps growing. Each alert is sent at most once per
+// dedup window, across all worker instances.
+type alerter struct {
+	cfg       *config.Config
+	cache     *cache.RedisCache
+	email     *email.EmailService
+	inspector *asynq.Inspector
+	log       *logger.Logger
+	client    *http.Client
+}
+
+func newAlerter(cfg *config.Config, redisCache *cache.RedisCache, emailSvc *email.EmailService, log *logger.Logger) *alerter {
+	return &alerter{
+		cfg:       cfg,
+		cache:     redisCache,
+		email:     emailSvc,
+		inspector: asynq.NewInspector(asynq.RedisClientOpt{Addr: cfg.Worker.RedisAddr}),
+		log:       log,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (a *alerter) enabled() bool {
+	return a.cfg.Worker.Alert.Email != "" || a.cfg.Worker.Alert.WebhookURL != ""
+}
+
+// TaskFailed alerts when the failed task has exhausted its retries and was archived
+func (a *alerter) TaskFailed(ctx context.Context, task *asynq.Task, err error) {
+	if !a.enabled() {
+		return
+	}
+
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	if retried < maxRetry && !errors.Is(err, asynq.SkipRetry) {
+		return
+	}
+
+	taskID, _ := asynq.GetTaskID(ctx)
+	queueName, _ := asynq.GetQueueName(ctx)
+
+	// Delivery must not hold up the worker that reported the failure
+	go a.send(context.Background(), "archived:"+task.Type(),
+		fmt.Sprintf("[%s] Task %s archived", a.cfg.App.Name, task.Type()),
+		fmt.Sprintf("Task %s (%s) in queue %q failed after %d retries and was archived.\n\nLast error: %v",
+			taskID, task.Type(), queueName, retried, err))
+}
+
+// CheckArchived alerts for every queue holding more archived tasks than the threshold
+func (a *alerter) CheckArchived(ctx context.Context) {
+	threshold := a.cfg.Worker.Alert.ArchivedThreshold
+	if !a.enabled() || threshold <= 0 {
+		return
+	}
+
+	for _, name := range queue.KnownQueues {
+		info, err := a.inspector.GetQueueInfo(name)
+		if err != nil || info.Archived <= threshold {
+			continue
+		}
+		a.send(ctx, "threshold:"+name,
+			fmt.Sprintf("[%s] Queue %s has %d archived tasks", a.cfg.App.Name, name, info.Archived),
+			fmt.Sprintf("Queue %q holds %d archived tasks, above the alert threshold of %d.", name, info.Archived, threshold))
+	}
+}
+
+func (a *alerter) send(ctx context.Context, key, subject, body string) {
+	acquired, err := a.cache.Lock(ctx, cache.KeyWorkerAlert+key, subject, a.cfg.Worker.Alert.DedupWindow)
+	if err != nil || !acquired {
+		return
+	}
+
+	log := a.log.WithFields(map[string]interface{}{"alert": key})
+	log.Warn(subject)
+
+	if to := a.cfg.Worker.Alert.Email; to != "" && a.email != nil {
+		if err := a.email.Send(ctx, email.Email{To: []string{to}, Subject: subject, Body: body}); err != nil {
+			log.WithError(err).Error("Failed to send alert email")
+		}
+	}
+
+	if url := a.cfg.Worker.Alert.WebhookURL; url != "" {
+		if err := a.postWebhook(ctx, url, key, subject, body); err != nil {
+			log.WithError(err).Error("Failed to post alert webhook")
+		}
+	}
+}
+
+func (a *alerter) postWebhook(ctx context.Context, url, key, subject, body string) error {
+	data, _ := json.Marshal(map[string]interface{}{
+		"alert":   key,
+		"subject": subject,
+		"message": body,
+		"sent_at": time.Now(),
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
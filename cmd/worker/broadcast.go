@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"hr-management-system/internal/infrastructure/cache"
+	"hr-management-system/internal/infrastructure/queue"
+
+	"github.com/hibiken/asynq"
+	"github.com/lib/pq"
+)
+
+// broadcastProgress is written as the task result after every window of
+// batches, so the task status endpoint shows how far a fan-out got
+type broadcastProgress struct {
+	BroadcastID string `json:"broadcast_id"`
+	Status      string `json:"status"`
+	Total       int    `json:"total"`
+	Processed   int    `json:"processed"`
+}
+
+// notificationEvent is published to a user's channel for live delivery
+type notificationEvent struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Title     string          `json:"title"`
+	Message   string          `json:"message"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// publishNotification pushes a stored notification to the user's live
+// channel. Clients that are offline read it from the database later, so a
+// failure is only logged.
+func (h *Handlers) publishNotification(ctx context.Context, userID string, event notificationEvent) {
+	if err := h.cache.Publish(ctx, cache.KeyNotifyChannel+userID, event); err != nil {
+		h.log.WithError(err).WithField("user_id", userID).Warn("Failed to publish notification")
+	}
+}
+
+// broadcastAudienceSQL selects active users in the broadcast's departments
+// and roles (NULL means everyone) after the checkpoint $3, in id order
+const broadcastAudienceSQL = `
+	FROM users u
+	WHERE u.status = 'active' AND u.deleted_at IS NULL
+	  AND ($1::uuid[] IS NULL OR EXISTS (
+	      SELECT 1 FROM employees e
+	      WHERE e.user_id = u.id AND e.deleted_at IS NULL AND e.department_id = ANY($1::uuid[])
+	  ))
+	  AND ($2::uuid[] IS NULL OR EXISTS (
+	      SELECT 1 FROM user_roles ur WHERE ur.user_id = u.id AND ur.role_id = ANY($2::uuid[])
+	  ))
+	  AND ($3::uuid IS NULL OR u.id > $3::uuid)`
+
+// HandleNotificationFanout delivers a broadcast to its audience. Users are
+// walked in id order in windows of BroadcastConcurrency batches; batches of a
+// window are inserted in parallel and the checkpoint moves past the window
+// once all of them committed. A retried task resumes from the checkpoint, and
+// replaying a partly inserted window notifies nobody twice.
+func (h *Handlers) HandleNotificationFanout(ctx context.Context, t *asynq.Task) (err error) {
+	var payload queue.NotificationBroadcastPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return err
+	}
+
+	var title, message, notificationType, status string
+	var data []byte
+	var departmentIDs, roleIDs []string
+	var cursor sql.NullString
+	progress := broadcastProgress{BroadcastID: payload.BroadcastID}
+	err = h.db.QueryRowContext(ctx, `
+		SELECT title, message, type, data, department_ids, role_ids, status, total, processed, last_user_id
+		FROM notification_broadcasts WHERE id = $1
+	`, payload.BroadcastID).Scan(&title, &message, &notificationType, &data, pq.Array(&departmentIDs),
+		pq.Array(&roleIDs), &status, &progress.Total, &progress.Processed, &cursor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("notification broadcast %s not found: %w", payload.BroadcastID, asynq.SkipRetry)
+	}
+	if err != nil {
+		return err
+	}
+	if status == "completed" {
+		progress.Status = status
+		h.writeBroadcastProgress(t, progress)
+		return nil
+	}
+
+	if status == "queued" {
+		err := h.db.QueryRowContext(ctx, `SELECT COUNT(*)`+broadcastAudienceSQL,
+			pq.Array(departmentIDs), pq.Array(roleIDs), nil).Scan(&progress.Total)
+		if err != nil {
+			return err
+		}
+		if _, err := h.db.ExecContext(ctx, `
+			UPDATE notification_broadcasts SET status = 'running', total = $2, started_at = NOW(), updated_at = NOW()
+			WHERE id = $1
+		`, payload.BroadcastID, progress.Total); err != nil {
+			return err
+		}
+	}
+	progress.Status = "running"
+	h.writeBroadcastProgress(t, progress)
+
+	// The row stays "running" between attempts; after the last one it is
+	// marked failed, keeping the checkpoint for a manual requeue
+	defer func() {
+		retried, _ := asynq.GetRetryCount(ctx)
+		maxRetry, _ := asynq.GetMaxRetry(ctx)
+		if err != nil && retried >= maxRetry {
+			h.db.ExecContext(context.Background(), `
+				UPDATE notification_broadcasts SET status = 'failed', updated_at = NOW() WHERE id = $1
+			`, payload.BroadcastID)
+		}
+	}()
+
+	batchSize := h.cfg.Notification.BroadcastBatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	concurrency := h.cfg.Notification.BroadcastConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	sendEmail := h.cfg.Notification.EmailsType(notificationType)
+	start := time.Now()
+
+	for {
+		var after interface{}
+		if cursor.Valid {
+			after = cursor.String
+		}
+		rows, err := h.db.QueryContext(ctx, `SELECT u.id`+broadcastAudienceSQL+` ORDER BY u.id LIMIT $4`,
+			pq.Array(departmentIDs), pq.Array(roleIDs), after, batchSize*concurrency)
+		if err != nil {
+			return err
+		}
+		var userIDs []string
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			userIDs = append(userIDs, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(userIDs) == 0 {
+			break
+		}
+
+		var wg sync.WaitGroup
+		errs := make([]error, 0, concurrency)
+		var mu sync.Mutex
+		for from := 0; from < len(userIDs); from += batchSize {
+			to := from + batchSize
+			if to > len(userIDs) {
+				to = len(userIDs)
+			}
+			wg.Add(1)
+			go func(batch []string) {
+				defer wg.Done()
+				if err := h.insertBroadcastBatch(ctx, payload.BroadcastID, title, message, notificationType, data, batch, sendEmail); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}(userIDs[from:to])
+		}
+		wg.Wait()
+		if len(errs) > 0 {
+			return errs[0]
+		}
+
+		cursor = sql.NullString{String: userIDs[len(userIDs)-1], Valid: true}
+		progress.Processed += len(userIDs)
+		if _, err := h.db.ExecContext(ctx, `
+			UPDATE notification_broadcasts SET last_user_id = $2, processed = $3, updated_at = NOW()
+			WHERE id = $1
+		`, payload.BroadcastID, cursor.String, progress.Processed); err != nil {
+			return err
+		}
+		h.writeBroadcastProgress(t, progress)
+	}
+
+	if _, err := h.db.ExecContext(ctx, `
+		UPDATE notification_broadcasts SET status = 'completed', completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, payload.BroadcastID); err != nil {
+		return err
+	}
+	progress.Status = "completed"
+	h.writeBroadcastProgress(t, progress)
+	h.log.LogJobExecution(queue.TypeNotificationFanout, t.ResultWriter().TaskID(), time.Since(start), nil)
+	return nil
+}
+
+// insertBroadcastBatch stores one multi-row batch and publishes the rows it
+// created; users already notified by an earlier attempt are skipped
+func (h *Handlers) insertBroadcastBatch(ctx context.Context, broadcastID, title, message, notificationType string, data []byte, userIDs []string, sendEmail bool) error {
+	rows, err := h.db.QueryContext(ctx, `
+		INSERT INTO notifications (id, user_id, title, message, type, data, broadcast_id, created_at, updated_at)
+		SELECT gen_random_uuid(), u.id, $2, $3, $4, $5, $1, NOW(), NOW()
+		FROM unnest($6::uuid[]) AS u(id)
+		ON CONFLICT (broadcast_id, user_id) WHERE broadcast_id IS NOT NULL DO NOTHING
+		RETURNING id, user_id, created_at
+	`, broadcastID, title, message, notificationType, data, pq.Array(userIDs))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type created struct {
+		id, userID string
+		at         time.Time
+	}
+	var inserted []created
+	for rows.Next() {
+		var n created
+		if err := rows.Scan(&n.id, &n.userID, &n.at); err != nil {
+			return err
+		}
+		inserted = append(inserted, n)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, n := range inserted {
+		h.publishNotification(ctx, n.userID, notificationEvent{
+			ID: n.id, Type: notificationType, Title: title, Message: message, Data: data, CreatedAt: n.at,
+		})
+		if sendEmail {
+			h.queueNotificationEmail(ctx, n.id)
+		}
+	}
+	return nil
+}
+
+func (h *Handlers) writeBroadcastProgress(t *asynq.Task, progress broadcastProgress) {
+	data, _ := json.Marshal(progress)
+	if _, err := t.ResultWriter().Write(data); err != nil {
+		h.log.WithError(err).WithField("broadcast_id", progress.BroadcastID).Warn("Failed to write broadcast progress")
+	}
+}
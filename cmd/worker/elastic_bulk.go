@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/infrastructure/search"
+
+	"github.com/hibiken/asynq"
+)
+
+// bulkIndexMaxAttempts bounds the re-queues of documents that keep failing
+const bulkIndexMaxAttempts = 5
+
+// HandleElasticBulkIndex indexes the documents of a bulk task in batches of
+// ELASTIC_BULK_BATCH_SIZE. Documents Elasticsearch rejects, or whose batch
+// could not be sent, are logged and queued again on their own with a growing
+// delay, so one bad document does not make the whole task retry.
+func (h *Handlers) HandleElasticBulkIndex(ctx context.Context, t *asynq.Task) error {
+	if h.es == nil {
+		return nil
+	}
+
+	var payload queue.ElasticBulkPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(payload.Documents))
+	for id := range payload.Documents {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	size := h.bulkBatchSize()
+	failed := make(map[string]interface{})
+	var lastErr error
+	for from := 0; from < len(ids); from += size {
+		to := from + size
+		if to > len(ids) {
+			to = len(ids)
+		}
+		batch := make(map[string]interface{}, to-from)
+		for _, id := range ids[from:to] {
+			batch[id] = payload.Documents[id]
+		}
+
+		err := h.es.BulkIndex(ctx, payload.Index, batch)
+		if err == nil {
+			continue
+		}
+		lastErr = err
+		var bulkErr *search.BulkIndexError
+		if errors.As(err, &bulkErr) {
+			for _, id := range bulkErr.Failed {
+				failed[id] = batch[id]
+			}
+		} else {
+			for id, doc := range batch {
+				failed[id] = doc
+			}
+		}
+	}
+
+	// The documents that made it are in; only the failures are retried
+	if len(failed) == 0 {
+		return nil
+	}
+
+	entry := h.log.WithError(lastErr).WithFields(map[string]interface{}{
+		"index":   payload.Index,
+		"total":   len(ids),
+		"failed":  len(failed),
+		"attempt": payload.Attempt,
+	})
+	if payload.Attempt+1 >= bulkIndexMaxAttempts {
+		entry.Error("Giving up on bulk indexing documents, reconcile will pick them up")
+		return nil
+	}
+	entry.Warn("Bulk indexing partly failed, retrying the failed documents")
+
+	delay := time.Duration(payload.Attempt+1) * time.Minute
+	_, err := h.queue.BulkIndexDocuments(ctx, queue.ElasticBulkPayload{
+		Index: payload.Index, Documents: failed, Attempt: payload.Attempt + 1,
+	}, asynq.ProcessIn(delay))
+	return err
+}
+
+// bulkBatchSize is the configured number of documents per bulk request
+func (h *Handlers) bulkBatchSize() int {
+	if h.cfg.Elastic.BulkBatchSize > 0 {
+		return h.cfg.Elastic.BulkBatchSize
+	}
+	return reconcileBatchSize
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/payroll"
+
+	"github.com/google/uuid"
+)
+
+// lateness is the accumulated late arrival of an employee over a period
+type lateness struct {
+	Count   int
+	Minutes int
+}
+
+// accumulatedLateness counts check-ins more than the grace period after the
+// shift start, the same rule that marks an attendance late. Late minutes are
+// counted from the shift start. Remote days without an assigned shift start at
+// ATTENDANCE_REMOTE_SHIFT_START. When that is empty, they are flexible and
+// never late.
+func (h *Handlers) accumulatedLateness(ctx context.Context, employeeID uuid.UUID, from, to time.Time) (lateness, error) {
+	var result lateness
+
+	defaultStart, err := time.Parse("15:04", h.cfg.Attendance.DefaultShiftStart)
+	if err != nil {
+		return result, fmt.Errorf("invalid ATTENDANCE_DEFAULT_SHIFT_START: %w", err)
+	}
+
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT a.date, a.check_in, ws.start_time, a.work_mode
+		FROM attendances a
+		LEFT JOIN employee_shifts es ON es.employee_id = a.employee_id AND es.date = a.date
+		LEFT JOIN work_shifts ws ON ws.id = es.shift_id
+		WHERE a.employee_id = $1 AND a.date BETWEEN $2 AND $3
+		  AND a.check_in IS NOT NULL AND a.deleted_at IS NULL
+	`, employeeID, from, to)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	var remoteStart *time.Time
+	if h.cfg.Attendance.RemoteShiftStart != "" {
+		start, err := time.Parse("15:04", h.cfg.Attendance.RemoteShiftStart)
+		if err != nil {
+			return result, fmt.Errorf("invalid ATTENDANCE_REMOTE_SHIFT_START: %w", err)
+		}
+		remoteStart = &start
+	}
+
+	grace := h.payroll.LateGrace(ctx, time.Duration(h.cfg.Attendance.LateGraceMinutes)*time.Minute)
+	for rows.Next() {
+		var date, checkIn time.Time
+		var startTime sql.NullString
+		var workMode string
+		if err := rows.Scan(&date, &checkIn, &startTime, &workMode); err != nil {
+			return result, err
+		}
+
+		start := defaultStart
+		if workMode == "remote" && !startTime.Valid {
+			if remoteStart == nil {
+				continue
+			}
+			start = *remoteStart
+		}
+		if startTime.Valid {
+			if start, err = time.Parse("15:04:05", startTime.String); err != nil {
+				return result, err
+			}
+		}
+
+		// check_in is stored as local wall-clock time
+		shiftStart := time.Date(date.Year(), date.Month(), date.Day(), start.Hour(), start.Minute(), 0, 0, time.Local)
+		arrived := time.Date(checkIn.Year(), checkIn.Month(), checkIn.Day(),
+			checkIn.Hour(), checkIn.Minute(), checkIn.Second(), 0, time.Local)
+
+		if late := arrived.Sub(shiftStart); late > grace {
+			result.Count++
+			result.Minutes += int(late.Minutes())
+		}
+	}
+	return result, rows.Err()
+}
+
+// latePenalty applies the late arrival policy to a payslip. It returns the
+// deduction line to record, or nil when no threshold was crossed. The line
+// carries a zero amount when the policy does not deduct pay.
+func (h *Handlers) latePenalty(ctx context.Context, period payrollPeriod, employeeID uuid.UUID, employeeName string, baseSalary float64, currency string, workingDays float64) (*payslipLine, error) {
+	cfg := h.cfg.Attendance
+	if cfg.LateCountThreshold <= 0 && cfg.LateMinutesThreshold <= 0 {
+		return nil, nil
+	}
+
+	late, err := h.accumulatedLateness(ctx, employeeID, period.StartDate, period.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	overCount := cfg.LateCountThreshold > 0 && late.Count > cfg.LateCountThreshold
+	overMinutes := cfg.LateMinutesThreshold > 0 && late.Minutes > cfg.LateMinutesThreshold
+	if !overCount && !overMinutes {
+		return nil, nil
+	}
+
+	amount := 0.0
+	if cfg.LateDeductPay && workingDays > 0 {
+		minuteRate := baseSalary / workingDays / payroll.StandardHoursPerDay / 60
+		amount = roundMoney(minuteRate*float64(late.Minutes), currency)
+	}
+
+	if cfg.LateNotifyHR {
+		h.notifyLatePenalty(ctx, period, employeeID, employeeName, late, amount, currency)
+	}
+
+	return &payslipLine{
+		Code:   "LATE",
+		Name:   fmt.Sprintf("Đi muộn (%d lần, %d phút)", late.Count, late.Minutes),
+		Amount: amount,
+		Details: map[string]interface{}{
+			"late_count":            late.Count,
+			"late_minutes":          late.Minutes,
+			"grace_minutes":         int(h.payroll.LateGrace(ctx, time.Duration(cfg.LateGraceMinutes)*time.Minute).Minutes()),
+			"count_threshold":       cfg.LateCountThreshold,
+			"minutes_threshold":     cfg.LateMinutesThreshold,
+			"deducted":              cfg.LateDeductPay,
+			"count_threshold_hit":   overCount,
+			"minutes_threshold_hit": overMinutes,
+		},
+	}, nil
+}
+
+// notifyLatePenalty tells HR managers about the penalty once per employee and
+// period; recalculating the period does not notify again.
+func (h *Handlers) notifyLatePenalty(ctx context.Context, period payrollPeriod, employeeID uuid.UUID, employeeName string, late lateness, amount float64, currency string) {
+	var exists bool
+	h.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM notifications
+			WHERE type = 'late_penalty' AND data->>'employee_id' = $1 AND data->>'period_id' = $2
+		)
+	`, employeeID.String(), period.ID.String()).Scan(&exists)
+	if exists {
+		return
+	}
+
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT ur.user_id FROM user_roles ur
+		INNER JOIN roles r ON r.id = ur.role_id
+		WHERE r.slug = 'hr_manager' AND r.deleted_at IS NULL
+	`)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to get HR managers")
+		return
+	}
+	var hrUserIDs []string
+	for rows.Next() {
+		var id string
+		rows.Scan(&id)
+		hrUserIDs = append(hrUserIDs, id)
+	}
+	rows.Close()
+
+	message := fmt.Sprintf("%s đi muộn %d lần (%d phút) trong kỳ lương %02d/%d.", employeeName, late.Count, late.Minutes, period.Month, period.Year)
+	if amount > 0 {
+		message += fmt.Sprintf(" Khấu trừ %s.", payroll.FormatAmount(amount, currency))
+	}
+	for _, userID := range hrUserIDs {
+		h.queue.SendNotification(ctx, queue.NotificationPayload{
+			UserID:  userID,
+			Title:   "Nhân viên vượt ngưỡng đi muộn",
+			Message: message,
+			Type:    "late_penalty",
+			Data: map[string]interface{}{
+				"employee_id":  employeeID.String(),
+				"period_id":    period.ID.String(),
+				"late_count":   late.Count,
+				"late_minutes": late.Minutes,
+			},
+		})
+	}
+}
@@ -2,20 +2,28 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"reflect"
+	"sync"
 	"syscall"
 	"time"
 
 	"hr-management-system/internal/config"
+	"hr-management-system/internal/i18n"
 	"hr-management-system/internal/infrastructure/cache"
 	"hr-management-system/internal/infrastructure/database"
 	"hr-management-system/internal/infrastructure/email"
 	"hr-management-system/internal/infrastructure/logger"
 	"hr-management-system/internal/infrastructure/queue"
 	"hr-management-system/internal/infrastructure/search"
+	"hr-management-system/internal/infrastructure/sms"
+	"hr-management-system/internal/infrastructure/storage"
+	"hr-management-system/internal/payroll"
 
 	"github.com/hibiken/asynq"
 )
@@ -36,6 +44,11 @@ func main() {
 
 	log.Info("Starting HR Management Worker...")
 
+	// OTP texts are translated here, so the worker needs i18n too
+	if _, err := i18n.New("vi"); err != nil {
+		log.WithError(err).Fatal("Failed to initialize i18n")
+	}
+
 	// Initialize dependencies
 	db, err := database.NewConnection(&cfg.Database)
 	if err != nil {
@@ -51,49 +64,93 @@ func main() {
 
 	es, _ := search.NewElasticSearch(&cfg.Elastic)
 	emailSvc, _ := email.NewEmailService(&cfg.Email)
+	smsSender, err := sms.NewSender(&cfg.SMS, log)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to initialize SMS sender")
+	}
 
-	// Create worker handlers
-	handlers := NewHandlers(db, redisCache, es, emailSvc, log, cfg)
+	// Follow-up tasks (e.g. notification emails) are queued from handlers
+	jobQueue, err := queue.NewQueue(&cfg.Worker)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to initialize job queue")
+	}
+	defer jobQueue.Close()
 
-	// Create Asynq server
-	srv := asynq.NewServer(
-		asynq.RedisClientOpt{Addr: cfg.Worker.RedisAddr},
-		asynq.Config{
-			Concurrency: cfg.Worker.Concurrency,
-			Queues:      cfg.Worker.Queues,
-			RetryDelayFunc: func(n int, e error, t *asynq.Task) time.Duration {
-				return time.Duration(n) * cfg.Worker.RetryDelay
-			},
-			ErrorHandler: asynq.ErrorHandlerFunc(func(ctx context.Context, task *asynq.Task, err error) {
-				log.WithFields(map[string]interface{}{
-					"task_type": task.Type(),
-					"error":     err.Error(),
-				}).Error("Task failed")
-			}),
-		},
-	)
+	// Generated reports are written here
+	store, err := storage.NewBackend(&cfg.Storage, cfg.App.BaseURL)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to initialize file storage")
+	}
+
+	// Create worker handlers
+	handlers := NewHandlers(db, redisCache, es, emailSvc, smsSender, jobQueue, store, log, cfg)
 
 	// Register handlers
 	mux := asynq.NewServeMux()
 	mux.HandleFunc(queue.TypeEmailSend, handlers.HandleEmailSend)
 	mux.HandleFunc(queue.TypeEmailOTP, handlers.HandleEmailOTP)
+	mux.HandleFunc(queue.TypeSMSOTP, handlers.HandleSMSOTP)
 	mux.HandleFunc(queue.TypeEmailPasswordReset, handlers.HandleEmailPasswordReset)
 	mux.HandleFunc(queue.TypeEmailPayslip, handlers.HandleEmailPayslip)
+	mux.HandleFunc(queue.TypeEmailWelcome, handlers.HandleEmailWelcome)
+	mux.HandleFunc(queue.TypeEmailLeaveRequest, handlers.HandleEmailLeaveRequest)
+	mux.HandleFunc(queue.TypeEmailLeaveApproved, handlers.HandleEmailLeaveApproved)
 	mux.HandleFunc(queue.TypePayrollCalculate, handlers.HandlePayrollCalculate)
 	mux.HandleFunc(queue.TypeReportGenerate, handlers.HandleReportGenerate)
 	mux.HandleFunc(queue.TypeNotificationSend, handlers.HandleNotificationSend)
+	mux.HandleFunc(queue.TypeNotificationEmail, handlers.HandleNotificationEmail)
+	mux.HandleFunc(queue.TypeNotificationFanout, handlers.HandleNotificationFanout)
 	mux.HandleFunc(queue.TypeElasticIndex, handlers.HandleElasticIndex)
 	mux.HandleFunc(queue.TypeElasticDelete, handlers.HandleElasticDelete)
+	mux.HandleFunc(queue.TypeElasticBulkIndex, handlers.HandleElasticBulkIndex)
+	mux.HandleFunc(queue.TypeElasticReconcile, handlers.HandleElasticReconcile)
 	mux.HandleFunc(queue.TypeAuditLog, handlers.HandleAuditLog)
 
-	// Start server
+	// Start server with the runtime settings stored in Redis, if any
+	settings := loadWorkerSettings(context.Background(), redisCache, cfg)
+	alerts := newAlerter(cfg, redisCache, emailSvc, log)
+	srv := newServer(cfg, settings, alerts, log)
+	if err := srv.Start(mux); err != nil {
+		log.WithError(err).Fatal("Worker server failed")
+	}
+
+	log.Info("Worker started successfully")
+
+	// Periodically reload runtime settings and restart the server when they change
+	var srvMu sync.Mutex
+	reloadTicker := time.NewTicker(cfg.Worker.ReloadInterval)
+	defer reloadTicker.Stop()
 	go func() {
-		if err := srv.Run(mux); err != nil {
-			log.WithError(err).Fatal("Worker server failed")
+		for range reloadTicker.C {
+			next := loadWorkerSettings(context.Background(), redisCache, cfg)
+			if reflect.DeepEqual(next.Queues, settings.Queues) && next.Concurrency == settings.Concurrency {
+				continue
+			}
+
+			log.WithFields(map[string]interface{}{
+				"concurrency": next.Concurrency,
+				"queues":      next.Queues,
+			}).Info("Worker settings changed, restarting server")
+
+			srvMu.Lock()
+			srv.Shutdown()
+			srv = newServer(cfg, next, alerts, log)
+			if err := srv.Start(mux); err != nil {
+				log.WithError(err).Fatal("Worker server failed")
+			}
+			srvMu.Unlock()
+			settings = next
 		}
 	}()
 
-	log.Info("Worker started successfully")
+	// Periodically alert on queues with too many archived tasks
+	alertTicker := time.NewTicker(cfg.Worker.Alert.CheckInterval)
+	defer alertTicker.Stop()
+	go func() {
+		for range alertTicker.C {
+			alerts.CheckArchived(context.Background())
+		}
+	}()
 
 	// Wait for shutdown
 	quit := make(chan os.Signal, 1)
@@ -101,21 +158,67 @@ func main() {
 	<-quit
 
 	log.Info("Shutting down worker...")
+	reloadTicker.Stop()
+	alertTicker.Stop()
+	srvMu.Lock()
 	srv.Shutdown()
+	srvMu.Unlock()
 	log.Info("Worker stopped")
 }
 
+// newServer builds an asynq server for the given concurrency and queue weights
+func newServer(cfg *config.Config, settings queue.WorkerSettings, alerts *alerter, log *logger.Logger) *asynq.Server {
+	return asynq.NewServer(
+		asynq.RedisClientOpt{Addr: cfg.Worker.RedisAddr},
+		asynq.Config{
+			Concurrency: settings.Concurrency,
+			Queues:      settings.Queues,
+			RetryDelayFunc: func(n int, e error, t *asynq.Task) time.Duration {
+				return time.Duration(n) * cfg.Worker.RetryDelay
+			},
+			ErrorHandler: asynq.ErrorHandlerFunc(func(ctx context.Context, task *asynq.Task, err error) {
+				log.WithFields(map[string]interface{}{
+					"task_type": task.Type(),
+					"error":     err.Error(),
+				}).Error("Task failed")
+				alerts.TaskFailed(ctx, task, err)
+			}),
+		},
+	)
+}
+
+// loadWorkerSettings returns the operator overrides from Redis, falling back to the static config
+func loadWorkerSettings(ctx context.Context, redisCache *cache.RedisCache, cfg *config.Config) queue.WorkerSettings {
+	settings := queue.WorkerSettings{Concurrency: cfg.Worker.Concurrency, Queues: cfg.Worker.Queues}
+
+	var override queue.WorkerSettings
+	if err := redisCache.Get(ctx, cache.KeyWorkerSettings, &override); err != nil {
+		return settings
+	}
+	if override.Concurrency > 0 {
+		settings.Concurrency = override.Concurrency
+	}
+	if len(override.Queues) > 0 {
+		settings.Queues = override.Queues
+	}
+	return settings
+}
+
 type Handlers struct {
 	db       *database.Database
 	cache    *cache.RedisCache
 	es       *search.ElasticSearch
 	email    *email.EmailService
+	sms      sms.SMSSender
+	queue    *queue.Queue
+	payroll  *payroll.Service
+	store    storage.Backend
 	log      *logger.Logger
 	cfg      *config.Config
 }
 
-func NewHandlers(db *database.Database, cache *cache.RedisCache, es *search.ElasticSearch, emailSvc *email.EmailService, log *logger.Logger, cfg *config.Config) *Handlers {
-	return &Handlers{db: db, cache: cache, es: es, email: emailSvc, log: log, cfg: cfg}
+func NewHandlers(db *database.Database, cache *cache.RedisCache, es *search.ElasticSearch, emailSvc *email.EmailService, smsSender sms.SMSSender, q *queue.Queue, store storage.Backend, log *logger.Logger, cfg *config.Config) *Handlers {
+	return &Handlers{db: db, cache: cache, es: es, email: emailSvc, sms: smsSender, queue: q, payroll: payroll.NewService(db), store: store, log: log, cfg: cfg}
 }
 
 func (h *Handlers) HandleEmailSend(ctx context.Context, t *asynq.Task) error {
@@ -150,6 +253,25 @@ func (h *Handlers) HandleEmailOTP(ctx context.Context, t *asynq.Task) error {
 	return err
 }
 
+// HandleSMSOTP texts an OTP to a phone
+func (h *Handlers) HandleSMSOTP(ctx context.Context, t *asynq.Task) error {
+	var payload queue.OTPPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err := h.sendSMSOTP(ctx, payload)
+	h.log.LogJobExecution(queue.TypeSMSOTP, t.ResultWriter().TaskID(), time.Since(start), err)
+	return err
+}
+
+// sendSMSOTP texts the OTP in the payload language, with its validity
+func (h *Handlers) sendSMSOTP(ctx context.Context, payload queue.OTPPayload) error {
+	body := i18n.T(payload.Language, "sms.otp", payload.OTP, int(h.cfg.Security.OTPExpiry.Minutes()))
+	return h.sms.Send(ctx, payload.Phone, body)
+}
+
 func (h *Handlers) HandleEmailPasswordReset(ctx context.Context, t *asynq.Task) error {
 	var payload struct {
 		Email     string `json:"email"`
@@ -163,21 +285,79 @@ func (h *Handlers) HandleEmailPasswordReset(ctx context.Context, t *asynq.Task)
 	return h.email.SendPasswordReset(ctx, payload.Email, payload.Name, payload.ResetLink)
 }
 
-func (h *Handlers) HandleEmailPayslip(ctx context.Context, t *asynq.Task) error {
-	var payload struct {
-		Email      string `json:"email"`
-		Name       string `json:"name"`
-		Period     string `json:"period"`
-		NetSalary  string `json:"net_salary"`
-		PDFContent []byte `json:"pdf_content"`
+func (h *Handlers) HandleEmailLeaveRequest(ctx context.Context, t *asynq.Task) error {
+	var payload queue.LeaveRequestEmailPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return err
 	}
+
+	start := time.Now()
+	err := h.email.SendLeaveRequest(ctx, payload.Email, email.LeaveRequestData{
+		EmployeeName: payload.EmployeeName,
+		LeaveType:    payload.LeaveType,
+		StartDate:    payload.StartDate,
+		EndDate:      payload.EndDate,
+		TotalDays:    payload.TotalDays,
+		Reason:       payload.Reason,
+		ApproveURL:   payload.ApproveURL,
+		AppName:      "HR Management System",
+	})
+	h.log.LogJobExecution(queue.TypeEmailLeaveRequest, t.ResultWriter().TaskID(), time.Since(start), err)
+	return err
+}
+
+func (h *Handlers) HandleEmailLeaveApproved(ctx context.Context, t *asynq.Task) error {
+	var payload queue.LeaveApprovedEmailPayload
 	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
 		return err
 	}
 
+	start := time.Now()
+	err := h.email.SendLeaveApproved(ctx, payload.Email, email.LeaveApprovedData{
+		Name:      payload.Name,
+		LeaveType: payload.LeaveType,
+		StartDate: payload.StartDate,
+		EndDate:   payload.EndDate,
+		Status:    payload.Status,
+		Notes:     payload.Notes,
+		AppName:   "HR Management System",
+	})
+	h.log.LogJobExecution(queue.TypeEmailLeaveApproved, t.ResultWriter().TaskID(), time.Since(start), err)
+	return err
+}
+
+func (h *Handlers) HandleEmailPayslip(ctx context.Context, t *asynq.Task) error {
+	var payload queue.PayslipEmailPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return err
+	}
+
+	if len(payload.PDFContent) == 0 && payload.PayslipID != "" {
+		doc, err := h.payroll.LoadPayslipDocument(ctx, payload.PayslipID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("payslip %s not found: %w", payload.PayslipID, asynq.SkipRetry)
+		}
+		if err != nil {
+			return err
+		}
+		payload.PDFContent = payroll.RenderPayslipPDF(doc)
+	}
+
 	return h.email.SendPayslip(ctx, payload.Email, payload.Name, payload.Period, payload.NetSalary, payload.PDFContent)
 }
 
+func (h *Handlers) HandleEmailWelcome(ctx context.Context, t *asynq.Task) error {
+	var payload queue.WelcomeEmailPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err := h.email.SendWelcome(ctx, payload.Email, payload.Name, payload.TempPassword, payload.LoginURL)
+	h.log.LogJobExecution(queue.TypeEmailWelcome, t.ResultWriter().TaskID(), time.Since(start), err)
+	return err
+}
+
 func (h *Handlers) HandlePayrollCalculate(ctx context.Context, t *asynq.Task) error {
 	var payload queue.PayrollPayload
 	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
@@ -185,22 +365,24 @@ func (h *Handlers) HandlePayrollCalculate(ctx context.Context, t *asynq.Task) er
 	}
 
 	h.log.WithField("period_id", payload.PeriodID).Info("Calculating payroll")
-	// Payroll calculation logic here
-	return nil
-}
 
-func (h *Handlers) HandleReportGenerate(ctx context.Context, t *asynq.Task) error {
-	var payload queue.ReportPayload
-	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+	// Only one calculation per period may run at a time; a retry that finds
+	// the lock held fails and is rescheduled by asynq.
+	lockKey := cache.KeyPayrollPrefix + "calculate:" + payload.PeriodID
+	lockValue := t.ResultWriter().TaskID()
+	locked, err := h.cache.Lock(ctx, lockKey, lockValue, payrollLockTTL)
+	if err != nil {
 		return err
 	}
+	if !locked {
+		return fmt.Errorf("payroll calculation already running for period %s", payload.PeriodID)
+	}
+	defer h.cache.Unlock(context.Background(), lockKey, lockValue)
 
-	h.log.WithFields(map[string]interface{}{
-		"report_type": payload.ReportType,
-		"format":      payload.Format,
-	}).Info("Generating report")
-	// Report generation logic here
-	return nil
+	start := time.Now()
+	err = h.calculatePayroll(ctx, payload)
+	h.log.LogJobExecution(queue.TypePayrollCalculate, t.ResultWriter().TaskID(), time.Since(start), err)
+	return err
 }
 
 func (h *Handlers) HandleNotificationSend(ctx context.Context, t *asynq.Task) error {
@@ -209,12 +391,32 @@ func (h *Handlers) HandleNotificationSend(ctx context.Context, t *asynq.Task) er
 		return err
 	}
 
-	_, err := h.db.ExecContext(ctx, `
+	var data []byte
+	if payload.Data != nil {
+		data, _ = json.Marshal(payload.Data)
+	}
+
+	var notificationID string
+	var createdAt time.Time
+	err := h.db.QueryRowContext(ctx, `
 		INSERT INTO notifications (id, user_id, title, message, type, data, created_at, updated_at)
 		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, NOW(), NOW())
-	`, payload.UserID, payload.Title, payload.Message, payload.Type, payload.Data)
+		RETURNING id, created_at
+	`, payload.UserID, payload.Title, payload.Message, payload.Type, data).Scan(&notificationID, &createdAt)
+	if err != nil {
+		return err
+	}
 
-	return err
+	h.publishNotification(ctx, payload.UserID, notificationEvent{
+		ID: notificationID, Type: payload.Type, Title: payload.Title, Message: payload.Message,
+		Data: data, CreatedAt: createdAt,
+	})
+
+	if h.cfg.Notification.EmailsType(payload.Type) {
+		// The in-app notification is stored, so a failure here must not retry this task
+		h.queueNotificationEmail(ctx, notificationID)
+	}
+	return nil
 }
 
 func (h *Handlers) HandleElasticIndex(ctx context.Context, t *asynq.Task) error {
@@ -252,10 +454,16 @@ func (h *Handlers) HandleAuditLog(ctx context.Context, t *asynq.Task) error {
 	oldJSON, _ := json.Marshal(payload.OldValues)
 	newJSON, _ := json.Marshal(payload.NewValues)
 
+	// Scheduled jobs act without a user
+	var userID interface{}
+	if payload.UserID != "" {
+		userID = payload.UserID
+	}
+
 	_, err := h.db.ExecContext(ctx, `
 		INSERT INTO audit_logs (id, user_id, action, table_name, record_id, old_values, new_values, ip_address, user_agent, created_at)
 		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, NOW())
-	`, payload.UserID, payload.Action, payload.TableName, payload.RecordID, oldJSON, newJSON, payload.IPAddress, payload.UserAgent)
+	`, userID, payload.Action, payload.TableName, payload.RecordID, oldJSON, newJSON, payload.IPAddress, payload.UserAgent)
 
 	return err
 }
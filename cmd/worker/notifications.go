@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"hr-management-system/internal/infrastructure/email"
+	"hr-management-system/internal/infrastructure/queue"
+
+	"github.com/hibiken/asynq"
+)
+
+// queueNotificationEmail records a pending email delivery for the notification
+// and queues it. Errors are logged only; the delivery row stays visible to
+// admins, who can retry it.
+func (h *Handlers) queueNotificationEmail(ctx context.Context, notificationID string) {
+	log := h.log.WithFields(map[string]interface{}{"notification_id": notificationID})
+
+	var deliveryID string
+	err := h.db.QueryRowContext(ctx, `
+		INSERT INTO notification_deliveries (id, notification_id, channel, status, attempts, created_at, updated_at)
+		VALUES (gen_random_uuid(), $1, 'email', 'pending', 0, NOW(), NOW())
+		RETURNING id
+	`, notificationID).Scan(&deliveryID)
+	if err != nil {
+		log.WithError(err).Error("Failed to record notification email delivery")
+		return
+	}
+
+	_, err = h.queue.SendNotificationEmail(ctx, queue.NotificationEmailPayload{DeliveryID: deliveryID}, h.cfg.Notification.EmailMaxRetry)
+	if err != nil {
+		log.WithError(err).Error("Failed to queue notification email")
+		h.db.ExecContext(ctx, `
+			UPDATE notification_deliveries SET status = 'failed', last_error = $2, updated_at = NOW() WHERE id = $1
+		`, deliveryID, err.Error())
+	}
+}
+
+// HandleNotificationEmail sends the email copy of a notification and records
+// the outcome. The delivery is marked failed once the last retry fails.
+func (h *Handlers) HandleNotificationEmail(ctx context.Context, t *asynq.Task) error {
+	var payload queue.NotificationEmailPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return err
+	}
+
+	var to, title, message, status string
+	err := h.db.QueryRowContext(ctx, `
+		SELECT u.email, n.title, n.message, d.status
+		FROM notification_deliveries d
+		INNER JOIN notifications n ON n.id = d.notification_id
+		INNER JOIN users u ON u.id = n.user_id
+		WHERE d.id = $1
+	`, payload.DeliveryID).Scan(&to, &title, &message, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("notification delivery %s not found: %w", payload.DeliveryID, asynq.SkipRetry)
+	}
+	if err != nil {
+		return err
+	}
+	if status == "sent" {
+		return nil
+	}
+
+	start := time.Now()
+	err = errors.New("email service is not configured")
+	if h.email != nil {
+		err = h.email.Send(ctx, email.Email{To: []string{to}, Subject: title, Body: message})
+	}
+	h.log.LogJobExecution(queue.TypeNotificationEmail, t.ResultWriter().TaskID(), time.Since(start), err)
+
+	if err == nil {
+		h.db.ExecContext(ctx, `
+			UPDATE notification_deliveries
+			SET status = 'sent', attempts = attempts + 1, last_error = NULL, delivered_at = NOW(), updated_at = NOW()
+			WHERE id = $1
+		`, payload.DeliveryID)
+		return nil
+	}
+
+	status = "retrying"
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	if retried >= maxRetry {
+		status = "failed"
+	}
+	h.db.ExecContext(ctx, `
+		UPDATE notification_deliveries
+		SET status = $2, attempts = attempts + 1, last_error = $3, updated_at = NOW()
+		WHERE id = $1
+	`, payload.DeliveryID, status, err.Error())
+	return err
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math"
+	"time"
+
+	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/payroll"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// payrollLockTTL bounds how long a crashed worker can block recalculation of a period
+const payrollLockTTL = 30 * time.Minute
+
+type payrollPeriod struct {
+	ID        uuid.UUID
+	Year      int
+	Month     int
+	StartDate time.Time
+	EndDate   time.Time
+	Status    string
+}
+
+type payslipLine struct {
+	Code    string                 `json:"code"`
+	Name    string                 `json:"name"`
+	Amount  float64                `json:"amount"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// calculatePayroll (re)builds the draft payslips of a period. It is safe to
+// run repeatedly: payslips are upserted per (employee, period), non-draft
+// payslips are never overwritten and finalized periods are skipped. A run
+// that fails puts the period back to its status before the run, so it can be
+// calculated again.
+func (h *Handlers) calculatePayroll(ctx context.Context, payload queue.PayrollPayload) error {
+	var period payrollPeriod
+	err := h.db.QueryRowContext(ctx, `
+		SELECT id, year, month, start_date, end_date, status
+		FROM payroll_periods WHERE id = $1 AND deleted_at IS NULL
+	`, payload.PeriodID).Scan(&period.ID, &period.Year, &period.Month, &period.StartDate, &period.EndDate, &period.Status)
+	if err == sql.ErrNoRows {
+		h.log.WithField("period_id", payload.PeriodID).Warn("Payroll period not found, skipping calculation")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	switch period.Status {
+	case "approved", "paid", "cancelled":
+		h.log.WithFields(map[string]interface{}{
+			"period_id": payload.PeriodID,
+			"status":    period.Status,
+		}).Info("Payroll period already finalized, skipping calculation")
+		return nil
+	}
+
+	if _, err := h.db.ExecContext(ctx, `
+		UPDATE payroll_periods SET status = 'processing', updated_at = NOW() WHERE id = $1
+	`, period.ID); err != nil {
+		return err
+	}
+
+	if err := h.calculatePayslips(ctx, period, payload.EmployeeID); err != nil {
+		// A period left processing by a crashed run goes back to draft
+		previous := period.Status
+		if previous == "processing" {
+			previous = "draft"
+		}
+		if _, resetErr := h.db.ExecContext(context.Background(), `
+			UPDATE payroll_periods SET status = $2, updated_at = NOW() WHERE id = $1 AND status = 'processing'
+		`, period.ID, previous); resetErr != nil {
+			h.log.WithError(resetErr).WithField("period_id", period.ID.String()).Error("Failed to reset payroll period status")
+		}
+		return err
+	}
+	return nil
+}
+
+// calculatePayslips calculates the payslips of a period being processed, or
+// of one employee when employeeID is set, and marks the period pending
+func (h *Handlers) calculatePayslips(ctx context.Context, period payrollPeriod, employeeID string) error {
+	// Leavers are still paid out any pending leave encashment
+	query := `
+		SELECT e.id FROM employees e
+		WHERE e.join_date <= $1 AND (
+			(e.deleted_at IS NULL AND e.employment_status IN ('active', 'on_leave'))
+			OR EXISTS (SELECT 1 FROM leave_encashments le WHERE le.employee_id = e.id AND le.status = 'pending')
+		)`
+	args := []interface{}{period.EndDate}
+	if employeeID != "" {
+		query += " AND e.id = $2"
+		args = append(args, employeeID)
+	}
+
+	rows, err := h.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	var employeeIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		employeeIDs = append(employeeIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, employeeID := range employeeIDs {
+		if err := h.calculatePayslip(ctx, period, employeeID); err != nil {
+			h.log.WithError(err).WithField("employee_id", employeeID.String()).Error("Failed to calculate payslip")
+			return err
+		}
+	}
+
+	_, err = h.db.ExecContext(ctx, `
+		UPDATE payroll_periods
+		SET status = 'pending', calculated_at = NOW(), processed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, period.ID)
+	return err
+}
+
+// calculatePayslip computes and upserts the draft payslip of one employee
+func (h *Handlers) calculatePayslip(ctx context.Context, period payrollPeriod, employeeID uuid.UUID) error {
+	var employeeCode, employeeName, departmentName, positionName, currency string
+	var baseSalary float64
+	err := h.db.QueryRowContext(ctx, `
+		SELECT e.employee_code, e.full_name, COALESCE(d.name, ''), COALESCE(p.name, ''), e.base_salary, e.salary_currency
+		FROM employees e
+		LEFT JOIN departments d ON d.id = e.department_id
+		LEFT JOIN positions p ON p.id = e.position_id
+		WHERE e.id = $1
+	`, employeeID).Scan(&employeeCode, &employeeName, &departmentName, &positionName, &baseSalary, &currency)
+	if err != nil {
+		return err
+	}
+
+	// The payslip is in the salary currency; amounts kept in other currencies
+	// are converted at the rate in effect at the end of the period
+	converter := h.payroll.NewConverter(currency)
+
+	calendar, err := h.payroll.WorkingCalendar(ctx, period.StartDate, period.EndDate)
+	if err != nil {
+		return err
+	}
+	workingDays := calendar.WorkingDays
+
+	// Salary changes during the period are paid pro rata; insurance is on the
+	// salary in effect at the end of it
+	baseSalary, insuredSalary, err := h.periodSalary(ctx, converter, calendar, employeeID, baseSalary, currency)
+	if err != nil {
+		return err
+	}
+
+	var actualDays, absentDays float64
+	err = h.db.QueryRowContext(ctx, `
+		SELECT
+			COUNT(CASE WHEN check_in IS NOT NULL THEN 1 END),
+			COUNT(CASE WHEN status = 'absent' THEN 1 END)
+		FROM attendances
+		WHERE employee_id = $1 AND date BETWEEN $2 AND $3 AND deleted_at IS NULL
+	`, employeeID, period.StartDate, period.EndDate).Scan(&actualDays, &absentDays)
+	if err != nil {
+		return err
+	}
+
+	var leaveDays float64
+	err = h.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(lr.total_days), 0)
+		FROM leave_requests lr
+		INNER JOIN leave_types lt ON lt.id = lr.leave_type_id
+		WHERE lr.employee_id = $1 AND lr.status = 'approved' AND lt.is_paid = TRUE
+		  AND lr.start_date BETWEEN $2 AND $3 AND lr.deleted_at IS NULL
+	`, employeeID, period.StartDate, period.EndDate).Scan(&leaveDays)
+	if err != nil {
+		return err
+	}
+
+	var overtimeHours, overtimeWeightedHours float64
+	err = h.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(s.hours), 0), COALESCE(SUM(s.hours * s.multiplier), 0)
+		FROM overtime_request_segments s
+		INNER JOIN overtime_requests o ON o.id = s.overtime_request_id
+		WHERE o.employee_id = $1 AND o.status IN ('approved', 'completed')
+		  AND s.date BETWEEN $2 AND $3 AND o.deleted_at IS NULL
+	`, employeeID, period.StartDate, period.EndDate).Scan(&overtimeHours, &overtimeWeightedHours)
+	if err != nil {
+		return err
+	}
+
+	var earnings, deductions []payslipLine
+
+	// Base salary is prorated on days worked plus paid leave
+	earnedBase := baseSalary
+	if workingDays > 0 {
+		paidDays := math.Min(actualDays+leaveDays, workingDays)
+		earnedBase = roundMoney(baseSalary*paidDays/workingDays, currency)
+	}
+	earnings = append(earnings, payslipLine{Code: "BASE", Name: "Lương cơ bản", Amount: earnedBase})
+
+	overtimePay := 0.0
+	if workingDays > 0 && overtimeWeightedHours > 0 {
+		hourlyRate := baseSalary / workingDays / payroll.StandardHoursPerDay
+		overtimePay = roundMoney(hourlyRate*overtimeWeightedHours, currency)
+		earnings = append(earnings, payslipLine{Code: "OT", Name: "Lương tăng ca", Amount: overtimePay})
+	}
+
+	// Employee allowances are set in the salary currency. They apply by their
+	// dates rather than their status, and one starting or ending mid-period
+	// is prorated on the working days it covers.
+	allowanceTotal, nonTaxableAllowances := 0.0, 0.0
+	allowanceRows, err := h.db.QueryContext(ctx, `
+		SELECT a.code, a.name, ea.amount, COALESCE(a.is_taxable, TRUE), ea.start_date, ea.end_date
+		FROM employee_allowances ea
+		INNER JOIN allowances a ON a.id = ea.allowance_id
+		WHERE ea.employee_id = $1 AND (ea.status = 'active' OR ea.end_date IS NOT NULL) AND a.status = 'active'
+		  AND `+payroll.EffectiveDuringSQL("ea.start_date", "ea.end_date", "$2", "$3")+`
+	`, employeeID, period.StartDate, period.EndDate)
+	if err != nil {
+		return err
+	}
+	for allowanceRows.Next() {
+		var line payslipLine
+		var taxable bool
+		var startDate time.Time
+		var endDate *time.Time
+		if err := allowanceRows.Scan(&line.Code, &line.Name, &line.Amount, &taxable, &startDate, &endDate); err != nil {
+			allowanceRows.Close()
+			return err
+		}
+		if fraction := calendar.EffectiveFraction(startDate, endDate); fraction < 1 {
+			line.Details = map[string]interface{}{"full_amount": line.Amount, "prorated": fraction}
+			line.Amount = roundMoney(line.Amount*fraction, currency)
+		}
+		allowanceTotal += line.Amount
+		if !taxable {
+			nonTaxableAllowances += line.Amount
+		}
+		earnings = append(earnings, line)
+	}
+	allowanceRows.Close()
+	if err := allowanceRows.Err(); err != nil {
+		return err
+	}
+
+	// Unused leave paid out at year end or on termination
+	otherEarnings := 0.0
+	encashmentRows, err := h.db.QueryContext(ctx, `
+		SELECT le.id, lt.code, lt.name, le.amount, le.currency
+		FROM leave_encashments le
+		INNER JOIN leave_types lt ON lt.id = le.leave_type_id
+		WHERE le.employee_id = $1 AND le.status = 'pending'
+		  AND (le.payroll_period_id IS NULL OR le.payroll_period_id = $2)
+	`, employeeID, period.ID)
+	if err != nil {
+		return err
+	}
+	var encashmentIDs []string
+	var encashments []payslipLine
+	var encashmentCurrencies []string
+	for encashmentRows.Next() {
+		var id uuid.UUID
+		var code, name, encashmentCurrency string
+		var amount float64
+		if err := encashmentRows.Scan(&id, &code, &name, &amount, &encashmentCurrency); err != nil {
+			encashmentRows.Close()
+			return err
+		}
+		encashmentIDs = append(encashmentIDs, id.String())
+		encashments = append(encashments, payslipLine{Code: "LEAVE_CASH_" + code, Name: "Thanh toán phép chưa nghỉ - " + name, Amount: amount})
+		encashmentCurrencies = append(encashmentCurrencies, encashmentCurrency)
+	}
+	encashmentRows.Close()
+	if err := encashmentRows.Err(); err != nil {
+		return err
+	}
+
+	for i, line := range encashments {
+		if line.Amount, err = converter.Convert(ctx, line.Amount, encashmentCurrencies[i], period.EndDate); err != nil {
+			return err
+		}
+		otherEarnings += line.Amount
+		earnings = append(earnings, line)
+	}
+
+	gross := earnedBase + overtimePay + allowanceTotal + otherEarnings
+
+	// Statutory insurance is computed on the contractual base salary. Fixed
+	// amounts are set in the default currency.
+	type requiredDeduction struct {
+		line        payslipLine
+		percentage  float64
+		fixedAmount float64
+	}
+	var required []requiredDeduction
+	deductionRows, err := h.db.QueryContext(ctx, `
+		SELECT code, name, COALESCE(percentage, 0), COALESCE(fixed_amount, 0)
+		FROM deductions
+		WHERE is_required = TRUE AND status = 'active' AND deleted_at IS NULL
+		  AND `+payroll.EffectiveDuringSQL("effective_from", "effective_to", "$1", "$1")+`
+	`, period.EndDate)
+	if err != nil {
+		return err
+	}
+	for deductionRows.Next() {
+		var d requiredDeduction
+		if err := deductionRows.Scan(&d.line.Code, &d.line.Name, &d.percentage, &d.fixedAmount); err != nil {
+			deductionRows.Close()
+			return err
+		}
+		required = append(required, d)
+	}
+	deductionRows.Close()
+	if err := deductionRows.Err(); err != nil {
+		return err
+	}
+
+	var socialIns, healthIns, unemploymentIns, otherDeductions float64
+	for _, d := range required {
+		fixedAmount := 0.0
+		if d.fixedAmount != 0 {
+			if fixedAmount, err = converter.Convert(ctx, d.fixedAmount, h.cfg.Payroll.DefaultCurrency(), period.EndDate); err != nil {
+				return err
+			}
+		}
+		line := d.line
+		line.Amount = roundMoney(insuredSalary*d.percentage/100+fixedAmount, currency)
+
+		switch line.Code {
+		case "SI":
+			socialIns += line.Amount
+		case "HI":
+			healthIns += line.Amount
+		case "UI":
+			unemploymentIns += line.Amount
+		default:
+			otherDeductions += line.Amount
+		}
+		deductions = append(deductions, line)
+	}
+
+	pit, err := h.personalIncomeTax(ctx, period, employeeID, currency, gross-nonTaxableAllowances, socialIns+healthIns+unemploymentIns)
+	if err != nil {
+		return err
+	}
+	if pit > 0 {
+		deductions = append(deductions, payslipLine{Code: "PIT", Name: "Thuế thu nhập cá nhân", Amount: pit})
+	}
+
+	latePenalty, err := h.latePenalty(ctx, period, employeeID, employeeName, baseSalary, currency, workingDays)
+	if err != nil {
+		return err
+	}
+	if latePenalty != nil {
+		otherDeductions += latePenalty.Amount
+		deductions = append(deductions, *latePenalty)
+	}
+
+	totalDeductions := socialIns + healthIns + unemploymentIns + pit + otherDeductions
+	net := gross - totalDeductions
+
+	earningsJSON, _ := json.Marshal(earnings)
+	deductionsJSON, _ := json.Marshal(deductions)
+
+	// Upsert keyed by (employee, period); confirmed/paid payslips are left as-is.
+	// The encashments are tied to the period only along with a payslip that
+	// pays them, and marked paid when the period is approved.
+	var payslipID uuid.UUID
+	err = h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO payslips (
+				id, employee_id, payroll_period_id, employee_code, employee_name, department_name, position_name,
+				working_days, actual_working_days, leave_days, absent_days, overtime_hours,
+				base_salary, overtime_pay, allowances, other_earnings, gross_earnings,
+				social_insurance, health_insurance, unemployment_insurance, personal_income_tax, other_deductions, total_deductions,
+				net_salary, currency, earnings_details, deductions_details, status, created_at, updated_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, 'draft', NOW(), NOW())
+			ON CONFLICT (employee_id, payroll_period_id) DO UPDATE SET
+				employee_code = EXCLUDED.employee_code, employee_name = EXCLUDED.employee_name,
+				department_name = EXCLUDED.department_name, position_name = EXCLUDED.position_name,
+				working_days = EXCLUDED.working_days, actual_working_days = EXCLUDED.actual_working_days,
+				leave_days = EXCLUDED.leave_days, absent_days = EXCLUDED.absent_days, overtime_hours = EXCLUDED.overtime_hours,
+				base_salary = EXCLUDED.base_salary, overtime_pay = EXCLUDED.overtime_pay, allowances = EXCLUDED.allowances,
+				other_earnings = EXCLUDED.other_earnings,
+				gross_earnings = EXCLUDED.gross_earnings, social_insurance = EXCLUDED.social_insurance,
+				health_insurance = EXCLUDED.health_insurance, unemployment_insurance = EXCLUDED.unemployment_insurance,
+				personal_income_tax = EXCLUDED.personal_income_tax, other_deductions = EXCLUDED.other_deductions, total_deductions = EXCLUDED.total_deductions,
+				net_salary = EXCLUDED.net_salary, currency = EXCLUDED.currency, earnings_details = EXCLUDED.earnings_details,
+				deductions_details = EXCLUDED.deductions_details, deleted_at = NULL, updated_at = NOW()
+			WHERE payslips.status = 'draft'
+			RETURNING id
+		`, uuid.New(), employeeID, period.ID, employeeCode, employeeName, departmentName, positionName,
+			workingDays, actualDays, leaveDays, absentDays, overtimeHours,
+			earnedBase, overtimePay, allowanceTotal, otherEarnings, gross,
+			socialIns, healthIns, unemploymentIns, pit, otherDeductions, totalDeductions,
+			net, currency, string(earningsJSON), string(deductionsJSON)).Scan(&payslipID)
+		if err != nil || len(encashmentIDs) == 0 {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE leave_encashments SET payroll_period_id = $1, updated_at = NOW() WHERE id = ANY($2::uuid[])
+		`, period.ID, pq.Array(encashmentIDs))
+		return err
+	})
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return h.flagPayslip(ctx, period, payslipID, employeeID, payslipFigures{
+		Net: net, Currency: currency, OvertimeHours: overtimeHours,
+	})
+}
+
+// periodSalary returns the base salary rate of the period and the salary
+// insured at its end, from the salary history converted to the payslip
+// currency. Salaries that applied to part of the period are weighted by the
+// working days they covered; attendance then prorates the rate, so a mid-month
+// joiner is not prorated twice. Without history the current salary is used.
+func (h *Handlers) periodSalary(ctx context.Context, converter *payroll.Converter, calendar *payroll.WorkingCalendar, employeeID uuid.UUID, current float64, currency string) (rate, insured float64, err error) {
+	segments, err := h.payroll.SalarySegments(ctx, employeeID, calendar.Start, calendar.End)
+	if err != nil || len(segments) == 0 {
+		return current, current, err
+	}
+
+	var weighted, days float64
+	for _, seg := range segments {
+		amount, err := converter.Convert(ctx, seg.Amount, seg.Currency, calendar.End)
+		if err != nil {
+			return 0, 0, err
+		}
+		covered := calendar.WorkingDaysBetween(seg.From, seg.To)
+		weighted += amount * covered
+		days += covered
+		insured = amount
+	}
+	if days == 0 {
+		return insured, insured, nil
+	}
+	return roundMoney(weighted/days, currency), insured, nil
+}
+
+// personalIncomeTax computes the monthly PIT of a payslip, with a family
+// deduction for each dependent eligible during the period. Brackets and family
+// deductions are in the default currency, so other salary currencies are
+// converted there and back at the end-of-period rate.
+func (h *Handlers) personalIncomeTax(ctx context.Context, period payrollPeriod, employeeID uuid.UUID, currency string, taxableEarnings, insurance float64) (float64, error) {
+	defaultCurrency := h.cfg.Payroll.DefaultCurrency()
+	rate, err := h.payroll.ExchangeRate(ctx, currency, defaultCurrency, period.EndDate)
+	if err != nil {
+		return 0, err
+	}
+	dependents, err := h.payroll.Dependents(ctx, employeeID, period.StartDate, period.EndDate)
+	if err != nil {
+		return 0, err
+	}
+
+	taxable := payroll.TaxableIncome(taxableEarnings*rate, insurance*rate, h.payroll.FamilyDeduction(ctx, dependents))
+	pit, err := h.payroll.CalculatePIT(ctx, taxable, period.Year)
+	if err != nil || pit == 0 {
+		return 0, err
+	}
+	return roundMoney(pit/rate, currency), nil
+}
+
+func roundMoney(amount float64, currency string) float64 {
+	return payroll.RoundAmount(amount, currency)
+}
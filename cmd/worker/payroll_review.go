@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"hr-management-system/internal/payroll"
+
+	"github.com/google/uuid"
+)
+
+// payslipFigures are the calculated values the review rules look at
+type payslipFigures struct {
+	Net           float64
+	Currency      string
+	OvertimeHours float64
+}
+
+// payslipFlag is one anomaly found on a payslip
+type payslipFlag struct {
+	Rule    string
+	Reason  string
+	Details map[string]interface{}
+}
+
+// payslipFlags applies the review rules of PAYROLL_FLAG_*. previousNet is the
+// net of the employee's previous payslip, nil when there is none.
+func (h *Handlers) payslipFlags(figures payslipFigures, previousNet *float64) []payslipFlag {
+	cfg := h.cfg.Payroll
+	var flags []payslipFlag
+
+	if figures.Net < 0 {
+		flags = append(flags, payslipFlag{
+			Rule:    "negative_net",
+			Reason:  fmt.Sprintf("Lương thực nhận âm (%s)", payroll.FormatAmount(figures.Net, figures.Currency)),
+			Details: map[string]interface{}{"net_salary": figures.Net},
+		})
+	}
+	if cfg.FlagOvertimeHours > 0 && figures.OvertimeHours > cfg.FlagOvertimeHours {
+		flags = append(flags, payslipFlag{
+			Rule:   "overtime_hours",
+			Reason: fmt.Sprintf("Tăng ca %g giờ, vượt ngưỡng %g giờ", figures.OvertimeHours, cfg.FlagOvertimeHours),
+			Details: map[string]interface{}{
+				"overtime_hours": figures.OvertimeHours,
+				"threshold":      cfg.FlagOvertimeHours,
+			},
+		})
+	}
+	if cfg.FlagNetChangePercent > 0 && previousNet != nil && *previousNet > 0 {
+		change := (figures.Net - *previousNet) / *previousNet * 100
+		if math.Abs(change) > cfg.FlagNetChangePercent {
+			flags = append(flags, payslipFlag{
+				Rule:   "net_change",
+				Reason: fmt.Sprintf("Lương thực nhận thay đổi %.1f%% so với kỳ trước", change),
+				Details: map[string]interface{}{
+					"previous_net":   *previousNet,
+					"net_salary":     figures.Net,
+					"change_percent": math.Round(change*10) / 10,
+					"threshold":      cfg.FlagNetChangePercent,
+				},
+			})
+		}
+	}
+	if cfg.FlagNetAbove > 0 && figures.Net > cfg.FlagNetAbove {
+		flags = append(flags, payslipFlag{
+			Rule:   "net_above",
+			Reason: fmt.Sprintf("Lương thực nhận %s vượt ngưỡng", payroll.FormatAmount(figures.Net, figures.Currency)),
+			Details: map[string]interface{}{
+				"net_salary": figures.Net,
+				"threshold":  cfg.FlagNetAbove,
+			},
+		})
+	}
+	return flags
+}
+
+// flagPayslip replaces the review flags of a recalculated draft payslip.
+// Earlier acknowledgements applied to the old figures, so they are dropped.
+func (h *Handlers) flagPayslip(ctx context.Context, period payrollPeriod, payslipID, employeeID uuid.UUID, figures payslipFigures) error {
+	var previous sql.NullFloat64
+	err := h.db.QueryRowContext(ctx, `
+		SELECT ps.net_salary FROM payslips ps
+		INNER JOIN payroll_periods pp ON pp.id = ps.payroll_period_id
+		WHERE ps.employee_id = $1 AND ps.deleted_at IS NULL AND pp.deleted_at IS NULL
+		  AND (pp.year, pp.month) < ($2, $3) AND ps.currency = $4
+		ORDER BY pp.year DESC, pp.month DESC
+		LIMIT 1
+	`, employeeID, period.Year, period.Month, figures.Currency).Scan(&previous)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	var previousNet *float64
+	if previous.Valid {
+		previousNet = &previous.Float64
+	}
+
+	if _, err := h.db.ExecContext(ctx, `DELETE FROM payslip_flags WHERE payslip_id = $1`, payslipID); err != nil {
+		return err
+	}
+	for _, flag := range h.payslipFlags(figures, previousNet) {
+		details, _ := json.Marshal(flag.Details)
+		if _, err := h.db.ExecContext(ctx, `
+			INSERT INTO payslip_flags (payslip_id, payroll_period_id, rule, reason, details)
+			VALUES ($1, $2, $3, $4, $5)
+		`, payslipID, period.ID, flag.Rule, flag.Reason, details); err != nil {
+			return err
+		}
+	}
+	return nil
+}
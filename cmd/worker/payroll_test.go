@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/testutil"
+
+	"github.com/google/uuid"
+)
+
+func newTestHandlers(t *testing.T) *Handlers {
+	t.Helper()
+	db := testutil.DB(t)
+	return NewHandlers(db, testutil.Cache(t), nil, nil, nil, testutil.Queue(t), nil, testutil.Logger(), testutil.Config(t))
+}
+
+func createPayrollPeriod(t *testing.T, h *Handlers) uuid.UUID {
+	t.Helper()
+	var id uuid.UUID
+	testutil.Must(t, h.db.QueryRow(`
+		INSERT INTO payroll_periods (name, year, month, start_date, end_date, pay_date)
+		VALUES ('03/2025', 2025, 3, '2025-03-01', '2025-03-31', '2025-04-05') RETURNING id
+	`).Scan(&id), "create payroll period")
+	return id
+}
+
+// A retried calculation upserts the payslips it made before instead of
+// adding a second one per employee
+func TestCalculatePayrollTwice(t *testing.T) {
+	h := newTestHandlers(t)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		testutil.CreateEmployee(t, h.db, testutil.EmployeeOptions{})
+	}
+	periodID := createPayrollPeriod(t, h)
+
+	payload := queue.PayrollPayload{PeriodID: periodID.String()}
+	for run := 1; run <= 2; run++ {
+		if err := h.calculatePayroll(ctx, payload); err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+	}
+
+	var employees, payslips, duplicated int
+	testutil.Must(t, h.db.QueryRow(`
+		SELECT COUNT(*) FROM employees WHERE deleted_at IS NULL AND employment_status = 'active'
+	`).Scan(&employees), "count employees")
+	testutil.Must(t, h.db.QueryRow(`
+		SELECT COUNT(*), COUNT(*) - COUNT(DISTINCT employee_id) FROM payslips WHERE payroll_period_id = $1
+	`, periodID).Scan(&payslips, &duplicated), "count payslips")
+	if payslips != employees || duplicated != 0 {
+		t.Fatalf("%d payslips (%d duplicated) for %d employees, want one each", payslips, duplicated, employees)
+	}
+
+	var status string
+	testutil.Must(t, h.db.QueryRow(`SELECT status FROM payroll_periods WHERE id = $1`, periodID).Scan(&status), "read period")
+	if status != "pending" {
+		t.Fatalf("period status = %s, want pending", status)
+	}
+}
+
+// A failed calculation does not leave the period stuck in processing
+func TestCalculatePayrollFailureResetsPeriod(t *testing.T) {
+	h := newTestHandlers(t)
+	testutil.CreateEmployee(t, h.db, testutil.EmployeeOptions{})
+	periodID := createPayrollPeriod(t, h)
+
+	// Break the overtime query the payslips depend on
+	_, err := h.db.Exec(`ALTER TABLE overtime_request_segments RENAME TO overtime_request_segments_gone`)
+	testutil.Must(t, err, "break overtime segments")
+
+	if err := h.calculatePayroll(context.Background(), queue.PayrollPayload{PeriodID: periodID.String()}); err == nil {
+		t.Fatal("calculation succeeded without the overtime segments")
+	}
+
+	var status string
+	var payslips int
+	testutil.Must(t, h.db.QueryRow(`SELECT status FROM payroll_periods WHERE id = $1`, periodID).Scan(&status), "read period")
+	testutil.Must(t, h.db.QueryRow(`SELECT COUNT(*) FROM payslips WHERE payroll_period_id = $1`, periodID).Scan(&payslips),
+		"count payslips")
+	if status != "draft" {
+		t.Fatalf("period status = %s after a failed run, want draft", status)
+	}
+	if payslips != 0 {
+		t.Fatalf("%d payslips written from a failed query", payslips)
+	}
+}
+
+// Encashments are linked to a period only by a payslip that pays them
+func TestCalculatePayrollLinksEncashments(t *testing.T) {
+	h := newTestHandlers(t)
+	ctx := context.Background()
+	paid := testutil.CreateEmployee(t, h.db, testutil.EmployeeOptions{})
+	confirmed := testutil.CreateEmployee(t, h.db, testutil.EmployeeOptions{})
+	periodID := createPayrollPeriod(t, h)
+	payload := queue.PayrollPayload{PeriodID: periodID.String()}
+
+	testutil.Must(t, h.calculatePayroll(ctx, payload), "first run")
+	_, err := h.db.Exec(`UPDATE payslips SET status = 'confirmed' WHERE employee_id = $1`, confirmed.ID)
+	testutil.Must(t, err, "confirm payslip")
+
+	encashment := func(employee testutil.Employee) uuid.UUID {
+		var id uuid.UUID
+		testutil.Must(t, h.db.QueryRow(`
+			INSERT INTO leave_encashments (employee_id, leave_type_id, year, reason, days, daily_rate, amount)
+			VALUES ($1, $2, 2024, 'year_end', 2, 500000, 1000000) RETURNING id
+		`, employee.ID, testutil.LeaveTypeAnnual).Scan(&id), "create encashment")
+		return id
+	}
+	paidID, confirmedID := encashment(paid), encashment(confirmed)
+
+	testutil.Must(t, h.calculatePayroll(ctx, payload), "second run")
+
+	linked := func(id uuid.UUID) bool {
+		var periodID *uuid.UUID
+		testutil.Must(t, h.db.QueryRow(`SELECT payroll_period_id FROM leave_encashments WHERE id = $1`, id).Scan(&periodID),
+			"read encashment")
+		return periodID != nil
+	}
+	if !linked(paidID) {
+		t.Error("encashment of the draft payslip was not linked to the period")
+	}
+	if linked(confirmedID) {
+		t.Error("encashment was linked to a period whose payslip was already confirmed")
+	}
+
+	var otherEarnings float64
+	testutil.Must(t, h.db.QueryRow(`
+		SELECT other_earnings FROM payslips WHERE employee_id = $1 AND payroll_period_id = $2
+	`, paid.ID, periodID).Scan(&otherEarnings), "read payslip")
+	if otherEarnings != 1000000 {
+		t.Errorf("other earnings = %g, want the 1000000 encashment", otherEarnings)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"hr-management-system/internal/infrastructure/queue"
+
+	"github.com/hibiken/asynq"
+)
+
+// reconcileBatchSize is the bulk request size when none is configured
+const reconcileBatchSize = 500
+
+// reconcileResult is written as the task result and logged
+type reconcileResult struct {
+	Index      string `json:"index"`
+	DBCount    int    `json:"db_count"`
+	IndexCount int    `json:"index_count"`
+	Missing    int    `json:"missing"`
+	Stale      int    `json:"stale"`
+	Deleted    int    `json:"deleted"`
+}
+
+// HandleElasticReconcile compares the employees index with the database:
+// documents missing from the index or older than the row are re-indexed, and
+// documents of deleted employees are removed. It catches drift left behind by
+// failed index tasks that the daily incremental sync never revisits.
+func (h *Handlers) HandleElasticReconcile(ctx context.Context, t *asynq.Task) error {
+	if h.es == nil {
+		return nil
+	}
+
+	var payload queue.ElasticReconcilePayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return err
+	}
+	if payload.Index != "employees" {
+		return fmt.Errorf("reconcile not supported for index %q: %w", payload.Index, asynq.SkipRetry)
+	}
+
+	indexed, err := h.es.DocumentTimestamps(ctx, payload.Index, "updated_at")
+	if err != nil {
+		return err
+	}
+
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT e.id, e.employee_code, e.full_name, u.email, e.department_id, COALESCE(d.name, ''),
+		       e.position_id, COALESCE(p.name, ''), e.employment_status, e.employment_type, e.join_date, e.updated_at
+		FROM employees e
+		INNER JOIN users u ON u.id = e.user_id
+		LEFT JOIN departments d ON d.id = e.department_id
+		LEFT JOIN positions p ON p.id = e.position_id
+		WHERE e.deleted_at IS NULL
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	result := reconcileResult{Index: payload.Index, IndexCount: len(indexed)}
+	docs := make(map[string]interface{})
+	live := make(map[string]bool)
+	now := time.Now()
+
+	for rows.Next() {
+		var id, code, name, email, deptID, deptName, posID, posName, status, empType string
+		var joinDate, updatedAt time.Time
+		if err := rows.Scan(&id, &code, &name, &email, &deptID, &deptName, &posID, &posName,
+			&status, &empType, &joinDate, &updatedAt); err != nil {
+			return err
+		}
+		result.DBCount++
+		live[id] = true
+
+		// updated_at is stored as local wall-clock time
+		rowUpdated := time.Date(updatedAt.Year(), updatedAt.Month(), updatedAt.Day(),
+			updatedAt.Hour(), updatedAt.Minute(), updatedAt.Second(), updatedAt.Nanosecond(), time.Local)
+		indexedAt, ok := indexed[id]
+		switch {
+		case !ok:
+			result.Missing++
+		case indexedAt.Before(rowUpdated):
+			result.Stale++
+		default:
+			continue
+		}
+
+		docs[id] = map[string]interface{}{
+			"id": id, "employee_code": code, "full_name": name, "email": email,
+			"department_id": deptID, "department_name": deptName, "position_id": posID,
+			"position_name": posName, "employment_status": status, "employment_type": empType,
+			"join_date": joinDate, "updated_at": now,
+		}
+		if len(docs) >= h.bulkBatchSize() {
+			if err := h.es.BulkIndex(ctx, payload.Index, docs); err != nil {
+				return err
+			}
+			docs = make(map[string]interface{})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(docs) > 0 {
+		if err := h.es.BulkIndex(ctx, payload.Index, docs); err != nil {
+			return err
+		}
+	}
+
+	var orphans []string
+	for id := range indexed {
+		if !live[id] {
+			orphans = append(orphans, id)
+		}
+	}
+	for start := 0; start < len(orphans); start += h.bulkBatchSize() {
+		end := start + h.bulkBatchSize()
+		if end > len(orphans) {
+			end = len(orphans)
+		}
+		if err := h.es.BulkDelete(ctx, payload.Index, orphans[start:end]); err != nil {
+			return err
+		}
+	}
+	result.Deleted = len(orphans)
+
+	h.log.WithFields(map[string]interface{}{
+		"index":        result.Index,
+		"db_count":     result.DBCount,
+		"index_count":  result.IndexCount,
+		"missing":      result.Missing,
+		"stale":        result.Stale,
+		"deleted":      result.Deleted,
+		"requested_by": payload.RequestedBy,
+	}).Info("Elasticsearch reconcile completed")
+
+	data, _ := json.Marshal(result)
+	t.ResultWriter().Write(data)
+	return nil
+}
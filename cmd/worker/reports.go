@@ -0,0 +1,419 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"hr-management-system/internal/infrastructure/email"
+	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/report"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+// errReportFilter marks filters a report cannot be generated with, which no
+// retry will fix
+var errReportFilter = errors.New("invalid report filter")
+
+// HandleReportGenerate renders a report into the requested format, stores the
+// file and records it on the report's row, where the API reads its status
+// and download. The file is emailed when the payload names a recipient. The
+// report is marked failed once the last retry fails.
+func (h *Handlers) HandleReportGenerate(ctx context.Context, t *asynq.Task) error {
+	var payload queue.ReportPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return err
+	}
+
+	reportID, err := h.startReport(ctx, t.ResultWriter().TaskID(), payload)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("report %s not found: %w", payload.ReportID, asynq.SkipRetry)
+	}
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err = h.generateReport(ctx, reportID, payload)
+	h.log.LogJobExecution(queue.TypeReportGenerate, t.ResultWriter().TaskID(), time.Since(start), err)
+	if err == nil {
+		return nil
+	}
+
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	final := retried >= maxRetry || errors.Is(err, asynq.SkipRetry)
+	if final {
+		h.db.ExecContext(ctx, `
+			UPDATE reports SET status = 'failed', error = $2, completed_at = NOW(), updated_at = NOW() WHERE id = $1
+		`, reportID, err.Error())
+	} else {
+		h.db.ExecContext(ctx, `UPDATE reports SET error = $2, updated_at = NOW() WHERE id = $1`, reportID, err.Error())
+	}
+	return err
+}
+
+// startReport marks the report processing and returns its id. Scheduled
+// reports have no row yet; one is created per task, so retries reuse it.
+func (h *Handlers) startReport(ctx context.Context, taskID string, payload queue.ReportPayload) (uuid.UUID, error) {
+	var id uuid.UUID
+	if payload.ReportID != "" {
+		err := h.db.QueryRowContext(ctx, `
+			UPDATE reports SET status = 'processing', started_at = NOW(), updated_at = NOW()
+			WHERE id::text = $1
+			RETURNING id
+		`, payload.ReportID).Scan(&id)
+		return id, err
+	}
+
+	filters, _ := json.Marshal(payload.Filters)
+	var to interface{}
+	if payload.Email != "" {
+		to = payload.Email
+	}
+	err := h.db.QueryRowContext(ctx, `
+		INSERT INTO reports (report_type, format, filters, status, task_id, email, started_at, created_at, updated_at)
+		VALUES ($1, $2, $3, 'processing', $4, $5, NOW(), NOW(), NOW())
+		ON CONFLICT (task_id) DO UPDATE SET status = 'processing', started_at = NOW(), updated_at = NOW()
+		RETURNING id
+	`, payload.ReportType, payload.Format, filters, taskID, to).Scan(&id)
+	return id, err
+}
+
+// generateReport builds the report table, stores the rendered file and
+// emails it
+func (h *Handlers) generateReport(ctx context.Context, reportID uuid.UUID, payload queue.ReportPayload) error {
+	// Reject formats that were unregistered since the task was queued
+	formatter, ok := report.Lookup(payload.Format)
+	if !ok {
+		return fmt.Errorf("unsupported report format %q: %w", payload.Format, asynq.SkipRetry)
+	}
+
+	table, err := h.reportTable(ctx, payload.ReportType, payload.Filters)
+	if errors.Is(err, errReportFilter) {
+		return fmt.Errorf("%w: %w", err, asynq.SkipRetry)
+	}
+	if err != nil {
+		return err
+	}
+
+	var file bytes.Buffer
+	if err := formatter.Format(&file, table); err != nil {
+		return err
+	}
+
+	fileName := fmt.Sprintf("%s_%s%s", payload.ReportType, time.Now().Format("20060102_150405"), formatter.Extension())
+	key := "reports/" + reportID.String() + "/" + fileName
+	if err := h.store.Put(ctx, key, bytes.NewReader(file.Bytes()), formatter.ContentType()); err != nil {
+		return err
+	}
+
+	_, err = h.db.ExecContext(ctx, `
+		UPDATE reports
+		SET status = 'completed', file_key = $2, file_name = $3, content_type = $4, file_size = $5,
+		    row_count = $6, error = NULL, completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, reportID, key, fileName, formatter.ContentType(), file.Len(), len(table.Rows))
+	if err != nil {
+		return err
+	}
+
+	h.log.WithFields(map[string]interface{}{
+		"report_id":   reportID,
+		"report_type": payload.ReportType,
+		"format":      formatter.Name(),
+		"rows":        len(table.Rows),
+	}).Info("Report generated")
+
+	// The report is stored and downloadable, so a failed email is only logged
+	if payload.Email != "" {
+		if err := h.emailReport(ctx, payload.Email, table.Title, fileName, formatter.ContentType(), file.Bytes()); err != nil {
+			h.log.WithError(err).WithField("report_id", reportID).Warn("Failed to email report")
+		} else {
+			h.db.ExecContext(ctx, `UPDATE reports SET emailed_at = NOW() WHERE id = $1`, reportID)
+		}
+	}
+	return nil
+}
+
+func (h *Handlers) emailReport(ctx context.Context, to, title, fileName, contentType string, content []byte) error {
+	if h.email == nil {
+		return errors.New("email service is not configured")
+	}
+	return h.email.Send(ctx, email.Email{
+		To:      []string{to},
+		Subject: fmt.Sprintf("Báo cáo: %s", title),
+		Body:    fmt.Sprintf("Báo cáo \"%s\" được đính kèm trong email này.", title),
+		Attachments: []email.Attachment{
+			{Filename: fileName, Content: content, MimeType: contentType},
+		},
+	})
+}
+
+// reportTable queries the rows of a report type
+func (h *Handlers) reportTable(ctx context.Context, reportType string, filters map[string]interface{}) (*report.Table, error) {
+	switch reportType {
+	case report.TypeDailyAttendance:
+		return h.dailyAttendanceReport(ctx, filters)
+	case report.TypeWeeklyAttendance:
+		return h.attendanceSummaryReport(ctx, filters)
+	case report.TypePayroll:
+		return h.payrollReport(ctx, filters)
+	case report.TypeLeave:
+		return h.leaveReport(ctx, filters)
+	}
+	return nil, fmt.Errorf("%w: unknown report type %q", errReportFilter, reportType)
+}
+
+// dailyAttendanceReport lists every active employee's attendance on one day,
+// ?date= defaulting to today
+func (h *Handlers) dailyAttendanceReport(ctx context.Context, filters map[string]interface{}) (*report.Table, error) {
+	date, err := reportDate(filters, "date", time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT e.employee_code, e.full_name, COALESCE(d.name, ''), a.check_in, a.check_out,
+		       COALESCE(a.working_hours, 0), COALESCE(a.overtime_hours, 0), COALESCE(a.status, 'no_record')
+		FROM employees e
+		LEFT JOIN departments d ON d.id = e.department_id
+		LEFT JOIN attendances a ON a.employee_id = e.id AND a.date = $1 AND a.deleted_at IS NULL
+		WHERE e.deleted_at IS NULL AND e.employment_status = 'active' AND e.join_date <= $1
+		  AND ($2 = '' OR e.department_id::text = $2)
+		ORDER BY d.name NULLS LAST, e.employee_code
+	`, date, reportFilter(filters, "department_id"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	table := &report.Table{
+		Title:   "Daily Attendance " + date.Format("2006-01-02"),
+		Columns: []string{"Employee Code", "Full Name", "Department", "Check In", "Check Out", "Working Hours", "Overtime Hours", "Status"},
+		Rows:    [][]string{},
+	}
+	for rows.Next() {
+		var code, name, department, status string
+		var checkIn, checkOut sql.NullTime
+		var working, overtime float64
+		if err := rows.Scan(&code, &name, &department, &checkIn, &checkOut, &working, &overtime, &status); err != nil {
+			return nil, err
+		}
+		table.Rows = append(table.Rows, []string{
+			code, name, department, reportTime(checkIn), reportTime(checkOut),
+			reportNumber(working), reportNumber(overtime), status,
+		})
+	}
+	return table, rows.Err()
+}
+
+// attendanceSummaryReport totals each active employee's attendance between
+// start_date and end_date, the last seven days by default
+func (h *Handlers) attendanceSummaryReport(ctx context.Context, filters map[string]interface{}) (*report.Table, error) {
+	now := time.Now()
+	startDate, endDate, err := reportRange(filters, now.AddDate(0, 0, -7), now)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT e.employee_code, e.full_name, COALESCE(d.name, ''),
+		       COUNT(a.id) FILTER (WHERE a.status IN ('present', 'late', 'early_leave', 'half_day')),
+		       COUNT(a.id) FILTER (WHERE a.status = 'late'),
+		       COUNT(a.id) FILTER (WHERE a.status = 'early_leave'),
+		       COUNT(a.id) FILTER (WHERE a.status = 'absent'),
+		       COUNT(a.id) FILTER (WHERE a.status = 'on_leave'),
+		       COALESCE(SUM(a.working_hours), 0), COALESCE(SUM(a.overtime_hours), 0)
+		FROM employees e
+		LEFT JOIN departments d ON d.id = e.department_id
+		LEFT JOIN attendances a ON a.employee_id = e.id AND a.date BETWEEN $1 AND $2 AND a.deleted_at IS NULL
+		WHERE e.deleted_at IS NULL AND e.employment_status = 'active' AND e.join_date <= $2
+		  AND ($3 = '' OR e.department_id::text = $3)
+		GROUP BY e.id, e.employee_code, e.full_name, d.name
+		ORDER BY d.name NULLS LAST, e.employee_code
+	`, startDate, endDate, reportFilter(filters, "department_id"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	table := &report.Table{
+		Title: fmt.Sprintf("Attendance Summary %s - %s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02")),
+		Columns: []string{"Employee Code", "Full Name", "Department", "Days Worked", "Late", "Early Leave",
+			"Absent", "On Leave", "Working Hours", "Overtime Hours"},
+		Rows: [][]string{},
+	}
+	for rows.Next() {
+		var code, name, department string
+		var worked, late, early, absent, onLeave int
+		var working, overtime float64
+		if err := rows.Scan(&code, &name, &department, &worked, &late, &early, &absent, &onLeave, &working, &overtime); err != nil {
+			return nil, err
+		}
+		table.Rows = append(table.Rows, []string{
+			code, name, department, strconv.Itoa(worked), strconv.Itoa(late), strconv.Itoa(early),
+			strconv.Itoa(absent), strconv.Itoa(onLeave), reportNumber(working), reportNumber(overtime),
+		})
+	}
+	return table, rows.Err()
+}
+
+// payrollReport lists the payslips of a payroll period, ?period_id=
+// defaulting to the latest period that is not cancelled
+func (h *Handlers) payrollReport(ctx context.Context, filters map[string]interface{}) (*report.Table, error) {
+	var periodID uuid.UUID
+	var periodName string
+	err := h.db.QueryRowContext(ctx, `
+		SELECT id, name FROM payroll_periods
+		WHERE deleted_at IS NULL AND status <> 'cancelled' AND ($1 = '' OR id::text = $1)
+		ORDER BY start_date DESC
+		LIMIT 1
+	`, reportFilter(filters, "period_id")).Scan(&periodID, &periodName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: payroll period not found", errReportFilter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT ps.employee_code, ps.employee_name, COALESCE(ps.department_name, ''),
+		       ps.working_days, ps.actual_working_days, ps.overtime_hours,
+		       ps.base_salary, ps.gross_earnings,
+		       ps.social_insurance + ps.health_insurance + ps.unemployment_insurance,
+		       ps.personal_income_tax, ps.total_deductions, ps.net_salary, ps.status
+		FROM payslips ps
+		INNER JOIN employees e ON e.id = ps.employee_id
+		WHERE ps.payroll_period_id = $1 AND ps.deleted_at IS NULL
+		  AND ($2 = '' OR e.department_id::text = $2)
+		ORDER BY ps.department_name NULLS LAST, ps.employee_code
+	`, periodID, reportFilter(filters, "department_id"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	table := &report.Table{
+		Title: "Payroll " + periodName,
+		Columns: []string{"Employee Code", "Full Name", "Department", "Working Days", "Days Worked", "Overtime Hours",
+			"Base Salary", "Gross Earnings", "Insurance", "Income Tax", "Total Deductions", "Net Salary", "Status"},
+		Rows: [][]string{},
+	}
+	for rows.Next() {
+		var code, name, department, status string
+		var workingDays, actualDays, overtime, base, gross, insurance, tax, deductions, net float64
+		if err := rows.Scan(&code, &name, &department, &workingDays, &actualDays, &overtime,
+			&base, &gross, &insurance, &tax, &deductions, &net, &status); err != nil {
+			return nil, err
+		}
+		table.Rows = append(table.Rows, []string{
+			code, name, department, reportNumber(workingDays), reportNumber(actualDays), reportNumber(overtime),
+			reportNumber(base), reportNumber(gross), reportNumber(insurance), reportNumber(tax),
+			reportNumber(deductions), reportNumber(net), status,
+		})
+	}
+	return table, rows.Err()
+}
+
+// leaveReport lists the leave requests overlapping start_date to end_date,
+// this month so far by default, optionally of one status
+func (h *Handlers) leaveReport(ctx context.Context, filters map[string]interface{}) (*report.Table, error) {
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	startDate, endDate, err := reportRange(filters, monthStart, now)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT e.employee_code, e.full_name, COALESCE(d.name, ''), lt.name,
+		       lr.start_date, lr.end_date, lr.total_days, lr.status, lr.created_at
+		FROM leave_requests lr
+		INNER JOIN employees e ON e.id = lr.employee_id
+		INNER JOIN leave_types lt ON lt.id = lr.leave_type_id
+		LEFT JOIN departments d ON d.id = e.department_id
+		WHERE lr.deleted_at IS NULL AND lr.start_date <= $2 AND lr.end_date >= $1
+		  AND ($3 = '' OR e.department_id::text = $3)
+		  AND ($4 = '' OR lr.status = $4)
+		ORDER BY lr.start_date, e.employee_code
+	`, startDate, endDate, reportFilter(filters, "department_id"), reportFilter(filters, "status"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	table := &report.Table{
+		Title: fmt.Sprintf("Leave %s - %s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02")),
+		Columns: []string{"Employee Code", "Full Name", "Department", "Leave Type", "Start Date", "End Date",
+			"Days", "Status", "Requested At"},
+		Rows: [][]string{},
+	}
+	for rows.Next() {
+		var code, name, department, leaveType, status string
+		var start, end, requestedAt time.Time
+		var days float64
+		if err := rows.Scan(&code, &name, &department, &leaveType, &start, &end, &days, &status, &requestedAt); err != nil {
+			return nil, err
+		}
+		table.Rows = append(table.Rows, []string{
+			code, name, department, leaveType, start.Format("2006-01-02"), end.Format("2006-01-02"),
+			reportNumber(days), status, requestedAt.Format("2006-01-02 15:04"),
+		})
+	}
+	return table, rows.Err()
+}
+
+// reportFilter returns a filter as a string, empty when it is not set
+func reportFilter(filters map[string]interface{}, key string) string {
+	value, ok := filters[key]
+	if !ok || value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+func reportDate(filters map[string]interface{}, key string, fallback time.Time) (time.Time, error) {
+	value := reportFilter(filters, key)
+	if value == "" {
+		return time.Date(fallback.Year(), fallback.Month(), fallback.Day(), 0, 0, 0, 0, time.Local), nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s %q", errReportFilter, key, value)
+	}
+	return date, nil
+}
+
+func reportRange(filters map[string]interface{}, from, to time.Time) (time.Time, time.Time, error) {
+	startDate, err := reportDate(filters, "start_date", from)
+	if err != nil {
+		return startDate, startDate, err
+	}
+	endDate, err := reportDate(filters, "end_date", to)
+	if err != nil {
+		return startDate, endDate, err
+	}
+	if endDate.Before(startDate) {
+		return startDate, endDate, fmt.Errorf("%w: end_date is before start_date", errReportFilter)
+	}
+	return startDate, endDate, nil
+}
+
+func reportTime(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.Format("15:04")
+}
+
+func reportNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
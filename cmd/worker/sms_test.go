@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/i18n"
+	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/testutil"
+)
+
+type sentSMS struct {
+	to, body string
+}
+
+// recordingSender keeps what it is asked to send, failing with err if set
+type recordingSender struct {
+	sent []sentSMS
+	err  error
+}
+
+func (s *recordingSender) Send(ctx context.Context, to, body string) error {
+	s.sent = append(s.sent, sentSMS{to, body})
+	return s.err
+}
+
+func TestSendSMSOTP(t *testing.T) {
+	if _, err := i18n.New("vi"); err != nil {
+		t.Fatalf("init i18n: %v", err)
+	}
+	cfg := &config.Config{Security: config.SecurityConfig{OTPExpiry: 5 * time.Minute}}
+
+	tests := []struct {
+		language string
+		want     string
+	}{
+		{"vi", "Ma xac thuc cua ban la 482913, hieu luc trong 5 phut."},
+		{"en", "Your verification code is 482913. It expires in 5 minutes."},
+		{"fr", "Ma xac thuc cua ban la 482913, hieu luc trong 5 phut."},
+	}
+	for _, tt := range tests {
+		sender := &recordingSender{}
+		h := &Handlers{sms: sender, log: testutil.Logger(), cfg: cfg}
+		err := h.sendSMSOTP(context.Background(), queue.OTPPayload{Phone: "+84901234567", OTP: "482913", Type: "login", Language: tt.language})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.language, err)
+		}
+		if len(sender.sent) != 1 || sender.sent[0].to != "+84901234567" {
+			t.Fatalf("%s: sent %v, want one message to +84901234567", tt.language, sender.sent)
+		}
+		if !strings.HasPrefix(sender.sent[0].body, tt.want) {
+			t.Errorf("%s: body %q, want it to start with %q", tt.language, sender.sent[0].body, tt.want)
+		}
+	}
+}
+
+// A failed send is returned so asynq retries the task
+func TestSendSMSOTPFailure(t *testing.T) {
+	sendErr := errors.New("provider down")
+	h := &Handlers{sms: &recordingSender{err: sendErr}, log: testutil.Logger(), cfg: &config.Config{}}
+	if err := h.sendSMSOTP(context.Background(), queue.OTPPayload{Phone: "+84901234567", OTP: "482913"}); !errors.Is(err, sendErr) {
+		t.Errorf("error = %v, want %v", err, sendErr)
+	}
+}
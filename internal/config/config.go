@@ -4,22 +4,33 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	App        AppConfig
-	Database   DatabaseConfig
-	Redis      RedisConfig
-	JWT        JWTConfig
-	Email      EmailConfig
-	Elastic    ElasticConfig
-	RateLimit  RateLimitConfig
-	Security   SecurityConfig
-	Logger     LoggerConfig
-	Worker     WorkerConfig
+	App          AppConfig
+	Database     DatabaseConfig
+	Redis        RedisConfig
+	JWT          JWTConfig
+	Email        EmailConfig
+	SMS          SMSConfig
+	Elastic      ElasticConfig
+	RateLimit    RateLimitConfig
+	Security     SecurityConfig
+	Logger       LoggerConfig
+	Worker       WorkerConfig
+	Leave        LeaveConfig
+	Attendance   AttendanceConfig
+	Overtime     OvertimeConfig
+	Employee     EmployeeConfig
+	Onboarding   OnboardingConfig
+	Notification NotificationConfig
+	Payroll      PayrollConfig
+	Scheduler    SchedulerConfig
+	Storage      StorageConfig
 }
 
 type AppConfig struct {
@@ -30,6 +41,10 @@ type AppConfig struct {
 	BaseURL     string
 	FrontendURL string
 	Timezone    string
+	// RequestTimeout bounds ordinary API requests, LongRequestTimeout
+	// exports, imports, search and reports
+	RequestTimeout     time.Duration
+	LongRequestTimeout time.Duration
 }
 
 type DatabaseConfig struct {
@@ -42,6 +57,23 @@ type DatabaseConfig struct {
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
+	// Page size caps for list endpoints; the export cap applies to callers
+	// holding the module's export permission
+	MaxPageSize       int
+	ExportMaxPageSize int
+	// Tables whose lists estimate their total by default instead of running
+	// COUNT(*); ?count=exact|estimate|none overrides it per request
+	CountEstimateTables []string
+}
+
+// CountModeFor returns the default count mode of lists over a table
+func (c DatabaseConfig) CountModeFor(table string) string {
+	for _, t := range c.CountEstimateTables {
+		if t == table {
+			return "estimate"
+		}
+	}
+	return "exact"
 }
 
 type RedisConfig struct {
@@ -62,6 +94,11 @@ type JWTConfig struct {
 	RefreshTokenExpiry  time.Duration
 	Issuer              string
 	Audience            string
+	// Cookie mode also sets the tokens as HttpOnly cookies; bearer headers keep working
+	CookieMode          bool
+	CookieDomain        string
+	CookieSecure        bool
+	CookieSameSite      string
 }
 
 type EmailConfig struct {
@@ -74,11 +111,25 @@ type EmailConfig struct {
 	EnableTLS  bool
 }
 
+// SMSConfig selects the text message provider: twilio, log or noop
+type SMSConfig struct {
+	Provider         string
+	From             string
+	TwilioAccountSID string
+	TwilioAuthToken  string
+	Timeout          time.Duration
+}
+
 type ElasticConfig struct {
 	URLs     []string
 	Username string
 	Password string
 	Index    string
+	// BulkBatchSize bounds the documents sent in one bulk index request
+	BulkBatchSize int
+	// SearchAfterThreshold is the result offset past which search pages by
+	// cursor (search_after) instead of page number
+	SearchAfterThreshold int
 }
 
 type RateLimitConfig struct {
@@ -87,6 +138,11 @@ type RateLimitConfig struct {
 	RequestsPerHour   int
 	BurstSize         int
 	BlockDuration     time.Duration
+	// While Redis is unreachable limits are kept in process when
+	// FallbackEnabled; otherwise the route classes (global, auth, endpoint)
+	// in FailClosed refuse requests and the others let them through
+	FallbackEnabled   bool
+	FailClosed        []string
 }
 
 type SecurityConfig struct {
@@ -102,6 +158,36 @@ type SecurityConfig struct {
 	TrustedProxies       []string
 	EnableIPWhitelist    bool
 	IPWhitelist          []string
+	PayrollIPFilter      IPFilterConfig
+	RolesIPFilter        IPFilterConfig
+	// Optional check of new passwords against a k-anonymity breach range API
+	BreachCheckEnabled   bool
+	BreachCheckURL       string
+	BreachCheckTimeout   time.Duration
+	BreachCheckCacheTTL  time.Duration
+	// Request header carrying the client location set by a proxy or CDN
+	// (e.g. CF-IPCountry), recorded with login attempts
+	GeoIPHeader          string
+	// TOTP two-factor: secrets are stored encrypted with TwoFactorKey
+	TwoFactorKey         string
+	TOTPIssuer           string
+	TOTPSkew             int
+	RecoveryCodeCount    int
+	// Lets approvers act on their own leave, overtime and attendance
+	// requests, for organisations too small to route them elsewhere
+	AllowSelfApproval    bool
+}
+
+// IPFilterConfig restricts a route group to client IPs or CIDR ranges.
+// Deny wins over allow; an empty allow list admits every address not denied.
+type IPFilterConfig struct {
+	Allow []string
+	Deny  []string
+}
+
+// Enabled reports whether the filter has any rule
+func (c IPFilterConfig) Enabled() bool {
+	return len(c.Allow) > 0 || len(c.Deny) > 0
 }
 
 type LoggerConfig struct {
@@ -113,14 +199,250 @@ type LoggerConfig struct {
 	MaxBackups int
 	MaxAge     int
 	Compress   bool
+
+	// Request/response body logging for debugging. Never enabled in production.
+	DebugBodies      bool
+	DebugRoutes      []string
+	DebugToken       string
+	DebugMaxBodySize int
 }
 
 type WorkerConfig struct {
-	Concurrency  int
-	RedisAddr    string
-	RetryMax     int
-	RetryDelay   time.Duration
-	Queues       map[string]int
+	Concurrency    int
+	RedisAddr      string
+	RetryMax       int
+	RetryDelay     time.Duration
+	Queues         map[string]int
+	ReloadInterval time.Duration
+	Alert          AlertConfig
+}
+
+type AlertConfig struct {
+	Email             string
+	WebhookURL        string
+	ArchivedThreshold int
+	DedupWindow       time.Duration
+	CheckInterval     time.Duration
+}
+
+type LeaveConfig struct {
+	AutoApproveMaxDays float64
+	YearEndEncashment  bool
+}
+
+type AttendanceConfig struct {
+	MinRestPeriod time.Duration
+	RestWarnOnly  bool
+	// ForgotCheckout is "close", "regularize" or "off"
+	ForgotCheckout    string
+	DefaultShiftStart string
+	DefaultShiftEnd   string
+
+	// Late arrivals: a check-in counts as late when it comes more than the
+	// late_threshold_minutes setting after the shift start. LateGraceMinutes
+	// applies when that setting is unset. Crossing either threshold in a
+	// payroll period triggers the penalty. A threshold of 0 is disabled.
+	LateGraceMinutes     int
+	LateCountThreshold   int
+	LateMinutesThreshold int
+	LateDeductPay        bool
+	LateNotifyHR         bool
+
+	// OfficeNetworks (CIDRs) restricts office check-ins to the office
+	// network; empty disables the check. Remote days are never restricted.
+	OfficeNetworks []string
+	// Remote days: declared days need RemoteMinNoticeDays of notice and are
+	// capped at RemoteMaxDaysPerMonth (0 = no cap). An empty
+	// RemoteShiftStart makes remote days flexible (never late).
+	RemoteMaxDaysPerMonth int
+	RemoteMinNoticeDays   int
+	RemoteShiftStart      string
+	RemoteExpectedHours   float64
+
+	// LeaveStatus writes on_leave/half_day attendance for approved leave when
+	// it is decided; the nightly backfill reconciles the last
+	// LeaveBackfillDays days
+	LeaveStatus       bool
+	LeaveBackfillDays int
+
+	// MarkAbsentees records employees without attendance on a working day as
+	// absent once their shift has ended
+	MarkAbsentees bool
+}
+
+type OvertimeConfig struct {
+	NightStart     string
+	NightEnd       string
+	TypePrecedence []string
+}
+
+type NotificationConfig struct {
+	// EmailTypes are the notification types also delivered by email
+	EmailTypes    []string
+	EmailMaxRetry int
+	// Broadcasts insert BroadcastBatchSize notifications per statement, with
+	// up to BroadcastConcurrency statements in flight
+	BroadcastBatchSize   int
+	BroadcastConcurrency int
+}
+
+type PayrollConfig struct {
+	// Currencies salaries may be paid in; the first one is the default
+	Currencies []string
+	// ReportingCurrency is what aggregate reports convert amounts to
+	ReportingCurrency string
+
+	// ReviewRequired blocks approving a period while flagged payslips are
+	// unreviewed. Payslips with a negative net are always flagged; the other
+	// rules are off at 0.
+	ReviewRequired       bool
+	FlagOvertimeHours    float64
+	FlagNetChangePercent float64
+	FlagNetAbove         float64
+}
+
+// DefaultCurrency is the currency of salaries entered without one
+func (c PayrollConfig) DefaultCurrency() string {
+	if len(c.Currencies) == 0 {
+		return "VND"
+	}
+	return c.Currencies[0]
+}
+
+// SupportsCurrency reports whether salaries may be paid in the currency
+func (c PayrollConfig) SupportsCurrency(currency string) bool {
+	for _, code := range c.Currencies {
+		if code == currency {
+			return true
+		}
+	}
+	return false
+}
+
+type StorageConfig struct {
+	// Driver is "local" or "s3" (any S3-compatible object storage)
+	Driver string
+	// SignedURLExpiry is how long download links stay valid
+	SignedURLExpiry time.Duration
+	// MaxUploadSize is in bytes
+	MaxUploadSize int64
+
+	// Local files are served by the API through links signed with LocalSigningKey
+	LocalPath       string
+	LocalSigningKey string
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	// S3PathStyle addresses objects as endpoint/bucket/key, as MinIO expects
+	S3PathStyle bool
+}
+
+type SchedulerConfig struct {
+	// QuietJobs do not run on weekends and public holidays
+	QuietJobs []string
+}
+
+// IsQuiet reports whether the job skips non-working days
+func (c SchedulerConfig) IsQuiet(job string) bool {
+	for _, name := range c.QuietJobs {
+		if name == job {
+			return true
+		}
+	}
+	return false
+}
+
+// EmailsType reports whether notifications of the type get an email copy
+func (c NotificationConfig) EmailsType(notificationType string) bool {
+	for _, t := range c.EmailTypes {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+// OnboardingConfig is the checklist new users work through after their first
+// login
+type OnboardingConfig struct {
+	// ProfileFields are the employee profile fields that must be filled in
+	ProfileFields []string
+	// Require2FA adds setting up two-factor authentication to the checklist
+	Require2FA bool
+	// Enforce keeps users from filing leave and overtime requests until the
+	// checklist is done
+	Enforce bool
+}
+
+type EmployeeConfig struct {
+	ProbationReminderDays int
+	ProbationAutoConvert  bool
+	DefaultRole           string
+	DefaultRoleByType     map[string]string
+	StatusTransitions     map[string][]string
+	AutoWelcomeEmail      bool
+	// OrgDeletePolicy is what deleting a department or position with
+	// employees does when the request names no policy: "block" or
+	// "reassign" to the fallback department/position. Cascade is never a
+	// default and must be requested.
+	OrgDeletePolicy       string
+	FallbackDepartmentID  string
+	FallbackPositionID    string
+	// NameOrder is how full_name is composed: "vietnamese" (Last First) or
+	// "western" (First Last). NameOrderByLocale overrides it per preferred
+	// language, e.g. en:western.
+	NameOrder             string
+	NameOrderByLocale     map[string]string
+	// Employee codes are CodePrefix and a number of at least CodeDigits
+	// digits, e.g. NV000123, allocated from a sequence per prefix
+	CodePrefix            string
+	CodeDigits            int
+}
+
+// Name orders for composing full names
+const (
+	NameOrderVietnamese = "vietnamese"
+	NameOrderWestern    = "western"
+)
+
+// CanTransitionStatus reports whether an employee may move from one employment
+// status to another. Keeping the current status is always allowed.
+func (c EmployeeConfig) CanTransitionStatus(from, to string) bool {
+	if from == to {
+		return true
+	}
+	for _, allowed := range c.StatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatFullName composes the display name of an employee in the order
+// configured for the locale, skipping an empty part
+func (c EmployeeConfig) FormatFullName(firstName, lastName, locale string) string {
+	order := c.NameOrder
+	if override, ok := c.NameOrderByLocale[locale]; ok {
+		order = override
+	}
+	first, last := strings.TrimSpace(firstName), strings.TrimSpace(lastName)
+	if order == NameOrderWestern {
+		first, last = last, first
+	}
+	return strings.TrimSpace(last + " " + first)
+}
+
+// DefaultRoleFor returns the role slug given to new users created without
+// explicit roles, preferring the override for the employment type
+func (c EmployeeConfig) DefaultRoleFor(employmentType string) string {
+	if slug, ok := c.DefaultRoleByType[employmentType]; ok {
+		return slug
+	}
+	return c.DefaultRole
 }
 
 var AppConfig_ *Config
@@ -139,17 +461,23 @@ func Load() (*Config, error) {
 			BaseURL:     getEnv("APP_BASE_URL", "http://localhost:8080"),
 			FrontendURL: getEnv("FRONTEND_URL", "http://localhost:3000"),
 			Timezone:    getEnv("APP_TIMEZONE", "Asia/Ho_Chi_Minh"),
+			RequestTimeout:     getEnvDuration("REQUEST_TIMEOUT", "15s"),
+			LongRequestTimeout: getEnvDuration("LONG_REQUEST_TIMEOUT", "2m"),
 		},
 		Database: DatabaseConfig{
-			Host:            getEnv("DB_HOST", "localhost"),
-			Port:            getEnv("DB_PORT", "5432"),
-			User:            getEnv("DB_USER", "postgres"),
-			Password:        getEnv("DB_PASSWORD", "postgres"),
-			DBName:          getEnv("DB_NAME", "hr_management"),
-			SSLMode:         getEnv("DB_SSL_MODE", "disable"),
-			MaxOpenConns:    getEnvInt("DB_MAX_OPEN_CONNS", 100),
-			MaxIdleConns:    getEnvInt("DB_MAX_IDLE_CONNS", 10),
-			ConnMaxLifetime: getEnvDuration("DB_CONN_MAX_LIFETIME", "1h"),
+			Host:              getEnv("DB_HOST", "localhost"),
+			Port:              getEnv("DB_PORT", "5432"),
+			User:              getEnv("DB_USER", "postgres"),
+			Password:          getEnv("DB_PASSWORD", "postgres"),
+			DBName:            getEnv("DB_NAME", "hr_management"),
+			SSLMode:           getEnv("DB_SSL_MODE", "disable"),
+			MaxOpenConns:      getEnvInt("DB_MAX_OPEN_CONNS", 100),
+			MaxIdleConns:      getEnvInt("DB_MAX_IDLE_CONNS", 10),
+			ConnMaxLifetime:   getEnvDuration("DB_CONN_MAX_LIFETIME", "1h"),
+			MaxPageSize:       getEnvInt("DB_MAX_PAGE_SIZE", 100),
+			ExportMaxPageSize: getEnvInt("DB_EXPORT_MAX_PAGE_SIZE", 1000),
+
+			CountEstimateTables: getEnvList("DB_COUNT_ESTIMATE_TABLES", "attendances,audit_logs"),
 		},
 		Redis: RedisConfig{
 			Host:         getEnv("REDIS_HOST", "localhost"),
@@ -168,6 +496,10 @@ func Load() (*Config, error) {
 			RefreshTokenExpiry: getEnvDuration("JWT_REFRESH_EXPIRY", "168h"),
 			Issuer:             getEnv("JWT_ISSUER", "hr-management-system"),
 			Audience:           getEnv("JWT_AUDIENCE", "hr-management-users"),
+			CookieMode:         getEnvBool("JWT_COOKIE_MODE", false),
+			CookieDomain:       getEnv("JWT_COOKIE_DOMAIN", ""),
+			CookieSecure:       getEnvBool("JWT_COOKIE_SECURE", true),
+			CookieSameSite:     getEnv("JWT_COOKIE_SAMESITE", "strict"),
 		},
 		Email: EmailConfig{
 			Host:      getEnv("EMAIL_HOST", "smtp.gmail.com"),
@@ -178,11 +510,20 @@ func Load() (*Config, error) {
 			FromName:  getEnv("EMAIL_FROM_NAME", "HR Management System"),
 			EnableTLS: getEnvBool("EMAIL_ENABLE_TLS", true),
 		},
+		SMS: SMSConfig{
+			Provider:         getEnv("SMS_PROVIDER", "log"),
+			From:             getEnv("SMS_FROM", ""),
+			TwilioAccountSID: getEnv("TWILIO_ACCOUNT_SID", ""),
+			TwilioAuthToken:  getEnv("TWILIO_AUTH_TOKEN", ""),
+			Timeout:          getEnvDuration("SMS_TIMEOUT", "10s"),
+		},
 		Elastic: ElasticConfig{
 			URLs:     []string{getEnv("ELASTIC_URL", "http://localhost:9200")},
 			Username: getEnv("ELASTIC_USERNAME", "elastic"),
 			Password: getEnv("ELASTIC_PASSWORD", "changeme"),
 			Index:    getEnv("ELASTIC_INDEX", "hr_management"),
+			BulkBatchSize: getEnvInt("ELASTIC_BULK_BATCH_SIZE", 500),
+			SearchAfterThreshold: getEnvInt("ELASTIC_SEARCH_AFTER_THRESHOLD", 1000),
 		},
 		RateLimit: RateLimitConfig{
 			RequestsPerSecond: getEnvInt("RATE_LIMIT_PER_SECOND", 10),
@@ -190,6 +531,8 @@ func Load() (*Config, error) {
 			RequestsPerHour:   getEnvInt("RATE_LIMIT_PER_HOUR", 1000),
 			BurstSize:         getEnvInt("RATE_LIMIT_BURST", 20),
 			BlockDuration:     getEnvDuration("RATE_LIMIT_BLOCK_DURATION", "1h"),
+			FallbackEnabled:   getEnvBool("RATE_LIMIT_FALLBACK", true),
+			FailClosed:        getEnvList("RATE_LIMIT_FAIL_CLOSED", ""),
 		},
 		Security: SecurityConfig{
 			BCryptCost:        getEnvInt("BCRYPT_COST", 12),
@@ -204,6 +547,24 @@ func Load() (*Config, error) {
 			TrustedProxies:    []string{getEnv("TRUSTED_PROXIES", "127.0.0.1")},
 			EnableIPWhitelist: getEnvBool("ENABLE_IP_WHITELIST", false),
 			IPWhitelist:       []string{},
+			PayrollIPFilter: IPFilterConfig{
+				Allow: getEnvList("PAYROLL_IP_ALLOW", ""),
+				Deny:  getEnvList("PAYROLL_IP_DENY", ""),
+			},
+			RolesIPFilter: IPFilterConfig{
+				Allow: getEnvList("ROLES_IP_ALLOW", ""),
+				Deny:  getEnvList("ROLES_IP_DENY", ""),
+			},
+			BreachCheckEnabled:  getEnvBool("PASSWORD_BREACH_CHECK", false),
+			BreachCheckURL:      getEnv("PASSWORD_BREACH_API_URL", "https://api.pwnedpasswords.com/range/"),
+			BreachCheckTimeout:  getEnvDuration("PASSWORD_BREACH_TIMEOUT", "2s"),
+			BreachCheckCacheTTL: getEnvDuration("PASSWORD_BREACH_CACHE_TTL", "10m"),
+			GeoIPHeader:         getEnv("GEOIP_HEADER", ""),
+			TwoFactorKey:        getEnv("TWO_FACTOR_ENCRYPTION_KEY", "your-two-factor-key-change-in-production"),
+			TOTPIssuer:          getEnv("TOTP_ISSUER", getEnv("APP_NAME", "HR Management System")),
+			TOTPSkew:            getEnvInt("TOTP_SKEW", 1),
+			RecoveryCodeCount:   getEnvInt("TWO_FACTOR_RECOVERY_CODES", 10),
+			AllowSelfApproval:   getEnvBool("ALLOW_SELF_APPROVAL", false),
 		},
 		Logger: LoggerConfig{
 			Level:      getEnv("LOG_LEVEL", "info"),
@@ -214,24 +575,143 @@ func Load() (*Config, error) {
 			MaxBackups: getEnvInt("LOG_MAX_BACKUPS", 5),
 			MaxAge:     getEnvInt("LOG_MAX_AGE", 5),
 			Compress:   getEnvBool("LOG_COMPRESS", true),
+
+			DebugBodies:      getEnvBool("LOG_DEBUG_BODIES", false),
+			DebugRoutes:      getEnvList("LOG_DEBUG_ROUTES", ""),
+			DebugToken:       getEnv("LOG_DEBUG_TOKEN", ""),
+			DebugMaxBodySize: getEnvInt("LOG_DEBUG_MAX_BODY", 4096),
 		},
 		Worker: WorkerConfig{
-			Concurrency: getEnvInt("WORKER_CONCURRENCY", 10),
-			RedisAddr:   fmt.Sprintf("%s:%s", getEnv("REDIS_HOST", "localhost"), getEnv("REDIS_PORT", "6379")),
-			RetryMax:    getEnvInt("WORKER_RETRY_MAX", 3),
-			RetryDelay:  getEnvDuration("WORKER_RETRY_DELAY", "10s"),
+			Concurrency:    getEnvInt("WORKER_CONCURRENCY", 10),
+			RedisAddr:      fmt.Sprintf("%s:%s", getEnv("REDIS_HOST", "localhost"), getEnv("REDIS_PORT", "6379")),
+			RetryMax:       getEnvInt("WORKER_RETRY_MAX", 3),
+			RetryDelay:     getEnvDuration("WORKER_RETRY_DELAY", "10s"),
+			ReloadInterval: getEnvDuration("WORKER_RELOAD_INTERVAL", "30s"),
+			Alert: AlertConfig{
+				Email:             getEnv("WORKER_ALERT_EMAIL", ""),
+				WebhookURL:        getEnv("WORKER_ALERT_WEBHOOK_URL", ""),
+				ArchivedThreshold: getEnvInt("WORKER_ALERT_ARCHIVED_THRESHOLD", 10),
+				DedupWindow:       getEnvDuration("WORKER_ALERT_DEDUP_WINDOW", "1h"),
+				CheckInterval:     getEnvDuration("WORKER_ALERT_CHECK_INTERVAL", "5m"),
+			},
 			Queues: map[string]int{
 				"critical": 6,
 				"default":  3,
 				"low":      1,
 			},
 		},
+		Leave: LeaveConfig{
+			AutoApproveMaxDays: getEnvFloat("LEAVE_AUTO_APPROVE_MAX_DAYS", 0),
+			YearEndEncashment:  getEnvBool("LEAVE_YEAR_END_ENCASHMENT", false),
+		},
+		Attendance: AttendanceConfig{
+			MinRestPeriod:     getEnvDuration("ATTENDANCE_MIN_REST_PERIOD", "0s"),
+			RestWarnOnly:      getEnvBool("ATTENDANCE_REST_WARN_ONLY", false),
+			ForgotCheckout:    getEnv("ATTENDANCE_FORGOT_CHECKOUT", "close"),
+			DefaultShiftStart: getEnv("ATTENDANCE_DEFAULT_SHIFT_START", "08:00"),
+			DefaultShiftEnd:   getEnv("ATTENDANCE_DEFAULT_SHIFT_END", "17:00"),
+
+			LateGraceMinutes:     getEnvInt("ATTENDANCE_LATE_GRACE_MINUTES", 5),
+			LateCountThreshold:   getEnvInt("ATTENDANCE_LATE_COUNT_THRESHOLD", 0),
+			LateMinutesThreshold: getEnvInt("ATTENDANCE_LATE_MINUTES_THRESHOLD", 0),
+			LateDeductPay:        getEnvBool("ATTENDANCE_LATE_DEDUCT_PAY", false),
+			LateNotifyHR:         getEnvBool("ATTENDANCE_LATE_NOTIFY_HR", true),
+
+			OfficeNetworks:        getEnvList("ATTENDANCE_OFFICE_NETWORKS", ""),
+			RemoteMaxDaysPerMonth: getEnvInt("ATTENDANCE_REMOTE_MAX_DAYS_PER_MONTH", 8),
+			RemoteMinNoticeDays:   getEnvInt("ATTENDANCE_REMOTE_MIN_NOTICE_DAYS", 1),
+			RemoteShiftStart:      getEnv("ATTENDANCE_REMOTE_SHIFT_START", ""),
+			RemoteExpectedHours:   getEnvFloat("ATTENDANCE_REMOTE_EXPECTED_HOURS", 8),
+
+			LeaveStatus:       getEnvBool("ATTENDANCE_LEAVE_STATUS", true),
+			LeaveBackfillDays: getEnvInt("ATTENDANCE_LEAVE_BACKFILL_DAYS", 7),
+
+			MarkAbsentees: getEnvBool("ATTENDANCE_MARK_ABSENTEES", true),
+		},
+		Overtime: OvertimeConfig{
+			NightStart:     getEnv("OVERTIME_NIGHT_START", "22:00"),
+			NightEnd:       getEnv("OVERTIME_NIGHT_END", "06:00"),
+			TypePrecedence: getEnvList("OVERTIME_TYPE_PRECEDENCE", "highest"),
+		},
+		Employee: EmployeeConfig{
+			ProbationReminderDays: getEnvInt("EMPLOYEE_PROBATION_REMINDER_DAYS", 7),
+			ProbationAutoConvert:  getEnvBool("EMPLOYEE_PROBATION_AUTO_CONVERT", false),
+			DefaultRole:           getEnv("EMPLOYEE_DEFAULT_ROLE", "employee"),
+			DefaultRoleByType:     getEnvMap("EMPLOYEE_DEFAULT_ROLE_BY_TYPE", ""),
+			StatusTransitions: getEnvTransitions("EMPLOYEE_STATUS_TRANSITIONS",
+				"active:on_leave|inactive|resigned|terminated,on_leave:active|resigned|terminated,inactive:active|resigned|terminated"),
+			AutoWelcomeEmail: getEnvBool("EMPLOYEE_AUTO_WELCOME_EMAIL", true),
+			OrgDeletePolicy:      getEnv("EMPLOYEE_ORG_DELETE_POLICY", "block"),
+			FallbackDepartmentID: getEnv("EMPLOYEE_FALLBACK_DEPARTMENT_ID", ""),
+			FallbackPositionID:   getEnv("EMPLOYEE_FALLBACK_POSITION_ID", ""),
+			NameOrder:            getEnv("EMPLOYEE_NAME_ORDER", "vietnamese"),
+			NameOrderByLocale:    getEnvMap("EMPLOYEE_NAME_ORDER_BY_LOCALE", ""),
+			CodePrefix:           getEnv("EMPLOYEE_CODE_PREFIX", "NV"),
+			CodeDigits:           getEnvInt("EMPLOYEE_CODE_DIGITS", 6),
+		},
+		Onboarding: OnboardingConfig{
+			ProfileFields: getEnvList("ONBOARDING_PROFILE_FIELDS",
+				"current_address,personal_phone,emergency_contact,emergency_phone,bank_account_no,tax_code"),
+			Require2FA: getEnvBool("ONBOARDING_REQUIRE_2FA", false),
+			Enforce:    getEnvBool("ONBOARDING_ENFORCE", false),
+		},
+		Notification: NotificationConfig{
+			EmailTypes:    getEnvList("NOTIFICATION_EMAIL_TYPES", "payslip_ready,leave_approved,leave_rejected,contract_expiry,probation_converted"),
+			EmailMaxRetry: getEnvInt("NOTIFICATION_EMAIL_MAX_RETRY", 5),
+
+			BroadcastBatchSize:   getEnvInt("NOTIFICATION_BROADCAST_BATCH_SIZE", 500),
+			BroadcastConcurrency: getEnvInt("NOTIFICATION_BROADCAST_CONCURRENCY", 4),
+		},
+		Payroll: PayrollConfig{
+			Currencies:        getEnvList("PAYROLL_CURRENCIES", "VND"),
+			ReportingCurrency: getEnv("PAYROLL_REPORTING_CURRENCY", "VND"),
+
+			ReviewRequired:       getEnvBool("PAYROLL_REVIEW_REQUIRED", true),
+			FlagOvertimeHours:    getEnvFloat("PAYROLL_FLAG_OVERTIME_HOURS", 40),
+			FlagNetChangePercent: getEnvFloat("PAYROLL_FLAG_NET_CHANGE_PERCENT", 50),
+			FlagNetAbove:         getEnvFloat("PAYROLL_FLAG_NET_ABOVE", 0),
+		},
+		Scheduler: SchedulerConfig{
+			QuietJobs: getEnvList("SCHEDULER_QUIET_JOBS", "attendance_reminder,daily_attendance_report,payroll_reminder"),
+		},
+		Storage: StorageConfig{
+			Driver:          getEnv("STORAGE_DRIVER", "local"),
+			SignedURLExpiry: getEnvDuration("STORAGE_SIGNED_URL_EXPIRY", "15m"),
+			MaxUploadSize:   int64(getEnvInt("STORAGE_MAX_UPLOAD_SIZE", 10<<20)),
+			LocalPath:       getEnv("STORAGE_LOCAL_PATH", "./storage"),
+			LocalSigningKey: getEnv("STORAGE_LOCAL_SIGNING_KEY", "your-storage-signing-key-change-in-production"),
+			S3Endpoint:      getEnv("STORAGE_S3_ENDPOINT", "https://s3.amazonaws.com"),
+			S3Region:        getEnv("STORAGE_S3_REGION", "us-east-1"),
+			S3Bucket:        getEnv("STORAGE_S3_BUCKET", ""),
+			S3AccessKey:     getEnv("STORAGE_S3_ACCESS_KEY", ""),
+			S3SecretKey:     getEnv("STORAGE_S3_SECRET_KEY", ""),
+			S3PathStyle:     getEnvBool("STORAGE_S3_PATH_STYLE", false),
+		},
+	}
+
+	if err := requireProductionSecrets(config.App.Environment); err != nil {
+		return nil, err
 	}
 
 	AppConfig_ = config
 	return config, nil
 }
 
+// requireProductionSecrets refuses to start production on the development
+// defaults of keys that protect stored data. Each key is independent of the
+// JWT secrets, so rotating one never breaks the others.
+func requireProductionSecrets(environment string) error {
+	if environment != "production" {
+		return nil
+	}
+	for _, key := range []string{"TWO_FACTOR_ENCRYPTION_KEY", "STORAGE_LOCAL_SIGNING_KEY"} {
+		if os.Getenv(key) == "" {
+			return fmt.Errorf("%s must be set in production", key)
+		}
+	}
+	return nil
+}
+
 func (c *DatabaseConfig) GetDSN() string {
 	return fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
@@ -255,6 +735,15 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
@@ -264,6 +753,43 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// getEnvList parses a comma separated list, trimming spaces and skipping
+// empty entries
+func getEnvList(key string, defaultValue string) []string {
+	var result []string
+	for _, item := range strings.Split(getEnv(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// getEnvMap parses "key:value" pairs separated by commas
+func getEnvMap(key string, defaultValue string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(getEnv(key, defaultValue), ",") {
+		k, v, ok := strings.Cut(pair, ":")
+		if ok && strings.TrimSpace(k) != "" {
+			result[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return result
+}
+
+// getEnvTransitions parses "from:to|to" entries separated by commas
+func getEnvTransitions(key string, defaultValue string) map[string][]string {
+	result := make(map[string][]string)
+	for from, targets := range getEnvMap(key, defaultValue) {
+		for _, to := range strings.Split(targets, "|") {
+			if to = strings.TrimSpace(to); to != "" {
+				result[from] = append(result[from], to)
+			}
+		}
+	}
+	return result
+}
+
 func getEnvDuration(key string, defaultValue string) time.Duration {
 	value := getEnv(key, defaultValue)
 	duration, err := time.ParseDuration(value)
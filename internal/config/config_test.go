@@ -0,0 +1,160 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCanTransitionStatus(t *testing.T) {
+	c := EmployeeConfig{StatusTransitions: map[string][]string{
+		"active":   {"on_leave", "terminated"},
+		"on_leave": {"active"},
+	}}
+
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{"active", "active", true},
+		{"active", "on_leave", true},
+		{"active", "terminated", true},
+		{"on_leave", "active", true},
+		{"on_leave", "terminated", false},
+		{"terminated", "active", false},
+		{"", "active", false},
+		{"unknown", "unknown", true},
+	}
+	for _, tt := range tests {
+		if got := c.CanTransitionStatus(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransitionStatus(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	// Without a table only staying put is allowed
+	var empty EmployeeConfig
+	if empty.CanTransitionStatus("active", "terminated") {
+		t.Error("transition allowed without a table")
+	}
+	if !empty.CanTransitionStatus("active", "active") {
+		t.Error("same status refused without a table")
+	}
+}
+
+func TestGetEnvTransitions(t *testing.T) {
+	const key = "EMPLOYEE_STATUS_TRANSITIONS"
+	const defaultValue = "active:inactive,inactive:active"
+
+	tests := []struct {
+		name  string
+		value string
+		want  map[string][]string
+	}{
+		{"unset uses the default", "", map[string][]string{
+			"active":   {"inactive"},
+			"inactive": {"active"},
+		}},
+		{"several targets", "active:on_leave|terminated,on_leave:active", map[string][]string{
+			"active":   {"on_leave", "terminated"},
+			"on_leave": {"active"},
+		}},
+		{"spaces trimmed", " active : on_leave | terminated , on_leave:active ", map[string][]string{
+			"active":   {"on_leave", "terminated"},
+			"on_leave": {"active"},
+		}},
+		{"empty targets skipped", "active:on_leave||,on_leave:|", map[string][]string{
+			"active": {"on_leave"},
+		}},
+		{"malformed entries skipped", "active,:on_leave,,terminated:active", map[string][]string{
+			"terminated": {"active"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(key, tt.value)
+			if got := getEnvTransitions(key, defaultValue); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getEnvTransitions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEnvList(t *testing.T) {
+	const key = "PAYROLL_CURRENCIES"
+
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"unset uses the default", "", []string{"VND", "USD"}},
+		{"spaces trimmed", " VND , USD ", []string{"VND", "USD"}},
+		{"empty entries skipped", "VND,, ,USD,", []string{"VND", "USD"}},
+		{"only separators", " , ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(key, tt.value)
+			if got := getEnvList(key, "VND,USD"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getEnvList = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// The default table lets active and on-leave employees leave, and brings
+// inactive ones back, but never reopens a resigned or terminated employee
+func TestDefaultStatusTransitions(t *testing.T) {
+	t.Setenv("EMPLOYEE_STATUS_TRANSITIONS", "")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{"active", "on_leave", true},
+		{"active", "terminated", true},
+		{"on_leave", "active", true},
+		{"on_leave", "inactive", false},
+		{"inactive", "active", true},
+		{"resigned", "active", false},
+		{"terminated", "active", false},
+	}
+	for _, tt := range tests {
+		if got := cfg.Employee.CanTransitionStatus(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransitionStatus(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestLoadRequiresProductionSecrets(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		twoFactor   string
+		signing     string
+		wantErr     bool
+	}{
+		{"development uses the defaults", "development", "", "", false},
+		{"production with both keys", "production", "2fa-key", "signing-key", false},
+		{"production without the 2FA key", "production", "", "signing-key", true},
+		{"production without the signing key", "production", "2fa-key", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("APP_ENV", tt.environment)
+			t.Setenv("JWT_ACCESS_SECRET", "jwt-secret")
+			t.Setenv("TWO_FACTOR_ENCRYPTION_KEY", tt.twoFactor)
+			t.Setenv("STORAGE_LOCAL_SIGNING_KEY", tt.signing)
+			cfg, err := Load()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && (cfg.Security.TwoFactorKey == "jwt-secret" || cfg.Storage.LocalSigningKey == "jwt-secret") {
+				t.Error("key falls back to the JWT secret")
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +18,7 @@ type LoginResponse struct {
 	RefreshToken string       `json:"refresh_token"`
 	ExpiresAt    time.Time    `json:"expires_at"`
 	TokenType    string       `json:"token_type"`
+	CSRFToken    string       `json:"csrf_token,omitempty"`
 	User         UserResponse `json:"user"`
 }
 
@@ -29,8 +31,9 @@ type RegisterRequest struct {
 	LastName        string `json:"last_name" binding:"required"`
 }
 
+// RefreshToken may be omitted in cookie mode, where the refresh cookie is used
 type RefreshTokenRequest struct {
-	RefreshToken string `json:"refresh_token" binding:"required"`
+	RefreshToken string `json:"refresh_token"`
 }
 
 type ChangePasswordRequest struct {
@@ -49,9 +52,30 @@ type ResetPasswordRequest struct {
 	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=Password"`
 }
 
+// Disable2FARequest needs the current password and a current code: a
+// two_factor OTP from /auth/send-otp, an authenticator app code or a recovery code
+type Disable2FARequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required,min=6,max=20"`
+}
+
+// TwoFactorSetupResponse carries what an authenticator app needs to enroll.
+// QRPayload is the text to encode in the QR code; the recovery codes are only
+// ever shown here.
+type TwoFactorSetupResponse struct {
+	Secret        string   `json:"secret"`
+	OTPAuthURI    string   `json:"otpauth_uri"`
+	QRPayload     string   `json:"qr_payload"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// SendOTPRequest asks for an OTP. Channel defaults to sms for
+// phone_verification and email otherwise; sms for other types needs a
+// verified phone.
 type SendOTPRequest struct {
-	Email string `json:"email" binding:"required,email"`
-	Type  string `json:"type" binding:"required,oneof=email_verification phone_verification password_reset login two_factor"`
+	Email   string `json:"email" binding:"required,email"`
+	Type    string `json:"type" binding:"required,oneof=email_verification phone_verification password_reset login two_factor"`
+	Channel string `json:"channel" binding:"omitempty,oneof=email sms"`
 }
 
 type VerifyOTPRequest struct {
@@ -64,6 +88,53 @@ type TwoFactorRequest struct {
 	Code string `json:"code" binding:"required,len=6"`
 }
 
+type LoginHistoryFilter struct {
+	Success  *bool  `form:"success"`
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"page_size,default=20"`
+}
+
+// LoginHistoryEntry is one authentication attempt. Successful attempts carry
+// the session they opened and whether it is still active.
+type LoginHistoryEntry struct {
+	ID            uuid.UUID  `json:"id"`
+	Success       bool       `json:"success"`
+	Reason        string     `json:"reason,omitempty"`
+	IPAddress     string     `json:"ip_address"`
+	UserAgent     string     `json:"user_agent"`
+	Location      string     `json:"location,omitempty"`
+	SessionID     *uuid.UUID `json:"session_id,omitempty"`
+	SessionActive bool       `json:"session_active"`
+	LastActivity  *time.Time `json:"last_activity,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// SessionResponse is one signed-in device of the current user
+type SessionResponse struct {
+	ID           uuid.UUID `json:"id"`
+	UserAgent    string    `json:"user_agent"`
+	IPAddress    string    `json:"ip_address"`
+	Current      bool      `json:"current"`
+	LastActivity time.Time `json:"last_activity"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ResetTokenResponse is an outstanding password reset link of a user. The
+// token itself is never shown.
+type ResetTokenResponse struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ==================== COMMON ====================
+
+// BatchRequest hydrates up to 100 records by id in one call
+type BatchRequest struct {
+	IDs []string `json:"ids" binding:"required,min=1,max=100,dive,uuid"`
+}
+
 // ==================== USER ====================
 
 type UserResponse struct {
@@ -80,6 +151,33 @@ type UserResponse struct {
 	CreatedAt         time.Time  `json:"created_at"`
 }
 
+// WhoAmIResponse carries only non-sensitive claim data of the current token
+type WhoAmIResponse struct {
+	UserID           string     `json:"user_id"`
+	Email            string     `json:"email"`
+	Roles            []string   `json:"roles"`
+	Permissions      []string   `json:"permissions"`
+	SessionID        string     `json:"session_id"`
+	IssuedAt         *time.Time `json:"issued_at,omitempty"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+}
+
+// OnboardingStep is an item of the first-login checklist: verify_email,
+// change_password, complete_profile or setup_2fa
+type OnboardingStep struct {
+	Key           string   `json:"key"`
+	Done          bool     `json:"done"`
+	MissingFields []string `json:"missing_fields,omitempty"`
+}
+
+type OnboardingResponse struct {
+	Completed   bool             `json:"completed"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	Steps       []OnboardingStep `json:"steps"`
+	Outstanding []string         `json:"outstanding"`
+}
+
 type CreateUserRequest struct {
 	Email    string   `json:"email" binding:"required,email"`
 	Phone    string   `json:"phone" binding:"required"`
@@ -119,6 +217,8 @@ type EmployeeResponse struct {
 	EmploymentStatus string     `json:"employment_status"`
 	JoinDate         time.Time  `json:"join_date"`
 	BaseSalary       float64    `json:"base_salary"`
+	SalaryCurrency   string     `json:"salary_currency"`
+	WorkMode         string     `json:"work_mode"`
 	Avatar           string     `json:"avatar,omitempty"`
 	CreatedAt        time.Time  `json:"created_at"`
 	UpdatedAt        time.Time  `json:"updated_at"`
@@ -157,8 +257,33 @@ type CreateEmployeeRequest struct {
 	JoinDate         string    `json:"join_date" binding:"required"`
 	ProbationEndDate string    `json:"probation_end_date"`
 	BaseSalary       float64   `json:"base_salary" binding:"required,min=0"`
+	SalaryCurrency   string    `json:"salary_currency" binding:"omitempty,len=3"`
 	SalaryGrade      string    `json:"salary_grade"`
+	// PreferredLanguage defaults to vi; it also picks the order of the full name
+	PreferredLanguage string   `json:"preferred_language"`
 	RoleIDs          []string  `json:"role_ids"`
+	// SendWelcome defaults to true; when false the temporary password is returned to HR instead
+	SendWelcome      *bool     `json:"send_welcome"`
+}
+
+// EmployeeImportRow is the outcome of one data row of an import file. Row is
+// the line in the file; failed rows carry the message key of the reason.
+type EmployeeImportRow struct {
+	Row          int               `json:"row"`
+	Email        string            `json:"email,omitempty"`
+	Success      bool              `json:"success"`
+	EmployeeID   *uuid.UUID        `json:"employee_id,omitempty"`
+	EmployeeCode string            `json:"employee_code,omitempty"`
+	TempPassword string            `json:"temp_password,omitempty"`
+	Reason       string            `json:"reason,omitempty"`
+	Details      map[string]string `json:"details,omitempty"`
+}
+
+type EmployeeImportResponse struct {
+	Total   int                 `json:"total"`
+	Created int                 `json:"created"`
+	Failed  int                 `json:"failed"`
+	Rows    []EmployeeImportRow `json:"rows"`
 }
 
 type UpdateEmployeeRequest struct {
@@ -172,7 +297,11 @@ type UpdateEmployeeRequest struct {
 	ManagerID        *string  `json:"manager_id"`
 	EmploymentType   *string  `json:"employment_type"`
 	EmploymentStatus *string  `json:"employment_status"`
+	WorkMode         *string  `json:"work_mode" binding:"omitempty,oneof=office remote hybrid"`
 	BaseSalary       *float64 `json:"base_salary"`
+	SalaryCurrency   *string  `json:"salary_currency" binding:"omitempty,len=3"`
+	// SalaryEffectiveFrom is when a salary change takes effect, today if empty
+	SalaryEffectiveFrom string `json:"salary_effective_from" binding:"omitempty,datetime=2006-01-02"`
 	SalaryGrade      *string  `json:"salary_grade"`
 	BankAccountNo    *string  `json:"bank_account_no"`
 	BankName         *string  `json:"bank_name"`
@@ -181,6 +310,19 @@ type UpdateEmployeeRequest struct {
 	CurrentWardID    *int     `json:"current_ward_id"`
 }
 
+type TerminateEmployeeRequest struct {
+	Status          string `json:"status" binding:"required,oneof=resigned terminated"`
+	LastWorkingDate string `json:"last_working_date" binding:"required"`
+	Reason          string `json:"reason" binding:"max=1000"`
+}
+
+// ReviewProbationRequest: confirm converts to full time, flag blocks automatic
+// conversion, deny records a failed probation
+type ReviewProbationRequest struct {
+	Decision string `json:"decision" binding:"required,oneof=confirm flag deny"`
+	Notes    string `json:"notes" binding:"max=1000"`
+}
+
 type EmployeeFilter struct {
 	Search           string `form:"search"`
 	DepartmentID     string `form:"department_id"`
@@ -193,6 +335,74 @@ type EmployeeFilter struct {
 	SortOrder        string `form:"sort_order,default=desc"`
 }
 
+// EmployeeDocumentResponse describes a stored document; the file itself is
+// fetched through a signed download link
+type EmployeeDocumentResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	EmployeeID  uuid.UUID  `json:"employee_id"`
+	Name        string     `json:"name"`
+	Category    string     `json:"category,omitempty"`
+	ContentType string     `json:"content_type"`
+	Size        int64      `json:"size"`
+	UploadedBy  *uuid.UUID `json:"uploaded_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// DependentRequest registers a dependent for the PIT family deduction. The
+// dependent counts for every month the effective window overlaps;
+// EffectiveTo is left empty while they remain a dependent.
+type DependentRequest struct {
+	FullName      string `json:"full_name" binding:"required,max=255"`
+	Relationship  string `json:"relationship" binding:"required,oneof=child spouse parent sibling other"`
+	DateOfBirth   string `json:"date_of_birth" binding:"omitempty,datetime=2006-01-02"`
+	IDNumber      string `json:"id_number" binding:"max=50"`
+	TaxCode       string `json:"tax_code" binding:"max=20"`
+	TaxDeductible *bool  `json:"tax_deductible"`
+	EffectiveFrom string `json:"effective_from" binding:"required,datetime=2006-01-02"`
+	EffectiveTo   string `json:"effective_to" binding:"omitempty,datetime=2006-01-02"`
+	Notes         string `json:"notes" binding:"max=1000"`
+}
+
+type DependentResponse struct {
+	ID            uuid.UUID `json:"id"`
+	EmployeeID    uuid.UUID `json:"employee_id"`
+	FullName      string    `json:"full_name"`
+	Relationship  string    `json:"relationship"`
+	DateOfBirth   string    `json:"date_of_birth,omitempty"`
+	IDNumber      string    `json:"id_number,omitempty"`
+	TaxCode       string    `json:"tax_code,omitempty"`
+	TaxDeductible bool      `json:"tax_deductible"`
+	EffectiveFrom string    `json:"effective_from"`
+	EffectiveTo   string    `json:"effective_to,omitempty"`
+	Notes         string    `json:"notes,omitempty"`
+	// Active is whether the dependent reduces this month's tax
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// EmployeeNoteRequest records a note about an employee. Visibility is who
+// besides the author may read it: nobody (private), the employee's manager
+// chain (managers), or the chain and HR (hr).
+type EmployeeNoteRequest struct {
+	Visibility string `json:"visibility" binding:"required,oneof=private managers hr"`
+	Body       string `json:"body" binding:"required,max=10000"`
+}
+
+type EmployeeNoteResponse struct {
+	ID         uuid.UUID `json:"id"`
+	EmployeeID uuid.UUID `json:"employee_id"`
+	AuthorID   uuid.UUID `json:"author_id"`
+	AuthorName string    `json:"author_name"`
+	Visibility string    `json:"visibility"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type FileDownloadResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
 // ==================== DEPARTMENT ====================
 
 type DepartmentResponse struct {
@@ -231,6 +441,43 @@ type UpdateDepartmentRequest struct {
 	CostCenter  *string `json:"cost_center"`
 }
 
+// DepartmentApproverRequest routes a department's leave, overtime or
+// attendance requests to named approvers, to the holders of a role, or both
+type DepartmentApproverRequest struct {
+	PrimaryApproverID string `json:"primary_approver_id" binding:"omitempty,uuid"`
+	BackupApproverID  string `json:"backup_approver_id" binding:"omitempty,uuid"`
+	ApproverRoleID    string `json:"approver_role_id" binding:"omitempty,uuid"`
+}
+
+// DepartmentApproverResponse is the routing of one request type. Routing is
+// "manager" when the department has no configuration for it.
+type DepartmentApproverResponse struct {
+	RequestType         string     `json:"request_type"`
+	Routing             string     `json:"routing"`
+	PrimaryApproverID   *uuid.UUID `json:"primary_approver_id,omitempty"`
+	PrimaryApproverName string     `json:"primary_approver_name,omitempty"`
+	BackupApproverID    *uuid.UUID `json:"backup_approver_id,omitempty"`
+	BackupApproverName  string     `json:"backup_approver_name,omitempty"`
+	ApproverRoleID      *uuid.UUID `json:"approver_role_id,omitempty"`
+	ApproverRoleName    string     `json:"approver_role_name,omitempty"`
+	UpdatedAt           *time.Time `json:"updated_at,omitempty"`
+}
+
+// DeleteOrgUnitRequest chooses what deleting a department or position does
+// to its employees; reassign_to defaults to the configured fallback
+type DeleteOrgUnitRequest struct {
+	Policy     string `form:"policy" binding:"omitempty,oneof=block reassign cascade"`
+	ReassignTo string `form:"reassign_to" binding:"omitempty,uuid"`
+}
+
+type DeleteOrgUnitResponse struct {
+	ID                  uuid.UUID  `json:"id"`
+	Policy              string     `json:"policy"`
+	ReassignedTo        *uuid.UUID `json:"reassigned_to,omitempty"`
+	EmployeesReassigned int64      `json:"employees_reassigned"`
+	UnitsDeleted        int64      `json:"units_deleted"`
+}
+
 // ==================== POSITION ====================
 
 type PositionResponse struct {
@@ -270,6 +517,7 @@ type AttendanceResponse struct {
 	WorkingHours    float64    `json:"working_hours"`
 	OvertimeHours   float64    `json:"overtime_hours"`
 	Status          string     `json:"status"`
+	WorkMode        string     `json:"work_mode"`
 	Notes           string     `json:"notes,omitempty"`
 	ApprovedBy      *uuid.UUID `json:"approved_by,omitempty"`
 	ApproverName    string     `json:"approver_name,omitempty"`
@@ -285,16 +533,157 @@ type CheckOutRequest struct {
 	Notes    string `json:"notes"`
 }
 
+// ApproveAttendanceRequest approves an attendance record, optionally
+// correcting its times first
+type ApproveAttendanceRequest struct {
+	CheckIn  *time.Time `json:"check_in"`
+	CheckOut *time.Time `json:"check_out"`
+	Notes    string     `json:"notes" binding:"max=1000"`
+}
+
+// ManualAttendanceRequest enters an employee's attendance for a day by hand,
+// for when they forgot to check in or out
+type ManualAttendanceRequest struct {
+	EmployeeID string    `json:"employee_id" binding:"required,uuid"`
+	Date       string    `json:"date" binding:"required,datetime=2006-01-02"`
+	CheckIn    time.Time `json:"check_in" binding:"required"`
+	CheckOut   time.Time `json:"check_out" binding:"required"`
+	WorkMode   string    `json:"work_mode" binding:"omitempty,oneof=office remote"`
+	Reason     string    `json:"reason" binding:"required,max=1000"`
+}
+
+// AttendanceLiveSnapshot is sent when a live attendance feed connects: each
+// department's active headcount and how many have checked in and out today
+type AttendanceLiveSnapshot struct {
+	Type        string                     `json:"type"`
+	Date        string                     `json:"date"`
+	Departments []AttendanceLiveDepartment `json:"departments"`
+}
+
+type AttendanceLiveDepartment struct {
+	DepartmentID   uuid.UUID `json:"department_id"`
+	DepartmentName string    `json:"department_name"`
+	Headcount      int       `json:"headcount"`
+	CheckedIn      int       `json:"checked_in"`
+	CheckedOut     int       `json:"checked_out"`
+}
+
+// AttendanceLiveEvent is pushed to live attendance feeds on every check-in
+// and check-out. Action is check_in or check_out; Date is the attendance day,
+// the day before for a night shift checked out after midnight.
+type AttendanceLiveEvent struct {
+	Type           string     `json:"type"`
+	Action         string     `json:"action"`
+	Date           string     `json:"date"`
+	EmployeeID     uuid.UUID  `json:"employee_id"`
+	EmployeeName   string     `json:"employee_name"`
+	DepartmentID   *uuid.UUID `json:"department_id"`
+	DepartmentName string     `json:"department_name"`
+	Status         string     `json:"status"`
+	At             time.Time  `json:"at"`
+}
+
+// RotateShiftsRequest assigns ShiftIDs in order, each for CycleDays calendar days
+type RotateShiftsRequest struct {
+	EmployeeIDs  []string `json:"employee_ids" binding:"required,min=1,dive,uuid"`
+	ShiftIDs     []string `json:"shift_ids" binding:"required,min=1,dive,uuid"`
+	StartDate    string   `json:"start_date" binding:"required"`
+	CycleDays    int      `json:"cycle_days" binding:"required,min=1,max=31"`
+	HorizonDays  int      `json:"horizon_days" binding:"omitempty,min=1,max=92"`
+	RestWeekdays []int    `json:"rest_weekdays" binding:"omitempty,dive,min=0,max=6"`
+}
+
+type TeamMemberAttendance struct {
+	EmployeeID   uuid.UUID  `json:"employee_id"`
+	EmployeeCode string     `json:"employee_code"`
+	FullName     string     `json:"full_name"`
+	CheckIn      *time.Time `json:"check_in"`
+	CheckOut     *time.Time `json:"check_out"`
+	Status       string     `json:"status,omitempty"`
+	WorkMode     string     `json:"work_mode,omitempty"`
+	OnLeave      bool       `json:"on_leave"`
+	LeaveType    string     `json:"leave_type,omitempty"`
+	NoShow       bool       `json:"no_show"`
+}
+
+type TeamAttendanceResponse struct {
+	Date      string                 `json:"date"`
+	Total     int                    `json:"total"`
+	CheckedIn int                    `json:"checked_in"`
+	OnLeave   int                    `json:"on_leave"`
+	NoShow    int                    `json:"no_show"`
+	Remote    int                    `json:"remote"`
+	Members   []TeamMemberAttendance `json:"members"`
+}
+
 type AttendanceFilter struct {
 	EmployeeID   string `form:"employee_id"`
 	DepartmentID string `form:"department_id"`
 	Status       string `form:"status"`
+	WorkMode     string `form:"work_mode" binding:"omitempty,oneof=office remote"`
 	StartDate    string `form:"start_date"`
 	EndDate      string `form:"end_date"`
 	Page         int    `form:"page,default=1"`
 	PageSize     int    `form:"page_size,default=20"`
 }
 
+// CreateRemoteWorkRequest declares remote days; weekends and holidays in the
+// range are not counted
+type CreateRemoteWorkRequest struct {
+	StartDate string `json:"start_date" binding:"required,datetime=2006-01-02"`
+	EndDate   string `json:"end_date" binding:"required,datetime=2006-01-02"`
+	Reason    string `json:"reason" binding:"max=1000"`
+}
+
+type ApproveRemoteWorkRequest struct {
+	Status string `json:"status" binding:"required,oneof=approved rejected"`
+	Notes  string `json:"notes" binding:"max=1000"`
+}
+
+type RemoteWorkFilter struct {
+	EmployeeID   string `form:"employee_id" binding:"omitempty,uuid"`
+	DepartmentID string `form:"department_id" binding:"omitempty,uuid"`
+	Status       string `form:"status" binding:"omitempty,oneof=pending approved rejected cancelled"`
+	StartDate    string `form:"start_date" binding:"omitempty,datetime=2006-01-02"`
+	EndDate      string `form:"end_date" binding:"omitempty,datetime=2006-01-02"`
+	Page         int    `form:"page,default=1"`
+	PageSize     int    `form:"page_size,default=20"`
+}
+
+type RemoteWorkResponse struct {
+	ID              uuid.UUID  `json:"id"`
+	EmployeeID      uuid.UUID  `json:"employee_id"`
+	EmployeeName    string     `json:"employee_name"`
+	StartDate       time.Time  `json:"start_date"`
+	EndDate         time.Time  `json:"end_date"`
+	TotalDays       int        `json:"total_days"`
+	Reason          string     `json:"reason,omitempty"`
+	Status          string     `json:"status"`
+	ApprovedBy      *uuid.UUID `json:"approved_by,omitempty"`
+	ApproverName    string     `json:"approver_name,omitempty"`
+	ApprovedAt      *time.Time `json:"approved_at,omitempty"`
+	RejectionReason string     `json:"rejection_reason,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+type WorkingDaysFilter struct {
+	Month        string `form:"month" binding:"omitempty,datetime=2006-01"`
+	DepartmentID string `form:"department_id" binding:"omitempty,uuid"`
+}
+
+// WorkingDaysResponse is the standard working calendar payroll prorates on
+type WorkingDaysResponse struct {
+	Month           string     `json:"month"`
+	DepartmentID    *uuid.UUID `json:"department_id,omitempty"`
+	StartDate       string     `json:"start_date"`
+	EndDate         string     `json:"end_date"`
+	WorkingDays     float64    `json:"working_days"`
+	HoursPerDay     float64    `json:"hours_per_day"`
+	ExpectedHours   float64    `json:"expected_hours"`
+	Holidays        []string   `json:"holidays"`
+	HalfDayHolidays []string   `json:"half_day_holidays"`
+}
+
 // ==================== LEAVE ====================
 
 type LeaveRequestResponse struct {
@@ -316,6 +705,7 @@ type LeaveRequestResponse struct {
 }
 
 type CreateLeaveRequest struct {
+	EmployeeID  string `json:"employee_id" binding:"omitempty,uuid"` // filed on behalf, requires leave.manage
 	LeaveTypeID string `json:"leave_type_id" binding:"required,uuid"`
 	StartDate   string `json:"start_date" binding:"required"`
 	EndDate     string `json:"end_date" binding:"required"`
@@ -338,6 +728,32 @@ type LeaveBalanceResponse struct {
 	RemainingDays float64   `json:"remaining_days"`
 }
 
+type LeaveBalanceCorrection struct {
+	LeaveTypeID     uuid.UUID `json:"leave_type_id"`
+	LeaveTypeName   string    `json:"leave_type_name"`
+	StoredUsed      float64   `json:"stored_used_days"`
+	ComputedUsed    float64   `json:"computed_used_days"`
+	StoredPending   float64   `json:"stored_pending_days"`
+	ComputedPending float64   `json:"computed_pending_days"`
+	Corrected       bool      `json:"corrected"`
+}
+
+type RecomputeLeaveBalanceResponse struct {
+	EmployeeID    uuid.UUID                `json:"employee_id"`
+	Year          int                      `json:"year"`
+	DryRun        bool                     `json:"dry_run"`
+	Balances      []LeaveBalanceCorrection `json:"balances"`
+	Discrepancies int                      `json:"discrepancies"`
+}
+
+// InitializeLeaveBalancesResponse counts balance rows (employee and leave type)
+// created for a year and those skipped because they already existed
+type InitializeLeaveBalancesResponse struct {
+	Year    int `json:"year"`
+	Created int `json:"created"`
+	Skipped int `json:"skipped"`
+}
+
 // ==================== OVERTIME ====================
 
 type OvertimeRequestResponse struct {
@@ -359,11 +775,12 @@ type OvertimeRequestResponse struct {
 	CreatedAt     time.Time  `json:"created_at"`
 }
 
+// CreateOvertimeRequest.Type is only recorded for audit; the server classifies the overtime
 type CreateOvertimeRequest struct {
 	Date      string `json:"date" binding:"required"`
 	StartTime string `json:"start_time" binding:"required"`
 	EndTime   string `json:"end_time" binding:"required"`
-	Type      string `json:"type" binding:"required,oneof=weekday weekend holiday night"`
+	Type      string `json:"type" binding:"omitempty,oneof=weekday weekend holiday night"`
 	Reason    string `json:"reason" binding:"required"`
 }
 
@@ -372,6 +789,36 @@ type ApproveOvertimeRequest struct {
 	Notes  string `json:"notes"`
 }
 
+// BulkApproveOvertimeRequest selects pending requests either by id or by a
+// date range, optionally narrowed to a department
+type BulkApproveOvertimeRequest struct {
+	IDs          []string `json:"ids" binding:"omitempty,max=500,dive,uuid"`
+	DepartmentID string   `json:"department_id" binding:"omitempty,uuid"`
+	StartDate    string   `json:"start_date" binding:"omitempty,datetime=2006-01-02"`
+	EndDate      string   `json:"end_date" binding:"omitempty,datetime=2006-01-02"`
+	Notes        string   `json:"notes"`
+}
+
+// BulkApproveOvertimeResult is the outcome of one request. Skipped requests
+// carry the message key of the reason.
+type BulkApproveOvertimeResult struct {
+	ID           uuid.UUID `json:"id"`
+	EmployeeID   uuid.UUID `json:"employee_id,omitempty"`
+	EmployeeName string    `json:"employee_name,omitempty"`
+	Date         string    `json:"date,omitempty"`
+	Hours        float64   `json:"hours,omitempty"`
+	Status       string    `json:"status"`
+	Reason       string    `json:"reason,omitempty"`
+	MonthlyHours float64   `json:"monthly_hours,omitempty"`
+}
+
+type BulkApproveOvertimeResponse struct {
+	BatchID  uuid.UUID                   `json:"batch_id"`
+	Approved int                         `json:"approved"`
+	Skipped  int                         `json:"skipped"`
+	Results  []BulkApproveOvertimeResult `json:"results"`
+}
+
 // ==================== PAYROLL ====================
 
 type PayrollPeriodResponse struct {
@@ -400,6 +847,44 @@ type CreatePayrollPeriodRequest struct {
 	PayDate   string `json:"pay_date" binding:"required"`
 }
 
+// PayslipFlagResponse is an anomaly the calculation found on a payslip
+type PayslipFlagResponse struct {
+	ID           uuid.UUID              `json:"id"`
+	PayslipID    uuid.UUID              `json:"payslip_id"`
+	EmployeeID   uuid.UUID              `json:"employee_id"`
+	EmployeeCode string                 `json:"employee_code"`
+	EmployeeName string                 `json:"employee_name"`
+	NetSalary    float64                `json:"net_salary"`
+	Currency     string                 `json:"currency"`
+	Rule         string                 `json:"rule"`
+	Reason       string                 `json:"reason"`
+	Details      map[string]interface{} `json:"details,omitempty"`
+	Status       string                 `json:"status"`
+	ReviewedBy   *uuid.UUID             `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time             `json:"reviewed_at,omitempty"`
+	ReviewNotes  string                 `json:"review_notes,omitempty"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+// ReviewPayrollRequest acknowledges flagged payslips or sends them back for
+// recalculation
+type ReviewPayrollRequest struct {
+	Items []PayslipReviewItem `json:"items" binding:"required,min=1,dive"`
+}
+
+type PayslipReviewItem struct {
+	PayslipID string `json:"payslip_id" binding:"required,uuid"`
+	Action    string `json:"action" binding:"required,oneof=acknowledge send_back"`
+	Notes     string `json:"notes" binding:"max=1000"`
+}
+
+type ReviewPayrollResponse struct {
+	Acknowledged int `json:"acknowledged"`
+	SentBack     int `json:"sent_back"`
+	// Unreviewed is the number of flags still blocking approval
+	Unreviewed int `json:"unreviewed"`
+}
+
 type PayslipResponse struct {
 	ID                uuid.UUID `json:"id"`
 	EmployeeID        uuid.UUID `json:"employee_id"`
@@ -428,9 +913,70 @@ type PayslipResponse struct {
 	OtherDeductions   float64   `json:"other_deductions"`
 	TotalDeductions   float64   `json:"total_deductions"`
 	NetSalary         float64   `json:"net_salary"`
+	Currency          string    `json:"currency"`
 	Status            string    `json:"status"`
 }
 
+// AnnualPayslipsResponse lists confirmed and paid payslips of a year with totals for tax filing.
+// Totals are in Currency: the payslips' currency, or the reporting currency when they differ.
+type AnnualPayslipsResponse struct {
+	EmployeeID        uuid.UUID         `json:"employee_id"`
+	Year              int               `json:"year"`
+	Currency          string            `json:"currency"`
+	Payslips          []PayslipResponse `json:"payslips"`
+	GrossEarnings     float64           `json:"gross_earnings"`
+	TotalDeductions   float64           `json:"total_deductions"`
+	PersonalIncomeTax float64           `json:"personal_income_tax"`
+	NetSalary         float64           `json:"net_salary"`
+}
+
+// PayslipTrendPoint is one period of a take-home pay trend, in the trend currency.
+// Change fields compare with the previous point and are absent on the first.
+type PayslipTrendPoint struct {
+	PeriodID         uuid.UUID `json:"period_id"`
+	Year             int       `json:"year"`
+	Month            int       `json:"month"`
+	GrossEarnings    float64   `json:"gross_earnings"`
+	TotalDeductions  float64   `json:"total_deductions"`
+	NetSalary        float64   `json:"net_salary"`
+	NetChange        *float64  `json:"net_change,omitempty"`
+	NetChangePercent *float64  `json:"net_change_percent,omitempty"`
+}
+
+// PayslipTrendResponse is the net salary series of the most recent confirmed and
+// paid payslips, oldest first. Trend is "up", "down" or "flat" comparing the latest
+// period with the one before it.
+type PayslipTrendResponse struct {
+	EmployeeID       uuid.UUID           `json:"employee_id"`
+	Currency         string              `json:"currency"`
+	Months           int                 `json:"months"`
+	Points           []PayslipTrendPoint `json:"points"`
+	AverageGross     float64             `json:"average_gross"`
+	AverageDeduction float64             `json:"average_deductions"`
+	AverageNet       float64             `json:"average_net"`
+	LatestNet        float64             `json:"latest_net"`
+	NetChange        *float64            `json:"net_change,omitempty"`
+	NetChangePercent *float64            `json:"net_change_percent,omitempty"`
+	Trend            string              `json:"trend"`
+}
+
+// ExchangeRateRequest sets how much of QuoteCurrency one unit of Currency is worth from EffectiveDate
+type ExchangeRateRequest struct {
+	Currency      string  `json:"currency" binding:"required,len=3,uppercase"`
+	QuoteCurrency string  `json:"quote_currency" binding:"required,len=3,uppercase"`
+	Rate          float64 `json:"rate" binding:"required,gt=0"`
+	EffectiveDate string  `json:"effective_date" binding:"required"`
+}
+
+type ExchangeRateResponse struct {
+	ID            uuid.UUID `json:"id"`
+	Currency      string    `json:"currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Rate          float64   `json:"rate"`
+	EffectiveDate string    `json:"effective_date"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
 // ==================== ROLE & PERMISSION ====================
 
 type RoleResponse struct {
@@ -468,6 +1014,182 @@ type PermissionResponse struct {
 	Description string    `json:"description"`
 }
 
+// PermissionCatalogItem is a permission with its name and description in the
+// caller's language. Dangerous permissions deserve a warning in role editors.
+type PermissionCatalogItem struct {
+	ID          uuid.UUID `json:"id"`
+	Slug        string    `json:"slug"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Dangerous   bool      `json:"dangerous"`
+}
+
+type PermissionModuleResponse struct {
+	Module      string                  `json:"module"`
+	Name        string                  `json:"name"`
+	Permissions []PermissionCatalogItem `json:"permissions"`
+}
+
+// PreviewRolePermissionsRequest is the permission set a role would be saved with
+type PreviewRolePermissionsRequest struct {
+	PermissionIDs []string `json:"permission_ids" binding:"dive,uuid"`
+}
+
+// RolePermissionPreview is the effect of replacing a role's permissions.
+// Members holding a permission through another role are not affected by it.
+type RolePermissionPreview struct {
+	RoleID        uuid.UUID               `json:"role_id"`
+	Added         []PermissionCatalogItem `json:"added"`
+	Removed       []PermissionCatalogItem `json:"removed"`
+	MemberCount   int                     `json:"member_count"`
+	AffectedUsers int                     `json:"affected_users"`
+	SampleUsers   []RolePreviewUser       `json:"sample_users"`
+}
+
+// RolePreviewUser is a member whose effective permissions would change
+type RolePreviewUser struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Email    string    `json:"email"`
+	FullName string    `json:"full_name,omitempty"`
+	Gained   []string  `json:"gained"`
+	Lost     []string  `json:"lost"`
+}
+
+// ==================== SYSTEM ====================
+
+type UpdateWorkerSettingsRequest struct {
+	Concurrency int            `json:"concurrency" binding:"omitempty,min=1,max=200"`
+	Queues      map[string]int `json:"queues" binding:"omitempty,dive,min=1,max=100"`
+}
+
+type QueueStateResponse struct {
+	Name     string `json:"name"`
+	Weight   int    `json:"weight"`
+	Paused   bool   `json:"paused"`
+	Size     int    `json:"size"`
+	Pending  int    `json:"pending"`
+	Active   int    `json:"active"`
+	Retry    int    `json:"retry"`
+	Archived int    `json:"archived"`
+}
+
+type WorkerSettingsResponse struct {
+	Concurrency int                  `json:"concurrency"`
+	Queues      []QueueStateResponse `json:"queues"`
+	UpdatedBy   string               `json:"updated_by,omitempty"`
+	UpdatedAt   *time.Time           `json:"updated_at,omitempty"`
+}
+
+// TaskStatusResponse describes an enqueued task without its payload
+type TaskStatusResponse struct {
+	ID            string          `json:"id"`
+	Queue         string          `json:"queue"`
+	Type          string          `json:"type"`
+	State         string          `json:"state"`
+	Retried       int             `json:"retried"`
+	MaxRetry      int             `json:"max_retry"`
+	LastError     string          `json:"last_error,omitempty"`
+	LastFailedAt  *time.Time      `json:"last_failed_at,omitempty"`
+	NextProcessAt *time.Time      `json:"next_process_at,omitempty"`
+	CompletedAt   *time.Time      `json:"completed_at,omitempty"`
+	Result        json.RawMessage `json:"result,omitempty"`
+}
+
+type NotificationDeliveryFilter struct {
+	Status   string `form:"status" binding:"omitempty,oneof=pending retrying sent failed"`
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"page_size,default=20"`
+}
+
+// NotificationDeliveryResponse is the state of one channel delivery of a notification
+type NotificationDeliveryResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	NotificationID uuid.UUID  `json:"notification_id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	Email          string     `json:"email"`
+	Type           string     `json:"type"`
+	Title          string     `json:"title"`
+	Channel        string     `json:"channel"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastError      string     `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// ==================== AUDIT ====================
+
+type AuditFieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditLogResponse shows the full record for create/delete actions
+// and a field-level diff for everything else
+type AuditLogResponse struct {
+	ID        uuid.UUID          `json:"id"`
+	Action    string             `json:"action"`
+	TableName string             `json:"table_name"`
+	RecordID  uuid.UUID          `json:"record_id"`
+	UserID    *uuid.UUID         `json:"user_id,omitempty"`
+	ActorName string             `json:"actor_name,omitempty"`
+	Record    interface{}        `json:"record,omitempty"`
+	Changes   []AuditFieldChange `json:"changes,omitempty"`
+	IPAddress string             `json:"ip_address,omitempty"`
+	UserAgent string             `json:"user_agent,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type RBACAuditFilter struct {
+	TableName string `form:"table_name" binding:"omitempty,oneof=roles role_permissions user_roles"`
+	ActorID   string `form:"actor_id" binding:"omitempty,uuid"`
+	RoleID    string `form:"role_id" binding:"omitempty,uuid"`
+	UserID    string `form:"user_id" binding:"omitempty,uuid"`
+	StartDate string `form:"start_date" binding:"omitempty,datetime=2006-01-02"`
+	EndDate   string `form:"end_date" binding:"omitempty,datetime=2006-01-02"`
+	Format    string `form:"format" binding:"omitempty,oneof=json csv"`
+	Page      int    `form:"page,default=1"`
+	PageSize  int    `form:"page_size,default=20"`
+}
+
+// RBACAuditEntry is a change to roles, role permissions or role assignments.
+// RoleName is set for roles/role_permissions entries, AffectedUser for
+// user_roles entries, whose record_id is the user the roles belong to.
+type RBACAuditEntry struct {
+	ID           uuid.UUID          `json:"id"`
+	Action       string             `json:"action"`
+	TableName    string             `json:"table_name"`
+	RecordID     uuid.UUID          `json:"record_id"`
+	UserID       *uuid.UUID         `json:"user_id,omitempty"`
+	ActorName    string             `json:"actor_name,omitempty"`
+	RoleName     string             `json:"role_name,omitempty"`
+	AffectedUser string             `json:"affected_user,omitempty"`
+	Record       interface{}        `json:"record,omitempty"`
+	Changes      []AuditFieldChange `json:"changes,omitempty"`
+	IPAddress    string             `json:"ip_address,omitempty"`
+	CreatedAt    time.Time          `json:"created_at"`
+}
+
+// ==================== VALIDATION ====================
+
+type ValidateContactRequest struct {
+	Email string `json:"email" binding:"required_without=Phone,max=255"`
+	Phone string `json:"phone" binding:"required_without=Email,max=30"`
+}
+
+type ContactFieldResult struct {
+	Input      string `json:"input"`
+	Normalized string `json:"normalized"`
+	Valid      bool   `json:"valid"`
+}
+
+type ValidateContactResponse struct {
+	Email *ContactFieldResult `json:"email,omitempty"`
+	Phone *ContactFieldResult `json:"phone,omitempty"`
+}
+
 // ==================== ADDRESS ====================
 
 type ProvinceResponse struct {
@@ -496,34 +1218,178 @@ type WardResponse struct {
 	Type       string `json:"type"`
 }
 
+// ==================== HOLIDAY ====================
+
+// HolidayRequest creates or replaces a holiday. A recurring holiday repeats
+// on its month and day every year; a half-day holiday takes only half the
+// day off.
+type HolidayRequest struct {
+	Name        string `json:"name" binding:"required,max=255"`
+	Date        string `json:"date" binding:"required,datetime=2006-01-02"`
+	Type        string `json:"type" binding:"omitempty,max=50"`
+	Description string `json:"description" binding:"max=1000"`
+	IsRecurring bool   `json:"is_recurring"`
+	IsHalfDay   bool   `json:"is_half_day"`
+}
+
+// HolidayResponse is a holiday; in a year's list, recurring holidays carry
+// their date in that year
+type HolidayResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Date        string    `json:"date"`
+	Type        string    `json:"type"`
+	Description string    `json:"description"`
+	IsRecurring bool      `json:"is_recurring"`
+	IsHalfDay   bool      `json:"is_half_day"`
+	Year        int       `json:"year"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
 // ==================== REPORT ====================
 
 type ReportRequest struct {
-	ReportType string            `json:"report_type" binding:"required"`
-	Format     string            `json:"format" binding:"required,oneof=pdf excel csv"`
-	StartDate  string            `json:"start_date"`
-	EndDate    string            `json:"end_date"`
+	ReportType string            `json:"report_type" binding:"required,report_type"`
+	Format     string            `json:"format" binding:"required,report_format"`
+	StartDate  string            `json:"start_date" binding:"omitempty,datetime=2006-01-02"`
+	EndDate    string            `json:"end_date" binding:"omitempty,datetime=2006-01-02"`
 	Filters    map[string]string `json:"filters"`
 	SendEmail  bool              `json:"send_email"`
-	Email      string            `json:"email"`
+	Email      string            `json:"email" binding:"omitempty,email"`
+}
+
+type ReportFormatResponse struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Extension   string `json:"extension"`
 }
 
 type ReportResponse struct {
-	ID         string `json:"id"`
-	ReportType string `json:"report_type"`
-	Status     string `json:"status"`
-	FileURL    string `json:"file_url,omitempty"`
-	CreatedAt  string `json:"created_at"`
+	ID          uuid.UUID         `json:"id"`
+	ReportType  string            `json:"report_type"`
+	Format      string            `json:"format"`
+	Filters     map[string]string `json:"filters,omitempty"`
+	Status      string            `json:"status"`
+	Error       string            `json:"error,omitempty"`
+	FileName    string            `json:"file_name,omitempty"`
+	FileSize    int64             `json:"file_size,omitempty"`
+	RowCount    int               `json:"row_count"`
+	FileURL     string            `json:"file_url,omitempty"`
+	Email       string            `json:"email,omitempty"`
+	TaskID      string            `json:"task_id,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	StartedAt   *time.Time        `json:"started_at,omitempty"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+}
+
+type HeadcountTrendPoint struct {
+	Month     string `json:"month"`
+	Headcount int    `json:"headcount"`
+	Joiners   int    `json:"joiners"`
+	Leavers   int    `json:"leavers"`
+	NetChange int    `json:"net_change"`
+	Source    string `json:"source"`
+}
+
+// DashboardKPIsResponse holds the top-line figures of the dashboard. Scope is
+// "organization" or "team", whose employees the figures count; the payroll
+// period is only included for holders of payroll.view.
+type DashboardKPIsResponse struct {
+	Scope                   string                  `json:"scope"`
+	ActiveHeadcount         int                     `json:"active_headcount"`
+	NewHires                int                     `json:"new_hires"`
+	Leavers                 int                     `json:"leavers"`
+	PendingLeaveRequests    int                     `json:"pending_leave_requests"`
+	PendingOvertimeRequests int                     `json:"pending_overtime_requests"`
+	AttendanceToday         DashboardAttendance     `json:"attendance_today"`
+	PayrollPeriod           *DashboardPayrollPeriod `json:"payroll_period"`
+	ExpiringContracts       int                     `json:"expiring_contracts"`
+	GeneratedAt             time.Time               `json:"generated_at"`
+}
+
+// DashboardAttendance is today's attendance. Employees on approved leave are
+// not expected; Rate is the percentage of the expected who checked in.
+type DashboardAttendance struct {
+	Expected  int     `json:"expected"`
+	CheckedIn int     `json:"checked_in"`
+	OnLeave   int     `json:"on_leave"`
+	Rate      float64 `json:"rate"`
+}
+
+// DashboardPayrollPeriod is the latest payroll period not yet paid
+type DashboardPayrollPeriod struct {
+	ID      uuid.UUID `json:"id"`
+	Name    string    `json:"name"`
+	Status  string    `json:"status"`
+	PayDate string    `json:"pay_date"`
 }
 
 // ==================== NOTIFICATION ====================
 
+// NotificationListQuery filters the caller's notifications. Read selects read
+// (true) or unread (false) ones; without it both are listed.
+type NotificationListQuery struct {
+	Read     *bool  `form:"read"`
+	Type     string `form:"type" binding:"omitempty,max=50"`
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"page_size,default=20"`
+}
+
 type NotificationResponse struct {
-	ID        uuid.UUID  `json:"id"`
-	Title     string     `json:"title"`
-	Message   string     `json:"message"`
-	Type      string     `json:"type"`
-	ReadAt    *time.Time `json:"read_at,omitempty"`
-	ActionURL string     `json:"action_url,omitempty"`
-	CreatedAt time.Time  `json:"created_at"`
+	ID        uuid.UUID              `json:"id"`
+	Title     string                 `json:"title"`
+	Message   string                 `json:"message"`
+	Type      string                 `json:"type"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	ReadAt    *time.Time             `json:"read_at,omitempty"`
+	ActionURL string                 `json:"action_url,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+type NotificationSyncItem struct {
+	ID     uuid.UUID  `json:"id" binding:"required"`
+	ReadAt *time.Time `json:"read_at"`
+}
+
+type SyncNotificationsRequest struct {
+	Items []NotificationSyncItem `json:"items" binding:"max=500,dive"`
+	Since *time.Time             `json:"since"`
+}
+
+type NotificationStateResponse struct {
+	ID     uuid.UUID  `json:"id"`
+	ReadAt *time.Time `json:"read_at"`
+}
+
+type SyncNotificationsResponse struct {
+	States        []NotificationStateResponse `json:"states"`
+	Notifications []NotificationResponse      `json:"notifications"`
+	Cursor        time.Time                   `json:"cursor"`
+}
+
+// BroadcastNotificationRequest sends one notification to every active user, or
+// to those in DepartmentIDs and holding one of RoleIDs when given
+type BroadcastNotificationRequest struct {
+	Title         string                 `json:"title" binding:"required,max=255"`
+	Message       string                 `json:"message" binding:"required,max=5000"`
+	Type          string                 `json:"type" binding:"omitempty,max=50"`
+	Data          map[string]interface{} `json:"data"`
+	DepartmentIDs []string               `json:"department_ids" binding:"omitempty,dive,uuid"`
+	RoleIDs       []string               `json:"role_ids" binding:"omitempty,dive,uuid"`
+}
+
+// BroadcastResponse is the state of a broadcast fan-out. TaskID and Queue
+// locate the worker task for the task status endpoint.
+type BroadcastResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	Type        string     `json:"type"`
+	Status      string     `json:"status"`
+	Total       int        `json:"total"`
+	Processed   int        `json:"processed"`
+	TaskID      string     `json:"task_id"`
+	Queue       string     `json:"queue"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
 }
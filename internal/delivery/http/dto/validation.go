@@ -0,0 +1,25 @@
+package dto
+
+import (
+	"hr-management-system/internal/report"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Custom binding tags. report_format accepts the formats registered with the
+// report package, so a new format needs no tag change; report_type accepts
+// the report types the worker generates.
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterValidation("report_format", func(fl validator.FieldLevel) bool {
+		_, ok := report.Lookup(fl.Field().String())
+		return ok
+	})
+	v.RegisterValidation("report_type", func(fl validator.FieldLevel) bool {
+		return report.IsType(fl.Field().String())
+	})
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/infrastructure/database"
+	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/security"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Approval routing sources
+const (
+	approvalRoutingDepartment = "department"
+	approvalRoutingManager    = "manager"
+)
+
+// approvalRoute is who handles an employee's leave, overtime or attendance
+// requests. Notify is who receives a new request; Approvers is everyone
+// allowed to act on it. The backup approver can always act but is only
+// notified while the primary approver is unavailable.
+type approvalRoute struct {
+	Source       string
+	EmployeeName string
+	Notify       []string
+	Approvers    []string
+}
+
+// resolveApprovalRoute applies the department_approvers configuration of the
+// employee's department, falling back to the direct manager (or the
+// department manager) when none is configured or nobody it names can act.
+// Nobody is routed their own requests: an approver named for their own
+// department has their requests routed to their manager as well.
+func resolveApprovalRoute(ctx context.Context, db *database.Database, employeeID uuid.UUID, requestType string) (*approvalRoute, error) {
+	var ownUserID, employeeName string
+	var configured bool
+	var primaryUserID, backupUserID, roleID sql.NullString
+	var primaryAway bool
+	err := db.QueryRowContext(ctx, `
+		SELECT e.user_id, e.full_name, da.department_id IS NOT NULL,
+		       pe.user_id, be.user_id, da.approver_role_id,
+		       pe.id IS NULL OR EXISTS (
+		           SELECT 1 FROM leave_requests lr
+		           WHERE lr.employee_id = pe.id AND lr.status = 'approved' AND lr.deleted_at IS NULL
+		             AND CURRENT_DATE BETWEEN lr.start_date AND lr.end_date
+		       )
+		FROM employees e
+		LEFT JOIN department_approvers da ON da.department_id = e.department_id AND da.request_type = $2
+		LEFT JOIN employees pe ON pe.id = da.primary_approver_id AND pe.employment_status = 'active' AND pe.deleted_at IS NULL
+		LEFT JOIN employees be ON be.id = da.backup_approver_id AND be.employment_status = 'active' AND be.deleted_at IS NULL
+		WHERE e.id = $1
+	`, employeeID, requestType).Scan(&ownUserID, &employeeName, &configured, &primaryUserID, &backupUserID, &roleID, &primaryAway)
+	if err != nil {
+		return nil, err
+	}
+
+	route := &approvalRoute{Source: approvalRoutingDepartment, EmployeeName: employeeName}
+	var selfRouted bool
+	add := func(userID string, notify bool) {
+		if userID == ownUserID {
+			selfRouted = true
+			return
+		}
+		if userID == "" || containsString(route.Approvers, userID) {
+			return
+		}
+		route.Approvers = append(route.Approvers, userID)
+		if notify {
+			route.Notify = append(route.Notify, userID)
+		}
+	}
+
+	if configured {
+		add(primaryUserID.String, !primaryAway)
+		add(backupUserID.String, primaryAway)
+		if roleID.Valid {
+			rows, err := db.QueryContext(ctx, `
+				SELECT ur.user_id FROM user_roles ur
+				INNER JOIN users u ON u.id = ur.user_id
+				WHERE ur.role_id = $1 AND u.status = 'active' AND u.deleted_at IS NULL
+			`, roleID.String)
+			if err != nil {
+				return nil, err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var userID string
+				if err := rows.Scan(&userID); err != nil {
+					return nil, err
+				}
+				add(userID, true)
+			}
+			if err := rows.Err(); err != nil {
+				return nil, err
+			}
+		}
+		// A primary on leave without a backup is still notified; a
+		// configuration naming nobody who can act falls back to the manager
+		if len(route.Notify) == 0 && len(route.Approvers) > 0 {
+			route.Notify = append(route.Notify, route.Approvers...)
+		}
+		if len(route.Approvers) > 0 && !selfRouted {
+			return route, nil
+		}
+	}
+
+	if len(route.Approvers) == 0 {
+		route.Source = approvalRoutingManager
+	}
+	var managerUserID sql.NullString
+	err = db.QueryRowContext(ctx, `
+		SELECT COALESCE(m.user_id, dm.user_id)
+		FROM employees e
+		LEFT JOIN employees m ON m.id = e.manager_id AND m.deleted_at IS NULL
+		INNER JOIN departments d ON d.id = e.department_id
+		LEFT JOIN employees dm ON dm.id = d.manager_id AND dm.id <> e.id AND dm.deleted_at IS NULL
+		WHERE e.id = $1
+	`, employeeID).Scan(&managerUserID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	add(managerUserID.String, true)
+	return route, nil
+}
+
+// canActOnRequest reports whether the caller may approve or reject the
+// employee's request: a routed approver, or HR holding the manage permission
+// of the request type.
+func canActOnRequest(ctx context.Context, db *database.Database, c *gin.Context, employeeID uuid.UUID, requestType string) (bool, error) {
+	if security.HasPermission(middleware.GetPermissions(c), requestType+".manage") {
+		return true, nil
+	}
+	route, err := resolveApprovalRoute(ctx, db, employeeID, requestType)
+	if err != nil {
+		return false, err
+	}
+	return containsString(route.Approvers, middleware.GetUserID(c)), nil
+}
+
+// authorizeApproval returns the message key of why the caller may not
+// approve or reject the employee's request, or "" when they may. Unless
+// allowSelf is set nobody decides their own request, not even with the manage
+// permission, and it waits for their manager or the department's other
+// approvers. With allowSelf, holders of the approve permission of the request
+// type may decide their own requests, which the route never offers them.
+func authorizeApproval(ctx context.Context, db *database.Database, c *gin.Context, employeeID uuid.UUID, requestType string, allowSelf bool) (string, error) {
+	var own bool
+	err := db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM employees WHERE id = $1 AND user_id::text = $2)
+	`, employeeID, middleware.GetUserID(c)).Scan(&own)
+	if err != nil {
+		return "", err
+	}
+	if own && !allowSelf {
+		return "approval.self_not_allowed", nil
+	}
+	if own && security.HasPermission(middleware.GetPermissions(c), requestType+".approve") {
+		return "", nil
+	}
+	allowed, err := canActOnRequest(ctx, db, c, employeeID, requestType)
+	if err != nil || allowed {
+		return "", err
+	}
+	return "permission.denied", nil
+}
+
+// notifyApprovers tells the routed approvers about a new request
+func notifyApprovers(ctx context.Context, q *queue.Queue, route *approvalRoute, title, message, notificationType string, data map[string]interface{}) {
+	for _, userID := range route.Notify {
+		q.SendNotification(ctx, queue.NotificationPayload{
+			UserID:  userID,
+			Title:   title,
+			Message: message,
+			Type:    notificationType,
+			Data:    data,
+		})
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
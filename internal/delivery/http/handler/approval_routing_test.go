@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"hr-management-system/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// approvalCase is a pending leave, overtime or attendance request of an
+// employee and the handler deciding it
+type approvalCase struct {
+	name    string
+	id      uuid.UUID
+	body    interface{}
+	handler gin.HandlerFunc
+	table   string
+}
+
+// pendingApprovals creates a pending request of each type for the employee,
+// with the leave balance to approve it
+func pendingApprovals(t *testing.T, env *testEnv, employeeID uuid.UUID) []approvalCase {
+	t.Helper()
+	date := time.Now().AddDate(0, 0, 7)
+
+	var leaveID, overtimeID, attendanceID uuid.UUID
+	_, err := env.db.Exec(`
+		INSERT INTO leave_balances (employee_id, leave_type_id, year, total_days, pending_days)
+		VALUES ($1, $2, $3, 5, 1)
+	`, employeeID, testutil.LeaveTypeAnnual, date.Year())
+	testutil.Must(t, err, "create leave balance")
+	testutil.Must(t, env.db.QueryRow(`
+		INSERT INTO leave_requests (employee_id, leave_type_id, start_date, end_date, total_days, reason)
+		VALUES ($1, $2, $3, $3, 1, 'test') RETURNING id
+	`, employeeID, testutil.LeaveTypeAnnual, date.Format("2006-01-02")).Scan(&leaveID), "create leave request")
+	testutil.Must(t, env.db.QueryRow(`
+		INSERT INTO overtime_requests (employee_id, date, start_time, end_time, hours, reason, type)
+		VALUES ($1, $2, '18:00', '20:00', 2, 'test', 'weekday') RETURNING id
+	`, employeeID, date.Format("2006-01-02")).Scan(&overtimeID), "create overtime request")
+	testutil.Must(t, env.db.QueryRow(`
+		INSERT INTO attendances (employee_id, date, check_in, check_out, working_hours, status)
+		VALUES ($1, CURRENT_DATE, CURRENT_DATE + TIME '08:00', CURRENT_DATE + TIME '17:00', 8, 'present') RETURNING id
+	`, employeeID).Scan(&attendanceID), "create attendance")
+
+	approve := gin.H{"status": "approved"}
+	return []approvalCase{
+		{"leave", leaveID, approve, NewLeaveHandler(env.db, env.cache, env.queue, env.log, env.cfg).Approve, "leave_requests"},
+		{"overtime", overtimeID, approve, NewOvertimeHandler(env.db, env.cache, env.queue, env.log, env.cfg).Approve, "overtime_requests"},
+		{"attendance", attendanceID, nil, NewAttendanceHandler(env.db, env.cache, env.queue, env.log, env.cfg).Approve, "attendances"},
+	}
+}
+
+// Employees holding every approval and manage permission still cannot decide
+// their own requests unless self-approval is allowed
+func TestSelfApprovalRejected(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.Security.AllowSelfApproval = false
+
+	manager := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{PositionID: testutil.PositionManager})
+	employee := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{ManagerID: &manager.ID})
+
+	for _, tt := range pendingApprovals(t, env, employee.ID) {
+		t.Run(tt.name, func(t *testing.T) {
+			c, recorder := testutil.Request(http.MethodPut, "/", tt.body, employee.UserID, tt.name+".approve", tt.name+".manage")
+			testutil.Param(c, "id", tt.id.String())
+			tt.handler(c)
+
+			testutil.ExpectStatus(t, recorder, http.StatusForbidden)
+			testutil.ExpectMessage(t, recorder, "approval.self_not_allowed")
+
+			var approvedAt *time.Time
+			testutil.Must(t, env.db.QueryRow(`SELECT approved_at FROM `+tt.table+` WHERE id = $1`, tt.id).Scan(&approvedAt),
+				"read %s", tt.table)
+			if approvedAt != nil {
+				t.Fatalf("%s was approved by its own employee", tt.table)
+			}
+		})
+	}
+}
+
+// With self-approval allowed, an employee holding only the approve
+// permission decides their own requests, while one without it still cannot
+func TestSelfApprovalAllowed(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.Security.AllowSelfApproval = true
+
+	manager := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{PositionID: testutil.PositionManager})
+	approver := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{PositionID: testutil.PositionManager, ManagerID: &manager.ID})
+	employee := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{ManagerID: &manager.ID})
+
+	for _, tt := range pendingApprovals(t, env, employee.ID) {
+		t.Run(tt.name+" without the approve permission", func(t *testing.T) {
+			c, recorder := testutil.Request(http.MethodPut, "/", tt.body, employee.UserID, tt.name+".view")
+			testutil.Param(c, "id", tt.id.String())
+			tt.handler(c)
+			testutil.ExpectStatus(t, recorder, http.StatusForbidden)
+			testutil.ExpectMessage(t, recorder, "permission.denied")
+		})
+	}
+
+	for _, tt := range pendingApprovals(t, env, approver.ID) {
+		t.Run(tt.name, func(t *testing.T) {
+			c, recorder := testutil.Request(http.MethodPut, "/", tt.body, approver.UserID, tt.name+".approve")
+			testutil.Param(c, "id", tt.id.String())
+			tt.handler(c)
+			testutil.ExpectStatus(t, recorder, http.StatusOK)
+
+			var approvedBy *uuid.UUID
+			testutil.Must(t, env.db.QueryRow(`SELECT approved_by FROM `+tt.table+` WHERE id = $1`, tt.id).Scan(&approvedBy),
+				"read %s", tt.table)
+			if approvedBy == nil || *approvedBy != approver.ID {
+				t.Fatalf("%s approved by %v, want the employee %s", tt.table, approvedBy, approver.ID)
+			}
+		})
+	}
+}
+
+// A department approver's own requests go to the other approvers and to
+// their manager, never to themselves
+func TestApprovalRouteSkipsRequester(t *testing.T) {
+	env := newTestEnv(t)
+	department := createOrgUnit(t, env.db, "departments", nil)
+	manager := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{PositionID: testutil.PositionManager})
+	primary := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{DepartmentID: department.String(), ManagerID: &manager.ID})
+	backup := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{DepartmentID: department.String(), ManagerID: &manager.ID})
+	employee := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{DepartmentID: department.String(), ManagerID: &manager.ID})
+	_, err := env.db.Exec(`
+		INSERT INTO department_approvers (department_id, request_type, primary_approver_id, backup_approver_id)
+		VALUES ($1, 'leave', $2, $3)
+	`, department, primary.ID, backup.ID)
+	testutil.Must(t, err, "configure approvers")
+
+	tests := []struct {
+		name      string
+		requester uuid.UUID
+		approvers []string
+	}{
+		{"employee", employee.ID, []string{primary.UserID, backup.UserID}},
+		{"primary approver", primary.ID, []string{backup.UserID, manager.UserID}},
+	}
+	for _, tt := range tests {
+		route, err := resolveApprovalRoute(context.Background(), env.db, tt.requester, "leave")
+		testutil.Must(t, err, "resolve route")
+		if strings.Join(route.Approvers, ",") != strings.Join(tt.approvers, ",") {
+			t.Errorf("%s: approvers %v, want %v", tt.name, route.Approvers, tt.approvers)
+		}
+		if route.Source != approvalRoutingDepartment {
+			t.Errorf("%s: routed by %s, want the department", tt.name, route.Source)
+		}
+	}
+}
+
+func TestOvertimeApproveByManager(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewOvertimeHandler(env.db, env.cache, env.queue, env.log, env.cfg)
+
+	manager := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{PositionID: testutil.PositionManager})
+	employee := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{ManagerID: &manager.ID})
+
+	var overtimeID uuid.UUID
+	testutil.Must(t, env.db.QueryRow(`
+		INSERT INTO overtime_requests (employee_id, date, start_time, end_time, hours, reason, type)
+		VALUES ($1, CURRENT_DATE, '18:00', '20:00', 2, 'test', 'weekday') RETURNING id
+	`, employee.ID).Scan(&overtimeID), "create overtime request")
+
+	decide := func() *httptest.ResponseRecorder {
+		c, recorder := testutil.Request(http.MethodPut, "/", gin.H{"status": "approved"}, manager.UserID, "overtime.approve")
+		testutil.Param(c, "id", overtimeID.String())
+		h.Approve(c)
+		return recorder
+	}
+
+	testutil.ExpectStatus(t, decide(), http.StatusOK)
+	var status string
+	var approvedBy uuid.UUID
+	testutil.Must(t, env.db.QueryRow(`SELECT status, approved_by FROM overtime_requests WHERE id = $1`, overtimeID).
+		Scan(&status, &approvedBy), "read overtime request")
+	if status != "approved" || approvedBy != manager.ID {
+		t.Fatalf("status = %s approved_by = %s, want approved by %s", status, approvedBy, manager.ID)
+	}
+
+	// A second decision finds the request no longer pending
+	recorder := decide()
+	testutil.ExpectStatus(t, recorder, http.StatusUnprocessableEntity)
+	testutil.ExpectMessage(t, recorder, "overtime.not_pending")
+}
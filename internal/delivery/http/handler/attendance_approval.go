@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"math"
+	"time"
+
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/payroll"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Approve signs off an attendance record. The approver may correct the
+// check-in and check-out first, for a forgotten check-out or a clock-in from
+// the wrong device; working and overtime hours, and whether the day was late
+// or an early leave, are then worked out again from the employee's shift.
+func (h *AttendanceHandler) Approve(c *gin.Context) {
+	var req dto.ApproveAttendanceRequest
+	// The body is optional: without one the record is approved as it stands
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	id := c.Param("id")
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	if _, err := uuid.Parse(id); err != nil {
+		response.NotFound(c, "attendance.not_found")
+		return
+	}
+
+	var employeeID uuid.UUID
+	var date time.Time
+	var checkIn, checkOut sql.NullTime
+	var workMode, status string
+	var workingHours, overtimeHours float64
+	err := h.db.QueryRowContext(ctx, `
+		SELECT employee_id, date, check_in, check_out, work_mode, status,
+		       COALESCE(working_hours, 0), COALESCE(overtime_hours, 0)
+		FROM attendances WHERE id = $1 AND deleted_at IS NULL
+	`, id).Scan(&employeeID, &date, &checkIn, &checkOut, &workMode, &status, &workingHours, &overtimeHours)
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "attendance.not_found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	if key, err := authorizeApproval(ctx, h.db, c, employeeID, "attendance", h.cfg.Security.AllowSelfApproval); err != nil {
+		response.InternalError(c, err)
+		return
+	} else if key != "" {
+		response.Forbidden(c, key)
+		return
+	}
+
+	old := gin.H{"check_in": nullTimeValue(checkIn), "check_out": nullTimeValue(checkOut), "status": status,
+		"working_hours": workingHours, "overtime_hours": overtimeHours}
+
+	// Timestamps are stored as local wall-clock time
+	if checkIn.Valid {
+		checkIn.Time = wallClock(checkIn.Time)
+	}
+	if checkOut.Valid {
+		checkOut.Time = wallClock(checkOut.Time)
+	}
+	corrected := req.CheckIn != nil || req.CheckOut != nil
+	if req.CheckIn != nil {
+		checkIn = sql.NullTime{Time: req.CheckIn.In(time.Local), Valid: true}
+	}
+	if req.CheckOut != nil {
+		checkOut = sql.NullTime{Time: req.CheckOut.In(time.Local), Valid: true}
+	}
+	if checkIn.Valid && checkOut.Valid && !checkOut.Time.After(checkIn.Time) {
+		response.UnprocessableEntity(c, "attendance.invalid_times", nil)
+		return
+	}
+
+	if checkIn.Valid && checkOut.Valid {
+		day, err := h.workedDay(ctx, employeeID, date, workMode, checkIn.Time, checkOut.Time)
+		if err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		workingHours, overtimeHours = day.WorkingHours, day.OvertimeHours
+		// Leave and holiday statuses stand; lateness follows corrected times
+		if corrected && clockedStatus(status) {
+			status = day.Status
+		}
+	}
+
+	var approverID interface{}
+	var approverEmployeeID uuid.UUID
+	if err := h.db.QueryRowContext(ctx, `SELECT id FROM employees WHERE user_id = $1 AND deleted_at IS NULL`, userID).Scan(&approverEmployeeID); err == nil {
+		approverID = approverEmployeeID
+	}
+
+	now := time.Now()
+	err = h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE attendances
+			SET check_in = $1, check_out = $2, working_hours = $3, overtime_hours = $4, status = $5,
+			    notes = COALESCE($6, notes), approved_by = $7, approved_at = $8, updated_at = NOW()
+			WHERE id = $9
+		`, checkIn, checkOut, workingHours, overtimeHours, status, nullIfEmpty(req.Notes), approverID, now, id); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO attendance_logs (id, attendance_id, action, timestamp, ip_address, user_agent)
+			VALUES ($1, $2, 'approve', $3, $4, $5)
+		`, uuid.New(), id, now, c.ClientIP(), c.Request.UserAgent())
+		return err
+	})
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	// Team views of the day and the monthly summary hold the old figures
+	h.forgetAttendanceDay(ctx, employeeID, date)
+
+	updated := gin.H{"check_in": nullTimeValue(checkIn), "check_out": nullTimeValue(checkOut), "status": status,
+		"working_hours": workingHours, "overtime_hours": overtimeHours}
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: userID, Action: "approve", TableName: "attendances", RecordID: id,
+		OldValues: old, NewValues: updated,
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	updated["id"] = id
+	updated["approved_at"] = now
+	response.OK(c, "common.updated", updated)
+}
+
+// workedDayResult is an attendance worked out from its times
+type workedDayResult struct {
+	WorkingHours  float64
+	OvertimeHours float64
+	// Status is present, late or early_leave against the shift
+	Status string
+}
+
+// workedDay works out the working and overtime hours of a day from its
+// check-in and check-out and the employee's shift, and whether the arrival
+// was late or the departure early. Flexible days without a shift are never
+// late.
+func (h *AttendanceHandler) workedDay(ctx context.Context, employeeID uuid.UUID, date time.Time, workMode string, checkIn, checkOut time.Time) (workedDayResult, error) {
+	result := workedDayResult{Status: "present"}
+	schedule, scheduled, err := h.scheduleOn(ctx, employeeID, date, workMode)
+	if err != nil {
+		return result, err
+	}
+	worked := checkOut.Sub(checkIn).Hours()
+	if scheduled {
+		worked = schedule.workedHours(checkIn, checkOut)
+		grace := payroll.NewService(h.db).LateGrace(ctx, time.Duration(h.cfg.Attendance.LateGraceMinutes)*time.Minute)
+		switch {
+		case checkIn.Sub(schedule.Start) > grace:
+			result.Status = "late"
+		case checkOut.Before(schedule.End):
+			result.Status = "early_leave"
+		}
+	}
+	expected, err := h.expectedHours(ctx, employeeID, date.Format("2006-01-02"), workMode)
+	if err != nil {
+		return result, err
+	}
+	result.WorkingHours = roundHours(worked)
+	result.OvertimeHours = roundHours(math.Max(0, worked-expected))
+	return result, nil
+}
+
+// clockedStatus reports whether an attendance status comes from clock times,
+// rather than leave or the calendar, and so follows corrected times
+func clockedStatus(status string) bool {
+	return status == "present" || status == "late" || status == "early_leave"
+}
+
+// nullTimeValue is the time, or nil when unset, for JSON
+func nullTimeValue(t sql.NullTime) interface{} {
+	if !t.Valid {
+		return nil
+	}
+	return t.Time
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"hr-management-system/internal/testutil"
+
+	"github.com/google/uuid"
+)
+
+// An HR employee named as the department's attendance approver decides its
+// attendance, other holders of attendance.approve cannot, and the approval
+// clears the employee's cached monthly summary
+func TestAttendanceApproveRouted(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.Security.AllowSelfApproval = false
+	h := NewAttendanceHandler(env.db, env.cache, env.queue, env.log, env.cfg)
+	ctx := context.Background()
+
+	department := createOrgUnit(t, env.db, "departments", nil)
+	manager := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{PositionID: testutil.PositionManager})
+	employee := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{DepartmentID: department.String(), ManagerID: &manager.ID})
+	hr := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{DepartmentID: testutil.DepartmentHR})
+	other := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{DepartmentID: testutil.DepartmentHR})
+	_, err := env.db.Exec(`
+		INSERT INTO department_approvers (department_id, request_type, primary_approver_id)
+		VALUES ($1, 'attendance', $2)
+	`, department, hr.ID)
+	testutil.Must(t, err, "route attendance to HR")
+
+	var attendanceID uuid.UUID
+	var date time.Time
+	testutil.Must(t, env.db.QueryRow(`
+		INSERT INTO attendances (employee_id, date, check_in, check_out, working_hours, status)
+		VALUES ($1, CURRENT_DATE, CURRENT_DATE + TIME '08:00', CURRENT_DATE + TIME '17:00', 8, 'present')
+		RETURNING id, date
+	`, employee.ID).Scan(&attendanceID, &date), "create attendance")
+
+	summaryKey := attendanceSummaryKey(employee.ID, date)
+	testutil.Must(t, env.cache.Set(ctx, summaryKey, map[string]int{"present_days": 0}, time.Hour), "cache summary")
+
+	approve := func(userID string) int {
+		c, recorder := testutil.Request(http.MethodPut, "/", nil, userID, "attendance.approve")
+		testutil.Param(c, "id", attendanceID.String())
+		h.Approve(c)
+		return recorder.Code
+	}
+
+	if code := approve(other.UserID); code != http.StatusForbidden {
+		t.Fatalf("an approver outside the route got %d, want %d", code, http.StatusForbidden)
+	}
+	if code := approve(hr.UserID); code != http.StatusOK {
+		t.Fatalf("the routed HR approver got %d, want %d", code, http.StatusOK)
+	}
+
+	var approvedBy uuid.UUID
+	testutil.Must(t, env.db.QueryRow(`SELECT approved_by FROM attendances WHERE id = $1`, attendanceID).Scan(&approvedBy), "read attendance")
+	if approvedBy != hr.ID {
+		t.Errorf("approved by %s, want %s", approvedBy, hr.ID)
+	}
+	var cached map[string]int
+	if err := env.cache.Get(ctx, summaryKey, &cached); err == nil {
+		t.Error("the monthly summary is still cached after the approval")
+	}
+}
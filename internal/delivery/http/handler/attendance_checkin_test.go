@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"hr-management-system/internal/testutil"
+)
+
+// Check-ins sent together, as from a double tap or two devices, record one
+// attendance; the others are told the employee already checked in
+func TestCheckInConcurrent(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.Attendance.OfficeNetworks = nil
+	env.cfg.Attendance.MinRestPeriod = 0
+	h := NewAttendanceHandler(env.db, env.cache, env.queue, env.log, env.cfg)
+	employee := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{})
+
+	const requests = 2
+	start := make(chan struct{})
+	recorders := make([]*httptest.ResponseRecorder, requests)
+	var wg sync.WaitGroup
+	for i := range recorders {
+		c, recorder := testutil.Request(http.MethodPost, "/attendance/check-in", nil, employee.UserID)
+		recorders[i] = recorder
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			h.CheckIn(c)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	checkedIn := 0
+	for _, recorder := range recorders {
+		switch recorder.Code {
+		case http.StatusOK:
+			checkedIn++
+		case http.StatusConflict:
+			testutil.ExpectMessage(t, recorder, "attendance.already_checked_in")
+		default:
+			t.Fatalf("unexpected response %d %s", recorder.Code, recorder.Body.String())
+		}
+	}
+	if checkedIn != 1 {
+		t.Fatalf("%d of %d concurrent check-ins succeeded, want 1", checkedIn, requests)
+	}
+
+	var attendances, logs int
+	testutil.Must(t, env.db.QueryRow(`
+		SELECT COUNT(*) FROM attendances WHERE employee_id = $1
+	`, employee.ID).Scan(&attendances), "count attendances")
+	testutil.Must(t, env.db.QueryRow(`
+		SELECT COUNT(*) FROM attendance_logs l INNER JOIN attendances a ON a.id = l.attendance_id
+		WHERE a.employee_id = $1 AND l.action = 'check_in'
+	`, employee.ID).Scan(&logs), "count check-in logs")
+	if attendances != 1 || logs != 1 {
+		t.Fatalf("%d attendances with %d check-in logs, want 1 of each", attendances, logs)
+	}
+}
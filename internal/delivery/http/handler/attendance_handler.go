@@ -1,8 +1,13 @@
 package handler
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,9 +19,11 @@ import (
 	"hr-management-system/internal/infrastructure/database"
 	"hr-management-system/internal/infrastructure/logger"
 	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/payroll"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type AttendanceHandler struct {
@@ -39,7 +46,7 @@ func (h *AttendanceHandler) CheckIn(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	ctx := c.Request.Context()
 	clientIP := c.ClientIP()
-	today := time.Now().Format("2006-01-02")
+	now := time.Now()
 
 	// Get employee ID
 	var employeeID uuid.UUID
@@ -49,10 +56,18 @@ func (h *AttendanceHandler) CheckIn(c *gin.Context) {
 		return
 	}
 
-	// Check if already checked in today
+	date, err := h.attendanceDate(ctx, employeeID, now)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	today := date.Format("2006-01-02")
+
+	// Check if already checked in today. A row without a check-in, such as
+	// a half day of leave, is checked in on.
 	var existingID uuid.UUID
 	err = h.db.QueryRowContext(ctx, `
-		SELECT id FROM attendances WHERE employee_id = $1 AND date = $2
+		SELECT id FROM attendances WHERE employee_id = $1 AND date = $2 AND check_in IS NOT NULL
 	`, employeeID, today).Scan(&existingID)
 
 	if err == nil {
@@ -60,15 +75,94 @@ func (h *AttendanceHandler) CheckIn(c *gin.Context) {
 		return
 	}
 
-	// Create attendance record
+	// Remote days skip the office network check
+	workMode, err := workModeOn(ctx, h.db, employeeID, today)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if workMode == workModeOffice && !inNetworks(clientIP, h.cfg.Attendance.OfficeNetworks) {
+		response.Forbidden(c, "attendance.outside_office_network")
+		return
+	}
+
+	// Office days must also check in within the geofence when it is enabled;
+	// the distance is kept on the log for auditing
+	var deviceInfo interface{}
+	if workMode == workModeOffice {
+		fence, err := loadGeofence(ctx, h.db)
+		if err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		if fence.Enabled {
+			lat, lng, ok := parseLatLng(req.Location)
+			if !ok {
+				response.BadRequest(c, "attendance.invalid_location", nil)
+				return
+			}
+			distance := math.Round(distanceMeters(fence.Latitude, fence.Longitude, lat, lng))
+			if distance > fence.Radius {
+				response.UnprocessableEntity(c, "attendance.outside_geofence", map[string]string{
+					"distance_meters": strconv.FormatFloat(distance, 'f', 0, 64),
+					"radius_meters":   strconv.FormatFloat(fence.Radius, 'f', 0, 64),
+				})
+				return
+			}
+			info, _ := json.Marshal(gin.H{"geofence_distance_meters": distance, "geofence_radius_meters": fence.Radius})
+			deviceInfo = string(info)
+		}
+	}
+
+	lastWork, err := lastWorkBeforeRest(ctx, h.db, h.cfg.Attendance.MinRestPeriod, employeeID, now)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	var restWarning map[string]string
+	if lastWork != nil {
+		restWarning = restPeriodDetails(*lastWork, h.cfg.Attendance.MinRestPeriod)
+		if !h.cfg.Attendance.RestWarnOnly {
+			response.UnprocessableEntity(c, "attendance.insufficient_rest", restWarning)
+			return
+		}
+	}
+
+	// Arrivals later than the grace period after the shift start are late
+	status := "present"
+	var lateMinutes int
+	schedule, scheduled, err := h.scheduleOn(ctx, employeeID, date, workMode)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	grace := payroll.NewService(h.db).LateGrace(ctx, time.Duration(h.cfg.Attendance.LateGraceMinutes)*time.Minute)
+	if late, ok := schedule.lateArrival(now, grace); scheduled && ok {
+		status = "late"
+		lateMinutes = int(late.Minutes())
+	}
+
+	// Create attendance record. The check above is only a fast path: a
+	// concurrent check-in may insert between it and here, so the unique
+	// (employee_id, date) constraint decides which request wins. A half day
+	// of leave keeps its status.
 	attendanceID := uuid.New()
-	now := time.Now()
 
-	_, err = h.db.ExecContext(ctx, `
-		INSERT INTO attendances (id, employee_id, date, check_in, check_in_ip, check_in_location, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, 'present', NOW(), NOW())
-	`, attendanceID, employeeID, today, now, clientIP, req.Location)
+	err = h.db.QueryRowContext(ctx, `
+		INSERT INTO attendances (id, employee_id, date, check_in, check_in_ip, check_in_location, status, work_mode, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
+		ON CONFLICT (employee_id, date) DO UPDATE
+		SET check_in = EXCLUDED.check_in, check_in_ip = EXCLUDED.check_in_ip, check_in_location = EXCLUDED.check_in_location,
+		    status = CASE WHEN attendances.status = 'half_day' THEN 'half_day' ELSE EXCLUDED.status END,
+		    work_mode = EXCLUDED.work_mode, deleted_at = NULL, updated_at = NOW()
+		WHERE attendances.check_in IS NULL
+		RETURNING id, status
+	`, attendanceID, employeeID, today, now, clientIP, req.Location, status, workMode).Scan(&attendanceID, &status)
 
+	if err == sql.ErrNoRows {
+		response.Conflict(c, "attendance.already_checked_in")
+		return
+	}
 	if err != nil {
 		response.InternalError(c, err)
 		return
@@ -76,16 +170,28 @@ func (h *AttendanceHandler) CheckIn(c *gin.Context) {
 
 	// Log attendance
 	h.db.ExecContext(ctx, `
-		INSERT INTO attendance_logs (id, attendance_id, action, timestamp, ip_address, user_agent, location)
-		VALUES ($1, $2, 'check_in', $3, $4, $5, $6)
-	`, uuid.New(), attendanceID, now, clientIP, c.Request.UserAgent(), req.Location)
+		INSERT INTO attendance_logs (id, attendance_id, action, timestamp, ip_address, user_agent, location, device_info)
+		VALUES ($1, $2, 'check_in', $3, $4, $5, $6, $7)
+	`, uuid.New(), attendanceID, now, clientIP, c.Request.UserAgent(), req.Location, deviceInfo)
+	h.publishAttendanceLive(ctx, employeeID, "check_in", date, status, now)
+	h.cache.Delete(ctx, attendanceSummaryKey(employeeID, date))
 
 	// h.log.WithModule("attendance").WithUserID(userID).Info("Employee checked in")
 
-	response.OK(c, "attendance.check_in", gin.H{
+	data := gin.H{
 		"attendance_id": attendanceID,
+		"date":          today,
 		"check_in":      now,
-	})
+		"status":        status,
+		"work_mode":     workMode,
+	}
+	if status == "late" {
+		data["late_minutes"] = lateMinutes
+	}
+	if restWarning != nil {
+		data["warning"] = gin.H{"code": "attendance.insufficient_rest", "details": restWarning}
+	}
+	response.OK(c, "attendance.check_in", data)
 }
 
 // CheckOut records employee check-out
@@ -96,41 +202,78 @@ func (h *AttendanceHandler) CheckOut(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	ctx := c.Request.Context()
 	clientIP := c.ClientIP()
-	today := time.Now().Format("2006-01-02")
+	now := time.Now()
+	today := now.Format("2006-01-02")
 
 	var employeeID uuid.UUID
 	h.db.QueryRowContext(ctx, `SELECT id FROM employees WHERE user_id = $1`, userID).Scan(&employeeID)
 
 	// Get today's attendance
 	var attendanceID uuid.UUID
-	var checkIn time.Time
+	var date, checkIn time.Time
 	var checkOut sql.NullTime
+	var workMode, status string
 
-	err := h.db.QueryRowContext(ctx, `
-		SELECT id, check_in, check_out FROM attendances 
-		WHERE employee_id = $1 AND date = $2
-	`, employeeID, today).Scan(&attendanceID, &checkIn, &checkOut)
+	openAttendance := `
+		SELECT id, date, check_in, check_out, work_mode, status FROM attendances 
+		WHERE employee_id = $1 AND date = $2 AND check_in IS NOT NULL`
+	err := h.db.QueryRowContext(ctx, openAttendance, employeeID, today).
+		Scan(&attendanceID, &date, &checkIn, &checkOut, &workMode, &status)
+
+	// A night shift started yesterday is checked out after midnight
+	nightShift := false
+	if err == sql.ErrNoRows {
+		err = h.db.QueryRowContext(ctx, openAttendance+" AND check_out IS NULL", employeeID, now.AddDate(0, 0, -1).Format("2006-01-02")).
+			Scan(&attendanceID, &date, &checkIn, &checkOut, &workMode, &status)
+		nightShift = err == nil
+	}
 
 	if err == sql.ErrNoRows {
 		response.BadRequest(c, "attendance.not_checked_in", nil)
 		return
 	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
 
 	if checkOut.Valid {
 		response.Conflict(c, "attendance.already_checked_out")
 		return
 	}
 
-	now := time.Now()
+	if workMode == workModeOffice && !inNetworks(clientIP, h.cfg.Attendance.OfficeNetworks) {
+		response.Forbidden(c, "attendance.outside_office_network")
+		return
+	}
+
+	schedule, scheduled, err := h.scheduleOn(ctx, employeeID, date, workMode)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if nightShift && !(scheduled && schedule.overnight()) {
+		response.BadRequest(c, "attendance.not_checked_in", nil)
+		return
+	}
+
+	// Leaving before the shift end is an early leave; a late arrival stays late
+	checkIn = wallClock(checkIn)
 	workingHours := now.Sub(checkIn).Hours()
+	if scheduled {
+		workingHours = schedule.workedHours(checkIn, now)
+		if status == "present" && schedule.earlyLeave(now) {
+			status = "early_leave"
+		}
+	}
 
 	// Update attendance
 	_, err = h.db.ExecContext(ctx, `
 		UPDATE attendances 
 		SET check_out = $1, check_out_ip = $2, check_out_location = $3, 
-		    working_hours = $4, updated_at = NOW()
-		WHERE id = $5
-	`, now, clientIP, req.Location, workingHours, attendanceID)
+		    working_hours = $4, status = $5, updated_at = NOW()
+		WHERE id = $6
+	`, now, clientIP, req.Location, workingHours, status, attendanceID)
 
 	if err != nil {
 		response.InternalError(c, err)
@@ -142,11 +285,21 @@ func (h *AttendanceHandler) CheckOut(c *gin.Context) {
 		INSERT INTO attendance_logs (id, attendance_id, action, timestamp, ip_address, user_agent, location)
 		VALUES ($1, $2, 'check_out', $3, $4, $5, $6)
 	`, uuid.New(), attendanceID, now, clientIP, c.Request.UserAgent(), req.Location)
+	h.publishAttendanceLive(ctx, employeeID, "check_out", date, status, now)
+	h.cache.Delete(ctx, attendanceSummaryKey(employeeID, date))
+
+	expectedHours, err := h.expectedHours(ctx, employeeID, date.Format("2006-01-02"), workMode)
+	if err != nil {
+		h.log.WithError(err).Warn("Failed to get expected working hours")
+	}
 
 	response.OK(c, "attendance.check_out", gin.H{
-		"attendance_id": attendanceID,
-		"check_out":     now,
-		"working_hours": workingHours,
+		"attendance_id":  attendanceID,
+		"check_out":      now,
+		"working_hours":  workingHours,
+		"status":         status,
+		"work_mode":      workMode,
+		"expected_hours": expectedHours,
 	})
 }
 
@@ -159,7 +312,8 @@ func (h *AttendanceHandler) GetMyAttendance(c *gin.Context) {
 	endDate := c.DefaultQuery("end_date", time.Now().Format("2006-01-02"))
 
 	rows, err := h.db.QueryContext(ctx, `
-		SELECT a.id, a.date, a.check_in, a.check_out, a.working_hours, a.overtime_hours, a.status, a.notes
+		SELECT a.id, a.date, a.check_in, a.check_out, a.working_hours, a.overtime_hours, a.status, a.notes,
+		       a.auto_closed, a.needs_regularization, a.work_mode
 		FROM attendances a
 		INNER JOIN employees e ON e.id = a.employee_id
 		WHERE e.user_id = $1 AND a.date BETWEEN $2 AND $3
@@ -178,17 +332,22 @@ func (h *AttendanceHandler) GetMyAttendance(c *gin.Context) {
 		var date time.Time
 		var checkIn, checkOut sql.NullTime
 		var workingHours, overtimeHours float64
-		var status string
+		var status, workMode string
 		var notes sql.NullString
+		var autoClosed, needsRegularization bool
 
-		rows.Scan(&id, &date, &checkIn, &checkOut, &workingHours, &overtimeHours, &status, &notes)
+		rows.Scan(&id, &date, &checkIn, &checkOut, &workingHours, &overtimeHours, &status, &notes,
+			&autoClosed, &needsRegularization, &workMode)
 
 		att := map[string]interface{}{
-			"id":             id,
-			"date":           date.Format("2006-01-02"),
-			"working_hours":  workingHours,
-			"overtime_hours": overtimeHours,
-			"status":         status,
+			"id":                   id,
+			"date":                 date.Format("2006-01-02"),
+			"working_hours":        workingHours,
+			"overtime_hours":       overtimeHours,
+			"status":               status,
+			"work_mode":            workMode,
+			"auto_closed":          autoClosed,
+			"needs_regularization": needsRegularization,
 		}
 		if checkIn.Valid {
 			att["check_in"] = checkIn.Time
@@ -210,12 +369,19 @@ func (h *AttendanceHandler) List(c *gin.Context) {
 	var filter dto.AttendanceFilter
 	c.ShouldBindQuery(&filter)
 
+	fields, unknown := response.ParseFields(c, dto.AttendanceResponse{})
+	if len(unknown) > 0 {
+		response.BadRequest(c, "common.unknown_fields", map[string]string{"fields": strings.Join(unknown, ",")})
+		return
+	}
+
 	ctx := c.Request.Context()
-	pagination := database.NewPagination(filter.Page, filter.PageSize)
+	pagination := database.NewPagination(filter.Page, filter.PageSize, middleware.GetPermissions(c), "attendance.export", &h.cfg.Database)
+	pagination.SetCountMode(c.Query("count"), h.cfg.Database.CountModeFor("attendances"))
 
 	baseQuery := `
 		SELECT a.id, a.employee_id, e.full_name, e.employee_code, a.date, 
-		       a.check_in, a.check_out, a.working_hours, a.overtime_hours, a.status, a.notes
+		       a.check_in, a.check_out, a.working_hours, a.overtime_hours, a.status, a.work_mode, a.notes
 		FROM attendances a
 		INNER JOIN employees e ON e.id = a.employee_id
 		WHERE a.deleted_at IS NULL`
@@ -241,6 +407,11 @@ func (h *AttendanceHandler) List(c *gin.Context) {
 		args = append(args, filter.Status)
 		argIdx++
 	}
+	if filter.WorkMode != "" {
+		conditions = append(conditions, fmt.Sprintf("a.work_mode = $%d", argIdx))
+		args = append(args, filter.WorkMode)
+		argIdx++
+	}
 	if filter.StartDate != "" {
 		conditions = append(conditions, fmt.Sprintf("a.date >= $%d", argIdx))
 		args = append(args, filter.StartDate)
@@ -258,12 +429,13 @@ func (h *AttendanceHandler) List(c *gin.Context) {
 		countQuery += whereClause
 	}
 
-	var total int
-	h.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
-	pagination.SetTotal(total)
+	if err := h.db.Count(ctx, pagination, "attendances", countQuery, args...); err != nil {
+		response.InternalError(c, err)
+		return
+	}
 
 	baseQuery += fmt.Sprintf(" ORDER BY a.date DESC, e.full_name LIMIT $%d OFFSET $%d", argIdx, argIdx+1)
-	args = append(args, pagination.GetLimit(), pagination.GetOffset())
+	args = append(args, pagination.FetchLimit(), pagination.GetOffset())
 
 	rows, err := h.db.QueryContext(ctx, baseQuery, args...)
 	if err != nil {
@@ -279,7 +451,7 @@ func (h *AttendanceHandler) List(c *gin.Context) {
 		var notes sql.NullString
 
 		rows.Scan(&att.ID, &att.EmployeeID, &att.EmployeeName, &att.EmployeeCode, &att.Date,
-			&checkIn, &checkOut, &att.WorkingHours, &att.OvertimeHours, &att.Status, &notes)
+			&checkIn, &checkOut, &att.WorkingHours, &att.OvertimeHours, &att.Status, &att.WorkMode, &notes)
 
 		if checkIn.Valid {
 			att.CheckIn = &checkIn.Time
@@ -292,8 +464,9 @@ func (h *AttendanceHandler) List(c *gin.Context) {
 		}
 		attendances = append(attendances, att)
 	}
+	attendances = database.TrimPage(pagination, attendances)
 
-	response.OKWithMeta(c, "common.list", attendances, pagination)
+	response.OKWithMeta(c, "common.list", response.SelectFields(attendances, fields), pagination)
 }
 
 // GetSummary returns attendance summary
@@ -312,7 +485,9 @@ func (h *AttendanceHandler) GetSummary(c *gin.Context) {
 			COUNT(CASE WHEN a.status = 'absent' THEN 1 END) as absent_count,
 			COUNT(CASE WHEN a.status = 'late' THEN 1 END) as late_count,
 			COUNT(CASE WHEN a.status = 'on_leave' THEN 1 END) as leave_count,
+			COUNT(CASE WHEN a.work_mode = 'remote' AND a.check_in IS NOT NULL THEN 1 END) as remote_count,
 			COALESCE(SUM(a.working_hours), 0) as total_working_hours,
+			COALESCE(SUM(a.working_hours) FILTER (WHERE a.work_mode = 'remote'), 0) as remote_working_hours,
 			COALESCE(SUM(a.overtime_hours), 0) as total_overtime_hours
 		FROM employees e
 		LEFT JOIN attendances a ON a.employee_id = e.id AND a.date BETWEEN $1 AND $2
@@ -330,13 +505,16 @@ func (h *AttendanceHandler) GetSummary(c *gin.Context) {
 		AbsentCount        int     `json:"absent_count"`
 		LateCount          int     `json:"late_count"`
 		LeaveCount         int     `json:"leave_count"`
+		RemoteCount        int     `json:"remote_count"`
 		TotalWorkingHours  float64 `json:"total_working_hours"`
+		RemoteWorkingHours float64 `json:"remote_working_hours"`
 		TotalOvertimeHours float64 `json:"total_overtime_hours"`
 	}
 
 	h.db.QueryRowContext(ctx, query, args...).Scan(
 		&summary.TotalEmployees, &summary.PresentCount, &summary.AbsentCount,
-		&summary.LateCount, &summary.LeaveCount, &summary.TotalWorkingHours, &summary.TotalOvertimeHours,
+		&summary.LateCount, &summary.LeaveCount, &summary.RemoteCount,
+		&summary.TotalWorkingHours, &summary.RemoteWorkingHours, &summary.TotalOvertimeHours,
 	)
 
 	response.OK(c, "common.success", summary)
@@ -354,21 +532,29 @@ func (h *AttendanceHandler) GetTodayStatus(c *gin.Context) {
 		CheckOut     *time.Time `json:"check_out"`
 		WorkingHours float64    `json:"working_hours"`
 		Status       string     `json:"status"`
+		WorkMode     string     `json:"work_mode"`
 	}
 
 	var checkIn, checkOut sql.NullTime
 
 	err := h.db.QueryRowContext(ctx, `
-		SELECT a.id, a.check_in, a.check_out, a.working_hours, a.status
+		SELECT a.id, a.check_in, a.check_out, a.working_hours, a.status, a.work_mode
 		FROM attendances a
 		INNER JOIN employees e ON e.id = a.employee_id
 		WHERE e.user_id = $1 AND a.date = $2
-	`, userID, today).Scan(&att.ID, &checkIn, &checkOut, &att.WorkingHours, &att.Status)
+	`, userID, today).Scan(&att.ID, &checkIn, &checkOut, &att.WorkingHours, &att.Status, &att.WorkMode)
 
 	if err == sql.ErrNoRows {
+		// Before check-in, tell the employee where today's check-in is expected
+		var employeeID uuid.UUID
+		workMode := workModeOffice
+		if err := h.db.QueryRowContext(ctx, `SELECT id FROM employees WHERE user_id = $1 AND deleted_at IS NULL`, userID).Scan(&employeeID); err == nil {
+			workMode, _ = workModeOn(ctx, h.db, employeeID, today)
+		}
 		response.OK(c, "common.success", gin.H{
 			"checked_in":  false,
 			"checked_out": false,
+			"work_mode":   workMode,
 		})
 		return
 	}
@@ -388,5 +574,476 @@ func (h *AttendanceHandler) GetTodayStatus(c *gin.Context) {
 		"check_out":     att.CheckOut,
 		"working_hours": att.WorkingHours,
 		"status":        att.Status,
+		"work_mode":     att.WorkMode,
 	})
 }
+
+// GetTeamToday returns today's attendance of the caller's direct reports, or of
+// the whole reporting tree with ?recursive=true. Active employees with neither
+// a check-in nor approved leave are flagged as no-shows.
+func (h *AttendanceHandler) GetTeamToday(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+	recursive := c.Query("recursive") == "true"
+
+	var managerID uuid.UUID
+	err := h.db.QueryRowContext(ctx, `SELECT id FROM employees WHERE user_id = $1 AND deleted_at IS NULL`, userID).Scan(&managerID)
+	if err != nil {
+		response.NotFound(c, "employee.not_found")
+		return
+	}
+
+	today := time.Now().Format("2006-01-02")
+	cacheKey := fmt.Sprintf("%steam:%s:%s:%t", cache.KeyAttendancePrefix, managerID, today, recursive)
+	var result dto.TeamAttendanceResponse
+	if err := h.cache.Get(ctx, cacheKey, &result); err == nil {
+		response.OK(c, "common.success", result)
+		return
+	}
+
+	rows, err := h.db.QueryContext(ctx, `
+		WITH RECURSIVE team AS (
+			SELECT id FROM employees WHERE manager_id = $1 AND deleted_at IS NULL
+			UNION
+			SELECT e.id FROM employees e
+			INNER JOIN team t ON e.manager_id = t.id
+			WHERE $3::boolean AND e.deleted_at IS NULL
+		)
+		SELECT e.id, e.employee_code, e.full_name, e.employment_status,
+		       a.check_in, a.check_out, COALESCE(a.status, ''), COALESCE(l.name, ''),
+		       COALESCE(a.work_mode, CASE WHEN `+remoteDaySQL+` THEN 'remote' ELSE 'office' END)
+		FROM team t
+		INNER JOIN employees e ON e.id = t.id
+		LEFT JOIN attendances a ON a.employee_id = e.id AND a.date = $2 AND a.deleted_at IS NULL
+		LEFT JOIN LATERAL (
+			SELECT lt.name FROM leave_requests lr
+			INNER JOIN leave_types lt ON lt.id = lr.leave_type_id
+			WHERE lr.employee_id = e.id AND lr.status = 'approved' AND lr.deleted_at IS NULL
+			  AND $2::date BETWEEN lr.start_date AND lr.end_date
+			LIMIT 1
+		) l ON TRUE
+		WHERE e.employment_status IN ('active', 'on_leave')
+		ORDER BY e.full_name
+	`, managerID, today, recursive)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	result = dto.TeamAttendanceResponse{Date: today, Members: []dto.TeamMemberAttendance{}}
+	for rows.Next() {
+		var m dto.TeamMemberAttendance
+		var employmentStatus string
+		var checkIn, checkOut sql.NullTime
+		if err := rows.Scan(&m.EmployeeID, &m.EmployeeCode, &m.FullName, &employmentStatus,
+			&checkIn, &checkOut, &m.Status, &m.LeaveType, &m.WorkMode); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		if checkIn.Valid {
+			m.CheckIn = &checkIn.Time
+			result.CheckedIn++
+		}
+		if checkOut.Valid {
+			m.CheckOut = &checkOut.Time
+		}
+		m.OnLeave = m.LeaveType != ""
+		if m.OnLeave {
+			result.OnLeave++
+		}
+		m.NoShow = employmentStatus == "active" && !checkIn.Valid && !m.OnLeave
+		if m.NoShow {
+			result.NoShow++
+		}
+		if m.WorkMode == workModeRemote && !m.OnLeave {
+			result.Remote++
+		}
+		result.Members = append(result.Members, m)
+	}
+	result.Total = len(result.Members)
+
+	h.cache.Set(ctx, cacheKey, result, time.Minute)
+	response.OK(c, "common.success", result)
+}
+
+// defaultShiftHorizonDays is how far ahead a rotation is generated by default
+const defaultShiftHorizonDays = 28
+
+var errShiftOverlap = errors.New("shift overlap")
+
+// shiftWindow is a shift's start and end as offsets from midnight
+type shiftWindow struct {
+	start time.Duration
+	end   time.Duration
+}
+
+type plannedShift struct {
+	date    time.Time
+	shiftID string
+}
+
+// RotateShifts generates employee_shifts for a horizon following an ordered
+// shift rotation, skipping rest days and holidays. Existing assignments of the
+// employees within the horizon are replaced, so the call can be repeated.
+func (h *AttendanceHandler) RotateShifts(c *gin.Context) {
+	var req dto.RotateShiftsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		response.BadRequest(c, "validation.date_format", nil)
+		return
+	}
+	horizon := req.HorizonDays
+	if horizon == 0 {
+		horizon = defaultShiftHorizonDays
+	}
+	endDate := startDate.AddDate(0, 0, horizon-1)
+
+	ctx := c.Request.Context()
+
+	employeeIDs := uniqueStrings(req.EmployeeIDs)
+	var found int
+	if err := h.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM employees WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL
+	`, pq.Array(employeeIDs)).Scan(&found); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if found != len(employeeIDs) {
+		response.NotFound(c, "employee.not_found")
+		return
+	}
+
+	shifts, err := h.loadShiftWindows(ctx, req.ShiftIDs)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	for _, id := range req.ShiftIDs {
+		if _, ok := shifts[id]; !ok {
+			response.NotFound(c, "attendance.shift_not_found")
+			return
+		}
+	}
+
+	restDays := map[time.Weekday]bool{time.Saturday: true, time.Sunday: true}
+	if req.RestWeekdays != nil {
+		restDays = map[time.Weekday]bool{}
+		for _, d := range req.RestWeekdays {
+			restDays[time.Weekday(d)] = true
+		}
+	}
+
+	holidays, err := loadHolidayDates(ctx, h.db, startDate, endDate)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	// The rotation follows calendar days so skipped days keep the pattern aligned
+	var plan []plannedShift
+	skipped := []string{}
+	for i := 0; i < horizon; i++ {
+		date := startDate.AddDate(0, 0, i)
+		if restDays[date.Weekday()] || holidays[date.Format("2006-01-02")] {
+			skipped = append(skipped, date.Format("2006-01-02"))
+			continue
+		}
+		plan = append(plan, plannedShift{date: date, shiftID: req.ShiftIDs[(i/req.CycleDays)%len(req.ShiftIDs)]})
+	}
+
+	for i := 1; i < len(plan); i++ {
+		if shiftsOverlap(plan[i-1].date, shifts[plan[i-1].shiftID], plan[i].date, shifts[plan[i].shiftID]) {
+			response.UnprocessableEntity(c, "attendance.shift_overlap", map[string]string{"date": plan[i].date.Format("2006-01-02")})
+			return
+		}
+	}
+
+	var overlapDate string
+	var assigned int64
+	err = h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		// Assignments just outside the horizon must not overlap the new ones
+		if len(plan) > 0 {
+			rows, err := tx.QueryContext(ctx, `
+				SELECT es.date, ws.start_time, ws.end_time
+				FROM employee_shifts es
+				INNER JOIN work_shifts ws ON ws.id = es.shift_id
+				WHERE es.employee_id = ANY($1::uuid[]) AND es.date IN ($2, $3)
+			`, pq.Array(employeeIDs), startDate.AddDate(0, 0, -1).Format("2006-01-02"), endDate.AddDate(0, 0, 1).Format("2006-01-02"))
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			first, last := plan[0], plan[len(plan)-1]
+			for rows.Next() {
+				var date time.Time
+				var startTime, endTime string
+				if err := rows.Scan(&date, &startTime, &endTime); err != nil {
+					return err
+				}
+				window, err := parseShiftWindow(startTime, endTime)
+				if err != nil {
+					return err
+				}
+				if date.Before(startDate) && shiftsOverlap(date, window, first.date, shifts[first.shiftID]) {
+					overlapDate = first.date.Format("2006-01-02")
+					return errShiftOverlap
+				}
+				if date.After(endDate) && shiftsOverlap(last.date, shifts[last.shiftID], date, window) {
+					overlapDate = date.Format("2006-01-02")
+					return errShiftOverlap
+				}
+			}
+			if err := rows.Err(); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM employee_shifts
+			WHERE employee_id = ANY($1::uuid[]) AND date BETWEEN $2 AND $3
+		`, pq.Array(employeeIDs), req.StartDate, endDate.Format("2006-01-02")); err != nil {
+			return err
+		}
+
+		if len(plan) == 0 {
+			return nil
+		}
+
+		dates := make([]string, len(plan))
+		shiftIDs := make([]string, len(plan))
+		for i, p := range plan {
+			dates[i] = p.date.Format("2006-01-02")
+			shiftIDs[i] = p.shiftID
+		}
+
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO employee_shifts (id, employee_id, shift_id, date, created_at)
+			SELECT uuid_generate_v4(), e.id, p.shift_id, p.date, NOW()
+			FROM unnest($1::uuid[]) AS e(id)
+			CROSS JOIN unnest($2::uuid[], $3::date[]) AS p(shift_id, date)
+		`, pq.Array(employeeIDs), pq.Array(shiftIDs), pq.Array(dates))
+		if err != nil {
+			return err
+		}
+		assigned, err = result.RowsAffected()
+		return err
+	})
+
+	if err == errShiftOverlap {
+		response.UnprocessableEntity(c, "attendance.shift_overlap", map[string]string{"date": overlapDate})
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	// A rotation spans many rows, so it is audited under its own batch id
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: middleware.GetUserID(c), Action: "rotate", TableName: "employee_shifts", RecordID: uuid.New().String(),
+		NewValues: req, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "attendance.shifts_assigned", gin.H{
+		"start_date":    req.StartDate,
+		"end_date":      endDate.Format("2006-01-02"),
+		"employees":     len(employeeIDs),
+		"assignments":   assigned,
+		"skipped_dates": skipped,
+	})
+}
+
+func (h *AttendanceHandler) loadShiftWindows(ctx context.Context, shiftIDs []string) (map[string]shiftWindow, error) {
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT id, start_time, end_time FROM work_shifts
+		WHERE id = ANY($1::uuid[]) AND status = 'active' AND deleted_at IS NULL
+	`, pq.Array(shiftIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shifts := make(map[string]shiftWindow)
+	for rows.Next() {
+		var id, startTime, endTime string
+		if err := rows.Scan(&id, &startTime, &endTime); err != nil {
+			return nil, err
+		}
+		window, err := parseShiftWindow(startTime, endTime)
+		if err != nil {
+			return nil, err
+		}
+		shifts[id] = window
+	}
+	return shifts, rows.Err()
+}
+
+// loadHolidayDates returns the full-day holidays from from through to keyed
+// by YYYY-MM-DD, recurring holidays dated in each year of the range
+func loadHolidayDates(ctx context.Context, db *database.Database, from, to time.Time) (map[string]bool, error) {
+	service := payroll.NewService(db)
+	holidays := make(map[string]bool)
+	for year := from.Year(); year <= to.Year(); year++ {
+		expanded, err := service.ExpandHolidays(ctx, year)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range expanded {
+			if !h.IsHalfDay {
+				holidays[h.Date.Format("2006-01-02")] = true
+			}
+		}
+	}
+	return holidays, nil
+}
+
+func parseShiftWindow(startTime, endTime string) (shiftWindow, error) {
+	start, err := parseClock(startTime)
+	if err != nil {
+		return shiftWindow{}, err
+	}
+	end, err := parseClock(endTime)
+	if err != nil {
+		return shiftWindow{}, err
+	}
+	return shiftWindow{start: start, end: end}, nil
+}
+
+// parseClock reads a time of day ("15:04:05" from the database, "15:04" from
+// config) as an offset from midnight
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04:05", value)
+	if err != nil {
+		if t, err = time.Parse("15:04", value); err != nil {
+			return 0, err
+		}
+	}
+	midnight := time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)
+	return t.Sub(midnight), nil
+}
+
+// shiftsOverlap reports whether a shift ends after the next day's shift starts.
+// Only night shifts ending past midnight can reach into the next day.
+func shiftsOverlap(prevDate time.Time, prev shiftWindow, nextDate time.Time, next shiftWindow) bool {
+	if !nextDate.Equal(prevDate.AddDate(0, 0, 1)) || prev.end > prev.start {
+		return false
+	}
+	return prev.end > next.start
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// lastWorkBeforeRest returns the end of the employee's last work before start
+// when less than minRest has passed since, or nil when the rest period is met.
+// Only work on earlier days counts, so overtime right after a shift is not
+// treated as a new work day. A zero minRest disables the check.
+func lastWorkBeforeRest(ctx context.Context, db *database.Database, minRest time.Duration, employeeID uuid.UUID, start time.Time) (*time.Time, error) {
+	if minRest <= 0 {
+		return nil, nil
+	}
+
+	var lastWork sql.NullTime
+	err := db.QueryRowContext(ctx, `
+		SELECT GREATEST(
+			(SELECT MAX(check_out) FROM attendances
+			 WHERE employee_id = $1 AND date < $2::date AND check_out <= $3),
+			(SELECT MAX(s.date + s.end_time) FROM overtime_request_segments s
+			 INNER JOIN overtime_requests o ON o.id = s.overtime_request_id
+			 WHERE o.employee_id = $1 AND o.date < $2::date AND o.status IN ('approved', 'completed')
+			   AND o.deleted_at IS NULL AND s.date + s.end_time <= $3)
+		)
+	`, employeeID, start.Format("2006-01-02"), start.Format("2006-01-02 15:04:05")).Scan(&lastWork)
+	if err != nil || !lastWork.Valid {
+		return nil, err
+	}
+
+	// Timestamps are stored as local wall-clock time
+	t := lastWork.Time
+	lastWorkAt := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, start.Location())
+	if start.Sub(lastWorkAt) >= minRest {
+		return nil, nil
+	}
+	return &lastWorkAt, nil
+}
+
+func restPeriodDetails(lastWork time.Time, minRest time.Duration) map[string]string {
+	return map[string]string{
+		"last_work_at":    lastWork.Format(time.RFC3339),
+		"min_rest_period": minRest.String(),
+	}
+}
+
+// WorkingDays returns the working calendar of ?month= (default this month):
+// work week days less public holidays, half-day holidays counting half, as
+// the payroll worker counts them.
+// Holidays apply company-wide, so ?department_id= is only checked and echoed.
+func (h *AttendanceHandler) WorkingDays(c *gin.Context) {
+	var filter dto.WorkingDaysFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+	if filter.Month == "" {
+		filter.Month = time.Now().Format("2006-01")
+	}
+	monthStart, _ := time.Parse("2006-01", filter.Month)
+	monthEnd := monthStart.AddDate(0, 1, -1)
+	ctx := c.Request.Context()
+
+	result := dto.WorkingDaysResponse{
+		Month:           filter.Month,
+		StartDate:       monthStart.Format("2006-01-02"),
+		EndDate:         monthEnd.Format("2006-01-02"),
+		HoursPerDay:     payroll.StandardHoursPerDay,
+		Holidays:        []string{},
+		HalfDayHolidays: []string{},
+	}
+	if filter.DepartmentID != "" {
+		var departmentID uuid.UUID
+		err := h.db.QueryRowContext(ctx, `
+			SELECT id FROM departments WHERE id = $1 AND deleted_at IS NULL
+		`, filter.DepartmentID).Scan(&departmentID)
+		if err == sql.ErrNoRows {
+			response.NotFound(c, "department.not_found")
+			return
+		}
+		if err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		result.DepartmentID = &departmentID
+	}
+
+	calendar, err := payroll.NewService(h.db).WorkingCalendar(ctx, monthStart, monthEnd)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	result.WorkingDays = calendar.WorkingDays
+	result.ExpectedHours = calendar.ExpectedHours()
+	for _, d := range calendar.Holidays {
+		result.Holidays = append(result.Holidays, d.Format("2006-01-02"))
+	}
+	for _, d := range calendar.HalfDayHolidays {
+		result.HalfDayHolidays = append(result.HalfDayHolidays, d.Format("2006-01-02"))
+	}
+
+	response.OK(c, "common.success", result)
+}
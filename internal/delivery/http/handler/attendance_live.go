@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/cache"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/net/websocket"
+)
+
+// attendanceLiveChannel carries check-ins and check-outs to the live feeds
+// of every API instance
+const attendanceLiveChannel = cache.KeyAttendancePrefix + "live"
+
+// attendanceLiveHeartbeat is how often an idle feed sends a heartbeat message
+const attendanceLiveHeartbeat = 25 * time.Second
+
+var errOriginNotAllowed = errors.New("websocket origin not allowed")
+
+// Live is a WebSocket feed of today's attendance. It first sends a snapshot
+// of each department's headcount and check-ins, then an event for every
+// check-in and check-out, on whichever API instance it happened, as Redis
+// relays them. ?department_id= limits the feed to one department. Messages
+// from the client are ignored.
+func (h *AttendanceHandler) Live(c *gin.Context) {
+	departmentID := c.Query("department_id")
+	if departmentID != "" {
+		if _, err := uuid.Parse(departmentID); err != nil {
+			response.BadRequest(c, "common.validation_error", nil)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	// Subscribed before the snapshot is read, so no event falls between them
+	sub := h.cache.Subscribe(ctx, attendanceLiveChannel)
+	defer sub.Close()
+	if _, err := sub.Receive(ctx); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	server := websocket.Server{
+		Handshake: func(_ *websocket.Config, r *http.Request) error {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				return nil
+			}
+			for _, allowed := range h.cfg.Security.AllowedOrigins {
+				if allowed == "*" || allowed == origin {
+					return nil
+				}
+			}
+			return errOriginNotAllowed
+		},
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			// Reading fails once the client goes away, which ends the feed
+			go func() {
+				var discard []byte
+				for websocket.Message.Receive(ws, &discard) == nil {
+				}
+				cancel()
+			}()
+
+			snapshot, err := h.liveSnapshot(ctx, departmentID)
+			if err != nil {
+				h.log.WithModule("attendance").WithError(err).Warn("Failed to load live attendance snapshot")
+				return
+			}
+			if err := websocket.JSON.Send(ws, snapshot); err != nil {
+				return
+			}
+
+			heartbeat := time.NewTicker(attendanceLiveHeartbeat)
+			defer heartbeat.Stop()
+			messages := sub.Channel()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case msg, ok := <-messages:
+					if !ok {
+						return
+					}
+					if departmentID != "" {
+						var event dto.AttendanceLiveEvent
+						if json.Unmarshal([]byte(msg.Payload), &event) != nil ||
+							event.DepartmentID == nil || event.DepartmentID.String() != departmentID {
+							continue
+						}
+					}
+					if err := websocket.Message.Send(ws, msg.Payload); err != nil {
+						return
+					}
+				case <-heartbeat.C:
+					if err := websocket.JSON.Send(ws, gin.H{"type": "heartbeat"}); err != nil {
+						return
+					}
+				}
+			}
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// liveSnapshot counts today's check-ins and check-outs per department
+// against the active headcount
+func (h *AttendanceHandler) liveSnapshot(ctx context.Context, departmentID string) (*dto.AttendanceLiveSnapshot, error) {
+	today := time.Now().Format("2006-01-02")
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT d.id, d.name, COUNT(e.id), COUNT(a.check_in), COUNT(a.check_out)
+		FROM departments d
+		LEFT JOIN employees e ON e.department_id = d.id AND e.employment_status = 'active' AND e.deleted_at IS NULL
+		LEFT JOIN attendances a ON a.employee_id = e.id AND a.date = $1 AND a.deleted_at IS NULL
+		WHERE d.deleted_at IS NULL AND ($2 = '' OR d.id::text = $2)
+		GROUP BY d.id, d.name
+		ORDER BY d.name
+	`, today, departmentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshot := &dto.AttendanceLiveSnapshot{Type: "snapshot", Date: today, Departments: []dto.AttendanceLiveDepartment{}}
+	for rows.Next() {
+		var d dto.AttendanceLiveDepartment
+		if err := rows.Scan(&d.DepartmentID, &d.DepartmentName, &d.Headcount, &d.CheckedIn, &d.CheckedOut); err != nil {
+			return nil, err
+		}
+		snapshot.Departments = append(snapshot.Departments, d)
+	}
+	return snapshot, rows.Err()
+}
+
+// publishAttendanceLive tells the live feeds about a check-in or check-out.
+// The attendance is already recorded, so a failure is only logged.
+func (h *AttendanceHandler) publishAttendanceLive(ctx context.Context, employeeID uuid.UUID, action string, date time.Time, status string, at time.Time) {
+	event := dto.AttendanceLiveEvent{
+		Type: "attendance", Action: action, Date: date.Format("2006-01-02"),
+		EmployeeID: employeeID, Status: status, At: at,
+	}
+	var departmentID uuid.NullUUID
+	err := h.db.QueryRowContext(ctx, `
+		SELECT e.full_name, e.department_id, COALESCE(d.name, '')
+		FROM employees e
+		LEFT JOIN departments d ON d.id = e.department_id
+		WHERE e.id = $1
+	`, employeeID).Scan(&event.EmployeeName, &departmentID, &event.DepartmentName)
+	if err == nil {
+		if departmentID.Valid {
+			event.DepartmentID = &departmentID.UUID
+		}
+		err = h.cache.Publish(ctx, attendanceLiveChannel, event)
+	}
+	if err != nil {
+		h.log.WithModule("attendance").WithError(err).WithField("employee_id", employeeID).Warn("Failed to publish live attendance event")
+	}
+}
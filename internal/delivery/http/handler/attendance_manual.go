@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"database/sql"
+	"time"
+
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/queue"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ManualEntry enters an employee's check-in and check-out for a day by hand,
+// for someone who forgot to clock. The day's record is created, or corrected
+// when there already is one, so an employee never has two records for a date.
+// Working and overtime hours and the status are worked out from the shift as
+// for a device check-out; a half day of leave keeps its status. The reason is
+// kept in the notes and the attendance log records who made the entry.
+func (h *AttendanceHandler) ManualEntry(c *gin.Context) {
+	var req dto.ManualAttendanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+	employeeID := uuid.MustParse(req.EmployeeID)
+	date, _ := time.ParseInLocation("2006-01-02", req.Date, time.Local)
+
+	// Check-in falls on the day; check-out may run past midnight on a night
+	// shift but not into the day after
+	checkIn, checkOut := req.CheckIn.In(time.Local), req.CheckOut.In(time.Local)
+	if checkIn.Format("2006-01-02") != req.Date || !checkOut.After(checkIn) ||
+		checkOut.Sub(checkIn) > 24*time.Hour || checkOut.After(time.Now()) {
+		response.UnprocessableEntity(c, "attendance.invalid_times", nil)
+		return
+	}
+
+	var exists bool
+	if err := h.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM employees WHERE id = $1 AND deleted_at IS NULL)
+	`, employeeID).Scan(&exists); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if !exists {
+		response.NotFound(c, "employee.not_found")
+		return
+	}
+
+	workMode := req.WorkMode
+	if workMode == "" {
+		mode, err := workModeOn(ctx, h.db, employeeID, req.Date)
+		if err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		workMode = mode
+	}
+
+	// A soft-deleted record still holds the date, so it is corrected too
+	var old gin.H
+	var oldCheckIn, oldCheckOut sql.NullTime
+	var oldStatus string
+	err := h.db.QueryRowContext(ctx, `
+		SELECT check_in, check_out, status FROM attendances WHERE employee_id = $1 AND date = $2
+	`, employeeID, req.Date).Scan(&oldCheckIn, &oldCheckOut, &oldStatus)
+	if err != nil && err != sql.ErrNoRows {
+		response.InternalError(c, err)
+		return
+	}
+	if err == nil {
+		old = gin.H{"check_in": nullTimeValue(oldCheckIn), "check_out": nullTimeValue(oldCheckOut), "status": oldStatus}
+	}
+
+	day, err := h.workedDay(ctx, employeeID, date, workMode, checkIn, checkOut)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	var attendanceID uuid.UUID
+	status := day.Status
+	now := time.Now()
+	err = h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO attendances (id, employee_id, date, check_in, check_out, working_hours, overtime_hours,
+			                         status, work_mode, notes, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW())
+			ON CONFLICT (employee_id, date) DO UPDATE
+			SET check_in = EXCLUDED.check_in, check_out = EXCLUDED.check_out,
+			    working_hours = EXCLUDED.working_hours, overtime_hours = EXCLUDED.overtime_hours,
+			    status = CASE WHEN attendances.status = 'half_day' THEN 'half_day' ELSE EXCLUDED.status END,
+			    work_mode = EXCLUDED.work_mode, notes = EXCLUDED.notes, deleted_at = NULL, updated_at = NOW()
+			RETURNING id, status
+		`, uuid.New(), employeeID, req.Date, checkIn, checkOut, day.WorkingHours, day.OvertimeHours,
+			status, workMode, req.Reason).Scan(&attendanceID, &status)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO attendance_logs (id, attendance_id, action, timestamp, ip_address, user_agent, performed_by)
+			VALUES ($1, $2, 'manual_entry', $3, $4, $5, $6)
+		`, uuid.New(), attendanceID, now, c.ClientIP(), c.Request.UserAgent(), userID)
+		return err
+	})
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.forgetAttendanceDay(ctx, employeeID, date)
+
+	entry := gin.H{"check_in": checkIn, "check_out": checkOut, "status": status, "work_mode": workMode,
+		"working_hours": day.WorkingHours, "overtime_hours": day.OvertimeHours, "notes": req.Reason}
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: userID, Action: "manual_entry", TableName: "attendances", RecordID: attendanceID.String(),
+		OldValues: old, NewValues: entry,
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	entry["id"] = attendanceID
+	entry["employee_id"] = employeeID
+	entry["date"] = req.Date
+	if old != nil {
+		response.OK(c, "common.updated", entry)
+		return
+	}
+	response.Created(c, "common.created", entry)
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"hr-management-system/internal/delivery/http/middleware"
 	"hr-management-system/internal/delivery/http/response"
 	"hr-management-system/internal/domain/entity"
+	"hr-management-system/internal/i18n"
 	"hr-management-system/internal/infrastructure/cache"
 	"hr-management-system/internal/infrastructure/database"
 	"hr-management-system/internal/infrastructure/email"
@@ -74,17 +76,17 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	var user entity.User
 	err = h.db.QueryRowContext(ctx, `
 		SELECT id, email, phone, password, status, email_verified_at, 
-		       two_factor_enabled, preferred_language, failed_login_attempts, locked_until
+		       two_factor_enabled, two_factor_secret, preferred_language, failed_login_attempts, locked_until
 		FROM users WHERE email = $1 AND deleted_at IS NULL
 	`, req.Email).Scan(
 		&user.ID, &user.Email, &user.Phone, &user.Password, &user.Status,
-		&user.EmailVerifiedAt, &user.TwoFactorEnabled, &user.PreferredLanguage,
+		&user.EmailVerifiedAt, &user.TwoFactorEnabled, &user.TwoFactorSecret, &user.PreferredLanguage,
 		&user.FailedLoginAttempts, &user.LockedUntil,
 	)
 
 	if err == sql.ErrNoRows {
 		loginManager.RecordFailedAttempt(ctx, req.Email)
-		h.log.LogAuthAttempt(req.Email, clientIP, false, "user not found")
+		h.recordAuthAttempt(c, req.Email, false, "user not found", nil)
 		response.Unauthorized(c, "auth.login_failed")
 		return
 	}
@@ -98,34 +100,42 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	// Check password
 	if !security.CheckPassword(req.Password, user.Password) {
 		loginManager.RecordFailedAttempt(ctx, req.Email)
-		h.log.LogAuthAttempt(req.Email, clientIP, false, "invalid password")
+		h.recordAuthAttempt(c, req.Email, false, "invalid password", nil)
 		response.Unauthorized(c, "auth.login_failed")
 		return
 	}
 
 	// Check status
 	if user.Status != entity.UserStatusActive {
-		h.log.LogAuthAttempt(req.Email, clientIP, false, "account inactive")
+		h.recordAuthAttempt(c, req.Email, false, "account inactive", nil)
 		response.Unauthorized(c, "auth.account_inactive")
 		return
 	}
 
-	// Check 2FA
+	// Check 2FA. The password step is remembered so Verify2FA cannot be
+	// called on its own; users with an authenticator app are not emailed a
+	// code but can still ask for one through /auth/send-otp.
 	if user.TwoFactorEnabled {
-		// Generate and send OTP
-		otp, _ := security.GenerateOTP(6)
-		h.cache.SetOTP(ctx, user.Email, security.HashOTP(otp), h.cfg.Security.OTPExpiry)
-		
-		h.queue.SendOTP(ctx, queue.OTPPayload{
-			Email:    user.Email,
-			OTP:      otp,
-			Type:     "two_factor",
-			Language: user.PreferredLanguage,
-		})
+		h.cache.Set(ctx, "2fa_pending:"+user.Email, true, h.cfg.Security.OTPExpiry)
+
+		method := "totp"
+		if !user.TwoFactorSecret.Valid {
+			method = "email"
+			otp, _ := security.GenerateOTP(6)
+			h.cache.SetOTP(ctx, user.Email, security.HashOTP(otp), h.cfg.Security.OTPExpiry)
+
+			h.queue.SendOTP(ctx, queue.OTPPayload{
+				Email:    user.Email,
+				OTP:      otp,
+				Type:     "two_factor",
+				Language: user.PreferredLanguage,
+			})
+		}
 
 		response.OK(c, "auth.two_factor_required", gin.H{
 			"requires_2fa": true,
 			"email":        user.Email,
+			"method":       method,
 		})
 		return
 	}
@@ -156,15 +166,22 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	`, clientIP, user.ID)
 
 	// Store session
-	h.storeSession(ctx, user.ID, tokenPair, c)
+	sessionID := h.storeSession(ctx, user.ID, tokenPair, c)
 
-	h.log.LogAuthAttempt(req.Email, clientIP, true, "")
+	csrfToken, err := h.setSessionCookies(c, tokenPair)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.recordAuthAttempt(c, req.Email, true, "", &sessionID)
 
 	response.OK(c, "auth.login_success", dto.LoginResponse{
 		AccessToken:  tokenPair.AccessToken,
 		RefreshToken: tokenPair.RefreshToken,
 		ExpiresAt:    tokenPair.ExpiresAt,
 		TokenType:    tokenPair.TokenType,
+		CSRFToken:    csrfToken,
 		User: dto.UserResponse{
 			ID:                user.ID,
 			Email:             user.Email,
@@ -178,11 +195,12 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	})
 }
 
-// Verify2FA handles two-factor authentication
+// Verify2FA completes a login that needs two-factor authentication. The code
+// may be the emailed OTP, one from the user's authenticator app or a recovery code.
 func (h *AuthHandler) Verify2FA(c *gin.Context) {
 	var req struct {
 		Email string `json:"email" binding:"required,email"`
-		Code  string `json:"code" binding:"required,len=6"`
+		Code  string `json:"code" binding:"required,min=6,max=20"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, "common.validation_error", nil)
@@ -190,35 +208,52 @@ func (h *AuthHandler) Verify2FA(c *gin.Context) {
 	}
 
 	ctx := c.Request.Context()
+	pendingKey := "2fa_pending:" + req.Email
 
-	// Get stored OTP
-	storedHash, err := h.cache.GetOTP(ctx, req.Email)
-	if err != nil {
+	// The password must have been checked first
+	if pending, _ := h.cache.Exists(ctx, pendingKey); !pending {
 		response.BadRequest(c, "otp.expired", nil)
 		return
 	}
 
-	// Verify OTP
-	if !security.VerifyOTP(req.Code, storedHash) {
-		response.BadRequest(c, "otp.invalid", nil)
+	loginManager := security.NewLoginAttemptManager(h.cache)
+	if isLocked, lockDuration, err := loginManager.IsLocked(ctx, req.Email); err == nil && isLocked {
+		response.Error(c, 429, "ACCOUNT_LOCKED", "auth.account_locked", map[string]string{
+			"duration": lockDuration.String(),
+		})
 		return
 	}
 
-	// Delete OTP
-	h.cache.DeleteOTP(ctx, req.Email)
-
 	// Get user
 	var user entity.User
-	err = h.db.QueryRowContext(ctx, `
-		SELECT id, email, phone, status, preferred_language
+	err := h.db.QueryRowContext(ctx, `
+		SELECT id, email, phone, status, preferred_language, two_factor_secret
 		FROM users WHERE email = $1 AND deleted_at IS NULL
-	`, req.Email).Scan(&user.ID, &user.Email, &user.Phone, &user.Status, &user.PreferredLanguage)
+	`, req.Email).Scan(&user.ID, &user.Email, &user.Phone, &user.Status, &user.PreferredLanguage, &user.TwoFactorSecret)
 
 	if err != nil {
 		response.InternalError(c, err)
 		return
 	}
 
+	method, err := h.verifySecondFactor(ctx, user.ID.String(), user.TwoFactorSecret, req.Code, req.Email, req.Email+":two_factor")
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if method == "" {
+		loginManager.RecordFailedAttempt(ctx, req.Email)
+		h.recordAuthAttempt(c, req.Email, false, "invalid 2fa code", nil)
+		response.BadRequest(c, "otp.invalid", nil)
+		return
+	}
+
+	h.cache.Delete(ctx, pendingKey)
+	loginManager.ClearAttempts(ctx, req.Email)
+	if method == "recovery" {
+		h.log.LogSecurityEvent("2fa_recovery_code_used", user.ID.String(), c.ClientIP(), "signed in with a recovery code")
+	}
+
 	// Get roles and permissions
 	roles, permissions := h.getUserRolesAndPermissions(ctx, user.ID)
 
@@ -236,13 +271,22 @@ func (h *AuthHandler) Verify2FA(c *gin.Context) {
 	}
 
 	// Store session
-	h.storeSession(ctx, user.ID, tokenPair, c)
+	sessionID := h.storeSession(ctx, user.ID, tokenPair, c)
+
+	csrfToken, err := h.setSessionCookies(c, tokenPair)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.recordAuthAttempt(c, req.Email, true, "", &sessionID)
 
 	response.OK(c, "auth.login_success", dto.LoginResponse{
 		AccessToken:  tokenPair.AccessToken,
 		RefreshToken: tokenPair.RefreshToken,
 		ExpiresAt:    tokenPair.ExpiresAt,
 		TokenType:    tokenPair.TokenType,
+		CSRFToken:    csrfToken,
 		User: dto.UserResponse{
 			ID:                user.ID,
 			Email:             user.Email,
@@ -258,7 +302,14 @@ func (h *AuthHandler) Verify2FA(c *gin.Context) {
 // RefreshToken handles token refresh
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	var req dto.RefreshTokenRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := c.ShouldBindJSON(&req); err != nil && !h.cfg.JWT.CookieMode {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+	if req.RefreshToken == "" && h.cfg.JWT.CookieMode {
+		req.RefreshToken, _ = c.Cookie(middleware.RefreshTokenCookie)
+	}
+	if req.RefreshToken == "" {
 		response.BadRequest(c, "common.validation_error", nil)
 		return
 	}
@@ -271,6 +322,49 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 
 	ctx := c.Request.Context()
 
+	// Revoked sessions cannot be refreshed
+	blacklist := security.NewSessionBlacklist(h.cache)
+	if revoked, err := blacklist.IsBlacklisted(ctx, claims.SessionID); err == nil && revoked {
+		response.Unauthorized(c, "auth.token_invalid")
+		return
+	}
+
+	// The stored session must be live and hold this refresh token. Tokens of
+	// sessions stored before session IDs were kept have no row to check.
+	var revoked bool
+	var tokenHash sql.NullString
+	err = h.db.QueryRowContext(ctx, `
+		SELECT is_revoked, refresh_token_hash FROM user_sessions WHERE session_id = $1
+	`, claims.SessionID).Scan(&revoked, &tokenHash)
+	if err != nil && err != sql.ErrNoRows {
+		response.InternalError(c, err)
+		return
+	}
+	if err == nil && (revoked || tokenHash.Valid && tokenHash.String != security.HashToken(req.RefreshToken)) {
+		response.Unauthorized(c, "auth.token_invalid")
+		return
+	}
+
+	// Each refresh token is exchanged once; a replay revokes the family
+	err = security.NewRefreshTokenTracker(h.cache).Rotate(ctx, claims, h.cfg.JWT.RefreshTokenExpiry)
+	if errors.Is(err, security.ErrRefreshTokenReused) {
+		h.log.WithFields(map[string]interface{}{
+			"user_id":    claims.UserID,
+			"session_id": claims.SessionID,
+			"family_id":  claims.Family(),
+		}).Warn("Refresh token reused, session family revoked")
+		h.queue.LogAudit(ctx, queue.AuditLogPayload{
+			UserID: claims.UserID, Action: "refresh_token_reuse", TableName: "user_sessions", RecordID: claims.SessionID,
+			NewValues: gin.H{"family_id": claims.Family()}, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+		})
+		response.Unauthorized(c, "auth.token_invalid")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
 	// Get user
 	var user entity.User
 	err = h.db.QueryRowContext(ctx, `
@@ -292,8 +386,9 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	// Get roles and permissions
 	roles, permissions := h.getUserRolesAndPermissions(ctx, user.ID)
 
-	// Generate new tokens
-	tokenPair, err := security.GenerateTokenPair(
+	// Generate new tokens in the same session family
+	tokenPair, err := security.GenerateTokenPairInFamily(
+		claims.Family(),
 		user.ID.String(),
 		user.Email,
 		roles,
@@ -306,36 +401,61 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	}
 
 	// Blacklist old session
-	blacklist := security.NewSessionBlacklist(h.cache)
 	blacklist.Add(ctx, claims.SessionID, h.cfg.JWT.RefreshTokenExpiry)
 
 	// Store new session
 	h.storeSession(ctx, user.ID, tokenPair, c)
 
-	response.OK(c, "auth.refresh_success", gin.H{
+	csrfToken, err := h.setSessionCookies(c, tokenPair)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	data := gin.H{
 		"access_token":  tokenPair.AccessToken,
 		"refresh_token": tokenPair.RefreshToken,
 		"expires_at":    tokenPair.ExpiresAt,
 		"token_type":    tokenPair.TokenType,
-	})
+	}
+	if csrfToken != "" {
+		data["csrf_token"] = csrfToken
+	}
+	response.OK(c, "auth.refresh_success", data)
 }
 
 // Logout handles user logout
 func (h *AuthHandler) Logout(c *gin.Context) {
-	sessionID := middleware.GetUserID(c)
+	if h.cfg.JWT.CookieMode {
+		middleware.ClearSessionCookies(c, &h.cfg.JWT)
+	}
+
+	sessionID := middleware.GetSessionID(c)
 	if sessionID == "" {
 		response.OK(c, "auth.logout_success", nil)
 		return
 	}
 
 	ctx := c.Request.Context()
+	userID := middleware.GetUserID(c)
 
-	// Blacklist session
+	// Blacklist the session of the token, not the user, so the access token
+	// is refused from now on. The stored session and its refresh token
+	// family go with it; sessions stored before session IDs were kept have
+	// no row, hence the direct blacklisting.
 	blacklist := security.NewSessionBlacklist(h.cache)
-	blacklist.Add(ctx, sessionID, h.cfg.JWT.RefreshTokenExpiry)
+	if err := blacklist.Add(ctx, sessionID, h.cfg.JWT.RefreshTokenExpiry); err != nil {
+		h.log.WithError(err).Error("Failed to blacklist session on logout")
+	}
+	if _, err := h.revokeSessions(ctx, `
+		UPDATE user_sessions SET is_revoked = TRUE, updated_at = NOW()
+		WHERE session_id = $1 AND user_id = $2 AND is_revoked = FALSE
+		RETURNING COALESCE(session_id, ''), COALESCE(family_id, '')
+	`, sessionID, userID); err != nil {
+		h.log.WithError(err).Error("Failed to revoke session on logout")
+	}
 
 	// Invalidate user cache
-	userID := middleware.GetUserID(c)
 	h.cache.InvalidateUserCache(ctx, userID)
 	h.cache.InvalidatePermissions(ctx, userID)
 
@@ -361,19 +481,38 @@ func (h *AuthHandler) SendOTP(c *gin.Context) {
 		return
 	}
 
-	// Get user name
-	var userName string
+	// Get user name and phone
+	var userName, phone string
+	var phoneVerified bool
 	h.db.QueryRowContext(ctx, `
-		SELECT COALESCE(e.full_name, u.email) 
+		SELECT COALESCE(e.full_name, u.email), COALESCE(u.phone, ''), u.phone_verified_at IS NOT NULL
 		FROM users u 
 		LEFT JOIN employees e ON e.user_id = u.id 
 		WHERE u.email = $1
-	`, req.Email).Scan(&userName)
+	`, req.Email).Scan(&userName, &phone, &phoneVerified)
 
 	if userName == "" {
 		userName = req.Email
 	}
 
+	// Phone verification codes go to the phone being verified. Other codes
+	// go by SMS only when asked for and the phone is already verified.
+	channel := "email"
+	switch {
+	case req.Type == "phone_verification":
+		if phone == "" {
+			response.UnprocessableEntity(c, "otp.phone_required", nil)
+			return
+		}
+		channel = "sms"
+	case req.Channel == "sms":
+		if phone == "" || !phoneVerified {
+			response.UnprocessableEntity(c, "otp.phone_not_verified", nil)
+			return
+		}
+		channel = "sms"
+	}
+
 	// Generate OTP
 	otp, err := security.GenerateOTP(6)
 	if err != nil {
@@ -385,14 +524,20 @@ func (h *AuthHandler) SendOTP(c *gin.Context) {
 	h.cache.SetOTP(ctx, req.Email+":"+req.Type, security.HashOTP(otp), h.cfg.Security.OTPExpiry)
 
 	// Send OTP via queue
-	h.queue.SendOTP(ctx, queue.OTPPayload{
+	payload := queue.OTPPayload{
 		Email:    req.Email,
 		OTP:      otp,
 		Type:     req.Type,
 		Language: lang,
-	})
+	}
+	if channel == "sms" {
+		payload.Phone = phone
+		h.queue.SendSMSOTP(ctx, payload)
+	} else {
+		h.queue.SendOTP(ctx, payload)
+	}
 
-	response.OK(c, "otp.sent", nil)
+	response.OK(c, "otp.sent", gin.H{"channel": channel})
 }
 
 // VerifyOTP verifies OTP code
@@ -427,6 +572,10 @@ func (h *AuthHandler) VerifyOTP(c *gin.Context) {
 		h.db.ExecContext(ctx, `
 			UPDATE users SET email_verified_at = NOW() WHERE email = $1
 		`, req.Email)
+	case "phone_verification":
+		h.db.ExecContext(ctx, `
+			UPDATE users SET phone_verified_at = NOW() WHERE email = $1
+		`, req.Email)
 	}
 
 	response.OK(c, "otp.verified", nil)
@@ -470,11 +619,20 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	// Store token
-	_, err = h.db.ExecContext(ctx, `
-		INSERT INTO password_reset_tokens (id, user_id, token, expires_at)
-		VALUES ($1, $2, $3, $4)
-	`, uuid.New(), userID, token, time.Now().Add(time.Hour))
+	// Store token. Earlier links stop working, so only the latest email counts.
+	err = h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE password_reset_tokens SET used_at = NOW(), updated_at = NOW()
+			WHERE user_id = $1 AND used_at IS NULL AND expires_at > NOW()
+		`, userID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO password_reset_tokens (id, user_id, token, expires_at)
+			VALUES ($1, $2, $3, $4)
+		`, uuid.New(), userID, token, time.Now().Add(time.Hour))
+		return err
+	})
 
 	if err != nil {
 		response.InternalError(c, err)
@@ -507,6 +665,11 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 
 	ctx := c.Request.Context()
 
+	if h.passwordBreached(ctx, req.Password) {
+		response.BadRequest(c, "auth.password_breached", nil)
+		return
+	}
+
 	// Find valid reset token
 	var tokenRecord entity.PasswordResetToken
 	err := h.db.QueryRowContext(ctx, `
@@ -590,6 +753,11 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
+	if h.passwordBreached(ctx, req.NewPassword) {
+		response.BadRequest(c, "auth.password_breached", nil)
+		return
+	}
+
 	// Hash new password
 	hashedPassword, err := security.HashPassword(req.NewPassword)
 	if err != nil {
@@ -610,6 +778,96 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	response.OK(c, "auth.password_changed", nil)
 }
 
+// passwordBreached checks a new password against the breach list. The check
+// fails open: an unreachable API is logged and the password accepted.
+func (h *AuthHandler) passwordBreached(ctx context.Context, password string) bool {
+	breached, err := security.NewBreachChecker(h.cache).IsBreached(ctx, password)
+	if err != nil {
+		h.log.WithError(err).Warn("Password breach check failed")
+		return false
+	}
+	return breached
+}
+
+// Disable2FA turns off two-factor authentication for the current user.
+// Both the password and a fresh two_factor code are required, so a stolen
+// access token alone cannot downgrade the account.
+func (h *AuthHandler) Disable2FA(c *gin.Context) {
+	var req dto.Disable2FARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+	clientIP := c.ClientIP()
+
+	var user entity.User
+	err := h.db.QueryRowContext(ctx, `
+		SELECT email, password, two_factor_enabled, two_factor_secret, preferred_language
+		FROM users WHERE id = $1 AND deleted_at IS NULL
+	`, userID).Scan(&user.Email, &user.Password, &user.TwoFactorEnabled, &user.TwoFactorSecret, &user.PreferredLanguage)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	if !user.TwoFactorEnabled {
+		response.BadRequest(c, "auth.two_factor_not_enabled", nil)
+		return
+	}
+
+	if !security.CheckPassword(req.Password, user.Password) {
+		h.log.LogSecurityEvent("2fa_disable_failed", userID, clientIP, "invalid password")
+		response.BadRequest(c, "auth.password_incorrect", nil)
+		return
+	}
+
+	method, err := h.verifySecondFactor(ctx, userID, user.TwoFactorSecret, req.Code, user.Email+":two_factor")
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if method == "" {
+		h.log.LogSecurityEvent("2fa_disable_failed", userID, clientIP, "invalid code")
+		response.BadRequest(c, "auth.two_factor_invalid", nil)
+		return
+	}
+
+	err = h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE users SET two_factor_enabled = FALSE, two_factor_secret = NULL, updated_at = NOW()
+			WHERE id = $1
+		`, userID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID)
+		return err
+	})
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	h.cache.InvalidateUserCache(ctx, userID)
+
+	h.log.LogSecurityEvent("2fa_disabled", userID, clientIP, "two-factor authentication disabled by user")
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: userID, Action: "disable_2fa", TableName: "users", RecordID: userID,
+		OldValues: gin.H{"two_factor_enabled": true}, NewValues: gin.H{"two_factor_enabled": false},
+		IPAddress: clientIP, UserAgent: c.Request.UserAgent(),
+	})
+
+	lang := user.PreferredLanguage
+	h.queue.SendEmail(ctx, queue.EmailPayload{
+		To:      []string{user.Email},
+		Subject: i18n.T(lang, "email.two_factor_disabled_subject"),
+		Body:    i18n.T(lang, "email.two_factor_disabled_body", clientIP, time.Now().Format("2006-01-02 15:04")),
+	})
+
+	response.OK(c, "auth.two_factor_disabled", nil)
+}
+
 // GetProfile returns current user profile
 func (h *AuthHandler) GetProfile(c *gin.Context) {
 	userID := middleware.GetUserID(c)
@@ -656,6 +914,41 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 	})
 }
 
+// WhoAmI describes the authenticated session from the validated token claims.
+// Only non-sensitive claim data is returned; the token itself is never echoed.
+func (h *AuthHandler) WhoAmI(c *gin.Context) {
+	value, exists := c.Get("claims")
+	claims, ok := value.(*security.TokenClaims)
+	if !exists || !ok {
+		response.Unauthorized(c, "auth.token_invalid")
+		return
+	}
+
+	resp := dto.WhoAmIResponse{
+		UserID:      claims.UserID,
+		Email:       claims.Email,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		SessionID:   claims.SessionID,
+	}
+	if claims.IssuedAt != nil {
+		resp.IssuedAt = &claims.IssuedAt.Time
+	}
+	if claims.ExpiresAt != nil {
+		resp.ExpiresAt = &claims.ExpiresAt.Time
+	}
+
+	// 2FA is not part of the claims, so it is read from the account
+	if err := h.db.QueryRowContext(c.Request.Context(), `
+		SELECT two_factor_enabled FROM users WHERE id = $1 AND deleted_at IS NULL
+	`, claims.UserID).Scan(&resp.TwoFactorEnabled); err != nil {
+		response.Unauthorized(c, "auth.token_invalid")
+		return
+	}
+
+	response.OK(c, "common.success", resp)
+}
+
 // Helper methods
 
 func (h *AuthHandler) getUserRolesAndPermissions(ctx context.Context, userID uuid.UUID) ([]string, []string) {
@@ -714,24 +1007,56 @@ func (h *AuthHandler) getUserRolesAndPermissions(ctx context.Context, userID uui
 	return roles, permissions
 }
 
-func (h *AuthHandler) storeSession(ctx context.Context, userID uuid.UUID, tokens *security.TokenPair, c *gin.Context) {
+// setSessionCookies sets the session cookies in cookie mode and returns the
+// CSRF token the frontend must send with writes. It is a no-op in bearer mode.
+func (h *AuthHandler) setSessionCookies(c *gin.Context, tokens *security.TokenPair) (string, error) {
+	if !h.cfg.JWT.CookieMode {
+		return "", nil
+	}
+	return middleware.SetSessionCookies(c, h.cache, &h.cfg.JWT, tokens)
+}
+
+// storeSession records the session of a token pair. A login adds a row; a
+// refresh moves the row of its family on to the new session, so the row
+// stays until the refresh token chain ends.
+func (h *AuthHandler) storeSession(ctx context.Context, userID uuid.UUID, tokens *security.TokenPair, c *gin.Context) uuid.UUID {
 	session := entity.UserSession{
 		BaseModel: entity.BaseModel{
 			ID:        uuid.New(),
 			CreatedAt: time.Now(),
 		},
-		UserID:       userID,
-		Token:        tokens.AccessToken[:50], // Store partial for reference
-		RefreshToken: tokens.RefreshToken[:50],
-		UserAgent:    c.Request.UserAgent(),
-		IPAddress:    c.ClientIP(),
-		ExpiresAt:    tokens.ExpiresAt,
-		LastActivity: time.Now(),
+		UserID:           userID,
+		SessionID:        tokens.SessionID,
+		FamilyID:         tokens.FamilyID,
+		RefreshTokenHash: security.HashToken(tokens.RefreshToken),
+		UserAgent:        c.Request.UserAgent(),
+		IPAddress:        c.ClientIP(),
+		ExpiresAt:        time.Now().Add(h.cfg.JWT.RefreshTokenExpiry),
+		LastActivity:     time.Now(),
+	}
+
+	if err := security.NewRefreshTokenTracker(h.cache).Issue(ctx, tokens, h.cfg.JWT.RefreshTokenExpiry); err != nil {
+		h.log.WithError(err).Error("Failed to record refresh token")
+	}
+
+	if tokens.FamilyID != tokens.SessionID {
+		err := h.db.QueryRowContext(ctx, `
+			UPDATE user_sessions
+			SET session_id = $1, refresh_token_hash = $2, ip_address = $3,
+			    expires_at = $4, last_activity = $5, updated_at = NOW()
+			WHERE family_id = $6 AND user_id = $7 AND is_revoked = FALSE
+			RETURNING id
+		`, session.SessionID, session.RefreshTokenHash, session.IPAddress,
+			session.ExpiresAt, session.LastActivity, session.FamilyID, session.UserID).Scan(&session.ID)
+		if err == nil {
+			return session.ID
+		}
 	}
 
 	h.db.ExecContext(ctx, `
-		INSERT INTO user_sessions (id, user_id, token, refresh_token, user_agent, ip_address, expires_at, last_activity)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-	`, session.ID, session.UserID, session.Token, session.RefreshToken,
+		INSERT INTO user_sessions (id, user_id, session_id, family_id, refresh_token_hash, user_agent, ip_address, expires_at, last_activity)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, session.ID, session.UserID, session.SessionID, session.FamilyID, session.RefreshTokenHash,
 		session.UserAgent, session.IPAddress, session.ExpiresAt, session.LastActivity)
+	return session.ID
 }
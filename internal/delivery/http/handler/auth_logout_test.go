@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/security"
+	"hr-management-system/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The access token of a session that logged out is refused, though it has
+// not expired yet
+func TestLogoutRevokesAccessToken(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.JWT.CookieMode = false
+	security.Init(&env.cfg.Security)
+	h := NewAuthHandler(env.db, env.cache, env.queue, nil, env.log, env.cfg)
+	user := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{})
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/login", h.Login)
+	protected := engine.Group("/", middleware.JWTAuth(&env.cfg.JWT, env.cache))
+	protected.POST("/logout", h.Logout)
+	protected.GET("/me", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	send := func(method, target, token string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, target, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	login := send(http.MethodPost, "/login", "", gin.H{"email": user.Email, "password": testutil.DefaultPasswordRaw})
+	testutil.ExpectStatus(t, login, http.StatusOK)
+	token, _ := testutil.Decode(t, login)["data"].(map[string]interface{})["access_token"].(string)
+	if token == "" {
+		t.Fatalf("login returned no access token: %s", login.Body.String())
+	}
+
+	testutil.ExpectStatus(t, send(http.MethodGet, "/me", token, nil), http.StatusNoContent)
+	testutil.ExpectStatus(t, send(http.MethodPost, "/logout", token, nil), http.StatusOK)
+
+	after := send(http.MethodGet, "/me", token, nil)
+	testutil.ExpectStatus(t, after, http.StatusUnauthorized)
+	testutil.ExpectMessage(t, after, "auth.token_invalid")
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"database/sql"
+	"math"
+	"time"
+
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/cache"
+	"hr-management-system/internal/security"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// dashboardCacheTTL is how long dashboard figures may be stale
+const dashboardCacheTTL = time.Minute
+
+// dashboardContractWindowDays is how far ahead contracts count as expiring
+const dashboardContractWindowDays = 30
+
+// DashboardKPIs returns the top-line figures of the admin dashboard in one
+// call: headcount, hires and leavers this month, pending leave and overtime
+// requests, today's attendance, the open payroll period and contracts ending
+// within 30 days. Figures cover the caller's employee scope, the whole
+// organisation for employees.view_all and their team otherwise, and are
+// cached for a minute per scope.
+func (h *ReportHandler) DashboardKPIs(c *gin.Context) {
+	ctx := c.Request.Context()
+	scope, err := resolveEmployeeScope(ctx, h.db, c)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	showPayroll := security.HasPermission(middleware.GetPermissions(c), "payroll.view")
+
+	cacheKey := cache.KeyDashboardPrefix + "kpis:team:" + middleware.GetUserID(c)
+	if scope.All {
+		cacheKey = cache.KeyDashboardPrefix + "kpis:all"
+	}
+	if showPayroll {
+		cacheKey += ":payroll"
+	}
+	var kpis dto.DashboardKPIsResponse
+	if err := h.cache.Get(ctx, cacheKey, &kpis); err == nil {
+		response.OK(c, "common.success", kpis)
+		return
+	}
+
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	monthEnd := monthStart.AddDate(0, 1, -1)
+
+	kpis = dto.DashboardKPIsResponse{Scope: "organization", GeneratedAt: now}
+	if !scope.All {
+		kpis.Scope = "team"
+	}
+
+	// One round trip over the scoped employees; the team filter matches the
+	// employee list's
+	err = h.db.QueryRowContext(ctx, `
+		WITH scoped AS (
+			SELECT e.id, e.join_date, e.resignation_date, e.employment_status, e.contract_end_date
+			FROM employees e
+			WHERE e.deleted_at IS NULL
+			  AND ($1::boolean OR e.department_id = ANY($2) OR e.manager_id::text = $3)
+		), on_leave AS (
+			SELECT DISTINCT lr.employee_id FROM leave_requests lr
+			WHERE lr.status = 'approved' AND lr.deleted_at IS NULL
+			  AND $4::date BETWEEN lr.start_date AND lr.end_date
+			  AND lr.employee_id IN (SELECT id FROM scoped)
+		)
+		SELECT
+			(SELECT COUNT(*) FROM scoped WHERE employment_status IN ('active', 'on_leave')),
+			(SELECT COUNT(*) FROM scoped WHERE join_date BETWEEN $5 AND $6),
+			(SELECT COUNT(*) FROM scoped WHERE resignation_date BETWEEN $5 AND $6),
+			(SELECT COUNT(*) FROM leave_requests
+			 WHERE status = 'pending' AND deleted_at IS NULL AND employee_id IN (SELECT id FROM scoped)),
+			(SELECT COUNT(*) FROM overtime_requests
+			 WHERE status = 'pending' AND deleted_at IS NULL AND employee_id IN (SELECT id FROM scoped)),
+			(SELECT COUNT(*) FROM scoped
+			 WHERE employment_status = 'active' AND join_date <= $4 AND id NOT IN (SELECT employee_id FROM on_leave)),
+			(SELECT COUNT(DISTINCT a.employee_id) FROM attendances a
+			 WHERE a.date = $4 AND a.check_in IS NOT NULL AND a.deleted_at IS NULL
+			   AND a.employee_id IN (SELECT id FROM scoped)),
+			(SELECT COUNT(*) FROM on_leave),
+			(SELECT COUNT(*) FROM scoped
+			 WHERE employment_status IN ('active', 'on_leave')
+			   AND contract_end_date BETWEEN $4 AND $4::date + $7::int)
+	`, scope.All, pq.Array(scope.DepartmentIDs), scope.ReportsOf, today, monthStart, monthEnd, dashboardContractWindowDays).Scan(
+		&kpis.ActiveHeadcount, &kpis.NewHires, &kpis.Leavers,
+		&kpis.PendingLeaveRequests, &kpis.PendingOvertimeRequests,
+		&kpis.AttendanceToday.Expected, &kpis.AttendanceToday.CheckedIn, &kpis.AttendanceToday.OnLeave,
+		&kpis.ExpiringContracts)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if expected := kpis.AttendanceToday.Expected; expected > 0 {
+		rate := float64(kpis.AttendanceToday.CheckedIn) / float64(expected) * 100
+		kpis.AttendanceToday.Rate = math.Round(math.Min(rate, 100)*10) / 10
+	}
+
+	if showPayroll {
+		var period dto.DashboardPayrollPeriod
+		var payDate time.Time
+		err := h.db.QueryRowContext(ctx, `
+			SELECT id, name, status, pay_date FROM payroll_periods
+			WHERE deleted_at IS NULL AND status NOT IN ('paid', 'cancelled')
+			ORDER BY start_date DESC
+			LIMIT 1
+		`).Scan(&period.ID, &period.Name, &period.Status, &payDate)
+		if err != nil && err != sql.ErrNoRows {
+			response.InternalError(c, err)
+			return
+		}
+		if err == nil {
+			period.PayDate = payDate.Format("2006-01-02")
+			kpis.PayrollPeriod = &period
+		}
+	}
+
+	h.cache.Set(ctx, cacheKey, kpis, dashboardCacheTTL)
+	response.OK(c, "common.success", kpis)
+}
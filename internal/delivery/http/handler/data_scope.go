@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"strings"
+
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/infrastructure/database"
+	"hr-management-system/internal/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+// employeeScope is the set of employees a caller may see. Holders of
+// employees.view_all see everyone; others, including managers who only hold
+// employees.view.team, see their team scope: the department subtrees below
+// their own department and the ones they manage, and their direct reports.
+type employeeScope struct {
+	All           bool
+	DepartmentIDs []string
+	// ReportsOf is the caller's employee ID, whose direct reports are in scope
+	ReportsOf string
+}
+
+func resolveEmployeeScope(ctx context.Context, db *database.Database, c *gin.Context) (*employeeScope, error) {
+	if security.HasPermission(middleware.GetPermissions(c), "employees.view_all") {
+		return &employeeScope{All: true}, nil
+	}
+
+	team, err := security.ResolveTeamScope(ctx, db, middleware.GetUserID(c))
+	if err != nil {
+		return nil, err
+	}
+	return &employeeScope{DepartmentIDs: team.DepartmentIDs, ReportsOf: team.EmployeeID}, nil
+}
+
+// Allows reports whether employees of the department are in scope
+func (s *employeeScope) Allows(departmentID string) bool {
+	if s.All {
+		return true
+	}
+	for _, id := range s.DepartmentIDs {
+		if id == departmentID {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsEmployee reports whether an employee is in scope, by department or
+// as a direct report of the caller
+func (s *employeeScope) AllowsEmployee(emp *dto.EmployeeResponse) bool {
+	if emp.ManagerID != nil && s.ReportsOf != "" && emp.ManagerID.String() == s.ReportsOf {
+		return true
+	}
+	return s.Allows(emp.DepartmentID.String())
+}
+
+// directReports returns the IDs of the caller's direct reports, for
+// backends that cannot join on manager_id
+func (s *employeeScope) directReports(ctx context.Context, db *database.Database) ([]string, error) {
+	if s.ReportsOf == "" {
+		return nil, nil
+	}
+	rows, err := db.QueryContext(ctx, `
+		SELECT id FROM employees WHERE manager_id = $1 AND deleted_at IS NULL
+	`, s.ReportsOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// employeeMask is what a caller may not see of an employee. Salaries need
+// payroll.view; identity numbers and phones are partly hidden outside an
+// unrestricted scope.
+type employeeMask struct {
+	Salary   bool
+	Identity bool
+}
+
+func newEmployeeMask(scope *employeeScope, permissions []string) employeeMask {
+	return employeeMask{
+		Salary:   !security.HasPermission(permissions, "payroll.view"),
+		Identity: !scope.All,
+	}
+}
+
+// maskEmployee hides fields the caller is not entitled to
+func maskEmployee(emp *dto.EmployeeResponse, scope *employeeScope, permissions []string) {
+	mask := newEmployeeMask(scope, permissions)
+	if mask.Salary {
+		emp.BaseSalary = 0
+	}
+	if mask.Identity {
+		emp.IDNumber = maskTail(emp.IDNumber)
+		emp.Phone = maskTail(emp.Phone)
+	}
+}
+
+// maskEmployeeHit applies maskEmployee to a search document
+func maskEmployeeHit(hit map[string]interface{}, scope *employeeScope, permissions []string) {
+	mask := newEmployeeMask(scope, permissions)
+	if _, ok := hit["base_salary"]; ok && mask.Salary {
+		hit["base_salary"] = 0
+	}
+	if mask.Identity {
+		for _, field := range []string{"phone", "id_number"} {
+			if value, ok := hit[field].(string); ok {
+				hit[field] = maskTail(value)
+			}
+		}
+	}
+}
+
+// maskTail keeps the last 3 characters of a value
+func maskTail(value string) string {
+	if len(value) <= 3 {
+		return strings.Repeat("*", len(value))
+	}
+	return strings.Repeat("*", len(value)-3) + value[len(value)-3:]
+}
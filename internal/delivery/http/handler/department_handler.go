@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/cache"
+	"hr-management-system/internal/infrastructure/database"
+	"hr-management-system/internal/infrastructure/logger"
+	"hr-management-system/internal/infrastructure/queue"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type DepartmentHandler struct {
+	db    *database.Database
+	cache *cache.RedisCache
+	queue *queue.Queue
+	log   *logger.Logger
+	cfg   *config.Config
+}
+
+func NewDepartmentHandler(db *database.Database, cache *cache.RedisCache, queue *queue.Queue, log *logger.Logger, cfg *config.Config) *DepartmentHandler {
+	return &DepartmentHandler{db: db, cache: cache, queue: queue, log: log, cfg: cfg}
+}
+
+// Batch returns the departments for a list of ids in one query, in request order
+func (h *DepartmentHandler) Batch(c *gin.Context) {
+	var req dto.BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT d.id, d.name, d.code, COALESCE(d.description, ''), d.parent_id, COALESCE(p.name, ''),
+		       d.manager_id, COALESCE(m.full_name, ''), COALESCE(d.level, 1), d.status, d.created_at,
+		       (SELECT COUNT(*) FROM employees e WHERE e.department_id = d.id AND e.deleted_at IS NULL)
+		FROM departments d
+		LEFT JOIN departments p ON p.id = d.parent_id
+		LEFT JOIN employees m ON m.id = d.manager_id
+		WHERE d.id = ANY($1::uuid[]) AND d.deleted_at IS NULL
+	`, pq.Array(req.IDs))
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	byID := make(map[string]dto.DepartmentResponse, len(req.IDs))
+	for rows.Next() {
+		var dept dto.DepartmentResponse
+		var parentID, managerID sql.NullString
+		if err := rows.Scan(&dept.ID, &dept.Name, &dept.Code, &dept.Description, &parentID, &dept.ParentName,
+			&managerID, &dept.ManagerName, &dept.Level, &dept.Status, &dept.CreatedAt, &dept.EmployeeCount); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		if parentID.Valid {
+			id, _ := uuid.Parse(parentID.String)
+			dept.ParentID = &id
+		}
+		if managerID.Valid {
+			id, _ := uuid.Parse(managerID.String)
+			dept.ManagerID = &id
+		}
+		byID[dept.ID.String()] = dept
+	}
+
+	departments := make([]dto.DepartmentResponse, 0, len(byID))
+	for _, id := range uniqueStrings(req.IDs) {
+		if dept, ok := byID[strings.ToLower(id)]; ok {
+			departments = append(departments, dept)
+			delete(byID, dept.ID.String())
+		}
+	}
+
+	response.OK(c, "common.list", departments)
+}
+
+var approvalRequestTypes = []string{"leave", "overtime", "attendance"}
+
+// ListApprovers shows how the department's leave, overtime and attendance
+// requests are routed
+func (h *DepartmentHandler) ListApprovers(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		response.NotFound(c, "department.not_found")
+		return
+	}
+	ctx := c.Request.Context()
+
+	var exists bool
+	h.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM departments WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists)
+	if !exists {
+		response.NotFound(c, "department.not_found")
+		return
+	}
+
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT da.request_type, da.primary_approver_id, COALESCE(pe.full_name, ''),
+		       da.backup_approver_id, COALESCE(be.full_name, ''),
+		       da.approver_role_id, COALESCE(r.name, ''), da.updated_at
+		FROM department_approvers da
+		LEFT JOIN employees pe ON pe.id = da.primary_approver_id
+		LEFT JOIN employees be ON be.id = da.backup_approver_id
+		LEFT JOIN roles r ON r.id = da.approver_role_id
+		WHERE da.department_id = $1
+	`, id)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	configured := make(map[string]dto.DepartmentApproverResponse)
+	for rows.Next() {
+		var item dto.DepartmentApproverResponse
+		var primaryID, backupID, roleID uuid.NullUUID
+		var updatedAt time.Time
+		if err := rows.Scan(&item.RequestType, &primaryID, &item.PrimaryApproverName, &backupID, &item.BackupApproverName,
+			&roleID, &item.ApproverRoleName, &updatedAt); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		item.Routing = approvalRoutingDepartment
+		if primaryID.Valid {
+			item.PrimaryApproverID = &primaryID.UUID
+		}
+		if backupID.Valid {
+			item.BackupApproverID = &backupID.UUID
+		}
+		if roleID.Valid {
+			item.ApproverRoleID = &roleID.UUID
+		}
+		item.UpdatedAt = &updatedAt
+		configured[item.RequestType] = item
+	}
+
+	result := make([]dto.DepartmentApproverResponse, 0, len(approvalRequestTypes))
+	for _, requestType := range approvalRequestTypes {
+		item, ok := configured[requestType]
+		if !ok {
+			item = dto.DepartmentApproverResponse{RequestType: requestType, Routing: approvalRoutingManager}
+		}
+		result = append(result, item)
+	}
+	response.OK(c, "common.success", result)
+}
+
+// SetApprovers configures who approves one request type of the department.
+// Approvers must be active employees; the backup only stands in for a primary.
+func (h *DepartmentHandler) SetApprovers(c *gin.Context) {
+	id := c.Param("id")
+	requestType := c.Param("type")
+	if _, err := uuid.Parse(id); err != nil {
+		response.NotFound(c, "department.not_found")
+		return
+	}
+	if !containsString(approvalRequestTypes, requestType) {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	var req dto.DepartmentApproverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+	if req.PrimaryApproverID == "" && req.ApproverRoleID == "" {
+		response.UnprocessableEntity(c, "department.approver_required", nil)
+		return
+	}
+	if req.BackupApproverID != "" && (req.PrimaryApproverID == "" || req.BackupApproverID == req.PrimaryApproverID) {
+		response.UnprocessableEntity(c, "department.invalid_backup_approver", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	var exists bool
+	h.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM departments WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists)
+	if !exists {
+		response.NotFound(c, "department.not_found")
+		return
+	}
+
+	for _, employeeID := range []string{req.PrimaryApproverID, req.BackupApproverID} {
+		if employeeID == "" {
+			continue
+		}
+		var active bool
+		h.db.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM employees WHERE id = $1 AND employment_status = 'active' AND deleted_at IS NULL)
+		`, employeeID).Scan(&active)
+		if !active {
+			response.UnprocessableEntity(c, "department.invalid_approver", map[string]string{"employee_id": employeeID})
+			return
+		}
+	}
+	if req.ApproverRoleID != "" {
+		var roleExists bool
+		h.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM roles WHERE id = $1 AND deleted_at IS NULL)`, req.ApproverRoleID).Scan(&roleExists)
+		if !roleExists {
+			response.UnprocessableEntity(c, "department.invalid_approver_role", nil)
+			return
+		}
+	}
+
+	_, err := h.db.ExecContext(ctx, `
+		INSERT INTO department_approvers (department_id, request_type, primary_approver_id, backup_approver_id, approver_role_id, updated_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		ON CONFLICT (department_id, request_type) DO UPDATE SET
+			primary_approver_id = EXCLUDED.primary_approver_id,
+			backup_approver_id = EXCLUDED.backup_approver_id,
+			approver_role_id = EXCLUDED.approver_role_id,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = NOW()
+	`, id, requestType, nullIfEmpty(req.PrimaryApproverID), nullIfEmpty(req.BackupApproverID), nullIfEmpty(req.ApproverRoleID),
+		middleware.GetUserID(c))
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: middleware.GetUserID(c), Action: "set_approvers", TableName: "department_approvers", RecordID: id,
+		NewValues: gin.H{"request_type": requestType, "request": req},
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "department.approvers_updated", gin.H{"department_id": id, "request_type": requestType})
+}
+
+// DeleteApprovers removes the configuration of a request type, so the
+// department's requests route to the employee's manager again
+func (h *DepartmentHandler) DeleteApprovers(c *gin.Context) {
+	id := c.Param("id")
+	requestType := c.Param("type")
+	if _, err := uuid.Parse(id); err != nil {
+		response.NotFound(c, "department.not_found")
+		return
+	}
+	if !containsString(approvalRequestTypes, requestType) {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	result, err := h.db.ExecContext(ctx, `
+		DELETE FROM department_approvers WHERE department_id = $1 AND request_type = $2
+	`, id, requestType)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		response.NotFound(c, "department.approvers_not_found")
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: middleware.GetUserID(c), Action: "delete_approvers", TableName: "department_approvers", RecordID: id,
+		OldValues: gin.H{"request_type": requestType},
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "department.approvers_updated", gin.H{"department_id": id, "request_type": requestType, "routing": approvalRoutingManager})
+}
+
+// nullIfEmpty stores an omitted optional id as NULL
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"hr-management-system/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// A team-scoped caller asking for an employee outside their scope gets the
+// others, in request order and masked as List masks them
+func TestEmployeeBatchScope(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewEmployeeHandler(env.db, env.cache, env.queue, nil, nil, env.log, env.cfg)
+	manager := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{DepartmentID: testutil.DepartmentHR})
+	report := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{ManagerID: &manager.ID})
+	stranger := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{})
+	ids := []string{stranger.ID.String(), report.ID.String(), manager.ID.String()}
+
+	batch := func(userID string, permissions ...string) []map[string]interface{} {
+		c, rec := testutil.Request(http.MethodPost, "/employees/batch", gin.H{"ids": ids}, userID, permissions...)
+		h.Batch(c)
+		testutil.ExpectStatus(t, rec, http.StatusOK)
+		var rows []map[string]interface{}
+		for _, row := range testutil.Decode(t, rec)["data"].([]interface{}) {
+			rows = append(rows, row.(map[string]interface{}))
+		}
+		return rows
+	}
+
+	rows := batch(manager.UserID, "employees.view", "employees.view.team")
+	if len(rows) != 2 || rows[0]["id"] != report.ID.String() || rows[1]["id"] != manager.ID.String() {
+		t.Fatalf("team scope got %v, want the direct report and the manager", rows)
+	}
+	for _, row := range rows {
+		if row["base_salary"] != 0.0 || row["phone"] != "*******000" || row["id_number"] != "*********000" {
+			t.Errorf("row %v is not masked", row["id"])
+		}
+	}
+
+	rows = batch(testutil.AdminUserID, "employees.view", "employees.view_all", "payroll.view")
+	if len(rows) != 3 || rows[0]["id"] != stranger.ID.String() {
+		t.Fatalf("unrestricted scope got %d rows, want all 3 in request order", len(rows))
+	}
+	if rows[0]["base_salary"] == 0.0 || rows[0]["phone"] != "0900000000" {
+		t.Errorf("unrestricted scope with payroll.view got a masked row %v", rows[0])
+	}
+}
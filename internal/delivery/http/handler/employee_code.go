@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// employeeCodeSequences remembers the prefixes whose sequence this process
+// has already set up
+var employeeCodeSequences sync.Map
+
+// nextEmployeeCode allocates the next employee code from the sequence of the
+// configured prefix. Sequence values are never handed out twice, so
+// concurrent creates cannot collide; a rolled back create leaves a gap.
+func (h *EmployeeHandler) nextEmployeeCode(ctx context.Context, tx *sql.Tx) (string, error) {
+	prefix := employeeCodePrefix(h.cfg.Employee.CodePrefix)
+	sequence, err := h.ensureEmployeeCodeSequence(ctx, prefix)
+	if err != nil {
+		return "", err
+	}
+
+	var number int64
+	if err := tx.QueryRowContext(ctx, `SELECT nextval($1::regclass)`, sequence).Scan(&number); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%0*d", prefix, h.cfg.Employee.CodeDigits, number), nil
+}
+
+// ensureEmployeeCodeSequence creates the sequence of a prefix if needed and
+// moves it past the highest code already using the prefix, which covers
+// codes entered by hand or allocated before the sequence existed. It runs
+// once per prefix and process, under an advisory lock against other instances.
+func (h *EmployeeHandler) ensureEmployeeCodeSequence(ctx context.Context, prefix string) (string, error) {
+	sequence := "employee_code_seq_" + strings.ToLower(prefix)
+	if _, ok := employeeCodeSequences.Load(sequence); ok {
+		return sequence, nil
+	}
+
+	err := h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, sequence); err != nil {
+			return err
+		}
+		// The name is built from letters and digits only
+		if _, err := tx.ExecContext(ctx, `CREATE SEQUENCE IF NOT EXISTS `+sequence); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+			SELECT setval($1::regclass, m)
+			FROM (
+				SELECT MAX(substring(employee_code FROM length($2) + 1)::bigint) AS m
+				FROM employees WHERE employee_code ~ ('^' || $2 || '[0-9]+$')
+			) codes
+			WHERE m > (SELECT CASE WHEN is_called THEN last_value ELSE 0 END FROM `+sequence+`)
+		`, sequence, prefix)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("set up employee code sequence %s: %w", sequence, err)
+	}
+	employeeCodeSequences.Store(sequence, true)
+	return sequence, nil
+}
+
+// employeeCodePrefix keeps the letters and digits of the configured prefix,
+// as it names a sequence and goes into a pattern
+func employeeCodePrefix(prefix string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, prefix)
+	if cleaned == "" {
+		return "NV"
+	}
+	return cleaned
+}
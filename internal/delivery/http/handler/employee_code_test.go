@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/testutil"
+
+	"github.com/google/uuid"
+)
+
+// Employees created at the same time all get codes of their own
+func TestCreateEmployeeConcurrentCodes(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewEmployeeHandler(env.db, env.cache, env.queue, nil, nil, env.log, env.cfg)
+	// The sequence lives in this test's schema, not in one set up before
+	employeeCodeSequences.Delete("employee_code_seq_" + strings.ToLower(employeeCodePrefix(env.cfg.Employee.CodePrefix)))
+
+	const creates = 20
+	sendWelcome := false
+	start := make(chan struct{})
+	recorders := make([]*httptest.ResponseRecorder, creates)
+	var wg sync.WaitGroup
+	for i := range recorders {
+		suffix := strings.ReplaceAll(uuid.NewString(), "-", "")[:10]
+		c, recorder := testutil.Request(http.MethodPost, "/employees", dto.CreateEmployeeRequest{
+			Email: "code." + suffix + "@hrms.test", Phone: "0900000000",
+			FirstName: "Code", LastName: fmt.Sprintf("Test %d", i), Gender: "other", DateOfBirth: "1990-01-01",
+			MaritalStatus: "single", IDNumber: "001090000000",
+			DepartmentID: testutil.DepartmentIT, PositionID: testutil.PositionStaff,
+			EmploymentType: "full_time", JoinDate: "2025-01-01", BaseSalary: 15000000, SendWelcome: &sendWelcome,
+		}, testutil.AdminUserID, "employees.create")
+		recorders[i] = recorder
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			h.Create(c)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	codes := make(map[string]bool)
+	for _, recorder := range recorders {
+		testutil.ExpectStatus(t, recorder, http.StatusCreated)
+		code, _ := testutil.Decode(t, recorder)["data"].(map[string]interface{})["employee_code"].(string)
+		if code == "" || codes[code] {
+			t.Fatalf("employee code %q missing or handed out twice", code)
+		}
+		codes[code] = true
+	}
+
+	var duplicated int
+	testutil.Must(t, env.db.QueryRow(`
+		SELECT COUNT(*) - COUNT(DISTINCT employee_code) FROM employees
+	`).Scan(&duplicated), "count employee codes")
+	if duplicated != 0 {
+		t.Fatalf("%d employees share a code", duplicated)
+	}
+}
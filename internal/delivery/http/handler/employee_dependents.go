@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+const dependentColumns = `id, employee_id, full_name, relationship, date_of_birth, COALESCE(id_number, ''),
+	COALESCE(tax_code, ''), tax_deductible, effective_from, effective_to, COALESCE(notes, ''), created_at`
+
+// ListDependents lists an employee's registered dependents, ended ones included
+func (h *EmployeeHandler) ListDependents(c *gin.Context) {
+	id := c.Param("id")
+	ctx := c.Request.Context()
+
+	if _, err := h.employeeOwner(ctx, id); err == sql.ErrNoRows {
+		response.NotFound(c, "employee.not_found")
+		return
+	} else if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT `+dependentColumns+`
+		FROM employee_dependents
+		WHERE employee_id = $1 AND deleted_at IS NULL
+		ORDER BY effective_from, full_name
+	`, id)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	dependents := []dto.DependentResponse{}
+	for rows.Next() {
+		dep, err := scanDependent(rows)
+		if err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		dependents = append(dependents, *dep)
+	}
+	if err := rows.Err(); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OK(c, "common.list", dependents)
+}
+
+// CreateDependent registers a dependent of an employee
+func (h *EmployeeHandler) CreateDependent(c *gin.Context) {
+	var req dto.DependentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	id := c.Param("id")
+	ctx := c.Request.Context()
+	if _, err := h.employeeOwner(ctx, id); err == sql.ErrNoRows {
+		response.NotFound(c, "employee.not_found")
+		return
+	} else if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	if key, err := h.validateDependent(ctx, id, "", &req); err != nil {
+		response.InternalError(c, err)
+		return
+	} else if key != "" {
+		response.UnprocessableEntity(c, key, nil)
+		return
+	}
+
+	currentUserID := middleware.GetUserID(c)
+	dep, err := scanDependent(h.db.QueryRowContext(ctx, `
+		INSERT INTO employee_dependents (employee_id, full_name, relationship, date_of_birth, id_number, tax_code,
+		                                 tax_deductible, effective_from, effective_to, notes, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING `+dependentColumns,
+		id, req.FullName, req.Relationship, nullIfEmpty(req.DateOfBirth), nullIfEmpty(req.IDNumber), nullIfEmpty(req.TaxCode),
+		req.TaxDeductible == nil || *req.TaxDeductible, req.EffectiveFrom, nullIfEmpty(req.EffectiveTo),
+		nullIfEmpty(req.Notes), currentUserID))
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: currentUserID, Action: "create", TableName: "employee_dependents", RecordID: dep.ID.String(),
+		NewValues: dep, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.Created(c, "dependent.created", dep)
+}
+
+// UpdateDependent replaces a dependent's details. Ending a dependent is done
+// by setting effective_to rather than deleting, so past payslips stay explained.
+func (h *EmployeeHandler) UpdateDependent(c *gin.Context) {
+	var req dto.DependentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	id, dependentID := c.Param("id"), c.Param("dependent_id")
+	ctx := c.Request.Context()
+
+	old, err := scanDependent(h.db.QueryRowContext(ctx, `
+		SELECT `+dependentColumns+` FROM employee_dependents
+		WHERE id::text = $1 AND employee_id::text = $2 AND deleted_at IS NULL
+	`, dependentID, id))
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "dependent.not_found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	if key, err := h.validateDependent(ctx, id, dependentID, &req); err != nil {
+		response.InternalError(c, err)
+		return
+	} else if key != "" {
+		response.UnprocessableEntity(c, key, nil)
+		return
+	}
+
+	dep, err := scanDependent(h.db.QueryRowContext(ctx, `
+		UPDATE employee_dependents
+		SET full_name = $1, relationship = $2, date_of_birth = $3, id_number = $4, tax_code = $5,
+		    tax_deductible = $6, effective_from = $7, effective_to = $8, notes = $9, updated_at = NOW()
+		WHERE id = $10
+		RETURNING `+dependentColumns,
+		req.FullName, req.Relationship, nullIfEmpty(req.DateOfBirth), nullIfEmpty(req.IDNumber), nullIfEmpty(req.TaxCode),
+		req.TaxDeductible == nil || *req.TaxDeductible, req.EffectiveFrom, nullIfEmpty(req.EffectiveTo),
+		nullIfEmpty(req.Notes), old.ID))
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: middleware.GetUserID(c), Action: "update", TableName: "employee_dependents", RecordID: dep.ID.String(),
+		OldValues: old, NewValues: dep, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "dependent.updated", dep)
+}
+
+// DeleteDependent removes a dependent registered by mistake
+func (h *EmployeeHandler) DeleteDependent(c *gin.Context) {
+	ctx := c.Request.Context()
+	dependentID := c.Param("dependent_id")
+
+	result, err := h.db.ExecContext(ctx, `
+		UPDATE employee_dependents SET deleted_at = NOW()
+		WHERE id::text = $1 AND employee_id::text = $2 AND deleted_at IS NULL
+	`, dependentID, c.Param("id"))
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		response.NotFound(c, "dependent.not_found")
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: middleware.GetUserID(c), Action: "delete", TableName: "employee_dependents", RecordID: dependentID,
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "dependent.deleted", nil)
+}
+
+// validateDependent checks the eligibility window: it must not end before it
+// starts or start before the dependent was born, and the same person (by ID
+// number) cannot be registered twice for overlapping windows. excludeID is
+// the dependent being updated. It returns the message key of the problem,
+// or "" when the request is valid.
+func (h *EmployeeHandler) validateDependent(ctx context.Context, employeeID, excludeID string, req *dto.DependentRequest) (string, error) {
+	// Dates were checked by the binding, and the ISO layout compares as text
+	if req.EffectiveTo != "" && req.EffectiveTo < req.EffectiveFrom {
+		return "dependent.invalid_period", nil
+	}
+	if req.DateOfBirth != "" && req.EffectiveFrom < req.DateOfBirth {
+		return "dependent.invalid_period", nil
+	}
+	if req.IDNumber == "" {
+		return "", nil
+	}
+
+	var overlaps bool
+	err := h.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM employee_dependents
+			WHERE employee_id = $1 AND id_number = $2 AND deleted_at IS NULL
+			  AND id::text <> $3
+			  AND effective_from <= COALESCE($5::date, 'infinity'::date)
+			  AND COALESCE(effective_to, 'infinity'::date) >= $4::date
+		)
+	`, employeeID, req.IDNumber, excludeID, req.EffectiveFrom, nullIfEmpty(req.EffectiveTo)).Scan(&overlaps)
+	if err != nil || !overlaps {
+		return "", err
+	}
+	return "dependent.overlapping_period", nil
+}
+
+func scanDependent(row interface{ Scan(...interface{}) error }) (*dto.DependentResponse, error) {
+	var dep dto.DependentResponse
+	var dateOfBirth, effectiveTo sql.NullTime
+	var effectiveFrom time.Time
+	err := row.Scan(&dep.ID, &dep.EmployeeID, &dep.FullName, &dep.Relationship, &dateOfBirth, &dep.IDNumber,
+		&dep.TaxCode, &dep.TaxDeductible, &effectiveFrom, &effectiveTo, &dep.Notes, &dep.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	dep.EffectiveFrom = effectiveFrom.Format("2006-01-02")
+	if dateOfBirth.Valid {
+		dep.DateOfBirth = dateOfBirth.Time.Format("2006-01-02")
+	}
+	if effectiveTo.Valid {
+		dep.EffectiveTo = effectiveTo.Time.Format("2006-01-02")
+	}
+
+	// Counted for the month if the window overlaps it at all, as payroll does
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := monthStart.AddDate(0, 1, -1)
+	dep.Active = dep.TaxDeductible && !effectiveFrom.After(monthEnd) &&
+		(!effectiveTo.Valid || !effectiveTo.Time.Before(monthStart))
+	return &dep, nil
+}
@@ -0,0 +1,316 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/security"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var errUploadTooLarge = errors.New("upload too large")
+
+var avatarTypes = map[string]string{"image/jpeg": ".jpg", "image/png": ".png", "image/webp": ".webp"}
+
+// fileURL turns a storage key into a signed download link. Values that are
+// already URLs (avatars set before file storage existed) are returned as is.
+func (h *EmployeeHandler) fileURL(ctx context.Context, key string) string {
+	if key == "" || strings.HasPrefix(key, "http://") || strings.HasPrefix(key, "https://") {
+		return key
+	}
+	url, err := h.store.SignedURL(ctx, key, h.cfg.Storage.SignedURLExpiry)
+	if err != nil {
+		h.log.WithError(err).WithField("key", key).Error("Failed to sign file URL")
+		return ""
+	}
+	return url
+}
+
+// readUpload reads the "file" field of a multipart form, up to the configured
+// size. The content type is sniffed from the data rather than trusted.
+func (h *EmployeeHandler) readUpload(c *gin.Context) (*multipart.FileHeader, []byte, string, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.cfg.Storage.MaxUploadSize+1<<20)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, nil, "", errUploadTooLarge
+		}
+		return nil, nil, "", err
+	}
+	if header.Size > h.cfg.Storage.MaxUploadSize {
+		return nil, nil, "", errUploadTooLarge
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return header, data, http.DetectContentType(data), nil
+}
+
+func (h *EmployeeHandler) respondUploadError(c *gin.Context, err error) {
+	if errors.Is(err, errUploadTooLarge) {
+		response.BadRequest(c, "file.too_large", map[string]string{"max_size": fmt.Sprintf("%dMB", h.cfg.Storage.MaxUploadSize>>20)})
+		return
+	}
+	response.BadRequest(c, "file.required", nil)
+}
+
+// employeeOwner returns the user id of an employee, or sql.ErrNoRows
+func (h *EmployeeHandler) employeeOwner(ctx context.Context, employeeID string) (string, error) {
+	if _, err := uuid.Parse(employeeID); err != nil {
+		return "", sql.ErrNoRows
+	}
+	var userID string
+	err := h.db.QueryRowContext(ctx, `SELECT user_id FROM employees WHERE id = $1 AND deleted_at IS NULL`, employeeID).Scan(&userID)
+	return userID, err
+}
+
+// UploadAvatar replaces an employee's avatar. Employees may change their own;
+// changing someone else's needs employees.update.
+func (h *EmployeeHandler) UploadAvatar(c *gin.Context) {
+	id := c.Param("id")
+	ctx := c.Request.Context()
+	currentUserID := middleware.GetUserID(c)
+
+	ownerID, err := h.employeeOwner(ctx, id)
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "employee.not_found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if ownerID != currentUserID && !security.HasPermission(middleware.GetPermissions(c), "employees.update") {
+		response.Forbidden(c, "permission.denied")
+		return
+	}
+
+	_, data, contentType, err := h.readUpload(c)
+	if err != nil {
+		h.respondUploadError(c, err)
+		return
+	}
+	ext, ok := avatarTypes[contentType]
+	if !ok {
+		response.BadRequest(c, "file.invalid_type", map[string]string{"allowed": "jpeg,png,webp"})
+		return
+	}
+
+	key := path.Join("avatars", id, uuid.New().String()+ext)
+	if err := h.store.Put(ctx, key, bytes.NewReader(data), contentType); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	var oldKey sql.NullString
+	err = h.db.QueryRowContext(ctx, `
+		UPDATE employees e SET avatar = $1, updated_at = NOW()
+		FROM (SELECT avatar FROM employees WHERE id = $2) old
+		WHERE e.id = $2
+		RETURNING old.avatar
+	`, key, id).Scan(&oldKey)
+	if err != nil {
+		h.store.Delete(ctx, key)
+		response.InternalError(c, err)
+		return
+	}
+	if oldKey.Valid && oldKey.String != "" && !strings.Contains(oldKey.String, "://") {
+		if err := h.store.Delete(ctx, oldKey.String); err != nil {
+			h.log.WithError(err).WithField("key", oldKey.String).Warn("Failed to delete previous avatar")
+		}
+	}
+
+	h.cache.Delete(ctx, "employee:"+id)
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: currentUserID, Action: "update_avatar", TableName: "employees", RecordID: id,
+		OldValues: gin.H{"avatar": oldKey.String}, NewValues: gin.H{"avatar": key},
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "employee.avatar_updated", gin.H{"avatar": h.fileURL(ctx, key)})
+}
+
+// ListDocuments lists an employee's documents
+func (h *EmployeeHandler) ListDocuments(c *gin.Context) {
+	id := c.Param("id")
+	ctx := c.Request.Context()
+
+	if _, err := h.employeeOwner(ctx, id); err == sql.ErrNoRows {
+		response.NotFound(c, "employee.not_found")
+		return
+	} else if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT id, employee_id, name, COALESCE(category, ''), content_type, size, uploaded_by, created_at
+		FROM employee_documents
+		WHERE employee_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at DESC
+	`, id)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	documents := []dto.EmployeeDocumentResponse{}
+	for rows.Next() {
+		var doc dto.EmployeeDocumentResponse
+		var uploadedBy uuid.NullUUID
+		if err := rows.Scan(&doc.ID, &doc.EmployeeID, &doc.Name, &doc.Category, &doc.ContentType, &doc.Size, &uploadedBy, &doc.CreatedAt); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		if uploadedBy.Valid {
+			doc.UploadedBy = &uploadedBy.UUID
+		}
+		documents = append(documents, doc)
+	}
+
+	response.OK(c, "common.list", documents)
+}
+
+// UploadDocument stores a document for an employee. The form takes the file,
+// an optional display name (defaults to the file name) and a category.
+func (h *EmployeeHandler) UploadDocument(c *gin.Context) {
+	id := c.Param("id")
+	ctx := c.Request.Context()
+
+	if _, err := h.employeeOwner(ctx, id); err == sql.ErrNoRows {
+		response.NotFound(c, "employee.not_found")
+		return
+	} else if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	header, data, contentType, err := h.readUpload(c)
+	if err != nil {
+		h.respondUploadError(c, err)
+		return
+	}
+
+	name := strings.TrimSpace(c.PostForm("name"))
+	if name == "" {
+		name = path.Base(header.Filename)
+	}
+	category := strings.TrimSpace(c.PostForm("category"))
+
+	documentID := uuid.New()
+	key := path.Join("documents", id, documentID.String()+strings.ToLower(path.Ext(header.Filename)))
+	if err := h.store.Put(ctx, key, bytes.NewReader(data), contentType); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	currentUserID := middleware.GetUserID(c)
+	doc := dto.EmployeeDocumentResponse{
+		ID: documentID, Name: name, Category: category, ContentType: contentType,
+		Size: int64(len(data)), CreatedAt: time.Now(),
+	}
+	doc.EmployeeID, _ = uuid.Parse(id)
+	if uploader, err := uuid.Parse(currentUserID); err == nil {
+		doc.UploadedBy = &uploader
+	}
+
+	_, err = h.db.ExecContext(ctx, `
+		INSERT INTO employee_documents (id, employee_id, name, category, storage_key, content_type, size, uploaded_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, documentID, id, name, nullIfEmpty(category), key, contentType, doc.Size, doc.UploadedBy, doc.CreatedAt)
+	if err != nil {
+		h.store.Delete(ctx, key)
+		response.InternalError(c, err)
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: currentUserID, Action: "create", TableName: "employee_documents", RecordID: documentID.String(),
+		NewValues: doc, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.Created(c, "document.uploaded", doc)
+}
+
+// DownloadDocument returns a short-lived signed link to the document
+func (h *EmployeeHandler) DownloadDocument(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var key string
+	err := h.db.QueryRowContext(ctx, `
+		SELECT storage_key FROM employee_documents
+		WHERE id::text = $1 AND employee_id::text = $2 AND deleted_at IS NULL
+	`, c.Param("document_id"), c.Param("id")).Scan(&key)
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "document.not_found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	expiry := h.cfg.Storage.SignedURLExpiry
+	url, err := h.store.SignedURL(ctx, key, expiry)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	response.OK(c, "common.success", dto.FileDownloadResponse{URL: url, ExpiresAt: time.Now().Add(expiry)})
+}
+
+// DeleteDocument removes a document and its file
+func (h *EmployeeHandler) DeleteDocument(c *gin.Context) {
+	ctx := c.Request.Context()
+	documentID := c.Param("document_id")
+
+	var key string
+	err := h.db.QueryRowContext(ctx, `
+		UPDATE employee_documents SET deleted_at = NOW()
+		WHERE id::text = $1 AND employee_id::text = $2 AND deleted_at IS NULL
+		RETURNING storage_key
+	`, documentID, c.Param("id")).Scan(&key)
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "document.not_found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	if err := h.store.Delete(ctx, key); err != nil {
+		h.log.WithError(err).WithField("key", key).Warn("Failed to delete document file")
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: middleware.GetUserID(c), Action: "delete", TableName: "employee_documents", RecordID: documentID,
+		OldValues: gin.H{"storage_key": key}, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "document.deleted", nil)
+}
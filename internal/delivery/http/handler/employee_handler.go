@@ -2,7 +2,10 @@ package handler
 
 import (
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 	"context"
@@ -10,28 +13,34 @@ import (
 	"hr-management-system/internal/delivery/http/dto"
 	"hr-management-system/internal/delivery/http/middleware"
 	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/domain/entity"
 	"hr-management-system/internal/infrastructure/cache"
 	"hr-management-system/internal/infrastructure/database"
 	"hr-management-system/internal/infrastructure/logger"
 	"hr-management-system/internal/infrastructure/queue"
 	"hr-management-system/internal/infrastructure/search"
+	"hr-management-system/internal/infrastructure/storage"
+	"hr-management-system/internal/payroll"
 	"hr-management-system/internal/security"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type EmployeeHandler struct {
-	db    *database.Database
-	cache *cache.RedisCache
-	queue *queue.Queue
-	es    *search.ElasticSearch
-	log   *logger.Logger
-	cfg   *config.Config
+	db      *database.Database
+	cache   *cache.RedisCache
+	queue   *queue.Queue
+	es      *search.ElasticSearch
+	store   storage.Backend
+	payroll *payroll.Service
+	log     *logger.Logger
+	cfg     *config.Config
 }
 
-func NewEmployeeHandler(db *database.Database, cache *cache.RedisCache, queue *queue.Queue, es *search.ElasticSearch, log *logger.Logger, cfg *config.Config) *EmployeeHandler {
-	return &EmployeeHandler{db: db, cache: cache, queue: queue, es: es, log: log, cfg: cfg}
+func NewEmployeeHandler(db *database.Database, cache *cache.RedisCache, queue *queue.Queue, es *search.ElasticSearch, store storage.Backend, log *logger.Logger, cfg *config.Config) *EmployeeHandler {
+	return &EmployeeHandler{db: db, cache: cache, queue: queue, es: es, store: store, payroll: payroll.NewService(db), log: log, cfg: cfg}
 }
 
 func (h *EmployeeHandler) List(c *gin.Context) {
@@ -41,42 +50,39 @@ func (h *EmployeeHandler) List(c *gin.Context) {
 		return
 	}
 
+	fields, unknown := response.ParseFields(c, dto.EmployeeResponse{})
+	if len(unknown) > 0 {
+		response.BadRequest(c, "common.unknown_fields", map[string]string{"fields": strings.Join(unknown, ",")})
+		return
+	}
+
 	ctx := c.Request.Context()
-	pagination := database.NewPagination(filter.Page, filter.PageSize)
+	permissions := middleware.GetPermissions(c)
+	pagination := database.NewPagination(filter.Page, filter.PageSize, permissions, "employees.export", &h.cfg.Database)
+	pagination.SetCountMode(c.Query("count"), h.cfg.Database.CountModeFor("employees"))
+
+	scope, err := resolveEmployeeScope(ctx, h.db, c)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
 
 	baseQuery := `
 		SELECT e.id, e.user_id, e.employee_code, e.first_name, e.last_name, e.full_name,
-		       e.gender, e.date_of_birth, e.id_number, e.department_id, d.name,
-		       e.position_id, p.name, e.manager_id, COALESCE(m.full_name, ''),
-		       e.employment_type, e.employment_status, e.join_date, e.base_salary,
-		       e.avatar, e.created_at, e.updated_at, u.email, u.phone
+		       e.gender, e.date_of_birth, e.id_number, e.department_id, COALESCE(d.name, ''),
+		       e.position_id, COALESCE(p.name, ''), e.manager_id, COALESCE(m.full_name, ''),
+		       e.employment_type, e.employment_status, e.join_date, e.base_salary, e.salary_currency,
+		       e.work_mode, e.avatar, e.created_at, e.updated_at, u.email, u.phone
 		FROM employees e
 		INNER JOIN users u ON u.id = e.user_id
-		INNER JOIN departments d ON d.id = e.department_id
-		INNER JOIN positions p ON p.id = e.position_id
+		LEFT JOIN departments d ON d.id = e.department_id
+		LEFT JOIN positions p ON p.id = e.position_id
 		LEFT JOIN employees m ON m.id = e.manager_id
 		WHERE e.deleted_at IS NULL`
 
 	countQuery := `SELECT COUNT(*) FROM employees e WHERE e.deleted_at IS NULL`
-	var conditions []string
-	var args []interface{}
-	argIdx := 1
-
-	if filter.Search != "" {
-		conditions = append(conditions, fmt.Sprintf("(e.full_name ILIKE $%d OR e.employee_code ILIKE $%d)", argIdx, argIdx))
-		args = append(args, "%"+filter.Search+"%")
-		argIdx++
-	}
-	if filter.DepartmentID != "" {
-		conditions = append(conditions, fmt.Sprintf("e.department_id = $%d", argIdx))
-		args = append(args, filter.DepartmentID)
-		argIdx++
-	}
-	if filter.EmploymentStatus != "" {
-		conditions = append(conditions, fmt.Sprintf("e.employment_status = $%d", argIdx))
-		args = append(args, filter.EmploymentStatus)
-		argIdx++
-	}
+	conditions, args := employeeFilterConditions(&filter, scope)
+	argIdx := len(args) + 1
 
 	if len(conditions) > 0 {
 		whereClause := " AND " + strings.Join(conditions, " AND ")
@@ -84,12 +90,13 @@ func (h *EmployeeHandler) List(c *gin.Context) {
 		countQuery += whereClause
 	}
 
-	var total int
-	h.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
-	pagination.SetTotal(total)
+	if err := h.db.Count(ctx, pagination, "employees", countQuery, args...); err != nil {
+		response.InternalError(c, err)
+		return
+	}
 
 	baseQuery += fmt.Sprintf(" ORDER BY e.created_at DESC LIMIT $%d OFFSET $%d", argIdx, argIdx+1)
-	args = append(args, pagination.GetLimit(), pagination.GetOffset())
+	args = append(args, pagination.FetchLimit(), pagination.GetOffset())
 
 	rows, err := h.db.QueryContext(ctx, baseQuery, args...)
 	if err != nil {
@@ -106,52 +113,102 @@ func (h *EmployeeHandler) List(c *gin.Context) {
 			&emp.FullName, &emp.Gender, &emp.DateOfBirth, &emp.IDNumber,
 			&emp.DepartmentID, &emp.DepartmentName, &emp.PositionID, &emp.PositionName,
 			&managerID, &emp.ManagerName, &emp.EmploymentType, &emp.EmploymentStatus,
-			&emp.JoinDate, &emp.BaseSalary, &avatar, &emp.CreatedAt, &emp.UpdatedAt,
+			&emp.JoinDate, &emp.BaseSalary, &emp.SalaryCurrency, &emp.WorkMode, &avatar, &emp.CreatedAt, &emp.UpdatedAt,
 			&emp.Email, &emp.Phone)
 		if managerID.Valid {
 			id, _ := uuid.Parse(managerID.String)
 			emp.ManagerID = &id
 		}
 		if avatar.Valid {
-			emp.Avatar = avatar.String
+			emp.Avatar = h.fileURL(ctx, avatar.String)
 		}
+		maskEmployee(&emp, scope, permissions)
 		employees = append(employees, emp)
 	}
+	employees = database.TrimPage(pagination, employees)
+
+	response.OKWithMeta(c, "common.list", response.SelectFields(employees, fields), pagination)
+}
 
-	response.OKWithMeta(c, "common.list", employees, pagination)
+// employeeFilterConditions turns a list filter and the caller's data scope
+// into conditions on employees e, numbering placeholders from $1
+func employeeFilterConditions(filter *dto.EmployeeFilter, scope *employeeScope) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	argIdx := 1
+
+	if filter.Search != "" {
+		conditions = append(conditions, fmt.Sprintf("(e.full_name ILIKE $%d OR e.employee_code ILIKE $%d)", argIdx, argIdx))
+		args = append(args, "%"+filter.Search+"%")
+		argIdx++
+	}
+	if filter.DepartmentID != "" {
+		conditions = append(conditions, fmt.Sprintf("e.department_id = $%d", argIdx))
+		args = append(args, filter.DepartmentID)
+		argIdx++
+	}
+	if filter.EmploymentStatus != "" {
+		conditions = append(conditions, fmt.Sprintf("e.employment_status = $%d", argIdx))
+		args = append(args, filter.EmploymentStatus)
+		argIdx++
+	}
+	if !scope.All {
+		conditions = append(conditions, fmt.Sprintf("(e.department_id = ANY($%d) OR e.manager_id::text = $%d)", argIdx, argIdx+1))
+		args = append(args, pq.Array(scope.DepartmentIDs), scope.ReportsOf)
+	}
+	return conditions, args
 }
 
 func (h *EmployeeHandler) Get(c *gin.Context) {
 	id := c.Param("id")
 	ctx := c.Request.Context()
 
+	fields, unknown := response.ParseFields(c, dto.EmployeeResponse{})
+	if len(unknown) > 0 {
+		response.BadRequest(c, "common.unknown_fields", map[string]string{"fields": strings.Join(unknown, ",")})
+		return
+	}
+
+	permissions := middleware.GetPermissions(c)
+	scope, err := resolveEmployeeScope(ctx, h.db, c)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
 	cacheKey := "employee:" + id
 	var emp dto.EmployeeResponse
 	if err := h.cache.Get(ctx, cacheKey, &emp); err == nil {
-		response.OK(c, "common.success", emp)
+		if !scope.AllowsEmployee(&emp) {
+			response.NotFound(c, "employee.not_found")
+			return
+		}
+		maskEmployee(&emp, scope, permissions)
+		emp.Avatar = h.fileURL(ctx, emp.Avatar)
+		response.OK(c, "common.success", response.SelectFields(emp, fields))
 		return
 	}
 
 	query := `
 		SELECT e.id, e.user_id, e.employee_code, e.first_name, e.last_name, e.full_name,
-		       e.gender, e.date_of_birth, e.id_number, e.department_id, d.name,
-		       e.position_id, p.name, e.manager_id, COALESCE(m.full_name, ''),
-		       e.employment_type, e.employment_status, e.join_date, e.base_salary,
-		       e.avatar, e.created_at, e.updated_at, u.email, u.phone
+		       e.gender, e.date_of_birth, e.id_number, e.department_id, COALESCE(d.name, ''),
+		       e.position_id, COALESCE(p.name, ''), e.manager_id, COALESCE(m.full_name, ''),
+		       e.employment_type, e.employment_status, e.join_date, e.base_salary, e.salary_currency,
+		       e.work_mode, e.avatar, e.created_at, e.updated_at, u.email, u.phone
 		FROM employees e
 		INNER JOIN users u ON u.id = e.user_id
-		INNER JOIN departments d ON d.id = e.department_id
-		INNER JOIN positions p ON p.id = e.position_id
+		LEFT JOIN departments d ON d.id = e.department_id
+		LEFT JOIN positions p ON p.id = e.position_id
 		LEFT JOIN employees m ON m.id = e.manager_id
 		WHERE e.id = $1 AND e.deleted_at IS NULL`
 
 	var managerID, avatar sql.NullString
-	err := h.db.QueryRowContext(ctx, query, id).Scan(
+	err = h.db.QueryRowContext(ctx, query, id).Scan(
 		&emp.ID, &emp.UserID, &emp.EmployeeCode, &emp.FirstName, &emp.LastName,
 		&emp.FullName, &emp.Gender, &emp.DateOfBirth, &emp.IDNumber,
 		&emp.DepartmentID, &emp.DepartmentName, &emp.PositionID, &emp.PositionName,
 		&managerID, &emp.ManagerName, &emp.EmploymentType, &emp.EmploymentStatus,
-		&emp.JoinDate, &emp.BaseSalary, &avatar, &emp.CreatedAt, &emp.UpdatedAt,
+		&emp.JoinDate, &emp.BaseSalary, &emp.SalaryCurrency, &emp.WorkMode, &avatar, &emp.CreatedAt, &emp.UpdatedAt,
 		&emp.Email, &emp.Phone)
 
 	if err == sql.ErrNoRows {
@@ -171,8 +228,98 @@ func (h *EmployeeHandler) Get(c *gin.Context) {
 		emp.Avatar = avatar.String
 	}
 
+	// The cache keeps the storage key and the unmasked fields; links are
+	// signed and fields masked per response
 	h.cache.Set(ctx, cacheKey, emp, 15*time.Minute)
-	response.OK(c, "common.success", emp)
+	// Out of scope reads as missing, so IDs cannot be probed
+	if !scope.AllowsEmployee(&emp) {
+		response.NotFound(c, "employee.not_found")
+		return
+	}
+	maskEmployee(&emp, scope, permissions)
+	emp.Avatar = h.fileURL(ctx, emp.Avatar)
+	response.OK(c, "common.success", response.SelectFields(emp, fields))
+}
+
+// Batch returns the employees for a list of ids in one query, in request order.
+// Unknown, deleted and out of scope ids are left out, and rows are masked as
+// List masks them.
+func (h *EmployeeHandler) Batch(c *gin.Context) {
+	var req dto.BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	fields, unknown := response.ParseFields(c, dto.EmployeeResponse{})
+	if len(unknown) > 0 {
+		response.BadRequest(c, "common.unknown_fields", map[string]string{"fields": strings.Join(unknown, ",")})
+		return
+	}
+
+	ctx := c.Request.Context()
+	permissions := middleware.GetPermissions(c)
+	scope, err := resolveEmployeeScope(ctx, h.db, c)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	conditions, args := employeeFilterConditions(&dto.EmployeeFilter{}, scope)
+	conditions = append(conditions, fmt.Sprintf("e.id = ANY($%d::uuid[])", len(args)+1))
+	args = append(args, pq.Array(req.IDs))
+
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT e.id, e.user_id, e.employee_code, e.first_name, e.last_name, e.full_name,
+		       e.gender, e.date_of_birth, e.id_number, e.department_id, COALESCE(d.name, ''),
+		       e.position_id, COALESCE(p.name, ''), e.manager_id, COALESCE(m.full_name, ''),
+		       e.employment_type, e.employment_status, e.join_date, e.base_salary, e.salary_currency,
+		       e.work_mode, e.avatar, e.created_at, e.updated_at, u.email, u.phone
+		FROM employees e
+		INNER JOIN users u ON u.id = e.user_id
+		LEFT JOIN departments d ON d.id = e.department_id
+		LEFT JOIN positions p ON p.id = e.position_id
+		LEFT JOIN employees m ON m.id = e.manager_id
+		WHERE e.deleted_at IS NULL AND `+strings.Join(conditions, " AND "), args...)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	byID := make(map[string]dto.EmployeeResponse, len(req.IDs))
+	for rows.Next() {
+		var emp dto.EmployeeResponse
+		var managerID, avatar sql.NullString
+		if err := rows.Scan(&emp.ID, &emp.UserID, &emp.EmployeeCode, &emp.FirstName, &emp.LastName,
+			&emp.FullName, &emp.Gender, &emp.DateOfBirth, &emp.IDNumber,
+			&emp.DepartmentID, &emp.DepartmentName, &emp.PositionID, &emp.PositionName,
+			&managerID, &emp.ManagerName, &emp.EmploymentType, &emp.EmploymentStatus,
+			&emp.JoinDate, &emp.BaseSalary, &emp.SalaryCurrency, &emp.WorkMode, &avatar, &emp.CreatedAt, &emp.UpdatedAt,
+			&emp.Email, &emp.Phone); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		if managerID.Valid {
+			id, _ := uuid.Parse(managerID.String)
+			emp.ManagerID = &id
+		}
+		if avatar.Valid {
+			emp.Avatar = h.fileURL(ctx, avatar.String)
+		}
+		maskEmployee(&emp, scope, permissions)
+		byID[emp.ID.String()] = emp
+	}
+
+	employees := make([]dto.EmployeeResponse, 0, len(byID))
+	for _, id := range uniqueStrings(req.IDs) {
+		if emp, ok := byID[strings.ToLower(id)]; ok {
+			employees = append(employees, emp)
+			delete(byID, emp.ID.String())
+		}
+	}
+
+	response.OK(c, "common.list", response.SelectFields(employees, fields))
 }
 
 func (h *EmployeeHandler) Create(c *gin.Context) {
@@ -192,11 +339,69 @@ func (h *EmployeeHandler) Create(c *gin.Context) {
 		return
 	}
 
-	employeeCode, err := h.generateEmployeeCode(ctx)
+	if req.SalaryCurrency == "" {
+		req.SalaryCurrency = h.cfg.Payroll.DefaultCurrency()
+	}
+	if !h.cfg.Payroll.SupportsCurrency(req.SalaryCurrency) {
+		response.UnprocessableEntity(c, "employee.unsupported_currency", map[string]string{"currency": req.SalaryCurrency})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer tx.Rollback()
+
+	employeeCode, err := h.nextEmployeeCode(ctx, tx)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	created, err := h.createEmployee(ctx, tx, &req, employeeCode, currentUserID)
 	if err != nil {
 		response.InternalError(c, err)
 		return
 	}
+
+	if err := tx.Commit(); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.indexCreatedEmployee(ctx, created, &req)
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: currentUserID, Action: "create", TableName: "employees", RecordID: created.ID.String(),
+		NewValues: req, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+	h.auditRoleAssignment(c, created.UserID)
+
+	welcomeSent := h.sendWelcome(ctx, created, &req)
+
+	data := gin.H{"id": created.ID, "employee_code": employeeCode, "welcome_email_sent": welcomeSent}
+	// HR delivers the credentials itself when no email went out
+	if !welcomeSent && security.HasPermission(middleware.GetPermissions(c), "employees.create") {
+		data["temp_password"] = created.TempPassword
+	}
+
+	response.Created(c, "employee.created", data)
+}
+
+// createdEmployee is what createEmployee stored for a new employee
+type createdEmployee struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	Code         string
+	FullName     string
+	TempPassword string
+}
+
+// createEmployee inserts the user account, the employee and their roles (or
+// the default role) in tx. The request must already be validated.
+func (h *EmployeeHandler) createEmployee(ctx context.Context, tx *sql.Tx, req *dto.CreateEmployeeRequest, employeeCode, createdBy string) (createdEmployee, error) {
 	dateOfBirth, _ := time.Parse("2006-01-02", req.DateOfBirth)
 	joinDate, _ := time.Parse("2006-01-02", req.JoinDate)
 	idIssuedDate, _ := time.Parse("2006-01-02", req.IDIssuedDate)
@@ -204,21 +409,18 @@ func (h *EmployeeHandler) Create(c *gin.Context) {
 	tempPassword, _ := security.GenerateSecureToken(8)
 	hashedPassword, _ := security.HashPassword(tempPassword)
 
-	tx, err := h.db.Begin()
-	if err != nil {
-		response.InternalError(c, err)
-		return
+	if req.PreferredLanguage == "" {
+		req.PreferredLanguage = "vi"
 	}
-	defer tx.Rollback()
-
-	userID := uuid.New()
-	tx.ExecContext(ctx, `
+	created := createdEmployee{ID: uuid.New(), UserID: uuid.New(), Code: employeeCode, TempPassword: tempPassword}
+	if _, err := tx.ExecContext(ctx, `
 		INSERT INTO users (id, email, phone, password, status, preferred_language, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, 'active', 'vi', NOW(), NOW())`,
-		userID, req.Email, req.Phone, hashedPassword)
+		VALUES ($1, $2, $3, $4, 'active', $5, NOW(), NOW())`,
+		created.UserID, req.Email, req.Phone, hashedPassword, req.PreferredLanguage); err != nil {
+		return created, err
+	}
 
-	employeeID := uuid.New()
-	fullName := req.FirstName + " " + req.LastName
+	created.FullName = h.cfg.Employee.FormatFullName(req.FirstName, req.LastName, req.PreferredLanguage)
 	deptID, _ := uuid.Parse(req.DepartmentID)
 	posID, _ := uuid.Parse(req.PositionID)
 
@@ -228,40 +430,63 @@ func (h *EmployeeHandler) Create(c *gin.Context) {
 		managerID = &mid
 	}
 
-	tx.ExecContext(ctx, `
+	if _, err := tx.ExecContext(ctx, `
 		INSERT INTO employees (id, user_id, employee_code, first_name, last_name, full_name, gender,
 			date_of_birth, place_of_birth, nationality, marital_status, id_number, id_issued_date,
 			id_issued_place, department_id, position_id, manager_id, employment_type, employment_status,
-			join_date, base_salary, salary_grade, created_at, updated_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,'active',$19,$20,$21,NOW(),NOW())`,
-		employeeID, userID, employeeCode, req.FirstName, req.LastName, fullName, req.Gender,
+			join_date, base_salary, salary_currency, salary_grade, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,'active',$19,$20,$21,$22,NOW(),NOW())`,
+		created.ID, created.UserID, employeeCode, req.FirstName, req.LastName, created.FullName, req.Gender,
 		dateOfBirth, req.PlaceOfBirth, req.Nationality, req.MaritalStatus, req.IDNumber, idIssuedDate,
-		req.IDIssuedPlace, deptID, posID, managerID, req.EmploymentType, joinDate, req.BaseSalary, req.SalaryGrade)
+		req.IDIssuedPlace, deptID, posID, managerID, req.EmploymentType, joinDate, req.BaseSalary, req.SalaryCurrency, req.SalaryGrade); err != nil {
+		return created, err
+	}
 
 	for _, roleID := range req.RoleIDs {
 		rid, _ := uuid.Parse(roleID)
-		tx.ExecContext(ctx, `INSERT INTO user_roles (user_id, role_id, created_at, created_by) VALUES ($1, $2, NOW(), $3)`,
-			userID, rid, currentUserID)
+		if _, err := tx.ExecContext(ctx, `INSERT INTO user_roles (user_id, role_id, created_at, created_by) VALUES ($1, $2, NOW(), $3)`,
+			created.UserID, rid, createdBy); err != nil {
+			return created, err
+		}
 	}
-
-	if err := tx.Commit(); err != nil {
-		response.InternalError(c, err)
-		return
+	if len(req.RoleIDs) == 0 {
+		if err := assignDefaultRole(ctx, tx, h.log, h.cfg.Employee, created.UserID, req.EmploymentType, createdBy); err != nil {
+			return created, err
+		}
 	}
+	return created, nil
+}
 
+// indexCreatedEmployee queues the search document of a new employee
+func (h *EmployeeHandler) indexCreatedEmployee(ctx context.Context, created createdEmployee, req *dto.CreateEmployeeRequest) {
 	h.queue.IndexDocument(ctx, queue.ElasticPayload{
-		Index: "employees", DocumentID: employeeID.String(),
-		Document: map[string]interface{}{"id": employeeID.String(), "employee_code": employeeCode, "full_name": fullName,
-			"email": req.Email, "department_id": req.DepartmentID, "employment_status": "active", "created_at": time.Now()},
-		Action: "index",
+		Index: "employees", DocumentID: created.ID.String(),
+		Document: createdEmployeeDocument(created, req),
+		Action:   "index",
 	})
+}
 
-	h.queue.LogAudit(ctx, queue.AuditLogPayload{
-		UserID: currentUserID, Action: "create", TableName: "employees", RecordID: employeeID.String(),
-		NewValues: req, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
-	})
+// createdEmployeeDocument is the search document of a new employee
+func createdEmployeeDocument(created createdEmployee, req *dto.CreateEmployeeRequest) map[string]interface{} {
+	return map[string]interface{}{"id": created.ID.String(), "employee_code": created.Code, "full_name": created.FullName,
+		"email": req.Email, "department_id": req.DepartmentID, "employment_status": "active", "created_at": time.Now()}
+}
 
-	response.Created(c, "employee.created", gin.H{"id": employeeID, "employee_code": employeeCode, "temp_password": tempPassword})
+// sendWelcome queues the welcome email with the temporary password when
+// enabled and not opted out of, and reports whether it was queued
+func (h *EmployeeHandler) sendWelcome(ctx context.Context, created createdEmployee, req *dto.CreateEmployeeRequest) bool {
+	if !h.cfg.Employee.AutoWelcomeEmail || (req.SendWelcome != nil && !*req.SendWelcome) {
+		return false
+	}
+	_, err := h.queue.SendWelcomeEmail(ctx, queue.WelcomeEmailPayload{
+		Email: req.Email, Name: created.FullName, TempPassword: created.TempPassword,
+		LoginURL: h.cfg.App.FrontendURL + "/login",
+	})
+	if err != nil {
+		h.log.WithModule("employee").WithError(err).WithField("employee_id", created.ID).Warn("Failed to queue welcome email")
+		return false
+	}
+	return true
 }
 
 func (h *EmployeeHandler) Update(c *gin.Context) {
@@ -275,13 +500,28 @@ func (h *EmployeeHandler) Update(c *gin.Context) {
 	ctx := c.Request.Context()
 	currentUserID := middleware.GetUserID(c)
 
-	var exists bool
-	h.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM employees WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists)
-	if !exists {
+	var currentStatus, firstName, lastName, language, fullName string
+	err := h.db.QueryRowContext(ctx, `
+		SELECT e.employment_status, e.first_name, e.last_name, COALESCE(u.preferred_language, 'vi'), e.full_name
+		FROM employees e
+		INNER JOIN users u ON u.id = e.user_id
+		WHERE e.id = $1 AND e.deleted_at IS NULL
+	`, id).Scan(&currentStatus, &firstName, &lastName, &language, &fullName)
+	if err != nil {
 		response.NotFound(c, "employee.not_found")
 		return
 	}
 
+	if req.EmploymentStatus != nil && !h.cfg.Employee.CanTransitionStatus(currentStatus, *req.EmploymentStatus) {
+		response.UnprocessableEntity(c, "employee.invalid_status_transition", map[string]string{"from": currentStatus, "to": *req.EmploymentStatus})
+		return
+	}
+
+	if req.SalaryCurrency != nil && !h.cfg.Payroll.SupportsCurrency(*req.SalaryCurrency) {
+		response.UnprocessableEntity(c, "employee.unsupported_currency", map[string]string{"currency": *req.SalaryCurrency})
+		return
+	}
+
 	updates := []string{"updated_at = NOW()"}
 	args := []interface{}{}
 	argIdx := 1
@@ -296,6 +536,22 @@ func (h *EmployeeHandler) Update(c *gin.Context) {
 		args = append(args, *req.LastName)
 		argIdx++
 	}
+	// full_name is derived, so it follows either part of the name
+	nameChanged := false
+	if req.FirstName != nil || req.LastName != nil {
+		if req.FirstName != nil {
+			firstName = *req.FirstName
+		}
+		if req.LastName != nil {
+			lastName = *req.LastName
+		}
+		if name := h.cfg.Employee.FormatFullName(firstName, lastName, language); name != fullName {
+			fullName, nameChanged = name, true
+			updates = append(updates, fmt.Sprintf("full_name = $%d", argIdx))
+			args = append(args, fullName)
+			argIdx++
+		}
+	}
 	if req.DepartmentID != nil {
 		updates = append(updates, fmt.Sprintf("department_id = $%d", argIdx))
 		args = append(args, *req.DepartmentID)
@@ -311,17 +567,48 @@ func (h *EmployeeHandler) Update(c *gin.Context) {
 		args = append(args, *req.BaseSalary)
 		argIdx++
 	}
+	if req.SalaryCurrency != nil {
+		updates = append(updates, fmt.Sprintf("salary_currency = $%d", argIdx))
+		args = append(args, *req.SalaryCurrency)
+		argIdx++
+	}
 	if req.EmploymentStatus != nil {
 		updates = append(updates, fmt.Sprintf("employment_status = $%d", argIdx))
 		args = append(args, *req.EmploymentStatus)
 		argIdx++
 	}
+	if req.WorkMode != nil {
+		updates = append(updates, fmt.Sprintf("work_mode = $%d", argIdx))
+		args = append(args, *req.WorkMode)
+		argIdx++
+	}
 
 	args = append(args, id)
 	query := fmt.Sprintf(`UPDATE employees SET %s WHERE id = $%d`, strings.Join(updates, ", "), argIdx)
-	h.db.ExecContext(ctx, query, args...)
+	salaryChanged := req.BaseSalary != nil || req.SalaryCurrency != nil
+	err = h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		if salaryChanged {
+			if err := recordSalaryBaseline(ctx, tx, id); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+		if salaryChanged {
+			return recordSalaryChange(ctx, tx, id, req.SalaryEffectiveFrom, currentUserID)
+		}
+		return nil
+	})
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
 
 	h.cache.Delete(ctx, "employee:"+id)
+	if nameChanged || req.DepartmentID != nil || req.EmploymentStatus != nil {
+		h.reindexEmployee(ctx, id)
+	}
 
 	h.queue.LogAudit(ctx, queue.AuditLogPayload{
 		UserID: currentUserID, Action: "update", TableName: "employees", RecordID: id,
@@ -360,48 +647,445 @@ func (h *EmployeeHandler) Delete(c *gin.Context) {
 	response.OK(c, "employee.deleted", nil)
 }
 
+// Terminate ends an employment, deactivates the user account and records the
+// payout of unused encashable leave for the next payroll run
+func (h *EmployeeHandler) Terminate(c *gin.Context) {
+	id := c.Param("id")
+	var req dto.TerminateEmployeeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	lastDay, err := time.Parse("2006-01-02", req.LastWorkingDate)
+	if err != nil {
+		response.BadRequest(c, "validation.date_format", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	currentUserID := middleware.GetUserID(c)
+
+	employeeID, err := uuid.Parse(id)
+	if err != nil {
+		response.NotFound(c, "employee.not_found")
+		return
+	}
+
+	var currentStatus string
+	err = h.db.QueryRowContext(ctx, `SELECT employment_status FROM employees WHERE id = $1 AND deleted_at IS NULL`, employeeID).Scan(&currentStatus)
+	if err != nil {
+		response.NotFound(c, "employee.not_found")
+		return
+	}
+	if !h.cfg.Employee.CanTransitionStatus(currentStatus, req.Status) || currentStatus == req.Status {
+		response.UnprocessableEntity(c, "employee.invalid_status_transition", map[string]string{"from": currentStatus, "to": req.Status})
+		return
+	}
+
+	result, err := h.db.ExecContext(ctx, `
+		UPDATE employees SET employment_status = $1, resignation_date = $2, updated_at = NOW()
+		WHERE id = $3 AND deleted_at IS NULL AND employment_status = $4
+	`, req.Status, req.LastWorkingDate, employeeID, currentStatus)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		response.NotFound(c, "employee.not_found")
+		return
+	}
+
+	h.db.ExecContext(ctx, `UPDATE users SET status = 'inactive' WHERE id = (SELECT user_id FROM employees WHERE id = $1)`, employeeID)
+	h.cache.Delete(ctx, "employee:"+id)
+
+	encashment, err := h.payroll.CalculateLeaveEncashment(ctx, employeeID, lastDay.Year(), &lastDay)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if err := h.payroll.SaveLeaveEncashment(ctx, encashment); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: currentUserID, Action: "terminate", TableName: "employees", RecordID: id,
+		NewValues: gin.H{"request": req, "leave_encashment": encashment}, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "employee.terminated", gin.H{"id": employeeID, "employment_status": req.Status, "leave_encashment": encashment})
+}
+
+// ReviewProbation records the manual probation decision for an employee on probation
+func (h *EmployeeHandler) ReviewProbation(c *gin.Context) {
+	id := c.Param("id")
+	var req dto.ReviewProbationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	employeeID, err := uuid.Parse(id)
+	if err != nil {
+		response.NotFound(c, "employee.not_found")
+		return
+	}
+
+	ctx := c.Request.Context()
+	currentUserID := middleware.GetUserID(c)
+
+	status := map[string]string{"confirm": "confirmed", "flag": "flagged", "deny": "denied"}[req.Decision]
+	employmentType := string(entity.EmploymentTypeProbation)
+	if req.Decision == "confirm" {
+		employmentType = string(entity.EmploymentTypeFullTime)
+	}
+
+	var userID string
+	err = h.db.QueryRowContext(ctx, `
+		UPDATE employees SET employment_type = $1, probation_status = $2,
+		       probation_reviewed_by = $3, probation_reviewed_at = NOW(), updated_at = NOW()
+		WHERE id = $4 AND employment_type = 'probation' AND deleted_at IS NULL
+		RETURNING user_id
+	`, employmentType, status, currentUserID, employeeID).Scan(&userID)
+	if err == sql.ErrNoRows {
+		response.BadRequest(c, "employee.not_on_probation", nil)
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.cache.Delete(ctx, "employee:"+id)
+
+	if req.Decision == "confirm" {
+		h.queue.SendNotification(ctx, queue.NotificationPayload{
+			UserID:  userID,
+			Title:   "Chúc mừng bạn đã hoàn thành thử việc",
+			Message: "Bạn đã chính thức trở thành nhân viên toàn thời gian.",
+			Type:    "probation_converted",
+		})
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: currentUserID, Action: "probation_" + req.Decision, TableName: "employees", RecordID: id,
+		NewValues: gin.H{"request": req, "employment_type": employmentType, "probation_status": status},
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "employee.probation_reviewed", gin.H{
+		"id":               employeeID,
+		"employment_type":  employmentType,
+		"probation_status": status,
+	})
+}
+
+// Search finds employees by relevance in Elasticsearch, limited to the
+// caller's data scope. When Elasticsearch is unavailable the same scope is
+// searched in Postgres. The first pages are fetched by page number; past
+// ELASTIC_SEARCH_AFTER_THRESHOLD results the client follows next_cursor.
+// A cursor continues only in the backend that issued it, since the index and
+// the database may order names differently: a database cursor stays on the
+// database, and an Elasticsearch cursor the index can no longer serve is
+// rejected so the client restarts from the first page.
 func (h *EmployeeHandler) Search(c *gin.Context) {
 	query := c.Query("q")
 	page, size := 1, 20
 	fmt.Sscanf(c.DefaultQuery("page", "1"), "%d", &page)
 	fmt.Sscanf(c.DefaultQuery("size", "20"), "%d", &size)
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > h.cfg.Database.MaxPageSize {
+		size = 20
+	}
 
-	filters := make(map[string]interface{})
-	if dept := c.Query("department_id"); dept != "" {
-		filters["department_id"] = dept
+	var cursor *searchCursor
+	if raw := c.Query("cursor"); raw != "" {
+		var ok bool
+		if cursor, ok = decodeSearchCursor(raw); !ok {
+			response.BadRequest(c, "common.validation_error", map[string]string{"cursor": "invalid"})
+			return
+		}
+	} else if threshold := h.cfg.Elastic.SearchAfterThreshold; threshold > 0 && (page-1)*size >= threshold {
+		response.UnprocessableEntity(c, "search.page_too_deep", map[string]string{
+			"max_page": strconv.Itoa((threshold + size - 1) / size),
+		})
+		return
 	}
 
 	ctx := c.Request.Context()
-	result, err := h.es.SearchEmployees(ctx, query, filters, page, size)
+	scope, err := resolveEmployeeScope(ctx, h.db, c)
 	if err != nil {
 		response.InternalError(c, err)
 		return
 	}
 
-	response.OK(c, "common.success", gin.H{"total": result.Total, "hits": result.Hits, "page": page, "size": size})
+	departmentID := c.Query("department_id")
+
+	var result *search.SearchResult
+	source := searchSourceElastic
+	if h.es != nil && (cursor == nil || cursor.Source == searchSourceElastic) {
+		filters, err := h.searchScopeFilters(ctx, scope, departmentID)
+		if err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		if cursor != nil {
+			result, err = h.es.SearchEmployeesAfter(ctx, query, filters, cursor.After, size)
+		} else {
+			result, err = h.es.SearchEmployees(ctx, query, filters, page, size)
+		}
+		if err != nil {
+			h.log.WithModule("employee").WithError(err).Warn("Elasticsearch search failed, falling back to database")
+		}
+	}
+	if result == nil {
+		if cursor != nil && cursor.Source == searchSourceElastic {
+			response.BadRequest(c, "search.cursor_expired", map[string]string{"cursor": "restart"})
+			return
+		}
+		source = searchSourceDatabase
+		var after []interface{}
+		if cursor != nil {
+			after = cursor.After
+		}
+		result, err = h.searchEmployeesDB(ctx, query, scope, departmentID, after, page, size)
+		if err != nil {
+			response.InternalError(c, err)
+			return
+		}
+	}
+
+	permissions := middleware.GetPermissions(c)
+	for _, hit := range result.Hits {
+		maskEmployeeHit(hit, scope, permissions)
+	}
+
+	// A short page is the last one
+	var nextCursor interface{}
+	if len(result.Hits) == size && len(result.SortValues) > 0 {
+		nextCursor = encodeSearchCursor(source, result.SortValues)
+	}
+
+	response.OK(c, "common.success", gin.H{"total": result.Total, "hits": result.Hits, "page": page, "size": size,
+		"next_cursor": nextCursor, "source": source})
+}
+
+// Where Search results came from, returned as source
+const (
+	searchSourceElastic  = "elasticsearch"
+	searchSourceDatabase = "database"
+)
+
+// searchScopeFilters are the Elasticsearch filters of the caller's data
+// scope, as employeeFilterConditions applies it to List: the scope's
+// departments or the caller's direct reports, and the department asked for.
+func (h *EmployeeHandler) searchScopeFilters(ctx context.Context, scope *employeeScope, departmentID string) (map[string]interface{}, error) {
+	filters := make(map[string]interface{})
+	if departmentID != "" {
+		filters["department_id"] = departmentID
+	}
+	if !scope.All {
+		reports, err := scope.directReports(ctx, h.db)
+		if err != nil {
+			return nil, err
+		}
+		filters["scope"] = search.AnyOf{"department_id": scope.DepartmentIDs, "id": reports}
+	}
+	return filters, nil
+}
+
+// likeEscaper escapes the LIKE wildcards in a search term
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// searchEmployeesDB is the Postgres fallback of Search. Every word of the
+// query must appear in the name, code or email; names match without
+// diacritics, so "nguyen" finds "Nguyễn". Hits have the shape of the indexed
+// employee documents and are ordered by byte-wise name then id, as the index
+// sorts them. The scope and department are applied as List applies them.
+// after holds the name and id of the hit to continue after.
+func (h *EmployeeHandler) searchEmployeesDB(ctx context.Context, query string, scope *employeeScope, departmentID string, after []interface{}, page, size int) (*search.SearchResult, error) {
+	conditions, args := employeeFilterConditions(&dto.EmployeeFilter{DepartmentID: departmentID}, scope)
+	where := strings.Join(append([]string{"e.deleted_at IS NULL"}, conditions...), " AND ")
+	for _, term := range strings.Fields(query) {
+		args = append(args, "%"+likeEscaper.Replace(term)+"%")
+		where += fmt.Sprintf(" AND (f_unaccent(e.full_name) ILIKE f_unaccent($%[1]d) OR e.employee_code ILIKE $%[1]d OR u.email ILIKE $%[1]d)", len(args))
+	}
+
+	result := &search.SearchResult{Hits: []map[string]interface{}{}}
+	err := h.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM employees e INNER JOIN users u ON u.id = e.user_id WHERE `+where, args...).Scan(&result.Total)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := (page - 1) * size
+	if after != nil {
+		args = append(args, after[0], after[1])
+		where += fmt.Sprintf(` AND (e.full_name COLLATE "C", e.id::text) > ($%d, $%d)`, len(args)-1, len(args))
+		offset = 0
+	}
+	args = append(args, size, offset)
+	rows, err := h.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT e.id, e.employee_code, e.full_name, u.email, COALESCE(u.phone, ''),
+		       e.department_id, COALESCE(d.name, ''), e.position_id, COALESCE(p.name, ''),
+		       e.employment_status, e.employment_type, e.join_date
+		FROM employees e
+		INNER JOIN users u ON u.id = e.user_id
+		LEFT JOIN departments d ON d.id = e.department_id
+		LEFT JOIN positions p ON p.id = e.position_id
+		WHERE %s
+		ORDER BY e.full_name COLLATE "C", e.id::text
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, code, fullName, email, phone, deptID, deptName, posID, posName, status, empType string
+		var joinDate time.Time
+		if err := rows.Scan(&id, &code, &fullName, &email, &phone, &deptID, &deptName, &posID, &posName, &status, &empType, &joinDate); err != nil {
+			return nil, err
+		}
+		result.Hits = append(result.Hits, map[string]interface{}{
+			"_id": id, "id": id, "employee_code": code, "full_name": fullName, "email": email, "phone": phone,
+			"department_id": deptID, "department_name": deptName, "position_id": posID, "position_name": posName,
+			"employment_status": status, "employment_type": empType, "join_date": joinDate.Format("2006-01-02"),
+		})
+		result.SortValues = []interface{}{fullName, id}
+	}
+	return result, rows.Err()
+}
+
+// searchCursor is where a Search page ended: the backend that served it and
+// the name and id of its last hit
+type searchCursor struct {
+	Source string        `json:"source"`
+	After  []interface{} `json:"after"`
 }
 
-func (h *EmployeeHandler) generateEmployeeCode(ctx context.Context) (string, error) {
-	var lastCode string
+// encodeSearchCursor packs the source and the sort values of the last hit of
+// a search page into an opaque cursor
+func encodeSearchCursor(source string, sortValues []interface{}) string {
+	data, _ := json.Marshal(searchCursor{Source: source, After: sortValues})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
 
+// decodeSearchCursor unpacks a cursor from encodeSearchCursor
+func decodeSearchCursor(raw string) (*searchCursor, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, false
+	}
+	var cursor searchCursor
+	if json.Unmarshal(data, &cursor) != nil || len(cursor.After) != 2 {
+		return nil, false
+	}
+	if cursor.Source != searchSourceElastic && cursor.Source != searchSourceDatabase {
+		return nil, false
+	}
+	for _, v := range cursor.After {
+		if _, ok := v.(string); !ok {
+			return nil, false
+		}
+	}
+	return &cursor, true
+}
+
+// reindexEmployee refreshes the search document of an employee with the
+// fields Create indexes
+func (h *EmployeeHandler) reindexEmployee(ctx context.Context, id string) {
+	var code, fullName, email, deptID, status string
+	var createdAt time.Time
 	err := h.db.QueryRowContext(ctx, `
-		SELECT employee_code
-		FROM employees
-		WHERE employee_code LIKE 'NV%'
-		ORDER BY employee_code DESC
-		LIMIT 1
-	`).Scan(&lastCode)
+		SELECT e.employee_code, e.full_name, u.email, e.department_id, e.employment_status, e.created_at
+		FROM employees e
+		INNER JOIN users u ON u.id = e.user_id
+		WHERE e.id = $1
+	`, id).Scan(&code, &fullName, &email, &deptID, &status, &createdAt)
+	if err != nil {
+		h.log.WithModule("employee").WithError(err).WithField("employee_id", id).Warn("Failed to load employee for reindexing")
+		return
+	}
+	h.queue.IndexDocument(ctx, queue.ElasticPayload{
+		Index: "employees", DocumentID: id,
+		Document: map[string]interface{}{"id": id, "employee_code": code, "full_name": fullName,
+			"email": email, "department_id": deptID, "employment_status": status, "created_at": createdAt},
+		Action: "index",
+	})
+}
 
-	num := 1
+// assignDefaultRole gives a user created without roles the configured default
+// for the employment type. The slug is resolved when assigning so renamed or
+// reseeded roles are picked up; a missing role is logged and skipped.
+func assignDefaultRole(ctx context.Context, tx *sql.Tx, log *logger.Logger, cfg config.EmployeeConfig, userID uuid.UUID, employmentType, createdBy string) error {
+	slug := cfg.DefaultRoleFor(employmentType)
+	if slug == "" {
+		return nil
+	}
+
+	var roleID uuid.UUID
+	err := tx.QueryRowContext(ctx, `SELECT id FROM roles WHERE slug = $1 AND deleted_at IS NULL`, slug).Scan(&roleID)
+	if err == sql.ErrNoRows {
+		log.WithModule("employee").WithFields(map[string]interface{}{
+			"user_id": userID, "role": slug, "employment_type": employmentType,
+		}).Warn("Default role does not exist, user created without roles")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
 
+	var creator interface{}
+	if createdBy != "" {
+		creator = createdBy
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_roles (user_id, role_id, created_at, created_by) VALUES ($1, $2, NOW(), $3)
+		ON CONFLICT DO NOTHING
+	`, userID, roleID, creator)
+	return err
+}
+
+// auditRoleAssignment records the roles a new user was given. user_roles
+// entries are keyed by the user so the RBAC audit can resolve who was affected.
+func (h *EmployeeHandler) auditRoleAssignment(c *gin.Context, userID uuid.UUID) {
+	ctx := c.Request.Context()
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT r.id, r.slug FROM user_roles ur
+		INNER JOIN roles r ON r.id = ur.role_id
+		WHERE ur.user_id = $1
+		ORDER BY r.slug
+	`, userID)
 	if err != nil {
-		if err != sql.ErrNoRows {
-			return "", err
+		h.log.WithError(err).Error("Failed to load assigned roles for audit")
+		return
+	}
+	defer rows.Close()
+
+	roleIDs, roles := []string{}, []string{}
+	for rows.Next() {
+		var id, slug string
+		if err := rows.Scan(&id, &slug); err != nil {
+			h.log.WithError(err).Error("Failed to load assigned roles for audit")
+			return
 		}
-	} else {
-		fmt.Sscanf(lastCode, "NV%d", &num)
-		num++
+		roleIDs = append(roleIDs, id)
+		roles = append(roles, slug)
 	}
+	if len(roleIDs) == 0 {
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: middleware.GetUserID(c), Action: "assign", TableName: "user_roles", RecordID: userID.String(),
+		NewValues: map[string]interface{}{"role_ids": roleIDs, "roles": roles},
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+}
+
 
-	return fmt.Sprintf("NV%06d", num), nil
-}
\ No newline at end of file
@@ -0,0 +1,383 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/security"
+	"hr-management-system/internal/spreadsheet"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/lib/pq"
+)
+
+const (
+	// employeeImportMaxRows bounds the data rows of one import file
+	employeeImportMaxRows = 1000
+	// employeeImportBatchSize is how many rows share a transaction
+	employeeImportBatchSize = 50
+)
+
+// employeeImportDateFields are read as YYYY-MM-DD, DD/MM/YYYY or an Excel
+// date serial
+var employeeImportDateFields = map[string]bool{
+	"date_of_birth": true, "join_date": true, "id_issued_date": true, "probation_end_date": true,
+}
+
+// importRow is a data row parsed into a request, or the reason it was not
+type importRow struct {
+	result dto.EmployeeImportRow
+	req    dto.CreateEmployeeRequest
+}
+
+// Import creates employees from a CSV or XLSX file. The first row holds the
+// field names of CreateEmployeeRequest (role_ids separated by ";"). Every row
+// is validated on its own and the valid ones are created in transactions of
+// employeeImportBatchSize rows; a row failing to insert only fails itself.
+func (h *EmployeeHandler) Import(c *gin.Context) {
+	header, data, _, err := h.readUpload(c)
+	if err != nil {
+		h.respondUploadError(c, err)
+		return
+	}
+	table, err := spreadsheet.Read(data, header.Filename)
+	if err != nil {
+		response.BadRequest(c, "employee.import_invalid_file", nil)
+		return
+	}
+	if len(table) < 2 {
+		response.BadRequest(c, "employee.import_empty", nil)
+		return
+	}
+	if len(table)-1 > employeeImportMaxRows {
+		response.BadRequest(c, "employee.import_too_many_rows", map[string]string{"max_rows": strconv.Itoa(employeeImportMaxRows)})
+		return
+	}
+
+	columns := make([]string, len(table[0]))
+	for i, name := range table[0] {
+		columns[i] = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+	}
+
+	ctx := c.Request.Context()
+	var rows []*importRow
+	for i, values := range table[1:] {
+		if len(values) == 0 {
+			continue
+		}
+		rows = append(rows, parseImportRow(i+2, columns, values))
+	}
+	if len(rows) == 0 {
+		response.BadRequest(c, "employee.import_empty", nil)
+		return
+	}
+
+	if err := h.validateImportRows(ctx, rows); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	currentUserID := middleware.GetUserID(c)
+	returnPasswords := security.HasPermission(middleware.GetPermissions(c), "employees.create")
+	result := dto.EmployeeImportResponse{Total: len(rows), Rows: make([]dto.EmployeeImportRow, 0, len(rows))}
+
+	var valid []*importRow
+	for _, row := range rows {
+		if row.result.Reason == "" {
+			valid = append(valid, row)
+		}
+	}
+	// Search documents go out as one bulk task rather than a task per employee
+	documents := make(map[string]interface{})
+	for from := 0; from < len(valid); from += employeeImportBatchSize {
+		to := from + employeeImportBatchSize
+		if to > len(valid) {
+			to = len(valid)
+		}
+		created, err := h.importBatch(ctx, valid[from:to], currentUserID)
+		if err != nil {
+			h.log.WithModule("employee").WithError(err).Error("Failed to import employee batch")
+			for _, row := range valid[from:to] {
+				row.result.Reason = "employee.import_failed"
+			}
+			continue
+		}
+
+		for i, row := range valid[from:to] {
+			if row.result.Reason != "" {
+				continue
+			}
+			employee := created[i]
+			documents[employee.ID.String()] = createdEmployeeDocument(employee, &row.req)
+			h.queue.LogAudit(ctx, queue.AuditLogPayload{
+				UserID: currentUserID, Action: "create", TableName: "employees", RecordID: employee.ID.String(),
+				NewValues: row.req, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+			})
+			h.auditRoleAssignment(c, employee.UserID)
+
+			row.result.Success = true
+			row.result.EmployeeID = &employee.ID
+			row.result.EmployeeCode = employee.Code
+			if !h.sendWelcome(ctx, employee, &row.req) && returnPasswords {
+				row.result.TempPassword = employee.TempPassword
+			}
+		}
+	}
+
+	if len(documents) > 0 {
+		h.queue.BulkIndexDocuments(ctx, queue.ElasticBulkPayload{Index: "employees", Documents: documents})
+	}
+
+	for _, row := range rows {
+		if row.result.Success {
+			result.Created++
+		} else {
+			result.Failed++
+		}
+		result.Rows = append(result.Rows, row.result)
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: currentUserID, Action: "import", TableName: "employees",
+		NewValues: gin.H{"file": header.Filename, "total": result.Total, "created": result.Created, "failed": result.Failed},
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "employee.imported", result)
+}
+
+// parseImportRow maps the cells of a row to a request and runs the binding
+// validation on it. Unknown columns are ignored.
+func parseImportRow(line int, columns, values []string) *importRow {
+	row := &importRow{result: dto.EmployeeImportRow{Row: line}}
+	fields := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		if i >= len(values) || values[i] == "" || column == "" {
+			continue
+		}
+		value := values[i]
+		if column == "email" {
+			row.result.Email = value
+		}
+		switch {
+		case column == "base_salary" || column == "permanent_ward_id" || column == "current_ward_id":
+			number, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+			if err != nil {
+				row.fail("employee.import_invalid_number", map[string]string{"field": column, "value": value})
+				return row
+			}
+			fields[column] = number
+		case column == "role_ids":
+			fields[column] = strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == ',' || r == ' ' })
+		case column == "send_welcome":
+			send, err := strconv.ParseBool(value)
+			if err != nil {
+				row.fail("employee.import_invalid_value", map[string]string{"field": column, "value": value})
+				return row
+			}
+			fields[column] = send
+		case employeeImportDateFields[column]:
+			date, ok := parseImportDate(value)
+			if !ok {
+				row.fail("employee.import_invalid_date", map[string]string{"field": column, "value": value})
+				return row
+			}
+			fields[column] = date
+		default:
+			fields[column] = value
+		}
+	}
+
+	encoded, _ := json.Marshal(fields)
+	if err := json.Unmarshal(encoded, &row.req); err != nil {
+		row.fail("employee.import_invalid_value", nil)
+		return row
+	}
+
+	if err := binding.Validator.ValidateStruct(&row.req); err != nil {
+		details := map[string]string{}
+		var fieldErrors validator.ValidationErrors
+		if errors.As(err, &fieldErrors) {
+			requestType := reflect.TypeOf(row.req)
+			for _, fe := range fieldErrors {
+				name := fe.Field()
+				if f, ok := requestType.FieldByName(fe.StructField()); ok {
+					name = strings.Split(f.Tag.Get("json"), ",")[0]
+				}
+				details[name] = fe.Tag()
+			}
+		}
+		row.fail("common.validation_error", details)
+	}
+	return row
+}
+
+func (r *importRow) fail(reason string, details map[string]string) {
+	if r.result.Reason != "" {
+		return
+	}
+	r.result.Reason = reason
+	if len(details) > 0 {
+		r.result.Details = details
+	}
+}
+
+// parseImportDate normalizes a date cell to YYYY-MM-DD
+func parseImportDate(value string) (string, bool) {
+	for _, layout := range []string{"2006-01-02", "02/01/2006"} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.Format("2006-01-02"), true
+		}
+	}
+	if date, ok := spreadsheet.SerialDate(value); ok {
+		return date.Format("2006-01-02"), true
+	}
+	return "", false
+}
+
+// validateImportRows checks the rows that passed binding against the
+// database and each other: unique emails, live departments, positions,
+// managers and roles, and a supported salary currency
+func (h *EmployeeHandler) validateImportRows(ctx context.Context, rows []*importRow) error {
+	var emails, departmentIDs, positionIDs, managerIDs, roleIDs []string
+	for _, row := range rows {
+		if row.result.Reason != "" {
+			continue
+		}
+		emails = append(emails, strings.ToLower(row.req.Email))
+		departmentIDs = append(departmentIDs, row.req.DepartmentID)
+		positionIDs = append(positionIDs, row.req.PositionID)
+		if row.req.ManagerID != "" {
+			managerIDs = append(managerIDs, row.req.ManagerID)
+		}
+		roleIDs = append(roleIDs, row.req.RoleIDs...)
+	}
+
+	existingEmails, err := h.existingValues(ctx, `SELECT LOWER(email) FROM users WHERE LOWER(email) = ANY($1)`, emails)
+	if err != nil {
+		return err
+	}
+	departments, err := h.existingValues(ctx, `SELECT id::text FROM departments WHERE id::text = ANY($1) AND deleted_at IS NULL`, departmentIDs)
+	if err != nil {
+		return err
+	}
+	positions, err := h.existingValues(ctx, `SELECT id::text FROM positions WHERE id::text = ANY($1) AND deleted_at IS NULL`, positionIDs)
+	if err != nil {
+		return err
+	}
+	managers, err := h.existingValues(ctx, `SELECT id::text FROM employees WHERE id::text = ANY($1) AND deleted_at IS NULL`, managerIDs)
+	if err != nil {
+		return err
+	}
+	roles, err := h.existingValues(ctx, `SELECT id::text FROM roles WHERE id::text = ANY($1) AND deleted_at IS NULL`, roleIDs)
+	if err != nil {
+		return err
+	}
+
+	seenEmails := make(map[string]int)
+	for _, row := range rows {
+		if row.result.Reason != "" {
+			continue
+		}
+		req := &row.req
+		email := strings.ToLower(req.Email)
+		if req.SalaryCurrency == "" {
+			req.SalaryCurrency = h.cfg.Payroll.DefaultCurrency()
+		}
+
+		switch {
+		case existingEmails[email]:
+			row.fail("user.email_exists", map[string]string{"email": req.Email})
+		case seenEmails[email] > 0:
+			row.fail("employee.import_duplicate_email", map[string]string{"email": req.Email, "row": strconv.Itoa(seenEmails[email])})
+		case !departments[strings.ToLower(req.DepartmentID)]:
+			row.fail("department.not_found", map[string]string{"department_id": req.DepartmentID})
+		case !positions[strings.ToLower(req.PositionID)]:
+			row.fail("position.not_found", map[string]string{"position_id": req.PositionID})
+		case req.ManagerID != "" && !managers[strings.ToLower(req.ManagerID)]:
+			row.fail("employee.import_manager_not_found", map[string]string{"manager_id": req.ManagerID})
+		case !h.cfg.Payroll.SupportsCurrency(req.SalaryCurrency):
+			row.fail("employee.unsupported_currency", map[string]string{"currency": req.SalaryCurrency})
+		}
+		for _, roleID := range req.RoleIDs {
+			if !roles[strings.ToLower(roleID)] {
+				row.fail("role.not_found", map[string]string{"role_id": roleID})
+			}
+		}
+		if _, ok := seenEmails[email]; !ok {
+			seenEmails[email] = row.result.Row
+		}
+	}
+	return nil
+}
+
+// existingValues runs a query taking a text array and returns the values it
+// selects, lowercased
+func (h *EmployeeHandler) existingValues(ctx context.Context, query string, values []string) (map[string]bool, error) {
+	found := make(map[string]bool)
+	if len(values) == 0 {
+		return found, nil
+	}
+	lowered := make([]string, len(values))
+	for i, v := range values {
+		lowered[i] = strings.ToLower(v)
+	}
+	rows, err := h.db.QueryContext(ctx, query, pq.Array(uniqueStrings(lowered)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		found[strings.ToLower(value)] = true
+	}
+	return found, rows.Err()
+}
+
+// importBatch creates the rows of one batch in a transaction. Each row runs
+// under a savepoint, so a row the database rejects is marked failed and the
+// rest of the batch still commits. created is parallel to rows.
+func (h *EmployeeHandler) importBatch(ctx context.Context, rows []*importRow, createdBy string) ([]createdEmployee, error) {
+	created := make([]createdEmployee, len(rows))
+	err := h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		for i, row := range rows {
+			code, err := h.nextEmployeeCode(ctx, tx)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, `SAVEPOINT import_row`); err != nil {
+				return err
+			}
+			employee, err := h.createEmployee(ctx, tx, &row.req, code, createdBy)
+			if err != nil {
+				h.log.WithModule("employee").WithError(err).WithField("row", row.result.Row).Warn("Failed to import employee row")
+				if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT import_row`); err != nil {
+					return err
+				}
+				row.fail("employee.import_failed", nil)
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT import_row`); err != nil {
+				return err
+			}
+			created[i] = employee
+		}
+		return nil
+	})
+	return created, err
+}
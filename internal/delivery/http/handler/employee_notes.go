@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/security"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListNotes lists the notes on an employee the caller may read: their own,
+// managers notes when they are in the employee's manager chain, and hr notes
+// for the chain and holders of employees.notes_hr. Reads of hr notes written
+// by someone else are audited. The employee never sees notes about themselves.
+func (h *EmployeeHandler) ListNotes(c *gin.Context) {
+	id := c.Param("id")
+	ctx := c.Request.Context()
+	currentUserID := middleware.GetUserID(c)
+
+	isManager, isHR, ok := h.noteAccess(c, id)
+	if !ok {
+		return
+	}
+
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT n.id, n.employee_id, n.author_id, COALESCE(a.full_name, u.email), n.visibility, n.body, n.created_at
+		FROM employee_notes n
+		INNER JOIN users u ON u.id = n.author_id
+		LEFT JOIN employees a ON a.user_id = n.author_id AND a.deleted_at IS NULL
+		WHERE n.employee_id = $1
+		  AND (n.author_id = $2
+		       OR (n.visibility = 'managers' AND $3::boolean)
+		       OR (n.visibility = 'hr' AND ($3::boolean OR $4::boolean)))
+		ORDER BY n.created_at DESC
+	`, id, currentUserID, isManager, isHR)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	notes := []dto.EmployeeNoteResponse{}
+	hrNotes := []string{}
+	for rows.Next() {
+		var n dto.EmployeeNoteResponse
+		if err := rows.Scan(&n.ID, &n.EmployeeID, &n.AuthorID, &n.AuthorName, &n.Visibility, &n.Body, &n.CreatedAt); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		if n.Visibility == "hr" && n.AuthorID.String() != currentUserID {
+			hrNotes = append(hrNotes, n.ID.String())
+		}
+		notes = append(notes, n)
+	}
+	if err := rows.Err(); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	if len(hrNotes) > 0 {
+		h.queue.LogAudit(ctx, queue.AuditLogPayload{
+			UserID: currentUserID, Action: "view", TableName: "employee_notes", RecordID: id,
+			NewValues: gin.H{"note_ids": hrNotes}, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+		})
+	}
+
+	response.OK(c, "common.list", notes)
+}
+
+// CreateNote records a note on an employee. Only their manager chain and HR
+// may keep notes on someone.
+func (h *EmployeeHandler) CreateNote(c *gin.Context) {
+	var req dto.EmployeeNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	id := c.Param("id")
+	ctx := c.Request.Context()
+	currentUserID := middleware.GetUserID(c)
+
+	isManager, isHR, ok := h.noteAccess(c, id)
+	if !ok {
+		return
+	}
+	if !isManager && !isHR {
+		response.Forbidden(c, "note.forbidden")
+		return
+	}
+
+	var n dto.EmployeeNoteResponse
+	err := h.db.QueryRowContext(ctx, `
+		INSERT INTO employee_notes (employee_id, author_id, visibility, body)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, employee_id, author_id, visibility, body, created_at
+	`, id, currentUserID, req.Visibility, req.Body).Scan(&n.ID, &n.EmployeeID, &n.AuthorID, &n.Visibility, &n.Body, &n.CreatedAt)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	h.db.QueryRowContext(ctx, `
+		SELECT COALESCE(e.full_name, u.email) FROM users u
+		LEFT JOIN employees e ON e.user_id = u.id AND e.deleted_at IS NULL
+		WHERE u.id = $1
+	`, currentUserID).Scan(&n.AuthorName)
+
+	// The body stays out of the audit log, which has a wider audience than
+	// private notes
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: currentUserID, Action: "create", TableName: "employee_notes", RecordID: n.ID.String(),
+		NewValues: gin.H{"employee_id": n.EmployeeID, "visibility": n.Visibility},
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.Created(c, "note.created", n)
+}
+
+// noteAccess reports whether the caller is in the employee's manager chain
+// and whether they hold employees.notes_hr. It writes the response and
+// returns ok false when the employee does not exist or is the caller.
+func (h *EmployeeHandler) noteAccess(c *gin.Context, employeeID string) (isManager, isHR, ok bool) {
+	ctx := c.Request.Context()
+	currentUserID := middleware.GetUserID(c)
+
+	var ownerID sql.NullString
+	err := sql.ErrNoRows
+	if _, parseErr := uuid.Parse(employeeID); parseErr == nil {
+		err = h.db.QueryRowContext(ctx, `
+			SELECT user_id FROM employees WHERE id = $1 AND deleted_at IS NULL
+		`, employeeID).Scan(&ownerID)
+	}
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "employee.not_found")
+		return false, false, false
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return false, false, false
+	}
+	if ownerID.Valid && ownerID.String == currentUserID {
+		response.Forbidden(c, "note.forbidden")
+		return false, false, false
+	}
+
+	isManager, err = h.inManagerChain(ctx, employeeID, currentUserID)
+	if err != nil {
+		response.InternalError(c, err)
+		return false, false, false
+	}
+	return isManager, security.HasPermission(middleware.GetPermissions(c), "employees.notes_hr"), true
+}
+
+// inManagerChain reports whether the user is the employee's manager, their
+// manager's manager, and so on up
+func (h *EmployeeHandler) inManagerChain(ctx context.Context, employeeID, userID string) (bool, error) {
+	var found bool
+	err := h.db.QueryRowContext(ctx, `
+		WITH RECURSIVE chain AS (
+			SELECT manager_id FROM employees WHERE id = $1
+			UNION
+			SELECT e.manager_id FROM employees e
+			INNER JOIN chain c ON e.id = c.manager_id
+			WHERE e.deleted_at IS NULL
+		)
+		SELECT EXISTS (
+			SELECT 1 FROM chain c
+			INNER JOIN employees m ON m.id = c.manager_id
+			WHERE m.user_id = $2 AND m.deleted_at IS NULL
+		)
+	`, employeeID, userID).Scan(&found)
+	return found, err
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// recordSalaryBaseline gives an employee without salary history an entry
+// for their salary before the change being made, effective from joining,
+// so periods before the change keep being paid at it
+func recordSalaryBaseline(ctx context.Context, tx *sql.Tx, employeeID string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO employee_salary_history (employee_id, base_salary, currency, effective_from)
+		SELECT e.id, e.base_salary, e.salary_currency, e.join_date FROM employees e
+		WHERE e.id = $1 AND NOT EXISTS (SELECT 1 FROM employee_salary_history h WHERE h.employee_id = e.id)
+	`, employeeID)
+	return err
+}
+
+// recordSalaryChange adds the employee's current salary to their history,
+// effective from effectiveFrom (today if empty). A second change effective
+// the same day replaces the first.
+func recordSalaryChange(ctx context.Context, tx *sql.Tx, employeeID, effectiveFrom, changedBy string) error {
+	if effectiveFrom == "" {
+		effectiveFrom = time.Now().Format("2006-01-02")
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO employee_salary_history (employee_id, base_salary, currency, effective_from, created_by)
+		SELECT id, base_salary, salary_currency, $2, $3 FROM employees WHERE id = $1
+		ON CONFLICT (employee_id, effective_from) DO UPDATE
+		SET base_salary = EXCLUDED.base_salary, currency = EXCLUDED.currency, created_by = EXCLUDED.created_by
+	`, employeeID, effectiveFrom, changedBy)
+	return err
+}
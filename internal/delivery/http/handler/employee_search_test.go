@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"hr-management-system/internal/testutil"
+)
+
+func TestSearchCursorRoundTrip(t *testing.T) {
+	for _, source := range []string{searchSourceElastic, searchSourceDatabase} {
+		raw := encodeSearchCursor(source, []interface{}{"Nguyễn Văn A", "330e8400-e29b-41d4-a716-446655440001"})
+		cursor, ok := decodeSearchCursor(raw)
+		if !ok {
+			t.Fatalf("%s cursor %q did not decode", source, raw)
+		}
+		if cursor.Source != source || cursor.After[0] != "Nguyễn Văn A" {
+			t.Fatalf("decoded %+v, want the %s cursor back", cursor, source)
+		}
+	}
+}
+
+func TestSearchCursorRejected(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	cases := map[string]string{
+		"not base64":     "%%%",
+		"no source":      encode(`["Nguyen Van A","id"]`),
+		"unknown source": encode(`{"source":"cache","after":["Nguyen Van A","id"]}`),
+		"short after":    encode(`{"source":"database","after":["Nguyen Van A"]}`),
+		"numeric after":  encode(`{"source":"database","after":["Nguyen Van A",1]}`),
+	}
+	for name, raw := range cases {
+		if _, ok := decodeSearchCursor(raw); ok {
+			t.Errorf("%s: cursor accepted", name)
+		}
+	}
+}
+
+// An Elasticsearch cursor is not continued in the database, whose order may
+// differ, while a database cursor pages on without repeating a hit
+func TestSearchCursorSource(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewEmployeeHandler(env.db, env.cache, env.queue, nil, nil, env.log, env.cfg)
+	for i := 0; i < 3; i++ {
+		testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{})
+	}
+	search := func(cursor string) map[string]interface{} {
+		target := "/employees/search?q=Test&size=2"
+		if cursor != "" {
+			target += "&cursor=" + url.QueryEscape(cursor)
+		}
+		c, rec := testutil.Request(http.MethodGet, target, nil, testutil.AdminUserID, "employees.view_all")
+		h.Search(c)
+		if rec.Code != http.StatusOK {
+			testutil.ExpectStatus(t, rec, http.StatusBadRequest)
+			testutil.ExpectMessage(t, rec, "search.cursor_expired")
+			return nil
+		}
+		return testutil.Decode(t, rec)["data"].(map[string]interface{})
+	}
+
+	elastic := encodeSearchCursor(searchSourceElastic, []interface{}{"Test", "0"})
+	if search(elastic) != nil {
+		t.Fatal("an Elasticsearch cursor was continued in the database")
+	}
+
+	first := search("")
+	next, ok := first["next_cursor"].(string)
+	if !ok {
+		t.Fatalf("first page has no next_cursor: %v", first)
+	}
+	if cursor, _ := decodeSearchCursor(next); cursor == nil || cursor.Source != searchSourceDatabase {
+		t.Fatalf("next_cursor %q is not a database cursor", next)
+	}
+	second := search(next)
+	if second == nil {
+		t.Fatal("a database cursor was rejected")
+	}
+	seen := map[interface{}]bool{}
+	for _, page := range []map[string]interface{}{first, second} {
+		for _, hit := range page["hits"].([]interface{}) {
+			id := hit.(map[string]interface{})["id"]
+			if seen[id] {
+				t.Fatalf("hit %v repeated across pages", id)
+			}
+			seen[id] = true
+		}
+	}
+	if len(seen) != 3 {
+		t.Fatalf("%d hits over two pages, want the 3 employees", len(seen))
+	}
+}
+
+// A team-scoped caller finds their departments and direct reports, with the
+// masking List applies, and nobody else even by asking for a department
+func TestSearchOutOfScope(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewEmployeeHandler(env.db, env.cache, env.queue, nil, nil, env.log, env.cfg)
+	manager := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{DepartmentID: testutil.DepartmentHR})
+	report := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{ManagerID: &manager.ID})
+	stranger := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{})
+
+	search := func(target string) map[string]bool {
+		c, rec := testutil.Request(http.MethodGet, target, nil, manager.UserID, "employees.view.team")
+		h.Search(c)
+		testutil.ExpectStatus(t, rec, http.StatusOK)
+		found := map[string]bool{}
+		for _, hit := range testutil.Decode(t, rec)["data"].(map[string]interface{})["hits"].([]interface{}) {
+			hit := hit.(map[string]interface{})
+			if phone := hit["phone"].(string); phone != "*******000" {
+				t.Errorf("phone %q of %v is not masked", phone, hit["id"])
+			}
+			found[hit["id"].(string)] = true
+		}
+		return found
+	}
+
+	found := search("/employees/search?q=Test&size=50")
+	if !found[manager.ID.String()] || !found[report.ID.String()] {
+		t.Errorf("own department or direct report missing from %v", found)
+	}
+	if found[stranger.ID.String()] {
+		t.Error("an employee outside the scope was found")
+	}
+
+	found = search("/employees/search?q=Test&size=50&department_id=" + testutil.DepartmentIT)
+	if len(found) != 1 || !found[report.ID.String()] {
+		t.Errorf("department search found %v, want only the direct report", found)
+	}
+}
+
+// Get masks as List and Search do, on the database and the cache path, and
+// caches the unmasked employee for callers entitled to it
+func TestGetMasked(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewEmployeeHandler(env.db, env.cache, env.queue, nil, nil, env.log, env.cfg)
+	manager := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{DepartmentID: testutil.DepartmentHR})
+	report := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{ManagerID: &manager.ID})
+	stranger := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{})
+
+	get := func(id, userID string, permissions ...string) *httptest.ResponseRecorder {
+		c, rec := testutil.Request(http.MethodGet, "/employees/"+id, nil, userID, permissions...)
+		testutil.Param(c, "id", id)
+		h.Get(c)
+		return rec
+	}
+
+	// The first read misses the cache, the second hits it
+	for _, path := range []string{"database", "cache"} {
+		rec := get(report.ID.String(), manager.UserID, "employees.view.team")
+		testutil.ExpectStatus(t, rec, http.StatusOK)
+		emp := testutil.Decode(t, rec)["data"].(map[string]interface{})
+		if emp["base_salary"] != 0.0 || emp["phone"] != "*******000" || emp["id_number"] != "*********000" {
+			t.Errorf("%s path: team scope got an unmasked employee %v", path, emp)
+		}
+	}
+
+	rec := get(report.ID.String(), testutil.AdminUserID, "employees.view_all", "payroll.view")
+	testutil.ExpectStatus(t, rec, http.StatusOK)
+	if emp := testutil.Decode(t, rec)["data"].(map[string]interface{}); emp["base_salary"] == 0.0 || emp["phone"] != "0900000000" {
+		t.Errorf("the cached employee was masked: %v", emp)
+	}
+
+	testutil.ExpectStatus(t, get(stranger.ID.String(), manager.UserID, "employees.view.team"), http.StatusNotFound)
+}
+
+func TestMaskEmployeeHit(t *testing.T) {
+	hit := func() map[string]interface{} {
+		return map[string]interface{}{"phone": "0901234567", "id_number": "001090000123", "base_salary": 15000000.0}
+	}
+
+	team := hit()
+	maskEmployeeHit(team, &employeeScope{DepartmentIDs: []string{testutil.DepartmentIT}}, []string{"employees.view.team"})
+	if team["base_salary"] != 0 || team["phone"] != "*******567" || team["id_number"] != "*********123" {
+		t.Errorf("team scope without payroll.view got %v", team)
+	}
+
+	payroll := hit()
+	maskEmployeeHit(payroll, &employeeScope{All: true}, []string{"employees.view_all", "payroll.view"})
+	if payroll["base_salary"] != 15000000.0 || payroll["phone"] != "0901234567" {
+		t.Errorf("unrestricted scope with payroll.view got %v", payroll)
+	}
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"testing"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/infrastructure/cache"
+	"hr-management-system/internal/infrastructure/database"
+	"hr-management-system/internal/infrastructure/logger"
+	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/testutil"
+)
+
+// testEnv holds what every handler is built from, on the integration test
+// database and Redis
+type testEnv struct {
+	db    *database.Database
+	cache *cache.RedisCache
+	queue *queue.Queue
+	log   *logger.Logger
+	cfg   *config.Config
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	return &testEnv{
+		db:    testutil.DB(t),
+		cache: testutil.Cache(t),
+		queue: testutil.Queue(t),
+		log:   testutil.Logger(),
+		cfg:   testutil.Config(t),
+	}
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/logger"
+	"hr-management-system/internal/infrastructure/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FileHandler serves files of the local storage backend. Links carry their
+// own signature, so the route sits outside JWT auth.
+type FileHandler struct {
+	store *storage.Local
+	log   *logger.Logger
+}
+
+func NewFileHandler(store *storage.Local, log *logger.Logger) *FileHandler {
+	return &FileHandler{store: store, log: log}
+}
+
+// Serve streams a file after checking the link's signature and expiry
+func (h *FileHandler) Serve(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if !h.store.Verify(key, c.Query("expires"), c.Query("signature")) {
+		response.Forbidden(c, "file.link_expired")
+		return
+	}
+
+	file, err := h.store.Get(c.Request.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		response.NotFound(c, "file.not_found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer file.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, file); err != nil {
+		h.log.WithError(err).WithField("key", key).Warn("Failed to stream file")
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+
+	"hr-management-system/internal/infrastructure/database"
+)
+
+// earthRadiusMeters is the mean Earth radius used for distances
+const earthRadiusMeters = 6371000
+
+// geofence is the office check-in area configured in system_settings
+type geofence struct {
+	Enabled   bool
+	Latitude  float64
+	Longitude float64
+	Radius    float64
+}
+
+// loadGeofence reads the geofence settings. It is disabled unless enabled
+// and every coordinate and a positive radius are set.
+func loadGeofence(ctx context.Context, db *database.Database) (geofence, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT key, value FROM system_settings
+		WHERE key IN ('geofence_enabled', 'office_latitude', 'office_longitude', 'geofence_radius_meters')
+	`)
+	if err != nil {
+		return geofence{}, err
+	}
+	defer rows.Close()
+
+	var fence geofence
+	set := 0
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return geofence{}, err
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "geofence_enabled":
+			fence.Enabled, _ = strconv.ParseBool(value)
+		case "office_latitude":
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				fence.Latitude = v
+				set++
+			}
+		case "office_longitude":
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				fence.Longitude = v
+				set++
+			}
+		case "geofence_radius_meters":
+			if v, err := strconv.ParseFloat(value, 64); err == nil && v > 0 {
+				fence.Radius = v
+				set++
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return geofence{}, err
+	}
+	fence.Enabled = fence.Enabled && set == 3
+	return fence, nil
+}
+
+// parseLatLng reads a "lat,lng" location
+func parseLatLng(location string) (float64, float64, bool) {
+	latText, lngText, ok := strings.Cut(location, ",")
+	if !ok {
+		return 0, 0, false
+	}
+	lat, err1 := strconv.ParseFloat(strings.TrimSpace(latText), 64)
+	lng, err2 := strconv.ParseFloat(strings.TrimSpace(lngText), 64)
+	if err1 != nil || err2 != nil || math.Abs(lat) > 90 || math.Abs(lng) > 180 {
+		return 0, 0, false
+	}
+	return lat, lng, true
+}
+
+// distanceMeters is the great-circle distance between two points
+func distanceMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+}
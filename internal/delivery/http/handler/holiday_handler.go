@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/domain/entity"
+	"hr-management-system/internal/infrastructure/cache"
+	"hr-management-system/internal/infrastructure/database"
+	"hr-management-system/internal/infrastructure/logger"
+	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/payroll"
+
+	"github.com/gin-gonic/gin"
+)
+
+const holidayColumns = `id, name, date, COALESCE(type, ''), COALESCE(description, ''), COALESCE(is_recurring, FALSE),
+	is_half_day, COALESCE(year, 0), created_at`
+
+type HolidayHandler struct {
+	db    *database.Database
+	cache *cache.RedisCache
+	queue *queue.Queue
+	log   *logger.Logger
+	cfg   *config.Config
+}
+
+func NewHolidayHandler(db *database.Database, cache *cache.RedisCache, queue *queue.Queue, log *logger.Logger, cfg *config.Config) *HolidayHandler {
+	return &HolidayHandler{db: db, cache: cache, queue: queue, log: log, cfg: cfg}
+}
+
+// List returns the holidays of ?year= (default this year) as the working
+// calendar counts them, recurring holidays dated in that year
+func (h *HolidayHandler) List(c *gin.Context) {
+	year, err := strconv.Atoi(c.DefaultQuery("year", strconv.Itoa(time.Now().Year())))
+	if err != nil || year < 1900 || year > 9999 {
+		response.BadRequest(c, "common.validation_error", map[string]string{"year": "1900-9999"})
+		return
+	}
+
+	expanded, err := payroll.NewService(h.db).ExpandHolidays(c.Request.Context(), year)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	holidays := make([]dto.HolidayResponse, 0, len(expanded))
+	for _, holiday := range expanded {
+		holidays = append(holidays, holidayResponse(holiday))
+	}
+	response.OK(c, "common.list", holidays)
+}
+
+func (h *HolidayHandler) Create(c *gin.Context) {
+	var req dto.HolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+	date, _ := time.Parse("2006-01-02", req.Date)
+	ctx := c.Request.Context()
+
+	holiday, err := scanHoliday(h.db.QueryRowContext(ctx, `
+		INSERT INTO holidays (name, date, type, description, is_recurring, is_half_day, year, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+		RETURNING `+holidayColumns,
+		req.Name, req.Date, nullIfEmpty(req.Type), nullIfEmpty(req.Description), req.IsRecurring, req.IsHalfDay, date.Year()))
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: middleware.GetUserID(c), Action: "create", TableName: "holidays", RecordID: holiday.ID.String(),
+		NewValues: holiday, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.Created(c, "holiday.created", holiday)
+}
+
+func (h *HolidayHandler) Update(c *gin.Context) {
+	var req dto.HolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+	date, _ := time.Parse("2006-01-02", req.Date)
+	ctx := c.Request.Context()
+
+	old, err := scanHoliday(h.db.QueryRowContext(ctx, `
+		SELECT `+holidayColumns+` FROM holidays WHERE id::text = $1 AND deleted_at IS NULL
+	`, c.Param("id")))
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "holiday.not_found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	holiday, err := scanHoliday(h.db.QueryRowContext(ctx, `
+		UPDATE holidays
+		SET name = $1, date = $2, type = $3, description = $4, is_recurring = $5, is_half_day = $6, year = $7,
+		    updated_at = NOW()
+		WHERE id = $8
+		RETURNING `+holidayColumns,
+		req.Name, req.Date, nullIfEmpty(req.Type), nullIfEmpty(req.Description), req.IsRecurring, req.IsHalfDay,
+		date.Year(), old.ID))
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: middleware.GetUserID(c), Action: "update", TableName: "holidays", RecordID: holiday.ID.String(),
+		OldValues: old, NewValues: holiday, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "holiday.updated", holiday)
+}
+
+func (h *HolidayHandler) Delete(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+
+	result, err := h.db.ExecContext(ctx, `
+		UPDATE holidays SET deleted_at = NOW() WHERE id::text = $1 AND deleted_at IS NULL
+	`, id)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		response.NotFound(c, "holiday.not_found")
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: middleware.GetUserID(c), Action: "delete", TableName: "holidays", RecordID: id,
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "holiday.deleted", nil)
+}
+
+func scanHoliday(row interface{ Scan(...interface{}) error }) (*dto.HolidayResponse, error) {
+	var holiday entity.Holiday
+	err := row.Scan(&holiday.ID, &holiday.Name, &holiday.Date, &holiday.Type, &holiday.Description,
+		&holiday.IsRecurring, &holiday.IsHalfDay, &holiday.Year, &holiday.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	result := holidayResponse(holiday)
+	return &result, nil
+}
+
+func holidayResponse(holiday entity.Holiday) dto.HolidayResponse {
+	return dto.HolidayResponse{
+		ID: holiday.ID, Name: holiday.Name, Date: holiday.Date.Format("2006-01-02"), Type: holiday.Type,
+		Description: holiday.Description, IsRecurring: holiday.IsRecurring, IsHalfDay: holiday.IsHalfDay,
+		Year: holiday.Year, CreatedAt: holiday.CreatedAt,
+	}
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"hr-management-system/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Two approvals racing for a balance that covers only one of them: the
+// balance lock lets exactly one through
+func TestLeaveApproveConcurrentBalance(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewLeaveHandler(env.db, env.cache, env.queue, env.log, env.cfg)
+
+	manager := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{PositionID: testutil.PositionManager})
+	employee := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{ManagerID: &manager.ID})
+	year := time.Now().Year()
+
+	_, err := env.db.Exec(`
+		INSERT INTO leave_balances (employee_id, leave_type_id, year, total_days, pending_days)
+		VALUES ($1, $2, $3, 5, 6)
+	`, employee.ID, testutil.LeaveTypeAnnual, year)
+	testutil.Must(t, err, "create leave balance")
+
+	var requestIDs []uuid.UUID
+	for _, days := range [][2]int{{1, 3}, {8, 10}} {
+		var id uuid.UUID
+		testutil.Must(t, env.db.QueryRow(`
+			INSERT INTO leave_requests (employee_id, leave_type_id, start_date, end_date, total_days, reason)
+			VALUES ($1, $2, $3, $4, 3, 'test') RETURNING id
+		`, employee.ID, testutil.LeaveTypeAnnual, fmt.Sprintf("%d-12-%02d", year, days[0]),
+			fmt.Sprintf("%d-12-%02d", year, days[1])).Scan(&id), "create leave request")
+		requestIDs = append(requestIDs, id)
+	}
+
+	start := make(chan struct{})
+	recorders := make([]*httptest.ResponseRecorder, len(requestIDs))
+	var wg sync.WaitGroup
+	for i, id := range requestIDs {
+		c, recorder := testutil.Request(http.MethodPut, "/", gin.H{"status": "approved"}, manager.UserID, "leave.approve")
+		testutil.Param(c, "id", id.String())
+		recorders[i] = recorder
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			h.Approve(c)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	approved, refused := 0, 0
+	for _, recorder := range recorders {
+		switch recorder.Code {
+		case http.StatusOK:
+			approved++
+		case http.StatusUnprocessableEntity:
+			testutil.ExpectMessage(t, recorder, "leave.insufficient_balance")
+			refused++
+		default:
+			t.Fatalf("unexpected response %d %s", recorder.Code, recorder.Body.String())
+		}
+	}
+	if approved != 1 || refused != 1 {
+		t.Fatalf("%d approved and %d refused, want one of each", approved, refused)
+	}
+
+	var used float64
+	var approvedRequests int
+	testutil.Must(t, env.db.QueryRow(`
+		SELECT used_days FROM leave_balances WHERE employee_id = $1 AND leave_type_id = $2 AND year = $3
+	`, employee.ID, testutil.LeaveTypeAnnual, year).Scan(&used), "read leave balance")
+	testutil.Must(t, env.db.QueryRow(`
+		SELECT COUNT(*) FROM leave_requests WHERE employee_id = $1 AND status = 'approved'
+	`, employee.ID).Scan(&approvedRequests), "count approved requests")
+	if used != 3 || approvedRequests != 1 {
+		t.Fatalf("used %g days over %d approved requests, want 3 over 1", used, approvedRequests)
+	}
+}
+
+// leave.approve lets only routed approvers decide; an approver outside the
+// route needs leave.manage
+func TestLeaveApproveOutsideRoute(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []string
+		wantStatus  int
+		wantMessage string
+	}{
+		{"approve permission only", []string{"leave.approve"}, http.StatusForbidden, "permission.denied"},
+		{"manage permission", []string{"leave.approve", "leave.manage"}, http.StatusOK, "leave.approved"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			h := NewLeaveHandler(env.db, env.cache, env.queue, env.log, env.cfg)
+
+			manager := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{PositionID: testutil.PositionManager})
+			employee := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{ManagerID: &manager.ID})
+			outsider := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{DepartmentID: testutil.DepartmentHR})
+			year := time.Now().Year()
+
+			_, err := env.db.Exec(`
+				INSERT INTO leave_balances (employee_id, leave_type_id, year, total_days, pending_days)
+				VALUES ($1, $2, $3, 5, 1)
+			`, employee.ID, testutil.LeaveTypeAnnual, year)
+			testutil.Must(t, err, "create leave balance")
+			var id uuid.UUID
+			testutil.Must(t, env.db.QueryRow(`
+				INSERT INTO leave_requests (employee_id, leave_type_id, start_date, end_date, total_days, reason)
+				VALUES ($1, $2, $3, $3, 1, 'test') RETURNING id
+			`, employee.ID, testutil.LeaveTypeAnnual, fmt.Sprintf("%d-12-01", year)).Scan(&id), "create leave request")
+
+			c, recorder := testutil.Request(http.MethodPut, "/", gin.H{"status": "approved"}, outsider.UserID, tt.permissions...)
+			testutil.Param(c, "id", id.String())
+			h.Approve(c)
+			testutil.ExpectStatus(t, recorder, tt.wantStatus)
+			testutil.ExpectMessage(t, recorder, tt.wantMessage)
+		})
+	}
+}
@@ -0,0 +1,587 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/cache"
+	"hr-management-system/internal/infrastructure/database"
+	"hr-management-system/internal/infrastructure/logger"
+	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/payroll"
+	"hr-management-system/internal/security"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	errInsufficientLeaveBalance = errors.New("insufficient leave balance")
+	errLeaveOverlap             = errors.New("leave request overlaps an open request")
+	errLeaveNotPending          = errors.New("leave request is not pending")
+)
+
+type LeaveHandler struct {
+	db    *database.Database
+	cache *cache.RedisCache
+	queue *queue.Queue
+	log   *logger.Logger
+	cfg   *config.Config
+}
+
+func NewLeaveHandler(db *database.Database, cache *cache.RedisCache, queue *queue.Queue, log *logger.Logger, cfg *config.Config) *LeaveHandler {
+	return &LeaveHandler{db: db, cache: cache, queue: queue, log: log, cfg: cfg}
+}
+
+// Create submits a leave request for the current user.
+// Types that do not require approval, and short leaves under the configured
+// threshold, are approved immediately; everything else goes to the approval queue.
+func (h *LeaveHandler) Create(c *gin.Context) {
+	var req dto.CreateLeaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	startDate, err1 := time.Parse("2006-01-02", req.StartDate)
+	endDate, err2 := time.Parse("2006-01-02", req.EndDate)
+	if err1 != nil || err2 != nil || endDate.Before(startDate) {
+		response.BadRequest(c, "validation.date_format", nil)
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	// HR may file on behalf of an employee, which skips the notice rule
+	onBehalf := req.EmployeeID != ""
+	if onBehalf && !security.HasPermission(middleware.GetPermissions(c), "leave.manage") {
+		response.Forbidden(c, "permission.denied")
+		return
+	}
+
+	var employeeID uuid.UUID
+	var err error
+	if onBehalf {
+		err = h.db.QueryRowContext(ctx, `SELECT id FROM employees WHERE id = $1 AND deleted_at IS NULL`, req.EmployeeID).Scan(&employeeID)
+	} else {
+		employeeID, err = h.getEmployeeID(ctx, userID)
+	}
+	if err != nil {
+		response.NotFound(c, "employee.not_found")
+		return
+	}
+
+	var leaveTypeName string
+	var requiresApproval bool
+	var minNoticeDays, maxConsecutiveDays int
+	err = h.db.QueryRowContext(ctx, `
+		SELECT name, requires_approval, min_notice_days, max_consecutive_days FROM leave_types
+		WHERE id = $1 AND status = 'active' AND deleted_at IS NULL
+	`, req.LeaveTypeID).Scan(&leaveTypeName, &requiresApproval, &minNoticeDays, &maxConsecutiveDays)
+	if err != nil {
+		response.NotFound(c, "leave.type_not_found")
+		return
+	}
+
+	// Rest days and public holidays are not counted, as in payroll
+	totalDays, err := payroll.NewService(h.db).WorkingDaysInPeriod(ctx, startDate, endDate)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if totalDays <= 0 {
+		response.BadRequest(c, "leave.no_working_days", nil)
+		return
+	}
+
+	if key, details := leaveRuleViolation(startDate, time.Now(), totalDays, minNoticeDays, maxConsecutiveDays, onBehalf); key != "" {
+		response.UnprocessableEntity(c, key, details)
+		return
+	}
+
+	autoApprove := !requiresApproval ||
+		(h.cfg.Leave.AutoApproveMaxDays > 0 && totalDays <= h.cfg.Leave.AutoApproveMaxDays)
+
+	status := "pending"
+	if autoApprove {
+		status = "approved"
+	}
+
+	leaveID := uuid.New()
+	err = h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		// Locking the balance first serializes concurrent requests of the
+		// same leave type, so they cannot both spend the last days
+		available, pending, err := lockLeaveBalance(ctx, tx, employeeID, req.LeaveTypeID, startDate.Year())
+		if err == sql.ErrNoRows || (err == nil && available-pending < totalDays) {
+			return errInsufficientLeaveBalance
+		}
+		if err != nil {
+			return err
+		}
+
+		var overlap bool
+		if err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM leave_requests
+				WHERE employee_id = $1 AND status IN ('pending', 'approved') AND deleted_at IS NULL
+				  AND start_date <= $3 AND end_date >= $2
+			)
+		`, employeeID, req.StartDate, req.EndDate).Scan(&overlap); err != nil {
+			return err
+		}
+		if overlap {
+			return errLeaveOverlap
+		}
+
+		var approvedAt interface{}
+		if autoApprove {
+			approvedAt = time.Now()
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO leave_requests (id, employee_id, leave_type_id, start_date, end_date, total_days, reason, status, approved_at, attachments, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW())
+		`, leaveID, employeeID, req.LeaveTypeID, req.StartDate, req.EndDate, totalDays, req.Reason, status, approvedAt, req.Attachments); err != nil {
+			return err
+		}
+
+		// Approved leave is deducted right away, pending leave is reserved
+		balanceColumn := "pending_days"
+		if autoApprove {
+			balanceColumn = "used_days"
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE leave_balances SET `+balanceColumn+` = `+balanceColumn+` + $1, updated_at = NOW()
+			WHERE employee_id = $2 AND leave_type_id = $3 AND year = $4 AND deleted_at IS NULL
+		`, totalDays, employeeID, req.LeaveTypeID, startDate.Year())
+		return err
+	})
+
+	if err == errInsufficientLeaveBalance {
+		response.UnprocessableEntity(c, "leave.insufficient_balance", nil)
+		return
+	}
+	if err == errLeaveOverlap {
+		response.Conflict(c, "leave.overlap")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	auditAction := "create"
+	if autoApprove {
+		auditAction = "auto_approve"
+		h.reconcileLeaveAttendance(ctx, employeeID, startDate, endDate)
+	}
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: userID, Action: auditAction, TableName: "leave_requests", RecordID: leaveID.String(),
+		NewValues: gin.H{"request": req, "total_days": totalDays, "status": status, "on_behalf": onBehalf},
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	if !autoApprove {
+		route, err := resolveApprovalRoute(ctx, h.db, employeeID, "leave")
+		if err != nil {
+			h.log.WithError(err).Error("Failed to route leave request for approval")
+		} else {
+			notifyApprovers(ctx, h.queue, route, "Đơn nghỉ phép chờ duyệt",
+				fmt.Sprintf("%s xin nghỉ %g ngày từ %s đến %s.", route.EmployeeName, totalDays,
+					startDate.Format("02/01/2006"), endDate.Format("02/01/2006")),
+				"leave_approval_request", map[string]interface{}{
+					"leave_request_id": leaveID.String(),
+					"employee_id":      employeeID.String(),
+				})
+			h.emailLeaveApprovers(ctx, route, queue.LeaveRequestEmailPayload{
+				EmployeeName: route.EmployeeName,
+				LeaveType:    leaveTypeName,
+				StartDate:    startDate.Format("02/01/2006"),
+				EndDate:      endDate.Format("02/01/2006"),
+				TotalDays:    totalDays,
+				Reason:       req.Reason,
+				ApproveURL:   fmt.Sprintf("%s/leave/requests/%s", h.cfg.App.FrontendURL, leaveID),
+			})
+		}
+	}
+
+	messageKey := "leave.created"
+	if autoApprove {
+		messageKey = "leave.auto_approved"
+	}
+	response.Created(c, messageKey, gin.H{
+		"id":         leaveID,
+		"total_days": totalDays,
+		"status":     status,
+	})
+}
+
+// Approve decides a pending leave request. Approval moves its days from
+// pending to used on the balance, rejection releases them; both happen in the
+// same transaction as the status change. Only routed approvers and holders of
+// leave.manage may decide: leave.approve, which the route requires, is not
+// enough on its own, so a request stays with the manager or department
+// approvers it was routed to.
+func (h *LeaveHandler) Approve(c *gin.Context) {
+	var req dto.ApproveLeaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	id := c.Param("id")
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	var employeeID, leaveTypeID uuid.UUID
+	var employeeName, employeeEmail, leaveTypeName, status string
+	var startDate, endDate time.Time
+	var totalDays float64
+	err := h.db.QueryRowContext(ctx, `
+		SELECT lr.employee_id, e.full_name, u.email, lr.leave_type_id, lt.name, lr.status,
+		       lr.start_date, lr.end_date, lr.total_days
+		FROM leave_requests lr
+		INNER JOIN employees e ON e.id = lr.employee_id
+		INNER JOIN users u ON u.id = e.user_id
+		INNER JOIN leave_types lt ON lt.id = lr.leave_type_id
+		WHERE lr.id::text = $1 AND lr.deleted_at IS NULL
+	`, id).Scan(&employeeID, &employeeName, &employeeEmail, &leaveTypeID, &leaveTypeName, &status,
+		&startDate, &endDate, &totalDays)
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "leave.not_found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	if key, err := authorizeApproval(ctx, h.db, c, employeeID, "leave", h.cfg.Security.AllowSelfApproval); err != nil {
+		response.InternalError(c, err)
+		return
+	} else if key != "" {
+		response.Forbidden(c, key)
+		return
+	}
+
+	var approverID interface{}
+	if approverEmployeeID, err := h.getEmployeeID(ctx, userID); err == nil {
+		approverID = approverEmployeeID
+	}
+
+	err = h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		// The status guard makes a concurrent second decision a no-op
+		result, err := tx.ExecContext(ctx, `
+			UPDATE leave_requests
+			SET status = $1, approved_by = $2, approved_at = NOW(), approver_notes = $3, updated_at = NOW()
+			WHERE id = $4 AND status = 'pending'
+		`, req.Status, approverID, nullIfEmpty(req.Notes), id)
+		if err != nil {
+			return err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return errLeaveNotPending
+		}
+
+		usedDelta := 0.0
+		if req.Status == "approved" {
+			usedDelta = totalDays
+
+			// The days were reserved when the request was filed, but the
+			// balance may have changed since; check it again under the lock
+			// so concurrent approvals cannot spend more than it holds
+			available, _, err := lockLeaveBalance(ctx, tx, employeeID, leaveTypeID, startDate.Year())
+			if err == sql.ErrNoRows || (err == nil && available < totalDays) {
+				return errInsufficientLeaveBalance
+			}
+			if err != nil {
+				return err
+			}
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE leave_balances
+			SET pending_days = GREATEST(pending_days - $1, 0), used_days = used_days + $2, updated_at = NOW()
+			WHERE employee_id = $3 AND leave_type_id = $4 AND year = $5 AND deleted_at IS NULL
+		`, totalDays, usedDelta, employeeID, leaveTypeID, startDate.Year())
+		return err
+	})
+	if err == errLeaveNotPending {
+		response.UnprocessableEntity(c, "leave.not_pending", map[string]string{"status": status})
+		return
+	}
+	if err == errInsufficientLeaveBalance {
+		response.UnprocessableEntity(c, "leave.insufficient_balance", nil)
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	if req.Status == "approved" {
+		h.reconcileLeaveAttendance(ctx, employeeID, startDate, endDate)
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: userID, Action: req.Status, TableName: "leave_requests", RecordID: id,
+		OldValues: gin.H{"status": status}, NewValues: req,
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	if _, err := h.queue.SendLeaveApprovedEmail(ctx, queue.LeaveApprovedEmailPayload{
+		Email:     employeeEmail,
+		Name:      employeeName,
+		LeaveType: leaveTypeName,
+		StartDate: startDate.Format("02/01/2006"),
+		EndDate:   endDate.Format("02/01/2006"),
+		Status:    req.Status,
+		Notes:     req.Notes,
+	}); err != nil {
+		h.log.WithError(err).Error("Failed to queue leave decision email")
+	}
+
+	messageKey := "leave.approved"
+	if req.Status == "rejected" {
+		messageKey = "leave.rejected"
+	}
+	response.OK(c, messageKey, gin.H{"id": id, "status": req.Status})
+}
+
+// RecomputeBalance rebuilds used and pending days of an employee's balances
+// from their leave requests. With ?dry_run=true only the discrepancies are reported.
+func (h *LeaveHandler) RecomputeBalance(c *gin.Context) {
+	employeeID, err := uuid.Parse(c.Param("employee_id"))
+	if err != nil {
+		response.NotFound(c, "employee.not_found")
+		return
+	}
+
+	year := time.Now().Year()
+	if y := c.Query("year"); y != "" {
+		if year, err = strconv.Atoi(y); err != nil {
+			response.BadRequest(c, "common.validation_error", nil)
+			return
+		}
+	}
+	dryRun := c.Query("dry_run") == "true"
+
+	ctx := c.Request.Context()
+
+	var exists bool
+	h.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM employees WHERE id = $1 AND deleted_at IS NULL)`, employeeID).Scan(&exists)
+	if !exists {
+		response.NotFound(c, "employee.not_found")
+		return
+	}
+
+	result := dto.RecomputeLeaveBalanceResponse{
+		EmployeeID: employeeID,
+		Year:       year,
+		DryRun:     dryRun,
+		Balances:   []dto.LeaveBalanceCorrection{},
+	}
+
+	// Requests count towards the year they start in, as when they were created
+	err = h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			SELECT lb.id, lb.leave_type_id, lt.name, lb.used_days, lb.pending_days,
+			       COALESCE(r.used, 0), COALESCE(r.pending, 0)
+			FROM leave_balances lb
+			INNER JOIN leave_types lt ON lt.id = lb.leave_type_id
+			LEFT JOIN (
+				SELECT leave_type_id,
+				       SUM(total_days) FILTER (WHERE status = 'approved') AS used,
+				       SUM(total_days) FILTER (WHERE status = 'pending') AS pending
+				FROM leave_requests
+				WHERE employee_id = $1 AND EXTRACT(YEAR FROM start_date) = $2 AND deleted_at IS NULL
+				GROUP BY leave_type_id
+			) r ON r.leave_type_id = lb.leave_type_id
+			WHERE lb.employee_id = $1 AND lb.year = $2 AND lb.deleted_at IS NULL
+			ORDER BY lt.name
+			FOR UPDATE OF lb
+		`, employeeID, year)
+		if err != nil {
+			return err
+		}
+
+		var balanceIDs []uuid.UUID
+		for rows.Next() {
+			var balanceID uuid.UUID
+			var line dto.LeaveBalanceCorrection
+			if err := rows.Scan(&balanceID, &line.LeaveTypeID, &line.LeaveTypeName, &line.StoredUsed,
+				&line.StoredPending, &line.ComputedUsed, &line.ComputedPending); err != nil {
+				rows.Close()
+				return err
+			}
+			result.Balances = append(result.Balances, line)
+			balanceIDs = append(balanceIDs, balanceID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for i := range result.Balances {
+			line := &result.Balances[i]
+			if line.StoredUsed == line.ComputedUsed && line.StoredPending == line.ComputedPending {
+				continue
+			}
+			result.Discrepancies++
+			if dryRun {
+				continue
+			}
+
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE leave_balances SET used_days = $1, pending_days = $2, updated_at = NOW()
+				WHERE id = $3
+			`, line.ComputedUsed, line.ComputedPending, balanceIDs[i]); err != nil {
+				return err
+			}
+			line.Corrected = true
+
+			h.log.WithModule("leave").WithFields(map[string]interface{}{
+				"employee_id":   employeeID,
+				"leave_type_id": line.LeaveTypeID,
+				"year":          year,
+				"used_delta":    line.ComputedUsed - line.StoredUsed,
+				"pending_delta": line.ComputedPending - line.StoredPending,
+			}).Warn("Leave balance corrected")
+		}
+		return nil
+	})
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	if !dryRun && result.Discrepancies > 0 {
+		h.queue.LogAudit(ctx, queue.AuditLogPayload{
+			UserID: middleware.GetUserID(c), Action: "recompute", TableName: "leave_balances", RecordID: employeeID.String(),
+			NewValues: result, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+		})
+	}
+
+	response.OK(c, "leave.balance_recomputed", result)
+}
+
+// InitializeBalances creates the year's missing balances of every employed
+// person for every active leave type, as the scheduler does nightly for the
+// current year. Existing rows are left alone, so the call can be repeated.
+func (h *LeaveHandler) InitializeBalances(c *gin.Context) {
+	year := time.Now().Year()
+	if y := c.Query("year"); y != "" {
+		var err error
+		if year, err = strconv.Atoi(y); err != nil || year < 2000 || year > 2100 {
+			response.BadRequest(c, "common.validation_error", nil)
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	result := dto.InitializeLeaveBalancesResponse{Year: year}
+
+	var err error
+	result.Created, result.Skipped, err = payroll.NewService(h.db).InitializeLeaveBalances(ctx, year)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.log.WithModule("leave").WithFields(map[string]interface{}{
+		"year":    year,
+		"created": result.Created,
+		"skipped": result.Skipped,
+	}).Info("Leave balances initialized")
+
+	if result.Created > 0 {
+		// The rows span every employee, so the run is audited under its own batch id
+		h.queue.LogAudit(ctx, queue.AuditLogPayload{
+			UserID: middleware.GetUserID(c), Action: "initialize", TableName: "leave_balances", RecordID: uuid.New().String(),
+			NewValues: result, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+		})
+	}
+
+	response.OK(c, "leave.balances_initialized", result)
+}
+
+// lockLeaveBalance locks an employee's balance row for a leave type and year
+// until the transaction ends. It returns the days not used yet and the days
+// reserved by pending requests, or sql.ErrNoRows when there is no balance.
+func lockLeaveBalance(ctx context.Context, tx *sql.Tx, employeeID uuid.UUID, leaveTypeID interface{}, year int) (available, pending float64, err error) {
+	err = tx.QueryRowContext(ctx, `
+		SELECT total_days + carried_over - used_days, pending_days FROM leave_balances
+		WHERE employee_id = $1 AND leave_type_id = $2 AND year = $3 AND deleted_at IS NULL
+		FOR UPDATE
+	`, employeeID, leaveTypeID, year).Scan(&available, &pending)
+	return available, pending, err
+}
+
+func (h *LeaveHandler) getEmployeeID(ctx context.Context, userID string) (uuid.UUID, error) {
+	var employeeID uuid.UUID
+	err := h.db.QueryRowContext(ctx, `SELECT id FROM employees WHERE user_id = $1 AND deleted_at IS NULL`, userID).Scan(&employeeID)
+	return employeeID, err
+}
+
+// reconcileLeaveAttendance marks the days of approved leave in attendance.
+// The nightly backfill catches up on failures, so they are only logged.
+func (h *LeaveHandler) reconcileLeaveAttendance(ctx context.Context, employeeID uuid.UUID, start, end time.Time) {
+	if !h.cfg.Attendance.LeaveStatus {
+		return
+	}
+	if _, err := payroll.NewService(h.db).ReconcileLeaveAttendance(ctx, &employeeID, start, end); err != nil {
+		h.log.WithError(err).WithField("employee_id", employeeID).Error("Failed to reconcile leave attendance")
+	}
+}
+
+// leaveNoticeDays is the number of calendar days between today and the first
+// day of leave. Starting exactly minNoticeDays from today meets the rule.
+func leaveNoticeDays(start, now time.Time) int {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return int(start.Sub(today).Hours() / 24)
+}
+
+// leaveRuleViolation checks a request starting on start, filed at now, for
+// totalDays working days against the notice and length rules of its leave
+// type, where zero means no limit. It returns the message key and details of
+// the first rule broken, or "" when there is none. Requests filed on behalf
+// of an employee skip the notice rule.
+func leaveRuleViolation(start, now time.Time, totalDays float64, minNoticeDays, maxConsecutiveDays int, onBehalf bool) (string, map[string]string) {
+	if !onBehalf && minNoticeDays > 0 && leaveNoticeDays(start, now) < minNoticeDays {
+		return "leave.notice_required", map[string]string{"min_notice_days": strconv.Itoa(minNoticeDays)}
+	}
+	if maxConsecutiveDays > 0 && totalDays > float64(maxConsecutiveDays) {
+		return "leave.too_long", map[string]string{"max_consecutive_days": strconv.Itoa(maxConsecutiveDays)}
+	}
+	return "", nil
+}
+
+// emailLeaveApprovers queues the leave request email to every routed approver
+func (h *LeaveHandler) emailLeaveApprovers(ctx context.Context, route *approvalRoute, payload queue.LeaveRequestEmailPayload) {
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT email FROM users WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL
+	`, pq.Array(route.Notify))
+	if err != nil {
+		h.log.WithError(err).Error("Failed to get leave approver emails")
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := rows.Scan(&payload.Email); err != nil {
+			return
+		}
+		if _, err := h.queue.SendLeaveRequestEmail(ctx, payload); err != nil {
+			h.log.WithError(err).Error("Failed to queue leave request email")
+		}
+	}
+}
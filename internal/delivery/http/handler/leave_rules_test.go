@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeaveRuleViolation(t *testing.T) {
+	// Late in the evening, so only the date of now counts
+	now := time.Date(2025, time.March, 10, 23, 30, 0, 0, time.UTC)
+	inDays := func(n int) time.Time {
+		return time.Date(2025, time.March, 10+n, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name          string
+		start         time.Time
+		totalDays     float64
+		minNotice     int
+		maxDays       int
+		onBehalf      bool
+		wantKey       string
+		wantDetailKey string
+		wantDetail    string
+	}{
+		{"notice exactly the minimum", inDays(3), 1, 3, 0, false, "", "", ""},
+		{"notice a day short", inDays(2), 1, 3, 0, false, "leave.notice_required", "min_notice_days", "3"},
+		{"starting today without notice", inDays(0), 1, 1, 0, false, "leave.notice_required", "min_notice_days", "1"},
+		{"no notice rule", inDays(0), 1, 0, 0, false, "", "", ""},
+		{"on behalf skips the notice", inDays(0), 1, 3, 0, true, "", "", ""},
+		{"days exactly the maximum", inDays(7), 5, 0, 5, false, "", "", ""},
+		{"half a day over the maximum", inDays(7), 5.5, 0, 5, false, "leave.too_long", "max_consecutive_days", "5"},
+		{"on behalf keeps the maximum", inDays(0), 6, 3, 5, true, "leave.too_long", "max_consecutive_days", "5"},
+		{"no maximum", inDays(7), 30, 0, 0, false, "", "", ""},
+		{"both at their limits", inDays(3), 5, 3, 5, false, "", "", ""},
+		{"notice reported before length", inDays(1), 6, 3, 5, false, "leave.notice_required", "min_notice_days", "3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, details := leaveRuleViolation(tt.start, now, tt.totalDays, tt.minNotice, tt.maxDays, tt.onBehalf)
+			if key != tt.wantKey {
+				t.Fatalf("key = %q, want %q", key, tt.wantKey)
+			}
+			if tt.wantDetailKey != "" && details[tt.wantDetailKey] != tt.wantDetail {
+				t.Errorf("details = %v, want %s=%s", details, tt.wantDetailKey, tt.wantDetail)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"database/sql"
+	"fmt"
+
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// recordAuthAttempt logs an authentication attempt and stores it for the
+// login history. The user is resolved from the email, so attempts against
+// unknown accounts are kept without one. A failed insert is only logged.
+func (h *AuthHandler) recordAuthAttempt(c *gin.Context, email string, success bool, reason string, sessionID *uuid.UUID) {
+	clientIP := c.ClientIP()
+	h.log.LogAuthAttempt(email, clientIP, success, reason)
+
+	var location string
+	if h.cfg.Security.GeoIPHeader != "" {
+		location = c.GetHeader(h.cfg.Security.GeoIPHeader)
+	}
+	_, err := h.db.ExecContext(c.Request.Context(), `
+		INSERT INTO auth_attempts (id, user_id, email, ip_address, user_agent, location, success, reason, session_id, created_at)
+		VALUES ($1, (SELECT id FROM users WHERE email = $2 AND deleted_at IS NULL), $2, $3, $4, $5, $6, $7, $8, NOW())
+	`, uuid.New(), email, clientIP, c.Request.UserAgent(), nullIfEmpty(location), success, nullIfEmpty(reason), sessionID)
+	if err != nil {
+		h.log.WithError(err).WithField("email", email).Warn("Failed to store auth attempt")
+	}
+}
+
+// LoginHistory returns the recent sign-in attempts of the current user
+func (h *AuthHandler) LoginHistory(c *gin.Context) {
+	h.loginHistory(c, middleware.GetUserID(c))
+}
+
+// UserLoginHistory returns the sign-in attempts of any user, for security
+// reviews and incident investigation
+func (h *AuthHandler) UserLoginHistory(c *gin.Context) {
+	userID := c.Param("id")
+	if !h.requireUser(c, userID) {
+		return
+	}
+	h.loginHistory(c, userID)
+}
+
+// requireUser reports whether the user exists, writing the response when
+// it does not
+func (h *AuthHandler) requireUser(c *gin.Context, userID string) bool {
+	if _, err := uuid.Parse(userID); err != nil {
+		response.NotFound(c, "user.not_found")
+		return false
+	}
+	var exists bool
+	if err := h.db.QueryRowContext(c.Request.Context(), `
+		SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)
+	`, userID).Scan(&exists); err != nil {
+		response.InternalError(c, err)
+		return false
+	}
+	if !exists {
+		response.NotFound(c, "user.not_found")
+		return false
+	}
+	return true
+}
+
+func (h *AuthHandler) loginHistory(c *gin.Context, userID string) {
+	var filter dto.LoginHistoryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	pagination := database.NewPagination(filter.Page, filter.PageSize, nil, "", &h.cfg.Database)
+	pagination.SetCountMode(c.Query("count"), h.cfg.Database.CountModeFor("auth_attempts"))
+
+	where := " WHERE a.user_id = $1"
+	args := []interface{}{userID}
+	if filter.Success != nil {
+		args = append(args, *filter.Success)
+		where += fmt.Sprintf(" AND a.success = $%d", len(args))
+	}
+
+	if err := h.db.Count(ctx, pagination, "auth_attempts", `SELECT COUNT(*) FROM auth_attempts a`+where, args...); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	args = append(args, pagination.FetchLimit(), pagination.GetOffset())
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT a.id, a.success, COALESCE(a.reason, ''), COALESCE(a.ip_address, ''), COALESCE(a.user_agent, ''),
+		       COALESCE(a.location, ''), a.session_id,
+		       COALESCE(NOT s.is_revoked AND s.expires_at > NOW(), FALSE), s.last_activity, a.created_at
+		FROM auth_attempts a
+		LEFT JOIN user_sessions s ON s.id = a.session_id`+where+
+		fmt.Sprintf(" ORDER BY a.created_at DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args)),
+		args...)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	entries := []dto.LoginHistoryEntry{}
+	for rows.Next() {
+		var e dto.LoginHistoryEntry
+		var sessionID uuid.NullUUID
+		var lastActivity sql.NullTime
+		if err := rows.Scan(&e.ID, &e.Success, &e.Reason, &e.IPAddress, &e.UserAgent, &e.Location, &sessionID,
+			&e.SessionActive, &lastActivity, &e.CreatedAt); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		if sessionID.Valid {
+			e.SessionID = &sessionID.UUID
+		}
+		if lastActivity.Valid {
+			e.LastActivity = &lastActivity.Time
+		}
+		entries = append(entries, e)
+	}
+	entries = database.TrimPage(pagination, entries)
+
+	response.OKWithMeta(c, "common.list", entries, pagination)
+}
@@ -0,0 +1,342 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/cache"
+	"hr-management-system/internal/infrastructure/database"
+	"hr-management-system/internal/infrastructure/logger"
+	"hr-management-system/internal/infrastructure/queue"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// maxSyncNotifications caps how many new notifications a single sync returns
+const maxSyncNotifications = 200
+
+type NotificationHandler struct {
+	db    *database.Database
+	cache *cache.RedisCache
+	queue *queue.Queue
+	log   *logger.Logger
+	cfg   *config.Config
+}
+
+func NewNotificationHandler(db *database.Database, cache *cache.RedisCache, queue *queue.Queue, log *logger.Logger, cfg *config.Config) *NotificationHandler {
+	return &NotificationHandler{db: db, cache: cache, queue: queue, log: log, cfg: cfg}
+}
+
+// List pages through the caller's notifications, newest first
+func (h *NotificationHandler) List(c *gin.Context) {
+	var query dto.NotificationListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	pagination := database.NewPagination(query.Page, query.PageSize, nil, "", &h.cfg.Database)
+	pagination.SetCountMode(c.Query("count"), database.CountExact)
+
+	conditions := []string{"user_id = $1", "deleted_at IS NULL"}
+	args := []interface{}{middleware.GetUserID(c)}
+	if query.Read != nil {
+		if *query.Read {
+			conditions = append(conditions, "read_at IS NOT NULL")
+		} else {
+			conditions = append(conditions, "read_at IS NULL")
+		}
+	}
+	if query.Type != "" {
+		args = append(args, query.Type)
+		conditions = append(conditions, fmt.Sprintf("type = $%d", len(args)))
+	}
+	whereClause := " WHERE " + strings.Join(conditions, " AND ")
+
+	if err := h.db.Count(ctx, pagination, "", `SELECT COUNT(*) FROM notifications`+whereClause, args...); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	args = append(args, pagination.FetchLimit(), pagination.GetOffset())
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT id, title, message, type, data, read_at, COALESCE(action_url, ''), created_at
+		FROM notifications`+whereClause+
+		fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args)), args...)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	notifications := []dto.NotificationResponse{}
+	for rows.Next() {
+		var n dto.NotificationResponse
+		var data []byte
+		var readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.Title, &n.Message, &n.Type, &data, &readAt, &n.ActionURL, &n.CreatedAt); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		if len(data) > 0 {
+			json.Unmarshal(data, &n.Data)
+		}
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	notifications = database.TrimPage(pagination, notifications)
+
+	response.OKWithMeta(c, "common.list", notifications, pagination)
+}
+
+// UnreadCount returns how many of the caller's notifications are unread
+func (h *NotificationHandler) UnreadCount(c *gin.Context) {
+	var count int
+	err := h.db.QueryRowContext(c.Request.Context(), `
+		SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL AND deleted_at IS NULL
+	`, middleware.GetUserID(c)).Scan(&count)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	response.OK(c, "common.success", gin.H{"count": count})
+}
+
+// MarkRead marks one of the caller's notifications read. A notification
+// already read keeps its first read time.
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	var readAt time.Time
+	err := h.db.QueryRowContext(c.Request.Context(), `
+		UPDATE notifications SET read_at = COALESCE(read_at, NOW()), updated_at = NOW()
+		WHERE id::text = $1 AND user_id = $2 AND deleted_at IS NULL
+		RETURNING read_at
+	`, c.Param("id"), middleware.GetUserID(c)).Scan(&readAt)
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "notification.not_found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	response.OK(c, "notification.marked_read", gin.H{"id": c.Param("id"), "read_at": readAt})
+}
+
+// MarkAllRead marks every unread notification of the caller read
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	result, err := h.db.ExecContext(c.Request.Context(), `
+		UPDATE notifications SET read_at = NOW(), updated_at = NOW()
+		WHERE user_id = $1 AND read_at IS NULL AND deleted_at IS NULL
+	`, middleware.GetUserID(c))
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	updated, _ := result.RowsAffected()
+	response.OK(c, "notification.all_marked_read", gin.H{"updated": updated})
+}
+
+// Sync reconciles client-side read state after offline use.
+// Read always wins over unread and the earliest read timestamp is kept.
+func (h *NotificationHandler) Sync(c *gin.Context) {
+	var req dto.SyncNotificationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+	cursor := time.Now()
+
+	ids := make([]string, 0, len(req.Items))
+	for _, item := range req.Items {
+		ids = append(ids, item.ID.String())
+	}
+
+	// Apply client read timestamps, never un-reading a notification
+	err := h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		for _, item := range req.Items {
+			if item.ReadAt == nil {
+				continue
+			}
+			readAt := *item.ReadAt
+			if readAt.After(cursor) {
+				readAt = cursor
+			}
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE notifications
+				SET read_at = LEAST(COALESCE(read_at, $3), $3), updated_at = NOW()
+				WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+			`, item.ID, userID, readAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	result := dto.SyncNotificationsResponse{
+		States:        []dto.NotificationStateResponse{},
+		Notifications: []dto.NotificationResponse{},
+		Cursor:        cursor,
+	}
+
+	// Authoritative state for the notifications the client sent
+	if len(ids) > 0 {
+		rows, err := h.db.QueryContext(ctx, `
+			SELECT id, read_at FROM notifications
+			WHERE user_id = $1 AND id = ANY($2::uuid[]) AND deleted_at IS NULL
+		`, userID, pq.Array(ids))
+		if err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var state dto.NotificationStateResponse
+			var readAt sql.NullTime
+			rows.Scan(&state.ID, &readAt)
+			if readAt.Valid {
+				state.ReadAt = &readAt.Time
+			}
+			result.States = append(result.States, state)
+		}
+	}
+
+	// Notifications created since the client's cursor
+	if req.Since != nil {
+		rows, err := h.db.QueryContext(ctx, `
+			SELECT id, title, message, type, read_at, COALESCE(action_url, ''), created_at
+			FROM notifications
+			WHERE user_id = $1 AND created_at > $2 AND created_at <= $3 AND deleted_at IS NULL
+			ORDER BY created_at
+			LIMIT $4
+		`, userID, *req.Since, cursor, maxSyncNotifications)
+		if err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var n dto.NotificationResponse
+			var readAt sql.NullTime
+			rows.Scan(&n.ID, &n.Title, &n.Message, &n.Type, &readAt, &n.ActionURL, &n.CreatedAt)
+			if readAt.Valid {
+				n.ReadAt = &readAt.Time
+			}
+			result.Notifications = append(result.Notifications, n)
+		}
+
+		// Let the client page through large backlogs
+		if len(result.Notifications) == maxSyncNotifications {
+			result.Cursor = result.Notifications[len(result.Notifications)-1].CreatedAt
+		}
+	}
+
+	response.OK(c, "notification.synced", result)
+}
+
+// Broadcast queues one notification for many users. The worker fans it out
+// in batches; progress is available from GetBroadcast or the task status
+// endpoint, where the task id is the broadcast id.
+func (h *NotificationHandler) Broadcast(c *gin.Context) {
+	var req dto.BroadcastNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+	if req.Type == "" {
+		req.Type = "announcement"
+	}
+
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	var data []byte
+	if req.Data != nil {
+		data, _ = json.Marshal(req.Data)
+	}
+	var departmentIDs, roleIDs interface{}
+	if len(req.DepartmentIDs) > 0 {
+		departmentIDs = pq.Array(req.DepartmentIDs)
+	}
+	if len(req.RoleIDs) > 0 {
+		roleIDs = pq.Array(req.RoleIDs)
+	}
+
+	broadcast := dto.BroadcastResponse{ID: uuid.New(), Title: req.Title, Type: req.Type, Status: "queued", Queue: queue.QueueDefault}
+	err := h.db.QueryRowContext(ctx, `
+		INSERT INTO notification_broadcasts (id, title, message, type, data, department_ids, role_ids, status, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 'queued', $8, NOW(), NOW())
+		RETURNING created_at
+	`, broadcast.ID, req.Title, req.Message, req.Type, data, departmentIDs, roleIDs, userID).Scan(&broadcast.CreatedAt)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	info, err := h.queue.BroadcastNotification(ctx, queue.NotificationBroadcastPayload{BroadcastID: broadcast.ID.String()})
+	if err != nil {
+		h.db.ExecContext(ctx, `UPDATE notification_broadcasts SET status = 'failed', updated_at = NOW() WHERE id = $1`, broadcast.ID)
+		response.InternalError(c, err)
+		return
+	}
+	broadcast.TaskID = info.ID
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: userID, Action: "broadcast", TableName: "notification_broadcasts", RecordID: broadcast.ID.String(),
+		NewValues: req, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.Created(c, "notification.broadcast_queued", broadcast)
+}
+
+// GetBroadcast returns the progress of a broadcast
+func (h *NotificationHandler) GetBroadcast(c *gin.Context) {
+	broadcast := dto.BroadcastResponse{Queue: queue.QueueDefault}
+	var startedAt, completedAt sql.NullTime
+	err := h.db.QueryRowContext(c.Request.Context(), `
+		SELECT id, title, type, status, total, processed, created_at, started_at, completed_at
+		FROM notification_broadcasts WHERE id::text = $1
+	`, c.Param("id")).Scan(&broadcast.ID, &broadcast.Title, &broadcast.Type, &broadcast.Status,
+		&broadcast.Total, &broadcast.Processed, &broadcast.CreatedAt, &startedAt, &completedAt)
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "notification.broadcast_not_found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	broadcast.TaskID = broadcast.ID.String()
+	if startedAt.Valid {
+		broadcast.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		broadcast.CompletedAt = &completedAt.Time
+	}
+
+	response.OK(c, "common.success", broadcast)
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/cache"
+
+	"github.com/gin-gonic/gin"
+)
+
+// notificationHeartbeat is how often an idle stream sends a comment, so
+// proxies and load balancers do not close it
+const notificationHeartbeat = 25 * time.Second
+
+// notificationRetryMillis is the reconnect delay suggested to EventSource
+const notificationRetryMillis = 5000
+
+// Stream holds a Server-Sent Events connection and pushes the caller's new
+// notifications as "notification" events. The worker publishes each stored
+// notification on the user's Redis channel, so whichever API instance holds
+// the connection forwards it. Notifications sent while disconnected are read
+// from List or Sync after reconnecting. The stream ends when the client goes
+// away.
+func (h *NotificationHandler) Stream(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := middleware.GetUserID(c)
+
+	sub := h.cache.Subscribe(ctx, cache.KeyNotifyChannel+userID)
+	defer sub.Close()
+	// Wait for the subscription, so nothing published after the client is
+	// told it is connected is missed
+	if _, err := sub.Receive(ctx); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", notificationRetryMillis)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(notificationHeartbeat)
+	defer heartbeat.Stop()
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			// The id lets EventSource report the last notification it saw
+			var event struct {
+				ID string `json:"id"`
+			}
+			json.Unmarshal([]byte(msg.Payload), &event)
+			if _, err := fmt.Fprintf(c.Writer, "id: %s\nevent: notification\ndata: %s\n\n", event.ID, msg.Payload); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
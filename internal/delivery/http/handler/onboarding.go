@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// onboardingProfileColumns are the employee fields ONBOARDING_PROFILE_FIELDS
+// may name. Others are ignored, as the names go into the query.
+var onboardingProfileColumns = map[string]bool{
+	"gender": true, "place_of_birth": true, "marital_status": true,
+	"id_issued_date": true, "id_issued_place": true, "tax_code": true,
+	"social_insurance_no": true, "health_insurance_no": true,
+	"bank_account_no": true, "bank_name": true, "bank_branch": true, "avatar": true,
+	"permanent_address": true, "current_address": true,
+	"personal_email": true, "personal_phone": true,
+	"emergency_contact": true, "emergency_phone": true,
+}
+
+const onboardingCacheTTL = 24 * time.Hour
+
+// Onboarding returns the first-login checklist of the current user with the
+// steps still outstanding
+func (h *AuthHandler) Onboarding(c *gin.Context) {
+	status, err := h.onboardingStatus(c.Request.Context(), middleware.GetUserID(c))
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "user.not_found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	response.OK(c, "common.success", status)
+}
+
+// OnboardingCompleted reports whether the user has finished onboarding. The
+// answer is cached once true, as a finished checklist is never reopened.
+func (h *AuthHandler) OnboardingCompleted(ctx context.Context, userID string) (bool, error) {
+	if done, _ := h.cache.Exists(ctx, "onboarding_done:"+userID); done {
+		return true, nil
+	}
+	status, err := h.onboardingStatus(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return status.Completed, nil
+}
+
+// onboardingStatus works the steps out from the account and the employee
+// profile. The first time they are all done the completion is recorded, and
+// from then on the user counts as onboarded whatever changes later.
+func (h *AuthHandler) onboardingStatus(ctx context.Context, userID string) (*dto.OnboardingResponse, error) {
+	var fields []string
+	for _, field := range h.cfg.Onboarding.ProfileFields {
+		if field = strings.TrimSpace(field); onboardingProfileColumns[field] {
+			fields = append(fields, field)
+		}
+	}
+
+	query := `
+		SELECT u.email_verified_at IS NOT NULL, u.password_changed_at IS NOT NULL,
+		       COALESCE(u.two_factor_enabled, FALSE), u.onboarding_completed_at, e.id IS NOT NULL`
+	for _, field := range fields {
+		query += fmt.Sprintf(", COALESCE(e.%s::text, '') <> ''", field)
+	}
+	query += `
+		FROM users u
+		LEFT JOIN employees e ON e.user_id = u.id AND e.deleted_at IS NULL
+		WHERE u.id = $1 AND u.deleted_at IS NULL`
+
+	var emailVerified, passwordChanged, twoFactor, hasProfile bool
+	var completedAt sql.NullTime
+	filled := make([]bool, len(fields))
+	dest := []interface{}{&emailVerified, &passwordChanged, &twoFactor, &completedAt, &hasProfile}
+	for i := range filled {
+		dest = append(dest, &filled[i])
+	}
+	if err := h.db.QueryRowContext(ctx, query, userID).Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	steps := []dto.OnboardingStep{
+		{Key: "verify_email", Done: emailVerified},
+		{Key: "change_password", Done: passwordChanged},
+	}
+	if hasProfile && len(fields) > 0 {
+		profile := dto.OnboardingStep{Key: "complete_profile", Done: true}
+		for i, field := range fields {
+			if !filled[i] {
+				profile.Done = false
+				profile.MissingFields = append(profile.MissingFields, field)
+			}
+		}
+		steps = append(steps, profile)
+	}
+	if h.cfg.Onboarding.Require2FA {
+		steps = append(steps, dto.OnboardingStep{Key: "setup_2fa", Done: twoFactor})
+	}
+
+	status := &dto.OnboardingResponse{Steps: steps, Outstanding: []string{}}
+	for _, step := range steps {
+		if !step.Done {
+			status.Outstanding = append(status.Outstanding, step.Key)
+		}
+	}
+
+	if !completedAt.Valid && len(status.Outstanding) == 0 {
+		if err := h.db.QueryRowContext(ctx, `
+			UPDATE users SET onboarding_completed_at = NOW() WHERE id = $1
+			RETURNING onboarding_completed_at
+		`, userID).Scan(&completedAt); err != nil {
+			return nil, err
+		}
+	}
+	if completedAt.Valid {
+		status.Completed = true
+		status.CompletedAt = &completedAt.Time
+		h.cache.Set(ctx, "onboarding_done:"+userID, true, onboardingCacheTTL)
+	}
+	return status, nil
+}
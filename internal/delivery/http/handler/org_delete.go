@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/database"
+	"hr-management-system/internal/infrastructure/queue"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Policies for deleting a department or position that employees reference
+const (
+	orgDeleteBlock    = "block"
+	orgDeleteReassign = "reassign"
+	orgDeleteCascade  = "cascade"
+)
+
+var (
+	errOrgUnitHasEmployees   = errors.New("org unit has employees")
+	errDepartmentHasChildren = errors.New("department has sub-departments")
+)
+
+// orgDeletePolicy returns the requested policy, or the configured default.
+// A default other than block or reassign is treated as block so cascade only
+// ever happens on request.
+func orgDeletePolicy(requested string, cfg config.EmployeeConfig) string {
+	if requested != "" {
+		return requested
+	}
+	if cfg.OrgDeletePolicy == orgDeleteReassign {
+		return orgDeleteReassign
+	}
+	return orgDeleteBlock
+}
+
+// Delete soft-deletes a department. With employees or sub-departments left,
+// block refuses; reassign moves employees to ?reassign_to= (or the fallback
+// department) and sub-departments up to the deleted department's parent;
+// cascade deletes the whole subtree and leaves employees on it.
+func (h *DepartmentHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.NotFound(c, "department.not_found")
+		return
+	}
+	var req dto.DeleteOrgUnitRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	var parentID sql.NullString
+	err = h.db.QueryRowContext(ctx, `
+		SELECT parent_id FROM departments WHERE id = $1 AND deleted_at IS NULL
+	`, id).Scan(&parentID)
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "department.not_found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	result := dto.DeleteOrgUnitResponse{ID: id, Policy: orgDeletePolicy(req.Policy, h.cfg.Employee)}
+	if result.Policy == orgDeleteReassign {
+		target, ok, err := reassignTarget(ctx, h.db, "departments", id, req.ReassignTo, h.cfg.Employee.FallbackDepartmentID)
+		if err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		if !ok {
+			response.BadRequest(c, "department.invalid_reassign_target", nil)
+			return
+		}
+		result.ReassignedTo = &target
+	}
+
+	err = h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		switch result.Policy {
+		case orgDeleteBlock:
+			var employees, children int
+			if err := tx.QueryRowContext(ctx, `
+				SELECT (SELECT COUNT(*) FROM employees WHERE department_id = $1 AND deleted_at IS NULL),
+				       (SELECT COUNT(*) FROM departments WHERE parent_id = $1 AND deleted_at IS NULL)
+			`, id).Scan(&employees, &children); err != nil {
+				return err
+			}
+			if employees > 0 {
+				return errOrgUnitHasEmployees
+			}
+			if children > 0 {
+				return errDepartmentHasChildren
+			}
+
+		case orgDeleteReassign:
+			moved, err := tx.ExecContext(ctx, `
+				UPDATE employees SET department_id = $2, updated_at = NOW()
+				WHERE department_id = $1 AND deleted_at IS NULL
+			`, id, *result.ReassignedTo)
+			if err != nil {
+				return err
+			}
+			result.EmployeesReassigned, _ = moved.RowsAffected()
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE departments SET parent_id = $2, updated_at = NOW()
+				WHERE parent_id = $1 AND deleted_at IS NULL
+			`, id, parentID); err != nil {
+				return err
+			}
+
+		case orgDeleteCascade:
+			deleted, err := tx.ExecContext(ctx, `
+				WITH RECURSIVE tree AS (
+					SELECT id FROM departments WHERE id = $1
+					UNION
+					SELECT d.id FROM departments d INNER JOIN tree t ON d.parent_id = t.id
+					WHERE d.deleted_at IS NULL
+				)
+				UPDATE departments SET deleted_at = NOW(), updated_at = NOW()
+				WHERE id IN (SELECT id FROM tree) AND id <> $1 AND deleted_at IS NULL
+			`, id)
+			if err != nil {
+				return err
+			}
+			result.UnitsDeleted, _ = deleted.RowsAffected()
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			UPDATE departments SET deleted_at = NOW(), updated_at = NOW() WHERE id = $1
+		`, id)
+		result.UnitsDeleted++
+		return err
+	})
+	if errors.Is(err, errOrgUnitHasEmployees) {
+		response.Conflict(c, "department.has_employees")
+		return
+	}
+	if errors.Is(err, errDepartmentHasChildren) {
+		response.Conflict(c, "department.has_children")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: middleware.GetUserID(c), Action: "delete", TableName: "departments", RecordID: id.String(),
+		NewValues: result, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "department.deleted", result)
+}
+
+// Delete soft-deletes a position. With employees left, block refuses,
+// reassign moves them to ?reassign_to= (or the fallback position) and
+// cascade leaves them on the deleted position.
+func (h *PositionHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.NotFound(c, "position.not_found")
+		return
+	}
+	var req dto.DeleteOrgUnitRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	var exists bool
+	if err := h.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM positions WHERE id = $1 AND deleted_at IS NULL)
+	`, id).Scan(&exists); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if !exists {
+		response.NotFound(c, "position.not_found")
+		return
+	}
+
+	result := dto.DeleteOrgUnitResponse{ID: id, Policy: orgDeletePolicy(req.Policy, h.cfg.Employee)}
+	if result.Policy == orgDeleteReassign {
+		target, ok, err := reassignTarget(ctx, h.db, "positions", id, req.ReassignTo, h.cfg.Employee.FallbackPositionID)
+		if err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		if !ok {
+			response.BadRequest(c, "position.invalid_reassign_target", nil)
+			return
+		}
+		result.ReassignedTo = &target
+	}
+
+	err = h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		switch result.Policy {
+		case orgDeleteBlock:
+			var employees int
+			if err := tx.QueryRowContext(ctx, `
+				SELECT COUNT(*) FROM employees WHERE position_id = $1 AND deleted_at IS NULL
+			`, id).Scan(&employees); err != nil {
+				return err
+			}
+			if employees > 0 {
+				return errOrgUnitHasEmployees
+			}
+
+		case orgDeleteReassign:
+			moved, err := tx.ExecContext(ctx, `
+				UPDATE employees SET position_id = $2, updated_at = NOW()
+				WHERE position_id = $1 AND deleted_at IS NULL
+			`, id, *result.ReassignedTo)
+			if err != nil {
+				return err
+			}
+			result.EmployeesReassigned, _ = moved.RowsAffected()
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			UPDATE positions SET deleted_at = NOW(), updated_at = NOW() WHERE id = $1
+		`, id)
+		result.UnitsDeleted = 1
+		return err
+	})
+	if errors.Is(err, errOrgUnitHasEmployees) {
+		response.Conflict(c, "position.has_employees")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: middleware.GetUserID(c), Action: "delete", TableName: "positions", RecordID: id.String(),
+		NewValues: result, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "position.deleted", result)
+}
+
+// reassignTarget resolves where reassigned employees go: the requested id or
+// the configured fallback. ok is false when neither names a live row of the
+// table other than the one being deleted.
+func reassignTarget(ctx context.Context, db *database.Database, table string, deleting uuid.UUID, requested, fallback string) (uuid.UUID, bool, error) {
+	if requested == "" {
+		requested = fallback
+	}
+	target, err := uuid.Parse(requested)
+	if err != nil || target == deleting {
+		return uuid.Nil, false, nil
+	}
+
+	var exists bool
+	err = db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM `+table+` WHERE id = $1 AND deleted_at IS NULL)`, target).Scan(&exists)
+	return target, exists, err
+}
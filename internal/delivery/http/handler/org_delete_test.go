@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"hr-management-system/internal/infrastructure/database"
+	"hr-management-system/internal/testutil"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func createOrgUnit(t *testing.T, db *database.Database, table string, parentID *uuid.UUID) uuid.UUID {
+	t.Helper()
+	code := "T" + strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", "")[:10])
+	var id uuid.UUID
+	var err error
+	if table == "departments" {
+		err = db.QueryRow(`INSERT INTO departments (name, code, parent_id) VALUES ($1, $1, $2) RETURNING id`, code, parentID).Scan(&id)
+	} else {
+		err = db.QueryRow(`INSERT INTO positions (name, code) VALUES ($1, $1) RETURNING id`, code).Scan(&id)
+	}
+	testutil.Must(t, err, "create %s", table)
+	return id
+}
+
+// danglingEmployees counts the given employees still referring to a deleted
+// department or position through column
+func danglingEmployees(t *testing.T, db *database.Database, table, column string, ids ...uuid.UUID) int {
+	t.Helper()
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+	var count int
+	testutil.Must(t, db.QueryRow(`
+		SELECT COUNT(*) FROM employees e
+		INNER JOIN `+table+` u ON u.id = e.`+column+`
+		WHERE e.id = ANY($1::uuid[]) AND u.deleted_at IS NOT NULL
+	`, pq.Array(values)).Scan(&count), "count dangling employees")
+	return count
+}
+
+func isDeleted(t *testing.T, db *database.Database, table string, id uuid.UUID) bool {
+	t.Helper()
+	var deleted bool
+	testutil.Must(t, db.QueryRow(`SELECT deleted_at IS NOT NULL FROM `+table+` WHERE id = $1`, id).Scan(&deleted), "read %s", table)
+	return deleted
+}
+
+// A department holding an employee and a sub-department holding another:
+// block refuses, reassign leaves no employee on a deleted department, and
+// cascade deletes the subtree and leaves both employees dangling
+func TestDepartmentDeletePolicies(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		configured    string
+		fallback      string
+		wantStatus    int
+		wantMessage   string
+		wantDeleted   int
+		wantDangling  int
+		wantReassigns bool
+	}{
+		{"block", "?policy=block", "", "", http.StatusConflict, "department.has_employees", 0, 0, false},
+		{"default is block", "", "block", "", http.StatusConflict, "department.has_employees", 0, 0, false},
+		{"configured cascade is treated as block", "", "cascade", "", http.StatusConflict, "department.has_employees", 0, 0, false},
+		{"reassign to a department", "?policy=reassign&reassign_to=" + testutil.DepartmentHR, "", "", http.StatusOK, "department.deleted", 1, 0, true},
+		{"reassign to the fallback", "?policy=reassign", "", testutil.DepartmentHR, http.StatusOK, "department.deleted", 1, 0, true},
+		{"configured reassign", "", "reassign", testutil.DepartmentHR, http.StatusOK, "department.deleted", 1, 0, true},
+		{"reassign without a target", "?policy=reassign", "", "", http.StatusBadRequest, "department.invalid_reassign_target", 0, 0, false},
+		{"cascade", "?policy=cascade", "", "", http.StatusOK, "department.deleted", 2, 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.cfg.Employee.OrgDeletePolicy = tt.configured
+			env.cfg.Employee.FallbackDepartmentID = tt.fallback
+			h := NewDepartmentHandler(env.db, env.cache, env.queue, env.log, env.cfg)
+
+			department := createOrgUnit(t, env.db, "departments", nil)
+			child := createOrgUnit(t, env.db, "departments", &department)
+			onDepartment := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{DepartmentID: department.String()})
+			onChild := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{DepartmentID: child.String()})
+
+			c, recorder := testutil.Request(http.MethodDelete, "/"+tt.query, nil, testutil.AdminUserID, "departments.delete")
+			testutil.Param(c, "id", department.String())
+			h.Delete(c)
+			testutil.ExpectStatus(t, recorder, tt.wantStatus)
+			testutil.ExpectMessage(t, recorder, tt.wantMessage)
+
+			deleted := 0
+			for _, id := range []uuid.UUID{department, child} {
+				if isDeleted(t, env.db, "departments", id) {
+					deleted++
+				}
+			}
+			if deleted != tt.wantDeleted {
+				t.Errorf("%d departments deleted, want %d", deleted, tt.wantDeleted)
+			}
+			if got := danglingEmployees(t, env.db, "departments", "department_id", onDepartment.ID, onChild.ID); got != tt.wantDangling {
+				t.Errorf("%d employees on a deleted department, want %d", got, tt.wantDangling)
+			}
+
+			if tt.wantReassigns {
+				var departmentID string
+				var childParent *string
+				testutil.Must(t, env.db.QueryRow(`SELECT department_id FROM employees WHERE id = $1`, onDepartment.ID).Scan(&departmentID), "read employee")
+				testutil.Must(t, env.db.QueryRow(`SELECT parent_id FROM departments WHERE id = $1`, child).Scan(&childParent), "read sub-department")
+				if departmentID != testutil.DepartmentHR {
+					t.Errorf("employee moved to %s, want %s", departmentID, testutil.DepartmentHR)
+				}
+				if childParent != nil {
+					t.Errorf("sub-department moved under %s, want the deleted department's parent (none)", *childParent)
+				}
+			}
+		})
+	}
+}
+
+// Block refuses, reassign moves the employee off the position, and cascade
+// deletes the position and leaves the employee dangling on it
+func TestPositionDeletePolicies(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		fallback     string
+		wantStatus   int
+		wantMessage  string
+		wantDeleted  bool
+		wantDangling int
+	}{
+		{"block", "?policy=block", "", http.StatusConflict, "position.has_employees", false, 0},
+		{"reassign to a position", "?policy=reassign&reassign_to=" + testutil.PositionStaff, "", http.StatusOK, "position.deleted", true, 0},
+		{"reassign to the fallback", "?policy=reassign", testutil.PositionStaff, http.StatusOK, "position.deleted", true, 0},
+		{"reassign without a target", "?policy=reassign", "", http.StatusBadRequest, "position.invalid_reassign_target", false, 0},
+		{"cascade", "?policy=cascade", "", http.StatusOK, "position.deleted", true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.cfg.Employee.OrgDeletePolicy = orgDeleteBlock
+			env.cfg.Employee.FallbackPositionID = tt.fallback
+			h := NewPositionHandler(env.db, env.cache, env.queue, env.log, env.cfg)
+
+			position := createOrgUnit(t, env.db, "positions", nil)
+			employee := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{PositionID: position.String()})
+
+			c, recorder := testutil.Request(http.MethodDelete, "/"+tt.query, nil, testutil.AdminUserID, "positions.delete")
+			testutil.Param(c, "id", position.String())
+			h.Delete(c)
+			testutil.ExpectStatus(t, recorder, tt.wantStatus)
+			testutil.ExpectMessage(t, recorder, tt.wantMessage)
+
+			if got := isDeleted(t, env.db, "positions", position); got != tt.wantDeleted {
+				t.Errorf("position deleted = %v, want %v", got, tt.wantDeleted)
+			}
+			if got := danglingEmployees(t, env.db, "positions", "position_id", employee.ID); got != tt.wantDangling {
+				t.Errorf("%d employees on a deleted position, want %d", got, tt.wantDangling)
+			}
+		})
+	}
+}
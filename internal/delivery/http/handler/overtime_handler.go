@@ -0,0 +1,788 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/domain/entity"
+	"hr-management-system/internal/infrastructure/cache"
+	"hr-management-system/internal/infrastructure/database"
+	"hr-management-system/internal/infrastructure/logger"
+	"hr-management-system/internal/infrastructure/queue"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type OvertimeHandler struct {
+	db    *database.Database
+	cache *cache.RedisCache
+	queue *queue.Queue
+	log   *logger.Logger
+	cfg   *config.Config
+}
+
+func NewOvertimeHandler(db *database.Database, cache *cache.RedisCache, queue *queue.Queue, log *logger.Logger, cfg *config.Config) *OvertimeHandler {
+	return &OvertimeHandler{db: db, cache: cache, queue: queue, log: log, cfg: cfg}
+}
+
+// Create submits an overtime request for the current user
+func (h *OvertimeHandler) Create(c *gin.Context) {
+	var req dto.CreateOvertimeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	startAt, err1 := time.ParseInLocation("2006-01-02 15:04", req.Date+" "+req.StartTime, time.Local)
+	endAt, err2 := time.ParseInLocation("2006-01-02 15:04", req.Date+" "+req.EndTime, time.Local)
+	if err1 != nil || err2 != nil {
+		response.BadRequest(c, "validation.date_format", nil)
+		return
+	}
+	// An end before the start means the overtime runs past midnight
+	if endAt.Equal(startAt) {
+		response.BadRequest(c, "overtime.invalid_time_range", nil)
+		return
+	}
+	if endAt.Before(startAt) {
+		endAt = endAt.AddDate(0, 0, 1)
+	}
+
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	var employeeID uuid.UUID
+	err := h.db.QueryRowContext(ctx, `SELECT id FROM employees WHERE user_id = $1 AND deleted_at IS NULL`, userID).Scan(&employeeID)
+	if err != nil {
+		response.NotFound(c, "employee.not_found")
+		return
+	}
+
+	lastWork, err := lastWorkBeforeRest(ctx, h.db, h.cfg.Attendance.MinRestPeriod, employeeID, startAt)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	var restWarning map[string]string
+	if lastWork != nil {
+		restWarning = restPeriodDetails(*lastWork, h.cfg.Attendance.MinRestPeriod)
+		if !h.cfg.Attendance.RestWarnOnly {
+			response.UnprocessableEntity(c, "attendance.insufficient_rest", restWarning)
+			return
+		}
+	}
+
+	var policy entity.OvertimePolicy
+	err = h.db.QueryRowContext(ctx, `
+		SELECT weekday_multiplier, weekend_multiplier, holiday_multiplier, night_multiplier,
+		       COALESCE(max_hours_per_day, 0), COALESCE(max_hours_per_month, 0)
+		FROM overtime_policies
+		WHERE status = 'active' AND deleted_at IS NULL
+		ORDER BY created_at DESC LIMIT 1
+	`).Scan(&policy.WeekdayMultiplier, &policy.WeekendMultiplier, &policy.HolidayMultiplier, &policy.NightMultiplier,
+		&policy.MaxHoursPerDay, &policy.MaxHoursPerMonth)
+	if err == sql.ErrNoRows {
+		policy = entity.OvertimePolicy{WeekdayMultiplier: 1.5, WeekendMultiplier: 2.0, HolidayMultiplier: 3.0, NightMultiplier: 1.3,
+			MaxHoursPerDay: 4, MaxHoursPerMonth: 40}
+	} else if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	holidays, err := loadHolidayDates(ctx, h.db, startAt, endAt)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	// The client's type is not trusted; classify from the calendar and the night
+	// window. Each date and night/day portion is rated separately.
+	segments := h.splitOvertime(policy, holidays, startAt, endAt)
+	var applicable []entity.OvertimeType
+	var weighted float64
+	for _, seg := range segments {
+		applicable = appendOvertimeTypes(applicable, h.applicableOvertimeTypes(seg.start, seg.night, holidays)...)
+		weighted += seg.Hours * seg.Multiplier
+	}
+	overtimeType := h.selectOvertimeType(policy, applicable)
+
+	hours := roundHours(endAt.Sub(startAt).Hours())
+	// The request keeps one effective multiplier so hours * multiplier still totals the segments
+	multiplier := math.Round(weighted/hours*100) / 100
+
+	overtimeID := uuid.New()
+	segmentValues := make([]entity.OvertimeRequestSegment, len(segments))
+	var exceeded map[string]string
+	err = h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		// Serializes concurrent requests of the same employee so the caps hold
+		if _, err := tx.ExecContext(ctx, `SELECT id FROM employees WHERE id = $1 FOR UPDATE`, employeeID); err != nil {
+			return err
+		}
+		var capErr error
+		if exceeded, capErr = overtimeCapExceeded(ctx, tx, policy, employeeID, startAt, segments); capErr != nil {
+			return capErr
+		}
+		if exceeded != nil {
+			return errOvertimeMaxHours
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO overtime_requests (id, employee_id, date, start_time, end_time, hours, reason, type, status, multiplier, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'pending', $9, NOW(), NOW())
+		`, overtimeID, employeeID, req.Date, req.StartTime, req.EndTime, hours, req.Reason, overtimeType, multiplier); err != nil {
+			return err
+		}
+		for i, seg := range segments {
+			seg.ID = uuid.New()
+			seg.OvertimeRequestID = overtimeID
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO overtime_request_segments (id, overtime_request_id, date, start_time, end_time, hours, type, multiplier, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+			`, seg.ID, overtimeID, seg.Date.Format("2006-01-02"), seg.StartTime, seg.EndTime, seg.Hours, seg.Type, seg.Multiplier); err != nil {
+				return err
+			}
+			segmentValues[i] = seg.OvertimeRequestSegment
+		}
+		return nil
+	})
+	if errors.Is(err, errOvertimeMaxHours) {
+		response.UnprocessableEntity(c, "overtime.max_hours_exceeded", exceeded)
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: userID, Action: "create", TableName: "overtime_requests", RecordID: overtimeID.String(),
+		NewValues: gin.H{
+			"request": req, "hours": hours, "multiplier": multiplier,
+			"type": overtimeType, "requested_type": req.Type, "applicable_types": applicable,
+			"segments": segmentValues,
+		},
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	route, err := resolveApprovalRoute(ctx, h.db, employeeID, "overtime")
+	if err != nil {
+		h.log.WithError(err).Error("Failed to route overtime request for approval")
+	} else {
+		notifyApprovers(ctx, h.queue, route, "Đề xuất tăng ca chờ duyệt",
+			fmt.Sprintf("%s đề xuất tăng ca %g giờ ngày %s (%s - %s).", route.EmployeeName, hours,
+				startAt.Format("02/01/2006"), req.StartTime, req.EndTime),
+			"overtime_approval_request", map[string]interface{}{
+				"overtime_request_id": overtimeID.String(),
+				"employee_id":         employeeID.String(),
+			})
+	}
+
+	data := gin.H{
+		"id":         overtimeID,
+		"hours":      hours,
+		"type":       overtimeType,
+		"multiplier": multiplier,
+		"segments":   segmentValues,
+		"status":     entity.OvertimeStatusPending,
+	}
+	if restWarning != nil {
+		data["warning"] = gin.H{"code": "attendance.insufficient_rest", "details": restWarning}
+	}
+	response.Created(c, "overtime.created", data)
+}
+
+// ApproveBulk approves pending overtime selected by ids, or by date range and
+// department. Requests the caller may not act on, and requests that would take
+// the employee past the monthly cap of the active policy, are skipped. The cap
+// counts the batch itself, in date order.
+func (h *OvertimeHandler) ApproveBulk(c *gin.Context) {
+	var req dto.BulkApproveOvertimeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+	if len(req.IDs) == 0 && (req.StartDate == "" || req.EndDate == "") {
+		response.BadRequest(c, "overtime.bulk_filter_required", nil)
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	// approved_by references employees; accounts without a profile leave it empty
+	var approverID interface{}
+	var approverEmployeeID uuid.UUID
+	if err := h.db.QueryRowContext(ctx, `SELECT id FROM employees WHERE user_id = $1 AND deleted_at IS NULL`, userID).Scan(&approverEmployeeID); err == nil {
+		approverID = approverEmployeeID
+	}
+
+	maxMonthlyHours := 40.0
+	err := h.db.QueryRowContext(ctx, `
+		SELECT COALESCE(max_hours_per_month, 0) FROM overtime_policies
+		WHERE status = 'active' AND deleted_at IS NULL
+		ORDER BY created_at DESC LIMIT 1
+	`).Scan(&maxMonthlyHours)
+	if err != nil && err != sql.ErrNoRows {
+		response.InternalError(c, err)
+		return
+	}
+
+	type candidate struct {
+		id, employeeID uuid.UUID
+		employeeName   string
+		userID         string
+		date           time.Time
+		hours          float64
+		status         string
+	}
+
+	result := dto.BulkApproveOvertimeResponse{BatchID: uuid.New(), Results: []dto.BulkApproveOvertimeResult{}}
+	var approved []candidate
+	err = h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		query := `
+			SELECT o.id, o.employee_id, e.full_name, e.user_id, o.date, o.hours, o.status
+			FROM overtime_requests o
+			INNER JOIN employees e ON e.id = o.employee_id
+			WHERE o.deleted_at IS NULL`
+		var args []interface{}
+		if len(req.IDs) > 0 {
+			query += " AND o.id = ANY($1::uuid[])"
+			args = append(args, pq.Array(req.IDs))
+		} else {
+			query += " AND o.status = 'pending' AND o.date BETWEEN $1 AND $2"
+			args = append(args, req.StartDate, req.EndDate)
+			if req.DepartmentID != "" {
+				query += " AND e.department_id = $3"
+				args = append(args, req.DepartmentID)
+			}
+		}
+		query += " ORDER BY o.date, o.created_at FOR UPDATE OF o"
+
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		var candidates []candidate
+		for rows.Next() {
+			var cand candidate
+			if err := rows.Scan(&cand.id, &cand.employeeID, &cand.employeeName, &cand.userID, &cand.date, &cand.hours, &cand.status); err != nil {
+				rows.Close()
+				return err
+			}
+			candidates = append(candidates, cand)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		// Lock the employees as Create does, so concurrent approvals and new
+		// requests cannot each fit under the monthly cap and together exceed
+		// it. The fixed order keeps two batches from deadlocking.
+		employeeIDs := make([]string, 0, len(candidates))
+		for _, cand := range candidates {
+			employeeIDs = append(employeeIDs, cand.employeeID.String())
+		}
+		if _, err := tx.ExecContext(ctx, `
+			SELECT id FROM employees WHERE id = ANY($1::uuid[]) ORDER BY id FOR UPDATE
+		`, pq.Array(uniqueStrings(employeeIDs))); err != nil {
+			return err
+		}
+
+		found := make(map[string]bool, len(candidates))
+		for _, cand := range candidates {
+			found[cand.id.String()] = true
+		}
+		for _, id := range uniqueStrings(req.IDs) {
+			if parsed, _ := uuid.Parse(id); !found[parsed.String()] {
+				result.Results = append(result.Results, dto.BulkApproveOvertimeResult{ID: parsed, Status: "skipped", Reason: "overtime.not_found"})
+			}
+		}
+
+		// Why each employee's requests may not be approved, "" when they may
+		refused := make(map[uuid.UUID]string)
+		monthlyHours := make(map[string]float64)
+		for _, cand := range candidates {
+			item := dto.BulkApproveOvertimeResult{
+				ID: cand.id, EmployeeID: cand.employeeID, EmployeeName: cand.employeeName,
+				Date: cand.date.Format("2006-01-02"), Hours: cand.hours, Status: "skipped",
+			}
+
+			refusal, ok := refused[cand.employeeID]
+			if !ok {
+				if refusal, err = authorizeApproval(ctx, h.db, c, cand.employeeID, "overtime", h.cfg.Security.AllowSelfApproval); err != nil {
+					return err
+				}
+				refused[cand.employeeID] = refusal
+			}
+
+			monthKey := cand.employeeID.String() + cand.date.Format("2006-01")
+			used, ok := monthlyHours[monthKey]
+			if !ok {
+				if err := tx.QueryRowContext(ctx, `
+					SELECT COALESCE(SUM(hours), 0) FROM overtime_requests
+					WHERE employee_id = $1 AND status IN ('approved', 'completed') AND deleted_at IS NULL
+					  AND date_trunc('month', date) = date_trunc('month', $2::date)
+				`, cand.employeeID, item.Date).Scan(&used); err != nil {
+					return err
+				}
+				monthlyHours[monthKey] = used
+			}
+			item.MonthlyHours = used
+
+			switch {
+			case cand.status != string(entity.OvertimeStatusPending):
+				item.Reason = "overtime.not_pending"
+			case refusal != "":
+				item.Reason = refusal
+			case maxMonthlyHours > 0 && used+cand.hours > maxMonthlyHours:
+				item.Reason = "overtime.max_hours_exceeded"
+			default:
+				if _, err := tx.ExecContext(ctx, `
+					UPDATE overtime_requests SET status = 'approved', approved_by = $1, approved_at = NOW(),
+					       approver_notes = $2, updated_at = NOW()
+					WHERE id = $3
+				`, approverID, req.Notes, cand.id); err != nil {
+					return err
+				}
+				monthlyHours[monthKey] = used + cand.hours
+				item.MonthlyHours = used + cand.hours
+				item.Status = "approved"
+				approved = append(approved, cand)
+			}
+			result.Results = append(result.Results, item)
+		}
+		return nil
+	})
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	approvedIDs := make([]string, 0, len(approved))
+	skipped := make(map[string]string)
+	for _, item := range result.Results {
+		if item.Status == "approved" {
+			result.Approved++
+			approvedIDs = append(approvedIDs, item.ID.String())
+		} else {
+			result.Skipped++
+			skipped[item.ID.String()] = item.Reason
+		}
+	}
+
+	for _, cand := range approved {
+		h.queue.SendNotification(ctx, queue.NotificationPayload{
+			UserID:  cand.userID,
+			Title:   "Đề xuất tăng ca đã được duyệt",
+			Message: fmt.Sprintf("Đề xuất tăng ca %g giờ ngày %s của bạn đã được duyệt.", cand.hours, cand.date.Format("02/01/2006")),
+			Type:    "overtime_approved",
+			Data:    map[string]interface{}{"overtime_request_id": cand.id.String()},
+		})
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: userID, Action: "bulk_approve", TableName: "overtime_requests", RecordID: result.BatchID.String(),
+		NewValues: gin.H{
+			"filter": req, "max_hours_per_month": maxMonthlyHours,
+			"approved": approvedIDs, "skipped": skipped,
+		},
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "overtime.bulk_approved", result)
+}
+
+var errOvertimeNotPending = errors.New("overtime request is not pending")
+
+// Approve approves or rejects one pending overtime request. Like ApproveBulk
+// it refuses callers who may not act on the request, their own included, and
+// an approval that would take the employee past the monthly cap.
+func (h *OvertimeHandler) Approve(c *gin.Context) {
+	var req dto.ApproveOvertimeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	id := c.Param("id")
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	var overtimeID, employeeID uuid.UUID
+	var employeeUserID, status string
+	var date time.Time
+	var hours float64
+	err := h.db.QueryRowContext(ctx, `
+		SELECT o.id, o.employee_id, e.user_id, o.date, o.hours, o.status
+		FROM overtime_requests o
+		INNER JOIN employees e ON e.id = o.employee_id
+		WHERE o.id::text = $1 AND o.deleted_at IS NULL
+	`, id).Scan(&overtimeID, &employeeID, &employeeUserID, &date, &hours, &status)
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "overtime.not_found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	if key, err := authorizeApproval(ctx, h.db, c, employeeID, "overtime", h.cfg.Security.AllowSelfApproval); err != nil {
+		response.InternalError(c, err)
+		return
+	} else if key != "" {
+		response.Forbidden(c, key)
+		return
+	}
+
+	var approverID interface{}
+	var approverEmployeeID uuid.UUID
+	if err := h.db.QueryRowContext(ctx, `SELECT id FROM employees WHERE user_id = $1 AND deleted_at IS NULL`, userID).Scan(&approverEmployeeID); err == nil {
+		approverID = approverEmployeeID
+	}
+
+	var monthlyHours, maxMonthlyHours float64
+	err = h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		if req.Status == "approved" {
+			// Lock the employee as Create and ApproveBulk do before counting
+			// the month against the cap
+			if _, err := tx.ExecContext(ctx, `SELECT id FROM employees WHERE id = $1 FOR UPDATE`, employeeID); err != nil {
+				return err
+			}
+			maxMonthlyHours = 40
+			err := tx.QueryRowContext(ctx, `
+				SELECT COALESCE(max_hours_per_month, 0) FROM overtime_policies
+				WHERE status = 'active' AND deleted_at IS NULL
+				ORDER BY created_at DESC LIMIT 1
+			`).Scan(&maxMonthlyHours)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+			if err := tx.QueryRowContext(ctx, `
+				SELECT COALESCE(SUM(hours), 0) FROM overtime_requests
+				WHERE employee_id = $1 AND status IN ('approved', 'completed') AND deleted_at IS NULL
+				  AND date_trunc('month', date) = date_trunc('month', $2::date)
+			`, employeeID, date.Format("2006-01-02")).Scan(&monthlyHours); err != nil {
+				return err
+			}
+			if maxMonthlyHours > 0 && monthlyHours+hours > maxMonthlyHours {
+				return errOvertimeMaxHours
+			}
+		}
+
+		// The status guard makes a concurrent second decision a no-op
+		result, err := tx.ExecContext(ctx, `
+			UPDATE overtime_requests SET status = $1, approved_by = $2, approved_at = NOW(),
+			       approver_notes = $3, updated_at = NOW()
+			WHERE id = $4 AND status = 'pending'
+		`, req.Status, approverID, nullIfEmpty(req.Notes), overtimeID)
+		if err != nil {
+			return err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return errOvertimeNotPending
+		}
+		return nil
+	})
+	if err == errOvertimeNotPending {
+		response.UnprocessableEntity(c, "overtime.not_pending", map[string]string{"status": status})
+		return
+	}
+	if err == errOvertimeMaxHours {
+		response.UnprocessableEntity(c, "overtime.max_hours_exceeded", map[string]string{
+			"monthly_hours":       fmt.Sprintf("%g", monthlyHours),
+			"requested_hours":     fmt.Sprintf("%g", hours),
+			"max_hours_per_month": fmt.Sprintf("%g", maxMonthlyHours),
+		})
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	title, verb := "Đề xuất tăng ca đã được duyệt", "được duyệt"
+	if req.Status == "rejected" {
+		title, verb = "Đề xuất tăng ca bị từ chối", "bị từ chối"
+	}
+	h.queue.SendNotification(ctx, queue.NotificationPayload{
+		UserID:  employeeUserID,
+		Title:   title,
+		Message: fmt.Sprintf("Đề xuất tăng ca %g giờ ngày %s của bạn đã %s.", hours, date.Format("02/01/2006"), verb),
+		Type:    "overtime_" + req.Status,
+		Data:    map[string]interface{}{"overtime_request_id": overtimeID.String()},
+	})
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: userID, Action: req.Status, TableName: "overtime_requests", RecordID: overtimeID.String(),
+		OldValues: gin.H{"status": status}, NewValues: req,
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	messageKey := "overtime.approved"
+	if req.Status == "rejected" {
+		messageKey = "overtime.rejected"
+	}
+	response.OK(c, messageKey, gin.H{"id": overtimeID, "status": req.Status})
+}
+
+var errOvertimeMaxHours = errors.New("overtime exceeds the policy caps")
+
+// overtimeCapExceeded checks a new request against the daily and monthly caps
+// of the policy, counting requests still pending as well as approved ones.
+// Daily hours come from the segments, so a span past midnight counts towards
+// both dates; the month is the one of the request date, as in ApproveBulk.
+// It returns the figures of the first cap broken, or nil.
+func overtimeCapExceeded(ctx context.Context, tx *sql.Tx, policy entity.OvertimePolicy, employeeID uuid.UUID, startAt time.Time, segments []overtimeSegment) (map[string]string, error) {
+	if policy.MaxHoursPerDay > 0 {
+		requested := make(map[string]float64)
+		var dates []string
+		for _, seg := range segments {
+			date := seg.Date.Format("2006-01-02")
+			if _, ok := requested[date]; !ok {
+				dates = append(dates, date)
+			}
+			requested[date] += seg.Hours
+		}
+
+		rows, err := tx.QueryContext(ctx, `
+			SELECT s.date, COALESCE(SUM(s.hours), 0)
+			FROM overtime_request_segments s
+			INNER JOIN overtime_requests o ON o.id = s.overtime_request_id
+			WHERE o.employee_id = $1 AND o.status IN ('pending', 'approved', 'completed') AND o.deleted_at IS NULL
+			  AND s.date = ANY($2::date[])
+			GROUP BY s.date
+		`, employeeID, pq.Array(dates))
+		if err != nil {
+			return nil, err
+		}
+		used := make(map[string]float64)
+		for rows.Next() {
+			var date time.Time
+			var hours float64
+			if err := rows.Scan(&date, &hours); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			used[date.Format("2006-01-02")] = hours
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		for _, date := range dates {
+			if total := roundHours(used[date] + requested[date]); total > policy.MaxHoursPerDay {
+				return map[string]string{
+					"date": date, "hours": fmt.Sprint(total), "max_hours_per_day": fmt.Sprint(policy.MaxHoursPerDay),
+				}, nil
+			}
+		}
+	}
+
+	if policy.MaxHoursPerMonth > 0 {
+		var used float64
+		if err := tx.QueryRowContext(ctx, `
+			SELECT COALESCE(SUM(hours), 0) FROM overtime_requests
+			WHERE employee_id = $1 AND status IN ('pending', 'approved', 'completed') AND deleted_at IS NULL
+			  AND date_trunc('month', date) = date_trunc('month', $2::date)
+		`, employeeID, startAt.Format("2006-01-02")).Scan(&used); err != nil {
+			return nil, err
+		}
+		var hours float64
+		for _, seg := range segments {
+			hours += seg.Hours
+		}
+		if total := roundHours(used + hours); total > policy.MaxHoursPerMonth {
+			return map[string]string{
+				"month": startAt.Format("2006-01"), "hours": fmt.Sprint(total), "max_hours_per_month": fmt.Sprint(policy.MaxHoursPerMonth),
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// overtimeSegment is a rated piece of a request plus the data used to classify it
+type overtimeSegment struct {
+	entity.OvertimeRequestSegment
+	start time.Time
+	night bool
+}
+
+// splitOvertime cuts the span at midnight and at the night window boundaries,
+// rates every piece on its own date and merges neighbours with the same rate
+func (h *OvertimeHandler) splitOvertime(policy entity.OvertimePolicy, holidays map[string]bool, startAt, endAt time.Time) []overtimeSegment {
+	cuts := []time.Time{startAt, endAt}
+	nightStart, err1 := time.Parse("15:04", h.cfg.Overtime.NightStart)
+	nightEnd, err2 := time.Parse("15:04", h.cfg.Overtime.NightEnd)
+	for day := startOfDay(startAt); day.Before(endAt); day = day.AddDate(0, 0, 1) {
+		candidates := []time.Time{day}
+		if err1 == nil && err2 == nil {
+			candidates = append(candidates,
+				day.Add(time.Duration(minutesOfDay(nightStart))*time.Minute),
+				day.Add(time.Duration(minutesOfDay(nightEnd))*time.Minute))
+		}
+		for _, t := range candidates {
+			if t.After(startAt) && t.Before(endAt) {
+				cuts = append(cuts, t)
+			}
+		}
+	}
+	sort.Slice(cuts, func(i, j int) bool { return cuts[i].Before(cuts[j]) })
+
+	var segments []overtimeSegment
+	for i := 0; i+1 < len(cuts); i++ {
+		from, to := cuts[i], cuts[i+1]
+		if !to.After(from) {
+			continue
+		}
+		night := h.isNightTime(from)
+		overtimeType := h.selectOvertimeType(policy, h.applicableOvertimeTypes(from, night, holidays))
+
+		if n := len(segments); n > 0 {
+			last := &segments[n-1]
+			if last.Type == overtimeType && last.night == night && sameDay(last.start, from) {
+				last.EndTime = formatSegmentEnd(last.start, to)
+				last.Hours = roundHours(to.Sub(last.start).Hours())
+				continue
+			}
+		}
+		segments = append(segments, overtimeSegment{
+			OvertimeRequestSegment: entity.OvertimeRequestSegment{
+				Date:       startOfDay(from),
+				StartTime:  from.Format("15:04"),
+				EndTime:    formatSegmentEnd(from, to),
+				Hours:      roundHours(to.Sub(from).Hours()),
+				Type:       overtimeType,
+				Multiplier: overtimeMultiplier(policy, overtimeType),
+			},
+			start: from,
+			night: night,
+		})
+	}
+	return segments
+}
+
+// applicableOvertimeTypes lists every classification that applies to work
+// starting at t, most specific first. Weekday always applies as the baseline.
+func (h *OvertimeHandler) applicableOvertimeTypes(t time.Time, night bool, holidays map[string]bool) []entity.OvertimeType {
+	var types []entity.OvertimeType
+	if holidays[t.Format("2006-01-02")] || holidays[t.Format("01-02")] {
+		types = append(types, entity.OvertimeTypeHoliday)
+	}
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		types = append(types, entity.OvertimeTypeWeekend)
+	}
+	if night {
+		types = append(types, entity.OvertimeTypeNight)
+	}
+	return append(types, entity.OvertimeTypeWeekday)
+}
+
+// selectOvertimeType applies the configured precedence: either the type with
+// the highest multiplier, or the first applicable type of an explicit order
+func (h *OvertimeHandler) selectOvertimeType(policy entity.OvertimePolicy, applicable []entity.OvertimeType) entity.OvertimeType {
+	precedence := h.cfg.Overtime.TypePrecedence
+	if len(precedence) == 0 || strings.TrimSpace(precedence[0]) == "highest" {
+		best := applicable[0]
+		for _, t := range applicable[1:] {
+			if overtimeMultiplier(policy, t) > overtimeMultiplier(policy, best) {
+				best = t
+			}
+		}
+		return best
+	}
+
+	for _, name := range precedence {
+		for _, t := range applicable {
+			if string(t) == strings.TrimSpace(name) {
+				return t
+			}
+		}
+	}
+	return entity.OvertimeTypeWeekday
+}
+
+// isNightTime reports whether t falls in the configured night window, which
+// may wrap around midnight
+func (h *OvertimeHandler) isNightTime(t time.Time) bool {
+	nightStart, err1 := time.Parse("15:04", h.cfg.Overtime.NightStart)
+	nightEnd, err2 := time.Parse("15:04", h.cfg.Overtime.NightEnd)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+
+	m, ns, ne := minutesOfDay(t), minutesOfDay(nightStart), minutesOfDay(nightEnd)
+	if ns > ne {
+		return m >= ns || m < ne
+	}
+	return m >= ns && m < ne
+}
+
+func appendOvertimeTypes(types []entity.OvertimeType, more ...entity.OvertimeType) []entity.OvertimeType {
+	for _, t := range more {
+		found := false
+		for _, existing := range types {
+			if existing == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+// formatSegmentEnd writes an end at the following midnight as 24:00 so the
+// segment stays on its own date
+func formatSegmentEnd(from, to time.Time) string {
+	if !sameDay(from, to) {
+		return "24:00"
+	}
+	return to.Format("15:04")
+}
+
+func minutesOfDay(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
+
+func overtimeMultiplier(policy entity.OvertimePolicy, overtimeType entity.OvertimeType) float64 {
+	switch overtimeType {
+	case entity.OvertimeTypeWeekend:
+		return policy.WeekendMultiplier
+	case entity.OvertimeTypeHoliday:
+		return policy.HolidayMultiplier
+	case entity.OvertimeTypeNight:
+		return policy.NightMultiplier
+	default:
+		return policy.WeekdayMultiplier
+	}
+}
+
+// roundHours rounds to the two decimals stored in overtime_requests.hours
+func roundHours(hours float64) float64 {
+	return math.Round(hours*100) / 100
+}
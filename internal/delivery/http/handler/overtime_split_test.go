@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/domain/entity"
+)
+
+func TestSplitOvertime(t *testing.T) {
+	policy := entity.OvertimePolicy{WeekdayMultiplier: 1.5, WeekendMultiplier: 2, HolidayMultiplier: 3, NightMultiplier: 2.1}
+	holidays := map[string]bool{"2025-03-10": true}
+	at := func(s string) time.Time {
+		t, _ := time.Parse("2006-01-02 15:04", s)
+		return t
+	}
+
+	// 2025-03-05 is a Wednesday and 2025-03-10, a Monday, a holiday. Each
+	// segment is "date start-end hours type".
+	tests := []struct {
+		name       string
+		precedence []string
+		start, end string
+		want       []string
+	}{
+		{"weekday evening", nil, "2025-03-05 18:00", "2025-03-05 21:00",
+			[]string{"2025-03-05 18:00-21:00 3 weekday"}},
+		{"into the night window", nil, "2025-03-05 20:00", "2025-03-05 23:00",
+			[]string{"2025-03-05 20:00-22:00 2 weekday", "2025-03-05 22:00-23:00 1 night"}},
+		{"pure night across midnight", nil, "2025-03-05 23:00", "2025-03-06 03:00",
+			[]string{"2025-03-05 23:00-24:00 1 night", "2025-03-06 00:00-03:00 3 night"}},
+		{"pure night after midnight", nil, "2025-03-06 01:00", "2025-03-06 05:00",
+			[]string{"2025-03-06 01:00-05:00 4 night"}},
+		{"out of the night window", nil, "2025-03-06 04:00", "2025-03-06 08:00",
+			[]string{"2025-03-06 04:00-06:00 2 night", "2025-03-06 06:00-08:00 2 weekday"}},
+		{"the whole night window", nil, "2025-03-05 22:00", "2025-03-06 06:00",
+			[]string{"2025-03-05 22:00-24:00 2 night", "2025-03-06 00:00-06:00 6 night"}},
+		{"Friday evening into Saturday", nil, "2025-03-07 20:00", "2025-03-08 01:00",
+			[]string{"2025-03-07 20:00-22:00 2 weekday", "2025-03-07 22:00-24:00 2 night", "2025-03-08 00:00-01:00 1 night"}},
+		{"Sunday night into a holiday", nil, "2025-03-09 23:00", "2025-03-10 02:00",
+			[]string{"2025-03-09 23:00-24:00 1 night", "2025-03-10 00:00-02:00 2 holiday"}},
+		{"weekend before night by precedence", []string{"holiday", "weekend", "night", "weekday"},
+			"2025-03-08 21:00", "2025-03-08 23:00",
+			[]string{"2025-03-08 21:00-22:00 1 weekend", "2025-03-08 22:00-23:00 1 weekend"}},
+		{"ending at midnight", nil, "2025-03-05 23:30", "2025-03-06 00:00",
+			[]string{"2025-03-05 23:30-24:00 0.5 night"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			precedence := tt.precedence
+			if precedence == nil {
+				precedence = []string{"highest"}
+			}
+			h := &OvertimeHandler{cfg: &config.Config{Overtime: config.OvertimeConfig{
+				NightStart: "22:00", NightEnd: "06:00", TypePrecedence: precedence,
+			}}}
+
+			var got []string
+			for _, seg := range h.splitOvertime(policy, holidays, at(tt.start), at(tt.end)) {
+				got = append(got, fmt.Sprintf("%s %s-%s %g %s", seg.Date.Format("2006-01-02"), seg.StartTime, seg.EndTime, seg.Hours, seg.Type))
+				if seg.Multiplier != overtimeMultiplier(policy, seg.Type) {
+					t.Errorf("segment %s-%s has multiplier %g for %s", seg.StartTime, seg.EndTime, seg.Multiplier, seg.Type)
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("segments:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,492 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/cache"
+	"hr-management-system/internal/infrastructure/database"
+	"hr-management-system/internal/infrastructure/logger"
+	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/payroll"
+	"hr-management-system/internal/security"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PayrollHandler struct {
+	db    *database.Database
+	cache *cache.RedisCache
+	queue *queue.Queue
+	log   *logger.Logger
+	cfg   *config.Config
+}
+
+func NewPayrollHandler(db *database.Database, cache *cache.RedisCache, queue *queue.Queue, log *logger.Logger, cfg *config.Config) *PayrollHandler {
+	return &PayrollHandler{db: db, cache: cache, queue: queue, log: log, cfg: cfg}
+}
+
+// MyAnnualPayslips returns the caller's confirmed and paid payslips of ?year=
+// with yearly totals. HR with payroll.view may pass ?employee_id=, and
+// ?format=csv downloads the same data as a spreadsheet.
+func (h *PayrollHandler) MyAnnualPayslips(c *gin.Context) {
+	year := time.Now().Year()
+	if v := c.Query("year"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 2000 || parsed > 2100 {
+			response.BadRequest(c, "common.validation_error", nil)
+			return
+		}
+		year = parsed
+	}
+
+	ctx := c.Request.Context()
+	employeeID, ok := h.payslipEmployee(c)
+	if !ok {
+		return
+	}
+
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT ps.id, ps.employee_id, ps.employee_code, ps.employee_name,
+		       COALESCE(ps.department_name, ''), COALESCE(ps.position_name, ''),
+		       pp.id, pp.year, pp.month, ps.working_days, ps.actual_working_days, ps.leave_days,
+		       ps.absent_days, ps.overtime_hours, ps.base_salary, ps.overtime_pay, ps.allowances,
+		       ps.bonuses, ps.other_earnings, ps.gross_earnings, ps.social_insurance,
+		       ps.health_insurance, ps.unemployment_insurance, ps.personal_income_tax,
+		       ps.other_deductions, ps.total_deductions, ps.net_salary, ps.currency, ps.status, pp.end_date
+		FROM payslips ps
+		INNER JOIN payroll_periods pp ON pp.id = ps.payroll_period_id
+		WHERE ps.employee_id = $1 AND pp.year = $2
+		  AND ps.status IN ('confirmed', 'paid') AND ps.deleted_at IS NULL
+		ORDER BY pp.month
+	`, employeeID, year)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	result := dto.AnnualPayslipsResponse{EmployeeID: employeeID, Year: year, Payslips: []dto.PayslipResponse{}}
+	var periodEnds []time.Time
+	for rows.Next() {
+		var p dto.PayslipResponse
+		var periodEnd time.Time
+		if err := rows.Scan(&p.ID, &p.EmployeeID, &p.EmployeeCode, &p.EmployeeName,
+			&p.DepartmentName, &p.PositionName,
+			&p.PeriodID, &p.Year, &p.Month, &p.WorkingDays, &p.ActualWorkingDays, &p.LeaveDays,
+			&p.AbsentDays, &p.OvertimeHours, &p.BaseSalary, &p.OvertimePay, &p.Allowances,
+			&p.Bonuses, &p.OtherEarnings, &p.GrossEarnings, &p.SocialInsurance,
+			&p.HealthInsurance, &p.UnemploymentIns, &p.PersonalIncomeTax,
+			&p.OtherDeductions, &p.TotalDeductions, &p.NetSalary, &p.Currency, &p.Status, &periodEnd); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		result.Payslips = append(result.Payslips, p)
+		periodEnds = append(periodEnds, periodEnd)
+	}
+	rows.Close()
+
+	// Totals stay in the payslip currency unless the salary currency changed
+	// during the year
+	result.Currency = h.cfg.Payroll.ReportingCurrency
+	for i, p := range result.Payslips {
+		if i == 0 {
+			result.Currency = p.Currency
+		} else if p.Currency != result.Currency {
+			result.Currency = h.cfg.Payroll.ReportingCurrency
+			break
+		}
+	}
+	converter := payroll.NewService(h.db).NewConverter(result.Currency)
+	for i, p := range result.Payslips {
+		amounts := []float64{p.GrossEarnings, p.TotalDeductions, p.PersonalIncomeTax, p.NetSalary}
+		for j := range amounts {
+			if amounts[j], err = converter.Convert(ctx, amounts[j], p.Currency, periodEnds[i]); err != nil {
+				respondConversionError(c, err)
+				return
+			}
+		}
+		result.GrossEarnings += amounts[0]
+		result.TotalDeductions += amounts[1]
+		result.PersonalIncomeTax += amounts[2]
+		result.NetSalary += amounts[3]
+	}
+
+	if c.Query("format") == "csv" {
+		h.writeAnnualPayslipsCSV(c, result)
+		return
+	}
+	response.OK(c, "common.success", result)
+}
+
+// payslipEmployee resolves whose payslips a self-service request reads: the
+// caller's, or ?employee_id= for HR with payroll.view. It responds itself
+// when the request cannot proceed.
+func (h *PayrollHandler) payslipEmployee(c *gin.Context) (uuid.UUID, bool) {
+	var employeeID uuid.UUID
+	err := h.db.QueryRowContext(c.Request.Context(), `
+		SELECT id FROM employees WHERE user_id = $1 AND deleted_at IS NULL
+	`, middleware.GetUserID(c)).Scan(&employeeID)
+	if requested := c.Query("employee_id"); requested != "" && (err != nil || requested != employeeID.String()) {
+		if !security.HasPermission(middleware.GetPermissions(c), "payroll.view") {
+			response.Forbidden(c, "permission.denied")
+			return uuid.Nil, false
+		}
+		employeeID, err = uuid.Parse(requested)
+	}
+	if err != nil {
+		response.NotFound(c, "employee.not_found")
+		return uuid.Nil, false
+	}
+	return employeeID, true
+}
+
+// MyPayslipTrend returns take-home pay over the last ?months= (default 12)
+// confirmed and paid payslips, for the caller or, for HR with payroll.view,
+// ?employee_id=. Amounts share one currency like the annual summary.
+func (h *PayrollHandler) MyPayslipTrend(c *gin.Context) {
+	months := 12
+	if v := c.Query("months"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > maxPayslipTrendMonths {
+			response.BadRequest(c, "common.validation_error", map[string]string{"months": "1-" + strconv.Itoa(maxPayslipTrendMonths)})
+			return
+		}
+		months = parsed
+	}
+
+	ctx := c.Request.Context()
+	employeeID, ok := h.payslipEmployee(c)
+	if !ok {
+		return
+	}
+
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT pp.id, pp.year, pp.month, ps.gross_earnings, ps.total_deductions, ps.net_salary,
+		       ps.currency, pp.end_date
+		FROM payslips ps
+		INNER JOIN payroll_periods pp ON pp.id = ps.payroll_period_id
+		WHERE ps.employee_id = $1 AND ps.status IN ('confirmed', 'paid') AND ps.deleted_at IS NULL
+		ORDER BY pp.year DESC, pp.month DESC
+		LIMIT $2
+	`, employeeID, months)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	var points []dto.PayslipTrendPoint
+	var currencies []string
+	var periodEnds []time.Time
+	for rows.Next() {
+		var p dto.PayslipTrendPoint
+		var currency string
+		var periodEnd time.Time
+		if err := rows.Scan(&p.PeriodID, &p.Year, &p.Month, &p.GrossEarnings, &p.TotalDeductions,
+			&p.NetSalary, &currency, &periodEnd); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		points = append(points, p)
+		currencies = append(currencies, currency)
+		periodEnds = append(periodEnds, periodEnd)
+	}
+	rows.Close()
+
+	result := dto.PayslipTrendResponse{
+		EmployeeID: employeeID,
+		Currency:   h.cfg.Payroll.ReportingCurrency,
+		Months:     months,
+		Points:     make([]dto.PayslipTrendPoint, 0, len(points)),
+		Trend:      "flat",
+	}
+	for i, currency := range currencies {
+		if i == 0 {
+			result.Currency = currency
+		} else if currency != result.Currency {
+			result.Currency = h.cfg.Payroll.ReportingCurrency
+			break
+		}
+	}
+
+	// Rows come newest first; the series runs oldest first
+	converter := payroll.NewService(h.db).NewConverter(result.Currency)
+	for i := len(points) - 1; i >= 0; i-- {
+		p := points[i]
+		amounts := []*float64{&p.GrossEarnings, &p.TotalDeductions, &p.NetSalary}
+		for _, amount := range amounts {
+			if *amount, err = converter.Convert(ctx, *amount, currencies[i], periodEnds[i]); err != nil {
+				respondConversionError(c, err)
+				return
+			}
+		}
+		if n := len(result.Points); n > 0 {
+			p.NetChange, p.NetChangePercent = netChange(result.Points[n-1].NetSalary, p.NetSalary)
+		}
+		result.AverageGross += p.GrossEarnings
+		result.AverageDeduction += p.TotalDeductions
+		result.AverageNet += p.NetSalary
+		result.Points = append(result.Points, p)
+	}
+
+	if n := len(result.Points); n > 0 {
+		result.AverageGross = payroll.RoundAmount(result.AverageGross/float64(n), result.Currency)
+		result.AverageDeduction = payroll.RoundAmount(result.AverageDeduction/float64(n), result.Currency)
+		result.AverageNet = payroll.RoundAmount(result.AverageNet/float64(n), result.Currency)
+		latest := result.Points[n-1]
+		result.LatestNet = latest.NetSalary
+		result.NetChange, result.NetChangePercent = latest.NetChange, latest.NetChangePercent
+		if latest.NetChange != nil && *latest.NetChange > 0 {
+			result.Trend = "up"
+		} else if latest.NetChange != nil && *latest.NetChange < 0 {
+			result.Trend = "down"
+		}
+	}
+
+	response.OK(c, "common.success", result)
+}
+
+// maxPayslipTrendMonths bounds ?months= of the payslip trend
+const maxPayslipTrendMonths = 36
+
+// netChange compares a net salary with the previous one. The percentage is
+// left out when the previous salary was zero.
+func netChange(previous, current float64) (*float64, *float64) {
+	change := math.Round((current-previous)*100) / 100
+	if previous == 0 {
+		return &change, nil
+	}
+	percent := math.Round(change/previous*10000) / 100
+	return &change, &percent
+}
+
+// payslipAccess loads who may act on a payslip and responds itself when it
+// does not exist or the caller may not. Employees act on their own confirmed
+// or paid payslips; HR holding anyPermission on any payslip.
+func (h *PayrollHandler) payslipAccess(c *gin.Context, id, anyPermission string) (email, status string, ok bool) {
+	if _, err := uuid.Parse(id); err != nil {
+		response.NotFound(c, "payslip.not_found")
+		return "", "", false
+	}
+
+	var ownerUserID string
+	err := h.db.QueryRowContext(c.Request.Context(), `
+		SELECT e.user_id, u.email, ps.status
+		FROM payslips ps
+		INNER JOIN employees e ON e.id = ps.employee_id
+		INNER JOIN users u ON u.id = e.user_id
+		WHERE ps.id = $1 AND ps.deleted_at IS NULL
+	`, id).Scan(&ownerUserID, &email, &status)
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "payslip.not_found")
+		return "", "", false
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return "", "", false
+	}
+
+	if security.HasPermission(middleware.GetPermissions(c), anyPermission) {
+		return email, status, true
+	}
+	if ownerUserID != middleware.GetUserID(c) {
+		response.Forbidden(c, "permission.denied")
+		return "", "", false
+	}
+	if status != "confirmed" && status != "paid" {
+		response.UnprocessableEntity(c, "payslip.not_finalized", nil)
+		return "", "", false
+	}
+	return email, status, true
+}
+
+// SendPayslipEmail emails a confirmed or paid payslip, with its PDF, to the
+// employee again. Employees may resend their own payslips; HR with
+// payroll.manage may resend anyone's, as payroll.view only reads. The worker
+// renders the PDF.
+func (h *PayrollHandler) SendPayslipEmail(c *gin.Context) {
+	id := c.Param("id")
+	email, status, ok := h.payslipAccess(c, id, "payroll.manage")
+	if !ok {
+		return
+	}
+	if status != "confirmed" && status != "paid" {
+		response.UnprocessableEntity(c, "payslip.not_finalized", nil)
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	doc, err := payroll.NewService(h.db).LoadPayslipDocument(ctx, id)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	// The period also names the attachment, so it must not contain a slash
+	_, err = h.queue.SendPayslipEmail(ctx, queue.PayslipEmailPayload{
+		Email:     email,
+		Name:      doc.EmployeeName,
+		Period:    fmt.Sprintf("%02d-%d", doc.Month, doc.Year),
+		NetSalary: payroll.FormatAmount(doc.NetSalary, doc.Currency),
+		PayslipID: id,
+	})
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: userID, Action: "send_email", TableName: "payslips", RecordID: id,
+		NewValues: map[string]interface{}{"email": email},
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "payslip.sent", nil)
+}
+
+// PayslipPDF streams the payslip rendered as a PDF to its employee, or to HR
+// with payroll.view
+func (h *PayrollHandler) PayslipPDF(c *gin.Context) {
+	id := c.Param("id")
+	if _, _, ok := h.payslipAccess(c, id, "payroll.view"); !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	doc, err := payroll.NewService(h.db).LoadPayslipDocument(ctx, id)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=payslip_%s_%d_%02d.pdf", doc.EmployeeCode, doc.Year, doc.Month))
+	c.Data(http.StatusOK, "application/pdf", payroll.RenderPayslipPDF(doc))
+}
+
+// ListExchangeRates returns the exchange rates, newest first, optionally of
+// one ?currency=
+func (h *PayrollHandler) ListExchangeRates(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	query := `
+		SELECT id, currency, quote_currency, rate, effective_date, created_at
+		FROM exchange_rates`
+	var args []interface{}
+	if currency := c.Query("currency"); currency != "" {
+		query += " WHERE currency = $1 OR quote_currency = $1"
+		args = append(args, currency)
+	}
+	query += " ORDER BY effective_date DESC, currency, quote_currency LIMIT 500"
+
+	rows, err := h.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	rates := []dto.ExchangeRateResponse{}
+	for rows.Next() {
+		var r dto.ExchangeRateResponse
+		var effectiveDate time.Time
+		if err := rows.Scan(&r.ID, &r.Currency, &r.QuoteCurrency, &r.Rate, &effectiveDate, &r.CreatedAt); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		r.EffectiveDate = effectiveDate.Format("2006-01-02")
+		rates = append(rates, r)
+	}
+
+	response.OK(c, "common.list", rates)
+}
+
+// SetExchangeRate records the rate of a currency pair from a date. Setting the
+// same pair and date again replaces the rate.
+func (h *PayrollHandler) SetExchangeRate(c *gin.Context) {
+	var req dto.ExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+	effectiveDate, err := time.Parse("2006-01-02", req.EffectiveDate)
+	if err != nil || req.Currency == req.QuoteCurrency {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	var id uuid.UUID
+	err = h.db.QueryRowContext(ctx, `
+		INSERT INTO exchange_rates (id, currency, quote_currency, rate, effective_date, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		ON CONFLICT (currency, quote_currency, effective_date) DO UPDATE SET
+			rate = EXCLUDED.rate, created_by = EXCLUDED.created_by, updated_at = NOW()
+		RETURNING id
+	`, uuid.New(), req.Currency, req.QuoteCurrency, req.Rate, effectiveDate, userID).Scan(&id)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: userID, Action: "upsert", TableName: "exchange_rates", RecordID: id.String(),
+		NewValues: req, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "payroll.exchange_rate_saved", dto.ExchangeRateResponse{
+		ID: id, Currency: req.Currency, QuoteCurrency: req.QuoteCurrency, Rate: req.Rate,
+		EffectiveDate: req.EffectiveDate, CreatedAt: time.Now(),
+	})
+}
+
+// respondConversionError reports a missing exchange rate as a client-fixable
+// error naming the pair
+func respondConversionError(c *gin.Context, err error) {
+	if errors.Is(err, payroll.ErrNoExchangeRate) {
+		response.UnprocessableEntity(c, "payroll.missing_exchange_rate", map[string]string{"error": err.Error()})
+		return
+	}
+	response.InternalError(c, err)
+}
+
+func (h *PayrollHandler) writeAnnualPayslipsCSV(c *gin.Context, result dto.AnnualPayslipsResponse) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"month", "gross_earnings", "social_insurance", "health_insurance",
+		"unemployment_insurance", "personal_income_tax", "total_deductions", "net_salary", "currency", "status"})
+	for _, p := range result.Payslips {
+		w.Write([]string{
+			fmt.Sprintf("%02d/%d", p.Month, p.Year),
+			payroll.FormatMoneyIn(p.GrossEarnings, p.Currency), payroll.FormatMoneyIn(p.SocialInsurance, p.Currency),
+			payroll.FormatMoneyIn(p.HealthInsurance, p.Currency), payroll.FormatMoneyIn(p.UnemploymentIns, p.Currency),
+			payroll.FormatMoneyIn(p.PersonalIncomeTax, p.Currency), payroll.FormatMoneyIn(p.TotalDeductions, p.Currency),
+			payroll.FormatMoneyIn(p.NetSalary, p.Currency), p.Currency, p.Status,
+		})
+	}
+	w.Write([]string{
+		strconv.Itoa(result.Year), payroll.FormatMoneyIn(result.GrossEarnings, result.Currency), "", "", "",
+		payroll.FormatMoneyIn(result.PersonalIncomeTax, result.Currency), payroll.FormatMoneyIn(result.TotalDeductions, result.Currency),
+		payroll.FormatMoneyIn(result.NetSalary, result.Currency), result.Currency, "",
+	})
+	w.Flush()
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=payslips_%d.csv", result.Year))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
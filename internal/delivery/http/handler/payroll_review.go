@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/queue"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var errPayslipNotFlagged = errors.New("payslip has no open review flags")
+
+// unreviewedFlagsSQL counts the flags of a period that still block approval:
+// open ones, and ones sent back whose payslip was not recalculated yet
+const unreviewedFlagsSQL = `
+	SELECT COUNT(*) FROM payslip_flags
+	WHERE payroll_period_id = $1 AND status IN ('open', 'sent_back')`
+
+// payrollPeriodStatus returns the status of a period, responding itself when
+// it does not exist
+func (h *PayrollHandler) payrollPeriodStatus(c *gin.Context, periodID string) (string, bool) {
+	if _, err := uuid.Parse(periodID); err != nil {
+		response.NotFound(c, "payroll.not_found")
+		return "", false
+	}
+	var status string
+	err := h.db.QueryRowContext(c.Request.Context(), `
+		SELECT status FROM payroll_periods WHERE id = $1 AND deleted_at IS NULL
+	`, periodID).Scan(&status)
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "payroll.not_found")
+		return "", false
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return "", false
+	}
+	return status, true
+}
+
+// ListFlags returns the flagged payslips of a period with the reasons.
+// ?status= narrows to open, acknowledged or sent_back flags.
+func (h *PayrollHandler) ListFlags(c *gin.Context) {
+	periodID := c.Param("id")
+	if _, ok := h.payrollPeriodStatus(c, periodID); !ok {
+		return
+	}
+
+	query := `
+		SELECT f.id, f.payslip_id, ps.employee_id, ps.employee_code, ps.employee_name, ps.net_salary, ps.currency,
+		       f.rule, f.reason, f.details, f.status, f.reviewed_by, f.reviewed_at, COALESCE(f.review_notes, ''), f.created_at
+		FROM payslip_flags f
+		INNER JOIN payslips ps ON ps.id = f.payslip_id
+		WHERE f.payroll_period_id = $1`
+	args := []interface{}{periodID}
+	if status := c.Query("status"); status != "" {
+		query += " AND f.status = $2"
+		args = append(args, status)
+	}
+	query += " ORDER BY ps.employee_name, f.rule"
+
+	rows, err := h.db.QueryContext(c.Request.Context(), query, args...)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	flags := []dto.PayslipFlagResponse{}
+	for rows.Next() {
+		var f dto.PayslipFlagResponse
+		var details []byte
+		var reviewedBy uuid.NullUUID
+		var reviewedAt sql.NullTime
+		if err := rows.Scan(&f.ID, &f.PayslipID, &f.EmployeeID, &f.EmployeeCode, &f.EmployeeName, &f.NetSalary, &f.Currency,
+			&f.Rule, &f.Reason, &details, &f.Status, &reviewedBy, &reviewedAt, &f.ReviewNotes, &f.CreatedAt); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		if len(details) > 0 {
+			json.Unmarshal(details, &f.Details)
+		}
+		if reviewedBy.Valid {
+			f.ReviewedBy = &reviewedBy.UUID
+		}
+		if reviewedAt.Valid {
+			f.ReviewedAt = &reviewedAt.Time
+		}
+		flags = append(flags, f)
+	}
+
+	response.OK(c, "common.list", flags)
+}
+
+// Review acknowledges the open flags of payslips, or sends the payslips back
+// to be recalculated. Recalculation replaces their flags.
+func (h *PayrollHandler) Review(c *gin.Context) {
+	periodID := c.Param("id")
+	var req dto.ReviewPayrollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+	status, ok := h.payrollPeriodStatus(c, periodID)
+	if !ok {
+		return
+	}
+	if status != "pending" {
+		response.UnprocessableEntity(c, "payroll.invalid_status", map[string]string{"status": status})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+	var result dto.ReviewPayrollResponse
+	var recalculate []string
+	notFlagged := map[string]string{}
+
+	err := h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		for i, item := range req.Items {
+			newStatus := "acknowledged"
+			if item.Action == "send_back" {
+				newStatus = "sent_back"
+			}
+			res, err := tx.ExecContext(ctx, `
+				UPDATE payslip_flags SET status = $3, reviewed_by = $4, reviewed_at = NOW(), review_notes = $5
+				WHERE payslip_id = $1 AND payroll_period_id = $2 AND status = 'open'
+			`, item.PayslipID, periodID, newStatus, userID, nullIfEmpty(item.Notes))
+			if err != nil {
+				return err
+			}
+			if affected, _ := res.RowsAffected(); affected == 0 {
+				notFlagged["items."+strconv.Itoa(i)] = item.PayslipID
+				continue
+			}
+
+			if item.Action == "acknowledge" {
+				result.Acknowledged++
+				continue
+			}
+			var employeeID string
+			if err := tx.QueryRowContext(ctx, `
+				SELECT employee_id FROM payslips WHERE id = $1
+			`, item.PayslipID).Scan(&employeeID); err != nil {
+				return err
+			}
+			recalculate = append(recalculate, employeeID)
+			result.SentBack++
+		}
+		if len(notFlagged) > 0 {
+			return errPayslipNotFlagged
+		}
+		return nil
+	})
+	if errors.Is(err, errPayslipNotFlagged) {
+		response.UnprocessableEntity(c, "payroll.payslip_not_flagged", notFlagged)
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	for _, employeeID := range recalculate {
+		if _, err := h.queue.CalculatePayroll(ctx, queue.PayrollPayload{
+			PeriodID: periodID, EmployeeID: employeeID, Action: "recalculate",
+		}); err != nil {
+			h.log.WithError(err).WithField("employee_id", employeeID).Error("Failed to queue payslip recalculation")
+		}
+	}
+
+	h.db.QueryRowContext(ctx, unreviewedFlagsSQL, periodID).Scan(&result.Unreviewed)
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: userID, Action: "review", TableName: "payroll_periods", RecordID: periodID,
+		NewValues: req, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "payroll.reviewed", result)
+}
+
+// Approve approves a calculated period, confirms its payslips and marks the
+// leave encashments they pay as paid. With PAYROLL_REVIEW_REQUIRED,
+// unreviewed flags block approval.
+func (h *PayrollHandler) Approve(c *gin.Context) {
+	periodID := c.Param("id")
+	status, ok := h.payrollPeriodStatus(c, periodID)
+	if !ok {
+		return
+	}
+	if status != "pending" {
+		response.UnprocessableEntity(c, "payroll.invalid_status", map[string]string{"status": status})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	if h.cfg.Payroll.ReviewRequired {
+		var unreviewed int
+		if err := h.db.QueryRowContext(ctx, unreviewedFlagsSQL, periodID).Scan(&unreviewed); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		if unreviewed > 0 {
+			response.UnprocessableEntity(c, "payroll.review_pending", map[string]string{"unreviewed": strconv.Itoa(unreviewed)})
+			return
+		}
+	}
+
+	err := h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE payroll_periods SET status = 'approved', approved_by = $2, approved_at = NOW(), updated_at = NOW()
+			WHERE id = $1
+		`, periodID, userID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE payslips SET status = 'confirmed', updated_at = NOW()
+			WHERE payroll_period_id = $1 AND status = 'draft' AND deleted_at IS NULL
+		`, periodID); err != nil {
+			return err
+		}
+		// Leave encashments are paid by the payslips they were calculated into
+		_, err := tx.ExecContext(ctx, `
+			UPDATE leave_encashments le SET status = 'paid', updated_at = NOW()
+			FROM payslips ps
+			WHERE le.payroll_period_id = $1 AND le.status = 'pending'
+			  AND ps.payroll_period_id = le.payroll_period_id AND ps.employee_id = le.employee_id
+			  AND ps.status IN ('confirmed', 'paid') AND ps.deleted_at IS NULL
+		`, periodID)
+		return err
+	})
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: userID, Action: "approve", TableName: "payroll_periods", RecordID: periodID,
+		OldValues: gin.H{"status": status}, NewValues: gin.H{"status": "approved"},
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "payroll.approved", gin.H{"id": periodID, "status": "approved"})
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"hr-management-system/internal/testutil"
+
+	"github.com/google/uuid"
+)
+
+// Resending emails the employee, so payroll.view, which only reads, does not
+// allow it for someone else's payslip; payroll.manage and the owner do
+func TestSendPayslipEmailPermission(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewPayrollHandler(env.db, env.cache, env.queue, env.log, env.cfg)
+	employee := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{})
+	hr := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{DepartmentID: testutil.DepartmentHR})
+
+	var periodID, payslipID uuid.UUID
+	testutil.Must(t, env.db.QueryRow(`
+		INSERT INTO payroll_periods (name, year, month, start_date, end_date, pay_date)
+		VALUES ('03/2025', 2025, 3, '2025-03-01', '2025-03-31', '2025-04-05') RETURNING id
+	`).Scan(&periodID), "create payroll period")
+	testutil.Must(t, env.db.QueryRow(`
+		INSERT INTO payslips (employee_id, payroll_period_id, employee_code, employee_name, net_salary, status)
+		VALUES ($1, $2, 'T0001', 'Test', 15000000, 'confirmed') RETURNING id
+	`, employee.ID, periodID).Scan(&payslipID), "create payslip")
+
+	tests := []struct {
+		name   string
+		userID string
+		perms  []string
+		want   int
+	}{
+		{"payroll.view", hr.UserID, []string{"payroll.view"}, http.StatusForbidden},
+		{"payroll.manage", hr.UserID, []string{"payroll.view", "payroll.manage"}, http.StatusOK},
+		{"owner", employee.UserID, nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, recorder := testutil.Request(http.MethodPost, "/", nil, tt.userID, tt.perms...)
+			testutil.Param(c, "id", payslipID.String())
+			h.SendPayslipEmail(c)
+			testutil.ExpectStatus(t, recorder, tt.want)
+		})
+	}
+
+	// Reading stays open to payroll.view
+	c, recorder := testutil.Request(http.MethodGet, "/", nil, hr.UserID, "payroll.view")
+	testutil.Param(c, "id", payslipID.String())
+	h.PayslipPDF(c)
+	testutil.ExpectStatus(t, recorder, http.StatusOK)
+}
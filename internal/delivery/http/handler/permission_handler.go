@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"strings"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/i18n"
+	"hr-management-system/internal/infrastructure/cache"
+	"hr-management-system/internal/infrastructure/database"
+	"hr-management-system/internal/infrastructure/logger"
+	"hr-management-system/internal/infrastructure/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dangerousPermissions can destroy data, move money or widen access. Any
+// *.delete permission is dangerous as well.
+var dangerousPermissions = map[string]bool{
+	"roles.create":    true,
+	"roles.update":    true,
+	"payroll.approve": true,
+	"payroll.pay":     true,
+	"system.manage":   true,
+}
+
+type PermissionHandler struct {
+	db    *database.Database
+	cache *cache.RedisCache
+	queue *queue.Queue
+	log   *logger.Logger
+	cfg   *config.Config
+}
+
+func NewPermissionHandler(db *database.Database, cache *cache.RedisCache, queue *queue.Queue, log *logger.Logger, cfg *config.Config) *PermissionHandler {
+	return &PermissionHandler{db: db, cache: cache, queue: queue, log: log, cfg: cfg}
+}
+
+// Catalog returns every permission grouped by module for role editors, whatever
+// permissions the caller holds. Names come from the i18n keys
+// permissions.<slug> and permissions.<slug>.description, falling back to the
+// stored name and description.
+func (h *PermissionHandler) Catalog(c *gin.Context) {
+	ctx := c.Request.Context()
+	lang := middleware.GetLanguage(c)
+
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT id, slug, module, name, COALESCE(description, '')
+		FROM permissions
+		ORDER BY module, slug
+	`)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	modules := []dto.PermissionModuleResponse{}
+	for rows.Next() {
+		var item dto.PermissionCatalogItem
+		var module string
+		if err := rows.Scan(&item.ID, &item.Slug, &module, &item.Name, &item.Description); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		item.Name = translateOr(lang, "permissions."+item.Slug, item.Name)
+		item.Description = translateOr(lang, "permissions."+item.Slug+".description", item.Description)
+		item.Dangerous = dangerousPermissions[item.Slug] || strings.HasSuffix(item.Slug, ".delete")
+
+		if n := len(modules); n == 0 || modules[n-1].Module != module {
+			modules = append(modules, dto.PermissionModuleResponse{
+				Module:      module,
+				Name:        translateOr(lang, "permission_modules."+module, module),
+				Permissions: []dto.PermissionCatalogItem{},
+			})
+		}
+		last := &modules[len(modules)-1]
+		last.Permissions = append(last.Permissions, item)
+	}
+
+	response.OK(c, "common.success", modules)
+}
+
+// translateOr returns the translation of key, or fallback when there is none
+func translateOr(lang, key, fallback string) string {
+	if msg := i18n.T(lang, key); msg != key {
+		return msg
+	}
+	return fallback
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"strings"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/cache"
+	"hr-management-system/internal/infrastructure/database"
+	"hr-management-system/internal/infrastructure/logger"
+	"hr-management-system/internal/infrastructure/queue"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type PositionHandler struct {
+	db    *database.Database
+	cache *cache.RedisCache
+	queue *queue.Queue
+	log   *logger.Logger
+	cfg   *config.Config
+}
+
+func NewPositionHandler(db *database.Database, cache *cache.RedisCache, queue *queue.Queue, log *logger.Logger, cfg *config.Config) *PositionHandler {
+	return &PositionHandler{db: db, cache: cache, queue: queue, log: log, cfg: cfg}
+}
+
+// Batch returns the positions for a list of ids in one query, in request order
+func (h *PositionHandler) Batch(c *gin.Context) {
+	var req dto.BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT p.id, p.name, p.code, COALESCE(p.description, ''), COALESCE(p.level, 1),
+		       COALESCE(p.min_salary, 0), COALESCE(p.max_salary, 0), p.status, p.created_at,
+		       (SELECT COUNT(*) FROM employees e WHERE e.position_id = p.id AND e.deleted_at IS NULL)
+		FROM positions p
+		WHERE p.id = ANY($1::uuid[]) AND p.deleted_at IS NULL
+	`, pq.Array(req.IDs))
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	byID := make(map[string]dto.PositionResponse, len(req.IDs))
+	for rows.Next() {
+		var pos dto.PositionResponse
+		if err := rows.Scan(&pos.ID, &pos.Name, &pos.Code, &pos.Description, &pos.Level,
+			&pos.MinSalary, &pos.MaxSalary, &pos.Status, &pos.CreatedAt, &pos.EmployeeCount); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		byID[pos.ID.String()] = pos
+	}
+
+	positions := make([]dto.PositionResponse, 0, len(byID))
+	for _, id := range uniqueStrings(req.IDs) {
+		if pos, ok := byID[strings.ToLower(id)]; ok {
+			positions = append(positions, pos)
+			delete(byID, pos.ID.String())
+		}
+	}
+
+	response.OK(c, "common.list", positions)
+}
@@ -0,0 +1,514 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/database"
+	"hr-management-system/internal/infrastructure/queue"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Work modes of an attendance day. Employees are "office", "remote" or
+// "hybrid"; a hybrid employee works remotely on approved remote days only.
+const (
+	workModeOffice = "office"
+	workModeRemote = "remote"
+)
+
+// maxRemoteRequestDays bounds the range of a single remote work request
+const maxRemoteRequestDays = 31
+
+var errRemoteOverlap = errors.New("remote work request overlaps an open request")
+
+// remoteCapError is returned when a request would exceed the monthly cap
+type remoteCapError struct {
+	month string
+}
+
+func (e *remoteCapError) Error() string {
+	return "remote day cap exceeded in " + e.month
+}
+
+// remoteDaySQL is true when employee e works remotely on the date in $2
+const remoteDaySQL = `(e.work_mode = 'remote' OR EXISTS (
+			SELECT 1 FROM remote_work_requests rw
+			WHERE rw.employee_id = e.id AND rw.status = 'approved'
+			  AND $2::date BETWEEN rw.start_date AND rw.end_date
+		))`
+
+// workModeOn returns where the employee is expected to work on a day
+func workModeOn(ctx context.Context, db *database.Database, employeeID uuid.UUID, date string) (string, error) {
+	var remote bool
+	err := db.QueryRowContext(ctx, `SELECT `+remoteDaySQL+` FROM employees e WHERE e.id = $1`, employeeID, date).Scan(&remote)
+	if err != nil {
+		return "", err
+	}
+	if remote {
+		return workModeRemote, nil
+	}
+	return workModeOffice, nil
+}
+
+// inNetworks reports whether ip is in one of the CIDRs; an empty list allows
+// every address
+func inNetworks(ip string, cidrs []string) bool {
+	if len(cidrs) == 0 {
+		return true
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, cidr := range cidrs {
+		if _, network, err := net.ParseCIDR(cidr); err == nil && network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// expectedHours is how long the employee is expected to work on a day: the
+// remote policy on remote days, otherwise the assigned or default shift
+func (h *AttendanceHandler) expectedHours(ctx context.Context, employeeID uuid.UUID, date, workMode string) (float64, error) {
+	if workMode == workModeRemote {
+		return h.cfg.Attendance.RemoteExpectedHours, nil
+	}
+	var hours float64
+	err := h.db.QueryRowContext(ctx, `
+		SELECT ws.working_hours FROM employee_shifts es
+		INNER JOIN work_shifts ws ON ws.id = es.shift_id
+		WHERE es.employee_id = $1 AND es.date = $2
+	`, employeeID, date).Scan(&hours)
+	if err == nil {
+		return hours, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+	window, err := parseShiftWindow(h.cfg.Attendance.DefaultShiftStart, h.cfg.Attendance.DefaultShiftEnd)
+	if err != nil {
+		return 0, err
+	}
+	return (window.end - window.start).Hours(), nil
+}
+
+// remoteWorkingDays returns the dates in range that are neither weekends nor
+// holidays
+func remoteWorkingDays(start, end time.Time, holidays map[string]bool) []time.Time {
+	var days []time.Time
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday || holidays[d.Format("2006-01-02")] {
+			continue
+		}
+		days = append(days, d)
+	}
+	return days
+}
+
+// RequestRemote declares remote days for the caller. Requests need the
+// configured notice, may not overlap an open request and count toward the
+// monthly remote day cap while pending or approved.
+func (h *AttendanceHandler) RequestRemote(c *gin.Context) {
+	var req dto.CreateRemoteWorkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+	policy := h.cfg.Attendance
+
+	var employeeID uuid.UUID
+	var workMode string
+	err := h.db.QueryRowContext(ctx, `
+		SELECT id, work_mode FROM employees WHERE user_id = $1 AND deleted_at IS NULL
+	`, userID).Scan(&employeeID, &workMode)
+	if err != nil {
+		response.NotFound(c, "employee.not_found")
+		return
+	}
+	if workMode == workModeRemote {
+		response.UnprocessableEntity(c, "attendance.remote_by_default", nil)
+		return
+	}
+
+	startDate, _ := time.Parse("2006-01-02", req.StartDate)
+	endDate, _ := time.Parse("2006-01-02", req.EndDate)
+	if endDate.Before(startDate) || endDate.Sub(startDate) >= maxRemoteRequestDays*24*time.Hour {
+		response.BadRequest(c, "attendance.remote_invalid_range", map[string]string{"max_days": strconv.Itoa(maxRemoteRequestDays)})
+		return
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if startDate.Before(today.AddDate(0, 0, policy.RemoteMinNoticeDays)) {
+		response.UnprocessableEntity(c, "attendance.remote_notice_required", map[string]string{"min_notice_days": strconv.Itoa(policy.RemoteMinNoticeDays)})
+		return
+	}
+
+	// The cap is per calendar month, so every month the request touches is
+	// checked in full
+	monthStart := time.Date(startDate.Year(), startDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := time.Date(endDate.Year(), endDate.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+	holidays, err := loadHolidayDates(ctx, h.db, monthStart, monthEnd)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	days := remoteWorkingDays(startDate, endDate, holidays)
+	if len(days) == 0 {
+		response.UnprocessableEntity(c, "attendance.remote_no_working_days", nil)
+		return
+	}
+
+	requestID := uuid.New()
+	err = h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		// Serializes concurrent requests of the same employee
+		if _, err := tx.ExecContext(ctx, `SELECT id FROM employees WHERE id = $1 FOR UPDATE`, employeeID); err != nil {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx, `
+			SELECT start_date, end_date FROM remote_work_requests
+			WHERE employee_id = $1 AND status IN ('pending', 'approved')
+			  AND start_date <= $3 AND end_date >= $2
+		`, employeeID, monthStart, monthEnd)
+		if err != nil {
+			return err
+		}
+		perMonth := make(map[string]int)
+		overlap := false
+		for rows.Next() {
+			var from, to time.Time
+			if err := rows.Scan(&from, &to); err != nil {
+				rows.Close()
+				return err
+			}
+			if !from.After(endDate) && !to.Before(startDate) {
+				overlap = true
+			}
+			for _, d := range remoteWorkingDays(from, to, holidays) {
+				perMonth[d.Format("2006-01")]++
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if overlap {
+			return errRemoteOverlap
+		}
+
+		if policy.RemoteMaxDaysPerMonth > 0 {
+			for _, d := range days {
+				month := d.Format("2006-01")
+				perMonth[month]++
+				if perMonth[month] > policy.RemoteMaxDaysPerMonth {
+					return &remoteCapError{month: month}
+				}
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO remote_work_requests (id, employee_id, start_date, end_date, total_days, reason, status, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, 'pending', NOW(), NOW())
+		`, requestID, employeeID, req.StartDate, req.EndDate, len(days), nullIfEmpty(req.Reason))
+		return err
+	})
+	var capErr *remoteCapError
+	if errors.Is(err, errRemoteOverlap) {
+		response.Conflict(c, "attendance.remote_overlap")
+		return
+	}
+	if errors.As(err, &capErr) {
+		response.UnprocessableEntity(c, "attendance.remote_cap_exceeded", map[string]string{
+			"month":    capErr.month,
+			"max_days": strconv.Itoa(policy.RemoteMaxDaysPerMonth),
+		})
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: userID, Action: "create", TableName: "remote_work_requests", RecordID: requestID.String(),
+		NewValues: req, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	route, err := resolveApprovalRoute(ctx, h.db, employeeID, "attendance")
+	if err != nil {
+		h.log.WithError(err).Error("Failed to route remote work request for approval")
+	} else {
+		notifyApprovers(ctx, h.queue, route, "Đăng ký làm việc từ xa chờ duyệt",
+			fmt.Sprintf("%s đăng ký làm việc từ xa %d ngày từ %s đến %s.", route.EmployeeName, len(days),
+				startDate.Format("02/01/2006"), endDate.Format("02/01/2006")),
+			"remote_work_approval_request", map[string]interface{}{
+				"remote_work_request_id": requestID.String(),
+				"employee_id":            employeeID.String(),
+			})
+	}
+
+	response.Created(c, "attendance.remote_requested", gin.H{
+		"id":         requestID,
+		"start_date": req.StartDate,
+		"end_date":   req.EndDate,
+		"total_days": len(days),
+		"status":     "pending",
+	})
+}
+
+// ListMyRemote lists the caller's remote work requests
+func (h *AttendanceHandler) ListMyRemote(c *gin.Context) {
+	var filter dto.RemoteWorkFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+	var employeeID uuid.UUID
+	err := h.db.QueryRowContext(c.Request.Context(), `
+		SELECT id FROM employees WHERE user_id = $1 AND deleted_at IS NULL
+	`, middleware.GetUserID(c)).Scan(&employeeID)
+	if err != nil {
+		response.NotFound(c, "employee.not_found")
+		return
+	}
+	filter.EmployeeID = employeeID.String()
+	filter.DepartmentID = ""
+	h.listRemote(c, filter)
+}
+
+// ListRemote lists remote work requests for managers and HR
+func (h *AttendanceHandler) ListRemote(c *gin.Context) {
+	var filter dto.RemoteWorkFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+	h.listRemote(c, filter)
+}
+
+func (h *AttendanceHandler) listRemote(c *gin.Context, filter dto.RemoteWorkFilter) {
+	ctx := c.Request.Context()
+	pagination := database.NewPagination(filter.Page, filter.PageSize, middleware.GetPermissions(c), "attendance.export", &h.cfg.Database)
+	pagination.SetCountMode(c.Query("count"), h.cfg.Database.CountModeFor("remote_work_requests"))
+
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.EmployeeID != "" {
+		add("rw.employee_id = $%d", filter.EmployeeID)
+	}
+	if filter.DepartmentID != "" {
+		add("e.department_id = $%d", filter.DepartmentID)
+	}
+	if filter.Status != "" {
+		add("rw.status = $%d", filter.Status)
+	}
+	if filter.StartDate != "" {
+		add("rw.end_date >= $%d", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		add("rw.start_date <= $%d", filter.EndDate)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	from := `
+		FROM remote_work_requests rw
+		INNER JOIN employees e ON e.id = rw.employee_id
+		LEFT JOIN employees ap ON ap.id = rw.approved_by` + where
+
+	if err := h.db.Count(ctx, pagination, "remote_work_requests", `SELECT COUNT(*)`+from, args...); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	args = append(args, pagination.FetchLimit(), pagination.GetOffset())
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT rw.id, rw.employee_id, e.full_name, rw.start_date, rw.end_date, rw.total_days,
+		       COALESCE(rw.reason, ''), rw.status, rw.approved_by, COALESCE(ap.full_name, ''),
+		       rw.approved_at, COALESCE(rw.rejection_reason, ''), rw.created_at`+from+
+		fmt.Sprintf(" ORDER BY rw.start_date DESC, rw.created_at DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args)),
+		args...)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	requests := []dto.RemoteWorkResponse{}
+	for rows.Next() {
+		var r dto.RemoteWorkResponse
+		var approvedBy uuid.NullUUID
+		var approvedAt sql.NullTime
+		if err := rows.Scan(&r.ID, &r.EmployeeID, &r.EmployeeName, &r.StartDate, &r.EndDate, &r.TotalDays,
+			&r.Reason, &r.Status, &approvedBy, &r.ApproverName, &approvedAt, &r.RejectionReason, &r.CreatedAt); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		if approvedBy.Valid {
+			r.ApprovedBy = &approvedBy.UUID
+		}
+		if approvedAt.Valid {
+			r.ApprovedAt = &approvedAt.Time
+		}
+		requests = append(requests, r)
+	}
+	requests = database.TrimPage(pagination, requests)
+
+	response.OKWithMeta(c, "common.list", requests, pagination)
+}
+
+// ApproveRemote approves or rejects a pending remote work request. Only the
+// employee's routed approvers or holders of attendance.manage may act.
+func (h *AttendanceHandler) ApproveRemote(c *gin.Context) {
+	var req dto.ApproveRemoteWorkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	id := c.Param("id")
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	var employeeID uuid.UUID
+	var employeeUserID, status string
+	var startDate, endDate time.Time
+	err := h.db.QueryRowContext(ctx, `
+		SELECT rw.employee_id, e.user_id, rw.status, rw.start_date, rw.end_date
+		FROM remote_work_requests rw
+		INNER JOIN employees e ON e.id = rw.employee_id
+		WHERE rw.id::text = $1
+	`, id).Scan(&employeeID, &employeeUserID, &status, &startDate, &endDate)
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "attendance.remote_not_found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	if key, err := authorizeApproval(ctx, h.db, c, employeeID, "attendance", h.cfg.Security.AllowSelfApproval); err != nil {
+		response.InternalError(c, err)
+		return
+	} else if key != "" {
+		response.Forbidden(c, key)
+		return
+	}
+
+	var approverID interface{}
+	var approverEmployeeID uuid.UUID
+	if err := h.db.QueryRowContext(ctx, `SELECT id FROM employees WHERE user_id = $1 AND deleted_at IS NULL`, userID).Scan(&approverEmployeeID); err == nil {
+		approverID = approverEmployeeID
+	}
+
+	// The status guard makes a concurrent second decision a no-op
+	var rejectionReason interface{}
+	if req.Status == "rejected" {
+		rejectionReason = nullIfEmpty(req.Notes)
+	}
+	result, err := h.db.ExecContext(ctx, `
+		UPDATE remote_work_requests
+		SET status = $1, approved_by = $2, approved_at = NOW(), rejection_reason = $3, updated_at = NOW()
+		WHERE id = $4 AND status = 'pending'
+	`, req.Status, approverID, rejectionReason, id)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		response.UnprocessableEntity(c, "attendance.remote_not_pending", map[string]string{"status": status})
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: userID, Action: req.Status, TableName: "remote_work_requests", RecordID: id,
+		OldValues: gin.H{"status": status}, NewValues: req,
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	title, verb := "Đăng ký làm việc từ xa được duyệt", "đã được duyệt"
+	if req.Status == "rejected" {
+		title, verb = "Đăng ký làm việc từ xa bị từ chối", "đã bị từ chối"
+	}
+	h.queue.SendNotification(ctx, queue.NotificationPayload{
+		UserID:  employeeUserID,
+		Title:   title,
+		Message: fmt.Sprintf("Đăng ký làm việc từ xa từ %s đến %s %s.", startDate.Format("02/01/2006"), endDate.Format("02/01/2006"), verb),
+		Type:    "remote_work_" + req.Status,
+		Data:    map[string]interface{}{"remote_work_request_id": id},
+	})
+
+	messageKey := "attendance.remote_approved"
+	if req.Status == "rejected" {
+		messageKey = "attendance.remote_rejected"
+	}
+	response.OK(c, messageKey, gin.H{"id": id, "status": req.Status})
+}
+
+// CancelRemote withdraws the caller's own request while it is pending, or
+// before its first day once approved
+func (h *AttendanceHandler) CancelRemote(c *gin.Context) {
+	id := c.Param("id")
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	var status string
+	var startDate time.Time
+	err := h.db.QueryRowContext(ctx, `
+		SELECT rw.status, rw.start_date FROM remote_work_requests rw
+		INNER JOIN employees e ON e.id = rw.employee_id
+		WHERE rw.id::text = $1 AND e.user_id = $2
+	`, id, userID).Scan(&status, &startDate)
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "attendance.remote_not_found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	result, err := h.db.ExecContext(ctx, `
+		UPDATE remote_work_requests SET status = 'cancelled', updated_at = NOW()
+		WHERE id = $1 AND (status = 'pending' OR (status = 'approved' AND start_date > CURRENT_DATE))
+	`, id)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		response.UnprocessableEntity(c, "attendance.remote_not_cancellable", map[string]string{"status": status})
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: userID, Action: "cancel", TableName: "remote_work_requests", RecordID: id,
+		OldValues: gin.H{"status": status}, NewValues: gin.H{"status": "cancelled"},
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "attendance.remote_cancelled", nil)
+}
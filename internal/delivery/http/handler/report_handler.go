@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/cache"
+	"hr-management-system/internal/infrastructure/database"
+	"hr-management-system/internal/infrastructure/logger"
+	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/infrastructure/storage"
+	"hr-management-system/internal/report"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTrendMonths bounds the range of trend reports
+const maxTrendMonths = 60
+
+type ReportHandler struct {
+	db    *database.Database
+	cache *cache.RedisCache
+	queue *queue.Queue
+	store storage.Backend
+	log   *logger.Logger
+	cfg   *config.Config
+}
+
+func NewReportHandler(db *database.Database, cache *cache.RedisCache, queue *queue.Queue, store storage.Backend, log *logger.Logger, cfg *config.Config) *ReportHandler {
+	return &ReportHandler{db: db, cache: cache, queue: queue, store: store, log: log, cfg: cfg}
+}
+
+// Formats lists the export formats reports can be generated in
+func (h *ReportHandler) Formats(c *gin.Context) {
+	names := report.Formats()
+	formats := make([]dto.ReportFormatResponse, 0, len(names))
+	for _, name := range names {
+		f, _ := report.Lookup(name)
+		formats = append(formats, dto.ReportFormatResponse{
+			Name: f.Name(), ContentType: f.ContentType(), Extension: f.Extension(),
+		})
+	}
+	response.OK(c, "common.list", formats)
+}
+
+// HeadcountTrend returns the monthly headcount series of a department subtree.
+// Months with a captured snapshot use it; other months are computed from
+// join and resignation dates.
+func (h *ReportHandler) HeadcountTrend(c *gin.Context) {
+	ctx := c.Request.Context()
+	now := time.Now()
+
+	from, err1 := time.Parse("2006-01", c.DefaultQuery("from", now.AddDate(0, -11, 0).Format("2006-01")))
+	to, err2 := time.Parse("2006-01", c.DefaultQuery("to", now.Format("2006-01")))
+	if err1 != nil || err2 != nil || to.Before(from) || from.AddDate(0, maxTrendMonths, 0).Before(to) {
+		response.BadRequest(c, "validation.date_format", nil)
+		return
+	}
+
+	scope := "d.deleted_at IS NULL"
+	args := []interface{}{from.Format("2006-01-02"), to.Format("2006-01-02")}
+
+	if departmentID := c.Query("department_id"); departmentID != "" {
+		var path sql.NullString
+		err := h.db.QueryRowContext(ctx, `
+			SELECT path FROM departments WHERE id = $1 AND deleted_at IS NULL
+		`, departmentID).Scan(&path)
+		if err != nil {
+			response.NotFound(c, "department.not_found")
+			return
+		}
+		scope += " AND (d.id = $3"
+		args = append(args, departmentID)
+		if path.Valid && path.String != "" {
+			scope += " OR d.path LIKE $4"
+			args = append(args, path.String+"/%")
+		}
+		scope += ")"
+	}
+
+	query := fmt.Sprintf(`
+		WITH months AS (
+			SELECT month_start::date AS month_start,
+			       (month_start + INTERVAL '1 month' - INTERVAL '1 day')::date AS month_end
+			FROM generate_series($1::date, $2::date, INTERVAL '1 month') AS month_start
+		), scope AS (
+			SELECT d.id FROM departments d WHERE %s
+		)
+		SELECT TO_CHAR(m.month_start, 'YYYY-MM'),
+			(SELECT SUM(hs.headcount) FROM headcount_snapshots hs
+			 WHERE hs.department_id IN (SELECT id FROM scope) AND hs.snapshot_date = m.month_end),
+			(SELECT COUNT(*) FROM employees e
+			 WHERE e.deleted_at IS NULL AND e.department_id IN (SELECT id FROM scope)
+			   AND e.join_date <= m.month_end
+			   AND (e.resignation_date IS NULL OR e.resignation_date > m.month_end)),
+			(SELECT COUNT(*) FROM employees e
+			 WHERE e.deleted_at IS NULL AND e.department_id IN (SELECT id FROM scope)
+			   AND e.join_date BETWEEN m.month_start AND m.month_end),
+			(SELECT COUNT(*) FROM employees e
+			 WHERE e.deleted_at IS NULL AND e.department_id IN (SELECT id FROM scope)
+			   AND e.resignation_date BETWEEN m.month_start AND m.month_end)
+		FROM months m
+		ORDER BY m.month_start`, scope)
+
+	rows, err := h.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	trend := []dto.HeadcountTrendPoint{}
+	for rows.Next() {
+		var point dto.HeadcountTrendPoint
+		var snapshot sql.NullInt64
+		var computed int
+
+		rows.Scan(&point.Month, &snapshot, &computed, &point.Joiners, &point.Leavers)
+
+		point.Headcount = computed
+		point.Source = "computed"
+		if snapshot.Valid {
+			point.Headcount = int(snapshot.Int64)
+			point.Source = "snapshot"
+		}
+		point.NetChange = point.Joiners - point.Leavers
+		trend = append(trend, point)
+	}
+
+	response.OK(c, "common.success", trend)
+}
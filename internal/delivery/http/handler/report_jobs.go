@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/domain/entity"
+	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/infrastructure/storage"
+	"hr-management-system/internal/report"
+	"hr-management-system/internal/security"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Generate queues a report for the worker. start_date and end_date join the
+// other filters; send_email mails the file to the caller, or to email when
+// it is given. The report's progress is read from Status.
+func (h *ReportHandler) Generate(c *gin.Context) {
+	var req dto.ReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+	if !h.canViewReport(c, req.ReportType) {
+		response.Forbidden(c, "permission.denied")
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := middleware.GetUserID(c)
+
+	filters := map[string]string{}
+	for key, value := range req.Filters {
+		filters[key] = value
+	}
+	if req.StartDate != "" {
+		filters["start_date"] = req.StartDate
+	}
+	if req.EndDate != "" {
+		filters["end_date"] = req.EndDate
+	}
+
+	to := req.Email
+	if to == "" && req.SendEmail {
+		if err := h.db.QueryRowContext(ctx, `SELECT email FROM users WHERE id = $1`, userID).Scan(&to); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+	}
+
+	result := dto.ReportResponse{
+		ID: uuid.New(), ReportType: req.ReportType, Format: req.Format, Filters: filters, Status: "pending", Email: to,
+	}
+	filtersJSON, _ := json.Marshal(filters)
+	err := h.db.QueryRowContext(ctx, `
+		INSERT INTO reports (id, report_type, format, filters, status, email, requested_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, 'pending', $5, $6, NOW(), NOW())
+		RETURNING created_at
+	`, result.ID, req.ReportType, req.Format, filtersJSON, nullIfEmpty(to), userID).Scan(&result.CreatedAt)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	payloadFilters := make(map[string]interface{}, len(filters))
+	for key, value := range filters {
+		payloadFilters[key] = value
+	}
+	info, err := h.queue.GenerateReport(ctx, queue.ReportPayload{
+		ReportID: result.ID.String(), ReportType: req.ReportType, Format: req.Format,
+		Filters: payloadFilters, RequestedBy: userID, Email: to,
+	})
+	if err != nil {
+		h.db.ExecContext(ctx, `UPDATE reports SET status = 'failed', error = $2, updated_at = NOW() WHERE id = $1`, result.ID, err.Error())
+		response.InternalError(c, err)
+		return
+	}
+	result.TaskID = info.ID
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: userID, Action: "generate", TableName: "reports", RecordID: result.ID.String(),
+		NewValues: req, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.Created(c, "report.queued", result)
+}
+
+// Status returns the progress of a report and, once it is completed, the
+// link to download it
+func (h *ReportHandler) Status(c *gin.Context) {
+	result, _, ok := h.loadReport(c)
+	if !ok {
+		return
+	}
+	response.OK(c, "common.success", result)
+}
+
+// Download streams a completed report's file. A report that is not ready,
+// or whose file is gone, is not found.
+func (h *ReportHandler) Download(c *gin.Context) {
+	result, key, ok := h.loadReport(c)
+	if !ok {
+		return
+	}
+	if result.Status != string(entity.ReportStatusCompleted) {
+		response.NotFound(c, "report.not_ready")
+		return
+	}
+
+	file, err := h.store.Get(c.Request.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		response.NotFound(c, "file.not_found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer file.Close()
+
+	contentType := "application/octet-stream"
+	if formatter, ok := report.Lookup(result.Format); ok {
+		contentType = formatter.ContentType()
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", result.FileName))
+	c.Header("Cache-Control", "private, no-store")
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, file); err != nil {
+		h.log.WithError(err).WithField("report_id", result.ID).Warn("Failed to stream report")
+	}
+}
+
+// loadReport reads the report named by :id along with its storage key,
+// answering the request itself when it is missing or the caller may not see
+// its type
+func (h *ReportHandler) loadReport(c *gin.Context) (dto.ReportResponse, string, bool) {
+	var result dto.ReportResponse
+	var filters []byte
+	var fileKey, fileName, reportError, email sql.NullString
+	var startedAt, completedAt sql.NullTime
+	err := h.db.QueryRowContext(c.Request.Context(), `
+		SELECT id, report_type, format, filters, status, error, file_key, file_name, file_size, row_count,
+		       email, COALESCE(task_id, ''), created_at, started_at, completed_at
+		FROM reports WHERE id::text = $1
+	`, c.Param("id")).Scan(&result.ID, &result.ReportType, &result.Format, &filters, &result.Status,
+		&reportError, &fileKey, &fileName, &result.FileSize, &result.RowCount,
+		&email, &result.TaskID, &result.CreatedAt, &startedAt, &completedAt)
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "report.not_found")
+		return result, "", false
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return result, "", false
+	}
+	if !h.canViewReport(c, result.ReportType) {
+		response.Forbidden(c, "permission.denied")
+		return result, "", false
+	}
+
+	// Scheduled reports store filters as any JSON value
+	var raw map[string]interface{}
+	if json.Unmarshal(filters, &raw) == nil && len(raw) > 0 {
+		result.Filters = make(map[string]string, len(raw))
+		for key, value := range raw {
+			result.Filters[key] = fmt.Sprint(value)
+		}
+	}
+	result.Error = reportError.String
+	result.FileName = fileName.String
+	result.Email = email.String
+	if startedAt.Valid {
+		result.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		result.CompletedAt = &completedAt.Time
+	}
+	if result.Status == string(entity.ReportStatusCompleted) {
+		result.FileURL = fmt.Sprintf("/api/v1/reports/%s/download", result.ID)
+	}
+	return result, fileKey.String, true
+}
+
+// canViewReport keeps salary figures to callers who may see payroll
+func (h *ReportHandler) canViewReport(c *gin.Context, reportType string) bool {
+	if reportType == report.TypePayroll {
+		return security.HasPermission(middleware.GetPermissions(c), "payroll.view")
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"fmt"
+
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListResetTokens lists a user's password reset links that could still be
+// used, for support checking on a reset link that may have leaked
+func (h *AuthHandler) ListResetTokens(c *gin.Context) {
+	userID := c.Param("id")
+	if !h.requireUser(c, userID) {
+		return
+	}
+
+	rows, err := h.db.QueryContext(c.Request.Context(), `
+		SELECT id, created_at, expires_at FROM password_reset_tokens
+		WHERE user_id = $1 AND used_at IS NULL AND expires_at > NOW() AND deleted_at IS NULL
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	tokens := []dto.ResetTokenResponse{}
+	for rows.Next() {
+		var t dto.ResetTokenResponse
+		if err := rows.Scan(&t.ID, &t.CreatedAt, &t.ExpiresAt); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		tokens = append(tokens, t)
+	}
+	if err := rows.Err(); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OK(c, "common.list", tokens)
+}
+
+// RevokeResetTokens invalidates every outstanding password reset link of a
+// user. The links are marked used, so they fail like a spent link would.
+func (h *AuthHandler) RevokeResetTokens(c *gin.Context) {
+	userID := c.Param("id")
+	if !h.requireUser(c, userID) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	result, err := h.db.ExecContext(ctx, `
+		UPDATE password_reset_tokens SET used_at = NOW(), updated_at = NOW()
+		WHERE user_id = $1 AND used_at IS NULL AND expires_at > NOW() AND deleted_at IS NULL
+	`, userID)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	revoked, _ := result.RowsAffected()
+
+	currentUserID := middleware.GetUserID(c)
+	h.log.LogSecurityEvent("reset_tokens_revoked", currentUserID, c.ClientIP(),
+		fmt.Sprintf("revoked %d password reset tokens of user %s", revoked, userID))
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: currentUserID, Action: "revoke_reset_tokens", TableName: "password_reset_tokens", RecordID: userID,
+		NewValues: gin.H{"revoked": revoked}, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "auth.reset_tokens_revoked", gin.H{"revoked": revoked})
+}
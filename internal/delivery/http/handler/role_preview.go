@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"strings"
+
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// rolePreviewSampleSize bounds the affected users listed by a preview
+const rolePreviewSampleSize = 10
+
+// PreviewPermissions shows what saving a role with the proposed permission
+// set would change: permissions added and removed, and which members would
+// gain or lose them. Nothing is persisted.
+func (h *PermissionHandler) PreviewPermissions(c *gin.Context) {
+	roleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.NotFound(c, "role.not_found")
+		return
+	}
+	var req dto.PreviewRolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	lang := middleware.GetLanguage(c)
+	proposed := uniqueStrings(req.PermissionIDs)
+
+	var exists bool
+	if err := h.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM roles WHERE id = $1 AND deleted_at IS NULL)
+	`, roleID).Scan(&exists); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if !exists {
+		response.NotFound(c, "role.not_found")
+		return
+	}
+
+	// Every proposed and current permission, flagged by which side holds it
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT p.id, p.slug, p.name, COALESCE(p.description, ''),
+		       p.id = ANY($2::uuid[]),
+		       EXISTS (SELECT 1 FROM role_permissions rp WHERE rp.role_id = $1 AND rp.permission_id = p.id)
+		FROM permissions p
+		WHERE p.deleted_at IS NULL
+		  AND (p.id = ANY($2::uuid[]) OR p.id IN (SELECT permission_id FROM role_permissions WHERE role_id = $1))
+		ORDER BY p.module, p.slug
+	`, roleID, pq.Array(proposed))
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	preview := dto.RolePermissionPreview{
+		RoleID: roleID, Added: []dto.PermissionCatalogItem{}, Removed: []dto.PermissionCatalogItem{},
+		SampleUsers: []dto.RolePreviewUser{},
+	}
+	found := make(map[uuid.UUID]bool, len(proposed))
+	var addedIDs, removedIDs []string
+	for rows.Next() {
+		var item dto.PermissionCatalogItem
+		var inProposed, inCurrent bool
+		if err := rows.Scan(&item.ID, &item.Slug, &item.Name, &item.Description, &inProposed, &inCurrent); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		if inProposed {
+			found[item.ID] = true
+		}
+		item.Name = translateOr(lang, "permissions."+item.Slug, item.Name)
+		item.Description = translateOr(lang, "permissions."+item.Slug+".description", item.Description)
+		item.Dangerous = dangerousPermissions[item.Slug] || strings.HasSuffix(item.Slug, ".delete")
+		switch {
+		case inProposed && !inCurrent:
+			preview.Added = append(preview.Added, item)
+			addedIDs = append(addedIDs, item.ID.String())
+		case inCurrent && !inProposed:
+			preview.Removed = append(preview.Removed, item)
+			removedIDs = append(removedIDs, item.ID.String())
+		}
+	}
+	if err := rows.Err(); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	unknown := map[string]string{}
+	for _, id := range proposed {
+		if !found[uuid.MustParse(id)] {
+			unknown[id] = "not_found"
+		}
+	}
+	if len(unknown) > 0 {
+		response.UnprocessableEntity(c, "role.unknown_permissions", unknown)
+		return
+	}
+
+	// A member gains an added permission, or loses a removed one, only when
+	// no other live role of theirs grants it
+	userRows, err := h.db.QueryContext(ctx, `
+		WITH members AS (
+			SELECT u.id, u.email, COALESCE(e.full_name, '') AS full_name
+			FROM user_roles ur
+			INNER JOIN users u ON u.id = ur.user_id AND u.deleted_at IS NULL
+			LEFT JOIN employees e ON e.user_id = u.id AND e.deleted_at IS NULL
+			WHERE ur.role_id = $1
+		),
+		changes AS (
+			SELECT m.id, m.email, m.full_name,
+			       ARRAY(SELECT p.slug FROM permissions p
+			             WHERE p.id = ANY($2::uuid[]) AND NOT EXISTS (
+			                 SELECT 1 FROM user_roles ur
+			                 INNER JOIN roles r ON r.id = ur.role_id AND r.deleted_at IS NULL
+			                 INNER JOIN role_permissions rp ON rp.role_id = ur.role_id
+			                 WHERE ur.user_id = m.id AND ur.role_id <> $1 AND rp.permission_id = p.id)
+			             ORDER BY p.slug) AS gained,
+			       ARRAY(SELECT p.slug FROM permissions p
+			             WHERE p.id = ANY($3::uuid[]) AND NOT EXISTS (
+			                 SELECT 1 FROM user_roles ur
+			                 INNER JOIN roles r ON r.id = ur.role_id AND r.deleted_at IS NULL
+			                 INNER JOIN role_permissions rp ON rp.role_id = ur.role_id
+			                 WHERE ur.user_id = m.id AND ur.role_id <> $1 AND rp.permission_id = p.id)
+			             ORDER BY p.slug) AS lost
+			FROM members m
+		)
+		SELECT (SELECT COUNT(*) FROM members),
+		       COUNT(*) OVER (),
+		       id, email, full_name, gained, lost
+		FROM changes
+		WHERE cardinality(gained) > 0 OR cardinality(lost) > 0
+		ORDER BY full_name, email
+		LIMIT $4
+	`, roleID, pq.Array(addedIDs), pq.Array(removedIDs), rolePreviewSampleSize)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer userRows.Close()
+
+	for userRows.Next() {
+		var user dto.RolePreviewUser
+		if err := userRows.Scan(&preview.MemberCount, &preview.AffectedUsers, &user.UserID, &user.Email, &user.FullName,
+			pq.Array(&user.Gained), pq.Array(&user.Lost)); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		preview.SampleUsers = append(preview.SampleUsers, user)
+	}
+	if err := userRows.Err(); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	// Without affected users no row carries the member count
+	if len(preview.SampleUsers) == 0 {
+		if err := h.db.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM user_roles ur
+			INNER JOIN users u ON u.id = ur.user_id AND u.deleted_at IS NULL
+			WHERE ur.role_id = $1
+		`, roleID).Scan(&preview.MemberCount); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+	}
+
+	response.OK(c, "common.success", preview)
+}
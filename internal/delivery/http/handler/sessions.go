@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"context"
+
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/security"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListSessions returns the active sessions of the current user, one per
+// signed-in device, flagging the one making the request
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	ctx := c.Request.Context()
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT id, COALESCE(session_id, ''), COALESCE(user_agent, ''), COALESCE(ip_address, ''),
+		       last_activity, expires_at, created_at
+		FROM user_sessions
+		WHERE user_id = $1 AND is_revoked = FALSE AND expires_at > NOW()
+		ORDER BY last_activity DESC
+	`, middleware.GetUserID(c))
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	current := middleware.GetSessionID(c)
+	sessions := []dto.SessionResponse{}
+	for rows.Next() {
+		var s dto.SessionResponse
+		var sessionID string
+		if err := rows.Scan(&s.ID, &sessionID, &s.UserAgent, &s.IPAddress, &s.LastActivity, &s.ExpiresAt, &s.CreatedAt); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		s.Current = sessionID != "" && sessionID == current
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OK(c, "common.success", sessions)
+}
+
+// RevokeSession signs one of the current user's devices out. Its access
+// and refresh tokens stop working at once.
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.NotFound(c, "session.not_found")
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	revoked, err := h.revokeSessions(c.Request.Context(), `
+		UPDATE user_sessions SET is_revoked = TRUE, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND is_revoked = FALSE
+		RETURNING COALESCE(session_id, ''), COALESCE(family_id, '')
+	`, id, userID)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if revoked == 0 {
+		response.NotFound(c, "session.not_found")
+		return
+	}
+
+	h.queue.LogAudit(c.Request.Context(), queue.AuditLogPayload{
+		UserID: userID, Action: "revoke_session", TableName: "user_sessions", RecordID: id.String(),
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "session.revoked", nil)
+}
+
+// RevokeOtherSessions signs the current user out everywhere except the
+// device making the request
+func (h *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	revoked, err := h.revokeSessions(c.Request.Context(), `
+		UPDATE user_sessions SET is_revoked = TRUE, updated_at = NOW()
+		WHERE user_id = $1 AND is_revoked = FALSE AND session_id IS DISTINCT FROM $2
+		RETURNING COALESCE(session_id, ''), COALESCE(family_id, '')
+	`, userID, middleware.GetSessionID(c))
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.log.LogSecurityEvent("sessions_revoked", userID, c.ClientIP(), "signed out of all other sessions")
+	h.queue.LogAudit(c.Request.Context(), queue.AuditLogPayload{
+		UserID: userID, Action: "revoke_other_sessions", TableName: "user_sessions", RecordID: userID,
+		NewValues: gin.H{"revoked": revoked},
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "session.revoked", gin.H{"revoked": revoked})
+}
+
+// revokeSessions runs an UPDATE returning the session and family IDs of the
+// rows it revoked, and blacklists them so their tokens are refused. Rows
+// from before session IDs were stored can only be marked revoked.
+func (h *AuthHandler) revokeSessions(ctx context.Context, query string, args ...interface{}) (int, error) {
+	rows, err := h.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	var sessions, families []string
+	for rows.Next() {
+		var sessionID, familyID string
+		if err := rows.Scan(&sessionID, &familyID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		sessions = append(sessions, sessionID)
+		families = append(families, familyID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	ttl := h.cfg.JWT.RefreshTokenExpiry
+	blacklist := security.NewSessionBlacklist(h.cache)
+	tracker := security.NewRefreshTokenTracker(h.cache)
+	for i := range sessions {
+		if sessions[i] != "" {
+			if err := blacklist.Add(ctx, sessions[i], ttl); err != nil {
+				return 0, err
+			}
+		}
+		if families[i] != "" {
+			if err := tracker.RevokeFamily(ctx, families[i], ttl); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(sessions), nil
+}
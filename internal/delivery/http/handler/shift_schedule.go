@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// shiftSchedule is the shift an employee works on a date, in local
+// wall-clock time. A night shift ends on the next day. The break is zero when
+// the shift has none.
+type shiftSchedule struct {
+	Start      time.Time
+	End        time.Time
+	BreakStart time.Time
+	BreakEnd   time.Time
+}
+
+// newShiftSchedule places a shift window on date. A shift ending at or
+// before its start runs past midnight; so does a break starting before the
+// shift does.
+func newShiftSchedule(date time.Time, window shiftWindow, breakWindow *shiftWindow) shiftSchedule {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+	s := shiftSchedule{Start: day.Add(window.start), End: day.Add(window.end)}
+	if window.end <= window.start {
+		s.End = day.AddDate(0, 0, 1).Add(window.end)
+	}
+
+	if breakWindow != nil {
+		breakDay := day
+		if breakWindow.start < window.start {
+			breakDay = day.AddDate(0, 0, 1)
+		}
+		s.BreakStart = breakDay.Add(breakWindow.start)
+		s.BreakEnd = breakDay.Add(breakWindow.end)
+		if breakWindow.end <= breakWindow.start {
+			s.BreakEnd = breakDay.AddDate(0, 0, 1).Add(breakWindow.end)
+		}
+	}
+	return s
+}
+
+// overnight reports whether the shift runs past the midnight after its start
+func (s shiftSchedule) overnight() bool {
+	return s.End.After(time.Date(s.Start.Year(), s.Start.Month(), s.Start.Day()+1, 0, 0, 0, 0, time.Local))
+}
+
+// lateArrival is how long after the shift start a check-in at t came, and
+// whether that is past the grace period
+func (s shiftSchedule) lateArrival(t time.Time, grace time.Duration) (time.Duration, bool) {
+	late := t.Sub(s.Start)
+	return late, late > grace
+}
+
+// earlyLeave reports whether a check-out at t is before the shift end
+func (s shiftSchedule) earlyLeave(t time.Time) bool {
+	return t.Before(s.End)
+}
+
+// workedHours is the time between check-in and check-out less the part of
+// the break that falls inside it
+func (s shiftSchedule) workedHours(checkIn, checkOut time.Time) float64 {
+	worked := checkOut.Sub(checkIn)
+	if !s.BreakStart.IsZero() {
+		from, to := s.BreakStart, s.BreakEnd
+		if checkIn.After(from) {
+			from = checkIn
+		}
+		if checkOut.Before(to) {
+			to = checkOut
+		}
+		if to.After(from) {
+			worked -= to.Sub(from)
+		}
+	}
+	return worked.Hours()
+}
+
+// scheduleOn returns the employee's shift on date: the assigned one, else the
+// default shift. Remote days without an assigned shift start at
+// ATTENDANCE_REMOTE_SHIFT_START and last the remote expected hours; ok is
+// false when it is empty, as such days are flexible.
+func (h *AttendanceHandler) scheduleOn(ctx context.Context, employeeID uuid.UUID, date time.Time, workMode string) (shiftSchedule, bool, error) {
+	var startTime, endTime string
+	var breakStart, breakEnd sql.NullString
+	err := h.db.QueryRowContext(ctx, `
+		SELECT ws.start_time, ws.end_time, ws.break_start, ws.break_end
+		FROM employee_shifts es
+		INNER JOIN work_shifts ws ON ws.id = es.shift_id
+		WHERE es.employee_id = $1 AND es.date = $2
+	`, employeeID, date.Format("2006-01-02")).Scan(&startTime, &endTime, &breakStart, &breakEnd)
+
+	if err == sql.ErrNoRows {
+		cfg := h.cfg.Attendance
+		if workMode != workModeRemote {
+			window, err := parseShiftWindow(cfg.DefaultShiftStart, cfg.DefaultShiftEnd)
+			if err != nil {
+				return shiftSchedule{}, false, err
+			}
+			return newShiftSchedule(date, window, nil), true, nil
+		}
+		if cfg.RemoteShiftStart == "" {
+			return shiftSchedule{}, false, nil
+		}
+		start, err := parseClock(cfg.RemoteShiftStart)
+		if err != nil {
+			return shiftSchedule{}, false, err
+		}
+		length := time.Duration(cfg.RemoteExpectedHours * float64(time.Hour))
+		return newShiftSchedule(date, shiftWindow{start: start, end: (start + length) % (24 * time.Hour)}, nil), true, nil
+	}
+	if err != nil {
+		return shiftSchedule{}, false, err
+	}
+
+	window, err := parseShiftWindow(startTime, endTime)
+	if err != nil {
+		return shiftSchedule{}, false, err
+	}
+	var breakWindow *shiftWindow
+	if breakStart.Valid && breakEnd.Valid {
+		w, err := parseShiftWindow(breakStart.String, breakEnd.String)
+		if err != nil {
+			return shiftSchedule{}, false, err
+		}
+		breakWindow = &w
+	}
+	return newShiftSchedule(date, window, breakWindow), true, nil
+}
+
+// attendanceDate returns the work date a check-in at now belongs to. It is
+// today, unless yesterday's night shift is still running and has no
+// attendance yet, so a late arrival after midnight counts toward that shift.
+func (h *AttendanceHandler) attendanceDate(ctx context.Context, employeeID uuid.UUID, now time.Time) (time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	yesterday := today.AddDate(0, 0, -1)
+
+	workMode, err := workModeOn(ctx, h.db, employeeID, yesterday.Format("2006-01-02"))
+	if err != nil {
+		return today, err
+	}
+	schedule, ok, err := h.scheduleOn(ctx, employeeID, yesterday, workMode)
+	if err != nil || !ok || !now.Before(schedule.End) {
+		return today, err
+	}
+
+	var recorded bool
+	if err := h.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM attendances WHERE employee_id = $1 AND date = $2 AND check_in IS NOT NULL)
+	`, employeeID, yesterday.Format("2006-01-02")).Scan(&recorded); err != nil {
+		return today, err
+	}
+	if recorded {
+		return today, nil
+	}
+	return yesterday, nil
+}
+
+// wallClock reads a TIMESTAMP column, stored as local wall-clock time
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"testing"
+	"time"
+)
+
+func localTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local)
+	if err != nil {
+		t.Fatalf("parse %q: %v", value, err)
+	}
+	return parsed
+}
+
+func TestParseShiftWindow(t *testing.T) {
+	tests := []struct {
+		start, end         string
+		wantStart, wantEnd time.Duration
+		wantErr            bool
+	}{
+		{"08:00:00", "17:00:00", 8 * time.Hour, 17 * time.Hour, false},
+		{"22:00:00", "06:00:00", 22 * time.Hour, 6 * time.Hour, false},
+		{"22:00", "06:00", 22 * time.Hour, 6 * time.Hour, false},
+		{"16:00", "00:00", 16 * time.Hour, 0, false},
+		{"22:00", "6am", 0, 0, true},
+		{"", "06:00", 0, 0, true},
+	}
+	for _, tt := range tests {
+		window, err := parseShiftWindow(tt.start, tt.end)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseShiftWindow(%q, %q) error = %v, want error %v", tt.start, tt.end, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (window.start != tt.wantStart || window.end != tt.wantEnd) {
+			t.Errorf("parseShiftWindow(%q, %q) = %s-%s, want %s-%s", tt.start, tt.end, window.start, window.end, tt.wantStart, tt.wantEnd)
+		}
+	}
+}
+
+func TestNewShiftScheduleNight(t *testing.T) {
+	date := localTime(t, "2025-03-05 00:00")
+	night, _ := parseShiftWindow("22:00", "06:00")
+
+	tests := []struct {
+		name                 string
+		window               shiftWindow
+		breakWindow          *shiftWindow
+		start, end           string
+		breakStart, breakEnd string
+		overnight            bool
+	}{
+		{"day shift", shiftWindow{8 * time.Hour, 17 * time.Hour}, &shiftWindow{12 * time.Hour, 13 * time.Hour},
+			"2025-03-05 08:00", "2025-03-05 17:00", "2025-03-05 12:00", "2025-03-05 13:00", false},
+		{"night shift with a break after midnight", night, &shiftWindow{2 * time.Hour, 2*time.Hour + 30*time.Minute},
+			"2025-03-05 22:00", "2025-03-06 06:00", "2025-03-06 02:00", "2025-03-06 02:30", true},
+		{"night shift with a break across midnight", night, &shiftWindow{23*time.Hour + 30*time.Minute, 30 * time.Minute},
+			"2025-03-05 22:00", "2025-03-06 06:00", "2025-03-05 23:30", "2025-03-06 00:30", true},
+		{"evening shift ending at midnight", shiftWindow{16 * time.Hour, 0}, nil,
+			"2025-03-05 16:00", "2025-03-06 00:00", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newShiftSchedule(date, tt.window, tt.breakWindow)
+			if !s.Start.Equal(localTime(t, tt.start)) || !s.End.Equal(localTime(t, tt.end)) {
+				t.Errorf("shift %s - %s, want %s - %s", s.Start, s.End, tt.start, tt.end)
+			}
+			if tt.breakWindow == nil {
+				if !s.BreakStart.IsZero() {
+					t.Errorf("break %s without a break window", s.BreakStart)
+				}
+			} else if !s.BreakStart.Equal(localTime(t, tt.breakStart)) || !s.BreakEnd.Equal(localTime(t, tt.breakEnd)) {
+				t.Errorf("break %s - %s, want %s - %s", s.BreakStart, s.BreakEnd, tt.breakStart, tt.breakEnd)
+			}
+			if s.overnight() != tt.overnight {
+				t.Errorf("overnight = %v, want %v", s.overnight(), tt.overnight)
+			}
+		})
+	}
+}
+
+// A 22:00-06:00 shift starting on 5 March: arrivals are late against 22:00
+// on the 5th, departures early against 06:00 on the 6th
+func TestNightShiftLateAndEarly(t *testing.T) {
+	night, _ := parseShiftWindow("22:00:00", "06:00:00")
+	s := newShiftSchedule(localTime(t, "2025-03-05 00:00"), night, &shiftWindow{2 * time.Hour, 2*time.Hour + 30*time.Minute})
+	grace := 5 * time.Minute
+
+	arrivals := []struct {
+		at          string
+		late        bool
+		lateMinutes int
+	}{
+		{"2025-03-05 21:50", false, 0},
+		{"2025-03-05 22:05", false, 0},
+		{"2025-03-05 22:06", true, 6},
+		{"2025-03-05 23:59", true, 119},
+		{"2025-03-06 00:30", true, 150},
+	}
+	for _, a := range arrivals {
+		late, ok := s.lateArrival(localTime(t, a.at), grace)
+		if ok != a.late || ok && int(late.Minutes()) != a.lateMinutes {
+			t.Errorf("check-in at %s: late %v by %d minutes, want %v by %d", a.at, ok, int(late.Minutes()), a.late, a.lateMinutes)
+		}
+	}
+
+	departures := []struct {
+		at    string
+		early bool
+	}{
+		{"2025-03-05 23:00", true},
+		{"2025-03-06 00:00", true},
+		{"2025-03-06 05:59", true},
+		{"2025-03-06 06:00", false},
+		{"2025-03-06 07:15", false},
+	}
+	for _, d := range departures {
+		if got := s.earlyLeave(localTime(t, d.at)); got != d.early {
+			t.Errorf("check-out at %s: early leave %v, want %v", d.at, got, d.early)
+		}
+	}
+
+	// Worked across midnight, less the break
+	if hours := s.workedHours(localTime(t, "2025-03-05 22:00"), localTime(t, "2025-03-06 06:00")); hours != 7.5 {
+		t.Errorf("worked %g hours over the night shift, want 7.5", hours)
+	}
+}
@@ -0,0 +1,331 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/cache"
+	"hr-management-system/internal/infrastructure/database"
+	"hr-management-system/internal/infrastructure/logger"
+	"hr-management-system/internal/infrastructure/queue"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+)
+
+type SystemHandler struct {
+	db    *database.Database
+	cache *cache.RedisCache
+	queue *queue.Queue
+	log   *logger.Logger
+	cfg   *config.Config
+}
+
+func NewSystemHandler(db *database.Database, cache *cache.RedisCache, queue *queue.Queue, log *logger.Logger, cfg *config.Config) *SystemHandler {
+	return &SystemHandler{db: db, cache: cache, queue: queue, log: log, cfg: cfg}
+}
+
+// GetWorkerSettings returns the effective worker settings and per-queue state
+func (h *SystemHandler) GetWorkerSettings(c *gin.Context) {
+	response.OK(c, "common.success", h.workerSettingsResponse(c.Request.Context()))
+}
+
+// UpdateWorkerSettings stores new concurrency and queue weights in Redis.
+// Running workers pick them up on their next reload.
+func (h *SystemHandler) UpdateWorkerSettings(c *gin.Context) {
+	var req dto.UpdateWorkerSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	for name := range req.Queues {
+		if !isKnownQueue(name) {
+			response.BadRequest(c, "system.unknown_queue", map[string]string{"queue": name})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	settings := h.currentWorkerSettings(ctx)
+	if req.Concurrency > 0 {
+		settings.Concurrency = req.Concurrency
+	}
+	if len(req.Queues) > 0 {
+		queues := make(map[string]int, len(settings.Queues))
+		for name, weight := range settings.Queues {
+			queues[name] = weight
+		}
+		for name, weight := range req.Queues {
+			queues[name] = weight
+		}
+		settings.Queues = queues
+	}
+	settings.UpdatedBy = middleware.GetUserID(c)
+	settings.UpdatedAt = time.Now()
+
+	if err := h.cache.Set(ctx, cache.KeyWorkerSettings, settings, 0); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: settings.UpdatedBy, Action: "update", TableName: "worker_settings", RecordID: settings.UpdatedBy,
+		NewValues: req, IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "common.updated", h.workerSettingsResponse(ctx))
+}
+
+// PauseQueue stops workers from processing tasks of a queue
+func (h *SystemHandler) PauseQueue(c *gin.Context) {
+	h.setQueuePaused(c, true)
+}
+
+// ResumeQueue lets workers process a paused queue again
+func (h *SystemHandler) ResumeQueue(c *gin.Context) {
+	h.setQueuePaused(c, false)
+}
+
+func (h *SystemHandler) setQueuePaused(c *gin.Context, paused bool) {
+	name := c.Param("queue")
+	if !isKnownQueue(name) {
+		response.NotFound(c, "system.unknown_queue")
+		return
+	}
+
+	var err error
+	if paused {
+		err = h.queue.PauseQueue(name)
+	} else {
+		err = h.queue.UnpauseQueue(name)
+	}
+	if err != nil {
+		response.BadRequest(c, "system.queue_state_unchanged", map[string]string{"error": err.Error()})
+		return
+	}
+
+	message := "Queue resumed"
+	if paused {
+		message = "Queue paused"
+	}
+	h.log.WithModule("system").WithField("user_id", middleware.GetUserID(c)).WithField("queue", name).Info(message)
+
+	response.OK(c, "common.updated", h.workerSettingsResponse(c.Request.Context()))
+}
+
+// GetTask reports the state of one enqueued task so callers can poll jobs they
+// started. The payload is left out since it may carry personal data; the
+// result written by the task handler is included.
+func (h *SystemHandler) GetTask(c *gin.Context) {
+	name := c.Param("queue")
+	if !isKnownQueue(name) {
+		response.NotFound(c, "system.unknown_queue")
+		return
+	}
+
+	info, err := h.queue.GetTaskInfo(name, c.Param("id"))
+	if errors.Is(err, asynq.ErrTaskNotFound) || errors.Is(err, asynq.ErrQueueNotFound) {
+		response.NotFound(c, "system.task_not_found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	resp := dto.TaskStatusResponse{
+		ID:        info.ID,
+		Queue:     info.Queue,
+		Type:      info.Type,
+		State:     info.State.String(),
+		Retried:   info.Retried,
+		MaxRetry:  info.MaxRetry,
+		LastError: info.LastErr,
+	}
+	if !info.LastFailedAt.IsZero() {
+		resp.LastFailedAt = &info.LastFailedAt
+	}
+	if !info.NextProcessAt.IsZero() {
+		resp.NextProcessAt = &info.NextProcessAt
+	}
+	if !info.CompletedAt.IsZero() {
+		resp.CompletedAt = &info.CompletedAt
+	}
+	if len(info.Result) > 0 && json.Valid(info.Result) {
+		resp.Result = info.Result
+	}
+
+	response.OK(c, "common.success", resp)
+}
+
+// ReconcileSearch queues a reconciliation of the employees index with the
+// database. Progress and the number of fixes are available from GetTask.
+func (h *SystemHandler) ReconcileSearch(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	info, err := h.queue.ReconcileIndex(c.Request.Context(), queue.ElasticReconcilePayload{Index: "employees", RequestedBy: userID})
+	if errors.Is(err, asynq.ErrDuplicateTask) {
+		response.Conflict(c, "system.reconcile_running")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.log.WithModule("system").WithField("user_id", userID).WithField("task_id", info.ID).Info("Elasticsearch reconcile requested")
+	response.OK(c, "system.reconcile_queued", gin.H{"task_id": info.ID, "queue": info.Queue})
+}
+
+// ListNotificationDeliveries lists email copies of notifications, by default
+// those that failed permanently, so admins can see what never arrived
+func (h *SystemHandler) ListNotificationDeliveries(c *gin.Context) {
+	var filter dto.NotificationDeliveryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+	if filter.Status == "" {
+		filter.Status = "failed"
+	}
+
+	ctx := c.Request.Context()
+	pagination := database.NewPagination(filter.Page, filter.PageSize, nil, "", &h.cfg.Database)
+	pagination.SetCountMode(c.Query("count"), h.cfg.Database.CountModeFor("notification_deliveries"))
+
+	err := h.db.Count(ctx, pagination, "notification_deliveries",
+		`SELECT COUNT(*) FROM notification_deliveries WHERE status = $1`, filter.Status)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT d.id, d.notification_id, n.user_id, u.email, n.type, n.title, d.channel, d.status,
+		       d.attempts, COALESCE(d.last_error, ''), d.delivered_at, d.created_at, d.updated_at
+		FROM notification_deliveries d
+		INNER JOIN notifications n ON n.id = d.notification_id
+		INNER JOIN users u ON u.id = n.user_id
+		WHERE d.status = $1
+		ORDER BY d.updated_at DESC
+		LIMIT $2 OFFSET $3
+	`, filter.Status, pagination.FetchLimit(), pagination.GetOffset())
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	deliveries := []dto.NotificationDeliveryResponse{}
+	for rows.Next() {
+		var d dto.NotificationDeliveryResponse
+		var deliveredAt sql.NullTime
+		if err := rows.Scan(&d.ID, &d.NotificationID, &d.UserID, &d.Email, &d.Type, &d.Title, &d.Channel, &d.Status,
+			&d.Attempts, &d.LastError, &deliveredAt, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		if deliveredAt.Valid {
+			d.DeliveredAt = &deliveredAt.Time
+		}
+		deliveries = append(deliveries, d)
+	}
+	deliveries = database.TrimPage(pagination, deliveries)
+
+	response.OKWithMeta(c, "common.list", deliveries, pagination)
+}
+
+// RetryNotificationDelivery queues a permanently failed delivery again with a
+// fresh set of retries
+func (h *SystemHandler) RetryNotificationDelivery(c *gin.Context) {
+	id := c.Param("id")
+	ctx := c.Request.Context()
+
+	var status string
+	err := h.db.QueryRowContext(ctx, `SELECT status FROM notification_deliveries WHERE id = $1`, id).Scan(&status)
+	if err != nil {
+		response.NotFound(c, "system.delivery_not_found")
+		return
+	}
+	if status != "failed" {
+		response.Conflict(c, "system.delivery_not_failed")
+		return
+	}
+
+	h.db.ExecContext(ctx, `UPDATE notification_deliveries SET status = 'pending', updated_at = NOW() WHERE id = $1`, id)
+
+	info, err := h.queue.SendNotificationEmail(ctx, queue.NotificationEmailPayload{DeliveryID: id}, h.cfg.Notification.EmailMaxRetry)
+	if err != nil {
+		h.db.ExecContext(ctx, `UPDATE notification_deliveries SET status = 'failed', updated_at = NOW() WHERE id = $1`, id)
+		response.InternalError(c, err)
+		return
+	}
+
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: middleware.GetUserID(c), Action: "retry", TableName: "notification_deliveries", RecordID: id,
+		IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "system.delivery_retry_queued", gin.H{"task_id": info.ID, "queue": info.Queue})
+}
+
+func (h *SystemHandler) currentWorkerSettings(ctx context.Context) queue.WorkerSettings {
+	settings := queue.WorkerSettings{Concurrency: h.cfg.Worker.Concurrency, Queues: h.cfg.Worker.Queues}
+
+	var override queue.WorkerSettings
+	if err := h.cache.Get(ctx, cache.KeyWorkerSettings, &override); err == nil {
+		if override.Concurrency > 0 {
+			settings.Concurrency = override.Concurrency
+		}
+		if len(override.Queues) > 0 {
+			settings.Queues = override.Queues
+		}
+		settings.UpdatedBy = override.UpdatedBy
+		settings.UpdatedAt = override.UpdatedAt
+	}
+	return settings
+}
+
+func (h *SystemHandler) workerSettingsResponse(ctx context.Context) dto.WorkerSettingsResponse {
+	settings := h.currentWorkerSettings(ctx)
+
+	resp := dto.WorkerSettingsResponse{
+		Concurrency: settings.Concurrency,
+		Queues:      []dto.QueueStateResponse{},
+		UpdatedBy:   settings.UpdatedBy,
+	}
+	if !settings.UpdatedAt.IsZero() {
+		resp.UpdatedAt = &settings.UpdatedAt
+	}
+
+	for _, name := range queue.KnownQueues {
+		state := dto.QueueStateResponse{Name: name, Weight: settings.Queues[name]}
+		// Queues that never received a task are unknown to the inspector
+		if info, err := h.queue.GetQueueInfo(name); err == nil {
+			state.Paused = info.Paused
+			state.Size = info.Size
+			state.Pending = info.Pending
+			state.Active = info.Active
+			state.Retry = info.Retry
+			state.Archived = info.Archived
+		}
+		resp.Queues = append(resp.Queues, state)
+	}
+	return resp
+}
+
+func isKnownQueue(name string) bool {
+	for _, q := range queue.KnownQueues {
+		if q == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/cache"
+	"hr-management-system/internal/payroll"
+	"hr-management-system/internal/security"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Timesheet downloads an employee's monthly timesheet as a PDF to sign.
+// ?employee_id= defaults to the caller and ?month= (YYYY-MM) to the current
+// month. Employees get their own and their direct reports'; those who may
+// view the team, the ones in their team scope; holders of attendance.manage
+// anyone's.
+func (h *AttendanceHandler) Timesheet(c *gin.Context) {
+	if format := c.DefaultQuery("format", "pdf"); format != "pdf" {
+		response.BadRequest(c, "common.validation_error", map[string]string{"format": "pdf"})
+		return
+	}
+	month, err := time.Parse("2006-01", c.DefaultQuery("month", time.Now().Format("2006-01")))
+	if err != nil {
+		response.BadRequest(c, "validation.date_format", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := middleware.GetUserID(c)
+
+	var employeeID uuid.UUID
+	if id := c.Query("employee_id"); id != "" {
+		if employeeID, err = uuid.Parse(id); err != nil {
+			response.NotFound(c, "employee.not_found")
+			return
+		}
+	} else if err := h.db.QueryRowContext(ctx, `
+		SELECT id FROM employees WHERE user_id = $1 AND deleted_at IS NULL
+	`, userID).Scan(&employeeID); err != nil {
+		response.NotFound(c, "employee.not_found")
+		return
+	}
+
+	allowed, err := h.canViewTimesheet(ctx, c, employeeID)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if !allowed {
+		response.Forbidden(c, "permission.denied")
+		return
+	}
+
+	cacheKey := attendanceSummaryKey(employeeID, month)
+	var doc payroll.TimesheetDocument
+	if err := h.cache.Get(ctx, cacheKey, &doc); err != nil {
+		defaultShift := fmt.Sprintf("%s-%s", h.cfg.Attendance.DefaultShiftStart, h.cfg.Attendance.DefaultShiftEnd)
+		loaded, err := payroll.NewService(h.db).LoadTimesheet(ctx, employeeID, month.Year(), int(month.Month()), defaultShift)
+		if err == sql.ErrNoRows {
+			response.NotFound(c, "employee.not_found")
+			return
+		}
+		if err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		doc = *loaded
+		h.cache.Set(ctx, cacheKey, doc, 10*time.Minute)
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=timesheet_%s_%d_%02d.pdf", doc.EmployeeCode, doc.Year, doc.Month))
+	c.Data(http.StatusOK, "application/pdf", payroll.RenderTimesheetPDF(&doc))
+}
+
+// attendanceSummaryKey caches an employee's monthly timesheet. Check-ins,
+// check-outs, manual entries and approvals in the month clear it.
+func attendanceSummaryKey(employeeID uuid.UUID, month time.Time) string {
+	return fmt.Sprintf("%ssummary:%s:%s", cache.KeyAttendancePrefix, employeeID, month.Format("2006-01"))
+}
+
+// forgetAttendanceDay drops what was cached of an employee's attendance on
+// the date: the team views of the day and the employee's monthly summary
+func (h *AttendanceHandler) forgetAttendanceDay(ctx context.Context, employeeID uuid.UUID, date time.Time) {
+	h.cache.DeleteByPattern(ctx, cache.KeyAttendancePrefix+"team:*:"+date.Format("2006-01-02")+":*")
+	h.cache.Delete(ctx, attendanceSummaryKey(employeeID, date))
+}
+
+// canViewTimesheet reports whether the caller may see the employee's
+// timesheet: their own, a direct report's, one within their team scope when
+// they may view the team, or any with attendance.manage
+func (h *AttendanceHandler) canViewTimesheet(ctx context.Context, c *gin.Context, employeeID uuid.UUID) (bool, error) {
+	permissions := middleware.GetPermissions(c)
+	if security.HasPermission(permissions, "attendance.manage") {
+		return true, nil
+	}
+	var departmentID, managerID string
+	err := h.db.QueryRowContext(ctx, `
+		SELECT COALESCE(department_id::text, ''), COALESCE(manager_id::text, '')
+		FROM employees WHERE id = $1 AND deleted_at IS NULL
+	`, employeeID).Scan(&departmentID, &managerID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	scope, err := security.ResolveTeamScope(ctx, h.db, middleware.GetUserID(c))
+	if err != nil {
+		return false, err
+	}
+	if scope.EmployeeID != "" && (scope.EmployeeID == employeeID.String() || scope.EmployeeID == managerID) {
+		return true, nil
+	}
+	// Colleagues in the scope's departments take a team view permission
+	if !security.HasPermission(permissions, "employees.view") && !security.HasPermission(permissions, "employees.view.team") {
+		return false, nil
+	}
+	return scope.Allows(departmentID, managerID), nil
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"hr-management-system/internal/testutil"
+)
+
+// A manager in HR with a report in IT, and an IT colleague: department access
+// needs a team view permission, direct reports and oneself never do
+func TestCanViewTimesheet(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewAttendanceHandler(env.db, env.cache, env.queue, env.log, env.cfg)
+	manager := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{DepartmentID: testutil.DepartmentHR})
+	report := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{ManagerID: &manager.ID})
+	colleague := testutil.CreateEmployee(t, env.db, testutil.EmployeeOptions{})
+
+	tests := []struct {
+		name   string
+		caller testutil.Employee
+		target testutil.Employee
+		perms  []string
+		want   bool
+	}{
+		{"own", colleague, colleague, nil, true},
+		{"direct report", manager, report, nil, true},
+		{"colleague without a team permission", colleague, report, nil, false},
+		{"colleague in the team scope", colleague, report, []string{"employees.view.team"}, true},
+		{"outside the team scope", manager, colleague, []string{"employees.view.team"}, false},
+		{"anyone with attendance.manage", manager, colleague, []string{"attendance.manage"}, true},
+	}
+	for _, tt := range tests {
+		c, _ := testutil.Request(http.MethodGet, "/", nil, tt.caller.UserID, tt.perms...)
+		allowed, err := h.canViewTimesheet(context.Background(), c, tt.target.ID)
+		testutil.Must(t, err, "%s", tt.name)
+		if allowed != tt.want {
+			t.Errorf("%s: allowed = %v, want %v", tt.name, allowed, tt.want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/middleware"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/queue"
+	"hr-management-system/internal/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Setup2FA starts authenticator app enrollment for the current user. The new
+// secret is stored encrypted but 2FA stays off until Confirm2FA sees a code
+// from the app, so a half-finished setup never locks the user out. Starting
+// again replaces the secret and the recovery codes.
+func (h *AuthHandler) Setup2FA(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+
+	var email string
+	var enabled bool
+	err := h.db.QueryRowContext(ctx, `
+		SELECT email, COALESCE(two_factor_enabled, FALSE) FROM users WHERE id = $1 AND deleted_at IS NULL
+	`, userID).Scan(&email, &enabled)
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "user.not_found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if enabled {
+		response.BadRequest(c, "auth.two_factor_already_enabled", nil)
+		return
+	}
+
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	encrypted, err := security.EncryptSecret(secret, h.cfg.Security.TwoFactorKey)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	codes, err := security.GenerateRecoveryCodes(h.cfg.Security.RecoveryCodeCount)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	err = h.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE users SET two_factor_secret = $1, updated_at = NOW() WHERE id = $2
+		`, encrypted, userID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+		for _, code := range codes {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)
+			`, userID, security.HashOTP(security.NormalizeRecoveryCode(code))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+
+	h.log.LogSecurityEvent("2fa_setup_started", userID, c.ClientIP(), "authenticator app enrollment started")
+
+	uri := security.TOTPURI(h.cfg.Security.TOTPIssuer, email, secret)
+	response.OK(c, "auth.two_factor_setup", dto.TwoFactorSetupResponse{
+		Secret:        secret,
+		OTPAuthURI:    uri,
+		QRPayload:     uri,
+		RecoveryCodes: codes,
+	})
+}
+
+// Confirm2FA turns two-factor authentication on once the user proves their
+// authenticator app produces the right codes for the secret from Setup2FA
+func (h *AuthHandler) Confirm2FA(c *gin.Context) {
+	var req dto.TwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	ctx := c.Request.Context()
+	clientIP := c.ClientIP()
+
+	var enabled bool
+	var secret sql.NullString
+	err := h.db.QueryRowContext(ctx, `
+		SELECT COALESCE(two_factor_enabled, FALSE), two_factor_secret FROM users WHERE id = $1 AND deleted_at IS NULL
+	`, userID).Scan(&enabled, &secret)
+	if err == sql.ErrNoRows {
+		response.NotFound(c, "user.not_found")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if enabled {
+		response.BadRequest(c, "auth.two_factor_already_enabled", nil)
+		return
+	}
+	if !secret.Valid {
+		response.BadRequest(c, "auth.two_factor_setup_required", nil)
+		return
+	}
+
+	if !h.verifyTOTP(ctx, userID, secret.String, req.Code) {
+		h.log.LogSecurityEvent("2fa_setup_failed", userID, clientIP, "invalid code")
+		response.BadRequest(c, "auth.two_factor_invalid", nil)
+		return
+	}
+
+	if _, err := h.db.ExecContext(ctx, `
+		UPDATE users SET two_factor_enabled = TRUE, updated_at = NOW() WHERE id = $1
+	`, userID); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	h.cache.InvalidateUserCache(ctx, userID)
+
+	h.log.LogSecurityEvent("2fa_enabled", userID, clientIP, "authenticator app two-factor authentication enabled")
+	h.queue.LogAudit(ctx, queue.AuditLogPayload{
+		UserID: userID, Action: "enable_2fa", TableName: "users", RecordID: userID,
+		OldValues: gin.H{"two_factor_enabled": false}, NewValues: gin.H{"two_factor_enabled": true, "method": "totp"},
+		IPAddress: clientIP, UserAgent: c.Request.UserAgent(),
+	})
+
+	response.OK(c, "auth.two_factor_enabled", nil)
+}
+
+// verifySecondFactor checks code against each way the user can pass 2FA: an
+// emailed OTP under one of otpKeys, a code from their authenticator app, or an
+// unused recovery code. It returns which one matched, or "" if none did.
+// Emailed OTPs and recovery codes are used up by a match.
+func (h *AuthHandler) verifySecondFactor(ctx context.Context, userID string, secret sql.NullString, code string, otpKeys ...string) (string, error) {
+	for _, key := range otpKeys {
+		if storedHash, err := h.cache.GetOTP(ctx, key); err == nil && security.VerifyOTP(code, storedHash) {
+			h.cache.DeleteOTP(ctx, key)
+			return "email", nil
+		}
+	}
+
+	if secret.Valid && h.verifyTOTP(ctx, userID, secret.String, code) {
+		return "totp", nil
+	}
+
+	normalized := security.NormalizeRecoveryCode(code)
+	if len(normalized) <= security.TOTPDigits {
+		return "", nil
+	}
+	result, err := h.db.ExecContext(ctx, `
+		UPDATE user_recovery_codes SET used_at = NOW()
+		WHERE id = (
+			SELECT id FROM user_recovery_codes
+			WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+			LIMIT 1
+		)
+	`, userID, security.HashOTP(normalized))
+	if err != nil {
+		return "", err
+	}
+	if n, _ := result.RowsAffected(); n == 1 {
+		return "recovery", nil
+	}
+	return "", nil
+}
+
+// verifyTOTP checks an authenticator app code against the encrypted secret.
+// A code is accepted once: it is remembered for as long as it could still
+// validate, so one seen over the shoulder cannot be replayed.
+func (h *AuthHandler) verifyTOTP(ctx context.Context, userID, encryptedSecret, code string) bool {
+	secret, err := security.DecryptSecret(encryptedSecret, h.cfg.Security.TwoFactorKey)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to decrypt two-factor secret")
+		return false
+	}
+	skew := h.cfg.Security.TOTPSkew
+	if !security.ValidateTOTP(secret, code, time.Now(), skew) {
+		return false
+	}
+	window := time.Duration(2*skew+1) * security.TOTPPeriod
+	fresh, err := h.cache.Lock(ctx, "totp_used:"+userID+":"+code, "1", window)
+	return err == nil && fresh
+}
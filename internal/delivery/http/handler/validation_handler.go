@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/delivery/http/dto"
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/infrastructure/logger"
+	"hr-management-system/internal/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ValidationHandler struct {
+	log *logger.Logger
+	cfg *config.Config
+}
+
+func NewValidationHandler(log *logger.Logger, cfg *config.Config) *ValidationHandler {
+	return &ValidationHandler{log: log, cfg: cfg}
+}
+
+// ValidateContact normalizes an email and/or phone number the same way the
+// backend stores them and reports whether each is valid
+func (h *ValidationHandler) ValidateContact(c *gin.Context) {
+	var req dto.ValidateContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "common.validation_error", nil)
+		return
+	}
+
+	var result dto.ValidateContactResponse
+
+	if req.Email != "" {
+		normalized := security.SanitizeEmail(req.Email)
+		result.Email = &dto.ContactFieldResult{
+			Input:      req.Email,
+			Normalized: normalized,
+			Valid:      security.IsValidEmail(normalized),
+		}
+	}
+
+	if req.Phone != "" {
+		normalized := security.SanitizePhone(req.Phone)
+		result.Phone = &dto.ContactFieldResult{
+			Input:      req.Phone,
+			Normalized: normalized,
+			Valid:      security.IsValidPhone(normalized),
+		}
+	}
+
+	response.OK(c, "common.success", result)
+}
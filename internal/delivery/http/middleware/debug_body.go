@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== DEBUG BODY LOGGER ====================
+
+// Bodies larger than this are not buffered, since they cannot be redacted
+// without being parsed in full
+const debugBodyReadLimit = 1 << 20
+
+const redactedValue = "[REDACTED]"
+
+// Field names containing any of these are redacted
+var sensitiveFields = []string{"password", "token", "secret", "otp", "authorization", "api_key", "apikey"}
+
+// DebugBodyLogger logs request and response bodies for the configured route
+// prefixes, or for any request whose X-Debug header carries the debug token.
+// Sensitive fields are redacted and bodies are cut to the configured size.
+func DebugBodyLogger(log *logger.Logger, cfg *config.LoggerConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !debugBodyEnabled(c, cfg) {
+			c.Next()
+			return
+		}
+
+		requestBody, err := peekRequestBody(c)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		writer := &bodyCaptureWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		responseBody := writer.body.Bytes()
+		if writer.truncated {
+			responseBody = nil
+		}
+
+		requestID, _ := c.Get("request_id")
+		log.WithFields(map[string]interface{}{
+			"request_id":    requestID,
+			"method":        c.Request.Method,
+			"path":          c.Request.URL.Path,
+			"status":        c.Writer.Status(),
+			"request_body":  formatDebugBody(requestBody, c.ContentType(), cfg.DebugMaxBodySize),
+			"response_body": formatDebugBody(responseBody, contentTypeOf(writer.Header().Get("Content-Type")), cfg.DebugMaxBodySize),
+		}).Info("HTTP body")
+	}
+}
+
+func debugBodyEnabled(c *gin.Context, cfg *config.LoggerConfig) bool {
+	if header := c.GetHeader("X-Debug"); header != "" && cfg.DebugToken != "" {
+		if subtle.ConstantTimeCompare([]byte(header), []byte(cfg.DebugToken)) == 1 {
+			return true
+		}
+	}
+
+	path := c.Request.URL.Path
+	for _, prefix := range cfg.DebugRoutes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// peekRequestBody reads the request body up to the read limit and puts it
+// back so handlers still see the full body. A nil result means it was too
+// large to buffer.
+func peekRequestBody(c *gin.Context) ([]byte, error) {
+	if c.Request.Body == nil {
+		return []byte{}, nil
+	}
+
+	head, err := io.ReadAll(io.LimitReader(c.Request.Body, debugBodyReadLimit+1))
+	if err != nil {
+		return nil, err
+	}
+	c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(head), c.Request.Body), c.Request.Body}
+
+	if len(head) > debugBodyReadLimit {
+		return nil, nil
+	}
+	return head, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyCaptureWriter keeps a copy of the response body up to the read limit
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyCaptureWriter) capture(b []byte) {
+	if w.truncated {
+		return
+	}
+	if w.body.Len()+len(b) > debugBodyReadLimit {
+		w.truncated = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(b)
+}
+
+// formatDebugBody redacts sensitive fields of JSON and form bodies and cuts the
+// result to maxSize. Other content types are summarized rather than logged,
+// since they cannot be redacted.
+func formatDebugBody(body []byte, contentType string, maxSize int) string {
+	if body == nil {
+		return fmt.Sprintf("[body larger than %d bytes omitted]", debugBodyReadLimit)
+	}
+	if len(body) == 0 {
+		return ""
+	}
+
+	var text string
+	switch contentType {
+	case "application/json":
+		var data interface{}
+		if err := json.Unmarshal(body, &data); err != nil {
+			return fmt.Sprintf("[invalid JSON body, %d bytes omitted]", len(body))
+		}
+		redacted, _ := json.Marshal(redactFields(data))
+		text = string(redacted)
+	case "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return fmt.Sprintf("[invalid form body, %d bytes omitted]", len(body))
+		}
+		for key := range values {
+			if isSensitiveField(key) {
+				values.Set(key, redactedValue)
+			}
+		}
+		text = values.Encode()
+	default:
+		return fmt.Sprintf("[%s body, %d bytes omitted]", contentType, len(body))
+	}
+
+	if maxSize > 0 && len(text) > maxSize {
+		return text[:maxSize] + fmt.Sprintf("...[truncated, %d bytes total]", len(text))
+	}
+	return text
+}
+
+func redactFields(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if isSensitiveField(key) {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactFields(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactFields(value)
+		}
+	}
+	return data
+}
+
+func isSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	for _, field := range sensitiveFields {
+		if strings.Contains(name, field) {
+			return true
+		}
+	}
+	return false
+}
+
+func contentTypeOf(header string) string {
+	mediaType, _, _ := strings.Cut(header, ";")
+	return strings.TrimSpace(mediaType)
+}
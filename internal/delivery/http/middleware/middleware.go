@@ -1,8 +1,8 @@
 package middleware
 
 import (
-	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 	"time"
@@ -109,21 +109,13 @@ func JWTAuth(jwtCfg *config.JWTConfig, redisCache *cache.RedisCache) gin.Handler
 	blacklist := security.NewSessionBlacklist(redisCache)
 
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			response.Unauthorized(c, "auth.token_invalid")
-			c.Abort()
-			return
-		}
-
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		tokenString, fromCookie := accessToken(c, jwtCfg)
+		if tokenString == "" {
 			response.Unauthorized(c, "auth.token_invalid")
 			c.Abort()
 			return
 		}
 
-		tokenString := parts[1]
 		claims, err := security.ValidateAccessToken(tokenString, jwtCfg)
 		if err != nil {
 			response.Unauthorized(c, "auth.token_expired")
@@ -139,6 +131,14 @@ func JWTAuth(jwtCfg *config.JWTConfig, redisCache *cache.RedisCache) gin.Handler
 			return
 		}
 
+		// Browsers send cookies on their own, so cookie-authenticated writes
+		// must carry the session's CSRF token
+		if fromCookie && !isSafeMethod(c.Request.Method) && !validCSRFToken(c, redisCache, claims.SessionID) {
+			response.Forbidden(c, "common.forbidden")
+			c.Abort()
+			return
+		}
+
 		// Set user context
 		c.Set("user_id", claims.UserID)
 		c.Set("email", claims.Email)
@@ -294,7 +294,7 @@ func RateLimiter(redisCache *cache.RedisCache, cfg *config.RateLimitConfig) gin.
 		)
 
 		if err != nil {
-			c.Next()
+			rateLimitUnavailable(c, security.RateLimitClassGlobal)
 			return
 		}
 
@@ -324,7 +324,11 @@ func EndpointRateLimiter(redisCache *cache.RedisCache, limit int64, window time.
 
 		result, err := limiter.CheckEndpoint(c.Request.Context(), clientIP, endpoint, limit, window)
 		if err != nil {
-			c.Next()
+			class := security.RateLimitClassEndpoint
+			if strings.Contains(endpoint, "/auth/") {
+				class = security.RateLimitClassAuth
+			}
+			rateLimitUnavailable(c, class)
 			return
 		}
 
@@ -367,38 +371,73 @@ func IPWhitelist(cfg *config.SecurityConfig) gin.HandlerFunc {
 	}
 }
 
-// ==================== CSRF ====================
+// RouteIPFilter applies a group's own allow/deny lists on top of the global
+// whitelist. It runs after authentication so denied attempts can be attributed,
+// and rejects even users holding every permission.
+func RouteIPFilter(group string, cfg config.IPFilterConfig, log *logger.Logger) gin.HandlerFunc {
+	if !cfg.Enabled() {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	allow := parseIPNets(cfg.Allow)
+	deny := parseIPNets(cfg.Deny)
 
-func CSRF(redisCache *cache.RedisCache, cfg *config.SecurityConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Skip for GET, HEAD, OPTIONS
-		if c.Request.Method == "GET" || c.Request.Method == "HEAD" || c.Request.Method == "OPTIONS" {
-			c.Next()
+		ip := net.ParseIP(c.ClientIP())
+		if ip == nil || ipInNets(ip, deny) || (len(allow) > 0 && !ipInNets(ip, allow)) {
+			log.LogSecurityEvent("ip_denied", GetUserID(c), c.ClientIP(),
+				fmt.Sprintf("%s %s blocked by %s IP filter", c.Request.Method, c.Request.URL.Path, group))
+			response.Forbidden(c, "common.forbidden")
+			c.Abort()
 			return
 		}
+		c.Next()
+	}
+}
 
-		token := c.GetHeader("X-CSRF-Token")
-		if token == "" {
-			token = c.PostForm("_csrf")
+// parseIPNets accepts CIDR ranges and bare addresses, skipping invalid entries
+func parseIPNets(entries []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil {
+				bits := 128
+				if ip.To4() != nil {
+					ip, bits = ip.To4(), 32
+				}
+				nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			}
+			continue
+		}
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			nets = append(nets, ipNet)
 		}
+	}
+	return nets
+}
 
-		if token == "" {
-			response.Forbidden(c, "common.forbidden")
-			c.Abort()
-			return
+func ipInNets(ip net.IP, nets []*net.IPNet) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
 		}
+	}
+	return false
+}
 
-		// Validate token from cache
-		sessionID, _ := c.Get("session_id")
-		if sessionID == nil {
-			response.Forbidden(c, "common.forbidden")
-			c.Abort()
+// ==================== CSRF ====================
+
+func CSRF(redisCache *cache.RedisCache, cfg *config.SecurityConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Skip for GET, HEAD, OPTIONS
+		if isSafeMethod(c.Request.Method) {
+			c.Next()
 			return
 		}
 
-		var storedToken string
-		err := redisCache.Get(c.Request.Context(), fmt.Sprintf("csrf:%s", sessionID), &storedToken)
-		if err != nil || storedToken != token {
+		// Validate token from cache
+		sessionID := c.GetString("session_id")
+		if !validCSRFToken(c, redisCache, sessionID) {
 			response.Forbidden(c, "common.forbidden")
 			c.Abort()
 			return
@@ -428,30 +467,6 @@ func Language() gin.HandlerFunc {
 	}
 }
 
-// ==================== TIMEOUT ====================
-
-func Timeout(timeout time.Duration) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
-		defer cancel()
-
-		c.Request = c.Request.WithContext(ctx)
-
-		done := make(chan struct{})
-		go func() {
-			c.Next()
-			close(done)
-		}()
-
-		select {
-		case <-done:
-			return
-		case <-ctx.Done():
-			c.AbortWithStatus(http.StatusGatewayTimeout)
-		}
-	}
-}
-
 // ==================== RECOVERY ====================
 
 func Recovery(log *logger.Logger) gin.HandlerFunc {
@@ -474,6 +489,18 @@ func Recovery(log *logger.Logger) gin.HandlerFunc {
 	}
 }
 
+// rateLimitUnavailable handles a request whose limit could not be checked:
+// refused if its route class fails closed, let through otherwise
+func rateLimitUnavailable(c *gin.Context, class string) {
+	if security.RateLimitFailsClosed(class) {
+		c.Header("Retry-After", "30")
+		response.ServiceUnavailable(c)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
 // ==================== HELPER FUNCTIONS ====================
 
 func GetUserID(c *gin.Context) string {
@@ -484,6 +511,15 @@ func GetUserID(c *gin.Context) string {
 	return userID.(string)
 }
 
+// GetSessionID returns the JWT session of the request
+func GetSessionID(c *gin.Context) string {
+	sessionID, _ := c.Get("session_id")
+	if sessionID == nil {
+		return ""
+	}
+	return sessionID.(string)
+}
+
 func GetEmail(c *gin.Context) string {
 	email, _ := c.Get("email")
 	if email == nil {
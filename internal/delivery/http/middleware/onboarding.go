@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"context"
+
+	"hr-management-system/internal/delivery/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OnboardingChecker reports whether a user has finished onboarding
+type OnboardingChecker func(ctx context.Context, userID string) (bool, error)
+
+// RequireOnboarding rejects users who have not finished the first-login
+// checklist. It must run after JWTAuth. A failing check lets the request
+// through rather than locking everyone out.
+func RequireOnboarding(enforce bool, completed OnboardingChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enforce {
+			c.Next()
+			return
+		}
+		done, err := completed(c.Request.Context(), GetUserID(c))
+		if err == nil && !done {
+			response.Forbidden(c, "auth.onboarding_required")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
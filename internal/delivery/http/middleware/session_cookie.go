@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/infrastructure/cache"
+	"hr-management-system/internal/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cookies set in cookie session mode (JWT_COOKIE_MODE)
+const (
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	CSRFTokenCookie    = "csrf_token"
+)
+
+// The refresh token is only needed by the refresh and logout endpoints
+const refreshCookiePath = "/api/v1/auth"
+
+// SetSessionCookies sets the token pair as HttpOnly cookies and issues a CSRF
+// token for the session. The CSRF cookie is readable by the frontend, which
+// echoes it in X-CSRF-Token on unsafe requests. The token is returned as well.
+func SetSessionCookies(c *gin.Context, redisCache *cache.RedisCache, jwtCfg *config.JWTConfig, tokens *security.TokenPair) (string, error) {
+	csrfToken, err := security.GenerateCSRFToken()
+	if err != nil {
+		return "", err
+	}
+	if err := redisCache.Set(c.Request.Context(), fmt.Sprintf("csrf:%s", tokens.SessionID), csrfToken, jwtCfg.AccessTokenExpiry); err != nil {
+		return "", err
+	}
+
+	setCookie(c, jwtCfg, AccessTokenCookie, tokens.AccessToken, "/", int(jwtCfg.AccessTokenExpiry.Seconds()), true)
+	setCookie(c, jwtCfg, RefreshTokenCookie, tokens.RefreshToken, refreshCookiePath, int(jwtCfg.RefreshTokenExpiry.Seconds()), true)
+	setCookie(c, jwtCfg, CSRFTokenCookie, csrfToken, "/", int(jwtCfg.AccessTokenExpiry.Seconds()), false)
+	return csrfToken, nil
+}
+
+// ClearSessionCookies expires the cookies set by SetSessionCookies
+func ClearSessionCookies(c *gin.Context, jwtCfg *config.JWTConfig) {
+	setCookie(c, jwtCfg, AccessTokenCookie, "", "/", -1, true)
+	setCookie(c, jwtCfg, RefreshTokenCookie, "", refreshCookiePath, -1, true)
+	setCookie(c, jwtCfg, CSRFTokenCookie, "", "/", -1, false)
+}
+
+func setCookie(c *gin.Context, jwtCfg *config.JWTConfig, name, value, path string, maxAge int, httpOnly bool) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   jwtCfg.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   jwtCfg.CookieSecure,
+		HttpOnly: httpOnly,
+		SameSite: cookieSameSite(jwtCfg.CookieSameSite),
+	})
+}
+
+func cookieSameSite(mode string) http.SameSite {
+	switch strings.ToLower(mode) {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteStrictMode
+	}
+}
+
+// accessToken returns the bearer token of the request. Without an
+// Authorization header the access cookie is used in cookie mode, which
+// fromCookie reports.
+func accessToken(c *gin.Context, jwtCfg *config.JWTConfig) (token string, fromCookie bool) {
+	if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+			return "", false
+		}
+		return parts[1], false
+	}
+
+	if !jwtCfg.CookieMode {
+		return "", false
+	}
+	token, err := c.Cookie(AccessTokenCookie)
+	if err != nil {
+		return "", false
+	}
+	return token, true
+}
+
+// validCSRFToken checks the X-CSRF-Token header (or _csrf form field) against
+// the token stored for the session
+func validCSRFToken(c *gin.Context, redisCache *cache.RedisCache, sessionID string) bool {
+	token := c.GetHeader("X-CSRF-Token")
+	if token == "" {
+		token = c.PostForm("_csrf")
+	}
+	if token == "" || sessionID == "" {
+		return false
+	}
+
+	var storedToken string
+	err := redisCache.Get(c.Request.Context(), fmt.Sprintf("csrf:%s", sessionID), &storedToken)
+	return err == nil && storedToken == token
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
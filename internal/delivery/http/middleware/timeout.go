@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"hr-management-system/internal/delivery/http/response"
+	"hr-management-system/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== TIMEOUT ====================
+
+// TimeoutClass says how long a route may run
+type TimeoutClass int
+
+const (
+	// TimeoutDefault is for ordinary reads and writes
+	TimeoutDefault TimeoutClass = iota
+	// TimeoutLong is for exports, imports, search and reports
+	TimeoutLong
+	// TimeoutNone is for streams and downloads, which run as long as the
+	// client keeps reading
+	TimeoutNone
+)
+
+// timeoutWriteGrace is how long after its timeout a request may still spend
+// writing the response
+const timeoutWriteGrace = 5 * time.Second
+
+// RouteTimeouts is the request timeout of each route class. Classify gets
+// the registered path of the route, such as /api/v1/employees/:id.
+type RouteTimeouts struct {
+	Default  time.Duration
+	Long     time.Duration
+	Classify func(path string) TimeoutClass
+}
+
+// Timeout cancels the request context once the route's timeout passes and
+// answers 504 right away, flushed to the client even while a handler that
+// ignores the context keeps running. Handlers write into a buffer, which is
+// only sent if they finish in time; after a timeout their writes, and
+// Recovery's answer to a panic, are dropped. The chain stays on the request
+// goroutine, so gin does not reuse the context under a running handler.
+func Timeout(timeouts RouteTimeouts) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		class := TimeoutDefault
+		if timeouts.Classify != nil {
+			class = timeouts.Classify(c.FullPath())
+		}
+
+		rc := http.NewResponseController(c.Writer)
+		if class == TimeoutNone {
+			rc.SetWriteDeadline(time.Time{})
+			c.Next()
+			return
+		}
+
+		timeout := timeouts.Default
+		if class == TimeoutLong {
+			timeout = timeouts.Long
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+		rc.SetWriteDeadline(time.Now().Add(timeout + timeoutWriteGrace))
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		lang := c.GetString("language")
+		w := c.Writer
+		tw := &timeoutWriter{ResponseWriter: w, header: make(http.Header), status: http.StatusOK}
+		c.Writer = tw
+
+		timer := time.AfterFunc(timeout, func() { tw.timeout(lang) })
+		panicking := true
+		defer func() {
+			timer.Stop()
+			// A timed out request keeps the writer that drops writes and
+			// reports the 504 to the middlewares further out
+			if tw.finish(!panicking) {
+				c.Writer = w
+			}
+		}()
+		c.Next()
+		panicking = false
+	}
+}
+
+// timeoutWriter holds a handler's response until it is known whether the
+// handler finished in time
+type timeoutWriter struct {
+	gin.ResponseWriter
+
+	mu       sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	status   int
+	written  bool
+	timedOut bool
+	finished bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut || w.written || code <= 0 {
+		return
+	}
+	w.status = code
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.written = true
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *timeoutWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.written
+}
+
+// Flush is a no-op: nothing reaches the client before the handler is done
+func (w *timeoutWriter) Flush() {}
+
+// timeout sends the 504 and drops whatever the handler writes from now on.
+// It does nothing once the handler has finished.
+func (w *timeoutWriter) timeout(lang string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.finished {
+		return
+	}
+	w.timedOut = true
+	w.status = http.StatusGatewayTimeout
+
+	body, _ := json.Marshal(response.Response{
+		Success: false,
+		Message: i18n.T(lang, "common.timeout"),
+		Error:   &response.ErrorInfo{Code: "REQUEST_TIMEOUT"},
+	})
+	header := w.ResponseWriter.Header()
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	w.ResponseWriter.WriteHeader(http.StatusGatewayTimeout)
+	w.ResponseWriter.Write(body)
+	w.ResponseWriter.Flush()
+}
+
+// finish ends the request once the handler has returned, sending its
+// buffered response when send is set. It reports whether the request was
+// answered in time.
+func (w *timeoutWriter) finish(send bool) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.finished = true
+	if w.timedOut {
+		return false
+	}
+	if !send {
+		return true
+	}
+
+	dst := w.ResponseWriter.Header()
+	for key, values := range w.header {
+		dst[key] = values
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.written {
+		w.ResponseWriter.WriteHeaderNow()
+		w.ResponseWriter.Write(w.body.Bytes())
+	}
+	return true
+}
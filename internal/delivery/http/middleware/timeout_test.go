@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTimeoutServer(t *testing.T, timeout time.Duration, handler gin.HandlerFunc) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Timeout(RouteTimeouts{Default: timeout, Long: time.Minute}))
+	engine.GET("/", handler)
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// A handler that ignores its context still gets its client a 504 at the
+// deadline, not when it finally returns, and what it writes later is dropped
+func TestTimeoutSlowHandler(t *testing.T) {
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	server := newTimeoutServer(t, 50*time.Millisecond, func(c *gin.Context) {
+		defer close(finished)
+		<-release
+		c.JSON(http.StatusOK, gin.H{"late": true})
+	})
+	// Closing the server waits for the handler, so release it first
+	t.Cleanup(func() { <-finished })
+	t.Cleanup(func() { close(release) })
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	start := time.Now()
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("504 took %s, want it at the 50ms deadline", elapsed)
+	}
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want 504", resp.StatusCode)
+	}
+	var envelope struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.Error.Code != "REQUEST_TIMEOUT" {
+		t.Fatalf("body = %s, want the REQUEST_TIMEOUT envelope", body)
+	}
+	select {
+	case <-finished:
+		t.Fatal("handler finished before the 504 was received")
+	default:
+	}
+}
+
+func TestTimeoutHandlerInTime(t *testing.T) {
+	server := newTimeoutServer(t, time.Second, func(c *gin.Context) {
+		c.Header("X-Handler", "yes")
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("X-Handler") != "yes" {
+		t.Fatalf("status = %d header = %q, want the handler's 201", resp.StatusCode, resp.Header.Get("X-Handler"))
+	}
+	if string(body) != `{"ok":true}` {
+		t.Fatalf("body = %s", body)
+	}
+}
+
+// A panic is left to Recovery, further out, with the handler's partial
+// output dropped
+func TestTimeoutPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		defer func() {
+			if recover() != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"recovered": true})
+			}
+		}()
+		c.Next()
+	})
+	engine.Use(Timeout(RouteTimeouts{Default: time.Second}))
+	engine.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "partial")
+		panic("boom")
+	})
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	if recorder.Code != http.StatusInternalServerError || recorder.Body.String() != `{"recovered":true}` {
+		t.Fatalf("got %d %s, want Recovery's 500 alone", recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,105 @@
+package response
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ParseFields reads the ?fields= query parameter (comma separated JSON names).
+// It returns nil when no projection was requested, and the names that do not
+// exist on model so the caller can reject the request.
+func ParseFields(c *gin.Context, model interface{}) (fields []string, unknown []string) {
+	raw := strings.TrimSpace(c.Query("fields"))
+	if raw == "" {
+		return nil, nil
+	}
+
+	known := jsonFieldNames(reflect.TypeOf(model))
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			unknown = append(unknown, name)
+			continue
+		}
+		fields = append(fields, name)
+	}
+	return fields, unknown
+}
+
+// SelectFields projects a DTO, or a slice of DTOs, to the given JSON fields.
+// A nil field list returns data unchanged.
+func SelectFields(data interface{}, fields []string) interface{} {
+	if fields == nil {
+		return data
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return data
+	}
+
+	switch v := decoded.(type) {
+	case []interface{}:
+		for i, item := range v {
+			v[i] = projectObject(item, fields)
+		}
+		return v
+	default:
+		return projectObject(v, fields)
+	}
+}
+
+func projectObject(value interface{}, fields []string) interface{} {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	projected := make(map[string]interface{}, len(fields))
+	for _, name := range fields {
+		if v, ok := obj[name]; ok {
+			projected[name] = v
+		}
+	}
+	return projected
+}
+
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	names := make(map[string]bool)
+	if t.Kind() != reflect.Struct {
+		return names
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if field.Anonymous && tag == "" {
+			for name := range jsonFieldNames(field.Type) {
+				names[name] = true
+			}
+			continue
+		}
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
+}
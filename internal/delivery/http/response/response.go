@@ -22,11 +22,15 @@ type ErrorInfo struct {
 	Details map[string]string `json:"details,omitempty"`
 }
 
+// Meta describes a page. CountMode tells whether Total is exact, an
+// estimate, or absent ("none"), in which case HasNext is the only hint.
 type Meta struct {
-	Page       int `json:"page,omitempty"`
-	PageSize   int `json:"page_size,omitempty"`
-	Total      int `json:"total,omitempty"`
-	TotalPages int `json:"total_pages,omitempty"`
+	Page       int    `json:"page,omitempty"`
+	PageSize   int    `json:"page_size,omitempty"`
+	Total      int    `json:"total,omitempty"`
+	TotalPages int    `json:"total_pages,omitempty"`
+	CountMode  string `json:"count_mode,omitempty"`
+	HasNext    bool   `json:"has_next"`
 }
 
 func getLanguage(c *gin.Context) string {
@@ -63,6 +67,8 @@ func OKWithMeta(c *gin.Context, messageKey string, data interface{}, pagination
 			PageSize:   pagination.PageSize,
 			Total:      pagination.Total,
 			TotalPages: pagination.Pages,
+			CountMode:  pagination.CountMode,
+			HasNext:    pagination.HasNext,
 		},
 	})
 }
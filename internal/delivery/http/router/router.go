@@ -1,6 +1,7 @@
 package router
 
 import (
+	"strings"
 	"time"
 
 	"hr-management-system/internal/config"
@@ -12,6 +13,8 @@ import (
 	"hr-management-system/internal/infrastructure/logger"
 	"hr-management-system/internal/infrastructure/queue"
 	"hr-management-system/internal/infrastructure/search"
+	"hr-management-system/internal/infrastructure/storage"
+	"hr-management-system/internal/security"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,6 +26,7 @@ type Router struct {
 	cache  *cache.RedisCache
 	queue  *queue.Queue
 	es     *search.ElasticSearch
+	store  storage.Backend
 	email  *email.EmailService
 	log    *logger.Logger
 }
@@ -33,6 +37,7 @@ func NewRouter(
 	cache *cache.RedisCache,
 	queue *queue.Queue,
 	es *search.ElasticSearch,
+	store storage.Backend,
 	emailSvc *email.EmailService,
 	log *logger.Logger,
 ) *Router {
@@ -49,6 +54,7 @@ func NewRouter(
 		cache:  cache,
 		queue:  queue,
 		es:     es,
+		store:  store,
 		email:  emailSvc,
 		log:    log,
 	}
@@ -59,10 +65,17 @@ func (r *Router) Setup() *gin.Engine {
 	r.engine.Use(middleware.Recovery(r.log))
 	r.engine.Use(middleware.RequestID())
 	r.engine.Use(middleware.Logger(r.log))
+	if r.cfg.Logger.DebugBodies && r.cfg.App.Environment != "production" {
+		r.engine.Use(middleware.DebugBodyLogger(r.log, &r.cfg.Logger))
+	}
 	r.engine.Use(middleware.CORS(&r.cfg.Security))
 	r.engine.Use(middleware.SecurityHeaders())
 	r.engine.Use(middleware.Language())
-	r.engine.Use(middleware.Timeout(30 * time.Second))
+	r.engine.Use(middleware.Timeout(middleware.RouteTimeouts{
+		Default:  r.cfg.App.RequestTimeout,
+		Long:     r.cfg.App.LongRequestTimeout,
+		Classify: routeTimeoutClass,
+	}))
 
 	// Health check
 	r.engine.GET("/health", r.healthCheck)
@@ -75,22 +88,52 @@ func (r *Router) Setup() *gin.Engine {
 		v1.Use(middleware.RateLimiter(r.cache, &r.cfg.RateLimit))
 
 		r.setupAuthRoutes(v1)
+		r.setupUserRoutes(v1)
 		r.setupEmployeeRoutes(v1)
 		r.setupDepartmentRoutes(v1)
 		r.setupPositionRoutes(v1)
 		r.setupAttendanceRoutes(v1)
+		r.setupHolidayRoutes(v1)
 		r.setupLeaveRoutes(v1)
 		r.setupOvertimeRoutes(v1)
 		r.setupPayrollRoutes(v1)
 		r.setupRoleRoutes(v1)
 		r.setupAddressRoutes(v1)
 		r.setupReportRoutes(v1)
+		r.setupDashboardRoutes(v1)
 		r.setupNotificationRoutes(v1)
+		r.setupValidationRoutes(v1)
+		r.setupSystemRoutes(v1)
+		r.setupAuditRoutes(v1)
+		r.setupFileRoutes(v1)
 	}
 
 	return r.engine
 }
 
+// Path fragments that put a route in a timeout class
+var (
+	streamingRoutes   = []string{"/download", "/stream", "/live", "/files/"}
+	longRunningRoutes = []string{"/export", "/import", "/search", "/calculate", "/pdf", "/reports/"}
+)
+
+// routeTimeoutClass classes a route for the request timeout by its path.
+// Streams and downloads are exempt, bulk and reporting work gets the long
+// timeout and everything else the default.
+func routeTimeoutClass(path string) middleware.TimeoutClass {
+	for _, fragment := range streamingRoutes {
+		if strings.Contains(path, fragment) {
+			return middleware.TimeoutNone
+		}
+	}
+	for _, fragment := range longRunningRoutes {
+		if strings.Contains(path, fragment) {
+			return middleware.TimeoutLong
+		}
+	}
+	return middleware.TimeoutDefault
+}
+
 func (r *Router) setupAuthRoutes(rg *gin.RouterGroup) {
 	authHandler := handler.NewAuthHandler(r.db, r.cache, r.queue, r.email, r.log, r.cfg)
 
@@ -102,7 +145,7 @@ func (r *Router) setupAuthRoutes(rg *gin.RouterGroup) {
 		auth.POST("/refresh", authHandler.RefreshToken)
 		auth.POST("/forgot-password", middleware.EndpointRateLimiter(r.cache, 3, time.Minute), authHandler.ForgotPassword)
 		auth.POST("/reset-password", authHandler.ResetPassword)
-		auth.POST("/verify-2fa", authHandler.Verify2FA)
+		auth.POST("/verify-2fa", middleware.EndpointRateLimiter(r.cache, 10, time.Minute), authHandler.Verify2FA)
 		auth.POST("/send-otp", middleware.EndpointRateLimiter(r.cache, 3, 5*time.Minute), authHandler.SendOTP)
 		auth.POST("/verify-otp", authHandler.VerifyOTP)
 
@@ -113,26 +156,72 @@ func (r *Router) setupAuthRoutes(rg *gin.RouterGroup) {
 			protected.POST("/logout", authHandler.Logout)
 			protected.POST("/change-password", authHandler.ChangePassword)
 			protected.GET("/profile", authHandler.GetProfile)
+			protected.GET("/whoami", authHandler.WhoAmI)
+			protected.GET("/onboarding", authHandler.Onboarding)
+			protected.GET("/login-history", authHandler.LoginHistory)
+			protected.GET("/sessions", authHandler.ListSessions)
+			protected.DELETE("/sessions", authHandler.RevokeOtherSessions)
+			protected.DELETE("/sessions/:id", authHandler.RevokeSession)
+			protected.POST("/2fa/setup", middleware.EndpointRateLimiter(r.cache, 5, time.Minute), authHandler.Setup2FA)
+			protected.POST("/2fa/confirm", middleware.EndpointRateLimiter(r.cache, 5, time.Minute), authHandler.Confirm2FA)
+			protected.POST("/2fa/disable", middleware.EndpointRateLimiter(r.cache, 5, time.Minute), authHandler.Disable2FA)
 		}
 	}
 }
 
+// requireOnboarding holds back users who have not finished the first-login
+// checklist when ONBOARDING_ENFORCE is set
+func (r *Router) requireOnboarding() gin.HandlerFunc {
+	authHandler := handler.NewAuthHandler(r.db, r.cache, r.queue, r.email, r.log, r.cfg)
+	return middleware.RequireOnboarding(r.cfg.Onboarding.Enforce, authHandler.OnboardingCompleted)
+}
+
+func (r *Router) setupUserRoutes(rg *gin.RouterGroup) {
+	authHandler := handler.NewAuthHandler(r.db, r.cache, r.queue, r.email, r.log, r.cfg)
+
+	users := rg.Group("/users")
+	users.Use(middleware.JWTAuth(&r.cfg.JWT, r.cache))
+	{
+		users.GET("/:id/login-history", middleware.RequirePermission("users.view"), authHandler.UserLoginHistory)
+		users.GET("/:id/reset-tokens", middleware.RequirePermission("users.view"), authHandler.ListResetTokens)
+		users.DELETE("/:id/reset-tokens", middleware.RequirePermission("users.update"), authHandler.RevokeResetTokens)
+	}
+}
+
 func (r *Router) setupEmployeeRoutes(rg *gin.RouterGroup) {
-	h := handler.NewEmployeeHandler(r.db, r.cache, r.queue, r.es, r.log, r.cfg)
+	h := handler.NewEmployeeHandler(r.db, r.cache, r.queue, r.es, r.store, r.log, r.cfg)
 
 	employees := rg.Group("/employees")
 	employees.Use(middleware.JWTAuth(&r.cfg.JWT, r.cache))
 	{
-		employees.GET("", middleware.RequirePermission("employees.view"), h.List)
+		employees.GET("", middleware.RequirePermission("employees.view", "employees.view.team"), h.List)
 		employees.GET("/search", middleware.RequirePermission("employees.view"), h.Search)
-		employees.GET("/:id", middleware.RequirePermission("employees.view"), h.Get)
+		employees.GET("/export", middleware.RequirePermission("employees.view"), h.Export)
+		employees.POST("/batch", middleware.RequirePermission("employees.view"), h.Batch)
+		employees.POST("/import", middleware.RequirePermission("employees.create"), h.Import)
+		employees.GET("/:id", middleware.RequirePermission("employees.view", "employees.view.team"), h.Get)
 		employees.POST("", middleware.RequirePermission("employees.create"), h.Create)
 		employees.PUT("/:id", middleware.RequirePermission("employees.update"), h.Update)
 		employees.DELETE("/:id", middleware.RequirePermission("employees.delete"), h.Delete)
+		employees.POST("/:id/terminate", middleware.RequirePermission("employees.update"), h.Terminate)
+		employees.POST("/:id/probation", middleware.RequirePermission("employees.update"), h.ReviewProbation)
+		employees.POST("/:id/avatar", h.UploadAvatar)
+		employees.GET("/:id/documents", middleware.RequirePermission("employees.view"), h.ListDocuments)
+		employees.POST("/:id/documents", middleware.RequirePermission("employees.update"), h.UploadDocument)
+		employees.GET("/:id/documents/:document_id/download", middleware.RequirePermission("employees.view"), h.DownloadDocument)
+		employees.DELETE("/:id/documents/:document_id", middleware.RequirePermission("employees.update"), h.DeleteDocument)
+		employees.GET("/:id/dependents", middleware.RequirePermission("employees.view"), h.ListDependents)
+		employees.POST("/:id/dependents", middleware.RequirePermission("employees.update"), h.CreateDependent)
+		employees.PUT("/:id/dependents/:dependent_id", middleware.RequirePermission("employees.update"), h.UpdateDependent)
+		employees.DELETE("/:id/dependents/:dependent_id", middleware.RequirePermission("employees.update"), h.DeleteDependent)
+		employees.GET("/:id/notes", h.ListNotes)
+		employees.POST("/:id/notes", h.CreateNote)
 	}
 }
 
 func (r *Router) setupDepartmentRoutes(rg *gin.RouterGroup) {
+	h := handler.NewDepartmentHandler(r.db, r.cache, r.queue, r.log, r.cfg)
+
 	departments := rg.Group("/departments")
 	departments.Use(middleware.JWTAuth(&r.cfg.JWT, r.cache))
 	{
@@ -142,22 +231,29 @@ func (r *Router) setupDepartmentRoutes(rg *gin.RouterGroup) {
 		departments.GET("/tree", middleware.RequirePermission("departments.view"), func(c *gin.Context) {
 			// Department tree handler
 		})
+		departments.POST("/batch", middleware.RequirePermission("departments.view"), h.Batch)
 		departments.GET("/:id", middleware.RequirePermission("departments.view"), func(c *gin.Context) {})
 		departments.POST("", middleware.RequirePermission("departments.create"), func(c *gin.Context) {})
 		departments.PUT("/:id", middleware.RequirePermission("departments.update"), func(c *gin.Context) {})
-		departments.DELETE("/:id", middleware.RequirePermission("departments.delete"), func(c *gin.Context) {})
+		departments.DELETE("/:id", middleware.RequirePermission("departments.delete"), h.Delete)
+		departments.GET("/:id/approvers", middleware.RequirePermission("departments.view"), h.ListApprovers)
+		departments.PUT("/:id/approvers/:type", middleware.RequirePermission("departments.update"), h.SetApprovers)
+		departments.DELETE("/:id/approvers/:type", middleware.RequirePermission("departments.update"), h.DeleteApprovers)
 	}
 }
 
 func (r *Router) setupPositionRoutes(rg *gin.RouterGroup) {
+	h := handler.NewPositionHandler(r.db, r.cache, r.queue, r.log, r.cfg)
+
 	positions := rg.Group("/positions")
 	positions.Use(middleware.JWTAuth(&r.cfg.JWT, r.cache))
 	{
 		positions.GET("", middleware.RequirePermission("positions.view"), func(c *gin.Context) {})
+		positions.POST("/batch", middleware.RequirePermission("positions.view"), h.Batch)
 		positions.GET("/:id", middleware.RequirePermission("positions.view"), func(c *gin.Context) {})
 		positions.POST("", middleware.RequirePermission("positions.create"), func(c *gin.Context) {})
 		positions.PUT("/:id", middleware.RequirePermission("positions.update"), func(c *gin.Context) {})
-		positions.DELETE("/:id", middleware.RequirePermission("positions.delete"), func(c *gin.Context) {})
+		positions.DELETE("/:id", middleware.RequirePermission("positions.delete"), h.Delete)
 	}
 }
 
@@ -172,15 +268,41 @@ func (r *Router) setupAttendanceRoutes(rg *gin.RouterGroup) {
 		attendance.POST("/check-out", h.CheckOut)
 		attendance.GET("/my", h.GetMyAttendance)
 		attendance.GET("/today", h.GetTodayStatus)
+		attendance.GET("/team/today", h.GetTeamToday)
+		attendance.GET("/working-days", h.WorkingDays)
+		attendance.GET("/timesheet", h.Timesheet)
+		attendance.POST("/remote", h.RequestRemote)
+		attendance.GET("/remote/my", h.ListMyRemote)
+		attendance.DELETE("/remote/:id", h.CancelRemote)
 
 		// Management
 		attendance.GET("", middleware.RequirePermission("attendance.view"), h.List)
 		attendance.GET("/summary", middleware.RequirePermission("attendance.view"), h.GetSummary)
-		attendance.PUT("/:id/approve", middleware.RequirePermission("attendance.approve"), func(c *gin.Context) {})
+		attendance.GET("/live", middleware.RequirePermission("attendance.view"), h.Live)
+		attendance.GET("/remote", middleware.RequirePermission("attendance.view"), h.ListRemote)
+		attendance.PUT("/remote/:id/approve", middleware.RequirePermission("attendance.approve"), h.ApproveRemote)
+		attendance.POST("/shifts/rotate", middleware.RequirePermission("attendance.manage"), h.RotateShifts)
+		attendance.PUT("/:id/approve", middleware.RequirePermission("attendance.approve"), h.Approve)
+		attendance.POST("/manual", middleware.RequirePermission("attendance.create"), h.ManualEntry)
+	}
+}
+
+func (r *Router) setupHolidayRoutes(rg *gin.RouterGroup) {
+	h := handler.NewHolidayHandler(r.db, r.cache, r.queue, r.log, r.cfg)
+
+	holidays := rg.Group("/holidays")
+	holidays.Use(middleware.JWTAuth(&r.cfg.JWT, r.cache))
+	{
+		holidays.GET("", h.List)
+		holidays.POST("", middleware.RequirePermission("holidays.manage"), h.Create)
+		holidays.PUT("/:id", middleware.RequirePermission("holidays.manage"), h.Update)
+		holidays.DELETE("/:id", middleware.RequirePermission("holidays.manage"), h.Delete)
 	}
 }
 
 func (r *Router) setupLeaveRoutes(rg *gin.RouterGroup) {
+	h := handler.NewLeaveHandler(r.db, r.cache, r.queue, r.log, r.cfg)
+
 	leave := rg.Group("/leave")
 	leave.Use(middleware.JWTAuth(&r.cfg.JWT, r.cache))
 	{
@@ -190,27 +312,31 @@ func (r *Router) setupLeaveRoutes(rg *gin.RouterGroup) {
 		// Balance
 		leave.GET("/balance", func(c *gin.Context) {})
 		leave.GET("/balance/:employee_id", middleware.RequirePermission("leave.view"), func(c *gin.Context) {})
+		leave.POST("/balance/initialize", middleware.RequirePermission("leave.manage"), h.InitializeBalances)
+		leave.POST("/balance/:employee_id/recompute", middleware.RequirePermission("leave.manage"), h.RecomputeBalance)
 
 		// Requests
 		leave.GET("/requests", func(c *gin.Context) {})
 		leave.GET("/requests/pending", middleware.RequirePermission("leave.approve"), func(c *gin.Context) {})
 		leave.GET("/requests/:id", func(c *gin.Context) {})
-		leave.POST("/requests", func(c *gin.Context) {})
+		leave.POST("/requests", r.requireOnboarding(), h.Create)
 		leave.PUT("/requests/:id/cancel", func(c *gin.Context) {})
-		leave.PUT("/requests/:id/approve", middleware.RequirePermission("leave.approve"), func(c *gin.Context) {})
+		leave.PUT("/requests/:id/approve", middleware.RequirePermission("leave.approve"), h.Approve)
 	}
 }
 
 func (r *Router) setupOvertimeRoutes(rg *gin.RouterGroup) {
 	overtime := rg.Group("/overtime")
 	overtime.Use(middleware.JWTAuth(&r.cfg.JWT, r.cache))
+	h := handler.NewOvertimeHandler(r.db, r.cache, r.queue, r.log, r.cfg)
 	{
 		overtime.GET("/requests", func(c *gin.Context) {})
 		overtime.GET("/requests/pending", middleware.RequirePermission("overtime.approve"), func(c *gin.Context) {})
 		overtime.GET("/requests/:id", func(c *gin.Context) {})
-		overtime.POST("/requests", func(c *gin.Context) {})
+		overtime.POST("/requests", r.requireOnboarding(), h.Create)
 		overtime.PUT("/requests/:id/cancel", func(c *gin.Context) {})
-		overtime.PUT("/requests/:id/approve", middleware.RequirePermission("overtime.approve"), func(c *gin.Context) {})
+		overtime.PUT("/requests/:id/approve", middleware.RequirePermission("overtime.approve"), h.Approve)
+		overtime.POST("/approve-bulk", middleware.RequirePermission("overtime.approve"), h.ApproveBulk)
 
 		// Policy
 		overtime.GET("/policy", middleware.RequirePermission("overtime.view"), func(c *gin.Context) {})
@@ -219,23 +345,34 @@ func (r *Router) setupOvertimeRoutes(rg *gin.RouterGroup) {
 }
 
 func (r *Router) setupPayrollRoutes(rg *gin.RouterGroup) {
+	h := handler.NewPayrollHandler(r.db, r.cache, r.queue, r.log, r.cfg)
+
 	payroll := rg.Group("/payroll")
 	payroll.Use(middleware.JWTAuth(&r.cfg.JWT, r.cache))
+	payroll.Use(middleware.RouteIPFilter("payroll", r.cfg.Security.PayrollIPFilter, r.log))
 	{
 		// Periods
 		payroll.GET("/periods", middleware.RequirePermission("payroll.view"), func(c *gin.Context) {})
 		payroll.GET("/periods/:id", middleware.RequirePermission("payroll.view"), func(c *gin.Context) {})
 		payroll.POST("/periods", middleware.RequirePermission("payroll.create"), func(c *gin.Context) {})
 		payroll.POST("/periods/:id/calculate", middleware.RequirePermission("payroll.calculate"), func(c *gin.Context) {})
-		payroll.PUT("/periods/:id/approve", middleware.RequirePermission("payroll.approve"), func(c *gin.Context) {})
+		payroll.GET("/periods/:id/flags", middleware.RequirePermission("payroll.review"), h.ListFlags)
+		payroll.PUT("/periods/:id/review", middleware.RequirePermission("payroll.review"), h.Review)
+		payroll.PUT("/periods/:id/approve", middleware.RequirePermission("payroll.approve"), h.Approve)
 		payroll.PUT("/periods/:id/pay", middleware.RequirePermission("payroll.pay"), func(c *gin.Context) {})
 
 		// Payslips
 		payroll.GET("/payslips", func(c *gin.Context) {})
 		payroll.GET("/payslips/my", func(c *gin.Context) {})
+		payroll.GET("/payslips/my/annual", h.MyAnnualPayslips)
+		payroll.GET("/payslips/my/trend", h.MyPayslipTrend)
 		payroll.GET("/payslips/:id", func(c *gin.Context) {})
-		payroll.GET("/payslips/:id/pdf", func(c *gin.Context) {})
-		payroll.POST("/payslips/:id/send-email", middleware.RequirePermission("payroll.view"), func(c *gin.Context) {})
+		payroll.GET("/payslips/:id/pdf", h.PayslipPDF)
+		payroll.POST("/payslips/:id/send-email", h.SendPayslipEmail)
+
+		// Exchange rates
+		payroll.GET("/exchange-rates", middleware.RequirePermission("payroll.view"), h.ListExchangeRates)
+		payroll.POST("/exchange-rates", middleware.RequirePermission("payroll.manage"), h.SetExchangeRate)
 
 		// Allowances
 		payroll.GET("/allowances", middleware.RequirePermission("payroll.view"), func(c *gin.Context) {})
@@ -248,8 +385,11 @@ func (r *Router) setupPayrollRoutes(rg *gin.RouterGroup) {
 }
 
 func (r *Router) setupRoleRoutes(rg *gin.RouterGroup) {
+	h := handler.NewPermissionHandler(r.db, r.cache, r.queue, r.log, r.cfg)
+
 	roles := rg.Group("/roles")
 	roles.Use(middleware.JWTAuth(&r.cfg.JWT, r.cache))
+	roles.Use(middleware.RouteIPFilter("roles", r.cfg.Security.RolesIPFilter, r.log))
 	roles.Use(middleware.RequirePermission("roles.view"))
 	{
 		roles.GET("", func(c *gin.Context) {})
@@ -257,12 +397,14 @@ func (r *Router) setupRoleRoutes(rg *gin.RouterGroup) {
 		roles.POST("", middleware.RequirePermission("roles.create"), func(c *gin.Context) {})
 		roles.PUT("/:id", middleware.RequirePermission("roles.update"), func(c *gin.Context) {})
 		roles.DELETE("/:id", middleware.RequirePermission("roles.delete"), func(c *gin.Context) {})
+		roles.POST("/:id/preview-permissions", middleware.RequirePermission("roles.update"), h.PreviewPermissions)
 	}
 
 	permissions := rg.Group("/permissions")
 	permissions.Use(middleware.JWTAuth(&r.cfg.JWT, r.cache))
 	{
 		permissions.GET("", middleware.RequirePermission("permissions.view"), func(c *gin.Context) {})
+		permissions.GET("/catalog", middleware.RequirePermission("roles.create", "roles.update"), h.Catalog)
 	}
 }
 
@@ -282,13 +424,16 @@ func (r *Router) setupAddressRoutes(rg *gin.RouterGroup) {
 }
 
 func (r *Router) setupReportRoutes(rg *gin.RouterGroup) {
+	h := handler.NewReportHandler(r.db, r.cache, r.queue, r.store, r.log, r.cfg)
+
 	reports := rg.Group("/reports")
 	reports.Use(middleware.JWTAuth(&r.cfg.JWT, r.cache))
 	reports.Use(middleware.RequirePermission("reports.view"))
 	{
-		reports.POST("/generate", func(c *gin.Context) {})
-		reports.GET("/:id/status", func(c *gin.Context) {})
-		reports.GET("/:id/download", func(c *gin.Context) {})
+		reports.GET("/formats", h.Formats)
+		reports.POST("/generate", middleware.RequirePermission("reports.export"), h.Generate)
+		reports.GET("/:id/status", h.Status)
+		reports.GET("/:id/download", middleware.RequirePermission("reports.export"), h.Download)
 
 		// Pre-built reports
 		reports.GET("/attendance", func(c *gin.Context) {})
@@ -296,18 +441,93 @@ func (r *Router) setupReportRoutes(rg *gin.RouterGroup) {
 		reports.GET("/leave", func(c *gin.Context) {})
 		reports.GET("/overtime", func(c *gin.Context) {})
 		reports.GET("/employees", func(c *gin.Context) {})
+		reports.GET("/headcount-trend", h.HeadcountTrend)
+	}
+}
+
+func (r *Router) setupDashboardRoutes(rg *gin.RouterGroup) {
+	h := handler.NewReportHandler(r.db, r.cache, r.queue, r.store, r.log, r.cfg)
+
+	dashboard := rg.Group("/dashboard")
+	dashboard.Use(middleware.JWTAuth(&r.cfg.JWT, r.cache))
+	{
+		dashboard.GET("/kpis", middleware.RequirePermission("reports.view", "employees.view.team"), h.DashboardKPIs)
 	}
 }
 
 func (r *Router) setupNotificationRoutes(rg *gin.RouterGroup) {
+	h := handler.NewNotificationHandler(r.db, r.cache, r.queue, r.log, r.cfg)
+
 	notifications := rg.Group("/notifications")
 	notifications.Use(middleware.JWTAuth(&r.cfg.JWT, r.cache))
 	{
-		notifications.GET("", func(c *gin.Context) {})
-		notifications.GET("/unread-count", func(c *gin.Context) {})
-		notifications.PUT("/:id/read", func(c *gin.Context) {})
-		notifications.PUT("/read-all", func(c *gin.Context) {})
+		notifications.GET("", h.List)
+		notifications.GET("/unread-count", h.UnreadCount)
+		notifications.GET("/stream", h.Stream)
+		notifications.PUT("/:id/read", h.MarkRead)
+		notifications.PUT("/read-all", h.MarkAllRead)
+		notifications.POST("/sync", h.Sync)
+		notifications.POST("/broadcast", middleware.RequirePermission("notifications.broadcast"), h.Broadcast)
+		notifications.GET("/broadcasts/:id", middleware.RequirePermission("notifications.broadcast"), h.GetBroadcast)
+	}
+}
+
+func (r *Router) setupValidationRoutes(rg *gin.RouterGroup) {
+	h := handler.NewValidationHandler(r.log, r.cfg)
+
+	validate := rg.Group("/validate")
+	{
+		// Public, rate limited per IP
+		validate.POST("/contact", middleware.EndpointRateLimiter(r.cache, 30, time.Minute), h.ValidateContact)
+	}
+}
+
+func (r *Router) setupSystemRoutes(rg *gin.RouterGroup) {
+	h := handler.NewSystemHandler(r.db, r.cache, r.queue, r.log, r.cfg)
+
+	system := rg.Group("/system")
+	system.Use(middleware.JWTAuth(&r.cfg.JWT, r.cache))
+	system.Use(middleware.RequirePermission("system.manage"))
+	{
+		// Worker
+		system.GET("/worker", h.GetWorkerSettings)
+		system.PUT("/worker", h.UpdateWorkerSettings)
+		system.PUT("/worker/queues/:queue/pause", h.PauseQueue)
+		system.PUT("/worker/queues/:queue/resume", h.ResumeQueue)
+
+		// Tasks
+		system.GET("/tasks/:queue/:id", h.GetTask)
+
+		// Search
+		system.POST("/search/reconcile", h.ReconcileSearch)
+
+		// Notification deliveries
+		system.GET("/notifications/deliveries", h.ListNotificationDeliveries)
+		system.POST("/notifications/deliveries/:id/retry", h.RetryNotificationDelivery)
+	}
+}
+
+func (r *Router) setupAuditRoutes(rg *gin.RouterGroup) {
+	h := handler.NewAuditHandler(r.db, r.cache, r.queue, r.log, r.cfg)
+
+	audit := rg.Group("/audit-logs")
+	audit.Use(middleware.JWTAuth(&r.cfg.JWT, r.cache))
+	audit.Use(middleware.RequirePermission("audit.view"))
+	{
+		audit.GET("/rbac", h.ListRBAC)
+		audit.GET("/:id", h.Get)
+	}
+}
+
+// setupFileRoutes serves signed links of the local storage backend; object
+// storage links point at the bucket instead
+func (r *Router) setupFileRoutes(rg *gin.RouterGroup) {
+	local, ok := r.store.(*storage.Local)
+	if !ok {
+		return
 	}
+	h := handler.NewFileHandler(local, r.log)
+	rg.GET("/files/*key", h.Serve)
 }
 
 func (r *Router) healthCheck(c *gin.Context) {
@@ -318,6 +538,14 @@ func (r *Router) healthCheck(c *gin.Context) {
 	})
 }
 
+// rateLimitMode describes how requests are limited while Redis is down
+func rateLimitMode(fallback bool) string {
+	if fallback {
+		return "fallback"
+	}
+	return "degraded"
+}
+
 func (r *Router) readinessCheck(c *gin.Context) {
 	// Check database
 	if err := r.db.HealthCheck(c.Request.Context()); err != nil {
@@ -327,13 +555,21 @@ func (r *Router) readinessCheck(c *gin.Context) {
 
 	// Check redis
 	if err := r.cache.HealthCheck(c.Request.Context()); err != nil {
-		c.JSON(503, gin.H{"status": "unhealthy", "redis": "down"})
+		c.JSON(503, gin.H{"status": "unhealthy", "redis": "down", "rate_limit": rateLimitMode(r.cfg.RateLimit.FallbackEnabled)})
 		return
 	}
 
-	c.JSON(200, gin.H{
-		"status":   "ready",
-		"database": "up",
-		"redis":    "up",
-	})
+	// Redis answers again but limits may still be kept in process until
+	// the next limited request notices
+	status := gin.H{
+		"status":     "ready",
+		"database":   "up",
+		"redis":      "up",
+		"rate_limit": "ok",
+	}
+	if degraded, since := security.RateLimitDegraded(); degraded {
+		status["rate_limit"] = rateLimitMode(r.cfg.RateLimit.FallbackEnabled)
+		status["rate_limit_degraded_since"] = since
+	}
+	c.JSON(200, status)
 }
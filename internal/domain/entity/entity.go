@@ -50,14 +50,15 @@ const (
 
 type UserSession struct {
 	BaseModel
-	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
-	Token        string     `json:"-" db:"token"`
-	RefreshToken string     `json:"-" db:"refresh_token"`
-	UserAgent    string     `json:"user_agent" db:"user_agent"`
-	IPAddress    string     `json:"ip_address" db:"ip_address"`
-	ExpiresAt    time.Time  `json:"expires_at" db:"expires_at"`
-	LastActivity time.Time  `json:"last_activity" db:"last_activity"`
-	IsRevoked    bool       `json:"is_revoked" db:"is_revoked"`
+	UserID           uuid.UUID `json:"user_id" db:"user_id"`
+	SessionID        string    `json:"-" db:"session_id"`
+	FamilyID         string    `json:"-" db:"family_id"`
+	RefreshTokenHash string    `json:"-" db:"refresh_token_hash"` // SHA-256 of the current refresh token
+	UserAgent        string    `json:"user_agent" db:"user_agent"`
+	IPAddress        string    `json:"ip_address" db:"ip_address"`
+	ExpiresAt        time.Time `json:"expires_at" db:"expires_at"`
+	LastActivity     time.Time `json:"last_activity" db:"last_activity"`
+	IsRevoked        bool      `json:"is_revoked" db:"is_revoked"`
 }
 
 type OTPCode struct {
@@ -203,12 +204,16 @@ type Employee struct {
 	EmploymentStatus EmploymentStatus `json:"employment_status" db:"employment_status"`
 	JoinDate        time.Time        `json:"join_date" db:"join_date"`
 	ProbationEndDate sql.NullTime    `json:"probation_end_date" db:"probation_end_date"`
+	ProbationStatus  sql.NullString  `json:"probation_status" db:"probation_status"`
+	ProbationReviewedBy uuid.NullUUID `json:"probation_reviewed_by" db:"probation_reviewed_by"`
+	ProbationReviewedAt sql.NullTime  `json:"probation_reviewed_at" db:"probation_reviewed_at"`
 	ContractStartDate sql.NullTime   `json:"contract_start_date" db:"contract_start_date"`
 	ContractEndDate  sql.NullTime    `json:"contract_end_date" db:"contract_end_date"`
 	ResignationDate  sql.NullTime    `json:"resignation_date" db:"resignation_date"`
 	
 	// Salary
 	BaseSalary      float64 `json:"base_salary" db:"base_salary"`
+	SalaryCurrency  string  `json:"salary_currency" db:"salary_currency"`
 	SalaryGrade     string  `json:"salary_grade" db:"salary_grade"`
 	
 	// Relations
@@ -320,6 +325,8 @@ type Attendance struct {
 	Notes          sql.NullString     `json:"notes" db:"notes"`
 	ApprovedBy     uuid.NullUUID      `json:"approved_by" db:"approved_by"`
 	ApprovedAt     sql.NullTime       `json:"approved_at" db:"approved_at"`
+	AutoClosed     bool               `json:"auto_closed" db:"auto_closed"`
+	NeedsRegularization bool          `json:"needs_regularization" db:"needs_regularization"`
 	
 	Employee   *Employee `json:"employee,omitempty"`
 	Approver   *Employee `json:"approver,omitempty"`
@@ -375,15 +382,18 @@ type EmployeeShift struct {
 
 type LeaveType struct {
 	BaseModel
-	Name             string  `json:"name" db:"name"`
-	Code             string  `json:"code" db:"code"`
-	Description      string  `json:"description" db:"description"`
-	DefaultDays      int     `json:"default_days" db:"default_days"`
-	MaxCarryOver     int     `json:"max_carry_over" db:"max_carry_over"`
-	IsPaid           bool    `json:"is_paid" db:"is_paid"`
-	RequiresApproval bool    `json:"requires_approval" db:"requires_approval"`
-	Color            string  `json:"color" db:"color"`
-	Status           string  `json:"status" db:"status"`
+	Name               string `json:"name" db:"name"`
+	Code               string `json:"code" db:"code"`
+	Description        string `json:"description" db:"description"`
+	DefaultDays        int    `json:"default_days" db:"default_days"`
+	MaxCarryOver       int    `json:"max_carry_over" db:"max_carry_over"`
+	IsPaid             bool   `json:"is_paid" db:"is_paid"`
+	RequiresApproval   bool   `json:"requires_approval" db:"requires_approval"`
+	Encashable         bool   `json:"encashable" db:"encashable"`
+	MinNoticeDays      int    `json:"min_notice_days" db:"min_notice_days"`
+	MaxConsecutiveDays int    `json:"max_consecutive_days" db:"max_consecutive_days"`
+	Color              string `json:"color" db:"color"`
+	Status             string `json:"status" db:"status"`
 }
 
 type LeaveBalance struct {
@@ -400,6 +410,21 @@ type LeaveBalance struct {
 	LeaveType *LeaveType `json:"leave_type,omitempty"`
 }
 
+type LeaveEncashment struct {
+	ID              uuid.UUID     `json:"id" db:"id"`
+	EmployeeID      uuid.UUID     `json:"employee_id" db:"employee_id"`
+	LeaveTypeID     uuid.UUID     `json:"leave_type_id" db:"leave_type_id"`
+	Year            int           `json:"year" db:"year"`
+	Reason          string        `json:"reason" db:"reason"`
+	Days            float64       `json:"days" db:"days"`
+	DailyRate       float64       `json:"daily_rate" db:"daily_rate"`
+	Amount          float64       `json:"amount" db:"amount"`
+	PayrollPeriodID uuid.NullUUID `json:"payroll_period_id" db:"payroll_period_id"`
+	Status          string        `json:"status" db:"status"`
+	CreatedAt       time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at" db:"updated_at"`
+}
+
 type LeaveRequest struct {
 	BaseModel
 	EmployeeID    uuid.UUID        `json:"employee_id" db:"employee_id"`
@@ -449,6 +474,19 @@ type OvertimeRequest struct {
 	Approver *Employee `json:"approver,omitempty"`
 }
 
+// OvertimeRequestSegment is the part of a request on one date at one rate.
+// Payroll and monthly caps count segments by their own date.
+type OvertimeRequestSegment struct {
+	ID                uuid.UUID    `json:"id" db:"id"`
+	OvertimeRequestID uuid.UUID    `json:"overtime_request_id" db:"overtime_request_id"`
+	Date              time.Time    `json:"date" db:"date"`
+	StartTime         string       `json:"start_time" db:"start_time"`
+	EndTime           string       `json:"end_time" db:"end_time"`
+	Hours             float64      `json:"hours" db:"hours"`
+	Type              OvertimeType `json:"type" db:"type"`
+	Multiplier        float64      `json:"multiplier" db:"multiplier"`
+}
+
 type OvertimeType string
 
 const (
@@ -493,6 +531,7 @@ type PayrollPeriod struct {
 	Status      PayrollStatus `json:"status" db:"status"`
 	ProcessedBy uuid.NullUUID `json:"processed_by" db:"processed_by"`
 	ProcessedAt sql.NullTime  `json:"processed_at" db:"processed_at"`
+	CalculatedAt sql.NullTime `json:"calculated_at" db:"calculated_at"`
 	ApprovedBy  uuid.NullUUID `json:"approved_by" db:"approved_by"`
 	ApprovedAt  sql.NullTime  `json:"approved_at" db:"approved_at"`
 	Notes       sql.NullString `json:"notes" db:"notes"`
@@ -543,6 +582,7 @@ type Payslip struct {
 	
 	// Net
 	NetSalary         float64          `json:"net_salary" db:"net_salary"`
+	Currency          string           `json:"currency" db:"currency"`
 	
 	// Details JSON
 	EarningsDetails   string           `json:"earnings_details" db:"earnings_details"`
@@ -618,9 +658,22 @@ type Holiday struct {
 	Type        string    `json:"type" db:"type"`
 	Description string    `json:"description" db:"description"`
 	IsRecurring bool      `json:"is_recurring" db:"is_recurring"`
+	IsHalfDay   bool      `json:"is_half_day" db:"is_half_day"`
 	Year        int       `json:"year" db:"year"`
 }
 
+// ==================== HEADCOUNT ====================
+
+type HeadcountSnapshot struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	DepartmentID uuid.UUID `json:"department_id" db:"department_id"`
+	SnapshotDate time.Time `json:"snapshot_date" db:"snapshot_date"`
+	Headcount    int       `json:"headcount" db:"headcount"`
+	Joiners      int       `json:"joiners" db:"joiners"`
+	Leavers      int       `json:"leavers" db:"leavers"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
 // ==================== AUDIT LOG ====================
 
 type AuditLog struct {
@@ -636,6 +689,41 @@ type AuditLog struct {
 	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
 }
 
+// ==================== REPORTS ====================
+
+// Report is a report generated by the worker. FileKey is the file's key in
+// storage once the report is completed.
+type Report struct {
+	ID          uuid.UUID      `json:"id" db:"id"`
+	ReportType  string         `json:"report_type" db:"report_type"`
+	Format      string         `json:"format" db:"format"`
+	Filters     sql.NullString `json:"filters" db:"filters"`
+	Status      ReportStatus   `json:"status" db:"status"`
+	TaskID      sql.NullString `json:"task_id" db:"task_id"`
+	FileKey     sql.NullString `json:"file_key" db:"file_key"`
+	FileName    sql.NullString `json:"file_name" db:"file_name"`
+	ContentType sql.NullString `json:"content_type" db:"content_type"`
+	FileSize    int64          `json:"file_size" db:"file_size"`
+	RowCount    int            `json:"row_count" db:"row_count"`
+	Email       sql.NullString `json:"email" db:"email"`
+	EmailedAt   sql.NullTime   `json:"emailed_at" db:"emailed_at"`
+	Error       sql.NullString `json:"error" db:"error"`
+	RequestedBy uuid.NullUUID  `json:"requested_by" db:"requested_by"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	StartedAt   sql.NullTime   `json:"started_at" db:"started_at"`
+	CompletedAt sql.NullTime   `json:"completed_at" db:"completed_at"`
+	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+type ReportStatus string
+
+const (
+	ReportStatusPending    ReportStatus = "pending"
+	ReportStatusProcessing ReportStatus = "processing"
+	ReportStatusCompleted  ReportStatus = "completed"
+	ReportStatusFailed     ReportStatus = "failed"
+)
+
 // ==================== NOTIFICATIONS ====================
 
 type Notification struct {
@@ -204,6 +204,8 @@ var viTranslations = map[string]string{
 	"common.updated":              "Cập nhật thành công",
 	"common.deleted":              "Xóa thành công",
 	"common.list":                 "Lấy danh sách thành công",
+	"common.unknown_fields":       "Trường không hợp lệ trong tham số fields",
+	"common.timeout":              "Yêu cầu xử lý quá lâu, vui lòng thử lại",
 	
 	// Auth
 	"auth.login_success":          "Đăng nhập thành công",
@@ -221,6 +223,16 @@ var viTranslations = map[string]string{
 	"auth.refresh_success":        "Làm mới token thành công",
 	"auth.two_factor_required":    "Yêu cầu xác thực 2 bước",
 	"auth.two_factor_invalid":     "Mã xác thực không đúng",
+	"auth.two_factor_disabled":    "Đã tắt xác thực 2 bước",
+	"auth.two_factor_not_enabled": "Xác thực 2 bước chưa được bật",
+	"auth.password_incorrect":     "Mật khẩu không đúng",
+	"auth.password_breached":      "Mật khẩu này đã xuất hiện trong các vụ rò rỉ dữ liệu, vui lòng chọn mật khẩu khác",
+	"auth.onboarding_required":    "Vui lòng hoàn tất các bước thiết lập tài khoản trước",
+	"auth.two_factor_already_enabled": "Xác thực 2 bước đã được bật",
+	"auth.two_factor_setup":       "Quét mã QR bằng ứng dụng xác thực và nhập mã để hoàn tất",
+	"auth.two_factor_setup_required": "Chưa thiết lập ứng dụng xác thực",
+	"auth.two_factor_enabled":     "Đã bật xác thực 2 bước",
+	"auth.reset_tokens_revoked":   "Đã vô hiệu hóa các liên kết đặt lại mật khẩu",
 	
 	// OTP
 	"otp.sent":                    "Mã OTP đã được gửi",
@@ -228,6 +240,8 @@ var viTranslations = map[string]string{
 	"otp.invalid":                 "Mã OTP không đúng",
 	"otp.expired":                 "Mã OTP đã hết hạn",
 	"otp.too_many_attempts":       "Vượt quá số lần thử. Vui lòng yêu cầu mã mới",
+	"otp.phone_required":          "Tài khoản chưa có số điện thoại",
+	"otp.phone_not_verified":      "Số điện thoại chưa được xác thực",
 	
 	// User
 	"user.created":                "Tạo người dùng thành công",
@@ -243,6 +257,30 @@ var viTranslations = map[string]string{
 	"employee.deleted":            "Xóa nhân viên thành công",
 	"employee.not_found":          "Không tìm thấy nhân viên",
 	"employee.code_exists":        "Mã nhân viên đã tồn tại",
+	"employee.terminated":         "Đã chấm dứt hợp đồng nhân viên",
+	"employee.probation_reviewed": "Đã cập nhật kết quả thử việc",
+	"employee.not_on_probation":   "Nhân viên không trong thời gian thử việc",
+	"employee.invalid_status_transition": "Không thể chuyển trạng thái nhân viên như yêu cầu",
+	"employee.unsupported_currency": "Loại tiền tệ không được hỗ trợ",
+	"employee.avatar_updated":     "Cập nhật ảnh đại diện thành công",
+	"employee.imported":           "Đã xử lý tệp nhập nhân viên",
+	"employee.import_invalid_file": "Không đọc được tệp nhập, vui lòng dùng CSV hoặc XLSX",
+	"employee.import_empty":       "Tệp nhập không có dữ liệu",
+	"employee.import_too_many_rows": "Tệp nhập có quá nhiều dòng",
+	"employee.import_invalid_number": "Giá trị số không hợp lệ",
+	"employee.import_invalid_value": "Giá trị không hợp lệ",
+	"employee.import_invalid_date": "Ngày không hợp lệ",
+	"employee.import_duplicate_email": "Email bị trùng với một dòng khác trong tệp",
+	"employee.import_manager_not_found": "Không tìm thấy quản lý",
+	"employee.import_failed":      "Không thể tạo nhân viên từ dòng này",
+	"dependent.created":           "Đã thêm người phụ thuộc",
+	"dependent.updated":           "Đã cập nhật người phụ thuộc",
+	"dependent.deleted":           "Đã xóa người phụ thuộc",
+	"dependent.not_found":         "Không tìm thấy người phụ thuộc",
+	"dependent.invalid_period":    "Thời gian giảm trừ không hợp lệ",
+	"dependent.overlapping_period": "Người phụ thuộc đã được đăng ký trong khoảng thời gian này",
+	"note.created":                "Đã thêm ghi chú",
+	"note.forbidden":              "Chỉ quản lý của nhân viên hoặc nhân sự mới được ghi chú",
 	
 	// Department
 	"department.created":          "Tạo phòng ban thành công",
@@ -250,6 +288,14 @@ var viTranslations = map[string]string{
 	"department.deleted":          "Xóa phòng ban thành công",
 	"department.not_found":        "Không tìm thấy phòng ban",
 	"department.has_employees":    "Không thể xóa phòng ban còn nhân viên",
+	"department.approvers_updated": "Cập nhật người phê duyệt thành công",
+	"department.approvers_not_found": "Phòng ban chưa cấu hình người phê duyệt",
+	"department.approver_required": "Cần chỉ định người phê duyệt chính hoặc vai trò phê duyệt",
+	"department.invalid_backup_approver": "Người phê duyệt dự phòng phải khác người phê duyệt chính",
+	"department.invalid_approver": "Người phê duyệt phải là nhân viên đang làm việc",
+	"department.invalid_approver_role": "Không tìm thấy vai trò phê duyệt",
+	"department.has_children":     "Không thể xóa phòng ban còn phòng ban con",
+	"department.invalid_reassign_target": "Phòng ban tiếp nhận nhân viên không hợp lệ",
 	
 	// Attendance
 	"attendance.check_in":         "Chấm công vào thành công",
@@ -257,6 +303,28 @@ var viTranslations = map[string]string{
 	"attendance.already_checked_in": "Đã chấm công vào hôm nay",
 	"attendance.not_checked_in":   "Chưa chấm công vào",
 	"attendance.already_checked_out": "Đã chấm công ra hôm nay",
+	"attendance.insufficient_rest": "Chưa đủ thời gian nghỉ tối thiểu kể từ lần làm việc trước",
+	"attendance.shifts_assigned":  "Phân ca thành công",
+	"attendance.shift_not_found":  "Không tìm thấy ca làm việc",
+	"attendance.shift_overlap":    "Ca làm việc bị chồng lấn",
+	"attendance.outside_office_network": "Chỉ được chấm công tại văn phòng từ mạng nội bộ",
+	"attendance.remote_requested": "Đăng ký làm việc từ xa thành công",
+	"attendance.remote_approved":  "Đã duyệt đăng ký làm việc từ xa",
+	"attendance.remote_rejected":  "Đã từ chối đăng ký làm việc từ xa",
+	"attendance.remote_cancelled": "Đã hủy đăng ký làm việc từ xa",
+	"attendance.remote_not_found": "Không tìm thấy đăng ký làm việc từ xa",
+	"attendance.remote_not_pending": "Đăng ký làm việc từ xa không ở trạng thái chờ duyệt",
+	"attendance.remote_not_cancellable": "Không thể hủy đăng ký làm việc từ xa này",
+	"attendance.remote_by_default": "Nhân viên làm việc từ xa toàn thời gian không cần đăng ký",
+	"attendance.remote_invalid_range": "Khoảng thời gian làm việc từ xa không hợp lệ",
+	"attendance.remote_notice_required": "Cần đăng ký làm việc từ xa trước thời hạn quy định",
+	"attendance.remote_no_working_days": "Khoảng thời gian không có ngày làm việc",
+	"attendance.remote_overlap":   "Trùng với đăng ký làm việc từ xa khác",
+	"attendance.remote_cap_exceeded": "Vượt quá số ngày làm việc từ xa cho phép trong tháng",
+	"attendance.outside_geofence": "Vị trí chấm công nằm ngoài phạm vi văn phòng",
+	"attendance.invalid_location": "Vị trí chấm công phải có dạng \"vĩ độ,kinh độ\"",
+	"attendance.not_found":        "Không tìm thấy bản ghi chấm công",
+	"attendance.invalid_times":    "Giờ ra phải sau giờ vào",
 	
 	// Leave
 	"leave.created":               "Tạo đơn nghỉ phép thành công",
@@ -266,6 +334,14 @@ var viTranslations = map[string]string{
 	"leave.not_found":             "Không tìm thấy đơn nghỉ phép",
 	"leave.insufficient_balance":  "Số ngày phép không đủ",
 	"leave.overlap":               "Ngày nghỉ trùng với đơn khác",
+	"leave.auto_approved":         "Đơn nghỉ phép đã được tự động phê duyệt",
+	"leave.type_not_found":        "Không tìm thấy loại nghỉ phép",
+	"leave.no_working_days":       "Khoảng thời gian nghỉ không có ngày làm việc",
+	"leave.balance_recomputed":    "Đã đối soát số ngày phép",
+	"leave.notice_required":       "Đơn nghỉ phép chưa được gửi trước đủ số ngày quy định",
+	"leave.too_long":              "Số ngày nghỉ liên tiếp vượt quá mức cho phép",
+	"leave.balances_initialized":  "Đã khởi tạo số ngày phép cho năm mới",
+	"leave.not_pending":           "Đơn nghỉ phép không còn ở trạng thái chờ duyệt",
 	
 	// Overtime
 	"overtime.created":            "Tạo đề xuất tăng ca thành công",
@@ -273,6 +349,10 @@ var viTranslations = map[string]string{
 	"overtime.rejected":           "Từ chối tăng ca",
 	"overtime.not_found":          "Không tìm thấy đề xuất tăng ca",
 	"overtime.max_hours_exceeded": "Vượt quá số giờ tăng ca tối đa",
+	"overtime.invalid_time_range": "Giờ kết thúc phải sau giờ bắt đầu",
+	"overtime.bulk_approved":      "Đã xử lý phê duyệt tăng ca hàng loạt",
+	"overtime.bulk_filter_required": "Cần chọn danh sách đề xuất hoặc khoảng thời gian",
+	"overtime.not_pending":        "Đề xuất tăng ca không ở trạng thái chờ duyệt",
 	
 	// Payroll
 	"payroll.generated":           "Tạo bảng lương thành công",
@@ -280,6 +360,199 @@ var viTranslations = map[string]string{
 	"payroll.paid":                "Thanh toán lương thành công",
 	"payroll.not_found":           "Không tìm thấy bảng lương",
 	"payslip.sent":                "Gửi phiếu lương thành công",
+	"payroll.exchange_rate_saved": "Lưu tỷ giá thành công",
+	"payroll.missing_exchange_rate": "Chưa có tỷ giá để quy đổi tiền tệ",
+	"payroll.reviewed":            "Rà soát bảng lương thành công",
+	"payroll.review_pending":      "Còn phiếu lương bất thường chưa được rà soát",
+	"payroll.payslip_not_flagged": "Phiếu lương không có cảnh báo cần rà soát",
+	"payroll.invalid_status":      "Trạng thái kỳ lương không cho phép thao tác này",
+	
+	// Notification
+	"notification.synced":         "Đồng bộ thông báo thành công",
+	"notification.broadcast_queued": "Đã xếp hàng gửi thông báo hàng loạt",
+	"notification.broadcast_not_found": "Không tìm thấy thông báo hàng loạt",
+	"notification.not_found":      "Không tìm thấy thông báo",
+	"notification.marked_read":    "Đã đánh dấu thông báo là đã đọc",
+	"notification.all_marked_read": "Đã đánh dấu tất cả thông báo là đã đọc",
+	
+	// System
+	"system.unknown_queue":        "Hàng đợi không tồn tại",
+	"system.queue_state_unchanged": "Không thể thay đổi trạng thái hàng đợi",
+	"system.task_not_found":       "Không tìm thấy tác vụ",
+	"system.reconcile_queued":     "Đã đưa tác vụ đồng bộ tìm kiếm vào hàng đợi",
+	"system.reconcile_running":    "Tác vụ đồng bộ tìm kiếm đang chờ xử lý",
+	"system.delivery_not_found":   "Không tìm thấy lượt gửi thông báo",
+	"system.delivery_not_failed":  "Chỉ có thể gửi lại thông báo đã thất bại",
+	"system.delivery_retry_queued": "Đã đưa thông báo vào hàng đợi gửi lại",
+	
+	// Audit
+	"audit.not_found":             "Không tìm thấy nhật ký",
+	
+	// Email
+	"email.two_factor_disabled_subject": "Xác thực 2 bước đã bị tắt",
+	"email.two_factor_disabled_body": "<p>Xác thực 2 bước cho tài khoản của bạn đã được tắt từ địa chỉ IP %s lúc %s.</p><p>Nếu không phải bạn thực hiện, hãy đổi mật khẩu và liên hệ quản trị viên ngay.</p>",
+	
+	// Permissions
+	"permissions.users.view":      "Xem người dùng",
+	"permissions.users.view.description": "Xem danh sách người dùng",
+	"permissions.users.create":    "Tạo người dùng",
+	"permissions.users.create.description": "Tạo tài khoản người dùng",
+	"permissions.users.update":    "Cập nhật người dùng",
+	"permissions.users.update.description": "Chỉnh sửa tài khoản người dùng",
+	"permissions.users.delete":    "Xóa người dùng",
+	"permissions.users.delete.description": "Xóa tài khoản người dùng",
+	"permissions.employees.view":  "Xem nhân viên",
+	"permissions.employees.view.description": "Xem danh sách và hồ sơ nhân viên",
+	"permissions.employees.create": "Tạo nhân viên",
+	"permissions.employees.create.description": "Thêm nhân viên mới",
+	"permissions.employees.update": "Cập nhật nhân viên",
+	"permissions.employees.update.description": "Chỉnh sửa hồ sơ, cho nghỉ việc và đánh giá thử việc",
+	"permissions.employees.delete": "Xóa nhân viên",
+	"permissions.employees.delete.description": "Xóa nhân viên",
+	"permissions.employees.export": "Xuất nhân viên",
+	"permissions.employees.export.description": "Lấy danh sách nhân viên số lượng lớn để xuất dữ liệu",
+	"permissions.departments.view": "Xem phòng ban",
+	"permissions.departments.view.description": "Xem danh sách phòng ban",
+	"permissions.departments.create": "Tạo phòng ban",
+	"permissions.departments.create.description": "Thêm phòng ban mới",
+	"permissions.departments.update": "Cập nhật phòng ban",
+	"permissions.departments.update.description": "Chỉnh sửa phòng ban",
+	"permissions.departments.delete": "Xóa phòng ban",
+	"permissions.departments.delete.description": "Xóa phòng ban",
+	"permissions.positions.view":  "Xem vị trí",
+	"permissions.positions.view.description": "Xem danh sách vị trí",
+	"permissions.positions.create": "Tạo vị trí",
+	"permissions.positions.create.description": "Thêm vị trí mới",
+	"permissions.positions.update": "Cập nhật vị trí",
+	"permissions.positions.update.description": "Chỉnh sửa vị trí",
+	"permissions.positions.delete": "Xóa vị trí",
+	"permissions.positions.delete.description": "Xóa vị trí",
+	"permissions.attendance.view": "Xem chấm công",
+	"permissions.attendance.view.description": "Xem dữ liệu chấm công",
+	"permissions.attendance.manage": "Quản lý chấm công",
+	"permissions.attendance.manage.description": "Chỉnh sửa chấm công và phân ca",
+	"permissions.attendance.approve": "Phê duyệt chấm công",
+	"permissions.attendance.approve.description": "Phê duyệt điều chỉnh chấm công",
+	"permissions.attendance.export": "Xuất chấm công",
+	"permissions.attendance.export.description": "Lấy dữ liệu chấm công số lượng lớn để xuất",
+	"permissions.attendance.create": "Nhập chấm công",
+	"permissions.attendance.create.description": "Nhập chấm công thủ công khi nhân viên quên chấm",
+	"permissions.leave.view":      "Xem nghỉ phép",
+	"permissions.leave.view.description": "Xem đơn nghỉ phép và số ngày phép",
+	"permissions.leave.manage":    "Quản lý nghỉ phép",
+	"permissions.leave.manage.description": "Quản lý loại phép, số ngày phép và tạo đơn thay nhân viên",
+	"permissions.leave.approve":   "Phê duyệt nghỉ phép",
+	"permissions.leave.approve.description": "Phê duyệt hoặc từ chối đơn nghỉ phép",
+	"permissions.overtime.view":   "Xem tăng ca",
+	"permissions.overtime.view.description": "Xem yêu cầu tăng ca",
+	"permissions.overtime.manage": "Quản lý tăng ca",
+	"permissions.overtime.manage.description": "Quản lý yêu cầu và chính sách tăng ca",
+	"permissions.overtime.approve": "Phê duyệt tăng ca",
+	"permissions.overtime.approve.description": "Phê duyệt hoặc từ chối yêu cầu tăng ca",
+	"permissions.payroll.view":    "Xem lương",
+	"permissions.payroll.view.description": "Xem kỳ lương và phiếu lương của mọi nhân viên",
+	"permissions.payroll.create":  "Tạo bảng lương",
+	"permissions.payroll.create.description": "Tạo kỳ lương",
+	"permissions.payroll.calculate": "Tính lương",
+	"permissions.payroll.calculate.description": "Chạy tính lương",
+	"permissions.payroll.approve": "Phê duyệt lương",
+	"permissions.payroll.approve.description": "Xác nhận bảng lương đã tính",
+	"permissions.payroll.pay":     "Thanh toán lương",
+	"permissions.payroll.pay.description": "Đánh dấu bảng lương đã thanh toán",
+	"permissions.payroll.manage":  "Quản lý lương",
+	"permissions.payroll.manage.description": "Quản lý thành phần lương và cấu hình lương",
+	"permissions.roles.view":      "Xem vai trò",
+	"permissions.roles.view.description": "Xem vai trò và quyền của vai trò",
+	"permissions.roles.create":    "Tạo vai trò",
+	"permissions.roles.create.description": "Thêm vai trò mới",
+	"permissions.roles.update":    "Cập nhật vai trò",
+	"permissions.roles.update.description": "Chỉnh sửa vai trò và quyền",
+	"permissions.roles.delete":    "Xóa vai trò",
+	"permissions.roles.delete.description": "Xóa vai trò",
+	"permissions.permissions.view": "Xem quyền",
+	"permissions.permissions.view.description": "Xem danh sách quyền",
+	"permissions.reports.view":    "Xem báo cáo",
+	"permissions.reports.view.description": "Xem báo cáo",
+	"permissions.reports.export":  "Xuất báo cáo",
+	"permissions.reports.export.description": "Xuất báo cáo ra tệp",
+	"permissions.system.manage":   "Quản trị hệ thống",
+	"permissions.system.manage.description": "Điều khiển worker, hàng đợi và tác vụ bảo trì",
+	"permissions.audit.view":      "Xem nhật ký hệ thống",
+	"permissions.audit.view.description": "Xem nhật ký thao tác hệ thống",
+	"permissions.employees.view_all": "Xem tất cả nhân viên",
+	"permissions.employees.view_all.description": "Xem và tìm kiếm nhân viên của mọi phòng ban",
+	"permissions.employees.notes_hr": "Đọc ghi chú nhân sự",
+	"permissions.employees.notes_hr.description": "Đọc ghi chú về nhân viên được chia sẻ với nhân sự",
+	"permissions.employees.view.team": "Xem nhân viên trong nhóm",
+	"permissions.employees.view.team.description": "Xem nhân viên thuộc các phòng ban mình phụ trách và cấp dưới trực tiếp",
+	"permissions.holidays.manage": "Quản lý ngày nghỉ lễ",
+	"permissions.holidays.manage.description": "Thêm, sửa và xóa ngày nghỉ lễ",
+	
+	// Permission modules
+	"permission_modules.users":    "Người dùng",
+	"permission_modules.employees": "Nhân viên",
+	"permission_modules.departments": "Phòng ban",
+	"permission_modules.positions": "Vị trí",
+	"permission_modules.attendance": "Chấm công",
+	"permission_modules.leave":    "Nghỉ phép",
+	"permission_modules.overtime": "Tăng ca",
+	"permission_modules.payroll":  "Lương",
+	"permission_modules.roles":    "Vai trò",
+	"permission_modules.permissions": "Phân quyền",
+	"permission_modules.reports":  "Báo cáo",
+	"permission_modules.system":   "Hệ thống",
+	"permission_modules.audit":    "Nhật ký",
+	
+	// Payslip
+	"payslip.not_found":           "Không tìm thấy phiếu lương",
+	"payslip.not_finalized":       "Phiếu lương chưa được xác nhận",
+	
+	// Document
+	"document.uploaded":           "Tải lên tài liệu thành công",
+	"document.deleted":            "Xóa tài liệu thành công",
+	"document.not_found":          "Không tìm thấy tài liệu",
+	
+	// File
+	"file.required":               "Vui lòng chọn tệp",
+	"file.too_large":              "Tệp vượt quá dung lượng cho phép",
+	"file.invalid_type":           "Định dạng tệp không được hỗ trợ",
+	"file.link_expired":           "Liên kết tải xuống không hợp lệ hoặc đã hết hạn",
+	"file.not_found":              "Không tìm thấy tệp",
+	
+	// Position
+	"position.deleted":            "Xóa chức vụ thành công",
+	"position.not_found":          "Không tìm thấy chức vụ",
+	"position.has_employees":      "Không thể xóa chức vụ còn nhân viên",
+	"position.invalid_reassign_target": "Chức vụ tiếp nhận nhân viên không hợp lệ",
+	
+	// Role
+	"role.not_found":              "Không tìm thấy vai trò",
+	"role.unknown_permissions":    "Có quyền không tồn tại trong danh sách",
+	
+	// Session
+	"session.not_found":           "Không tìm thấy phiên đăng nhập",
+	"session.revoked":             "Đã đăng xuất phiên",
+	
+	// SMS
+	"sms.otp":                     "Ma xac thuc cua ban la %s, hieu luc trong %d phut. Khong chia se ma nay voi bat ky ai.",
+	
+	// Search
+	"search.page_too_deep":        "Trang quá sâu, hãy dùng next_cursor để xem tiếp",
+	"search.cursor_expired":       "Không thể xem tiếp từ next_cursor này, hãy tìm lại từ trang đầu",
+	
+	// Approval
+	"approval.self_not_allowed":   "Không thể tự phê duyệt yêu cầu của chính mình, yêu cầu sẽ được chuyển cho quản lý của bạn",
+	
+	// Report
+	"report.queued":               "Báo cáo đã được đưa vào hàng đợi",
+	"report.not_found":            "Không tìm thấy báo cáo",
+	"report.not_ready":            "Báo cáo chưa sẵn sàng để tải xuống",
+	
+	// Holiday
+	"holiday.created":             "Tạo ngày nghỉ lễ thành công",
+	"holiday.updated":             "Cập nhật ngày nghỉ lễ thành công",
+	"holiday.deleted":             "Xóa ngày nghỉ lễ thành công",
+	"holiday.not_found":           "Không tìm thấy ngày nghỉ lễ",
 	
 	// Validation
 	"validation.required":         "Trường %s là bắt buộc",
@@ -315,6 +588,8 @@ var enTranslations = map[string]string{
 	"common.updated":              "Updated successfully",
 	"common.deleted":              "Deleted successfully",
 	"common.list":                 "Retrieved successfully",
+	"common.unknown_fields":       "Unknown field in fields parameter",
+	"common.timeout":              "The request took too long, please try again",
 	
 	// Auth
 	"auth.login_success":          "Login successful",
@@ -332,6 +607,16 @@ var enTranslations = map[string]string{
 	"auth.refresh_success":        "Token refreshed successfully",
 	"auth.two_factor_required":    "Two-factor authentication required",
 	"auth.two_factor_invalid":     "Invalid verification code",
+	"auth.two_factor_disabled":    "Two-factor authentication disabled",
+	"auth.two_factor_not_enabled": "Two-factor authentication is not enabled",
+	"auth.password_incorrect":     "Incorrect password",
+	"auth.password_breached":      "This password has appeared in a data breach, please choose a different one",
+	"auth.onboarding_required":    "Please finish setting up your account first",
+	"auth.two_factor_already_enabled": "Two-factor authentication is already enabled",
+	"auth.two_factor_setup":       "Scan the QR code with your authenticator app and enter a code to finish",
+	"auth.two_factor_setup_required": "Set up an authenticator app first",
+	"auth.two_factor_enabled":     "Two-factor authentication enabled",
+	"auth.reset_tokens_revoked":   "Password reset links revoked",
 	
 	// OTP
 	"otp.sent":                    "OTP sent successfully",
@@ -339,6 +624,8 @@ var enTranslations = map[string]string{
 	"otp.invalid":                 "Invalid OTP",
 	"otp.expired":                 "OTP expired",
 	"otp.too_many_attempts":       "Too many attempts. Please request a new code",
+	"otp.phone_required":          "No phone number on the account",
+	"otp.phone_not_verified":      "Phone number is not verified",
 	
 	// User
 	"user.created":                "User created successfully",
@@ -354,6 +641,30 @@ var enTranslations = map[string]string{
 	"employee.deleted":            "Employee deleted successfully",
 	"employee.not_found":          "Employee not found",
 	"employee.code_exists":        "Employee code already exists",
+	"employee.terminated":         "Employee terminated successfully",
+	"employee.probation_reviewed": "Probation review saved",
+	"employee.not_on_probation":   "Employee is not on probation",
+	"employee.invalid_status_transition": "Employee status cannot be changed this way",
+	"employee.unsupported_currency": "Currency is not supported",
+	"employee.avatar_updated":     "Avatar updated successfully",
+	"employee.imported":           "Employee import processed",
+	"employee.import_invalid_file": "Import file could not be read; use CSV or XLSX",
+	"employee.import_empty":       "Import file has no data rows",
+	"employee.import_too_many_rows": "Import file has too many rows",
+	"employee.import_invalid_number": "Invalid number",
+	"employee.import_invalid_value": "Invalid value",
+	"employee.import_invalid_date": "Invalid date",
+	"employee.import_duplicate_email": "Email duplicates another row of the file",
+	"employee.import_manager_not_found": "Manager not found",
+	"employee.import_failed":      "Employee could not be created from this row",
+	"dependent.created":           "Dependent added",
+	"dependent.updated":           "Dependent updated",
+	"dependent.deleted":           "Dependent deleted",
+	"dependent.not_found":         "Dependent not found",
+	"dependent.invalid_period":    "Invalid deduction period",
+	"dependent.overlapping_period": "This dependent is already registered for an overlapping period",
+	"note.created":                "Note added",
+	"note.forbidden":              "Only the employee's managers or HR can keep notes on them",
 	
 	// Department
 	"department.created":          "Department created successfully",
@@ -361,6 +672,14 @@ var enTranslations = map[string]string{
 	"department.deleted":          "Department deleted successfully",
 	"department.not_found":        "Department not found",
 	"department.has_employees":    "Cannot delete department with employees",
+	"department.approvers_updated": "Approvers updated successfully",
+	"department.approvers_not_found": "No approvers are configured for the department",
+	"department.approver_required": "A primary approver or an approver role is required",
+	"department.invalid_backup_approver": "A backup approver requires a different primary approver",
+	"department.invalid_approver": "Approvers must be active employees",
+	"department.invalid_approver_role": "Approver role not found",
+	"department.has_children":     "Cannot delete department with sub-departments",
+	"department.invalid_reassign_target": "Invalid department to reassign employees to",
 	
 	// Attendance
 	"attendance.check_in":         "Checked in successfully",
@@ -368,6 +687,28 @@ var enTranslations = map[string]string{
 	"attendance.already_checked_in": "Already checked in today",
 	"attendance.not_checked_in":   "Not checked in yet",
 	"attendance.already_checked_out": "Already checked out today",
+	"attendance.insufficient_rest": "Minimum rest period since the last work has not been met",
+	"attendance.shifts_assigned":  "Shifts assigned successfully",
+	"attendance.shift_not_found":  "Work shift not found",
+	"attendance.shift_overlap":    "Work shifts overlap",
+	"attendance.outside_office_network": "Office check-ins must come from the office network",
+	"attendance.remote_requested": "Remote work request submitted",
+	"attendance.remote_approved":  "Remote work request approved",
+	"attendance.remote_rejected":  "Remote work request rejected",
+	"attendance.remote_cancelled": "Remote work request cancelled",
+	"attendance.remote_not_found": "Remote work request not found",
+	"attendance.remote_not_pending": "Remote work request is not pending",
+	"attendance.remote_not_cancellable": "This remote work request can no longer be cancelled",
+	"attendance.remote_by_default": "Fully remote employees do not need to declare remote days",
+	"attendance.remote_invalid_range": "Invalid remote work date range",
+	"attendance.remote_notice_required": "Remote days must be declared with the required notice",
+	"attendance.remote_no_working_days": "The range contains no working days",
+	"attendance.remote_overlap":   "Overlaps another remote work request",
+	"attendance.remote_cap_exceeded": "Exceeds the monthly remote day limit",
+	"attendance.outside_geofence": "Check-in location is outside the office geofence",
+	"attendance.invalid_location": "Check-in location must be \"latitude,longitude\"",
+	"attendance.not_found":        "Attendance record not found",
+	"attendance.invalid_times":    "Check-out must be after check-in",
 	
 	// Leave
 	"leave.created":               "Leave request created",
@@ -377,6 +718,14 @@ var enTranslations = map[string]string{
 	"leave.not_found":             "Leave request not found",
 	"leave.insufficient_balance":  "Insufficient leave balance",
 	"leave.overlap":               "Leave dates overlap with another request",
+	"leave.auto_approved":         "Leave request was approved automatically",
+	"leave.type_not_found":        "Leave type not found",
+	"leave.no_working_days":       "The requested period contains no working days",
+	"leave.balance_recomputed":    "Leave balance reconciled",
+	"leave.notice_required":       "Leave was not requested far enough in advance",
+	"leave.too_long":              "Leave exceeds the maximum consecutive days",
+	"leave.balances_initialized":  "Leave balances initialized",
+	"leave.not_pending":           "The leave request is no longer pending",
 	
 	// Overtime
 	"overtime.created":            "Overtime request created",
@@ -384,6 +733,10 @@ var enTranslations = map[string]string{
 	"overtime.rejected":           "Overtime request rejected",
 	"overtime.not_found":          "Overtime request not found",
 	"overtime.max_hours_exceeded": "Maximum overtime hours exceeded",
+	"overtime.invalid_time_range": "End time must be after start time",
+	"overtime.bulk_approved":      "Bulk overtime approval processed",
+	"overtime.bulk_filter_required": "Provide request ids or a date range",
+	"overtime.not_pending":        "Overtime request is not pending",
 	
 	// Payroll
 	"payroll.generated":           "Payroll generated successfully",
@@ -391,6 +744,199 @@ var enTranslations = map[string]string{
 	"payroll.paid":                "Payroll paid successfully",
 	"payroll.not_found":           "Payroll not found",
 	"payslip.sent":                "Payslip sent successfully",
+	"payroll.exchange_rate_saved": "Exchange rate saved successfully",
+	"payroll.missing_exchange_rate": "No exchange rate is available for the currency conversion",
+	"payroll.reviewed":            "Payroll reviewed successfully",
+	"payroll.review_pending":      "Flagged payslips must be reviewed before approval",
+	"payroll.payslip_not_flagged": "The payslip has no open review flags",
+	"payroll.invalid_status":      "The payroll period status does not allow this action",
+	
+	// Notification
+	"notification.synced":         "Notifications synced successfully",
+	"notification.broadcast_queued": "Broadcast queued",
+	"notification.broadcast_not_found": "Broadcast not found",
+	"notification.not_found":      "Notification not found",
+	"notification.marked_read":    "Notification marked as read",
+	"notification.all_marked_read": "All notifications marked as read",
+	
+	// System
+	"system.unknown_queue":        "Unknown queue",
+	"system.queue_state_unchanged": "Queue state could not be changed",
+	"system.task_not_found":       "Task not found",
+	"system.reconcile_queued":     "Search reconciliation queued",
+	"system.reconcile_running":    "A search reconciliation is already queued",
+	"system.delivery_not_found":   "Notification delivery not found",
+	"system.delivery_not_failed":  "Only failed deliveries can be retried",
+	"system.delivery_retry_queued": "Notification delivery queued for retry",
+	
+	// Audit
+	"audit.not_found":             "Audit log not found",
+	
+	// Email
+	"email.two_factor_disabled_subject": "Two-factor authentication disabled",
+	"email.two_factor_disabled_body": "<p>Two-factor authentication on your account was disabled from IP address %s at %s.</p><p>If this was not you, change your password and contact an administrator immediately.</p>",
+	
+	// Permissions
+	"permissions.users.view":      "View users",
+	"permissions.users.view.description": "See the list of user accounts",
+	"permissions.users.create":    "Create users",
+	"permissions.users.create.description": "Create user accounts",
+	"permissions.users.update":    "Update users",
+	"permissions.users.update.description": "Edit user accounts",
+	"permissions.users.delete":    "Delete users",
+	"permissions.users.delete.description": "Remove user accounts",
+	"permissions.employees.view":  "View employees",
+	"permissions.employees.view.description": "See employee profiles",
+	"permissions.employees.create": "Create employees",
+	"permissions.employees.create.description": "Add new employees",
+	"permissions.employees.update": "Update employees",
+	"permissions.employees.update.description": "Edit employee profiles, terminate and review probation",
+	"permissions.employees.delete": "Delete employees",
+	"permissions.employees.delete.description": "Remove employees",
+	"permissions.employees.export": "Export employees",
+	"permissions.employees.export.description": "Fetch large pages of employees for export",
+	"permissions.departments.view": "View departments",
+	"permissions.departments.view.description": "See departments",
+	"permissions.departments.create": "Create departments",
+	"permissions.departments.create.description": "Add departments",
+	"permissions.departments.update": "Update departments",
+	"permissions.departments.update.description": "Edit departments",
+	"permissions.departments.delete": "Delete departments",
+	"permissions.departments.delete.description": "Remove departments",
+	"permissions.positions.view":  "View positions",
+	"permissions.positions.view.description": "See positions",
+	"permissions.positions.create": "Create positions",
+	"permissions.positions.create.description": "Add positions",
+	"permissions.positions.update": "Update positions",
+	"permissions.positions.update.description": "Edit positions",
+	"permissions.positions.delete": "Delete positions",
+	"permissions.positions.delete.description": "Remove positions",
+	"permissions.attendance.view": "View attendance",
+	"permissions.attendance.view.description": "See attendance records",
+	"permissions.attendance.manage": "Manage attendance",
+	"permissions.attendance.manage.description": "Edit attendance and assign shifts",
+	"permissions.attendance.approve": "Approve attendance",
+	"permissions.attendance.approve.description": "Approve attendance corrections",
+	"permissions.attendance.export": "Export attendance",
+	"permissions.attendance.export.description": "Fetch large pages of attendance for export",
+	"permissions.attendance.create": "Create attendance",
+	"permissions.attendance.create.description": "Enter attendance manually for employees who forgot to clock",
+	"permissions.leave.view":      "View leave",
+	"permissions.leave.view.description": "See leave requests and balances",
+	"permissions.leave.manage":    "Manage leave",
+	"permissions.leave.manage.description": "Manage leave types, balances and requests on behalf of employees",
+	"permissions.leave.approve":   "Approve leave",
+	"permissions.leave.approve.description": "Approve or reject leave requests",
+	"permissions.overtime.view":   "View overtime",
+	"permissions.overtime.view.description": "See overtime requests",
+	"permissions.overtime.manage": "Manage overtime",
+	"permissions.overtime.manage.description": "Manage overtime requests and policies",
+	"permissions.overtime.approve": "Approve overtime",
+	"permissions.overtime.approve.description": "Approve or reject overtime requests",
+	"permissions.payroll.view":    "View payroll",
+	"permissions.payroll.view.description": "See payroll periods and payslips of all employees",
+	"permissions.payroll.create":  "Create payroll",
+	"permissions.payroll.create.description": "Open payroll periods",
+	"permissions.payroll.calculate": "Calculate payroll",
+	"permissions.payroll.calculate.description": "Run payroll calculations",
+	"permissions.payroll.approve": "Approve payroll",
+	"permissions.payroll.approve.description": "Confirm calculated payroll",
+	"permissions.payroll.pay":     "Pay payroll",
+	"permissions.payroll.pay.description": "Mark payroll as paid",
+	"permissions.payroll.manage":  "Manage payroll",
+	"permissions.payroll.manage.description": "Manage salary components and payroll settings",
+	"permissions.roles.view":      "View roles",
+	"permissions.roles.view.description": "See roles and their permissions",
+	"permissions.roles.create":    "Create roles",
+	"permissions.roles.create.description": "Add roles",
+	"permissions.roles.update":    "Update roles",
+	"permissions.roles.update.description": "Edit roles and their permissions",
+	"permissions.roles.delete":    "Delete roles",
+	"permissions.roles.delete.description": "Remove roles",
+	"permissions.permissions.view": "View permissions",
+	"permissions.permissions.view.description": "See the permission list",
+	"permissions.reports.view":    "View reports",
+	"permissions.reports.view.description": "See reports",
+	"permissions.reports.export":  "Export reports",
+	"permissions.reports.export.description": "Export reports to files",
+	"permissions.system.manage":   "Manage system",
+	"permissions.system.manage.description": "Control workers, queues and maintenance tasks",
+	"permissions.audit.view":      "View audit logs",
+	"permissions.audit.view.description": "See the audit trail",
+	"permissions.employees.view_all": "View all employees",
+	"permissions.employees.view_all.description": "See and search employees of every department",
+	"permissions.employees.notes_hr": "Read HR notes",
+	"permissions.employees.notes_hr.description": "Read notes on employees that were shared with HR",
+	"permissions.employees.view.team": "View team employees",
+	"permissions.employees.view.team.description": "See employees of the departments you belong to or manage, and your direct reports",
+	"permissions.holidays.manage": "Manage holidays",
+	"permissions.holidays.manage.description": "Add, change and remove public holidays",
+	
+	// Permission modules
+	"permission_modules.users":    "Users",
+	"permission_modules.employees": "Employees",
+	"permission_modules.departments": "Departments",
+	"permission_modules.positions": "Positions",
+	"permission_modules.attendance": "Attendance",
+	"permission_modules.leave":    "Leave",
+	"permission_modules.overtime": "Overtime",
+	"permission_modules.payroll":  "Payroll",
+	"permission_modules.roles":    "Roles",
+	"permission_modules.permissions": "Permissions",
+	"permission_modules.reports":  "Reports",
+	"permission_modules.system":   "System",
+	"permission_modules.audit":    "Audit",
+	
+	// Payslip
+	"payslip.not_found":           "Payslip not found",
+	"payslip.not_finalized":       "Payslip has not been confirmed yet",
+	
+	// Document
+	"document.uploaded":           "Document uploaded successfully",
+	"document.deleted":            "Document deleted successfully",
+	"document.not_found":          "Document not found",
+	
+	// File
+	"file.required":               "A file is required",
+	"file.too_large":              "File exceeds the maximum upload size",
+	"file.invalid_type":           "File type is not supported",
+	"file.link_expired":           "Download link is invalid or has expired",
+	"file.not_found":              "File not found",
+	
+	// Position
+	"position.deleted":            "Position deleted successfully",
+	"position.not_found":          "Position not found",
+	"position.has_employees":      "Cannot delete position with employees",
+	"position.invalid_reassign_target": "Invalid position to reassign employees to",
+	
+	// Role
+	"role.not_found":              "Role not found",
+	"role.unknown_permissions":    "Some permissions do not exist",
+	
+	// Session
+	"session.not_found":           "Session not found",
+	"session.revoked":             "Session signed out",
+	
+	// SMS
+	"sms.otp":                     "Your verification code is %s. It expires in %d minutes. Do not share it with anyone.",
+	
+	// Search
+	"search.page_too_deep":        "Page too deep, continue with next_cursor instead",
+	"search.cursor_expired":       "This next_cursor can no longer be continued, search again from the first page",
+	
+	// Approval
+	"approval.self_not_allowed":   "You cannot approve your own request; it goes to your manager instead",
+	
+	// Report
+	"report.queued":               "Report queued",
+	"report.not_found":            "Report not found",
+	"report.not_ready":            "Report is not ready for download",
+	
+	// Holiday
+	"holiday.created":             "Holiday created successfully",
+	"holiday.updated":             "Holiday updated successfully",
+	"holiday.deleted":             "Holiday deleted successfully",
+	"holiday.not_found":           "Holiday not found",
 	
 	// Validation
 	"validation.required":         "%s is required",
@@ -225,6 +225,16 @@ func (r *RedisCache) Decr(ctx context.Context, key string) (int64, error) {
 	return r.client.Decr(ctx, r.key(key)).Result()
 }
 
+// Swap stores a plain string value and returns the one it replaced, or
+// ErrCacheMiss when the key was not set
+func (r *RedisCache) Swap(ctx context.Context, key string, value string, ttl time.Duration) (string, error) {
+	old, err := r.client.SetArgs(ctx, r.key(key), value, redis.SetArgs{TTL: ttl, Get: true}).Result()
+	if err == redis.Nil {
+		return "", ErrCacheMiss
+	}
+	return old, err
+}
+
 // Lock operations (distributed lock)
 func (r *RedisCache) Lock(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
 	return r.client.SetNX(ctx, r.key("lock:"+key), value, ttl).Result()
@@ -334,6 +344,27 @@ func (r *RedisCache) HealthCheck(ctx context.Context) error {
 	return r.client.Ping(ctx).Err()
 }
 
+// Pub/sub
+
+// Publish sends a JSON message to subscribers of a channel, e.g. the API
+// instances streaming a user's notifications
+func (r *RedisCache) Publish(ctx context.Context, channel string, message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return r.client.Publish(ctx, r.key(channel), data).Err()
+}
+
+// Subscribe listens on channels; the caller closes the subscription
+func (r *RedisCache) Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
+	keys := make([]string, len(channels))
+	for i, channel := range channels {
+		keys[i] = r.key(channel)
+	}
+	return r.client.Subscribe(ctx, keys...)
+}
+
 // Cache miss error
 var ErrCacheMiss = fmt.Errorf("cache miss")
 
@@ -349,4 +380,8 @@ const (
 	KeyDepartmentPrefix = "dept:"
 	KeyAttendancePrefix = "att:"
 	KeyPayrollPrefix    = "payroll:"
+	KeyDashboardPrefix  = "dashboard:"
+	KeyWorkerSettings   = "worker:settings"
+	KeyWorkerAlert      = "worker:alert:"
+	KeyNotifyChannel    = "notify:"
 )
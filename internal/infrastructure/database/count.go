@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Count modes of a paginated list. Exact runs the COUNT(*) query, estimate
+// reads the planner statistics and none skips the total, only telling
+// whether another page follows.
+const (
+	CountExact    = "exact"
+	CountEstimate = "estimate"
+	CountNone     = "none"
+)
+
+const countPrefix = "SELECT COUNT(*)"
+
+// SetCountMode applies the requested ?count= mode, falling back to the
+// default for an empty or unknown value
+func (p *Pagination) SetCountMode(requested, defaultMode string) {
+	switch requested {
+	case CountExact, CountEstimate, CountNone:
+		p.CountMode = requested
+	default:
+		p.CountMode = defaultMode
+	}
+}
+
+// FetchLimit is the LIMIT of the page query. Without a count one extra row is
+// read to learn whether a next page exists.
+func (p *Pagination) FetchLimit() int {
+	if p.CountMode == CountNone {
+		return p.PageSize + 1
+	}
+	return p.PageSize
+}
+
+// TrimPage drops the extra row read in none mode and records whether there
+// was one
+func TrimPage[T any](p *Pagination, items []T) []T {
+	if p.CountMode != CountNone {
+		return items
+	}
+	p.HasNext = len(items) > p.PageSize
+	if p.HasNext {
+		items = items[:p.PageSize]
+	}
+	return items
+}
+
+// Count fills the total of a list according to its count mode. countQuery is
+// the exact "SELECT COUNT(*) FROM ..." query of the list. In estimate mode an
+// unfiltered list (no args) of table uses pg_class.reltuples; filtered lists,
+// and tables never analyzed, use the planner's row estimate of the query.
+func (d *Database) Count(ctx context.Context, p *Pagination, table, countQuery string, args ...interface{}) error {
+	var total int
+	var err error
+	switch p.CountMode {
+	case CountNone:
+		return nil
+	case CountEstimate:
+		total = -1
+		if table != "" && len(args) == 0 {
+			err = d.QueryRowContext(ctx, `
+				SELECT COALESCE((SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass($1)), -1)
+			`, table).Scan(&total)
+		}
+		if err == nil && total < 0 {
+			total, err = d.estimateRows(ctx, countQuery, args...)
+		}
+	default:
+		p.CountMode = CountExact
+		err = d.QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	}
+	if err != nil {
+		return err
+	}
+	p.SetTotal(total)
+	return nil
+}
+
+// estimateRows explains the rows behind a COUNT(*) query without running it
+func (d *Database) estimateRows(ctx context.Context, countQuery string, args ...interface{}) (int, error) {
+	query := strings.TrimSpace(countQuery)
+	if !strings.HasPrefix(strings.ToUpper(query), countPrefix) {
+		return 0, fmt.Errorf("count query must start with %s", countPrefix)
+	}
+
+	var plan []byte
+	err := d.QueryRowContext(ctx, "EXPLAIN (FORMAT JSON) SELECT 1"+query[len(countPrefix):], args...).Scan(&plan)
+	if err != nil {
+		return 0, err
+	}
+	var explained []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &explained); err != nil || len(explained) == 0 {
+		return 0, fmt.Errorf("unexpected plan: %s", plan)
+	}
+	return int(explained[0].Plan.Rows), nil
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"testing"
+
+	"hr-management-system/internal/config"
+)
+
+func TestNewPagination(t *testing.T) {
+	cfg := &config.DatabaseConfig{MaxPageSize: 100, ExportMaxPageSize: 1000}
+	const export = "employees.export"
+
+	tests := []struct {
+		name             string
+		page, pageSize   int
+		permissions      []string
+		exportPermission string
+		wantPage         int
+		wantPageSize     int
+	}{
+		{"defaults", 0, 0, nil, export, 1, 10},
+		{"negative page", -3, 20, nil, export, 1, 20},
+		{"anonymous capped", 1, 5000, nil, export, 1, 100},
+		{"anonymous without an export permission", 1, 5000, nil, "", 1, 100},
+		{"normal user capped", 2, 5000, []string{"employees.view"}, export, 2, 100},
+		{"normal user under the cap", 2, 50, []string{"employees.view"}, export, 2, 50},
+		{"exporter raised to the export cap", 1, 800, []string{export}, export, 1, 800},
+		{"exporter capped at the export cap", 1, 5000, []string{export}, export, 1, 1000},
+		{"wildcard grants the export cap", 1, 5000, []string{"employees.*"}, export, 1, 1000},
+		{"superuser grants the export cap", 1, 5000, []string{"*"}, export, 1, 1000},
+		{"another export permission", 1, 5000, []string{"attendance.export"}, export, 1, 100},
+		{"endpoint without an export permission", 1, 5000, []string{"*"}, "", 1, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPagination(tt.page, tt.pageSize, tt.permissions, tt.exportPermission, cfg)
+			if p.Page != tt.wantPage || p.PageSize != tt.wantPageSize {
+				t.Errorf("page %d size %d, want page %d size %d", p.Page, p.PageSize, tt.wantPage, tt.wantPageSize)
+			}
+		})
+	}
+}
+
+func TestNewPaginationCaps(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.DatabaseConfig
+		permissions []string
+		want        int
+	}{
+		{"unset cap falls back to 100", config.DatabaseConfig{}, nil, 100},
+		{"unset cap for an exporter", config.DatabaseConfig{}, []string{"employees.export"}, 100},
+		{"export cap below the normal cap is ignored", config.DatabaseConfig{MaxPageSize: 200, ExportMaxPageSize: 50}, []string{"employees.export"}, 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewPagination(1, 5000, tt.permissions, "employees.export", &tt.cfg).PageSize; got != tt.want {
+				t.Errorf("page size %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
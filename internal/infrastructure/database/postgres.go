@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"hr-management-system/internal/config"
+	"hr-management-system/internal/security"
 
 	_ "github.com/lib/pq"
 )
@@ -82,10 +83,12 @@ func (d *Database) HealthCheck(ctx context.Context) error {
 
 // Pagination helper
 type Pagination struct {
-	Page     int `json:"page"`
-	PageSize int `json:"page_size"`
-	Total    int `json:"total"`
-	Pages    int `json:"pages"`
+	Page      int    `json:"page"`
+	PageSize  int    `json:"page_size"`
+	Total     int    `json:"total"`
+	Pages     int    `json:"pages"`
+	CountMode string `json:"count_mode"`
+	HasNext   bool   `json:"has_next"`
 }
 
 func (p *Pagination) GetOffset() int {
@@ -96,23 +99,36 @@ func (p *Pagination) GetLimit() int {
 	return p.PageSize
 }
 
-func NewPagination(page, pageSize int) *Pagination {
+// NewPagination normalizes the requested page. The page size is capped at
+// cfg.MaxPageSize, or at cfg.ExportMaxPageSize when permissions include
+// exportPermission. Callers without permissions (nil) always get the normal cap.
+func NewPagination(page, pageSize int, permissions []string, exportPermission string, cfg *config.DatabaseConfig) *Pagination {
 	if page < 1 {
 		page = 1
 	}
 	if pageSize < 1 {
 		pageSize = 10
 	}
-	if pageSize > 100 {
-		pageSize = 100
+
+	maxPageSize := cfg.MaxPageSize
+	if maxPageSize < 1 {
+		maxPageSize = 100
+	}
+	if cfg.ExportMaxPageSize > maxPageSize && exportPermission != "" && security.HasPermission(permissions, exportPermission) {
+		maxPageSize = cfg.ExportMaxPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
 	}
 	return &Pagination{
-		Page:     page,
-		PageSize: pageSize,
+		Page:      page,
+		PageSize:  pageSize,
+		CountMode: CountExact,
 	}
 }
 
 func (p *Pagination) SetTotal(total int) {
 	p.Total = total
 	p.Pages = (total + p.PageSize - 1) / p.PageSize
+	p.HasNext = p.Page < p.Pages
 }
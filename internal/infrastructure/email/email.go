@@ -464,7 +464,7 @@ var payslipTemplate = `
             <p>Phiếu lương tháng {{.Period}} của bạn đã sẵn sàng.</p>
             <div class="salary-box">
                 <p>Lương thực nhận</p>
-                <p style="font-size: 32px; margin: 0;">{{.NetSalary}} VNĐ</p>
+                <p style="font-size: 32px; margin: 0;">{{.NetSalary}}</p>
             </div>
             <p>Chi tiết phiếu lương được đính kèm trong file PDF.</p>
         </div>
@@ -15,18 +15,26 @@ import (
 const (
 	TypeEmailSend           = "email:send"
 	TypeEmailOTP            = "email:otp"
+	TypeSMSOTP              = "sms:otp"
 	TypeEmailPasswordReset  = "email:password_reset"
 	TypeEmailPayslip        = "email:payslip"
+	TypeEmailWelcome        = "email:welcome"
+	TypeEmailLeaveRequest   = "email:leave_request"
+	TypeEmailLeaveApproved  = "email:leave_approved"
 	TypePayrollCalculate    = "payroll:calculate"
 	TypePayrollGenerate     = "payroll:generate"
 	TypeReportGenerate      = "report:generate"
 	TypeReportExport        = "report:export"
 	TypeNotificationSend    = "notification:send"
+	TypeNotificationEmail   = "notification:email"
+	TypeNotificationFanout  = "notification:fanout"
 	TypeAttendanceSync      = "attendance:sync"
 	TypeDataSync            = "data:sync"
 	TypeCacheInvalidate     = "cache:invalidate"
 	TypeElasticIndex        = "elastic:index"
 	TypeElasticDelete       = "elastic:delete"
+	TypeElasticBulkIndex    = "elastic:bulk_index"
+	TypeElasticReconcile    = "elastic:reconcile"
 	TypeAuditLog            = "audit:log"
 )
 
@@ -37,6 +45,17 @@ const (
 	QueueLow      = "low"
 )
 
+// KnownQueues lists every queue the worker consumes
+var KnownQueues = []string{QueueCritical, QueueDefault, QueueLow}
+
+// WorkerSettings holds the worker settings operators can change at runtime
+type WorkerSettings struct {
+	Concurrency int            `json:"concurrency"`
+	Queues      map[string]int `json:"queues"`
+	UpdatedBy   string         `json:"updated_by,omitempty"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
 type Queue struct {
 	client    *asynq.Client
 	inspector *asynq.Inspector
@@ -147,13 +166,60 @@ type OTPPayload struct {
 	Language string `json:"language"`
 }
 
+// WelcomeEmailPayload carries the first login credentials of a new account
+type WelcomeEmailPayload struct {
+	Email        string `json:"email"`
+	Name         string `json:"name"`
+	TempPassword string `json:"temp_password"`
+	LoginURL     string `json:"login_url"`
+}
+
+// PayslipEmailPayload carries a payslip to email to the employee. The worker
+// renders the PDF of PayslipID when PDFContent is empty.
+type PayslipEmailPayload struct {
+	Email      string `json:"email"`
+	Name       string `json:"name"`
+	Period     string `json:"period"`
+	NetSalary  string `json:"net_salary"`
+	PayslipID  string `json:"payslip_id,omitempty"`
+	PDFContent []byte `json:"pdf_content,omitempty"`
+}
+
+// LeaveRequestEmailPayload asks an approver to review a leave request
+type LeaveRequestEmailPayload struct {
+	Email        string  `json:"email"`
+	EmployeeName string  `json:"employee_name"`
+	LeaveType    string  `json:"leave_type"`
+	StartDate    string  `json:"start_date"`
+	EndDate      string  `json:"end_date"`
+	TotalDays    float64 `json:"total_days"`
+	Reason       string  `json:"reason"`
+	ApproveURL   string  `json:"approve_url"`
+}
+
+// LeaveApprovedEmailPayload tells the employee the decision on their leave;
+// Status is "approved" or "rejected"
+type LeaveApprovedEmailPayload struct {
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	LeaveType string `json:"leave_type"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	Status    string `json:"status"`
+	Notes     string `json:"notes"`
+}
+
 type PayrollPayload struct {
 	PeriodID   string `json:"period_id"`
 	EmployeeID string `json:"employee_id,omitempty"`
 	Action     string `json:"action"`
 }
 
+// ReportPayload describes a report to generate. ReportID is the reports row
+// tracking a report requested through the API; scheduled reports leave it
+// empty and the worker records them itself.
 type ReportPayload struct {
+	ReportID   string                 `json:"report_id,omitempty"`
 	ReportType string                 `json:"report_type"`
 	Format     string                 `json:"format"`
 	Filters    map[string]interface{} `json:"filters"`
@@ -169,6 +235,17 @@ type NotificationPayload struct {
 	Data    map[string]interface{} `json:"data,omitempty"`
 }
 
+// NotificationBroadcastPayload points at the broadcast row holding the message,
+// audience and progress
+type NotificationBroadcastPayload struct {
+	BroadcastID string `json:"broadcast_id"`
+}
+
+// NotificationEmailPayload points at the delivery row tracking the email copy
+type NotificationEmailPayload struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
 type ElasticPayload struct {
 	Index      string      `json:"index"`
 	DocumentID string      `json:"document_id"`
@@ -176,6 +253,20 @@ type ElasticPayload struct {
 	Action     string      `json:"action"`
 }
 
+// ElasticBulkPayload carries many documents of one index, keyed by id.
+// Attempt counts the re-queues of documents that failed to index.
+type ElasticBulkPayload struct {
+	Index     string                 `json:"index"`
+	Documents map[string]interface{} `json:"documents"`
+	Attempt   int                    `json:"attempt,omitempty"`
+}
+
+// ElasticReconcilePayload names the index to compare against the database
+type ElasticReconcilePayload struct {
+	Index       string `json:"index"`
+	RequestedBy string `json:"requested_by,omitempty"`
+}
+
 type AuditLogPayload struct {
 	UserID    string      `json:"user_id"`
 	Action    string      `json:"action"`
@@ -192,6 +283,10 @@ func (q *Queue) GetQueueInfo(queueName string) (*asynq.QueueInfo, error) {
 	return q.inspector.GetQueueInfo(queueName)
 }
 
+func (q *Queue) GetTaskInfo(queueName, taskID string) (*asynq.TaskInfo, error) {
+	return q.inspector.GetTaskInfo(queueName, taskID)
+}
+
 func (q *Queue) GetPendingTasks(queueName string, page, pageSize int) ([]*asynq.TaskInfo, error) {
 	return q.inspector.ListPendingTasks(queueName, asynq.Page(page), asynq.PageSize(pageSize))
 }
@@ -228,15 +323,46 @@ func (q *Queue) ArchiveTask(queueName, taskID string) error {
 	return q.inspector.ArchiveTask(queueName, taskID)
 }
 
+func (q *Queue) PauseQueue(queueName string) error {
+	return q.inspector.PauseQueue(queueName)
+}
+
+func (q *Queue) UnpauseQueue(queueName string) error {
+	return q.inspector.UnpauseQueue(queueName)
+}
+
 // Helper functions for common tasks
 func (q *Queue) SendEmail(ctx context.Context, payload EmailPayload) (*asynq.TaskInfo, error) {
 	return q.EnqueueDefault(ctx, TypeEmailSend, payload)
 }
 
+// SendWelcomeEmail queues the welcome email with the temporary password. The
+// task is not retained after completion so the password does not linger in Redis.
+func (q *Queue) SendWelcomeEmail(ctx context.Context, payload WelcomeEmailPayload) (*asynq.TaskInfo, error) {
+	return q.EnqueueDefault(ctx, TypeEmailWelcome, payload)
+}
+
+func (q *Queue) SendPayslipEmail(ctx context.Context, payload PayslipEmailPayload) (*asynq.TaskInfo, error) {
+	return q.EnqueueDefault(ctx, TypeEmailPayslip, payload)
+}
+
+func (q *Queue) SendLeaveRequestEmail(ctx context.Context, payload LeaveRequestEmailPayload) (*asynq.TaskInfo, error) {
+	return q.EnqueueDefault(ctx, TypeEmailLeaveRequest, payload)
+}
+
+func (q *Queue) SendLeaveApprovedEmail(ctx context.Context, payload LeaveApprovedEmailPayload) (*asynq.TaskInfo, error) {
+	return q.EnqueueDefault(ctx, TypeEmailLeaveApproved, payload)
+}
+
 func (q *Queue) SendOTP(ctx context.Context, payload OTPPayload) (*asynq.TaskInfo, error) {
 	return q.EnqueueCritical(ctx, TypeEmailOTP, payload)
 }
 
+// SendSMSOTP texts the OTP to payload.Phone
+func (q *Queue) SendSMSOTP(ctx context.Context, payload OTPPayload) (*asynq.TaskInfo, error) {
+	return q.EnqueueCritical(ctx, TypeSMSOTP, payload)
+}
+
 func (q *Queue) GenerateReport(ctx context.Context, payload ReportPayload) (*asynq.TaskInfo, error) {
 	return q.EnqueueLow(ctx, TypeReportGenerate, payload)
 }
@@ -249,10 +375,51 @@ func (q *Queue) SendNotification(ctx context.Context, payload NotificationPayloa
 	return q.EnqueueDefault(ctx, TypeNotificationSend, payload)
 }
 
+// SendNotificationEmail queues the email copy of a notification. Failed sends
+// are retried with the worker's backoff up to maxRetry times.
+func (q *Queue) SendNotificationEmail(ctx context.Context, payload NotificationEmailPayload, maxRetry int) (*asynq.TaskInfo, error) {
+	return q.Enqueue(ctx, TypeNotificationEmail, payload,
+		asynq.Queue(QueueDefault),
+		asynq.MaxRetry(maxRetry),
+		asynq.Timeout(time.Minute),
+	)
+}
+
+// BroadcastNotification queues the fan-out of a broadcast. The task id is the
+// broadcast id, so progress can be polled from the task status endpoint.
+func (q *Queue) BroadcastNotification(ctx context.Context, payload NotificationBroadcastPayload) (*asynq.TaskInfo, error) {
+	return q.Enqueue(ctx, TypeNotificationFanout, payload,
+		asynq.Queue(QueueDefault),
+		asynq.TaskID(payload.BroadcastID),
+		asynq.MaxRetry(5),
+		asynq.Timeout(time.Hour),
+		asynq.Retention(7*24*time.Hour),
+	)
+}
+
 func (q *Queue) IndexDocument(ctx context.Context, payload ElasticPayload) (*asynq.TaskInfo, error) {
 	return q.EnqueueLow(ctx, TypeElasticIndex, payload)
 }
 
+// BulkIndexDocuments queues documents to be indexed together, in place of
+// one IndexDocument task each
+func (q *Queue) BulkIndexDocuments(ctx context.Context, payload ElasticBulkPayload, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	opts = append([]asynq.Option{asynq.Queue(QueueLow), asynq.MaxRetry(2), asynq.Timeout(30 * time.Minute)}, opts...)
+	return q.Enqueue(ctx, TypeElasticBulkIndex, payload, opts...)
+}
+
+// ReconcileIndex queues a full comparison of an index with the database.
+// Only one reconciliation per index may be queued at a time.
+func (q *Queue) ReconcileIndex(ctx context.Context, payload ElasticReconcilePayload) (*asynq.TaskInfo, error) {
+	return q.Enqueue(ctx, TypeElasticReconcile, payload,
+		asynq.Queue(QueueLow),
+		asynq.MaxRetry(2),
+		asynq.Timeout(30*time.Minute),
+		asynq.Unique(30*time.Minute),
+		asynq.Retention(24*time.Hour),
+	)
+}
+
 func (q *Queue) LogAudit(ctx context.Context, payload AuditLogPayload) (*asynq.TaskInfo, error) {
 	return q.EnqueueLow(ctx, TypeAuditLog, payload)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -90,6 +91,19 @@ func (e *ElasticSearch) Index(ctx context.Context, indexName, id string, doc int
 	return err
 }
 
+// BulkIndexError reports the documents of a bulk request that Elasticsearch
+// rejected while indexing the rest
+type BulkIndexError struct {
+	Failed []string
+	Reason string
+}
+
+func (e *BulkIndexError) Error() string {
+	return fmt.Sprintf("bulk index: %d documents failed: %s", len(e.Failed), e.Reason)
+}
+
+// BulkIndex indexes docs by id in one request. When only some documents fail
+// the error is a *BulkIndexError naming them.
 func (e *ElasticSearch) BulkIndex(ctx context.Context, indexName string, docs map[string]interface{}) error {
 	fullIndex := fmt.Sprintf("%s_%s", e.index, indexName)
 	bulk := e.client.Bulk()
@@ -102,10 +116,76 @@ func (e *ElasticSearch) BulkIndex(ctx context.Context, indexName string, docs ma
 		bulk.Add(req)
 	}
 
+	res, err := bulk.Do(ctx)
+	if err != nil {
+		return err
+	}
+	if !res.Errors {
+		return nil
+	}
+
+	failed := &BulkIndexError{}
+	for _, item := range res.Failed() {
+		failed.Failed = append(failed.Failed, item.Id)
+		if failed.Reason == "" && item.Error != nil {
+			failed.Reason = item.Error.Reason
+		}
+	}
+	return failed
+}
+
+func (e *ElasticSearch) BulkDelete(ctx context.Context, indexName string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	fullIndex := fmt.Sprintf("%s_%s", e.index, indexName)
+	bulk := e.client.Bulk()
+
+	for _, id := range ids {
+		bulk.Add(elastic.NewBulkDeleteRequest().Index(fullIndex).Id(id))
+	}
+
 	_, err := bulk.Do(ctx)
 	return err
 }
 
+// DocumentTimestamps scrolls every document of an index and returns its id
+// with the time stored in field. Documents without the field map to the zero time.
+func (e *ElasticSearch) DocumentTimestamps(ctx context.Context, indexName, field string) (map[string]time.Time, error) {
+	fullIndex := fmt.Sprintf("%s_%s", e.index, indexName)
+	result := make(map[string]time.Time)
+
+	scroll := e.client.Scroll(fullIndex).
+		Size(1000).
+		FetchSourceContext(elastic.NewFetchSourceContext(true).Include(field))
+	defer scroll.Clear(context.Background())
+
+	for {
+		res, err := scroll.Do(ctx)
+		if err == io.EOF {
+			return result, nil
+		}
+		if elastic.IsNotFound(err) {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, hit := range res.Hits.Hits {
+			var source map[string]interface{}
+			if hit.Source != nil {
+				json.Unmarshal(hit.Source, &source)
+			}
+			var ts time.Time
+			if v, ok := source[field].(string); ok {
+				ts, _ = time.Parse(time.RFC3339Nano, v)
+			}
+			result[hit.Id] = ts
+		}
+	}
+}
+
 func (e *ElasticSearch) Get(ctx context.Context, indexName, id string) (*elastic.GetResult, error) {
 	fullIndex := fmt.Sprintf("%s_%s", e.index, indexName)
 	return e.client.Get().Index(fullIndex).Id(id).Do(ctx)
@@ -131,13 +211,24 @@ type SearchParams struct {
 	Sort         []string
 	Highlight    []string
 	Aggregations map[string]elastic.Aggregation
+	// SearchAfter continues after the hit with these sort values instead of
+	// skipping From hits, which gets slow deep into the results
+	SearchAfter []interface{}
 }
 
+// AnyOf is a filter matched when any of its fields has one of the given
+// values, a string or a []string. Its key in SearchParams.Filters is only a
+// name.
+type AnyOf map[string]interface{}
+
 type SearchResult struct {
 	Total    int64                    `json:"total"`
 	Hits     []map[string]interface{} `json:"hits"`
 	Aggs     map[string]interface{}   `json:"aggregations,omitempty"`
 	MaxScore float64                  `json:"max_score"`
+	// SortValues are the sort values of the last hit, to continue from with
+	// SearchAfter
+	SortValues []interface{} `json:"sort_values,omitempty"`
 }
 
 func (e *ElasticSearch) Search(ctx context.Context, indexName string, params SearchParams) (*SearchResult, error) {
@@ -154,6 +245,17 @@ func (e *ElasticSearch) Search(ctx context.Context, indexName string, params Sea
 			query.Filter(elastic.NewTermQuery(field, v))
 		case []string:
 			query.Filter(elastic.NewTermsQueryFromStrings(field, v...))
+		case AnyOf:
+			anyOf := elastic.NewBoolQuery().MinimumNumberShouldMatch(1)
+			for f, values := range v {
+				switch values := values.(type) {
+				case string:
+					anyOf.Should(elastic.NewTermQuery(f, values))
+				case []string:
+					anyOf.Should(elastic.NewTermsQueryFromStrings(f, values...))
+				}
+			}
+			query.Filter(anyOf)
 		case map[string]interface{}:
 			if gte, ok := v["gte"]; ok {
 				if lte, ok := v["lte"]; ok {
@@ -172,9 +274,13 @@ func (e *ElasticSearch) Search(ctx context.Context, indexName string, params Sea
 	search := e.client.Search().
 		Index(fullIndex).
 		Query(query).
-		From(params.From).
 		Size(params.Size).
 		TrackTotalHits(true)
+	if len(params.SearchAfter) > 0 {
+		search.SearchAfter(params.SearchAfter...)
+	} else {
+		search.From(params.From)
+	}
 
 	for _, s := range params.Sort {
 		asc := true
@@ -225,22 +331,41 @@ func (e *ElasticSearch) Search(ctx context.Context, indexName string, params Sea
 			doc["_highlight"] = hit.Highlight
 		}
 		searchResult.Hits = append(searchResult.Hits, doc)
+		searchResult.SortValues = hit.Sort
 	}
 
 	return searchResult, nil
 }
 
+// employeeSort orders employee hits by name; the id breaks ties so
+// search_after never skips or repeats a hit
+var employeeSort = []string{"full_name.keyword", "id"}
+
 func (e *ElasticSearch) SearchEmployees(ctx context.Context, query string, filters map[string]interface{}, page, size int) (*SearchResult, error) {
 	return e.Search(ctx, "employees", SearchParams{
 		Query:     query,
 		Filters:   filters,
 		From:      (page - 1) * size,
 		Size:      size,
-		Sort:      []string{"full_name.keyword"},
+		Sort:      employeeSort,
 		Highlight: []string{"full_name", "email", "employee_code"},
 	})
 }
 
+// SearchEmployeesAfter returns the size employees that follow the hit with
+// the given sort values, as returned in SortValues, for paging past where
+// from/size stays cheap
+func (e *ElasticSearch) SearchEmployeesAfter(ctx context.Context, query string, filters map[string]interface{}, sortValues []interface{}, size int) (*SearchResult, error) {
+	return e.Search(ctx, "employees", SearchParams{
+		Query:       query,
+		Filters:     filters,
+		Size:        size,
+		Sort:        employeeSort,
+		Highlight:   []string{"full_name", "email", "employee_code"},
+		SearchAfter: sortValues,
+	})
+}
+
 func (e *ElasticSearch) SearchAuditLogs(ctx context.Context, filters map[string]interface{}, page, size int) (*SearchResult, error) {
 	return e.Search(ctx, "audit_logs", SearchParams{
 		Filters: filters,
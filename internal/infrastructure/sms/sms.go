@@ -0,0 +1,81 @@
+// Package sms delivers text messages, used for OTPs sent to phones
+package sms
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/infrastructure/logger"
+)
+
+// SMSSender sends a text message to a phone number in E.164 form
+type SMSSender interface {
+	Send(ctx context.Context, to, body string) error
+}
+
+// NewSender returns the sender of the configured provider: twilio, log
+// (writes messages to the log, for development) or noop (drops them, for tests)
+func NewSender(cfg *config.SMSConfig, log *logger.Logger) (SMSSender, error) {
+	switch cfg.Provider {
+	case "", "log":
+		return &LogSender{log: log}, nil
+	case "noop":
+		return NoopSender{}, nil
+	case "twilio":
+		if cfg.TwilioAccountSID == "" || cfg.TwilioAuthToken == "" || cfg.From == "" {
+			return nil, fmt.Errorf("twilio sms provider needs an account sid, auth token and sender")
+		}
+		return &TwilioSender{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}}, nil
+	default:
+		return nil, fmt.Errorf("unknown sms provider %q", cfg.Provider)
+	}
+}
+
+// TwilioSender sends through the Twilio Messages API
+type TwilioSender struct {
+	cfg    *config.SMSConfig
+	client *http.Client
+}
+
+func (s *TwilioSender) Send(ctx context.Context, to, body string) error {
+	endpoint := fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json", url.PathEscape(s.cfg.TwilioAccountSID))
+	form := url.Values{"To": {to}, "From": {s.cfg.From}, "Body": {body}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.cfg.TwilioAccountSID, s.cfg.TwilioAuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send sms: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("send sms: twilio returned %s", resp.Status)
+	}
+	return nil
+}
+
+// LogSender writes messages to the log instead of sending them
+type LogSender struct {
+	log *logger.Logger
+}
+
+func (s *LogSender) Send(ctx context.Context, to, body string) error {
+	s.log.WithField("to", to).WithField("body", body).Info("SMS (log provider, not sent)")
+	return nil
+}
+
+// NoopSender drops every message
+type NoopSender struct{}
+
+func (NoopSender) Send(ctx context.Context, to, body string) error {
+	return nil
+}
@@ -0,0 +1,49 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/testutil"
+)
+
+func TestNewSender(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.SMSConfig
+		want    string
+		wantErr bool
+	}{
+		{"default logs", config.SMSConfig{}, "*sms.LogSender", false},
+		{"log", config.SMSConfig{Provider: "log"}, "*sms.LogSender", false},
+		{"noop", config.SMSConfig{Provider: "noop"}, "sms.NoopSender", false},
+		{"twilio", config.SMSConfig{Provider: "twilio", TwilioAccountSID: "AC1", TwilioAuthToken: "token", From: "+15005550006"}, "*sms.TwilioSender", false},
+		{"twilio without a token", config.SMSConfig{Provider: "twilio", TwilioAccountSID: "AC1", From: "+15005550006"}, "", true},
+		{"twilio without a sender", config.SMSConfig{Provider: "twilio", TwilioAccountSID: "AC1", TwilioAuthToken: "token"}, "", true},
+		{"unknown provider", config.SMSConfig{Provider: "carrier-pigeon"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender, err := NewSender(&tt.cfg, testutil.Logger())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := fmt.Sprintf("%T", sender); !tt.wantErr && got != tt.want {
+				t.Errorf("sender %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// The noop provider, which tests use, accepts every OTP without sending it
+func TestNoopSenderDeliversOTP(t *testing.T) {
+	sender, err := NewSender(&config.SMSConfig{Provider: "noop"}, testutil.Logger())
+	if err != nil {
+		t.Fatalf("new sender: %v", err)
+	}
+	if err := sender.Send(context.Background(), "+84901234567", "Your verification code is 482913."); err != nil {
+		t.Errorf("send: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+// Package storage keeps uploaded and generated files (avatars, employee
+// documents, reports) on local disk or in S3-compatible object storage, so
+// every API and worker instance sees the same files.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"hr-management-system/internal/config"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("storage: object not found")
+
+// Backend stores objects under slash-separated keys such as
+// "avatars/<employee id>/<file>"
+type Backend interface {
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a link that downloads the object without further
+	// authentication until it expires
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// NewBackend creates the backend selected by STORAGE_DRIVER. baseURL is the
+// public URL of the API, which serves the files of the local backend.
+func NewBackend(cfg *config.StorageConfig, baseURL string) (Backend, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocal(cfg.LocalPath, strings.TrimRight(baseURL, "/")+LocalFilesPath, cfg.LocalSigningKey)
+	case "s3":
+		return NewS3(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
+
+// CleanKey normalizes a key and rejects keys that would escape the storage
+// root, such as "../etc/passwd"
+func CleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if cleaned == "" || cleaned != strings.TrimPrefix(key, "/") {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return cleaned, nil
+}
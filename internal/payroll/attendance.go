@@ -0,0 +1,108 @@
+package payroll
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// LeaveAttendanceResult counts the attendance rows a reconciliation changed
+type LeaveAttendanceResult struct {
+	// OnLeave rows were written as on_leave or half_day
+	OnLeave int64 `json:"on_leave"`
+	// DaysOff rows were re-marked as holiday or weekend
+	DaysOff int64 `json:"days_off"`
+	// Cleared rows no longer had approved leave behind them and were removed
+	Cleared int64 `json:"cleared"`
+}
+
+// ReconcileLeaveAttendance makes the attendance of start..end inclusive agree
+// with approved leave, for one employee or everyone when employeeID is nil.
+// Working days of approved leave get an on_leave row (half_day for a
+// single-day request of less than a day); rest days and full-day holidays
+// are never leave days, and rows on them without a check-in become weekend
+// or holiday.
+// Rows with a check-in are left alone. Running it again changes nothing.
+func (s *Service) ReconcileLeaveAttendance(ctx context.Context, employeeID *uuid.UUID, start, end time.Time) (LeaveAttendanceResult, error) {
+	var result LeaveAttendanceResult
+
+	calendar, err := s.WorkingCalendar(ctx, start, end)
+	if err != nil {
+		return result, err
+	}
+	holidays := make([]string, len(calendar.Holidays))
+	for i, d := range calendar.Holidays {
+		holidays[i] = d.Format("2006-01-02")
+	}
+	restDays := make([]string, len(calendar.RestDays))
+	for i, d := range calendar.RestDays {
+		restDays[i] = d.Format("2006-01-02")
+	}
+
+	var employee interface{}
+	if employeeID != nil {
+		employee = *employeeID
+	}
+	from, to := start.Format("2006-01-02"), end.Format("2006-01-02")
+
+	err = s.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `
+			WITH leave_days AS (
+				SELECT DISTINCT ON (lr.employee_id, d::date) lr.employee_id, d::date AS date,
+				       CASE WHEN lr.start_date = lr.end_date AND lr.total_days < 1 THEN 'half_day' ELSE 'on_leave' END AS status
+				FROM leave_requests lr
+				CROSS JOIN LATERAL generate_series(GREATEST(lr.start_date, $1::date), LEAST(lr.end_date, $2::date), INTERVAL '1 day') d
+				WHERE lr.status = 'approved' AND lr.deleted_at IS NULL
+				  AND lr.start_date <= $2::date AND lr.end_date >= $1::date
+				  AND ($3::uuid IS NULL OR lr.employee_id = $3::uuid)
+				ORDER BY lr.employee_id, d::date, lr.total_days DESC
+			)
+			INSERT INTO attendances (id, employee_id, date, status, created_at, updated_at)
+			SELECT uuid_generate_v4(), employee_id, date, status, NOW(), NOW()
+			FROM leave_days
+			WHERE date <> ALL($4::date[]) AND date <> ALL($5::date[])
+			ON CONFLICT (employee_id, date) DO UPDATE SET status = EXCLUDED.status, deleted_at = NULL, updated_at = NOW()
+			WHERE attendances.check_in IS NULL
+			  AND (attendances.status <> EXCLUDED.status OR attendances.deleted_at IS NOT NULL)
+		`, from, to, employee, pq.Array(holidays), pq.Array(restDays))
+		if err != nil {
+			return err
+		}
+		result.OnLeave, _ = res.RowsAffected()
+
+		res, err = tx.ExecContext(ctx, `
+			UPDATE attendances
+			SET status = CASE WHEN date = ANY($5::date[]) THEN 'weekend' ELSE 'holiday' END, updated_at = NOW()
+			WHERE date BETWEEN $1::date AND $2::date AND check_in IS NULL AND deleted_at IS NULL
+			  AND ($3::uuid IS NULL OR employee_id = $3::uuid)
+			  AND (date = ANY($5::date[]) OR date = ANY($4::date[]))
+			  AND status <> CASE WHEN date = ANY($5::date[]) THEN 'weekend' ELSE 'holiday' END
+		`, from, to, employee, pq.Array(holidays), pq.Array(restDays))
+		if err != nil {
+			return err
+		}
+		result.DaysOff, _ = res.RowsAffected()
+
+		// Leave that was cancelled or rejected after its rows were written
+		res, err = tx.ExecContext(ctx, `
+			DELETE FROM attendances a
+			WHERE a.date BETWEEN $1::date AND $2::date AND a.check_in IS NULL AND a.check_out IS NULL
+			  AND a.status IN ('on_leave', 'half_day')
+			  AND ($3::uuid IS NULL OR a.employee_id = $3::uuid)
+			  AND NOT EXISTS (
+			      SELECT 1 FROM leave_requests lr
+			      WHERE lr.employee_id = a.employee_id AND lr.status = 'approved' AND lr.deleted_at IS NULL
+			        AND a.date BETWEEN lr.start_date AND lr.end_date
+			  )
+		`, from, to, employee)
+		if err != nil {
+			return err
+		}
+		result.Cleared, _ = res.RowsAffected()
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,191 @@
+package payroll
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"hr-management-system/internal/domain/entity"
+)
+
+// StandardHoursPerDay is the length of a working day used for hourly rates
+const StandardHoursPerDay = 8
+
+// defaultWorkWeek is Monday to Friday, used when the working_weekdays setting
+// is missing or invalid
+var defaultWorkWeek = map[time.Weekday]bool{
+	time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Thursday: true, time.Friday: true,
+}
+
+// WorkingCalendar is the standard working time of a period: the days of the
+// work week that are not public holidays, with half-day holidays counting
+// half. Payroll prorates salaries on it, so every place that reports working
+// days should use the same calendar.
+type WorkingCalendar struct {
+	Start       time.Time
+	End         time.Time
+	WorkingDays float64
+	// Holidays are the work week days excluded as public holidays
+	Holidays []time.Time
+	// HalfDayHolidays are the work week days of which half is a holiday
+	HalfDayHolidays []time.Time
+	// RestDays are the days outside the work week, usually weekends
+	RestDays []time.Time
+
+	// days is the working time of each day of the period, 0 to 1
+	days map[string]float64
+}
+
+// ExpectedHours is the standard working time of the period in hours
+func (w *WorkingCalendar) ExpectedHours() float64 {
+	return w.WorkingDays * StandardHoursPerDay
+}
+
+// IsRestDay reports whether the date is outside the work week
+func (w *WorkingCalendar) IsRestDay(d time.Time) bool {
+	for _, rest := range w.RestDays {
+		if rest.Format("2006-01-02") == d.Format("2006-01-02") {
+			return true
+		}
+	}
+	return false
+}
+
+// WorkingCalendar builds the calendar of start..end inclusive from the
+// holidays of the years it spans. A full-day holiday wins over a half-day
+// one on the same date. The work week is the working_weekdays setting.
+func (s *Service) WorkingCalendar(ctx context.Context, start, end time.Time) (*WorkingCalendar, error) {
+	workWeek := s.workWeek(ctx)
+
+	// Working time left on each holiday
+	holidays := make(map[string]float64)
+	for year := start.Year(); year <= end.Year(); year++ {
+		expanded, err := s.ExpandHolidays(ctx, year)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range expanded {
+			left := 0.0
+			if h.IsHalfDay {
+				left = 0.5
+			}
+			holidays[h.Date.Format("2006-01-02")] = left
+		}
+	}
+
+	calendar := &WorkingCalendar{
+		Start: start, End: end,
+		Holidays: []time.Time{}, HalfDayHolidays: []time.Time{}, RestDays: []time.Time{},
+		days: make(map[string]float64),
+	}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		key := d.Format("2006-01-02")
+		if !workWeek[d.Weekday()] {
+			calendar.RestDays = append(calendar.RestDays, d)
+			continue
+		}
+
+		left, holiday := holidays[key]
+		switch {
+		case !holiday:
+			left = 1
+		case left == 0:
+			calendar.Holidays = append(calendar.Holidays, d)
+		default:
+			calendar.HalfDayHolidays = append(calendar.HalfDayHolidays, d)
+		}
+		calendar.days[key] = left
+		calendar.WorkingDays += left
+	}
+	return calendar, nil
+}
+
+// ExpandHolidays returns the holidays of a year, one per date and ordered by
+// date. Recurring holidays are dated in the year by their month and day, and
+// skipped when that day does not exist, like 29 February outside leap years.
+// When several holidays fall on one date, one dated in the year wins over a
+// recurring one and a full day over a half day.
+func (s *Service) ExpandHolidays(ctx context.Context, year int) ([]entity.Holiday, error) {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, date, COALESCE(type, ''), COALESCE(description, ''), COALESCE(is_recurring, FALSE),
+		       is_half_day, COALESCE(year, 0), created_at, updated_at
+		FROM holidays
+		WHERE deleted_at IS NULL AND (date BETWEEN $1 AND $2 OR is_recurring = TRUE)
+		ORDER BY COALESCE(is_recurring, FALSE), is_half_day, created_at
+	`, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byDate := make(map[string]entity.Holiday)
+	for rows.Next() {
+		var h entity.Holiday
+		if err := rows.Scan(&h.ID, &h.Name, &h.Date, &h.Type, &h.Description, &h.IsRecurring,
+			&h.IsHalfDay, &h.Year, &h.CreatedAt, &h.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if h.IsRecurring {
+			date := time.Date(year, h.Date.Month(), h.Date.Day(), 0, 0, 0, 0, time.UTC)
+			if date.Day() != h.Date.Day() {
+				continue
+			}
+			h.Date, h.Year = date, year
+		}
+		// Rows come dated first and full days first, so the first one stays
+		key := h.Date.Format("2006-01-02")
+		if _, exists := byDate[key]; !exists {
+			byDate[key] = h
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	holidays := make([]entity.Holiday, 0, len(byDate))
+	for _, h := range byDate {
+		holidays = append(holidays, h)
+	}
+	sort.Slice(holidays, func(i, j int) bool { return holidays[i].Date.Before(holidays[j].Date) })
+	return holidays, nil
+}
+
+// WorkingDaysInPeriod counts the working days of start..end inclusive on the
+// working calendar: work week days less holidays, half-day holidays counting
+// half
+func (s *Service) WorkingDaysInPeriod(ctx context.Context, start, end time.Time) (float64, error) {
+	calendar, err := s.WorkingCalendar(ctx, start, end)
+	if err != nil {
+		return 0, err
+	}
+	return calendar.WorkingDays, nil
+}
+
+// workWeek reads the working_weekdays setting, a comma-separated list of ISO
+// weekdays from 1 (Monday) to 7 (Sunday)
+func (s *Service) workWeek(ctx context.Context) map[time.Weekday]bool {
+	var value string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT value FROM system_settings WHERE key = 'working_weekdays'
+	`).Scan(&value)
+	if err != nil {
+		return defaultWorkWeek
+	}
+
+	week := make(map[time.Weekday]bool)
+	for _, part := range strings.Split(value, ",") {
+		day, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || day < 1 || day > 7 {
+			return defaultWorkWeek
+		}
+		week[time.Weekday(day%7)] = true
+	}
+	if len(week) == 0 {
+		return defaultWorkWeek
+	}
+	return week
+}
@@ -0,0 +1,139 @@
+package payroll
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hr-management-system/internal/infrastructure/database"
+	"hr-management-system/internal/testutil"
+)
+
+func calendarDate(s string) time.Time {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// calendarTestHolidays replaces the seeded holidays with these, for
+// September 2030, which starts on a Sunday:
+//   - 1 and 2 September recur from 2024, a Sunday and a Monday in 2030
+//   - 6 (Friday) and 7 (Saturday) are half days of 2030
+//   - 13 (Friday) is a full day in 2030 and a recurring half day
+//   - 29 February recurs from 2024 and does not exist in 2030
+func calendarTestHolidays(t *testing.T, db *database.Database) {
+	t.Helper()
+	_, err := db.Exec(`
+		DELETE FROM holidays;
+		INSERT INTO holidays (name, date, is_recurring, is_half_day, year) VALUES
+		('Recurring Sunday', '2024-09-01', TRUE, FALSE, NULL),
+		('Recurring Monday', '2024-09-02', TRUE, FALSE, NULL),
+		('Half Friday', '2030-09-06', FALSE, TRUE, 2030),
+		('Half Saturday', '2030-09-07', FALSE, TRUE, 2030),
+		('Full over recurring half', '2030-09-13', FALSE, FALSE, 2030),
+		('Recurring half', '2024-09-13', TRUE, TRUE, NULL),
+		('Leap day', '2024-02-29', TRUE, FALSE, NULL);
+	`)
+	testutil.Must(t, err, "create holidays")
+}
+
+func setWorkWeek(t *testing.T, db *database.Database, weekdays string) {
+	t.Helper()
+	_, err := db.Exec(`UPDATE system_settings SET value = $1 WHERE key = 'working_weekdays'`, weekdays)
+	testutil.Must(t, err, "set working_weekdays")
+}
+
+func TestWorkingCalendar(t *testing.T) {
+	db := testutil.DB(t)
+	s := NewService(db)
+	ctx := context.Background()
+	calendarTestHolidays(t, db)
+
+	tests := []struct {
+		name        string
+		weekdays    string
+		start, end  string
+		workingDays float64
+		holidays    int
+		halfDays    int
+		restDays    int
+	}{
+		{"Monday to Friday", "1,2,3,4,5", "2030-09-01", "2030-09-30", 18.5, 2, 1, 9},
+		{"Monday to Saturday", "1,2,3,4,5,6", "2030-09-01", "2030-09-30", 22, 2, 2, 5},
+		{"invalid setting falls back to Monday to Friday", "1,9", "2030-09-01", "2030-09-30", 18.5, 2, 1, 9},
+		{"recurring leap day skipped", "1,2,3,4,5", "2030-02-01", "2030-02-28", 20, 0, 0, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setWorkWeek(t, db, tt.weekdays)
+			calendar, err := s.WorkingCalendar(ctx, calendarDate(tt.start), calendarDate(tt.end))
+			testutil.Must(t, err, "build calendar")
+			if calendar.WorkingDays != tt.workingDays || len(calendar.Holidays) != tt.holidays ||
+				len(calendar.HalfDayHolidays) != tt.halfDays || len(calendar.RestDays) != tt.restDays {
+				t.Errorf("%g working days, %d holidays, %d half days, %d rest days; want %g, %d, %d, %d",
+					calendar.WorkingDays, len(calendar.Holidays), len(calendar.HalfDayHolidays), len(calendar.RestDays),
+					tt.workingDays, tt.holidays, tt.halfDays, tt.restDays)
+			}
+		})
+	}
+}
+
+func TestWorkingDaysBetween(t *testing.T) {
+	db := testutil.DB(t)
+	s := NewService(db)
+	calendarTestHolidays(t, db)
+	setWorkWeek(t, db, "1,2,3,4,5")
+	calendar, err := s.WorkingCalendar(context.Background(), calendarDate("2030-09-01"), calendarDate("2030-09-30"))
+	testutil.Must(t, err, "build calendar")
+
+	tests := []struct {
+		name     string
+		from, to string
+		want     float64
+	}{
+		{"recurring Monday off, half Friday", "2030-09-01", "2030-09-07", 3.5},
+		{"full day wins over the recurring half", "2030-09-09", "2030-09-15", 4},
+		{"a single holiday", "2030-09-13", "2030-09-13", 0},
+		{"a single half day", "2030-09-06", "2030-09-06", 0.5},
+		{"from before the period", "2030-08-20", "2030-09-03", 1},
+		{"to after the period", "2030-09-27", "2030-10-10", 2},
+		{"whole period", "2030-09-01", "2030-09-30", 18.5},
+		{"empty range", "2030-09-10", "2030-09-09", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calendar.WorkingDaysBetween(calendarDate(tt.from), calendarDate(tt.to)); got != tt.want {
+				t.Errorf("WorkingDaysBetween(%s, %s) = %g, want %g", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRestDay(t *testing.T) {
+	db := testutil.DB(t)
+	s := NewService(db)
+	ctx := context.Background()
+	calendarTestHolidays(t, db)
+
+	tests := []struct {
+		weekdays string
+		date     string
+		want     bool
+	}{
+		{"1,2,3,4,5", "2030-09-07", true},    // Saturday, even with its half-day holiday
+		{"1,2,3,4,5,6", "2030-09-07", false}, // a working Saturday
+		{"1,2,3,4,5,6", "2030-09-08", true},  // Sunday
+		{"1,2,3,4,5", "2030-09-02", false},   // a holiday, not a rest day
+		{"2,3,4,5,6", "2030-09-09", true},    // Monday outside a Tuesday to Saturday week
+	}
+	for _, tt := range tests {
+		setWorkWeek(t, db, tt.weekdays)
+		calendar, err := s.WorkingCalendar(ctx, calendarDate("2030-09-01"), calendarDate("2030-09-30"))
+		testutil.Must(t, err, "build calendar")
+		if got := calendar.IsRestDay(calendarDate(tt.date)); got != tt.want {
+			t.Errorf("week %s: IsRestDay(%s) = %v, want %v", tt.weekdays, tt.date, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package payroll
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"golang.org/x/text/number"
+)
+
+// ErrNoExchangeRate is returned when no rate of a currency pair is in effect
+var ErrNoExchangeRate = errors.New("no exchange rate for currency pair")
+
+// ExchangeRate returns how much of quote one unit of currency was worth on the
+// date. A pair without a direct rate is looked up in reverse.
+func (s *Service) ExchangeRate(ctx context.Context, currency, quote string, on time.Time) (float64, error) {
+	if currency == quote {
+		return 1, nil
+	}
+
+	var rate float64
+	var inverse bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT rate, currency <> $1 FROM exchange_rates
+		WHERE ((currency = $1 AND quote_currency = $2) OR (currency = $2 AND quote_currency = $1))
+		  AND effective_date <= $3
+		ORDER BY effective_date DESC, currency = $1 DESC
+		LIMIT 1
+	`, currency, quote, on.Format("2006-01-02")).Scan(&rate, &inverse)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w %s/%s on %s", ErrNoExchangeRate, currency, quote, on.Format("2006-01-02"))
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if inverse {
+		return 1 / rate, nil
+	}
+	return rate, nil
+}
+
+// Converter converts amounts to one currency, caching the rates it looks up.
+// Aggregate reports use one converter so every row is converted the same way.
+type Converter struct {
+	service *Service
+	to      string
+	rates   map[string]float64
+}
+
+func (s *Service) NewConverter(to string) *Converter {
+	return &Converter{service: s, to: to, rates: make(map[string]float64)}
+}
+
+// Currency returns the currency amounts are converted to
+func (c *Converter) Currency() string {
+	return c.to
+}
+
+// Convert converts an amount at the rate in effect on the date, rounded to the
+// minor unit of the target currency
+func (c *Converter) Convert(ctx context.Context, amount float64, from string, on time.Time) (float64, error) {
+	key := from + on.Format("2006-01-02")
+	rate, ok := c.rates[key]
+	if !ok {
+		var err error
+		if rate, err = c.service.ExchangeRate(ctx, from, c.to, on); err != nil {
+			return 0, err
+		}
+		c.rates[key] = rate
+	}
+	return RoundAmount(amount*rate, c.to), nil
+}
+
+// Currencies without minor units; others are kept to 2 decimals
+var zeroDecimalCurrencies = map[string]bool{"VND": true, "JPY": true, "KRW": true, "IDR": true}
+
+// RoundAmount rounds an amount to the minor unit of the currency
+func RoundAmount(amount float64, currency string) float64 {
+	if zeroDecimalCurrencies[currency] {
+		return math.Round(amount)
+	}
+	return math.Round(amount*100) / 100
+}
+
+// FormatAmount renders an amount with its currency code, e.g. 12.500.000 VND
+// or 1.250,50 USD. Digit grouping follows FormatMoney.
+func FormatAmount(amount float64, currency string) string {
+	return FormatMoneyIn(amount, currency) + " " + currency
+}
+
+// FormatMoneyIn is FormatMoney with the decimals of the currency
+func FormatMoneyIn(amount float64, currency string) string {
+	if zeroDecimalCurrencies[currency] {
+		return FormatMoney(amount)
+	}
+	return moneyPrinter.Sprint(number.Decimal(amount, number.MinFractionDigits(2), number.MaxFractionDigits(2)))
+}
@@ -0,0 +1,110 @@
+package payroll
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Rows that change over time (salaries, allowances, deductions, dependents)
+// carry the date they take effect and, optionally, the last date they apply.
+// Payroll selects them by the period it calculates, never by their current
+// values, so recalculating an old period gives the same payslip.
+
+// EffectiveDuringSQL is the SQL condition that a row valid from startCol
+// through endCol (NULL for open-ended) overlaps the period from periodStart
+// through periodEnd, which are usually placeholders
+func EffectiveDuringSQL(startCol, endCol, periodStart, periodEnd string) string {
+	return fmt.Sprintf("%s <= %s AND (%s IS NULL OR %s >= %s)", startCol, periodEnd, endCol, endCol, periodStart)
+}
+
+// EffectiveDuring returns the part of the period from periodStart through
+// periodEnd in which a row valid from start through end applies. A nil end
+// is open-ended. ok is false when they do not overlap.
+func EffectiveDuring(periodStart, periodEnd, start time.Time, end *time.Time) (from, to time.Time, ok bool) {
+	from, to = periodStart, periodEnd
+	if start.After(from) {
+		from = start
+	}
+	if end != nil && end.Before(to) {
+		to = *end
+	}
+	return from, to, !from.After(to)
+}
+
+// WorkingDaysBetween counts the working days of the calendar from from
+// through to, half-day holidays counting half
+func (w *WorkingCalendar) WorkingDaysBetween(from, to time.Time) float64 {
+	if from.Before(w.Start) {
+		from = w.Start
+	}
+	if to.After(w.End) {
+		to = w.End
+	}
+
+	days := 0.0
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		days += w.days[d.Format("2006-01-02")]
+	}
+	return days
+}
+
+// EffectiveFraction is the share of the period's working days in which a row
+// valid from start through end applies, used to prorate monthly amounts that
+// start or stop mid-period. A period without working days counts whole if
+// the row overlaps it at all.
+func (w *WorkingCalendar) EffectiveFraction(start time.Time, end *time.Time) float64 {
+	from, to, ok := EffectiveDuring(w.Start, w.End, start, end)
+	if !ok {
+		return 0
+	}
+	if w.WorkingDays == 0 {
+		return 1
+	}
+	return w.WorkingDaysBetween(from, to) / w.WorkingDays
+}
+
+// SalarySegment is a base salary and the part of a period it applied to
+type SalarySegment struct {
+	Amount   float64
+	Currency string
+	From     time.Time
+	To       time.Time
+}
+
+// SalarySegments returns the base salaries in effect during the period from
+// the employee's salary history, oldest first. Each entry applies until the
+// next takes effect. It is empty when no history covers the period, in which
+// case the employee's current salary is all there is.
+func (s *Service) SalarySegments(ctx context.Context, employeeID uuid.UUID, start, end time.Time) ([]SalarySegment, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT base_salary, currency, effective_from, effective_to
+		FROM (
+			SELECT base_salary, currency, effective_from,
+			       LEAD(effective_from) OVER (ORDER BY effective_from) - 1 AS effective_to
+			FROM employee_salary_history
+			WHERE employee_id = $1
+		) h
+		WHERE `+EffectiveDuringSQL("effective_from", "effective_to", "$2", "$3")+`
+		ORDER BY effective_from
+	`, employeeID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var segments []SalarySegment
+	for rows.Next() {
+		var seg SalarySegment
+		var effectiveFrom time.Time
+		var effectiveTo *time.Time
+		if err := rows.Scan(&seg.Amount, &seg.Currency, &effectiveFrom, &effectiveTo); err != nil {
+			return nil, err
+		}
+		seg.From, seg.To, _ = EffectiveDuring(start, end, effectiveFrom, effectiveTo)
+		segments = append(segments, seg)
+	}
+	return segments, rows.Err()
+}
@@ -0,0 +1,87 @@
+package payroll
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// monthCalendar is the Monday to Friday calendar of March 2025, which starts
+// on a Saturday and has 21 work week days, with the given working time left
+// on holidays
+func monthCalendar(holidays map[string]float64) *WorkingCalendar {
+	start := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC)
+	calendar := &WorkingCalendar{Start: start, End: end, days: make(map[string]float64)}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if !defaultWorkWeek[d.Weekday()] {
+			continue
+		}
+		key := d.Format("2006-01-02")
+		left, holiday := holidays[key]
+		if !holiday {
+			left = 1
+		}
+		calendar.days[key] = left
+		calendar.WorkingDays += left
+	}
+	return calendar
+}
+
+func TestEffectiveFraction(t *testing.T) {
+	date := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	ends := func(s string) *time.Time {
+		d := date(s)
+		return &d
+	}
+
+	tests := []struct {
+		name     string
+		holidays map[string]float64
+		start    string
+		end      *time.Time
+		want     float64
+	}{
+		{"open-ended from before the period", nil, "2025-01-01", nil, 1},
+		{"starting mid-period", nil, "2025-03-17", nil, 11.0 / 21},
+		{"starting on a weekend counts from the Monday", nil, "2025-03-15", nil, 11.0 / 21},
+		{"ending mid-period", nil, "2025-02-01", ends("2025-03-14"), 10.0 / 21},
+		{"ending on a weekend", nil, "2025-02-01", ends("2025-03-16"), 10.0 / 21},
+		{"starting and ending mid-period", nil, "2025-03-10", ends("2025-03-14"), 5.0 / 21},
+		{"starting on the last day", nil, "2025-03-31", nil, 1.0 / 21},
+		{"ended before the period", nil, "2025-01-01", ends("2025-02-28"), 0},
+		{"starting after the period", nil, "2025-04-01", nil, 0},
+		{"holiday inside the part", map[string]float64{"2025-03-18": 0}, "2025-03-17", nil, 10.0 / 20},
+		{"holiday outside the part", map[string]float64{"2025-03-04": 0}, "2025-03-17", nil, 11.0 / 20},
+		{"half-day holiday inside the part", map[string]float64{"2025-03-20": 0.5}, "2025-03-17", nil, 10.5 / 20.5},
+		{"half-day holiday ending the part", map[string]float64{"2025-03-14": 0.5}, "2025-02-01", ends("2025-03-14"), 9.5 / 20.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := monthCalendar(tt.holidays).EffectiveFraction(date(tt.start), tt.end)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("EffectiveFraction = %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
+
+// A period without working days pays a row that overlaps it in full
+func TestEffectiveFractionNoWorkingDays(t *testing.T) {
+	holidays := make(map[string]float64)
+	for d := 1; d <= 31; d++ {
+		holidays[time.Date(2025, time.March, d, 0, 0, 0, 0, time.UTC).Format("2006-01-02")] = 0
+	}
+	calendar := monthCalendar(holidays)
+	mid := time.Date(2025, time.March, 17, 0, 0, 0, 0, time.UTC)
+	if got := calendar.EffectiveFraction(mid, nil); got != 1 {
+		t.Errorf("EffectiveFraction = %f, want 1", got)
+	}
+	after := time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)
+	if got := calendar.EffectiveFraction(after, nil); got != 0 {
+		t.Errorf("EffectiveFraction of a row starting after = %f, want 0", got)
+	}
+}
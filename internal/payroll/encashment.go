@@ -0,0 +1,150 @@
+package payroll
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Encashment reasons
+const (
+	EncashmentReasonYearEnd     = "year_end"
+	EncashmentReasonTermination = "termination"
+)
+
+type EncashmentLine struct {
+	LeaveTypeID   uuid.UUID `json:"leave_type_id"`
+	Code          string    `json:"code"`
+	Name          string    `json:"name"`
+	RemainingDays float64   `json:"remaining_days"`
+	Days          float64   `json:"days"`
+	Amount        float64   `json:"amount"`
+}
+
+type LeaveEncashment struct {
+	EmployeeID  uuid.UUID        `json:"employee_id"`
+	Year        int              `json:"year"`
+	Reason      string           `json:"reason"`
+	Currency    string           `json:"currency"`
+	DailyRate   float64          `json:"daily_rate"`
+	TotalDays   float64          `json:"total_days"`
+	TotalAmount float64          `json:"total_amount"`
+	Lines       []EncashmentLine `json:"lines"`
+}
+
+// CalculateLeaveEncashment computes the payout for unused leave of encashable
+// types. At year end (terminatedOn == nil) only days above the carry-over cap
+// are paid. On termination the yearly entitlement is prorated to the months
+// served and every remaining day is paid, since nothing can carry over.
+func (s *Service) CalculateLeaveEncashment(ctx context.Context, employeeID uuid.UUID, year int, terminatedOn *time.Time) (*LeaveEncashment, error) {
+	var baseSalary float64
+	var currency string
+	var joinDate time.Time
+	err := s.db.QueryRowContext(ctx, `
+		SELECT base_salary, salary_currency, join_date FROM employees WHERE id = $1
+	`, employeeID).Scan(&baseSalary, &currency, &joinDate)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &LeaveEncashment{
+		EmployeeID: employeeID,
+		Year:       year,
+		Reason:     EncashmentReasonYearEnd,
+		Currency:   currency,
+		DailyRate:  math.Round(baseSalary / s.workingDaysPerMonth(ctx)),
+		Lines:      []EncashmentLine{},
+	}
+
+	prorate := 1.0
+	if terminatedOn != nil {
+		result.Reason = EncashmentReasonTermination
+		prorate = servedFraction(joinDate, *terminatedOn, year)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT lt.id, lt.code, lt.name, COALESCE(lt.max_carry_over, 0),
+		       lb.total_days, lb.carried_over, lb.used_days, lb.pending_days
+		FROM leave_balances lb
+		INNER JOIN leave_types lt ON lt.id = lb.leave_type_id
+		WHERE lb.employee_id = $1 AND lb.year = $2 AND lb.deleted_at IS NULL
+		  AND lt.encashable = TRUE AND lt.is_paid = TRUE AND lt.deleted_at IS NULL
+		ORDER BY lt.code
+	`, employeeID, year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line EncashmentLine
+		var maxCarryOver, totalDays, carriedOver, usedDays, pendingDays float64
+		if err := rows.Scan(&line.LeaveTypeID, &line.Code, &line.Name, &maxCarryOver,
+			&totalDays, &carriedOver, &usedDays, &pendingDays); err != nil {
+			return nil, err
+		}
+
+		line.RemainingDays, line.Days = encashableDays(totalDays, carriedOver, usedDays, pendingDays, maxCarryOver, prorate, terminatedOn != nil)
+		if line.Days <= 0 {
+			continue
+		}
+		line.Amount = math.Round(line.Days * result.DailyRate)
+
+		result.TotalDays += line.Days
+		result.TotalAmount += line.Amount
+		result.Lines = append(result.Lines, line)
+	}
+
+	return result, rows.Err()
+}
+
+// SaveLeaveEncashment records the payout so the next payroll run adds it as an
+// earnings line. Saving the same employee/year/reason twice is a no-op.
+func (s *Service) SaveLeaveEncashment(ctx context.Context, enc *LeaveEncashment) error {
+	for _, line := range enc.Lines {
+		_, err := s.db.ExecContext(ctx, `
+			INSERT INTO leave_encashments (id, employee_id, leave_type_id, year, reason, days, daily_rate, amount, currency, status, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 'pending', NOW(), NOW())
+			ON CONFLICT (employee_id, leave_type_id, year, reason) DO NOTHING
+		`, uuid.New(), enc.EmployeeID, line.LeaveTypeID, enc.Year, enc.Reason, line.Days, enc.DailyRate, line.Amount, enc.Currency)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encashableDays returns the remaining and payable days of one balance.
+// The entitlement (not carried-over days) is prorated before usage is taken off.
+func encashableDays(totalDays, carriedOver, usedDays, pendingDays, maxCarryOver, prorate float64, terminated bool) (remaining, payable float64) {
+	entitlement := roundDownHalfDay(totalDays*prorate) + carriedOver
+	remaining = math.Max(entitlement-usedDays-pendingDays, 0)
+
+	if terminated {
+		return remaining, remaining
+	}
+	return remaining, math.Max(remaining-maxCarryOver, 0)
+}
+
+// servedFraction is the share of the year's months worked up to the termination date
+func servedFraction(joinDate, terminatedOn time.Time, year int) float64 {
+	if terminatedOn.Year() > year {
+		return 1
+	}
+
+	firstMonth := 1
+	if joinDate.Year() == year {
+		firstMonth = int(joinDate.Month())
+	}
+	months := int(terminatedOn.Month()) - firstMonth + 1
+	if months <= 0 {
+		return 0
+	}
+	return float64(months) / 12
+}
+
+func roundDownHalfDay(days float64) float64 {
+	return math.Floor(days*2) / 2
+}
@@ -0,0 +1,66 @@
+package payroll
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEncashableDays(t *testing.T) {
+	tests := []struct {
+		name                               string
+		total, carried, used, pending, cap float64
+		prorate                            float64
+		terminated                         bool
+		wantRemaining, wantPayable         float64
+	}{
+		{"year end above the cap", 12, 0, 4, 0, 5, 1, false, 8, 3},
+		{"year end within the cap", 12, 0, 8, 0, 5, 1, false, 4, 0},
+		{"year end exactly the cap", 12, 0, 7, 0, 5, 1, false, 5, 0},
+		{"year end counts carried days", 12, 3, 4, 0, 5, 1, false, 11, 6},
+		{"year end less pending days", 12, 0, 4, 2, 5, 1, false, 6, 1},
+		{"year end without a cap", 12, 0, 4, 0, 0, 1, false, 8, 8},
+		{"termination pays everything left", 12, 0, 4, 0, 5, 1, true, 8, 8},
+		{"termination prorates the entitlement", 12, 2, 3, 0, 5, 0.5, true, 5, 5},
+		{"termination rounds down to half days", 14, 0, 0, 0, 5, 5.0 / 12, true, 5.5, 5.5},
+		{"termination rounds down whole", 15, 0, 0, 0, 5, 5.0 / 12, true, 6, 6},
+		{"termination overdrawn", 12, 0, 5, 0, 5, 0.25, true, 0, 0},
+		{"nothing served", 12, 0, 0, 0, 5, 0, true, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remaining, payable := encashableDays(tt.total, tt.carried, tt.used, tt.pending, tt.cap, tt.prorate, tt.terminated)
+			if remaining != tt.wantRemaining || payable != tt.wantPayable {
+				t.Errorf("encashableDays = %g, %g; want %g, %g", remaining, payable, tt.wantRemaining, tt.wantPayable)
+			}
+		})
+	}
+}
+
+func TestServedFraction(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	tests := []struct {
+		name         string
+		join, leave  string
+		year         int
+		wantFraction float64
+	}{
+		{"joined before the year", "2020-05-10", "2025-06-15", 2025, 6.0 / 12},
+		{"joined during the year", "2025-03-20", "2025-06-15", 2025, 4.0 / 12},
+		{"left in December", "2020-01-01", "2025-12-31", 2025, 1},
+		{"left after the year", "2020-01-01", "2026-02-01", 2025, 1},
+		{"left in the month joined", "2025-06-01", "2025-06-20", 2025, 1.0 / 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := servedFraction(date(tt.join), date(tt.leave), tt.year); got != tt.wantFraction {
+				t.Errorf("servedFraction = %g, want %g", got, tt.wantFraction)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package payroll
+
+import (
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+var moneyPrinter = message.NewPrinter(language.Vietnamese)
+
+// FormatMoney renders a VND amount with Vietnamese digit grouping and no
+// decimals, e.g. 12.500.000. Payslip emails, exports and reports share it.
+func FormatMoney(amount float64) string {
+	return moneyPrinter.Sprint(number.Decimal(amount, number.MaxFractionDigits(0)))
+}
@@ -0,0 +1,55 @@
+package payroll
+
+import (
+	"context"
+	"database/sql"
+)
+
+// InitializeLeaveBalances creates the year's balances of every employed
+// person for every active leave type: the type's default days plus what
+// carries over from the previous year. What carries over is the previous
+// year's availability, total_days + carried_over - used_days as everywhere
+// else, capped at max_carry_over; this is the one place unused days expire.
+// Existing rows are left alone, so it can be run again, for instance to cover
+// people hired since the last run. It returns how many balances were created
+// and how many already existed.
+func (s *Service) InitializeLeaveBalances(ctx context.Context, year int) (created, skipped int, err error) {
+	err = s.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		var candidates int
+		if err := tx.QueryRowContext(ctx, `
+			SELECT COUNT(*)
+			FROM employees e
+			CROSS JOIN leave_types lt
+			WHERE e.employment_status IN ('active', 'on_leave') AND e.deleted_at IS NULL
+			  AND lt.status = 'active' AND lt.deleted_at IS NULL
+		`).Scan(&candidates); err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO leave_balances (id, employee_id, leave_type_id, year, total_days, used_days, pending_days, carried_over, created_at, updated_at)
+			SELECT uuid_generate_v4(), e.id, lt.id, $1, COALESCE(lt.default_days, 0), 0, 0,
+			       GREATEST(LEAST(COALESCE(prev.total_days + prev.carried_over - prev.used_days, 0), COALESCE(lt.max_carry_over, 0)), 0),
+			       NOW(), NOW()
+			FROM employees e
+			CROSS JOIN leave_types lt
+			LEFT JOIN leave_balances prev ON prev.employee_id = e.id AND prev.leave_type_id = lt.id
+			     AND prev.year = $1 - 1 AND prev.deleted_at IS NULL
+			WHERE e.employment_status IN ('active', 'on_leave') AND e.deleted_at IS NULL
+			  AND lt.status = 'active' AND lt.deleted_at IS NULL
+			ON CONFLICT (employee_id, leave_type_id, year) DO NOTHING
+		`, year)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		created = int(n)
+		skipped = candidates - created
+		return nil
+	})
+	return created, skipped, err
+}
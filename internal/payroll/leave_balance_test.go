@@ -0,0 +1,53 @@
+package payroll
+
+import (
+	"context"
+	"testing"
+
+	"hr-management-system/internal/testutil"
+)
+
+// Carried days count towards what carries over again, within the cap
+func TestInitializeLeaveBalancesCarryOver(t *testing.T) {
+	db := testutil.DB(t)
+	s := NewService(db)
+	ctx := context.Background()
+
+	// The annual type carries over at most 5 days
+	tests := []struct {
+		name                       string
+		total, carried, used, want float64
+	}{
+		{"carried days kept", 12, 3, 12, 3},
+		{"capped", 12, 3, 4, 5},
+		{"nothing left", 12, 0, 12, 0},
+		{"overdrawn", 12, 0, 14, 0},
+	}
+	for _, tt := range tests {
+		employee := testutil.CreateEmployee(t, db, testutil.EmployeeOptions{})
+		_, err := db.Exec(`
+			INSERT INTO leave_balances (employee_id, leave_type_id, year, total_days, carried_over, used_days)
+			VALUES ($1, $2, 2025, $3, $4, $5)
+		`, employee.ID, testutil.LeaveTypeAnnual, tt.total, tt.carried, tt.used)
+		testutil.Must(t, err, "%s: create 2025 balance", tt.name)
+
+		if _, _, err := s.InitializeLeaveBalances(ctx, 2026); err != nil {
+			t.Fatalf("%s: initialize: %v", tt.name, err)
+		}
+
+		var total, carried float64
+		testutil.Must(t, db.QueryRow(`
+			SELECT total_days, carried_over FROM leave_balances
+			WHERE employee_id = $1 AND leave_type_id = $2 AND year = 2026
+		`, employee.ID, testutil.LeaveTypeAnnual).Scan(&total, &carried), "%s: read 2026 balance", tt.name)
+		if total != 12 || carried != tt.want {
+			t.Errorf("%s: total %g carried %g, want 12 and %g", tt.name, total, carried, tt.want)
+		}
+	}
+
+	// Running again leaves the balances alone
+	created, _, err := s.InitializeLeaveBalances(ctx, 2026)
+	if err != nil || created != 0 {
+		t.Fatalf("second run created %d (%v), want none", created, err)
+	}
+}
@@ -0,0 +1,62 @@
+package payroll
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"hr-management-system/internal/infrastructure/database"
+)
+
+// defaultWorkingDaysPerMonth is used when the working_days_per_month setting is missing
+const defaultWorkingDaysPerMonth = 22
+
+// Service holds payroll computations shared by the API, worker and scheduler
+type Service struct {
+	db *database.Database
+}
+
+func NewService(db *database.Database) *Service {
+	return &Service{db: db}
+}
+
+// workingDaysPerMonth returns the standard month length used for daily rates
+func (s *Service) workingDaysPerMonth(ctx context.Context) float64 {
+	return s.settingFloat(ctx, "working_days_per_month", defaultWorkingDaysPerMonth)
+}
+
+// LateGrace is how long after the shift start a check-in still counts as on
+// time: the late_threshold_minutes setting, or fallback when it is not set.
+// Check-in status and the payroll late penalty both use it.
+func (s *Service) LateGrace(ctx context.Context, fallback time.Duration) time.Duration {
+	var value string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT value FROM system_settings WHERE key = 'late_threshold_minutes'
+	`).Scan(&value)
+	if err != nil {
+		return fallback
+	}
+	minutes, err := strconv.Atoi(value)
+	if err != nil || minutes < 0 {
+		return fallback
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// settingFloat reads a positive number from system_settings, falling back to
+// the default when the setting is missing or invalid
+func (s *Service) settingFloat(ctx context.Context, key string, defaultValue float64) float64 {
+	var value string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT value FROM system_settings WHERE key = $1
+	`, key).Scan(&value)
+	if err != nil {
+		return defaultValue
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number <= 0 {
+		return defaultValue
+	}
+	return number
+}
@@ -0,0 +1,123 @@
+package payroll
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"hr-management-system/internal/pdf"
+)
+
+// PayslipLine is an entry of the earnings or deductions breakdown
+type PayslipLine struct {
+	Code   string  `json:"code"`
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+}
+
+// PayslipDocument is the data printed on a payslip
+type PayslipDocument struct {
+	EmployeeCode      string
+	EmployeeName      string
+	DepartmentName    string
+	PositionName      string
+	Year              int
+	Month             int
+	WorkingDays       float64
+	ActualWorkingDays float64
+	LeaveDays         float64
+	OvertimeHours     float64
+	Earnings          []PayslipLine
+	Deductions        []PayslipLine
+	GrossEarnings     float64
+	TotalDeductions   float64
+	NetSalary         float64
+	Currency          string
+}
+
+// LoadPayslipDocument reads a payslip with its breakdown. It returns
+// sql.ErrNoRows when the payslip does not exist.
+func (s *Service) LoadPayslipDocument(ctx context.Context, payslipID string) (*PayslipDocument, error) {
+	var doc PayslipDocument
+	var earnings, deductions []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT ps.employee_code, ps.employee_name, COALESCE(ps.department_name, ''), COALESCE(ps.position_name, ''),
+		       pp.year, pp.month, ps.working_days, ps.actual_working_days, ps.leave_days, ps.overtime_hours,
+		       ps.gross_earnings, ps.total_deductions, ps.net_salary, ps.currency,
+		       COALESCE(ps.earnings_details, '[]'), COALESCE(ps.deductions_details, '[]')
+		FROM payslips ps
+		INNER JOIN payroll_periods pp ON pp.id = ps.payroll_period_id
+		WHERE ps.id = $1 AND ps.deleted_at IS NULL
+	`, payslipID).Scan(&doc.EmployeeCode, &doc.EmployeeName, &doc.DepartmentName, &doc.PositionName,
+		&doc.Year, &doc.Month, &doc.WorkingDays, &doc.ActualWorkingDays, &doc.LeaveDays, &doc.OvertimeHours,
+		&doc.GrossEarnings, &doc.TotalDeductions, &doc.NetSalary, &doc.Currency, &earnings, &deductions)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(earnings, &doc.Earnings); err != nil {
+		return nil, fmt.Errorf("invalid earnings details: %w", err)
+	}
+	if err := json.Unmarshal(deductions, &doc.Deductions); err != nil {
+		return nil, fmt.Errorf("invalid deductions details: %w", err)
+	}
+	return &doc, nil
+}
+
+// RenderPayslipPDF lays out a one-page payslip: employee header, working
+// days, earnings and deductions breakdowns and the net salary, all in the
+// payslip currency
+func RenderPayslipPDF(doc *PayslipDocument) []byte {
+	const left, right = 50.0, pdf.PageWidth - 50
+	out := pdf.New()
+	out.AddPage()
+
+	y := 60.0
+	out.TextCenter(pdf.PageWidth/2, y, pdf.Bold, 16, "PHIẾU LƯƠNG")
+	y += 20
+	out.TextCenter(pdf.PageWidth/2, y, pdf.Regular, 11, fmt.Sprintf("Kỳ lương tháng %02d/%d", doc.Month, doc.Year))
+
+	y += 35
+	header := [][2]string{
+		{"Mã nhân viên", doc.EmployeeCode},
+		{"Họ và tên", doc.EmployeeName},
+		{"Phòng ban", doc.DepartmentName},
+		{"Vị trí", doc.PositionName},
+		{"Ngày công chuẩn / thực tế", fmt.Sprintf("%g / %g", doc.WorkingDays, doc.ActualWorkingDays)},
+		{"Ngày nghỉ phép có lương", fmt.Sprintf("%g", doc.LeaveDays)},
+		{"Giờ tăng ca", fmt.Sprintf("%g", doc.OvertimeHours)},
+	}
+	for _, row := range header {
+		out.Text(left, y, pdf.Regular, 10, row[0])
+		out.Text(left+170, y, pdf.Bold, 10, row[1])
+		y += 16
+	}
+
+	section := func(title string, lines []PayslipLine, totalLabel string, total float64) {
+		y += 14
+		out.Text(left, y, pdf.Bold, 11, title)
+		out.TextRight(right, y, pdf.Bold, 11, fmt.Sprintf("Số tiền (%s)", doc.Currency))
+		y += 6
+		out.Line(left, y, right, y, 0.8)
+		y += 15
+		for _, line := range lines {
+			out.Text(left, y, pdf.Regular, 10, line.Name)
+			out.TextRight(right, y, pdf.Regular, 10, FormatMoneyIn(line.Amount, doc.Currency))
+			y += 15
+		}
+		out.Line(left, y-9, right, y-9, 0.4)
+		y += 4
+		out.Text(left, y, pdf.Bold, 10, totalLabel)
+		out.TextRight(right, y, pdf.Bold, 10, FormatMoneyIn(total, doc.Currency))
+		y += 10
+	}
+	section("THU NHẬP", doc.Earnings, "Tổng thu nhập", doc.GrossEarnings)
+	section("KHẤU TRỪ", doc.Deductions, "Tổng khấu trừ", doc.TotalDeductions)
+
+	y += 20
+	out.Line(left, y-14, right, y-14, 1.2)
+	out.Text(left, y, pdf.Bold, 13, "THỰC LĨNH")
+	out.TextRight(right, y, pdf.Bold, 13, FormatAmount(doc.NetSalary, doc.Currency))
+
+	return out.Bytes()
+}
@@ -0,0 +1,109 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"hr-management-system/internal/domain/entity"
+
+	"github.com/google/uuid"
+)
+
+// Family deductions used when the personal_deduction and dependent_deduction
+// settings are missing (Resolution 954/2020/UBTVQH14)
+const (
+	defaultPersonalDeduction  = 11000000
+	defaultDependentDeduction = 4400000
+)
+
+// ErrNoTaxBrackets is returned when no tax bracket is configured for a year
+// or any year before it
+var ErrNoTaxBrackets = errors.New("no tax brackets configured")
+
+// TaxBrackets returns the brackets in effect for a year, ordered by
+// min_income. A year without its own rows uses the latest earlier year.
+func (s *Service) TaxBrackets(ctx context.Context, year int) ([]entity.TaxBracket, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, min_income, max_income, tax_rate, COALESCE(deduction, 0), year, created_at
+		FROM tax_brackets
+		WHERE year = (SELECT MAX(year) FROM tax_brackets WHERE year <= $1)
+		ORDER BY min_income
+	`, year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var brackets []entity.TaxBracket
+	for rows.Next() {
+		var b entity.TaxBracket
+		if err := rows.Scan(&b.ID, &b.MinIncome, &b.MaxIncome, &b.TaxRate, &b.Deduction, &b.Year, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		brackets = append(brackets, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(brackets) == 0 {
+		return nil, fmt.Errorf("%w for %d", ErrNoTaxBrackets, year)
+	}
+	return brackets, nil
+}
+
+// CalculatePIT returns the monthly personal income tax on a taxable income
+// (after insurance and family deductions) using the brackets of the year
+func (s *Service) CalculatePIT(ctx context.Context, taxableIncome float64, year int) (float64, error) {
+	if taxableIncome <= 0 {
+		return 0, nil
+	}
+	brackets, err := s.TaxBrackets(ctx, year)
+	if err != nil {
+		return 0, err
+	}
+	return ProgressiveTax(taxableIncome, brackets), nil
+}
+
+// ProgressiveTax applies each bracket's rate to the part of the income that
+// falls inside it. Brackets must be ordered by min_income. The result equals
+// the income*rate - deduction shortcut of the bracket the income ends in.
+func ProgressiveTax(taxableIncome float64, brackets []entity.TaxBracket) float64 {
+	tax := 0.0
+	for _, b := range brackets {
+		if taxableIncome <= b.MinIncome {
+			break
+		}
+		tax += (math.Min(taxableIncome, b.MaxIncome) - b.MinIncome) * b.TaxRate / 100
+	}
+	return tax
+}
+
+// FamilyDeduction returns the monthly deduction for the taxpayer and their
+// dependents, from the personal_deduction and dependent_deduction settings
+func (s *Service) FamilyDeduction(ctx context.Context, dependents int) float64 {
+	personal := s.settingFloat(ctx, "personal_deduction", defaultPersonalDeduction)
+	perDependent := s.settingFloat(ctx, "dependent_deduction", defaultDependentDeduction)
+	return personal + float64(dependents)*perDependent
+}
+
+// Dependents counts the employee's tax-deductible dependents whose eligibility
+// window overlaps the period. A dependent counts for the whole month in
+// which they start or stop being one.
+func (s *Service) Dependents(ctx context.Context, employeeID uuid.UUID, start, end time.Time) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM employee_dependents
+		WHERE employee_id = $1 AND tax_deductible AND deleted_at IS NULL
+		  AND `+EffectiveDuringSQL("effective_from", "effective_to", "$2", "$3")+`
+	`, employeeID, start, end).Scan(&count)
+	return count, err
+}
+
+// TaxableIncome is the taxable earnings less compulsory insurance and family
+// deductions, never below zero
+func TaxableIncome(taxableEarnings, insurance, familyDeduction float64) float64 {
+	return math.Max(taxableEarnings-insurance-familyDeduction, 0)
+}
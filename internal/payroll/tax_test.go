@@ -0,0 +1,73 @@
+package payroll
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"hr-management-system/internal/domain/entity"
+)
+
+// The brackets of migrations/002_seed_data.sql
+var testBrackets = []entity.TaxBracket{
+	{MinIncome: 0, MaxIncome: 5000000, TaxRate: 5, Deduction: 0},
+	{MinIncome: 5000000, MaxIncome: 10000000, TaxRate: 10, Deduction: 250000},
+	{MinIncome: 10000000, MaxIncome: 18000000, TaxRate: 15, Deduction: 750000},
+	{MinIncome: 18000000, MaxIncome: 32000000, TaxRate: 20, Deduction: 1650000},
+	{MinIncome: 32000000, MaxIncome: 52000000, TaxRate: 25, Deduction: 3250000},
+	{MinIncome: 52000000, MaxIncome: 80000000, TaxRate: 30, Deduction: 5850000},
+	{MinIncome: 80000000, MaxIncome: 999999999999, TaxRate: 35, Deduction: 9850000},
+}
+
+func TestProgressiveTax(t *testing.T) {
+	tests := []struct {
+		name    string
+		income  float64
+		wantTax float64
+	}{
+		{"negative", -1000000, 0},
+		{"zero", 0, 0},
+		{"inside the first bracket", 3000000, 150000},
+		{"top of the 5% bracket", 5000000, 250000},
+		{"just above 5M", 5000001, 250000.1},
+		{"top of the 10% bracket", 10000000, 750000},
+		{"just above 10M", 10000001, 750000.15},
+		{"top of the 15% bracket", 18000000, 1950000},
+		{"top of the 20% bracket", 32000000, 4750000},
+		{"top of the 25% bracket", 52000000, 9750000},
+		{"top of the 30% bracket", 80000000, 18150000},
+		{"just above 80M", 80000001, 18150000.35},
+		{"inside the 35% bracket", 100000000, 25150000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ProgressiveTax(tt.income, testBrackets); math.Abs(got-tt.wantTax) > 1e-6 {
+				t.Errorf("ProgressiveTax(%.0f) = %f, want %f", tt.income, got, tt.wantTax)
+			}
+		})
+	}
+}
+
+// Bracket by bracket the sum matches the rate*income - deduction shortcut
+// published with the brackets
+func TestProgressiveTaxMatchesDeductionShortcut(t *testing.T) {
+	for _, b := range testBrackets {
+		for _, income := range []float64{b.MinIncome + 1, (b.MinIncome + math.Min(b.MaxIncome, 200000000)) / 2, b.MaxIncome} {
+			want := income*b.TaxRate/100 - b.Deduction
+			if got := ProgressiveTax(income, testBrackets); math.Abs(got-want) > 1e-3 {
+				t.Errorf("ProgressiveTax(%.0f) = %f, the %g%% shortcut gives %f", income, got, b.TaxRate, want)
+			}
+		}
+	}
+}
+
+// No income owes no tax, without the brackets being looked up
+func TestCalculatePITWithoutIncome(t *testing.T) {
+	s := NewService(nil)
+	for _, income := range []float64{0, -500000} {
+		tax, err := s.CalculatePIT(context.Background(), income, 2025)
+		if err != nil || tax != 0 {
+			t.Errorf("CalculatePIT(%.0f) = %f, %v; want 0", income, tax, err)
+		}
+	}
+}
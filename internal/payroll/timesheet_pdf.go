@@ -0,0 +1,353 @@
+package payroll
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"hr-management-system/internal/pdf"
+
+	"github.com/google/uuid"
+)
+
+// TimesheetDay is one row of a monthly timesheet. Status is the attendance
+// status, or one derived from the calendar and approved leave when there is
+// no attendance: on_leave, weekend, holiday, absent for past working days and
+// empty for days still to come.
+type TimesheetDay struct {
+	Date          time.Time
+	Shift         string
+	CheckIn       *time.Time
+	CheckOut      *time.Time
+	WorkingHours  float64
+	OvertimeHours float64
+	Status        string
+	Leave         string
+}
+
+// TimesheetDocument is the data printed on a monthly timesheet
+type TimesheetDocument struct {
+	EmployeeCode   string
+	EmployeeName   string
+	DepartmentName string
+	PositionName   string
+	Year           int
+	Month          int
+	Days           []TimesheetDay
+	WorkingDays    float64
+	PresentDays    float64
+	LeaveDays      float64
+	AbsentDays     float64
+	LateDays       int
+	WorkingHours   float64
+	OvertimeHours  float64
+}
+
+// LoadTimesheet collects an employee's attendance, shifts, approved leave and
+// approved overtime of a month. Working days without an assigned shift show
+// defaultShift. It returns sql.ErrNoRows when the employee does not exist.
+func (s *Service) LoadTimesheet(ctx context.Context, employeeID uuid.UUID, year, month int, defaultShift string) (*TimesheetDocument, error) {
+	doc := TimesheetDocument{Year: year, Month: month}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT e.employee_code, e.full_name, COALESCE(d.name, ''), COALESCE(p.name, '')
+		FROM employees e
+		LEFT JOIN departments d ON d.id = e.department_id
+		LEFT JOIN positions p ON p.id = e.position_id
+		WHERE e.id = $1 AND e.deleted_at IS NULL
+	`, employeeID).Scan(&doc.EmployeeCode, &doc.EmployeeName, &doc.DepartmentName, &doc.PositionName)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, -1)
+	from, to := start.Format("2006-01-02"), end.Format("2006-01-02")
+
+	calendar, err := s.WorkingCalendar(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	doc.WorkingDays = calendar.WorkingDays
+	holidays := make(map[string]bool, len(calendar.Holidays))
+	for _, d := range calendar.Holidays {
+		holidays[d.Format("2006-01-02")] = true
+	}
+
+	days := make(map[string]*TimesheetDay)
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		doc.Days = append(doc.Days, TimesheetDay{Date: d})
+	}
+	for i := range doc.Days {
+		days[doc.Days[i].Date.Format("2006-01-02")] = &doc.Days[i]
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT es.date, ws.name, ws.start_time::text, ws.end_time::text
+		FROM employee_shifts es
+		INNER JOIN work_shifts ws ON ws.id = es.shift_id
+		WHERE es.employee_id = $1 AND es.date BETWEEN $2 AND $3
+	`, employeeID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var date time.Time
+		var name, startTime, endTime string
+		if err := rows.Scan(&date, &name, &startTime, &endTime); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if day, ok := days[date.Format("2006-01-02")]; ok {
+			day.Shift = fmt.Sprintf("%s %s-%s", name, clockHHMM(startTime), clockHHMM(endTime))
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT date, check_in, check_out, COALESCE(working_hours, 0), status
+		FROM attendances
+		WHERE employee_id = $1 AND date BETWEEN $2 AND $3 AND deleted_at IS NULL
+	`, employeeID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var date time.Time
+		var checkIn, checkOut sql.NullTime
+		var hours float64
+		var status string
+		if err := rows.Scan(&date, &checkIn, &checkOut, &hours, &status); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		day, ok := days[date.Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		if checkIn.Valid {
+			day.CheckIn = &checkIn.Time
+		}
+		if checkOut.Valid {
+			day.CheckOut = &checkOut.Time
+		}
+		day.WorkingHours = hours
+		day.Status = status
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT d::date, lt.name
+		FROM leave_requests lr
+		INNER JOIN leave_types lt ON lt.id = lr.leave_type_id
+		CROSS JOIN LATERAL generate_series(GREATEST(lr.start_date, $2::date), LEAST(lr.end_date, $3::date), INTERVAL '1 day') d
+		WHERE lr.employee_id = $1 AND lr.status = 'approved' AND lr.deleted_at IS NULL
+		  AND lr.start_date <= $3::date AND lr.end_date >= $2::date
+	`, employeeID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var date time.Time
+		var leaveType string
+		if err := rows.Scan(&date, &leaveType); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if day, ok := days[date.Format("2006-01-02")]; ok {
+			day.Leave = leaveType
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT date, SUM(hours)
+		FROM overtime_requests
+		WHERE employee_id = $1 AND date BETWEEN $2 AND $3 AND status IN ('approved', 'completed') AND deleted_at IS NULL
+		GROUP BY date
+	`, employeeID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var date time.Time
+		var hours float64
+		if err := rows.Scan(&date, &hours); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if day, ok := days[date.Format("2006-01-02")]; ok {
+			day.OvertimeHours = hours
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	today := time.Now().Format("2006-01-02")
+	for i := range doc.Days {
+		day := &doc.Days[i]
+		key := day.Date.Format("2006-01-02")
+		weekend := calendar.IsRestDay(day.Date)
+		if day.Shift == "" && !weekend && !holidays[key] {
+			day.Shift = defaultShift
+		}
+		if day.Status == "" {
+			switch {
+			case weekend:
+				day.Status = "weekend"
+			case holidays[key]:
+				day.Status = "holiday"
+			case day.Leave != "":
+				day.Status = "on_leave"
+			case key < today:
+				day.Status = "absent"
+			}
+		}
+
+		switch day.Status {
+		case "present", "early_leave":
+			doc.PresentDays++
+		case "late":
+			doc.PresentDays++
+			doc.LateDays++
+		case "half_day":
+			doc.PresentDays += 0.5
+			doc.LeaveDays += 0.5
+		case "on_leave":
+			doc.LeaveDays++
+		case "absent":
+			doc.AbsentDays++
+		}
+		doc.WorkingHours += day.WorkingHours
+		doc.OvertimeHours += day.OvertimeHours
+	}
+	return &doc, nil
+}
+
+var timesheetStatusLabels = map[string]string{
+	"present":     "Có mặt",
+	"late":        "Đi muộn",
+	"early_leave": "Về sớm",
+	"half_day":    "Nửa ngày",
+	"on_leave":    "Nghỉ phép",
+	"holiday":     "Nghỉ lễ",
+	"weekend":     "Cuối tuần",
+	"absent":      "Vắng",
+}
+
+var timesheetWeekdays = [...]string{"CN", "T2", "T3", "T4", "T5", "T6", "T7"}
+
+// RenderTimesheetPDF lays out a one-page monthly timesheet: employee header,
+// a row per day, the monthly totals and signature lines for the employee,
+// their manager and HR
+func RenderTimesheetPDF(doc *TimesheetDocument) []byte {
+	const left, right = 40.0, pdf.PageWidth - 40
+	const size, lineHeight = 8.0, 14.0
+	out := pdf.New()
+	out.AddPage()
+
+	y := 50.0
+	out.TextCenter(pdf.PageWidth/2, y, pdf.Bold, 16, "BẢNG CHẤM CÔNG")
+	y += 18
+	out.TextCenter(pdf.PageWidth/2, y, pdf.Regular, 11, fmt.Sprintf("Tháng %02d/%d", doc.Month, doc.Year))
+
+	y += 28
+	out.Text(left, y, pdf.Regular, 10, "Mã nhân viên")
+	out.Text(left+90, y, pdf.Bold, 10, doc.EmployeeCode)
+	out.Text(left+270, y, pdf.Regular, 10, "Phòng ban")
+	out.Text(left+340, y, pdf.Bold, 10, doc.DepartmentName)
+	y += 15
+	out.Text(left, y, pdf.Regular, 10, "Họ và tên")
+	out.Text(left+90, y, pdf.Bold, 10, doc.EmployeeName)
+	out.Text(left+270, y, pdf.Regular, 10, "Vị trí")
+	out.Text(left+340, y, pdf.Bold, 10, doc.PositionName)
+
+	// Column x positions; numbers are right-aligned at the end of theirs
+	colDate, colShift, colIn, colOut := left, left+50, left+175, left+215
+	colHours, colOvertime, colStatus, colNote := left+285, left+330, left+340, left+405
+
+	y += 25
+	out.Text(colDate, y, pdf.Bold, size, "Ngày")
+	out.Text(colShift, y, pdf.Bold, size, "Ca làm việc")
+	out.Text(colIn, y, pdf.Bold, size, "Vào")
+	out.Text(colOut, y, pdf.Bold, size, "Ra")
+	out.TextRight(colHours, y, pdf.Bold, size, "Giờ công")
+	out.TextRight(colOvertime, y, pdf.Bold, size, "Tăng ca")
+	out.Text(colStatus, y, pdf.Bold, size, "Trạng thái")
+	out.Text(colNote, y, pdf.Bold, size, "Ghi chú")
+	y += 5
+	out.Line(left, y, right, y, 0.8)
+	y += lineHeight - 2
+
+	for _, day := range doc.Days {
+		out.Text(colDate, y, pdf.Regular, size, fmt.Sprintf("%s %s", timesheetWeekdays[day.Date.Weekday()], day.Date.Format("02/01")))
+		out.Text(colShift, y, pdf.Regular, size, day.Shift)
+		if day.CheckIn != nil {
+			out.Text(colIn, y, pdf.Regular, size, day.CheckIn.Format("15:04"))
+		}
+		if day.CheckOut != nil {
+			out.Text(colOut, y, pdf.Regular, size, day.CheckOut.Format("15:04"))
+		}
+		if day.WorkingHours > 0 {
+			out.TextRight(colHours, y, pdf.Regular, size, fmt.Sprintf("%.2f", day.WorkingHours))
+		}
+		if day.OvertimeHours > 0 {
+			out.TextRight(colOvertime, y, pdf.Regular, size, fmt.Sprintf("%.2f", day.OvertimeHours))
+		}
+		out.Text(colStatus, y, pdf.Regular, size, timesheetStatusLabels[day.Status])
+		out.Text(colNote, y, pdf.Regular, size, day.Leave)
+		y += lineHeight
+	}
+	out.Line(left, y-9, right, y-9, 0.4)
+
+	y += 10
+	totals := [][2]string{
+		{"Ngày công chuẩn", fmt.Sprintf("%g", doc.WorkingDays)},
+		{"Ngày công thực tế", fmt.Sprintf("%g", doc.PresentDays)},
+		{"Ngày nghỉ phép", fmt.Sprintf("%g", doc.LeaveDays)},
+		{"Ngày vắng", fmt.Sprintf("%g", doc.AbsentDays)},
+		{"Số ngày đi muộn", fmt.Sprintf("%d", doc.LateDays)},
+		{"Tổng giờ công", fmt.Sprintf("%.2f", doc.WorkingHours)},
+		{"Tổng giờ tăng ca", fmt.Sprintf("%.2f", doc.OvertimeHours)},
+	}
+	// Two columns of totals
+	for i, row := range totals {
+		x, rowY := left, y+float64(i/2)*14
+		if i%2 == 1 {
+			x = left + 270
+		}
+		out.Text(x, rowY, pdf.Regular, 10, row[0])
+		out.TextRight(x+200, rowY, pdf.Bold, 10, row[1])
+	}
+	y += float64((len(totals)+1)/2)*14 + 25
+
+	signers := []string{"Người lao động", "Quản lý trực tiếp", "Phòng nhân sự"}
+	width := (right - left) / float64(len(signers))
+	for i, signer := range signers {
+		center := left + width*(float64(i)+0.5)
+		out.TextCenter(center, y, pdf.Bold, 10, signer)
+		out.TextCenter(center, y+13, pdf.Regular, 8, "(Ký, ghi rõ họ tên)")
+	}
+	out.TextCenter(left+width/2, y+70, pdf.Regular, 10, doc.EmployeeName)
+
+	return out.Bytes()
+}
+
+// clockHHMM shortens a TIME value such as 08:00:00 to 08:00
+func clockHHMM(value string) string {
+	if len(value) > 5 {
+		return value[:5]
+	}
+	return value
+}
@@ -0,0 +1,150 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+
+	"hr-management-system/internal/pdf"
+	"hr-management-system/internal/spreadsheet"
+)
+
+// csvFormatter writes a header line and the rows. The byte order mark makes
+// Excel open the file as UTF-8.
+type csvFormatter struct{}
+
+func (csvFormatter) Name() string        { return "csv" }
+func (csvFormatter) ContentType() string { return "text/csv; charset=utf-8" }
+func (csvFormatter) Extension() string   { return ".csv" }
+
+func (f csvFormatter) Format(w io.Writer, t *Table) error {
+	return writeTable(f, w, t)
+}
+
+func (csvFormatter) Stream(w io.Writer, title string, columns []string) (RowWriter, error) {
+	if _, err := io.WriteString(w, "\xef\xbb\xbf"); err != nil {
+		return nil, err
+	}
+	out := &csvRowWriter{csv.NewWriter(w)}
+	if err := out.WriteRow(columns); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+type csvRowWriter struct {
+	*csv.Writer
+}
+
+func (c *csvRowWriter) WriteRow(row []string) error {
+	return c.Write(row)
+}
+
+func (c *csvRowWriter) Close() error {
+	c.Flush()
+	return c.Error()
+}
+
+// excelFormatter writes an XLSX workbook with a single sheet named after the
+// report
+type excelFormatter struct{}
+
+func (excelFormatter) Name() string { return "excel" }
+func (excelFormatter) ContentType() string {
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+func (excelFormatter) Extension() string { return ".xlsx" }
+
+func (f excelFormatter) Format(w io.Writer, t *Table) error {
+	return writeTable(f, w, t)
+}
+
+func (excelFormatter) Stream(w io.Writer, title string, columns []string) (RowWriter, error) {
+	out, err := spreadsheet.NewXLSXWriter(w, title)
+	if err != nil {
+		return nil, err
+	}
+	if err := out.WriteRow(columns); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// pdfFormatter lays the table out on A4 pages with equal column widths,
+// repeating the header on every page. Cells too wide for their column are
+// cut short.
+type pdfFormatter struct{}
+
+func (pdfFormatter) Name() string        { return "pdf" }
+func (pdfFormatter) ContentType() string { return "application/pdf" }
+func (pdfFormatter) Extension() string   { return ".pdf" }
+
+func (pdfFormatter) Format(w io.Writer, t *Table) error {
+	const left, right, top, bottom = 40.0, pdf.PageWidth - 40, 50.0, pdf.PageHeight - 40
+	const size, lineHeight = 8.0, 13.0
+
+	columns := len(t.Columns)
+	if columns == 0 {
+		columns = 1
+	}
+	width := (right - left) / float64(columns)
+
+	doc := pdf.New()
+	var y float64
+	header := func() {
+		doc.AddPage()
+		y = top
+		if doc.PageCount() == 1 && t.Title != "" {
+			doc.TextCenter(pdf.PageWidth/2, y, pdf.Bold, 14, t.Title)
+			y += 25
+		}
+		for i, column := range t.Columns {
+			doc.Text(left+float64(i)*width, y, pdf.Bold, size, fit(column, width-4, size))
+		}
+		y += 5
+		doc.Line(left, y, right, y, 0.6)
+		y += lineHeight
+	}
+
+	header()
+	for _, row := range t.Rows {
+		if y > bottom {
+			header()
+		}
+		for i, cell := range row {
+			if i >= columns {
+				break
+			}
+			doc.Text(left+float64(i)*width, y, pdf.Regular, size, fit(cell, width-4, size))
+		}
+		y += lineHeight
+	}
+
+	_, err := w.Write(doc.Bytes())
+	return err
+}
+
+// writeTable renders a whole table through a streaming formatter
+func writeTable(f Streamer, w io.Writer, t *Table) error {
+	out, err := f.Stream(w, t.Title, t.Columns)
+	if err != nil {
+		return err
+	}
+	for _, row := range t.Rows {
+		if err := out.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return out.Close()
+}
+
+// fit shortens text with an ellipsis until it is at most width wide
+func fit(text string, width, size float64) string {
+	if pdf.TextWidth(text, size) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.TextWidth(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
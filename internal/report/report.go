@@ -0,0 +1,115 @@
+// Package report renders tabular reports into the export formats users can
+// request. Each format is a Formatter in a registry; request validation and
+// the report worker both take the allowed formats from it, so a format is
+// added by registering it.
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Table is a report ready to be rendered: a title, column headers and rows
+// of cell values in column order
+type Table struct {
+	Title   string
+	Columns []string
+	Rows    [][]string
+}
+
+// Formatter renders a Table in one export format
+type Formatter interface {
+	// Name is the value clients send as the report format
+	Name() string
+	// ContentType is the MIME type of the rendered file
+	ContentType() string
+	// Extension is the file extension including the dot
+	Extension() string
+	Format(w io.Writer, t *Table) error
+}
+
+// RowWriter receives the rows of a streamed report
+type RowWriter interface {
+	WriteRow(row []string) error
+	// Close completes the file; it does not close the underlying writer
+	Close() error
+}
+
+// Streamer is implemented by formatters that can write a report row by row,
+// so exports of any size use bounded memory
+type Streamer interface {
+	Formatter
+	Stream(w io.Writer, title string, columns []string) (RowWriter, error)
+}
+
+// Report types the worker generates
+const (
+	TypeDailyAttendance  = "daily_attendance"
+	TypeWeeklyAttendance = "weekly_attendance_summary"
+	TypePayroll          = "payroll"
+	TypeLeave            = "leave"
+)
+
+// Types returns the report types that can be generated
+func Types() []string {
+	return []string{TypeDailyAttendance, TypeWeeklyAttendance, TypePayroll, TypeLeave}
+}
+
+// IsType reports whether name is a report type that can be generated
+func IsType(name string) bool {
+	for _, t := range Types() {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	mu         sync.RWMutex
+	formatters = map[string]Formatter{}
+)
+
+// Register makes a format available. It panics when the name is empty or
+// already taken, as that is a programming error.
+func Register(f Formatter) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	name := f.Name()
+	if name == "" {
+		panic("report: formatter without a name")
+	}
+	if _, exists := formatters[name]; exists {
+		panic(fmt.Sprintf("report: formatter %q registered twice", name))
+	}
+	formatters[name] = f
+}
+
+// Lookup returns the formatter registered under name
+func Lookup(name string) (Formatter, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	f, ok := formatters[name]
+	return f, ok
+}
+
+// Formats returns the registered format names, sorted
+func Formats() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register(csvFormatter{})
+	Register(excelFormatter{})
+	Register(pdfFormatter{})
+}
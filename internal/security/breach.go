@@ -0,0 +1,84 @@
+package security
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"hr-management-system/internal/infrastructure/cache"
+)
+
+// ==================== BREACHED PASSWORDS ====================
+
+// BreachChecker looks passwords up in a k-anonymity range API such as Have I
+// Been Pwned. Only the first five hex characters of the SHA-1 hash leave the
+// process; the suffix is matched locally against the returned range.
+type BreachChecker struct {
+	cache  *cache.RedisCache
+	client *http.Client
+}
+
+func NewBreachChecker(c *cache.RedisCache) *BreachChecker {
+	return &BreachChecker{
+		cache:  c,
+		client: &http.Client{Timeout: cfg.BreachCheckTimeout},
+	}
+}
+
+// IsBreached reports whether the password appears in the breach list. It is
+// always false when the check is disabled. Callers should let the password
+// through on error. Clean results are cached for BreachCheckCacheTTL under a
+// SHA-256 of the SHA-1, so the cache never holds a range lookup key.
+func (b *BreachChecker) IsBreached(ctx context.Context, password string) (bool, error) {
+	if !cfg.BreachCheckEnabled {
+		return false, nil
+	}
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	cacheSum := sha256.Sum256([]byte(hash))
+	cacheKey := "password_breach:" + hex.EncodeToString(cacheSum[:])
+	if clean, _ := b.cache.Exists(ctx, cacheKey); clean {
+		return false, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(cfg.BreachCheckURL, "/")+"/"+prefix, nil)
+	if err != nil {
+		return false, err
+	}
+	// Padding hides the real size of the range from observers
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("breach range API returned %s", resp.Status)
+	}
+
+	// Each line is "SUFFIX:COUNT"; padding entries have a count of 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		candidate, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if ok && strings.EqualFold(candidate, suffix) && count != "0" {
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+
+	if cfg.BreachCheckCacheTTL > 0 {
+		b.cache.Set(ctx, cacheKey, true, cfg.BreachCheckCacheTTL)
+	}
+	return false, nil
+}
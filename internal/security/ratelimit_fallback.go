@@ -0,0 +1,195 @@
+package security
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/infrastructure/logger"
+)
+
+// Rate limit route classes, named in RATE_LIMIT_FAIL_CLOSED
+const (
+	RateLimitClassGlobal   = "global"
+	RateLimitClassAuth     = "auth"
+	RateLimitClassEndpoint = "endpoint"
+)
+
+var rateLimitCfg *config.RateLimitConfig
+
+// InitRateLimit sets how limiting behaves while Redis is unreachable
+func InitRateLimit(c *config.RateLimitConfig) {
+	rateLimitCfg = c
+}
+
+func fallbackEnabled() bool {
+	return rateLimitCfg != nil && rateLimitCfg.FallbackEnabled
+}
+
+// RateLimitFailsClosed reports whether requests of the route class are
+// refused, rather than let through, when their limit cannot be checked
+func RateLimitFailsClosed(class string) bool {
+	if rateLimitCfg == nil {
+		return false
+	}
+	for _, c := range rateLimitCfg.FailClosed {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// ==================== DEGRADATION ====================
+
+var (
+	rateLimitDegraded      atomic.Bool
+	rateLimitDegradedSince atomic.Int64
+)
+
+// RateLimitDegraded reports whether rate limiting and login lockout have lost
+// Redis, and since when. Limits are then per instance, or not applied at all
+// without the fallback.
+func RateLimitDegraded() (bool, time.Time) {
+	if !rateLimitDegraded.Load() {
+		return false, time.Time{}
+	}
+	return true, time.Unix(0, rateLimitDegradedSince.Load())
+}
+
+// markDegraded records a Redis failure, logging only the first of an outage
+func markDegraded(err error) {
+	if rateLimitDegraded.CompareAndSwap(false, true) {
+		rateLimitDegradedSince.Store(time.Now().UnixNano())
+		if log := logger.GetLogger(); log != nil {
+			log.WithError(err).WithField("fallback", fallbackEnabled()).
+				Error("Rate limit storage unavailable, protection degraded")
+		}
+	}
+}
+
+func markRecovered() {
+	if rateLimitDegraded.CompareAndSwap(true, false) {
+		if log := logger.GetLogger(); log != nil {
+			log.WithField("degraded_for", time.Since(time.Unix(0, rateLimitDegradedSince.Load())).String()).
+				Warn("Rate limit storage recovered")
+		}
+	}
+}
+
+// ==================== IN-PROCESS FALLBACK ====================
+
+// localLimiter keeps token buckets and attempt counters in memory. Each
+// instance only sees its own traffic, so limits loosen by the number of
+// instances, which is acceptable for the length of a Redis outage.
+type localLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	counters  map[string]*localCounter
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens   float64
+	capacity float64
+	rate     float64 // tokens per second
+	updated  time.Time
+}
+
+type localCounter struct {
+	count   int
+	resetAt time.Time
+}
+
+var fallbackLimiter = &localLimiter{
+	buckets:  make(map[string]*tokenBucket),
+	counters: make(map[string]*localCounter),
+}
+
+// allow takes a token from the bucket of key, which holds limit tokens and
+// refills at limit per window
+func (l *localLimiter) allow(key string, limit int64, window time.Duration) (bool, int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(limit), capacity: float64(limit), rate: float64(limit) / window.Seconds(), updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = b.refilled(now)
+	b.updated = now
+
+	if b.tokens < 1 {
+		return false, 0
+	}
+	b.tokens--
+	return true, int64(b.tokens)
+}
+
+func (b *tokenBucket) refilled(now time.Time) float64 {
+	tokens := b.tokens + now.Sub(b.updated).Seconds()*b.rate
+	if tokens > b.capacity {
+		return b.capacity
+	}
+	return tokens
+}
+
+// add counts an event against key, returning the count and the time left
+// until the count resets, window after the first event
+func (l *localLimiter) add(key string, window time.Duration) (int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	c, ok := l.counters[key]
+	if !ok || now.After(c.resetAt) {
+		c = &localCounter{resetAt: now.Add(window)}
+		l.counters[key] = c
+	}
+	c.count++
+	return c.count, c.resetAt.Sub(now)
+}
+
+// count returns the events counted against key by add and the time left
+func (l *localLimiter) count(key string) (int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c, ok := l.counters[key]
+	if !ok || time.Now().After(c.resetAt) {
+		return 0, 0
+	}
+	return c.count, time.Until(c.resetAt)
+}
+
+func (l *localLimiter) reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.counters, key)
+}
+
+// sweep drops refilled buckets and expired counters once a minute, so the
+// keys of clients that went away do not pile up
+func (l *localLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.refilled(now) >= b.capacity {
+			delete(l.buckets, key)
+		}
+	}
+	for key, c := range l.counters {
+		if now.After(c.resetAt) {
+			delete(l.counters, key)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package security_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hr-management-system/internal/config"
+	"hr-management-system/internal/security"
+	"hr-management-system/internal/testutil"
+)
+
+var testJWT = &config.JWTConfig{
+	AccessSecret:       "test-access-secret",
+	RefreshSecret:      "test-refresh-secret",
+	AccessTokenExpiry:  15 * time.Minute,
+	RefreshTokenExpiry: time.Hour,
+	Issuer:             "hrms-test",
+	Audience:           "hrms-test",
+}
+
+// Replaying a refresh token after it was rotated revokes every session of
+// its family, the one the replay came from and the one it was rotated into
+func TestRefreshTokenReplayRevokesFamily(t *testing.T) {
+	redisCache := testutil.Cache(t)
+	tracker := security.NewRefreshTokenTracker(redisCache)
+	blacklist := security.NewSessionBlacklist(redisCache)
+	ctx := context.Background()
+
+	login, err := security.GenerateTokenPair("user", "user@hrms.test", nil, nil, testJWT)
+	testutil.Must(t, err, "log in")
+	testutil.Must(t, tracker.Issue(ctx, login, testJWT.RefreshTokenExpiry), "issue login tokens")
+	stolen, err := security.ValidateRefreshToken(login.RefreshToken, testJWT)
+	testutil.Must(t, err, "validate refresh token")
+
+	// The rightful refresh
+	testutil.Must(t, tracker.Rotate(ctx, stolen, testJWT.RefreshTokenExpiry), "rotate")
+	refreshed, err := security.GenerateTokenPairInFamily(stolen.Family(), "user", "user@hrms.test", nil, nil, testJWT)
+	testutil.Must(t, err, "refresh")
+	testutil.Must(t, tracker.Issue(ctx, refreshed, testJWT.RefreshTokenExpiry), "issue refreshed tokens")
+	for _, sessionID := range []string{login.SessionID, refreshed.SessionID} {
+		if revoked, _ := blacklist.IsBlacklisted(ctx, sessionID); revoked {
+			t.Fatalf("session %s revoked by a single rotation", sessionID)
+		}
+	}
+
+	// The replay
+	if err := tracker.Rotate(ctx, stolen, testJWT.RefreshTokenExpiry); !errors.Is(err, security.ErrRefreshTokenReused) {
+		t.Fatalf("replayed rotation returned %v, want ErrRefreshTokenReused", err)
+	}
+	for _, sessionID := range []string{login.SessionID, refreshed.SessionID} {
+		revoked, err := blacklist.IsBlacklisted(ctx, sessionID)
+		testutil.Must(t, err, "read blacklist")
+		if !revoked {
+			t.Errorf("session %s of the family is still live after the replay", sessionID)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package security
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// Querier runs a single-row query, as *sql.DB and *sql.Tx do
+type Querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// TeamScope is the part of the organisation a user without
+// employees.view_all may see: the department subtrees below their own
+// department and below the departments they manage, and their direct reports
+// wherever those sit.
+type TeamScope struct {
+	DepartmentIDs []string
+	// EmployeeID is the user's employee record, "" when they have none
+	EmployeeID string
+}
+
+// ResolveTeamScope returns the team scope of a user. Subtrees follow the
+// materialized departments.path; a department without a path only covers
+// itself.
+func ResolveTeamScope(ctx context.Context, db Querier, userID string) (*TeamScope, error) {
+	scope := &TeamScope{}
+	err := db.QueryRowContext(ctx, `
+		WITH me AS (
+			SELECT id, department_id FROM employees WHERE user_id = $1 AND deleted_at IS NULL LIMIT 1
+		), roots AS (
+			SELECT d.id, COALESCE(d.path, '') AS path FROM departments d
+			WHERE d.deleted_at IS NULL
+			  AND (d.id IN (SELECT department_id FROM me) OR d.manager_id IN (SELECT id FROM me))
+		)
+		SELECT COALESCE((SELECT id::text FROM me), ''), ARRAY(
+			SELECT DISTINCT d.id::text FROM departments d
+			INNER JOIN roots r ON d.id = r.id OR (r.path <> '' AND d.path LIKE r.path || '/%')
+			WHERE d.deleted_at IS NULL
+		)
+	`, userID).Scan(&scope.EmployeeID, pq.Array(&scope.DepartmentIDs))
+	if err != nil {
+		return nil, err
+	}
+	return scope, nil
+}
+
+// Allows reports whether an employee of the department with the given
+// manager is in scope
+func (s *TeamScope) Allows(departmentID, managerID string) bool {
+	if s.EmployeeID != "" && managerID == s.EmployeeID {
+		return true
+	}
+	for _, id := range s.DepartmentIDs {
+		if id == departmentID {
+			return true
+		}
+	}
+	return false
+}
@@ -102,19 +102,45 @@ type TokenClaims struct {
 	Roles       []string `json:"roles"`
 	Permissions []string `json:"permissions,omitempty"`
 	SessionID   string   `json:"session_id"`
-	TokenType   string   `json:"token_type"`
+	// FamilyID is the session of the login a refresh token descends from
+	FamilyID  string `json:"family_id,omitempty"`
+	TokenType string `json:"token_type"`
 	jwt.RegisteredClaims
 }
 
+// Family returns the session family of the token. Tokens issued before
+// families were tracked form a family of their own session.
+func (c *TokenClaims) Family() string {
+	if c.FamilyID != "" {
+		return c.FamilyID
+	}
+	return c.SessionID
+}
+
 type TokenPair struct {
 	AccessToken  string    `json:"access_token"`
 	RefreshToken string    `json:"refresh_token"`
 	ExpiresAt    time.Time `json:"expires_at"`
 	TokenType    string    `json:"token_type"`
+	SessionID    string    `json:"-"`
+	FamilyID     string    `json:"-"`
+	// RefreshTokenID is the ID claim of the refresh token
+	RefreshTokenID string `json:"-"`
 }
 
+// GenerateTokenPair issues the tokens of a new login, which starts a session
+// family
 func GenerateTokenPair(userID, email string, roles, permissions []string, jwtCfg *config.JWTConfig) (*TokenPair, error) {
+	return GenerateTokenPairInFamily("", userID, email, roles, permissions, jwtCfg)
+}
+
+// GenerateTokenPairInFamily issues the tokens of a new session in an existing
+// family, as when refreshing. An empty familyID starts a new family.
+func GenerateTokenPairInFamily(familyID, userID, email string, roles, permissions []string, jwtCfg *config.JWTConfig) (*TokenPair, error) {
 	sessionID := uuid.New().String()
+	if familyID == "" {
+		familyID = sessionID
+	}
 	now := time.Now()
 
 	// Access Token
@@ -146,6 +172,7 @@ func GenerateTokenPair(userID, email string, roles, permissions []string, jwtCfg
 	refreshClaims := TokenClaims{
 		UserID:    userID,
 		SessionID: sessionID,
+		FamilyID:  familyID,
 		TokenType: "refresh",
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    jwtCfg.Issuer,
@@ -165,10 +192,13 @@ func GenerateTokenPair(userID, email string, roles, permissions []string, jwtCfg
 	}
 
 	return &TokenPair{
-		AccessToken:  accessTokenString,
-		RefreshToken: refreshTokenString,
-		ExpiresAt:    now.Add(jwtCfg.AccessTokenExpiry),
-		TokenType:    "Bearer",
+		AccessToken:    accessTokenString,
+		RefreshToken:   refreshTokenString,
+		ExpiresAt:      now.Add(jwtCfg.AccessTokenExpiry),
+		TokenType:      "Bearer",
+		SessionID:      sessionID,
+		FamilyID:       familyID,
+		RefreshTokenID: refreshClaims.ID,
 	}, nil
 }
 
@@ -229,6 +259,13 @@ func VerifyOTP(otp, hash string) bool {
 	return HashOTP(otp) == hash
 }
 
+// HashToken returns the SHA-256 of a token for storage, so a leaked table
+// does not hand out working tokens
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
 // ==================== TOKEN GENERATION ====================
 
 func GenerateSecureToken(length int) (string, error) {
@@ -265,13 +302,29 @@ type RateLimitResult struct {
 	Allowed   bool
 	Remaining int64
 	ResetAt   time.Time
+	// Degraded is set when the in-process fallback decided, Redis being down
+	Degraded bool
 }
 
+// Check counts a request against key. When Redis fails the in-process
+// fallback decides if enabled; otherwise the error is returned and the
+// caller applies the fail-open or fail-closed policy of the route.
 func (r *RateLimiter) Check(ctx context.Context, key string, limit int64, window time.Duration) (*RateLimitResult, error) {
 	allowed, remaining, err := r.cache.RateLimit(ctx, r.keyPrefix+key, limit, window)
 	if err != nil {
-		return nil, err
+		markDegraded(err)
+		if !fallbackEnabled() {
+			return nil, err
+		}
+		allowed, remaining = fallbackLimiter.allow(r.keyPrefix+key, limit, window)
+		return &RateLimitResult{
+			Allowed:   allowed,
+			Remaining: remaining,
+			ResetAt:   time.Now().Add(window),
+			Degraded:  true,
+		}, nil
 	}
+	markRecovered()
 
 	return &RateLimitResult{
 		Allowed:   allowed,
@@ -307,7 +360,12 @@ func (m *LoginAttemptManager) RecordFailedAttempt(ctx context.Context, identifie
 	key := "login_attempts:" + identifier
 	count, err := m.cache.Incr(ctx, key)
 	if err != nil {
-		return 0, err
+		markDegraded(err)
+		if !fallbackEnabled() {
+			return 0, err
+		}
+		count, _ := fallbackLimiter.add(key, cfg.LockoutDuration)
+		return count, nil
 	}
 
 	if count == 1 {
@@ -324,7 +382,15 @@ func (m *LoginAttemptManager) GetAttempts(ctx context.Context, identifier string
 	if err == cache.ErrCacheMiss {
 		return 0, nil
 	}
-	return count, err
+	if err != nil {
+		markDegraded(err)
+		if !fallbackEnabled() {
+			return 0, err
+		}
+		count, _ = fallbackLimiter.count(key)
+		return count, nil
+	}
+	return count, nil
 }
 
 func (m *LoginAttemptManager) IsLocked(ctx context.Context, identifier string) (bool, time.Duration, error) {
@@ -333,6 +399,11 @@ func (m *LoginAttemptManager) IsLocked(ctx context.Context, identifier string) (
 		return false, 0, err
 	}
 
+	// Counted in process while Redis is down
+	if local, ttl := fallbackLimiter.count("login_attempts:" + identifier); local >= cfg.MaxLoginAttempts {
+		return true, ttl, nil
+	}
+
 	if attempts >= cfg.MaxLoginAttempts {
 		ttl, err := m.cache.TTL(ctx, "login_attempts:"+identifier)
 		if err != nil {
@@ -345,6 +416,7 @@ func (m *LoginAttemptManager) IsLocked(ctx context.Context, identifier string) (
 }
 
 func (m *LoginAttemptManager) ClearAttempts(ctx context.Context, identifier string) error {
+	fallbackLimiter.reset("login_attempts:" + identifier)
 	return m.cache.Delete(ctx, "login_attempts:"+identifier)
 }
 
@@ -371,6 +443,74 @@ func (s *SessionBlacklist) Remove(ctx context.Context, sessionID string) error {
 	return s.cache.Delete(ctx, "blacklist:"+sessionID)
 }
 
+// ==================== REFRESH TOKEN ROTATION ====================
+
+// ErrRefreshTokenReused means a refresh token was presented after it had
+// already been exchanged
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+const refreshTokenRotated = "rotated"
+
+// RefreshTokenTracker lets each refresh token be exchanged once. It records
+// the refresh token issued to every session and the sessions of every
+// family. A rotated token coming back means it was copied, and as it is
+// unknown whether the thief or the user holds the newest token, the whole
+// family is revoked.
+type RefreshTokenTracker struct {
+	cache     *cache.RedisCache
+	blacklist *SessionBlacklist
+}
+
+func NewRefreshTokenTracker(c *cache.RedisCache) *RefreshTokenTracker {
+	return &RefreshTokenTracker{cache: c, blacklist: NewSessionBlacklist(c)}
+}
+
+// Issue records the refresh token of a new session
+func (t *RefreshTokenTracker) Issue(ctx context.Context, tokens *TokenPair, ttl time.Duration) error {
+	if _, err := t.cache.Swap(ctx, "refresh_token:"+tokens.SessionID, tokens.RefreshTokenID, ttl); err != nil && err != cache.ErrCacheMiss {
+		return err
+	}
+	if err := t.cache.SAdd(ctx, "refresh_family:"+tokens.FamilyID, tokens.SessionID); err != nil {
+		return err
+	}
+	return t.cache.Expire(ctx, "refresh_family:"+tokens.FamilyID, ttl)
+}
+
+// Rotate marks the refresh token as exchanged. When it was exchanged before,
+// the family is revoked and ErrRefreshTokenReused returned. Tokens issued
+// before tracking began are accepted once.
+func (t *RefreshTokenTracker) Rotate(ctx context.Context, claims *TokenClaims, ttl time.Duration) error {
+	previous, err := t.cache.Swap(ctx, "refresh_token:"+claims.SessionID, refreshTokenRotated, ttl)
+	if err == cache.ErrCacheMiss {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if previous == claims.ID {
+		return nil
+	}
+	if err := t.RevokeFamily(ctx, claims.Family(), ttl); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// RevokeFamily blacklists every session of the family
+func (t *RefreshTokenTracker) RevokeFamily(ctx context.Context, familyID string, ttl time.Duration) error {
+	sessions, err := t.cache.SMembers(ctx, "refresh_family:"+familyID)
+	if err != nil {
+		return err
+	}
+	sessions = append(sessions, familyID)
+	for _, sessionID := range sessions {
+		if err := t.blacklist.Add(ctx, sessionID, ttl); err != nil {
+			return err
+		}
+	}
+	return t.cache.Delete(ctx, "refresh_family:"+familyID)
+}
+
 // ==================== IP VALIDATION ====================
 
 func IsPrivateIP(ip string) bool {
@@ -0,0 +1,106 @@
+package security
+
+import (
+	"testing"
+	"time"
+)
+
+// The SHA-1 seed of RFC 6238 appendix B, "12345678901234567890", base32 encoded
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// RFC 6238 appendix B lists 8-digit codes; the 6 digits used here are their
+// last 6, as the truncation is the same value modulo 10^6
+func TestTOTPCodeRFC6238(t *testing.T) {
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, v := range vectors {
+		code, err := TOTPCode(rfc6238Secret, time.Unix(v.unix, 0))
+		if err != nil {
+			t.Fatalf("TOTPCode(%d): %v", v.unix, err)
+		}
+		if code != v.code {
+			t.Errorf("TOTPCode(%d) = %s, want %s", v.unix, code, v.code)
+		}
+		if !ValidateTOTP(rfc6238Secret, v.code, time.Unix(v.unix, 0), 0) {
+			t.Errorf("ValidateTOTP rejected the RFC code of %d", v.unix)
+		}
+	}
+}
+
+func TestValidateTOTPWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	codeAt := func(steps int) string {
+		code, err := TOTPCode(rfc6238Secret, now.Add(time.Duration(steps)*TOTPPeriod))
+		if err != nil {
+			t.Fatalf("TOTPCode: %v", err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name  string
+		steps int
+		skew  int
+		want  bool
+	}{
+		{"current step without skew", 0, 0, true},
+		{"previous step without skew", -1, 0, false},
+		{"next step without skew", 1, 0, false},
+		{"one step behind within skew 1", -1, 1, true},
+		{"one step ahead within skew 1", 1, 1, true},
+		{"two steps behind outside skew 1", -2, 1, false},
+		{"two steps ahead outside skew 1", 2, 1, false},
+		{"two steps behind within skew 2", -2, 2, true},
+		{"two steps ahead within skew 2", 2, 2, true},
+		{"three steps behind outside skew 2", -3, 2, false},
+		{"three steps ahead outside skew 2", 3, 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidateTOTP(rfc6238Secret, codeAt(tt.steps), now, tt.skew); got != tt.want {
+				t.Errorf("ValidateTOTP(code %+d steps, skew %d) = %v, want %v", tt.steps, tt.skew, got, tt.want)
+			}
+		})
+	}
+}
+
+// The window counts whole time steps, not seconds: a code stays valid to the
+// last second of the step skew steps away and not a second longer
+func TestValidateTOTPStepEdges(t *testing.T) {
+	stepStart := time.Unix(1111111110, 0) // 1111111110 is a multiple of 30
+	code, _ := TOTPCode(rfc6238Secret, stepStart)
+
+	if !ValidateTOTP(rfc6238Secret, code, stepStart.Add(2*TOTPPeriod-time.Second), 1) {
+		t.Error("code rejected on the last second of the next step")
+	}
+	if ValidateTOTP(rfc6238Secret, code, stepStart.Add(2*TOTPPeriod), 1) {
+		t.Error("code accepted on the first second two steps later")
+	}
+	if !ValidateTOTP(rfc6238Secret, code, stepStart.Add(-TOTPPeriod), 1) {
+		t.Error("code rejected on the first second of the previous step")
+	}
+	if ValidateTOTP(rfc6238Secret, code, stepStart.Add(-TOTPPeriod-time.Second), 1) {
+		t.Error("code accepted on the last second two steps earlier")
+	}
+}
+
+func TestValidateTOTPMalformed(t *testing.T) {
+	now := time.Unix(59, 0)
+	for _, code := range []string{"", "28708", "2870820", "94287082"} {
+		if ValidateTOTP(rfc6238Secret, code, now, 1) {
+			t.Errorf("ValidateTOTP accepted %q", code)
+		}
+	}
+	if ValidateTOTP("not base32!", "287082", now, 1) {
+		t.Error("ValidateTOTP accepted a code for an invalid secret")
+	}
+}
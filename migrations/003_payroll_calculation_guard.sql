@@ -0,0 +1,4 @@
+-- HR Management System
+-- Track payroll calculation runs so retried jobs stay idempotent
+
+ALTER TABLE payroll_periods ADD COLUMN IF NOT EXISTS calculated_at TIMESTAMP;
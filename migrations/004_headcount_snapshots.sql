@@ -0,0 +1,15 @@
+-- HR Management System
+-- Monthly per-department headcount snapshots for trend reporting
+
+CREATE TABLE IF NOT EXISTS headcount_snapshots (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    department_id UUID NOT NULL REFERENCES departments(id),
+    snapshot_date DATE NOT NULL,
+    headcount INT NOT NULL DEFAULT 0,
+    joiners INT NOT NULL DEFAULT 0,
+    leavers INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(department_id, snapshot_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_headcount_snapshots_date ON headcount_snapshots(snapshot_date);
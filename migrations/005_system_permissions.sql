@@ -0,0 +1,11 @@
+-- HR Management System
+-- System administration permissions
+
+INSERT INTO permissions (id, name, slug, module, description) VALUES
+('660e8400-e29b-41d4-a716-446655440111', 'Manage System', 'system.manage', 'system', 'Quản trị hệ thống')
+ON CONFLICT (slug) DO NOTHING;
+
+-- Super Admin
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT '550e8400-e29b-41d4-a716-446655440001', id FROM permissions WHERE slug = 'system.manage'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,24 @@
+-- HR Management System
+-- Leave encashment: payout of unused leave at year end or termination
+
+ALTER TABLE leave_types ADD COLUMN IF NOT EXISTS encashable BOOLEAN DEFAULT FALSE;
+
+UPDATE leave_types SET encashable = TRUE WHERE code = 'ANNUAL';
+
+CREATE TABLE IF NOT EXISTS leave_encashments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    employee_id UUID NOT NULL REFERENCES employees(id),
+    leave_type_id UUID NOT NULL REFERENCES leave_types(id),
+    year INT NOT NULL,
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('year_end', 'termination')),
+    days DECIMAL(4,1) NOT NULL,
+    daily_rate DECIMAL(15,2) NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    payroll_period_id UUID REFERENCES payroll_periods(id),
+    status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'paid', 'cancelled')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(employee_id, leave_type_id, year, reason)
+);
+
+CREATE INDEX IF NOT EXISTS idx_leave_encashments_pending ON leave_encashments(employee_id) WHERE status = 'pending';
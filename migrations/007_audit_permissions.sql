@@ -0,0 +1,11 @@
+-- HR Management System
+-- Audit log permissions
+
+INSERT INTO permissions (id, name, slug, module, description) VALUES
+('660e8400-e29b-41d4-a716-446655440112', 'View Audit Logs', 'audit.view', 'audit', 'Xem nhật ký hệ thống')
+ON CONFLICT (slug) DO NOTHING;
+
+-- Super Admin
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT '550e8400-e29b-41d4-a716-446655440001', id FROM permissions WHERE slug = 'audit.view'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,10 @@
+-- HR Management System
+-- Probation review outcome, used to gate automatic conversion to full time
+
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS probation_status VARCHAR(20)
+    CHECK (probation_status IN ('flagged', 'confirmed', 'denied'));
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS probation_reviewed_by UUID REFERENCES users(id);
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS probation_reviewed_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_employees_probation_end ON employees(probation_end_date)
+    WHERE employment_type = 'probation' AND deleted_at IS NULL;
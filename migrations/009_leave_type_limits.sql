@@ -0,0 +1,5 @@
+-- HR Management System
+-- Leave type limits: minimum advance notice and maximum consecutive days (0 = no limit)
+
+ALTER TABLE leave_types ADD COLUMN IF NOT EXISTS min_notice_days INT NOT NULL DEFAULT 0;
+ALTER TABLE leave_types ADD COLUMN IF NOT EXISTS max_consecutive_days INT NOT NULL DEFAULT 0;
@@ -0,0 +1,23 @@
+-- HR Management System
+-- Overtime segments: per-date, per-rate split of a request (e.g. 22:00-02:00 spans two dates)
+
+CREATE TABLE IF NOT EXISTS overtime_request_segments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    overtime_request_id UUID NOT NULL REFERENCES overtime_requests(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    hours DECIMAL(4,2) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('weekday', 'weekend', 'holiday', 'night')),
+    multiplier DECIMAL(3,2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_overtime_segments_request ON overtime_request_segments(overtime_request_id);
+CREATE INDEX IF NOT EXISTS idx_overtime_segments_date ON overtime_request_segments(date);
+
+-- Existing requests become a single segment on their own date
+INSERT INTO overtime_request_segments (overtime_request_id, date, start_time, end_time, hours, type, multiplier)
+SELECT o.id, o.date, o.start_time, o.end_time, o.hours, o.type, COALESCE(o.multiplier, 1.5)
+FROM overtime_requests o
+WHERE NOT EXISTS (SELECT 1 FROM overtime_request_segments s WHERE s.overtime_request_id = o.id);
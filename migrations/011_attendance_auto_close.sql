@@ -0,0 +1,8 @@
+-- HR Management System
+-- Forgotten check-outs: records closed by the nightly job or left for the employee to regularize
+
+ALTER TABLE attendances ADD COLUMN IF NOT EXISTS auto_closed BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE attendances ADD COLUMN IF NOT EXISTS needs_regularization BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_attendances_open ON attendances(date)
+    WHERE check_in IS NOT NULL AND check_out IS NULL AND deleted_at IS NULL;
@@ -0,0 +1,17 @@
+-- HR Management System
+-- Export permissions: list endpoints allow a larger page size to holders
+
+INSERT INTO permissions (id, name, slug, module, description) VALUES
+('660e8400-e29b-41d4-a716-446655440113', 'Export Employees', 'employees.export', 'employees', 'Xuất danh sách nhân viên'),
+('660e8400-e29b-41d4-a716-446655440114', 'Export Attendance', 'attendance.export', 'attendance', 'Xuất dữ liệu chấm công')
+ON CONFLICT (slug) DO NOTHING;
+
+-- Super Admin
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT '550e8400-e29b-41d4-a716-446655440001', id FROM permissions WHERE slug IN ('employees.export', 'attendance.export')
+ON CONFLICT DO NOTHING;
+
+-- HR Manager
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT '550e8400-e29b-41d4-a716-446655440002', id FROM permissions WHERE slug IN ('employees.export', 'attendance.export')
+ON CONFLICT DO NOTHING;
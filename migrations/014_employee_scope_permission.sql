@@ -0,0 +1,14 @@
+-- HR Management System
+-- Employee data scope: without employees.view_all, employee lists and search
+-- are limited to the caller's own department and the departments they manage
+
+INSERT INTO permissions (id, name, slug, module, description) VALUES
+('660e8400-e29b-41d4-a716-446655440115', 'View All Employees', 'employees.view_all', 'employees', 'Xem nhân viên của tất cả phòng ban')
+ON CONFLICT (slug) DO NOTHING;
+
+-- Super Admin, HR Manager and Payroll Manager
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.id IN ('550e8400-e29b-41d4-a716-446655440001', '550e8400-e29b-41d4-a716-446655440002', '550e8400-e29b-41d4-a716-446655440003')
+  AND p.slug = 'employees.view_all'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,23 @@
+-- HR Management System
+-- Multi-currency salaries: amounts are stored in the employee's salary
+-- currency and converted with exchange_rates for reporting
+
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS salary_currency CHAR(3) NOT NULL DEFAULT 'VND';
+ALTER TABLE payslips ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'VND';
+ALTER TABLE leave_encashments ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'VND';
+
+-- rate is the amount of quote_currency for one unit of currency, valid from
+-- effective_date until the next rate of the pair
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    currency CHAR(3) NOT NULL,
+    quote_currency CHAR(3) NOT NULL,
+    rate DECIMAL(20,8) NOT NULL CHECK (rate > 0),
+    effective_date DATE NOT NULL,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(currency, quote_currency, effective_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_exchange_rates_pair ON exchange_rates(currency, quote_currency, effective_date DESC);
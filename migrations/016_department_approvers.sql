@@ -0,0 +1,16 @@
+-- HR Management System
+-- Per-department approval routing for leave and overtime requests. A
+-- department without a row routes to the employee's manager.
+
+CREATE TABLE IF NOT EXISTS department_approvers (
+    department_id UUID NOT NULL REFERENCES departments(id),
+    request_type VARCHAR(20) NOT NULL CHECK (request_type IN ('leave', 'overtime')),
+    primary_approver_id UUID REFERENCES employees(id),
+    backup_approver_id UUID REFERENCES employees(id),
+    approver_role_id UUID REFERENCES roles(id),
+    updated_by UUID REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (department_id, request_type),
+    CHECK (primary_approver_id IS NOT NULL OR approver_role_id IS NOT NULL)
+);
@@ -0,0 +1,18 @@
+-- HR Management System
+-- Employee documents (contracts, certificates, ...) kept in file storage.
+-- employees.avatar now holds a storage key as well.
+
+CREATE TABLE IF NOT EXISTS employee_documents (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    employee_id UUID NOT NULL REFERENCES employees(id),
+    name VARCHAR(255) NOT NULL,
+    category VARCHAR(50),
+    storage_key TEXT NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    uploaded_by UUID REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_employee_documents_employee ON employee_documents(employee_id) WHERE deleted_at IS NULL;
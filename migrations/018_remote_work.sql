@@ -0,0 +1,28 @@
+-- HR Management System
+-- Work from home: an employee's default work mode, remote days declared by
+-- office and hybrid employees, and the mode each attendance was recorded in
+
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS work_mode VARCHAR(10) NOT NULL DEFAULT 'office'
+    CHECK (work_mode IN ('office', 'remote', 'hybrid'));
+
+ALTER TABLE attendances ADD COLUMN IF NOT EXISTS work_mode VARCHAR(10) NOT NULL DEFAULT 'office'
+    CHECK (work_mode IN ('office', 'remote'));
+
+CREATE TABLE IF NOT EXISTS remote_work_requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    employee_id UUID NOT NULL REFERENCES employees(id),
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    total_days INT NOT NULL,
+    reason TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')),
+    approved_by UUID REFERENCES employees(id),
+    approved_at TIMESTAMP,
+    rejection_reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_remote_work_employee ON remote_work_requests(employee_id, start_date, end_date)
+    WHERE status IN ('pending', 'approved');
@@ -0,0 +1,40 @@
+-- HR Management System
+-- Notification broadcasts: one message fanned out to many users by the
+-- worker. last_user_id checkpoints the fan-out so a retry resumes after the
+-- last committed batch; the unique index keeps a replayed batch from
+-- notifying anyone twice.
+
+CREATE TABLE IF NOT EXISTS notification_broadcasts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    title VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    data JSONB,
+    department_ids UUID[],
+    role_ids UUID[],
+    status VARCHAR(20) NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'completed', 'failed')),
+    total INT NOT NULL DEFAULT 0,
+    processed INT NOT NULL DEFAULT 0,
+    last_user_id UUID,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS broadcast_id UUID REFERENCES notification_broadcasts(id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_broadcast_user ON notifications(broadcast_id, user_id)
+    WHERE broadcast_id IS NOT NULL;
+
+INSERT INTO permissions (id, name, slug, module, description) VALUES
+('660e8400-e29b-41d4-a716-446655440116', 'Broadcast Notifications', 'notifications.broadcast', 'notifications', 'Gửi thông báo hàng loạt')
+ON CONFLICT (slug) DO NOTHING;
+
+-- Super Admin and HR Manager
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.id IN ('550e8400-e29b-41d4-a716-446655440001', '550e8400-e29b-41d4-a716-446655440002')
+  AND p.slug = 'notifications.broadcast'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,32 @@
+-- HR Management System
+-- Payroll review: the calculation flags anomalous payslips, and a reviewer
+-- acknowledges each flag or sends the payslip back for recalculation before
+-- the period can be approved. Recalculating a payslip replaces its flags.
+
+CREATE TABLE IF NOT EXISTS payslip_flags (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    payslip_id UUID NOT NULL REFERENCES payslips(id) ON DELETE CASCADE,
+    payroll_period_id UUID NOT NULL REFERENCES payroll_periods(id),
+    rule VARCHAR(50) NOT NULL,
+    reason TEXT NOT NULL,
+    details JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'acknowledged', 'sent_back')),
+    reviewed_by UUID REFERENCES users(id),
+    reviewed_at TIMESTAMP,
+    review_notes TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (payslip_id, rule)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payslip_flags_period ON payslip_flags(payroll_period_id, status);
+
+INSERT INTO permissions (id, name, slug, module, description) VALUES
+('660e8400-e29b-41d4-a716-446655440117', 'Review Payroll', 'payroll.review', 'payroll', 'Rà soát bảng lương trước khi duyệt')
+ON CONFLICT (slug) DO NOTHING;
+
+-- Super Admin and Payroll Manager
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.id IN ('550e8400-e29b-41d4-a716-446655440001', '550e8400-e29b-41d4-a716-446655440003')
+  AND p.slug = 'payroll.review'
+ON CONFLICT DO NOTHING;
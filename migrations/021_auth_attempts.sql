@@ -0,0 +1,20 @@
+-- HR Management System
+-- Authentication attempts, successful or not, for login history and incident
+-- investigation. user_id is resolved from the email when the account exists;
+-- successful logins link the session they opened.
+
+CREATE TABLE IF NOT EXISTS auth_attempts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    location VARCHAR(100),
+    success BOOLEAN NOT NULL,
+    reason VARCHAR(100),
+    session_id UUID REFERENCES user_sessions(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_attempts_user ON auth_attempts(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_auth_attempts_ip ON auth_attempts(ip_address, created_at DESC);
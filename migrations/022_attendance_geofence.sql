@@ -0,0 +1,10 @@
+-- HR Management System
+-- Optional check-in geofence: office days must check in within the radius
+-- of the office coordinates. Disabled until the coordinates are set.
+
+INSERT INTO system_settings (id, key, value, type, "group", label) VALUES
+('110e8400-e29b-41d4-a716-446655440011', 'geofence_enabled', 'false', 'boolean', 'attendance', 'Giới hạn vị trí chấm công'),
+('110e8400-e29b-41d4-a716-446655440012', 'office_latitude', '10.7295', 'number', 'attendance', 'Vĩ độ văn phòng'),
+('110e8400-e29b-41d4-a716-446655440013', 'office_longitude', '106.7218', 'number', 'attendance', 'Kinh độ văn phòng'),
+('110e8400-e29b-41d4-a716-446655440014', 'geofence_radius_meters', '200', 'number', 'attendance', 'Bán kính chấm công (mét)')
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,9 @@
+-- HR Management System
+-- First-login onboarding. The steps are worked out from the account and the
+-- employee profile; this only records when a user first had them all done.
+-- Users who have signed in before are considered onboarded.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS onboarding_completed_at TIMESTAMP;
+
+UPDATE users SET onboarding_completed_at = CURRENT_TIMESTAMP
+WHERE onboarding_completed_at IS NULL AND last_login_at IS NOT NULL;
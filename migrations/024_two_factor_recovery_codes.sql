@@ -0,0 +1,14 @@
+-- HR Management System
+-- Authenticator app (TOTP) two-factor authentication. users.two_factor_secret
+-- holds the encrypted TOTP secret; recovery codes are issued at setup and
+-- each can be used once instead of a code.
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(255) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user ON user_recovery_codes(user_id) WHERE used_at IS NULL;
@@ -0,0 +1,9 @@
+-- HR Management System
+-- Tie user_sessions rows to the JWT session they were issued for. A row stands
+-- for one login: refreshing moves it on to the newest session of the family.
+
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS session_id VARCHAR(64);
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS family_id VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_session ON user_sessions(session_id);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_family ON user_sessions(family_id) WHERE is_revoked = FALSE;
@@ -0,0 +1,25 @@
+-- HR Management System
+-- Dependents registered for the personal income tax family deduction. A
+-- dependent reduces the tax of every month their effective window overlaps,
+-- and only while tax_deductible is set.
+
+CREATE TABLE IF NOT EXISTS employee_dependents (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    full_name VARCHAR(255) NOT NULL,
+    relationship VARCHAR(20) NOT NULL CHECK (relationship IN ('child', 'spouse', 'parent', 'sibling', 'other')),
+    date_of_birth DATE,
+    id_number VARCHAR(50),
+    tax_code VARCHAR(20),
+    tax_deductible BOOLEAN NOT NULL DEFAULT TRUE,
+    effective_from DATE NOT NULL,
+    effective_to DATE,
+    notes TEXT,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP,
+    CHECK (effective_to IS NULL OR effective_to >= effective_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_employee_dependents_employee ON employee_dependents(employee_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,8 @@
+-- HR Management System
+-- Sessions are identified by session_id. The truncated token prefixes kept
+-- until now matched nothing, so they are replaced by a hash of the current
+-- refresh token, which refreshing checks.
+
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS refresh_token_hash VARCHAR(64);
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS token;
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS refresh_token;
@@ -0,0 +1,14 @@
+-- HR Management System
+-- Employee codes are numbered from a sequence per prefix instead of scanning
+-- for the highest code, so concurrent creates cannot collide. This sets up
+-- the sequence of the default NV prefix after the existing codes; sequences
+-- of other prefixes are created the same way on first use.
+
+CREATE SEQUENCE IF NOT EXISTS employee_code_seq_nv;
+
+SELECT setval('employee_code_seq_nv', m)
+FROM (
+    SELECT MAX(substring(employee_code FROM 3)::bigint) AS m
+    FROM employees WHERE employee_code ~ '^NV[0-9]+$'
+) codes
+WHERE m IS NOT NULL;
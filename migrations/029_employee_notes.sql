@@ -0,0 +1,27 @@
+-- HR Management System
+-- Manager notes on employees (one-on-ones, performance). Visibility widens
+-- the audience: private is the author only, managers adds the employee's
+-- manager chain, hr adds holders of employees.notes_hr. The subject employee
+-- never reads notes about themselves.
+
+CREATE TABLE IF NOT EXISTS employee_notes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    author_id UUID NOT NULL REFERENCES users(id),
+    visibility VARCHAR(20) NOT NULL CHECK (visibility IN ('private', 'managers', 'hr')),
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_employee_notes_employee ON employee_notes(employee_id, created_at DESC);
+
+INSERT INTO permissions (id, name, slug, module, description) VALUES
+('660e8400-e29b-41d4-a716-446655440118', 'Read HR Notes', 'employees.notes_hr', 'employees', 'Đọc ghi chú nhân viên chia sẻ với nhân sự')
+ON CONFLICT (slug) DO NOTHING;
+
+-- Super Admin and HR Manager
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.id IN ('550e8400-e29b-41d4-a716-446655440001', '550e8400-e29b-41d4-a716-446655440002')
+  AND p.slug = 'employees.notes_hr'
+ON CONFLICT DO NOTHING;
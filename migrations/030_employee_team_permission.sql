@@ -0,0 +1,16 @@
+-- HR Management System
+-- Team view: employees.view.team opens the employee list and profiles to
+-- managers without the full employees.view. Like every caller without
+-- employees.view_all, they see the department subtrees under their own
+-- department and the ones they manage (by departments.path), plus their
+-- direct reports.
+
+INSERT INTO permissions (id, name, slug, module, description) VALUES
+('660e8400-e29b-41d4-a716-446655440119', 'View Team Employees', 'employees.view.team', 'employees', 'Xem nhân viên trong nhóm và phòng ban quản lý')
+ON CONFLICT (slug) DO NOTHING;
+
+-- Department Manager
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.slug = 'department_manager' AND p.slug = 'employees.view.team'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,28 @@
+-- HR Management System
+-- Effective dating for payroll. Base salaries get a history, each entry
+-- applying until the next takes effect, and deductions get a validity window
+-- so rate changes keep past periods as they were. Payroll selects both, like
+-- employee allowances and dependents, by the period it calculates.
+
+CREATE TABLE IF NOT EXISTS employee_salary_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    base_salary DECIMAL(15,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    effective_from DATE NOT NULL,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (employee_id, effective_from)
+);
+
+-- Current salaries are taken to have applied since joining
+INSERT INTO employee_salary_history (employee_id, base_salary, currency, effective_from)
+SELECT id, base_salary, salary_currency, join_date FROM employees
+ON CONFLICT (employee_id, effective_from) DO NOTHING;
+
+ALTER TABLE deductions ADD COLUMN IF NOT EXISTS effective_from DATE NOT NULL DEFAULT DATE '1970-01-01';
+ALTER TABLE deductions ADD COLUMN IF NOT EXISTS effective_to DATE;
+
+-- A deduction code can now have one row per rate change
+ALTER TABLE deductions DROP CONSTRAINT IF EXISTS deductions_code_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_deductions_code_effective ON deductions(code, effective_from) WHERE deleted_at IS NULL;
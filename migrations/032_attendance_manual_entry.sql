@@ -0,0 +1,16 @@
+-- HR Management System
+-- Manual attendance: HR enters or corrects a day's check-in and check-out for
+-- an employee who forgot to clock, with a reason. attendance_logs records
+-- who made the change; device check-ins leave it empty.
+
+ALTER TABLE attendance_logs ADD COLUMN IF NOT EXISTS performed_by UUID REFERENCES users(id);
+
+INSERT INTO permissions (id, name, slug, module, description) VALUES
+('660e8400-e29b-41d4-a716-446655440120', 'Create Attendance', 'attendance.create', 'attendance', 'Nhập chấm công thủ công cho nhân viên')
+ON CONFLICT (slug) DO NOTHING;
+
+-- Super Admin and HR Manager
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.slug IN ('super_admin', 'hr_manager') AND p.slug = 'attendance.create'
+ON CONFLICT DO NOTHING;
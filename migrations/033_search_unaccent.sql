@@ -0,0 +1,17 @@
+-- HR Management System
+-- Accent-insensitive employee search in Postgres, used while Elasticsearch is
+-- unavailable: "nguyen van an" finds "Nguyễn Văn An". unaccent() is only
+-- STABLE, so f_unaccent pins its dictionary to be usable in indexes, and
+-- trigram indexes keep the ILIKE '%...%' matches off sequential scans.
+
+CREATE EXTENSION IF NOT EXISTS unaccent;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE OR REPLACE FUNCTION f_unaccent(text) RETURNS text
+LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT AS
+$$ SELECT public.unaccent('public.unaccent'::regdictionary, $1) $$;
+
+CREATE INDEX IF NOT EXISTS idx_employees_full_name_unaccent
+    ON employees USING gin (f_unaccent(full_name) gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_employees_code_trgm
+    ON employees USING gin (employee_code gin_trgm_ops) WHERE deleted_at IS NULL;
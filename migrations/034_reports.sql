@@ -0,0 +1,29 @@
+-- HR Management System
+-- Generated reports. The API records a report when it is requested and the
+-- worker moves it through processing to completed or failed, storing the
+-- file under file_key. Scheduled reports are recorded by the worker, keyed by
+-- task_id so a retried task reuses its row.
+
+CREATE TABLE IF NOT EXISTS reports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    report_type VARCHAR(50) NOT NULL,
+    format VARCHAR(20) NOT NULL,
+    filters JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'completed', 'failed')),
+    task_id VARCHAR(100) UNIQUE,
+    file_key TEXT,
+    file_name VARCHAR(255),
+    content_type VARCHAR(100),
+    file_size BIGINT NOT NULL DEFAULT 0,
+    row_count INT NOT NULL DEFAULT 0,
+    email VARCHAR(255),
+    emailed_at TIMESTAMP,
+    error TEXT,
+    requested_by UUID REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reports_requested_by ON reports(requested_by, created_at DESC);
@@ -0,0 +1,11 @@
+-- HR Management System
+-- Configurable working calendar: holidays can take only half a day off, and
+-- the working_weekdays setting lists the days of the work week as ISO
+-- weekdays, 1 (Monday) to 7 (Sunday). Payroll, leave and timesheets count
+-- working days on it.
+
+ALTER TABLE holidays ADD COLUMN IF NOT EXISTS is_half_day BOOLEAN NOT NULL DEFAULT FALSE;
+
+INSERT INTO system_settings (id, key, value, type, "group", label) VALUES
+('110e8400-e29b-41d4-a716-446655440015', 'working_weekdays', '1,2,3,4,5', 'string', 'attendance', 'Ngày làm việc trong tuần')
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,13 @@
+-- HR Management System
+-- Holiday management: holidays.manage allows adding, changing and removing
+-- public holidays, which every user can list.
+
+INSERT INTO permissions (id, name, slug, module, description) VALUES
+('660e8400-e29b-41d4-a716-446655440121', 'Manage Holidays', 'holidays.manage', 'attendance', 'Quản lý ngày nghỉ lễ')
+ON CONFLICT (slug) DO NOTHING;
+
+-- Super Admin and HR Manager
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.slug IN ('super_admin', 'hr_manager') AND p.slug = 'holidays.manage'
+ON CONFLICT DO NOTHING;
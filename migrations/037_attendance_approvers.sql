@@ -0,0 +1,7 @@
+-- HR Management System
+-- Attendance approvals are routed per department like leave and overtime, so
+-- a department can name HR, or a role, to approve its attendance.
+
+ALTER TABLE department_approvers DROP CONSTRAINT IF EXISTS department_approvers_request_type_check;
+ALTER TABLE department_approvers ADD CONSTRAINT department_approvers_request_type_check
+    CHECK (request_type IN ('leave', 'overtime', 'attendance'));